@@ -1,6 +1,9 @@
 package gopdfrab_test
 
 import (
+	"context"
+	"errors"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -58,3 +61,51 @@ func TestGeneratedCorpusTimeBounded(t *testing.T) {
 		}
 	}
 }
+
+// TestDocumentsIsolateContextAndLimits runs passes over several Documents of
+// one file at once, each under its own context or limits. A pass installs
+// those on its Document for as long as it runs, so they must neither leak
+// into another Document's pass nor race with it. Run with `go test -race`.
+func TestDocumentsIsolateContextAndLimits(t *testing.T) {
+	data, err := os.ReadFile("tests/veraPDF/PDF_A-1b/6.6 Actions/6.6.1 General/veraPDF test suite 6-6-1-t02-pass-a.pdf")
+	if err != nil {
+		t.Skip("veraPDF corpus not present")
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	limited := gopdfrab.PDFA_1B.Clone()
+	limited.Limits = gopdfrab.Limits{Duration: time.Nanosecond}
+
+	var wg sync.WaitGroup
+	for i := range 24 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doc, err := gopdfrab.OpenBytesWithPassword(data, "")
+			if err != nil {
+				t.Errorf("OpenBytesWithPassword: %v", err)
+				return
+			}
+			defer doc.Close()
+			var limitErr *gopdfrab.LimitError
+			switch i % 3 {
+			case 0:
+				if _, err := doc.VerifyContext(cancelled, gopdfrab.PDFA_1B); !errors.Is(err, context.Canceled) {
+					t.Errorf("VerifyContext(cancelled): err = %v, want context.Canceled", err)
+				}
+			case 1:
+				if _, err := doc.Convert(limited); !errors.As(err, &limitErr) || limitErr.Limit != "Duration" {
+					t.Errorf("Convert under a 1ns Duration: err = %v, want a Duration *LimitError", err)
+				}
+			case 2:
+				if _, err := doc.ConvertContext(context.Background(), gopdfrab.PDFA_1B); err != nil {
+					t.Errorf("ConvertContext: %v", err)
+				}
+				if _, err := doc.Verify(gopdfrab.PDFA_1B); err != nil {
+					t.Errorf("Verify after ConvertContext: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -128,3 +128,47 @@ func TestConvertObjectModelAPI(t *testing.T) {
 		t.Errorf("Document.ConvertObjectModel: residual %v", cr.Residual())
 	}
 }
+
+// TestConvertObjectModelObjectStreams checks that a profile's ObjectStreams
+// has object-model output written as PDF 1.5 with object streams and a
+// cross-reference stream, still conformant, and that PDF/A-1 output, which
+// may not use them, ignores it.
+func TestConvertObjectModelObjectStreams(t *testing.T) {
+	data := objModelFixture(t)
+	p := PDF.Clone()
+	p.ObjectStreams = true
+	cr, err := ConvertBytes(data, p)
+	if err != nil {
+		t.Fatalf("ConvertBytes: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Errorf("residual %v", cr.Residual())
+	}
+	if !bytes.HasPrefix(cr.Output, []byte("%PDF-1.5")) {
+		t.Errorf("header %q, want %%PDF-1.5", cr.Output[:min(len(cr.Output), 8)])
+	}
+	for _, want := range []string{"/Type /ObjStm", "/Type /XRef"} {
+		if !bytes.Contains(cr.Output, []byte(want)) {
+			t.Errorf("output has no %s", want)
+		}
+	}
+	if out, err := VerifyObjectModelBytes(cr.Output); err != nil || !out.Valid {
+		t.Errorf("output re-verifies as %v, %v", out.Issues, err)
+	}
+
+	plain, err := ConvertObjectModelBytes(data)
+	if err != nil {
+		t.Fatalf("ConvertObjectModelBytes: %v", err)
+	}
+	a1 := PDFA_1B.Clone()
+	a1.ObjectStreams = true
+	archival, err := ConvertBytes(data, a1)
+	if err != nil {
+		t.Fatalf("ConvertBytes(PDF/A-1b): %v", err)
+	}
+	for name, out := range map[string][]byte{"without ObjectStreams": plain.Output, "PDF/A-1b": archival.Output} {
+		if bytes.Contains(out, []byte("/ObjStm")) || !bytes.HasPrefix(out, []byte("%PDF-1.4")) {
+			t.Errorf("%s: output has object streams or is not PDF 1.4", name)
+		}
+	}
+}
@@ -1,7 +1,13 @@
-// Convert and verify PDF files for PDF/A conformance.
+// Convert and verify PDF files for PDF/A conformance, and verify them for
+// PDF/UA accessibility.
 package gopdfrab
 
 import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"io"
+
 	"github.com/voidrab/gopdfrab/internal/convert"
 	"github.com/voidrab/gopdfrab/internal/pdf"
 	"github.com/voidrab/gopdfrab/internal/verify"
@@ -12,14 +18,32 @@ type (
 	FileResult[T any] = pdf.FileResult[T]
 	Profile           = pdf.Profile
 	LevelType         = pdf.LevelType
+	Spec              = pdf.Spec
 	Check             = pdf.Check
 	PDFError          = pdf.PDFError
 	ConvertResult     = convert.ConvertResult
+	OptimizeOptions   = convert.OptimizeOptions
+	OptimizeResult    = convert.OptimizeResult
+	Permissions       = pdf.Permissions
+	Limits            = pdf.Limits
+	LimitError        = pdf.LimitError
+	Revision          = pdf.Revision
 )
 
 // PDF conformance levels.
 const (
+	A_1A      = pdf.A_1A
 	A_1B      = pdf.A_1B
+	A_2B      = pdf.A_2B
+	A_2U      = pdf.A_2U
+	A_3B      = pdf.A_3B
+	A_3U      = pdf.A_3U
+	A_4       = pdf.A_4
+	A_4E      = pdf.A_4E
+	A_4F      = pdf.A_4F
+	UA_1      = pdf.UA_1
+	X_1A      = pdf.X_1A
+	X_4       = pdf.X_4
 	Undefined = pdf.Undefined
 	// ObjectModel is a reporting-only level for the generic ISO 32000
 	// object-model checks.
@@ -30,12 +54,66 @@ const (
 var (
 	// PDF is the default profile for generic ISO 32000 object-model checks.
 	PDF = pdf.PDF
+	// PDFA_1A is the canonical PDF/A-1a profile
+	PDFA_1A = pdf.PDFA_1A
 	// PDFA_1B is the canonical PDF/A-1b profile
 	PDFA_1B = pdf.PDFA_1B
+	// PDFA_2B is the canonical PDF/A-2b profile
+	PDFA_2B = pdf.PDFA_2B
+	// PDFA_2U is the canonical PDF/A-2u profile
+	PDFA_2U = pdf.PDFA_2U
+	// PDFA_3B is the canonical PDF/A-3b profile
+	PDFA_3B = pdf.PDFA_3B
+	// PDFA_3U is the canonical PDF/A-3u profile
+	PDFA_3U = pdf.PDFA_3U
+	// PDFA_4 is the canonical PDF/A-4 profile
+	PDFA_4 = pdf.PDFA_4
+	// PDFA_4E is the canonical PDF/A-4e profile
+	PDFA_4E = pdf.PDFA_4E
+	// PDFA_4F is the canonical PDF/A-4f profile
+	PDFA_4F = pdf.PDFA_4F
+	// PDFUA_1 is the canonical PDF/UA-1 profile. It verifies only; Convert
+	// rejects it.
+	PDFUA_1 = pdf.PDFUA_1
+	// PDFX_1A is the canonical PDF/X-1a:2003 preflight profile. It verifies
+	// only; Convert rejects it.
+	PDFX_1A = pdf.PDFX_1A
+	// PDFX_4 is the canonical PDF/X-4 preflight profile. It verifies only;
+	// Convert rejects it.
+	PDFX_4 = pdf.PDFX_4
+	// FacturX is the Factur-X / ZUGFeRD hybrid e-invoice profile: PDFA_3B
+	// plus the embedded invoice and fx: metadata checks. ConvertInvoice
+	// produces files for it.
+	FacturX = pdf.FacturX
 	// Legacy_1B is stricter in some areas and compatible with the original Isartor PDF/A-1b test suite.
 	Legacy_1B = pdf.Legacy_1B
 )
 
+// Standards a check's clause numbering refers to.
+const (
+	SpecPDF     = pdf.SpecPDF
+	SpecPDFA1   = pdf.SpecPDFA1
+	SpecPDFA2   = pdf.SpecPDFA2
+	SpecPDFA3   = pdf.SpecPDFA3
+	SpecPDFA4   = pdf.SpecPDFA4
+	SpecPDFUA1  = pdf.SpecPDFUA1
+	SpecPDFX1A  = pdf.SpecPDFX1A
+	SpecPDFX4   = pdf.SpecPDFX4
+	SpecFacturX = pdf.SpecFacturX
+)
+
+// Access permissions an encrypted document declares.
+const (
+	PermPrint             = pdf.PermPrint
+	PermModify            = pdf.PermModify
+	PermCopy              = pdf.PermCopy
+	PermAnnotate          = pdf.PermAnnotate
+	PermFillForms         = pdf.PermFillForms
+	PermExtractAccessible = pdf.PermExtractAccessible
+	PermAssemble          = pdf.PermAssemble
+	PermPrintHighQuality  = pdf.PermPrintHighQuality
+)
+
 // Checks is the registry of every selectable PDF/A check, grouped by area.
 var Checks = pdf.Checks
 
@@ -57,20 +135,47 @@ func CheckByClause(clause string, subclause int) (Check, bool) {
 	return pdf.CheckByClause(clause, subclause)
 }
 
+// CheckBySpecClause looks up the registered check for a (clause, subclause)
+// pair in the numbering of spec, e.g. SpecPDFA2.
+func CheckBySpecClause(spec Spec, clause string, subclause int) (Check, bool) {
+	return pdf.CheckBySpecClause(spec, clause, subclause)
+}
+
 // ChecksForClause returns every registered check under the given clause.
 func ChecksForClause(clause string) []Check { return pdf.ChecksForClause(clause) }
 
+// ChecksForSpec returns every registered check numbered by spec.
+func ChecksForSpec(spec Spec) []Check { return pdf.ChecksForSpec(spec) }
+
 // Verify opens, verifies, and closes a single file.
 func Verify(path string, p *Profile) (Result, error) { return verify.VerifyFile(path, p) }
 
+// VerifyContext is Verify governed by ctx: once ctx is done, verification
+// stops and the issues found so far are returned with ctx's error.
+func VerifyContext(ctx context.Context, path string, p *Profile) (Result, error) {
+	return verify.VerifyFileContext(ctx, path, p)
+}
+
 // VerifyBytes is Verify for an in-memory PDF.
 func VerifyBytes(data []byte, p *Profile) (Result, error) { return verify.VerifyBytes(data, p) }
 
+// VerifyBytesContext is VerifyContext for an in-memory PDF.
+func VerifyBytesContext(ctx context.Context, data []byte, p *Profile) (Result, error) {
+	return verify.VerifyBytesContext(ctx, data, p)
+}
+
 // VerifyAll opens, verifies, and closes a batch of files concurrently.
 func VerifyAll(paths []string, p *Profile) ([]FileResult[Result], error) {
 	return verify.VerifyAll(paths, p)
 }
 
+// VerifyAllContext is VerifyAll governed by ctx: once ctx is done, the files
+// being verified return partial results and the rest are not opened, each
+// with ctx's error.
+func VerifyAllContext(ctx context.Context, paths []string, p *Profile) ([]FileResult[Result], error) {
+	return verify.VerifyAllContext(ctx, paths, p)
+}
+
 // VerifyObjectModel opens, checks, and closes a single file against the
 // generic ISO 32000 object-model checks only, independent of any PDF/A
 // conformance level.
@@ -79,20 +184,41 @@ func VerifyObjectModel(path string) (Result, error) { return verify.VerifyFile(p
 // VerifyObjectModelBytes is VerifyObjectModel for an in-memory PDF.
 func VerifyObjectModelBytes(data []byte) (Result, error) { return verify.VerifyBytes(data, PDF) }
 
-// Convert reads the PDF at path and attempts to produce a PDF/A-1b
-// conformant rewrite.
+// Convert reads the PDF at path and attempts to produce a rewrite conformant
+// to p (PDF/A-1a, PDF/A-1b, PDF/A-2b, PDF/A-2u, PDF/A-3b, PDF/A-3u, a
+// PDF/A-4 variant, or the object-model profile PDF). Verification-only
+// levels (PDF/UA-1 and PDF/X) are rejected with an error.
 func Convert(path string, p *Profile) (ConvertResult, error) { return convert.Convert(path, p) }
 
+// ConvertContext is Convert governed by ctx: once ctx is done, conversion
+// stops and ctx's error is returned with a ConvertResult holding no Output
+// and the Result of the last verification, which may itself be cut short.
+func ConvertContext(ctx context.Context, path string, p *Profile) (ConvertResult, error) {
+	return convert.ConvertContext(ctx, path, p)
+}
+
 // ConvertBytes is Convert for an in-memory PDF.
 func ConvertBytes(data []byte, p *Profile) (ConvertResult, error) {
 	return convert.ConvertBytes(data, p)
 }
 
+// ConvertBytesContext is ConvertContext for an in-memory PDF.
+func ConvertBytesContext(ctx context.Context, data []byte, p *Profile) (ConvertResult, error) {
+	return convert.ConvertBytesContext(ctx, data, p)
+}
+
 // ConvertAll opens, converts, and closes a batch of files concurrently.
 func ConvertAll(paths []string, p *Profile) ([]FileResult[ConvertResult], error) {
 	return convert.ConvertAll(paths, p)
 }
 
+// ConvertAllContext is ConvertAll governed by ctx: once ctx is done, the
+// files being converted return partial results and the rest are not opened,
+// each with ctx's error.
+func ConvertAllContext(ctx context.Context, paths []string, p *Profile) ([]FileResult[ConvertResult], error) {
+	return convert.ConvertAllContext(ctx, paths, p)
+}
+
 // ConvertObjectModel reads the PDF at path and attempts to produce a rewrite
 // conformant with the generic ISO 32000 object-model checks only, independent
 // of any PDF/A conformance level -- the conversion counterpart to
@@ -104,12 +230,52 @@ func ConvertObjectModelBytes(data []byte) (ConvertResult, error) {
 	return convert.ConvertBytes(data, PDF)
 }
 
-// Document represents an open PDF file.
+// ConvertInvoice reads the PDF at path, embeds invoice, a UN/CEFACT Cross
+// Industry Invoice XML document, as its factur-x.xml and converts the result
+// towards p, a PDF/A-3 profile -- typically FacturX.
+func ConvertInvoice(path string, invoice []byte, p *Profile) (ConvertResult, error) {
+	return convert.ConvertInvoice(path, invoice, p)
+}
+
+// ConvertInvoiceBytes is ConvertInvoice for an in-memory PDF.
+func ConvertInvoiceBytes(data, invoice []byte, p *Profile) (ConvertResult, error) {
+	return convert.ConvertInvoiceBytes(data, invoice, p)
+}
+
+// Optimize reads the PDF at path and rewrites its streams to take less
+// space as opts select -- recompressing, downsampling and re-encoding
+// images -- keeping only the rewrites that add no violation of p.
+func Optimize(path string, p *Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	return convert.Optimize(path, p, opts)
+}
+
+// OptimizeBytes is Optimize for an in-memory PDF, such as a ConvertResult's
+// Output.
+func OptimizeBytes(data []byte, p *Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	return convert.OptimizeBytes(data, p, opts)
+}
+
+// Document represents an open PDF file. A Document is not safe for
+// concurrent use: its methods share its object caches, and a verify,
+// convert or optimize call installs its context and its profile's Limits on
+// the Document for as long as it runs, so two calls at once would see each
+// other's. To work on one file from several goroutines, open it once per
+// goroutine; Documents share nothing.
 type Document struct {
 	r *pdf.Reader
 }
 
-// Open initializes the PDF document at path.
+var (
+	// ErrIncorrectPassword is returned when opening an encrypted document
+	// with a password that is neither its user nor its owner password.
+	ErrIncorrectPassword = pdf.ErrIncorrectPassword
+	// ErrNotRecipient is returned when opening a certificate-encrypted
+	// document without a certificate among its recipients.
+	ErrNotRecipient = pdf.ErrNotRecipient
+)
+
+// Open initializes the PDF document at path. An encrypted document is
+// opened with the empty user password and decrypted as it is read.
 func Open(path string) (*Document, error) {
 	r, err := pdf.Open(path)
 	if err != nil {
@@ -118,12 +284,110 @@ func Open(path string) (*Document, error) {
 	return &Document{r: r}, nil
 }
 
+// OpenWithPassword is Open for a document encrypted with the Standard
+// security handler, authenticating password as its user or owner password.
+func OpenWithPassword(path, password string) (*Document, error) {
+	r, err := pdf.OpenWithPassword(path, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: r}, nil
+}
+
+// OpenBytesWithPassword is OpenWithPassword for an in-memory PDF.
+func OpenBytesWithPassword(data []byte, password string) (*Document, error) {
+	r, err := pdf.OpenBytesWithPassword(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: r}, nil
+}
+
+// OpenWithCertificate is Open for a document encrypted with the public-key
+// security handler (Adobe.PubSec): key, the private key of the recipient
+// certificate cert, unwraps the document's key. An *rsa.PrivateKey
+// satisfies crypto.Decrypter.
+func OpenWithCertificate(path string, cert *x509.Certificate, key crypto.Decrypter) (*Document, error) {
+	r, err := pdf.OpenWithCertificate(path, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: r}, nil
+}
+
+// OpenBytesWithCertificate is OpenWithCertificate for an in-memory PDF.
+func OpenBytesWithCertificate(data []byte, cert *x509.Certificate, key crypto.Decrypter) (*Document, error) {
+	r, err := pdf.OpenBytesWithCertificate(data, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: r}, nil
+}
+
+// OpenReaderAt initializes the PDF document held in the size bytes of r,
+// such as a range-reading client for object storage, reading it as needed
+// instead of copying it into memory. r must stay readable until the
+// Document is closed; closing the Document does not close r.
+func OpenReaderAt(r io.ReaderAt, size int64) (*Document, error) {
+	pr, err := pdf.OpenReaderAt(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: pr}, nil
+}
+
+// OpenReaderAtWithPassword is OpenWithPassword for an io.ReaderAt.
+func OpenReaderAtWithPassword(r io.ReaderAt, size int64, password string) (*Document, error) {
+	pr, err := pdf.OpenReaderAtWithPassword(r, size, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: pr}, nil
+}
+
+// OpenReaderAtWithCertificate is OpenWithCertificate for an io.ReaderAt.
+func OpenReaderAtWithCertificate(r io.ReaderAt, size int64, cert *x509.Certificate, key crypto.Decrypter) (*Document, error) {
+	pr, err := pdf.OpenReaderAtWithCertificate(r, size, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: pr}, nil
+}
+
+// Encrypted reports whether d is encrypted and being decrypted as it is
+// read.
+func (d *Document) Encrypted() bool { return d.r.Encrypted() }
+
+// Permissions returns the access permissions d's security handler
+// declares, and false if d is not encrypted.
+func (d *Document) Permissions() (Permissions, bool) { return d.r.Permissions() }
+
+// Revisions returns d's incremental revisions, the original document first,
+// each with the byte range it appended and the objects it added or changed.
+func (d *Document) Revisions() ([]Revision, error) { return d.r.Revisions() }
+
+// OpenRevision opens d as it stood at revision n of Revisions, before any
+// later update was appended. d must stay open while the revision is in use.
+func (d *Document) OpenRevision(n int) (*Document, error) {
+	r, err := d.r.OpenRevision(n)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{r: r}, nil
+}
+
 // Close ensures the file handle is released.
 func (d *Document) Close() error { return d.r.Close() }
 
 // Verify verifies d against the checks enabled in profile p.
 func (d *Document) Verify(p *Profile) (Result, error) { return verify.Verify(d.r, p) }
 
+// VerifyContext is Verify governed by ctx; see the package-level
+// VerifyContext.
+func (d *Document) VerifyContext(ctx context.Context, p *Profile) (Result, error) {
+	return verify.VerifyContext(ctx, d.r, p)
+}
+
 // VerifyObjectModel checks d against the generic ISO 32000 object-model
 // checks only, independent of any PDF/A conformance level.
 func (d *Document) VerifyObjectModel() (Result, error) { return d.Verify(PDF) }
@@ -151,9 +415,29 @@ func (d *Document) IsPDF() (bool, error) {
 }
 
 // Convert converts d, an already-open document, attempting to produce a
-// PDF/A-1b conformant rewrite.
+// rewrite conformant to p (PDF/A-1a, PDF/A-1b, PDF/A-2b, PDF/A-2u, PDF/A-3b,
+// PDF/A-3u, a PDF/A-4 variant, or the object-model profile PDF).
+// Verification-only levels (PDF/UA-1 and PDF/X) are rejected with an error.
 func (d *Document) Convert(p *Profile) (ConvertResult, error) { return convert.Run(d.r, p) }
 
+// ConvertContext is Convert governed by ctx; see the package-level
+// ConvertContext.
+func (d *Document) ConvertContext(ctx context.Context, p *Profile) (ConvertResult, error) {
+	return convert.RunContext(ctx, d.r, p)
+}
+
+// ConvertInvoice embeds invoice in d as its Factur-X / ZUGFeRD invoice and
+// converts the result towards p, a PDF/A-3 profile -- typically FacturX.
+func (d *Document) ConvertInvoice(invoice []byte, p *Profile) (ConvertResult, error) {
+	return convert.RunInvoice(d.r, invoice, p)
+}
+
+// Optimize rewrites d's streams to take less space; see the package-level
+// Optimize.
+func (d *Document) Optimize(p *Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	return convert.RunOptimize(d.r, p, opts)
+}
+
 // ConvertObjectModel converts d against the generic ISO 32000 object-model
 // checks only, independent of any PDF/A conformance level.
 func (d *Document) ConvertObjectModel() (ConvertResult, error) { return convert.Run(d.r, PDF) }
@@ -1,6 +1,9 @@
 package gopdfrab
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -59,6 +62,80 @@ func TestVerifyWrappers(t *testing.T) {
 	}
 }
 
+// TestContextWrappers exercises the context-taking verify and convert
+// facades: with a live context they match the plain ones, and with a
+// cancelled one they return its error.
+func TestContextWrappers(t *testing.T) {
+	data := []byte(plainPDF)
+	path := filepath.Join(t.TempDir(), "plain.pdf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	live := context.Background()
+	cancelled, cancel := context.WithCancel(live)
+	cancel()
+
+	for _, tc := range []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{"VerifyContext", func(ctx context.Context) error { _, err := VerifyContext(ctx, path, PDF); return err }},
+		{"VerifyBytesContext", func(ctx context.Context) error { _, err := VerifyBytesContext(ctx, data, PDF); return err }},
+		{"VerifyAllContext", func(ctx context.Context) error {
+			r, _ := VerifyAllContext(ctx, []string{path}, PDF)
+			return r[0].Err
+		}},
+		{"ConvertContext", func(ctx context.Context) error { _, err := ConvertContext(ctx, path, PDFA_1B); return err }},
+		{"ConvertBytesContext", func(ctx context.Context) error { _, err := ConvertBytesContext(ctx, data, PDFA_1B); return err }},
+		{"ConvertAllContext", func(ctx context.Context) error {
+			r, _ := ConvertAllContext(ctx, []string{path}, PDFA_1B)
+			return r[0].Err
+		}},
+		{"Document.VerifyContext", func(ctx context.Context) error {
+			doc, err := Open(path)
+			if err != nil {
+				return err
+			}
+			defer doc.Close()
+			_, err = doc.VerifyContext(ctx, PDF)
+			return err
+		}},
+		{"Document.ConvertContext", func(ctx context.Context) error {
+			doc, err := Open(path)
+			if err != nil {
+				return err
+			}
+			defer doc.Close()
+			_, err = doc.ConvertContext(ctx, PDFA_1B)
+			return err
+		}},
+	} {
+		if err := tc.run(live); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		if err := tc.run(cancelled); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s(cancelled) = %v, want context.Canceled", tc.name, err)
+		}
+	}
+}
+
+// TestLimits confirms a profile's Limits reach verification and conversion
+// through the facade.
+func TestLimits(t *testing.T) {
+	data := []byte(plainPDF)
+	p := PDF.Clone()
+	p.Limits = Limits{Objects: 1}
+	var le *LimitError
+	if _, err := VerifyBytes(data, p); !errors.As(err, &le) || le.Limit != "Objects" {
+		t.Errorf("VerifyBytes = %v, want an Objects LimitError", err)
+	}
+	a := PDFA_1B.Clone()
+	a.Limits = p.Limits
+	if _, err := ConvertBytes(data, a); !errors.As(err, &le) || le.Limit != "Objects" {
+		t.Errorf("ConvertBytes = %v, want an Objects LimitError", err)
+	}
+}
+
 // plainPDF is a minimal one-page PDF with no PDF/A structure but a
 // well-formed base object model, so object-model-only checks pass on it.
 const plainPDF = "%PDF-1.4\n" +
@@ -123,6 +200,48 @@ func TestObjectModelWrappers(t *testing.T) {
 	}
 }
 
+// TestOpenReaderAt exercises the io.ReaderAt open facade.
+func TestOpenReaderAt(t *testing.T) {
+	data := []byte(plainPDF)
+	doc, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReaderAt: %v", err)
+	}
+	defer doc.Close()
+	if n, err := doc.GetPageCount(); err != nil || n != 1 {
+		t.Errorf("GetPageCount = %d, %v; want 1", n, err)
+	}
+	if _, err := OpenReaderAtWithPassword(bytes.NewReader(data), 4, ""); err == nil {
+		t.Error("OpenReaderAtWithPassword over a truncated size succeeded")
+	}
+}
+
+// TestRevisionWrappers exercises the revision facades over a document
+// with no incremental updates.
+func TestRevisionWrappers(t *testing.T) {
+	data := []byte(plainPDF)
+	doc, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReaderAt: %v", err)
+	}
+	defer doc.Close()
+	revs, err := doc.Revisions()
+	if err != nil || len(revs) != 1 {
+		t.Fatalf("Revisions = %d revisions, %v; want 1", len(revs), err)
+	}
+	orig, err := doc.OpenRevision(0)
+	if err != nil {
+		t.Fatalf("OpenRevision(0): %v", err)
+	}
+	defer orig.Close()
+	if n, err := orig.GetPageCount(); err != nil || n != 1 {
+		t.Errorf("revision 0 GetPageCount = %d, %v; want 1", n, err)
+	}
+	if _, err := doc.OpenRevision(1); err == nil {
+		t.Error("OpenRevision(1) of a single-revision document succeeded")
+	}
+}
+
 // TestDocumentAccessors exercises Open and every Document accessor facade,
 // including Open's error path.
 func TestDocumentAccessors(t *testing.T) {
@@ -1,6 +1,8 @@
 // Package arlington exposes the Arlington PDF Model
-// (https://github.com/pdf-association/arlington-pdf-model) as a compiled-in Go table,
-// generated from the vendored PDF 1.4 TSV set under testdata/tsv/1.4.
+// (https://github.com/pdf-association/arlington-pdf-model) as compiled-in Go tables: the PDF
+// 1.4 model generated from the vendored TSV set under testdata/tsv/1.4 (Types, the model
+// PDF/A-1 to PDF/A-3 validate against) and the PDF 2.0 model generated from testdata/tsv/latest
+// (Types20, for PDF/A-4). Model selects between them.
 //
 // Arlington describes what ISO 32000 permits for every dictionary/array/stream type in the
 // spec; it does not encode PDF/A's narrower restrictions, and this package has no opinion on
@@ -219,8 +221,8 @@ type KeyDef struct {
 	// Predicated.Values.
 	ValueCond         *Cond
 	IndirectReference IndirectRule
-	// SinceVersion/DeprecatedIn are unread at runtime (the 1.4 TSV set is pre-filtered);
-	// kept as groundwork for the predicate evaluator's version-gate families.
+	// SinceVersion/DeprecatedIn are unread at runtime (each TSV set is pre-filtered to its
+	// model's version); kept as groundwork for the predicate evaluator's version-gate families.
 	SinceVersion   string
 	DeprecatedIn   string      // empty if never deprecated
 	PossibleValues []string // enumerated legal values, when constrained; predicate-only entries are dropped
@@ -250,9 +252,13 @@ type ObjectType struct {
 	// tsv/latest set against tsv/1.4. Empty if the type itself did not exist in tsv/latest
 	// (e.g. renamed across versions), which is a safe (false-negative) default.
 	Post14Keys []string
+	// Post17Keys lists keys the vendored tsv/latest set marks as introduced in PDF 2.0 (plainly
+	// or as a 1.7 extension PDF 2.0 adopted); a subset of Post14Keys. Used by PDF/A-2 and
+	// PDF/A-3, which are based on PDF 1.7. Both lists are empty throughout Types20.
+	Post17Keys []string
 
 	// keyByName indexes Keys by row name (first row wins on duplicates,
-	// matching a front-to-back scan), built once over Types at init so the
+	// matching a front-to-back scan), built once over each model at init so the
 	// per-dict-entry lookups in schema validation are O(1) instead of a
 	// linear scan over the type's rows. Nil on hand-built values (tests),
 	// which fall back to the scan.
@@ -292,18 +298,37 @@ func (ot ObjectType) KeyDefByName(key string) *KeyDef {
 }
 
 func init() {
-	for name, ot := range Types {
-		m := make(map[string]int, len(ot.Keys))
-		for i := range ot.Keys {
-			if _, dup := m[ot.Keys[i].Name]; !dup {
-				m[ot.Keys[i].Name] = i
+	for _, types := range []map[string]ObjectType{Types, Types20} {
+		for name, ot := range types {
+			m := make(map[string]int, len(ot.Keys))
+			for i := range ot.Keys {
+				if _, dup := m[ot.Keys[i].Name]; !dup {
+					m[ot.Keys[i].Name] = i
+				}
 			}
+			ot.keyByName = m
+			types[name] = ot
 		}
-		ot.keyByName = m
-		Types[name] = ot
 	}
 }
 
+// Model is one compiled-in Arlington object model: the type table for a PDF version and its
+// self-identification table.
+type Model struct {
+	// Version is the PDF version the model's version gates were folded against.
+	Version        string
+	types          map[string]ObjectType
+	selfIdentified map[[2]string]string
+}
+
+var (
+	// PDF14 is the PDF 1.4 model (Types), which PDF/A-1 is based on. PDF/A-2 and PDF/A-3
+	// also validate against it, flagging later keys through Post14Keys and Post17Keys.
+	PDF14 = &Model{Version: "1.4", types: Types, selfIdentified: selfIdentified}
+	// PDF20 is the PDF 2.0 model (Types20), which PDF/A-4 is based on.
+	PDF20 = &Model{Version: "2.0", types: Types20, selfIdentified: selfIdentified20}
+)
+
 // standard14Fonts is the set of base font names every conforming reader must provide
 // (ISO 32000-1 §9.6.2.2); hand-maintained, not TSV data.
 var standard14Fonts = map[string]bool{
@@ -319,7 +344,12 @@ func IsStandard14(name string) bool { return standard14Fonts[name] }
 
 // Type looks up a vendored PDF 1.4 Arlington type by name (e.g. "Catalog", "ExtGState").
 func Type(name string) (ObjectType, bool) {
-	t, ok := Types[name]
+	return PDF14.Type(name)
+}
+
+// Type looks up an Arlington type of model m by name.
+func (m *Model) Type(name string) (ObjectType, bool) {
+	t, ok := m.types[name]
 	return t, ok
 }
 
@@ -328,10 +358,15 @@ func Type(name string) (ObjectType, bool) {
 // The generated table only contains pairs exactly one type in the model is consistent with,
 // so re-anchoring on the result never guesses.
 func SelfIdentified(typeVal, subtypeVal string) string {
+	return PDF14.SelfIdentified(typeVal, subtypeVal)
+}
+
+// SelfIdentified is the package-level SelfIdentified over model m.
+func (m *Model) SelfIdentified(typeVal, subtypeVal string) string {
 	if subtypeVal != "" {
-		if n, ok := selfIdentified[[2]string{typeVal, subtypeVal}]; ok {
+		if n, ok := m.selfIdentified[[2]string{typeVal, subtypeVal}]; ok {
 			return n
 		}
 	}
-	return selfIdentified[[2]string{typeVal, ""}]
+	return m.selfIdentified[[2]string{typeVal, ""}]
 }
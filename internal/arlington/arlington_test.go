@@ -598,9 +598,9 @@ func TestClassificationFloor(t *testing.T) {
 	t.Logf("simple-row fraction: %.3f (%d/%d)", fraction, simple, total)
 }
 
-// TestGeneratorIdempotent regenerates model_gen.go into a scratch copy of the package and
-// asserts it is byte-identical to the checked-in file, guarding against edits to gen.go or
-// the vendored TSVs that were never regenerated.
+// TestGeneratorIdempotent regenerates model_gen.go and model20_gen.go into a scratch copy of
+// the package and asserts each is byte-identical to the checked-in file, guarding against
+// edits to gen.go or the vendored TSVs that were never regenerated.
 func TestGeneratorIdempotent(t *testing.T) {
 	if testing.Short() {
 		t.Skip("invokes `go run`; skipped in -short mode")
@@ -613,11 +613,6 @@ func TestGeneratorIdempotent(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	checkedIn, err := os.ReadFile(filepath.Join(wd, "model_gen.go"))
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	scratch := t.TempDir()
 	for _, name := range []string{"gen.go", "arlington.go"} {
 		data, err := os.ReadFile(filepath.Join(wd, name))
@@ -638,12 +633,18 @@ func TestGeneratorIdempotent(t *testing.T) {
 		t.Fatalf("go run gen.go: %v\n%s", err, out)
 	}
 
-	regenerated, err := os.ReadFile(filepath.Join(scratch, "model_gen.go"))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if string(regenerated) != string(checkedIn) {
-		t.Error("model_gen.go is stale: regenerating produces a different file; run `go generate ./internal/arlington/...`")
+	for _, name := range []string{"model_gen.go", "model20_gen.go"} {
+		checkedIn, err := os.ReadFile(filepath.Join(wd, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		regenerated, err := os.ReadFile(filepath.Join(scratch, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(regenerated) != string(checkedIn) {
+			t.Errorf("%s is stale: regenerating produces a different file; run `go generate ./internal/arlington/...`", name)
+		}
 	}
 }
 
@@ -731,3 +732,54 @@ func TestColourSpaceArrayDiscriminator(t *testing.T) {
 		t.Errorf("ByValue[ICCBased] = %q, want ICCBasedColorSpace", g.ByValue["ICCBased"])
 	}
 }
+
+// TestModel20 pins the PDF 2.0 model generated from tsv/latest: version gates fold at 2.0
+// (including those wrapping Link candidates), every candidate resolves within the model, and
+// no type carries post-version keys.
+func TestModel20(t *testing.T) {
+	if PDF20.Version != "2.0" || PDF14.Version != "1.4" {
+		t.Fatalf("model versions = %q, %q; want 2.0, 1.4", PDF20.Version, PDF14.Version)
+	}
+	cat, ok := PDF20.Type("Catalog")
+	if !ok {
+		t.Fatal("PDF20 Catalog type not found")
+	}
+	for _, k := range []string{"AF", "DPartRoot", "Collection"} {
+		if findKey(cat, k) == nil {
+			t.Errorf("PDF20 Catalog: missing key %q", k)
+		}
+	}
+	// fn:MustBeIndirect(fn:BeforeVersion(2.0)) no longer applies at 2.0.
+	if outlines := findKey(cat, "Outlines"); outlines == nil || outlines.IndirectReference != IndirectEither {
+		t.Errorf("PDF20 Catalog.Outlines: want IndirectEither, got %+v", outlines)
+	}
+	if _, ok := PDF14.Type("DPartRoot"); ok {
+		t.Error("PDF14 model has the PDF 2.0 DPartRoot type")
+	}
+
+	for name, ot := range Types20 {
+		if len(ot.Post14Keys) != 0 || len(ot.Post17Keys) != 0 {
+			t.Errorf("Types20[%q]: want no post-version keys, got %v / %v", name, ot.Post14Keys, ot.Post17Keys)
+		}
+		rows := ot.Keys
+		if ot.Wildcard != nil {
+			rows = append(rows[:len(rows):len(rows)], *ot.Wildcard)
+		}
+		for _, kd := range rows {
+			for _, g := range kd.LinkGroups {
+				for _, c := range g.Candidates {
+					if _, ok := PDF20.Type(c); !ok {
+						t.Errorf("Types20[%q].%s: Candidate %q does not resolve", name, kd.Name, c)
+					}
+				}
+			}
+		}
+	}
+
+	if got := PDF20.SelfIdentified("Annot", "RichMedia"); got != "AnnotRichMedia" {
+		t.Errorf("PDF20.SelfIdentified(Annot, RichMedia) = %q, want AnnotRichMedia", got)
+	}
+	if got := SelfIdentified("Annot", "RichMedia"); got != "" {
+		t.Errorf("SelfIdentified(Annot, RichMedia) = %q, want \"\" in the 1.4 model", got)
+	}
+}
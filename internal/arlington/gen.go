@@ -1,7 +1,9 @@
 //go:build ignore
 
-// Command gen reads the vendored Arlington PDF Model TSVs under testdata/tsv/1.4 and emits
-// one Go map literal with one ObjectType entry per TSV file.
+// Command gen reads the vendored Arlington PDF Model TSVs and emits one Go map literal per
+// object model, with one ObjectType entry per TSV file: the PDF 1.4 model from
+// testdata/tsv/1.4 into model_gen.go and the PDF 2.0 model from testdata/tsv/latest into
+// model20_gen.go.
 //
 // Invoke via `go generate ./internal/arlington/...`.
 package main
@@ -13,6 +15,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,13 +24,31 @@ import (
 const (
 	tsvDir    = "testdata/tsv/1.4"
 	latestDir = "testdata/tsv/latest"
-	outFile   = "model_gen.go"
-
-	// modelVersion pins the PDF version version-gate predicates are folded against; it must
-	// match the vendored TSV subset under tsvDir.
-	modelVersion = "1.4"
 )
 
+// model describes one generated object model.
+type model struct {
+	dir, outFile string
+	// version is the PDF version the model's version-gate predicates are folded against;
+	// it must match the vendored TSV set under dir.
+	version string
+	// typesVar and selfIdentifiedVar name the emitted maps.
+	typesVar, selfIdentifiedVar string
+	// postKeys emits Post14Keys and Post17Keys, computed against latestDir. Only
+	// meaningful for a model older than the latest set.
+	postKeys bool
+}
+
+var models = []model{
+	{dir: tsvDir, outFile: "model_gen.go", version: "1.4",
+		typesVar: "Types", selfIdentifiedVar: "selfIdentified", postKeys: true},
+	{dir: latestDir, outFile: "model20_gen.go", version: "2.0",
+		typesVar: "Types20", selfIdentifiedVar: "selfIdentified20"},
+}
+
+// modelVersion is the version of the model being generated (model.version).
+var modelVersion string
+
 // valueTypeIdent maps an Arlington TSV Type token to the matching Go identifier declared in
 // arlington.go. Keep in sync with the ValueType const block there.
 var valueTypeIdent = map[string]string{
@@ -98,23 +119,35 @@ type typeEntry struct {
 }
 
 func main() {
-	files, err := filepath.Glob(filepath.Join(tsvDir, "*.tsv"))
+	for _, m := range models {
+		generate(m)
+	}
+}
+
+func generate(m model) {
+	modelVersion = m.version
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.tsv"))
 	if err != nil || len(files) == 0 {
-		log.Fatalf("glob %s: %v (found %d files)", tsvDir, err, len(files))
+		log.Fatalf("glob %s: %v (found %d files)", m.dir, err, len(files))
 	}
 	sort.Strings(files)
 
 	var b strings.Builder
-	b.WriteString("// Code generated by gen.go from testdata/tsv/1.4; DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "// Code generated by gen.go from %s; DO NOT EDIT.\n", m.dir)
 	b.WriteString("//\n")
 	b.WriteString("// Arlington PDF Model data is copyright PDF Association, Inc., Apache-2.0.\n")
 	b.WriteString("// See testdata/LICENSE and testdata/README.md for provenance.\n")
 	b.WriteString("package arlington\n\n")
-	b.WriteString("var Types = map[string]ObjectType{\n")
+	fmt.Fprintf(&b, "var %s = map[string]ObjectType{\n", m.typesVar)
 
-	post14Keys, err := computePost14Keys()
-	if err != nil {
-		log.Fatalf("computing post-1.4 keys: %v", err)
+	var post14Keys, post17Keys map[string][]string
+	if m.postKeys {
+		if post14Keys, err = computePost14Keys(); err != nil {
+			log.Fatalf("computing post-1.4 keys: %v", err)
+		}
+		if post17Keys, err = computePost17Keys(); err != nil {
+			log.Fatalf("computing post-1.7 keys: %v", err)
+		}
 	}
 
 	// First pass: parse every type's own keys. keyDefsByType is needed by the second pass to
@@ -181,27 +214,28 @@ func main() {
 			b.WriteString("},\n")
 		}
 		writeStringSlice(&b, "Post14Keys", post14Keys[t.name])
+		writeStringSlice(&b, "Post17Keys", post17Keys[t.name])
 		b.WriteString("},\n")
 	}
 	b.WriteString("}\n")
 
-	writeSelfIdentified(&b, types)
+	writeSelfIdentified(&b, m.selfIdentifiedVar, types)
 
 	fraction := 0.0
 	if totalRows > 0 {
 		fraction = float64(simpleRows) / float64(totalRows) * 100
 	}
-	fmt.Fprintf(os.Stderr, "arlington: %d/%d rows simple (%.1f%%), %d types, %d SpecialCase constraints\n",
-		simpleRows, totalRows, fraction, len(files), specialCases)
+	fmt.Fprintf(os.Stderr, "arlington %s: %d/%d rows simple (%.1f%%), %d types, %d SpecialCase constraints\n",
+		m.version, simpleRows, totalRows, fraction, len(files), specialCases)
 
 	src, err := format.Source([]byte(b.String()))
 	if err != nil {
 		// Write the unformatted source anyway so it can be inspected for the syntax error.
-		_ = os.WriteFile(outFile, []byte(b.String()), 0o644)
+		_ = os.WriteFile(m.outFile, []byte(b.String()), 0o644)
 		log.Fatalf("format generated source: %v", err)
 	}
-	if err := os.WriteFile(outFile, src, 0o644); err != nil {
-		log.Fatalf("write %s: %v", outFile, err)
+	if err := os.WriteFile(m.outFile, src, 0o644); err != nil {
+		log.Fatalf("write %s: %v", m.outFile, err)
 	}
 }
 
@@ -281,8 +315,8 @@ func writeKeyDefFields(b *strings.Builder, kd keyDef) {
 // (Type, Subtype) combination its enumerated PossibleValues allow, so overlapping claims
 // collide and are dropped; a bare (Type, "") claim survives only when no other type
 // constrains that Type value at all. Fail closed: any collision drops the pair. Predicated
-// or unconstrained Type/Subtype rows do not occur in the vendored 1.4 set.
-func writeSelfIdentified(b *strings.Builder, types []typeEntry) {
+// Type/Subtype rows claim nothing. varName names the emitted map.
+func writeSelfIdentified(b *strings.Builder, varName string, types []typeEntry) {
 	enumPV := func(keys []keyDef, name string) []string {
 		for _, kd := range keys {
 			if kd.name == name && !kd.predicated.values {
@@ -324,12 +358,12 @@ func writeSelfIdentified(b *strings.Builder, types []typeEntry) {
 		}
 		return keys[i][1] < keys[j][1]
 	})
-	b.WriteString("\nvar selfIdentified = map[[2]string]string{\n")
+	fmt.Fprintf(b, "\nvar %s = map[[2]string]string{\n", varName)
 	for _, k := range keys {
 		fmt.Fprintf(b, "{%q, %q}: %q,\n", k[0], k[1], claims[k][0])
 	}
 	b.WriteString("}\n")
-	fmt.Fprintf(os.Stderr, "arlington: %d self-identifying (Type, Subtype) pairs\n", len(keys))
+	fmt.Fprintf(os.Stderr, "arlington %s: %d self-identifying (Type, Subtype) pairs\n", modelVersion, len(keys))
 }
 
 // compileValueCond compiles a PossibleValues column that is exactly one whole-group
@@ -489,6 +523,42 @@ func computePost14Keys() (map[string][]string, error) {
 	return post14, nil
 }
 
+// computePost17Keys returns, per type name (TSV basename), the keys of testdata/tsv/latest
+// whose SinceVersion is 2.0 -- either plainly or as the "fn:Eval(fn:Extension(X,1.7) || 2.0)"
+// form, an extension to 1.7 that PDF 2.0 adopted, which a plain PDF 1.7 reader still lacks.
+// Keys that remain extension-only (no "|| 2.0") are left out, as are types that exist only in
+// tsv/1.4 -- the same false-negative default as Post14Keys.
+func computePost17Keys() (map[string][]string, error) {
+	files, err := filepath.Glob(filepath.Join(latestDir, "*.tsv"))
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, path := range files {
+		typeName := strings.TrimSuffix(filepath.Base(path), ".tsv")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var added []string
+		for i, line := range strings.Split(string(data), "\n") {
+			cols := strings.Split(line, "\t")
+			if i == 0 || len(cols) < 3 || cols[0] == "*" {
+				continue
+			}
+			since := strings.TrimSpace(cols[2])
+			if since == "2.0" || strings.HasSuffix(since, "|| 2.0)") {
+				added = append(added, cols[0])
+			}
+		}
+		if len(added) > 0 {
+			sort.Strings(added)
+			out[typeName] = slices.Compact(added)
+		}
+	}
+	return out, nil
+}
+
 // keySetsByType reads every TSV under dir and returns, per type name, the set of Key column
 // values (order not preserved; only membership matters). Unlike parseTSV, this only reads the
 // first column and tolerates rows with fewer than 12 columns -- tsv/latest, being upstream's
@@ -553,6 +623,9 @@ func parseTSV(path string) ([]row, error) {
 			continue
 		}
 		cols := strings.Split(line, "\t")
+		if len(cols) == 11 {
+			cols = append(cols, "") // a few latest-set rows drop the empty trailing Note column
+		}
 		if len(cols) != 12 {
 			return nil, fmt.Errorf("expected 12 columns, got %d: %q", len(cols), line)
 		}
@@ -1520,11 +1593,23 @@ func parseLinkGroups(typ, link string) []linkGroup {
 	return groups
 }
 
-// splitNames splits one bracket-group's comma-separated Arlington type names.
+// splitNames splits one bracket-group's comma-separated Arlington type names, folding
+// version-gated names (fn:SinceVersion(1.6,AnnotWatermark)) against modelVersion like
+// PossibleValues entries.
 func splitNames(group string) []string {
 	var out []string
-	for _, item := range strings.Split(group, ",") {
+	for _, item := range splitTopLevelComma(group) {
 		item = strings.TrimSpace(item)
+		if strings.HasPrefix(item, "fn:") {
+			val, keep, ok := foldValueEntry(item)
+			if !ok {
+				return nil // never guess a candidate set around an unfoldable gate
+			}
+			if !keep {
+				continue
+			}
+			item = val
+		}
 		if item != "" {
 			out = append(out, item)
 		}
@@ -159,6 +159,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"SD"},
+		Post17Keys: []string{"SD"},
 	},
 	"ActionGoToR": {
 		Name: "ActionGoToR",
@@ -226,6 +227,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"SD"},
+		Post17Keys: []string{"SD"},
 	},
 	"ActionHide": {
 		Name: "ActionHide",
@@ -1192,6 +1194,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BE", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RD", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotFileAttachment": {
 		Name: "AnnotFileAttachment",
@@ -1328,6 +1331,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotFreeText": {
 		Name: "AnnotFreeText",
@@ -1459,6 +1463,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BE", "BM", "BS", "CL", "CreationDate", "DS", "ExData", "IRT", "IT", "LE", "Lang", "OC", "RC", "RD", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotHighlight": {
 		Name: "AnnotHighlight",
@@ -1589,6 +1594,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotInk": {
 		Name: "AnnotInk",
@@ -1729,6 +1735,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "Path", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "Path", "ca"},
 	},
 	"AnnotLine": {
 		Name: "AnnotLine",
@@ -1889,6 +1896,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CO", "CP", "Cap", "CreationDate", "ExData", "IRT", "IT", "LL", "LLE", "LLO", "Lang", "Measure", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotLink": {
 		Name: "AnnotLink",
@@ -2037,6 +2045,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "Path", "QuadPoints", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotMovie": {
 		Name: "AnnotMovie",
@@ -2177,6 +2186,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotPopup": {
 		Name: "AnnotPopup",
@@ -2291,6 +2301,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotPrinterMark": {
 		Name: "AnnotPrinterMark",
@@ -2394,6 +2405,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotSound": {
 		Name: "AnnotSound",
@@ -2548,6 +2560,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotSquare": {
 		Name: "AnnotSquare",
@@ -2681,6 +2694,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BE", "BM", "CA", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RD", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotSquiggly": {
 		Name: "AnnotSquiggly",
@@ -2811,6 +2825,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotStamp": {
 		Name: "AnnotStamp",
@@ -2935,6 +2950,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "IT", "Lang", "ca"},
 	},
 	"AnnotStrikeOut": {
 		Name: "AnnotStrikeOut",
@@ -3065,6 +3081,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotText": {
 		Name: "AnnotText",
@@ -3195,6 +3212,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "State", "StateModel", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotTrapNetwork": {
 		Name: "AnnotTrapNetwork",
@@ -3350,6 +3368,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"AnnotUnderline": {
 		Name: "AnnotUnderline",
@@ -3480,6 +3499,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CreationDate", "ExData", "IRT", "IT", "Lang", "OC", "RC", "RT", "Subj", "ca"},
+		Post17Keys: []string{"AF", "BM", "Lang", "ca"},
 	},
 	"AnnotWidget": {
 		Name: "AnnotWidget",
@@ -3667,6 +3687,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "BM", "CA", "Lang", "OC", "PMD", "ca"},
+		Post17Keys: []string{"AF", "BM", "CA", "Lang", "ca"},
 	},
 	"Appearance": {
 		Name: "Appearance",
@@ -6226,6 +6247,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "Collection", "DPartRoot", "DSS", "Extensions", "Legal", "OCProperties", "Perms", "Requirements"},
+		Post17Keys: []string{"AF", "DPartRoot", "DSS"},
 	},
 	"CharProcMap": {
 		Name: "CharProcMap",
@@ -6499,6 +6521,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"SD"},
+		Post17Keys: []string{"SD"},
 	},
 	"DestXYZArray": {
 		Name: "DestXYZArray",
@@ -7889,6 +7912,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AFRelationship", "CI", "Desc", "EP", "Thumb", "UF"},
+		Post17Keys: []string{"AFRelationship", "EP", "Thumb"},
 	},
 	"FileTrailer": {
 		Name: "FileTrailer",
@@ -10741,6 +10765,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"HTO", "UseBlackPtComp"},
+		Post17Keys: []string{"HTO", "UseBlackPtComp"},
 	},
 	"GraphicsStateParameterMap": {
 		Name: "GraphicsStateParameterMap",
@@ -12853,6 +12878,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"DestOutputProfileRef", "MixingHints", "SpectralData"},
+		Post17Keys: []string{"DestOutputProfileRef", "MixingHints", "SpectralData"},
 	},
 	"PageLabel": {
 		Name: "PageLabel",
@@ -13103,6 +13129,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DPart", "OutputIntents", "PresSteps", "Tabs", "TemplateInstantiated", "UserUnit", "VP"},
+		Post17Keys: []string{"AF", "DPart", "OutputIntents"},
 	},
 	"PagePiece": {
 		Name: "PagePiece",
@@ -15428,6 +15455,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "E", "NS", "Phoneme", "PhoneticAlphabet", "Ref"},
+		Post17Keys: []string{"AF", "NS", "Phoneme", "PhoneticAlphabet", "Ref"},
 	},
 	"StructTreeRoot": {
 		Name: "StructTreeRoot",
@@ -15505,6 +15533,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "Namespaces", "PronunciationLexicon"},
+		Post17Keys: []string{"AF", "Namespaces", "PronunciationLexicon"},
 	},
 	"StructureAttributesDict": {
 		Name: "StructureAttributesDict",
@@ -15661,6 +15690,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"BackgroundColor", "BorderColor", "BorderStyle", "BorderThickness", "Checked", "Color", "ColumnCount", "ColumnGap", "ColumnWidths", "Contents", "ContinuedFrom", "ContinuedList", "Desc", "GlyphOrientationVertical", "NS", "NoteType", "P", "Padding", "Role", "RubyAlign", "RubyPosition", "Scope", "Short", "Subtype", "Summary", "TBorderStyle", "TPadding", "TextDecorationColor", "TextDecorationThickness", "TextPosition", "Type", "checked"},
+		Post17Keys: []string{"ContinuedFrom", "ContinuedList", "NS", "Short", "Subtype", "TextPosition", "Type"},
 	},
 	"StyleDict": {
 		Name: "StyleDict",
@@ -15706,6 +15736,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"Metadata"},
+		Post17Keys: []string{"Metadata"},
 	},
 	"Thumbnail": {
 		Name: "Thumbnail",
@@ -16155,6 +16186,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"Duplex", "Enforce", "NumCopies", "PickTrayByPDFSize", "PrintPageRange", "PrintScaling"},
+		Post17Keys: []string{"Enforce"},
 	},
 	"WebCaptureCommand": {
 		Name: "WebCaptureCommand",
@@ -16905,6 +16937,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL", "Measure", "OC", "PtData"},
+		Post17Keys: []string{"AF", "Measure", "PtData"},
 	},
 	"XObjectFormTrapNet": {
 		Name: "XObjectFormTrapNet",
@@ -17155,6 +17188,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL", "Measure", "OC", "PtData"},
+		Post17Keys: []string{"AF", "Measure", "PtData"},
 	},
 	"XObjectFormType1": {
 		Name: "XObjectFormType1",
@@ -17363,6 +17397,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL", "GTS_Encapsulated", "GTS_Env", "GTS_Scope", "GTS_XID", "Measure", "OC", "PtData"},
+		Post17Keys: []string{"AF", "Measure", "PtData"},
 	},
 	"XObjectImage": {
 		Name: "XObjectImage",
@@ -17593,6 +17628,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL", "GTS_Encapsulated", "GTS_Env", "GTS_Scope", "GTS_XID", "Measure", "OC", "PtData", "SMaskInData"},
+		Post17Keys: []string{"AF", "Measure", "PtData"},
 	},
 	"XObjectImageMask": {
 		Name: "XObjectImageMask",
@@ -17793,6 +17829,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL", "Measure", "OC", "PtData", "SMaskInData"},
+		Post17Keys: []string{"AF", "Measure", "PtData"},
 	},
 	"XObjectImageSoftMask": {
 		Name: "XObjectImageSoftMask",
@@ -17974,6 +18011,7 @@ var Types = map[string]ObjectType{
 			},
 		},
 		Post14Keys: []string{"AF", "DL"},
+		Post17Keys: []string{"AF"},
 	},
 	"XObjectMap": {
 		Name: "XObjectMap",
@@ -1,17 +1,21 @@
-// Package-level pipeline for converting an arbitrary PDF into a PDF/A-1b
-// rewrite:
+// Package-level pipeline for converting an arbitrary PDF into a PDF/A-1b,
+// PDF/A-2b, PDF/A-3b or PDF/A-4 rewrite (the part follows the target
+// profile's level):
 //
 //	PDF -> pre-emptive fixups -> [serialize -> verify -> targeted fixups]* -> raster last resort -> output
 package convert
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 
+	"github.com/voidrab/gopdfrab/internal/arlington"
 	"github.com/voidrab/gopdfrab/internal/pdf"
 	"github.com/voidrab/gopdfrab/internal/verify"
 	"github.com/voidrab/gopdfrab/internal/writer"
@@ -23,6 +27,25 @@ type ConvertResult struct {
 	Output     []byte
 	Result     pdf.Result
 	Iterations int
+
+	// Decrypted records that the source was encrypted and Output was
+	// written decrypted, without an Encrypt dictionary. Permissions are the
+	// access permissions the source declared, kept for the record since
+	// Output no longer enforces them.
+	Decrypted   bool
+	Permissions pdf.Permissions
+
+	// Deduplicated and DeduplicatedBytes report, for a profile with
+	// Deduplicate set, how many indirect objects conversion merged into an
+	// identical one and the bytes those copies would have taken in Output.
+	Deduplicated      int
+	DeduplicatedBytes int64
+
+	// FullRewrite records that a profile with IncrementalSave got a fresh
+	// file instead of an update, because its target requires a file header
+	// version the original's does not declare, which an update cannot
+	// change: PDF 2.0 for PDF/A-4.
+	FullRewrite bool
 }
 
 // Residual returns the issues remaining in r.Output that Convert was unable
@@ -40,30 +63,49 @@ func (r ConvertResult) Save(path string) error {
 	return os.WriteFile(path, r.Output, 0o644)
 }
 
-// Convert reads the PDF at path and attempts to produce a PDF/A-1b
-// conformant rewrite. It always returns the best attempt it produced,
-// even if some violations remain.
+// Convert reads the PDF at path and attempts to produce a rewrite conformant
+// to p (PDF/A-1a, PDF/A-1b, PDF/A-2b, PDF/A-2u, PDF/A-3b, PDF/A-3u, a
+// PDF/A-4 variant, or the object-model profile PDF). Verification-only
+// levels (PDF/UA-1 and PDF/X) are rejected with an error. It always returns
+// the best attempt it produced, even if some violations remain.
 func Convert(path string, p *pdf.Profile) (ConvertResult, error) {
+	return ConvertContext(context.Background(), path, p)
+}
+
+// ConvertContext is Convert governed by ctx; see RunContext.
+func ConvertContext(ctx context.Context, path string, p *pdf.Profile) (ConvertResult, error) {
 	doc, err := pdf.Open(path)
 	if err != nil {
 		return ConvertResult{}, fmt.Errorf("convert: %w", err)
 	}
 	defer doc.Close()
-	return Run(doc, p)
+	return RunContext(ctx, doc, p)
 }
 
 // ConvertBytes is Convert for an in-memory PDF.
 func ConvertBytes(data []byte, p *pdf.Profile) (ConvertResult, error) {
+	return ConvertBytesContext(context.Background(), data, p)
+}
+
+// ConvertBytesContext is ConvertBytes governed by ctx; see RunContext.
+func ConvertBytesContext(ctx context.Context, data []byte, p *pdf.Profile) (ConvertResult, error) {
 	doc, err := pdf.OpenBytes(data)
 	if err != nil {
 		return ConvertResult{}, fmt.Errorf("convert: %w", err)
 	}
 	defer doc.Close()
-	return Run(doc, p)
+	return RunContext(ctx, doc, p)
 }
 
 // ConvertAll opens, converts, and closes a batch of files concurrently.
 func ConvertAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[ConvertResult], error) {
+	return ConvertAllContext(context.Background(), paths, p)
+}
+
+// ConvertAllContext is ConvertAll governed by ctx: once ctx is done, the
+// files being converted return partial results and the rest are not opened,
+// each with ctx's error.
+func ConvertAllContext(ctx context.Context, paths []string, p *pdf.Profile) ([]pdf.FileResult[ConvertResult], error) {
 	results := make([]pdf.FileResult[ConvertResult], len(paths))
 
 	workers := min(runtime.NumCPU(), len(paths))
@@ -78,7 +120,7 @@ func ConvertAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[ConvertResult]
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
-				results[i] = convertFile(paths[i], p)
+				results[i] = convertFile(ctx, paths[i], p)
 			}
 		}()
 	}
@@ -91,14 +133,52 @@ func ConvertAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[ConvertResult]
 	return results, nil
 }
 
-func convertFile(path string, p *pdf.Profile) pdf.FileResult[ConvertResult] {
-	cr, err := Convert(path, p)
+// wholeGraphProfile returns p without an ObjectCacheLimit: conversion
+// rewrites the resolved graph in place, so its verify passes must see that
+// graph rather than page-at-a-time copies of the file.
+func wholeGraphProfile(p *pdf.Profile) *pdf.Profile {
+	if p.ObjectCacheLimit == 0 {
+		return p
+	}
+	p = p.Clone()
+	p.ObjectCacheLimit = 0
+	return p
+}
+
+func convertFile(ctx context.Context, path string, p *pdf.Profile) pdf.FileResult[ConvertResult] {
+	if err := ctx.Err(); err != nil {
+		return pdf.FileResult[ConvertResult]{Path: path, Err: fmt.Errorf("convert: %w", err)}
+	}
+	cr, err := ConvertContext(ctx, path, p)
 	return pdf.FileResult[ConvertResult]{Path: path, Result: cr, Err: err}
 }
 
+// RunContext is Run governed by ctx: once ctx is done, conversion stops and
+// RunContext returns ctx's error with a ConvertResult holding no Output and
+// the Result of the last verification, which may itself be cut short.
+func RunContext(ctx context.Context, doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
+	defer doc.SetContext(doc.SetContext(ctx))
+	return Run(doc, p)
+}
+
 // Run converts an already-open document, the shared implementation behind
-// Convert/ConvertBytes and the facade's (*Document).Convert.
+// Convert/ConvertBytes and the facade's (*Document).Convert, under p's
+// Limits. It stops early as RunContext does once doc's context (see
+// pdf.Reader.SetContext) is done, or once doc exceeds one of the limits,
+// returning the *pdf.LimitError. It resolves doc's whole object graph into
+// memory, whatever p's ObjectCacheLimit: conversion is not page-at-a-time.
 func Run(doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
+	if p.Level.VerifyOnly() {
+		return ConvertResult{}, fmt.Errorf("convert: %s is a verification-only level", p.Level)
+	}
+	if p.IncrementalSave && doc.Encrypted() {
+		return ConvertResult{}, fmt.Errorf("convert: an encrypted document cannot be saved incrementally")
+	}
+	p = wholeGraphProfile(p)
+	defer doc.ApplyLimits(p.Limits)()
+	if err := doc.Err(); err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
 	graph, err := doc.ResolveGraph()
 	if err != nil {
 		res, verr := verify.Verify(doc, p)
@@ -111,18 +191,41 @@ func Run(doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
 	if !ok {
 		return ConvertResult{}, fmt.Errorf("convert: resolved graph is not a dictionary")
 	}
+	var cr ConvertResult
+	// Each verify below renumbers the graph, so an incremental save records
+	// which file object every dict came from while _ref still says so.
+	var origins map[uintptr]pdf.PDFRef
+	if p.IncrementalSave {
+		if headerFits(doc, p) {
+			origins = writer.Origins(trailer)
+		} else {
+			cr.FullRewrite = true
+		}
+	}
 
-	if err := applyPreemptiveFixups(&trailer, doc); err != nil {
+	// cancelled returns what Run has once doc's context is done or it
+	// exceeds a limit: the Result of the last verification and no Output.
+	cancelled := func(err error) (ConvertResult, error) {
+		cr.Output = nil
+		return cr, fmt.Errorf("convert: %w", err)
+	}
+
+	if err := stripEncryption(&trailer, doc, &cr); err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	if err := applyPreemptiveFixups(&trailer, doc, p); err != nil {
 		return ConvertResult{}, fmt.Errorf("convert: pre-emptive fixups: %w", err)
 	}
+	if err := doc.Err(); err != nil {
+		return cancelled(err)
+	}
 
 	// Per-run deviceColourFixer wired to the Reader's concurrent decode cache,
 	// shared with the pre-loop detectColourModelUsage scan.
 	dcFixer := deviceColourFixer{decode: decoderFor(doc)}
-	localFixers := buildLocalFixers(dcFixer, doc)
+	localFixers := buildLocalFixers(dcFixer, doc, p)
 
 	var (
-		cr         ConvertResult
 		prevCounts map[pdf.Check]int
 
 		// graphClean records whether the in-heap graph is byte-for-byte the
@@ -138,6 +241,10 @@ func Run(doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
 		cr.Iterations = iter
 
 		result, parts, objs, err := inHeapVerify(doc, trailer, p)
+		if cerr := doc.Err(); cerr != nil {
+			cr.Result = result
+			return cancelled(cerr)
+		}
 		if err != nil {
 			return ConvertResult{}, fmt.Errorf("convert: %w", err)
 		}
@@ -160,10 +267,13 @@ func Run(doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
 		// straight to the objects their issues reference; everything else runs
 		// its own Fix as before. Sorted order keeps fixer application -- and
 		// with it the whole conversion -- deterministic across runs.
-		pass := &fixPass{trailer: &trailer, objs: objs}
+		pass := &fixPass{trailer: &trailer, objs: objs, model: targetObjectModel(p)}
 		var visitors []func(pdf.PDFDict)
 		batched := map[Fixer]bool{}
 		for _, c := range sortedChecks(counts) {
+			if err := doc.Err(); err != nil {
+				return cancelled(err)
+			}
 			fixer, ok := localFixers[c]
 			if !ok {
 				continue
@@ -212,12 +322,31 @@ func Run(doc *pdf.Reader, p *pdf.Profile) (ConvertResult, error) {
 	}
 
 	if err := rasterBackstop(doc, &trailer, &cr, p, localFixers, &lastParts, &graphClean); err != nil {
+		if cerr := doc.Err(); cerr != nil {
+			return cancelled(cerr)
+		}
 		return ConvertResult{}, fmt.Errorf("convert: %w", err)
 	}
+	if err := doc.Err(); err != nil {
+		return cancelled(err)
+	}
+	if p.Deduplicate {
+		report, err := writer.Dedup(trailer)
+		if err != nil {
+			return ConvertResult{}, fmt.Errorf("convert: %w", err)
+		}
+		cr.Deduplicated, cr.DeduplicatedBytes = report.Objects, report.Bytes
+		if report.Objects > 0 {
+			graphClean = false
+		}
+	}
 
 	// Final serialize + verify against the actual output bytes (structural checks
 	// like xref format must run on the written output, not the original reader).
-	if err := serializeAndVerify(doc, trailer, &cr, p, lastParts, graphClean); err != nil {
+	if err := serializeAndVerify(doc, trailer, origins, &cr, p, lastParts, graphClean); err != nil {
+		if cerr := doc.Err(); cerr != nil {
+			return cancelled(cerr)
+		}
 		return ConvertResult{}, fmt.Errorf("convert: %w", err)
 	}
 	return cr, nil
@@ -232,7 +361,7 @@ func rasterBackstop(doc *pdf.Reader, trailer *pdf.PDFDict, cr *ConvertResult, p
 	if cr.Result.Valid || !hasFixableIssue(cr.Result.Issues, localFixers, false) {
 		return nil
 	}
-	if applyRasterFallback(trailer, cr.Result.Issues) {
+	if applyRasterFallback(doc, trailer, cr.Result.Issues) {
 		cr.Iterations++
 		*graphClean = false
 		result, parts, _, err := inHeapVerify(doc, *trailer, p)
@@ -242,7 +371,7 @@ func rasterBackstop(doc *pdf.Reader, trailer *pdf.PDFDict, cr *ConvertResult, p
 		cr.Result = result
 		*lastParts, *graphClean = parts, true
 	}
-	if !cr.Result.Valid && hasFixableIssue(cr.Result.Issues, localFixers, true) && flattenAllPages(trailer) {
+	if !cr.Result.Valid && hasFixableIssue(cr.Result.Issues, localFixers, true) && flattenAllPages(doc, trailer) {
 		cr.Iterations++
 		*graphClean = false
 		result, parts, _, err := inHeapVerify(doc, *trailer, p)
@@ -259,12 +388,16 @@ func rasterBackstop(doc *pdf.Reader, trailer *pdf.PDFDict, cr *ConvertResult, p
 // by numbering objects and seeding the doc reader directly. It also returns
 // the split issue parts (for serializeAndVerify's merged final verify) and
 // the ObjNum -> object index so the fixer loop can target issues by ref;
-// the index is only valid until the next renumbering.
+// the index is only valid until the next renumbering. A verify cut short by
+// doc's context or limits returns its issues so far as an invalid Result.
 func inHeapVerify(doc *pdf.Reader, trailer pdf.PDFDict, p *pdf.Profile) (pdf.Result, verify.Parts, map[int]pdf.PDFValue, error) {
 	objs := writer.NumberObjects(trailer)
 	doc.SeedResolvedGraph(trailer, objs)
 	parts, err := verify.VerifyParts(doc, p)
 	if err != nil {
+		if doc.Err() != nil {
+			return pdf.Result{Type: p.Level, Issues: parts.Issues()}, parts, objs, err
+		}
 		return pdf.Result{}, verify.Parts{}, nil, err
 	}
 	return verify.ResultFromIssues(p, parts.Issues()), parts, objs, nil
@@ -277,19 +410,28 @@ var fullFinalVerify = os.Getenv("GOPDFRAB_FULL_FINAL_VERIFY") == "1"
 
 // serializeAndVerify serializes trailer and verifies the output bytes,
 // updating cr.Output and cr.Result. Called exactly once at the end of Run.
+// With origins (see writer.Origins) the output is loopDoc's own bytes plus
+// an incremental update, verified from scratch; without, a fresh file.
 // The loop Reader's stream caches carry over: the graph is the same in-heap
 // one, so unchanged streams keep their decoded/tokenized results while
 // rewritten streams miss on their fresh RawStream identity.
 //
-// When the graph is clean -- unchanged since the last inHeapVerify -- the
-// graph-side checks would be a deterministic replay of that verify (the
-// output reader is seeded with the very same graph and stream caches;
-// TestConvertSeededVerifyMatchesFreshVerify pins the equivalence), so only
-// the byte-level structural checks run against the output and lastParts
-// supplies the graph verdicts. A dirty graph gets today's full verify.
-func serializeAndVerify(loopDoc *pdf.Reader, trailer pdf.PDFDict, cr *ConvertResult, p *pdf.Profile, lastParts verify.Parts, graphClean bool) error {
+// When a fresh file's graph is clean -- unchanged since the last
+// inHeapVerify -- the graph-side checks would be a deterministic replay of
+// that verify (the output reader is seeded with the very same graph and
+// stream caches; TestConvertSeededVerifyMatchesFreshVerify pins the
+// equivalence), so only the byte-level structural checks run against the
+// output and lastParts supplies the graph verdicts. A dirty graph gets
+// today's full verify.
+func serializeAndVerify(loopDoc *pdf.Reader, trailer pdf.PDFDict, origins map[uintptr]pdf.PDFRef, cr *ConvertResult, p *pdf.Profile, lastParts verify.Parts, graphClean bool) error {
 	var buf bytes.Buffer
-	order, err := writer.WriteDocumentIndexed(&buf, trailer)
+	var order []pdf.PDFDict
+	var err error
+	if origins != nil {
+		order, err = writer.WriteIncremental(&buf, loopDoc, trailer, origins)
+	} else {
+		order, err = writer.WriteDocumentOptions(&buf, trailer, writerOptions(p))
+	}
 	if err != nil {
 		return err
 	}
@@ -301,21 +443,29 @@ func serializeAndVerify(loopDoc *pdf.Reader, trailer pdf.PDFDict, cr *ConvertRes
 	}
 	defer out.Close()
 
-	objs := make(map[int]pdf.PDFValue, len(order))
-	for i, obj := range order {
-		objs[i+1] = obj
+	if loopDoc != nil {
+		out.SetContext(loopDoc.Context())
+		out.AdoptLimits(loopDoc)
 	}
-	out.AdoptStreamCaches(loopDoc)
-	out.SeedResolvedGraph(trailer, objs)
+	// An incremental update leaves most objects to the original's bytes, which
+	// need not parse to what trailer holds, so only a fresh file is seeded.
+	if origins == nil {
+		objs := make(map[int]pdf.PDFValue, len(order))
+		for _, obj := range order {
+			objs[obj.Entries["_ref"].(pdf.PDFRef).ObjNum] = obj
+		}
+		out.AdoptStreamCaches(loopDoc)
+		out.SeedResolvedGraph(trailer, objs)
 
-	if graphClean && !fullFinalVerify {
-		parts, err := verify.VerifyStructural(out, p)
-		if err != nil {
-			return err
+		if graphClean && !fullFinalVerify {
+			parts, err := verify.VerifyStructural(out, p)
+			if err != nil {
+				return err
+			}
+			parts.Graph = lastParts.Graph
+			cr.Result = verify.ResultFromIssues(p, parts.Issues())
+			return nil
 		}
-		parts.Graph = lastParts.Graph
-		cr.Result = verify.ResultFromIssues(p, parts.Issues())
-		return nil
 	}
 
 	result, err := verify.Verify(out, p)
@@ -327,10 +477,15 @@ func serializeAndVerify(loopDoc *pdf.Reader, trailer pdf.PDFDict, cr *ConvertRes
 }
 
 // buildLocalFixers returns a per-run fixer map with run-scoped instances
-// substituted for the registry singletons: the per-run dcFixer, a
-// fontSubstitutionFixer carrying the run's Reader for cached usage scans,
-// and an appearanceFixer carrying the run's appearance font.
-func buildLocalFixers(dcFixer deviceColourFixer, doc *pdf.Reader) map[pdf.Check]Fixer {
+// substituted for the registry singletons: the per-run dcFixer, the fixers
+// that decode streams carrying the run's Reader (for its limits and, in
+// fontSubstitutionFixer, its cached usage scans), an appearanceFixer
+// carrying the run's appearance font, and the fixers whose repair depends on
+// the target part. For a part 2 or later profile every fixer of an earlier
+// part's check is also keyed by the target part's counterpart (pdf.CheckIn),
+// since the verifier reports carried-over rules in that numbering.
+func buildLocalFixers(dcFixer deviceColourFixer, doc *pdf.Reader, p *pdf.Profile) map[pdf.Check]Fixer {
+	part := targetPart(p)
 	fontSrc := &appearanceFontSource{}
 	local := make(map[pdf.Check]Fixer, len(fixerRegistry))
 	for c, f := range fixerRegistry {
@@ -343,18 +498,132 @@ func buildLocalFixers(dcFixer deviceColourFixer, doc *pdf.Reader) map[pdf.Check]
 			local[c] = trueTypeEncodingFixer{doc: doc}
 		case appearanceFixer:
 			local[c] = appearanceFixer{fontSrc: fontSrc}
+		case transparencyFlattener:
+			local[c] = transparencyFlattener{doc: doc}
+		case contentLimitsFixer:
+			local[c] = contentLimitsFixer{doc: doc}
+		case deviceNColorantsFixer:
+			local[c] = deviceNColorantsFixer{doc: doc}
+		case imageMetadataFixer:
+			local[c] = imageMetadataFixer{doc: doc}
+		case type0FontFixer:
+			local[c] = type0FontFixer{doc: doc}
+		case fontMetricFixer:
+			local[c] = fontMetricFixer{doc: doc}
+		case fontSubsetMetaFixer:
+			local[c] = fontSubsetMetaFixer{doc: doc}
+		case inlineImageLZWFixer:
+			local[c] = inlineImageLZWFixer{doc: doc}
+		case resourceDictPruneFixer:
+			local[c] = resourceDictPruneFixer{doc: doc}
+		case nameTooLongFixer:
+			local[c] = nameTooLongFixer{doc: doc}
+		case cmapCIDClampFixer:
+			local[c] = cmapCIDClampFixer{doc: doc}
+		case lzwStreamFixer:
+			local[c] = lzwStreamFixer{doc: doc}
+		case jpxStreamFixer:
+			local[c] = jpxStreamFixer{doc: doc}
+		case toUnicodeFixer:
+			local[c] = toUnicodeFixer{doc: doc}
+		case extGStateFixer:
+			local[c] = extGStateFixer{part: part}
+		case annotationFlagsFixer:
+			local[c] = annotationFlagsFixer{part: part}
+		case pagesTreeArrayFixer:
+			local[c] = pagesTreeArrayFixer{keepStructure: p.Level.RequiresLogicalStructure()}
+		case fileSpecFixer:
+			local[c] = f
+			if p.Level.AllowsAssociatedFiles() {
+				local[c] = associatedFileFixer{}
+			}
 		default:
 			local[c] = f
 		}
 	}
+	if spec := p.Level.Spec(); part >= 2 {
+		for _, from := range []pdf.Spec{pdf.SpecPDFA1, pdf.SpecPDFA2, pdf.SpecPDFA3} {
+			for _, c := range pdf.ChecksForSpec(from) {
+				f, ok := local[c]
+				if !ok {
+					continue
+				}
+				if c2, ok := pdf.CheckIn(spec, c); ok {
+					if _, taken := local[c2]; !taken {
+						local[c2] = f
+					}
+				}
+			}
+		}
+	}
 	return local
 }
 
+// targetPart returns the ISO 19005 part Run converts to under p. A profile
+// not tied to a PDF/A part (ObjectModel) converts to PDF/A-1, as it always
+// has.
+func targetPart(p *pdf.Profile) int {
+	if p == nil || p.Level.Part() == 0 {
+		return 1
+	}
+	return p.Level.Part()
+}
+
+// targetConformance returns the pdfaid:conformance value Run's output
+// claims under p: the level's own letter, if any, or B for a profile that is
+// not a PDF/A level.
+func targetConformance(p *pdf.Profile) string {
+	if p == nil || p.Level.Part() == 0 {
+		return "B"
+	}
+	return p.Level.Conformance()
+}
+
+// targetObjectModel returns the Arlington object model the verifier checks
+// Run's output against under p: PDF 2.0 for a PDF/A-4 target, else PDF 1.4.
+func targetObjectModel(p *pdf.Profile) *arlington.Model {
+	if targetPart(p) >= 4 {
+		return arlington.PDF20
+	}
+	return arlington.PDF14
+}
+
+// writerOptions returns the serialization options for Run's output under p:
+// a PDF/A-4 file header shall declare PDF 2.0 (ISO 19005-4 6.1.2), and from
+// PDF/A-2, whose base is PDF 1.7, objects are packed into object streams.
+// PDF/A-1 stays on PDF 1.4, which has none, and so does the object model
+// unless the profile asks for object streams, which the writer then
+// declares PDF 1.5 for. A profile asking for linearized output gets it.
+func writerOptions(p *pdf.Profile) writer.Options {
+	var opts writer.Options
+	switch part := targetPart(p); {
+	case part >= 4:
+		opts = writer.Options{Version: "2.0", ObjectStreams: true}
+	case part >= 2:
+		opts = writer.Options{Version: "1.7", ObjectStreams: true}
+	case p != nil && p.Level.Part() == 0:
+		opts.ObjectStreams = p.ObjectStreams
+	}
+	opts.Linearize = p != nil && p.Linearize
+	return opts
+}
+
+// headerFits reports whether doc's file header, which an incremental update
+// keeps, declares a version p's output may have: a PDF/A-4 file header
+// shall declare PDF 2.n (ISO 19005-4 6.1.2), where writerOptions writes 2.0.
+func headerFits(doc *pdf.Reader, p *pdf.Profile) bool {
+	if targetPart(p) < 4 {
+		return true
+	}
+	v, err := doc.GetVersion()
+	return err == nil && strings.HasPrefix(v, "2.")
+}
+
 // applyRasterFallback rebuilds every page carrying a residual issue as a flat
 // raster image (flattenPageToImage), the last-resort remediation for content
 // no targeted fixer could repair. Page numbers in issues align with the
 // graph's page order, since both come from the same Root/Pages/Kids walk.
-func applyRasterFallback(trailer *pdf.PDFDict, issues []pdf.PDFError) bool {
+func applyRasterFallback(doc *pdf.Reader, trailer *pdf.PDFDict, issues []pdf.PDFError) bool {
 	pages := orderedPages(*trailer)
 	flag := map[int]bool{}
 	for _, iss := range issues {
@@ -373,20 +642,23 @@ func applyRasterFallback(trailer *pdf.PDFDict, issues []pdf.PDFError) bool {
 			flagged = append(flagged, pages[i])
 		}
 	}
-	return flattenPagesParallel(flagged)
+	return flattenPagesParallel(doc, flagged)
 }
 
 // flattenAllPages rasterizes every page, the final backstop for residuals that
 // applyRasterFallback can't target -- document-level violations with no page
 // number, or anything its page-by-page pass left behind.
-func flattenAllPages(trailer *pdf.PDFDict) bool {
-	return flattenPagesParallel(orderedPages(*trailer))
+func flattenAllPages(doc *pdf.Reader, trailer *pdf.PDFDict) bool {
+	return flattenPagesParallel(doc, orderedPages(*trailer))
 }
 
 // flattenPagesParallel rasterizes distinct pages on a bounded worker pool;
 // each render mutates only its own page dict while reading the shared graph,
-// the same access pattern transparencyFlattener's workers rely on.
-func flattenPagesParallel(pages []pageTarget) bool {
+// the same access pattern transparencyFlattener's workers rely on. Pages
+// render under doc's limits, and once doc's work should stop (see
+// pdf.Reader.Err), the pages not yet started are left as they are. doc may
+// be nil.
+func flattenPagesParallel(doc *pdf.Reader, pages []pageTarget) bool {
 	seen := map[uintptr]bool{}
 	var unique []pageTarget
 	for _, p := range pages {
@@ -410,8 +682,11 @@ func flattenPagesParallel(pages []pageTarget) bool {
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
+				if doc.Err() != nil {
+					continue
+				}
 				p := unique[i]
-				results[i] = flattenPageToImage(p.dict, p.resources, p.mediaBox)
+				results[i] = flattenPageToImage(p.dict, p.resources, p.mediaBox, doc)
 			}
 		}()
 	}
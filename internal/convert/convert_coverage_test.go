@@ -131,7 +131,7 @@ func TestRasterBackstopSkipsUnfixableIssues(t *testing.T) {
 func TestSerializeAndVerifyRejectsBadProfile(t *testing.T) {
 	for _, clean := range []bool{true, false} {
 		cr := &ConvertResult{}
-		err := serializeAndVerify(nil, onePageTrailer(), cr, nil, verify.Parts{}, clean)
+		err := serializeAndVerify(nil, onePageTrailer(), nil, cr, nil, verify.Parts{}, clean)
 		if err == nil {
 			t.Errorf("serializeAndVerify(nil profile, graphClean=%v) did not error", clean)
 		}
@@ -171,7 +171,7 @@ func TestRunWrapsSerializeError(t *testing.T) {
 func TestApplyPreemptiveFixupsAfterFixupError(t *testing.T) {
 	old := preemptiveAfterFixups
 	t.Cleanup(func() { preemptiveAfterFixups = old })
-	preemptiveAfterFixups = append(slices.Clone(old), func(*pdf.PDFDict, *pdf.Reader) error {
+	preemptiveAfterFixups = append(slices.Clone(old), func(*pdf.PDFDict, *pdf.Reader, *pdf.Profile) error {
 		return errors.New("after fixup failed")
 	})
 
@@ -181,7 +181,7 @@ func TestApplyPreemptiveFixupsAfterFixupError(t *testing.T) {
 		t.Fatalf("ResolveGraph: %v", err)
 	}
 	trailer := g.(pdf.PDFDict)
-	if err := applyPreemptiveFixups(&trailer, doc); err == nil || !strings.Contains(err.Error(), "after fixup failed") {
+	if err := applyPreemptiveFixups(&trailer, doc, pdf.PDFA_1B); err == nil || !strings.Contains(err.Error(), "after fixup failed") {
 		t.Errorf("applyPreemptiveFixups err = %v, want the after-fixup failure", err)
 	}
 }
@@ -191,16 +191,16 @@ func TestApplyPreemptiveFixupsAfterFixupError(t *testing.T) {
 // FontFile2, and an undecodable program all leave the dict untouched.
 func TestPromoteEmptyGlyphsInFontGuards(t *testing.T) {
 	notCID := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Subtype": pdf.PDFName{Value: "TrueType"}}}
-	promoteEmptyGlyphsInFont(notCID)
+	promoteEmptyGlyphsInFont(notCID, nil)
 
 	noDesc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Subtype": pdf.PDFName{Value: "CIDFontType2"}}}
-	promoteEmptyGlyphsInFont(noDesc)
+	promoteEmptyGlyphsInFont(noDesc, nil)
 
 	noFF := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"Subtype":        pdf.PDFName{Value: "CIDFontType2"},
 		"FontDescriptor": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}},
 	}}
-	promoteEmptyGlyphsInFont(noFF)
+	promoteEmptyGlyphsInFont(noFF, nil)
 
 	streamless := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"Subtype": pdf.PDFName{Value: "CIDFontType2"},
@@ -208,7 +208,7 @@ func TestPromoteEmptyGlyphsInFontGuards(t *testing.T) {
 			"FontFile2": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}},
 		}},
 	}}
-	promoteEmptyGlyphsInFont(streamless)
+	promoteEmptyGlyphsInFont(streamless, nil)
 
 	badFF := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"Filter": pdf.PDFName{Value: "NoSuchFilter"},
@@ -219,7 +219,7 @@ func TestPromoteEmptyGlyphsInFontGuards(t *testing.T) {
 			"FontFile2": badFF,
 		}},
 	}}
-	promoteEmptyGlyphsInFont(undecodable)
+	promoteEmptyGlyphsInFont(undecodable, nil)
 	if string(badFF.RawStream) != "junk" {
 		t.Error("undecodable FontFile2 was rewritten")
 	}
@@ -303,3 +303,299 @@ func TestPlaceholderImageDegenerate(t *testing.T) {
 		t.Errorf("placeholderImage pixel = %d,%d,%d, want uniform gray", r, g, b)
 	}
 }
+
+// transparentOCTrailer is onePageTrailer with a transparency group, a
+// half-opaque ExtGState and optional content whose default configuration
+// has no Name -- all of which PDF/A-2 keeps and PDF/A-1 cannot.
+func transparentOCTrailer() pdf.PDFDict {
+	trailer := onePageTrailer()
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	pages := root.Entries["Pages"].(pdf.PDFDict)
+	page := pages.Entries["Kids"].(pdf.PDFArray)[0].(pdf.PDFDict)
+	pages.Entries["Count"] = pdf.PDFInteger(1)
+	pages.Entries["_ref"] = pdf.PDFRef{ObjNum: 2}
+	page.Entries["Parent"] = pages
+	page.Entries["_ref"] = pdf.PDFRef{ObjNum: 3}
+
+	gs := pdf.NewPDFDict()
+	gs.Entries["Type"] = pdf.PDFName{Value: "ExtGState"}
+	gs.Entries["ca"] = pdf.PDFReal(0.5)
+	gs.Entries["BM"] = pdf.PDFName{Value: "Multiply"}
+	group := pdf.NewPDFDict()
+	group.Entries["S"] = pdf.PDFName{Value: "Transparency"}
+	page.Entries["Group"] = group
+	page.Entries["Resources"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"ExtGState": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"GS0": gs}},
+	}}
+	page.Entries["Contents"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: []byte("/GS0 gs 1 0 0 rg 0 0 10 10 re f")}
+
+	ocg := pdf.NewPDFDict()
+	ocg.Entries["Type"] = pdf.PDFName{Value: "OCG"}
+	ocg.Entries["Name"] = pdf.PDFString{Value: "Layer"}
+	dflt := pdf.NewPDFDict()
+	dflt.Entries["Order"] = pdf.PDFArray{}
+	root.Entries["OCProperties"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"OCGs": pdf.PDFArray{ocg},
+		"D":    dflt,
+	}}
+	return trailer
+}
+
+// TestConvertPDFA2BKeepsTransparencyAndOptionalContent converts to PDF/A-2b
+// and confirms the output claims part 2 while keeping the transparency
+// group, the ExtGState's alpha and blend mode, and the optional content
+// (repaired rather than stripped).
+func TestConvertPDFA2BKeepsTransparencyAndOptionalContent(t *testing.T) {
+	cr, err := Run(openTrailer(t, transparentOCTrailer()), pdf.PDFA_2B)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("PDF/A-2b conversion left residuals: %v", cr.Residual())
+	}
+
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	if part, conf, err := out.ClaimedConformance(); err != nil || part != "2" || conf != "B" {
+		t.Errorf("ClaimedConformance = %q, %q, %v; want 2, B", part, conf, err)
+	}
+	g, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	root := g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict)
+	page := root.Entries["Pages"].(pdf.PDFDict).Entries["Kids"].(pdf.PDFArray)[0].(pdf.PDFDict)
+	if _, ok := page.Entries["Group"].(pdf.PDFDict); !ok {
+		t.Error("page transparency group was removed")
+	}
+	gs := page.Entries["Resources"].(pdf.PDFDict).Entries["ExtGState"].(pdf.PDFDict).Entries["GS0"].(pdf.PDFDict)
+	if ca, _ := verify.AsFloat(gs.Entries["ca"]); ca != 0.5 {
+		t.Errorf("ExtGState ca = %v, want 0.5 kept", gs.Entries["ca"])
+	}
+	if (gs.Entries["BM"] != pdf.PDFName{Value: "Multiply"}) {
+		t.Errorf("ExtGState BM = %v, want Multiply kept", gs.Entries["BM"])
+	}
+	oc, ok := root.Entries["OCProperties"].(pdf.PDFDict)
+	if !ok {
+		t.Fatal("OCProperties was removed")
+	}
+	dflt := oc.Entries["D"].(pdf.PDFDict)
+	if dflt.Entries["Name"] == nil {
+		t.Error("default OC configuration was not named")
+	}
+	if order, _ := dflt.Entries["Order"].(pdf.PDFArray); len(order) != 1 {
+		t.Errorf("default OC configuration Order = %v, want the one OCG", order)
+	}
+}
+
+// TestConvertPDFA2UClaimsLevelU converts to PDF/A-2u and confirms the
+// output identifies itself as level U.
+func TestConvertPDFA2UClaimsLevelU(t *testing.T) {
+	cr, err := Run(openTrailer(t, transparentOCTrailer()), pdf.PDFA_2U)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("PDF/A-2u conversion left residuals: %v", cr.Residual())
+	}
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	if part, conf, err := out.ClaimedConformance(); err != nil || part != "2" || conf != "U" {
+		t.Errorf("ClaimedConformance = %q, %q, %v; want 2, U", part, conf, err)
+	}
+}
+
+func TestConvertRejectsVerifyOnlyLevels(t *testing.T) {
+	for _, p := range []*pdf.Profile{pdf.PDFUA_1, pdf.PDFX_1A, pdf.PDFX_4} {
+		if _, err := Run(openTrailer(t, transparentOCTrailer()), p); err == nil {
+			t.Errorf("Run(%s) succeeded; %s is verification-only", p.Level, p.Level)
+		}
+	}
+}
+
+// attachmentTrailer returns a one-page document embedding an XML file whose
+// specification lacks every key PDF/A-3 requires of an associated file.
+func attachmentTrailer() pdf.PDFDict {
+	trailer := transparentOCTrailer()
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	delete(root.Entries, "OCProperties")
+	stm := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "EmbeddedFile"}}, HasStream: true, RawStream: []byte("<Invoice/>")}
+	spec := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type": pdf.PDFName{Value: "Filespec"},
+		"F":    pdf.PDFString{Value: "invoice.xml"},
+		"EF":   pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F": stm}},
+	}}
+	root.Entries["Names"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"EmbeddedFiles": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"Names": pdf.PDFArray{pdf.PDFString{Value: "invoice.xml"}, spec},
+		}},
+	}}
+	return trailer
+}
+
+// TestConvertPDFA3BKeepsAttachments converts to PDF/A-3b and confirms the
+// embedded file survives as an associated file, where PDF/A-1b drops it.
+func TestConvertPDFA3BKeepsAttachments(t *testing.T) {
+	cr, err := Run(openTrailer(t, attachmentTrailer()), pdf.PDFA_3B)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("PDF/A-3b conversion left residuals: %v", cr.Residual())
+	}
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	if part, _, err := out.ClaimedConformance(); err != nil || part != "3" {
+		t.Errorf("ClaimedConformance part = %q, %v; want 3", part, err)
+	}
+	g, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	root := g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict)
+	af, _ := root.Entries["AF"].(pdf.PDFArray)
+	if len(af) != 1 {
+		t.Fatalf("catalog AF = %v, want the attachment", af)
+	}
+	stm := af[0].(pdf.PDFDict).Entries["EF"].(pdf.PDFDict).Entries["F"].(pdf.PDFDict)
+	if data, err := pdf.DecodeStream(stm); err != nil || string(data) != "<Invoice/>" {
+		t.Errorf("embedded file = %q, %v; want the original XML", data, err)
+	}
+
+	cr, err = Run(openTrailer(t, attachmentTrailer()), pdf.PDFA_1B)
+	if err != nil {
+		t.Fatalf("Run(A-1b): %v", err)
+	}
+	if bytes.Contains(cr.Output, []byte("/EmbeddedFiles")) {
+		t.Error("PDF/A-1b conversion kept the embedded file")
+	}
+}
+
+// TestConvertPDFA4 converts to PDF/A-4 and confirms the PDF 2.0 header, the
+// part 4 identifier and the removal of an Info dictionary the catalog does not
+// justify with PieceInfo; PDF/A-4f additionally keeps the attachment.
+func TestConvertPDFA4(t *testing.T) {
+	trailer := attachmentTrailer()
+	trailer.Entries["Info"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Title": pdf.PDFString{Value: "Invoice"},
+	}}
+	trailer.Entries["Root"].(pdf.PDFDict).Entries["Version"] = pdf.PDFName{Value: "1.7"}
+
+	for _, tc := range []struct {
+		profile     *pdf.Profile
+		conformance string
+		attachment  bool
+	}{
+		{pdf.PDFA_4, "", false},
+		{pdf.PDFA_4F, "F", true},
+	} {
+		t.Run(string(tc.profile.Level), func(t *testing.T) {
+			cr, err := Run(openTrailer(t, trailer), tc.profile)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if !cr.Result.Valid {
+				t.Fatalf("conversion left residuals: %v", cr.Residual())
+			}
+			if !bytes.HasPrefix(cr.Output, []byte("%PDF-2.0")) {
+				t.Errorf("header = %q, want %%PDF-2.0", cr.Output[:8])
+			}
+			out, err := pdf.OpenBytes(cr.Output)
+			if err != nil {
+				t.Fatalf("OpenBytes(output): %v", err)
+			}
+			defer out.Close()
+			part, conformance, err := out.ClaimedConformance()
+			if err != nil || part != "4" || conformance != tc.conformance {
+				t.Errorf("ClaimedConformance = %q, %q, %v; want 4, %q", part, conformance, err, tc.conformance)
+			}
+			if !bytes.Contains(cr.Output, []byte("<pdfaid:rev>2020</pdfaid:rev>")) {
+				t.Error("XMP packet lacks pdfaid:rev")
+			}
+			g, err := out.ResolveGraph()
+			if err != nil {
+				t.Fatalf("ResolveGraph: %v", err)
+			}
+			if g.(pdf.PDFDict).Entries["Info"] != nil {
+				t.Error("Info dictionary kept without PieceInfo")
+			}
+			names, _ := g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict).Entries["Names"].(pdf.PDFDict)
+			if _, got := names.Entries["EmbeddedFiles"]; got != tc.attachment {
+				t.Errorf("attachment kept = %v, want %v", got, tc.attachment)
+			}
+		})
+	}
+}
+
+// TestConvertPDFA1AKeepsStructure converts a tagged document with broken
+// bookkeeping to PDF/A-1a and confirms the output claims level A with its
+// structure tree kept and repaired, where PDF/A-1b leaves it untouched.
+func TestConvertPDFA1AKeepsStructure(t *testing.T) {
+	tagged := func() pdf.PDFDict {
+		trailer := onePageTrailer()
+		root := trailer.Entries["Root"].(pdf.PDFDict)
+		pages := root.Entries["Pages"].(pdf.PDFDict)
+		pages.Entries["Count"] = pdf.PDFInteger(1)
+		pages.Entries["_ref"] = pdf.PDFRef{ObjNum: 2}
+		pages.Entries["Kids"].(pdf.PDFArray)[0].(pdf.PDFDict).Entries["Parent"] = pages
+
+		st := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "StructTreeRoot"}, "_ref": pdf.PDFRef{ObjNum: 3}}}
+		doc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"S": pdf.PDFName{Value: "Document"}, "P": st, "_ref": pdf.PDFRef{ObjNum: 4}}}
+		doc.Entries["K"] = pdf.PDFArray{pdf.PDFDict{Entries: map[string]pdf.PDFValue{"S": pdf.PDFName{Value: "Para"}, "P": doc}}}
+		st.Entries["K"] = doc
+		root.Entries["StructTreeRoot"] = st
+		root.Entries["Lang"] = pdf.PDFString{Value: "en_US"}
+		return trailer
+	}
+
+	cr, err := Run(openTrailer(t, tagged()), pdf.PDFA_1A)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("PDF/A-1a conversion left residuals: %v", cr.Residual())
+	}
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	if part, conf, err := out.ClaimedConformance(); err != nil || part != "1" || conf != "A" {
+		t.Errorf("ClaimedConformance = %q, %q, %v; want 1, A", part, conf, err)
+	}
+	g, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	root := g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict)
+	st, ok := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	if !ok {
+		t.Fatal("structure tree was removed")
+	}
+	if roleMap, _ := st.Entries["RoleMap"].(pdf.PDFDict); (roleMap.Entries["Para"] != pdf.PDFName{Value: "NonStruct"}) {
+		t.Errorf("RoleMap = %v, want /Para mapped to NonStruct", st.Entries["RoleMap"])
+	}
+	if marked, _ := root.Entries["MarkInfo"].(pdf.PDFDict).Entries["Marked"].(pdf.PDFBoolean); !marked {
+		t.Error("MarkInfo /Marked not set")
+	}
+	if lang := pdf.DecodeInfoTextString(root.Entries["Lang"]); lang != "en-US" {
+		t.Errorf("Lang = %q, want en-US", lang)
+	}
+
+	cr, err = Run(openTrailer(t, tagged()), pdf.PDFA_1B)
+	if err != nil {
+		t.Fatalf("Run(A-1b): %v", err)
+	}
+	if bytes.Contains(cr.Output, []byte("/RoleMap")) || bytes.Contains(cr.Output, []byte("/Marked")) {
+		t.Error("PDF/A-1b conversion applied level A repairs")
+	}
+}
@@ -0,0 +1,80 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// stripEncryption prepares the graph of an encrypted source for unencrypted
+// output: the Reader already decrypted every string and stream as it read
+// them, so all that remains is to drop the trailer's Encrypt dictionary and
+// the Crypt filters naming the source's crypt filters. It records the
+// source's declared permissions in cr. A source whose security handler the
+// Reader could not open is an error: its strings and streams are still
+// ciphertext, and writing them without the Encrypt dictionary would lose
+// the document.
+func stripEncryption(trailer *pdf.PDFDict, doc *pdf.Reader, cr *ConvertResult) error {
+	if trailer.Entries["Encrypt"] == nil {
+		return nil
+	}
+	if !doc.Encrypted() {
+		return fmt.Errorf("document is encrypted with an unsupported security handler")
+	}
+	delete(trailer.Entries, "Encrypt")
+	cr.Decrypted = true
+	cr.Permissions, _ = doc.Permissions()
+
+	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
+		if !d.HasStream {
+			return d, false
+		}
+		return d, dropCryptFilter(d)
+	})
+	return nil
+}
+
+// dropCryptFilter removes the Crypt entries from a stream's Filter and the
+// matching DecodeParms, reporting whether it found any.
+func dropCryptFilter(d pdf.PDFDict) bool {
+	names := pdf.FilterNames(d.Entries["Filter"])
+	parms, _ := d.Entries["DecodeParms"].(pdf.PDFArray)
+	var filters, keptParms pdf.PDFArray
+	dropped := false
+	for i, name := range names {
+		if name == "Crypt" {
+			dropped = true
+			continue
+		}
+		filters = append(filters, pdf.PDFName{Value: name})
+		if parms != nil {
+			var p pdf.PDFValue
+			if i < len(parms) {
+				p = parms[i]
+			}
+			keptParms = append(keptParms, p)
+		}
+	}
+	if !dropped {
+		return false
+	}
+	switch len(filters) {
+	case 0:
+		delete(d.Entries, "Filter")
+		delete(d.Entries, "DecodeParms")
+	case 1:
+		d.Entries["Filter"] = filters[0]
+		if parms != nil {
+			d.Entries["DecodeParms"] = keptParms[0]
+		}
+	default:
+		d.Entries["Filter"] = filters
+		if parms != nil {
+			d.Entries["DecodeParms"] = keptParms
+		}
+	}
+	if d.Entries["DecodeParms"] == nil {
+		delete(d.Entries, "DecodeParms")
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// TestRunDecryptsSource converts an RC4-encrypted corpus file (user password
+// empty, P -3904: every permission withheld) and checks the output is
+// written in the clear and the permissions are recorded.
+func TestRunDecryptsSource(t *testing.T) {
+	path := "../../tests/Isartor/PDFA-1b/6.1 File structure/6.1.3 File trailer/isartor-6-1-3-t02-fail-a.pdf"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip("Isartor suite not present")
+	}
+	cr, err := Convert(path, pdf.PDFA_1B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cr.Result.Valid {
+		t.Errorf("residual: %v", issueClauses(cr.Residual()))
+	}
+	if !cr.Decrypted || cr.Permissions != 0 || len(cr.Permissions.Restricted()) != 8 {
+		t.Errorf("Decrypted = %v, Permissions = %b; want true, all withheld", cr.Decrypted, cr.Permissions)
+	}
+
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if out.Encrypted() || out.EffectiveTrailer().Entries["Encrypt"] != nil {
+		t.Error("output is still encrypted")
+	}
+	if _, ok := out.Permissions(); ok {
+		t.Error("output declares permissions")
+	}
+	graph, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := graph.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict)
+	xmp, err := pdf.DecodeStream(root.Entries["Metadata"].(pdf.PDFDict))
+	if err != nil || !bytes.Contains(xmp, []byte("x:xmpmeta")) {
+		t.Errorf("metadata is not plaintext: %.40q, %v", xmp, err)
+	}
+}
+
+// TestRunRefusesIncrementalSaveOfEncrypted checks that IncrementalSave of
+// an encrypted source is refused up front: the update could not be
+// encrypted, and conversion writes its output in the clear.
+func TestRunRefusesIncrementalSaveOfEncrypted(t *testing.T) {
+	path := "../../tests/Isartor/PDFA-1b/6.1 File structure/6.1.3 File trailer/isartor-6-1-3-t02-fail-a.pdf"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip("Isartor suite not present")
+	}
+	p := pdf.PDFA_1B.Clone()
+	p.IncrementalSave = true
+	if _, err := Convert(path, p); err == nil || !strings.Contains(err.Error(), "incrementally") {
+		t.Errorf("Convert = %v, want the incremental save refused", err)
+	}
+}
+
+// TestRunRejectsUnsupportedEncryption checks a source the Reader cannot
+// decrypt is refused rather than written out as ciphertext.
+func TestRunRejectsUnsupportedEncryption(t *testing.T) {
+	trailer := minimalTrailer()
+	var buf bytes.Buffer
+	if err := writer.WriteDocument(&buf, trailer); err != nil {
+		t.Fatal(err)
+	}
+	// The trailer follows the xref table, so offsets stay valid.
+	data := strings.Replace(buf.String(), "trailer\n<<", "trailer\n<< /Encrypt << /Filter /ExampleSec /V 2 >>", 1)
+	if _, err := ConvertBytes([]byte(data), pdf.PDFA_1B); err == nil || !strings.Contains(err.Error(), "unsupported security handler") {
+		t.Errorf("ConvertBytes error = %v, want unsupported security handler", err)
+	}
+}
+
+func TestDropCryptFilter(t *testing.T) {
+	identity := pdf.NewPDFDict()
+	identity.Entries["Name"] = pdf.PDFName{Value: "Identity"}
+	predictor := pdf.NewPDFDict()
+	predictor.Entries["Predictor"] = pdf.PDFInteger(12)
+	crypt := pdf.PDFName{Value: "Crypt"}
+	flate := pdf.PDFName{Value: "FlateDecode"}
+
+	for _, tc := range []struct {
+		name                 string
+		filter, parms        pdf.PDFValue
+		wantFilter, wantParm pdf.PDFValue
+		want                 bool
+	}{
+		{"Crypt only", crypt, identity, nil, nil, true},
+		{"Crypt array", pdf.PDFArray{crypt}, pdf.PDFArray{identity}, nil, nil, true},
+		{"Crypt then Flate", pdf.PDFArray{crypt, flate}, pdf.PDFArray{identity, predictor}, flate, predictor, true},
+		{"Crypt then Flate, no parms", pdf.PDFArray{crypt, flate}, nil, flate, nil, true},
+		{"no Crypt", flate, predictor, flate, predictor, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := pdf.NewPDFDict()
+			d.HasStream = true
+			d.Entries["Filter"] = tc.filter
+			if tc.parms != nil {
+				d.Entries["DecodeParms"] = tc.parms
+			}
+			if got := dropCryptFilter(d); got != tc.want {
+				t.Errorf("dropCryptFilter = %v, want %v", got, tc.want)
+			}
+			if !reflect.DeepEqual(d.Entries["Filter"], tc.wantFilter) || !reflect.DeepEqual(d.Entries["DecodeParms"], tc.wantParm) {
+				t.Errorf("Filter, DecodeParms = %v, %v; want %v, %v", d.Entries["Filter"], d.Entries["DecodeParms"], tc.wantFilter, tc.wantParm)
+			}
+		})
+	}
+}
@@ -56,39 +56,41 @@ func registerFixer(f Fixer) {
 	}
 }
 
-var preemptiveFixups []func(trailer *pdf.PDFDict, doc *pdf.Reader) error
+// Pre-emptive fixups receive the conversion's target profile, for the few
+// whose repair depends on the PDF/A part (e.g. the XMP identifier).
+var preemptiveFixups []func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error
 
-func registerPreemptiveFixup(f func(trailer *pdf.PDFDict, doc *pdf.Reader) error) {
+func registerPreemptiveFixup(f func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error) {
 	preemptiveFixups = append(preemptiveFixups, f)
 }
 
 // preemptiveVisitors holds pre-emptive fixups expressed as per-dict visitors.
 // applyPreemptiveFixups drives all of them over the graph in one shared walk
 // instead of one full walk each; a prepare returning nil opts out of the pass.
-var preemptiveVisitors []func(trailer *pdf.PDFDict, doc *pdf.Reader) func(pdf.PDFDict)
+var preemptiveVisitors []func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) func(pdf.PDFDict)
 
-func registerPreemptiveVisitor(f func(trailer *pdf.PDFDict, doc *pdf.Reader) func(pdf.PDFDict)) {
+func registerPreemptiveVisitor(f func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) func(pdf.PDFDict)) {
 	preemptiveVisitors = append(preemptiveVisitors, f)
 }
 
 // preemptiveAfterFixups run after the shared visitor walk, for fixups that
 // must observe the visitors' edits (e.g. dropOversizedStructure must not see
 // Kids arrays the rebalance visitor is able to split).
-var preemptiveAfterFixups []func(trailer *pdf.PDFDict, doc *pdf.Reader) error
+var preemptiveAfterFixups []func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error
 
-func registerPreemptiveAfterFixup(f func(trailer *pdf.PDFDict, doc *pdf.Reader) error) {
+func registerPreemptiveAfterFixup(f func(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error) {
 	preemptiveAfterFixups = append(preemptiveAfterFixups, f)
 }
 
-func applyPreemptiveFixups(trailer *pdf.PDFDict, doc *pdf.Reader) error {
+func applyPreemptiveFixups(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error {
 	for _, f := range preemptiveFixups {
-		if err := f(trailer, doc); err != nil {
+		if err := f(trailer, doc, p); err != nil {
 			return err
 		}
 	}
 	var visitors []func(pdf.PDFDict)
 	for _, prepare := range preemptiveVisitors {
-		if visit := prepare(trailer, doc); visit != nil {
+		if visit := prepare(trailer, doc, p); visit != nil {
 			visitors = append(visitors, visit)
 		}
 	}
@@ -100,7 +102,7 @@ func applyPreemptiveFixups(trailer *pdf.PDFDict, doc *pdf.Reader) error {
 		})
 	}
 	for _, f := range preemptiveAfterFixups {
-		if err := f(trailer, doc); err != nil {
+		if err := f(trailer, doc, p); err != nil {
 			return err
 		}
 	}
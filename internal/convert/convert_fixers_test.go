@@ -38,13 +38,13 @@ func TestApplyPreemptiveFixupsPropagatesError(t *testing.T) {
 
 	wantErr := errors.New("boom")
 	ranSecond := false
-	preemptiveFixups = []func(*pdf.PDFDict, *pdf.Reader) error{
-		func(*pdf.PDFDict, *pdf.Reader) error { return wantErr },
-		func(*pdf.PDFDict, *pdf.Reader) error { ranSecond = true; return nil },
+	preemptiveFixups = []func(*pdf.PDFDict, *pdf.Reader, *pdf.Profile) error{
+		func(*pdf.PDFDict, *pdf.Reader, *pdf.Profile) error { return wantErr },
+		func(*pdf.PDFDict, *pdf.Reader, *pdf.Profile) error { ranSecond = true; return nil },
 	}
 
 	trailer := pdf.NewPDFDict()
-	if err := applyPreemptiveFixups(&trailer, nil); err != wantErr {
+	if err := applyPreemptiveFixups(&trailer, nil, pdf.PDFA_1B); err != wantErr {
 		t.Errorf("applyPreemptiveFixups error = %v, want %v", err, wantErr)
 	}
 	if ranSecond {
@@ -3,6 +3,7 @@ package convert
 import (
 	"sort"
 
+	"github.com/voidrab/gopdfrab/internal/arlington"
 	"github.com/voidrab/gopdfrab/internal/pdf"
 )
 
@@ -23,6 +24,10 @@ type fixPass struct {
 	trailer *pdf.PDFDict
 	objs    map[int]pdf.PDFValue
 
+	// model is the Arlington object model the target part is verified
+	// against (see objectModel); nil means PDF 1.4.
+	model *arlington.Model
+
 	// parents maps a dict's Entries identity to every graph slot holding it,
 	// lazily built on first replaceObject call and shared for the iteration.
 	parents map[uintptr][]parentSlot
@@ -37,6 +42,15 @@ type fixPass struct {
 // so every referencing slot must be rewritten (see walkStreamDicts).
 type parentSlot func(pdf.PDFDict)
 
+// objectModel returns the Arlington object model the object-model fixers
+// repair against, matching the verifier's for the target part.
+func (p *fixPass) objectModel() *arlington.Model {
+	if p == nil || p.model == nil {
+		return arlington.PDF14
+	}
+	return p.model
+}
+
 // dictForRef resolves ref against the iteration index. ok is false unless the
 // numbered object exists, is a dict, and still carries the matching _ref.
 func (p *fixPass) dictForRef(ref pdf.PDFRef) (pdf.PDFDict, bool) {
@@ -0,0 +1,200 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// Factur-X / ZUGFeRD hybrid invoices: ConvertInvoice embeds a Cross
+// Industry Invoice XML file in a PDF and converts the result to PDF/A-3,
+// the visual invoice and its machine-readable twin in one file. The
+// embedding happens on the resolved graph before Run's pipeline starts;
+// from there the invoice is an ordinary associated file, which
+// associatedFileFixer keeps, and regenerateXMP describes it in the fx:
+// properties (see invoiceXMP).
+
+// invoiceFileName is the name ConvertInvoice embeds the invoice under, the
+// one Factur-X and ZUGFeRD 2.1 onwards share.
+const invoiceFileName = "factur-x.xml"
+
+// ConvertInvoice reads the PDF at path, embeds invoice, a Cross Industry
+// Invoice XML document, as its Factur-X / ZUGFeRD invoice and converts the
+// result towards p, a PDF/A-3 profile (typically pdf.FacturX).
+func ConvertInvoice(path string, invoice []byte, p *pdf.Profile) (ConvertResult, error) {
+	doc, err := pdf.Open(path)
+	if err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	defer doc.Close()
+	return RunInvoice(doc, invoice, p)
+}
+
+// ConvertInvoiceBytes is ConvertInvoice for an in-memory PDF.
+func ConvertInvoiceBytes(data, invoice []byte, p *pdf.Profile) (ConvertResult, error) {
+	doc, err := pdf.OpenBytes(data)
+	if err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	defer doc.Close()
+	return RunInvoice(doc, invoice, p)
+}
+
+// RunInvoice is ConvertInvoice for an already-open document. Any invoice
+// the document already embeds is replaced.
+func RunInvoice(doc *pdf.Reader, invoice []byte, p *pdf.Profile) (ConvertResult, error) {
+	if p.Level.Part() != 3 {
+		return ConvertResult{}, fmt.Errorf("convert: a Factur-X invoice is a PDF/A-3 file; %s is not a PDF/A-3 level", p.Level)
+	}
+	p = wholeGraphProfile(p)
+	level, err := verify.InvoiceConformanceLevel(invoice)
+	if err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	trailer, ok := graph.(pdf.PDFDict)
+	if !ok {
+		return ConvertResult{}, fmt.Errorf("convert: resolved graph is not a dictionary")
+	}
+	if err := attachInvoice(trailer, invoice, level); err != nil {
+		return ConvertResult{}, fmt.Errorf("convert: %w", err)
+	}
+	// The graph is the Reader's cached one, so Run converts it with the
+	// invoice attached.
+	return Run(doc, p)
+}
+
+// attachInvoice embeds invoice, whose Factur-X profile is level, as the
+// document's factur-x.xml: an associated file listed in the catalog's AF
+// array and EmbeddedFiles name tree, as Factur-X requires. MINIMUM and
+// BASIC WL invoices lack the data of a full invoice, so they are attached
+// as Data; the others as Alternative, an equivalent of the visual invoice.
+func attachInvoice(trailer pdf.PDFDict, invoice []byte, level string) error {
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return fmt.Errorf("attachInvoice: Root is not a dictionary")
+	}
+	objNum := nextAvailableObjNum(trailer)
+
+	params := pdf.NewPDFDict()
+	params.Entries["ModDate"] = pdf.PDFString{Value: attachmentDate(trailer)}
+	params.Entries["Size"] = pdf.PDFInteger(len(invoice))
+	file := pdf.NewPDFDict()
+	file.Entries["Type"] = pdf.PDFName{Value: "EmbeddedFile"}
+	file.Entries["Subtype"] = pdf.PDFName{Value: mimeTypeName(invoiceFileName)}
+	file.Entries["Params"] = params
+	if err := writer.SetStreamFlate(&file, invoice); err != nil {
+		return fmt.Errorf("attachInvoice: %w", err)
+	}
+	file.Entries["_ref"] = pdf.PDFRef{ObjNum: objNum}
+
+	ef := pdf.NewPDFDict()
+	ef.Entries["F"] = file
+	ef.Entries["UF"] = file
+	rel := "Alternative"
+	if level == "MINIMUM" || level == "BASIC WL" {
+		rel = "Data"
+	}
+	name := pdf.PDFString{Value: invoiceFileName}
+	spec := pdf.NewPDFDict()
+	spec.Entries["Type"] = pdf.PDFName{Value: "Filespec"}
+	spec.Entries["F"] = name
+	spec.Entries["UF"] = name
+	spec.Entries["Desc"] = pdf.PDFString{Value: "Factur-X invoice"}
+	spec.Entries["AFRelationship"] = pdf.PDFName{Value: rel}
+	spec.Entries["EF"] = ef
+	spec.Entries["_ref"] = pdf.PDFRef{ObjNum: objNum + 1}
+
+	af := pdf.PDFArray{}
+	existing, _ := root.Entries["AF"].(pdf.PDFArray)
+	for _, v := range existing {
+		if fs, ok := v.(pdf.PDFDict); !ok || !isInvoiceSpec(fs) {
+			af = append(af, v)
+		}
+	}
+	root.Entries["AF"] = append(af, spec)
+
+	names, ok := root.Entries["Names"].(pdf.PDFDict)
+	if !ok {
+		names = pdf.NewPDFDict()
+		root.Entries["Names"] = names
+	}
+	var entries []nameTreeEntry
+	if tree, ok := names.Entries["EmbeddedFiles"].(pdf.PDFDict); ok {
+		collectNameTree(tree, 0, &entries)
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if _, isInvoice := verify.InvoiceNamespace(pdf.DecodePDFTextString([]byte(e.key))); isInvoice {
+			continue
+		}
+		if fs, ok := e.value.(pdf.PDFDict); ok && isInvoiceSpec(fs) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	names.Entries["EmbeddedFiles"] = flatNameTree(append(kept, nameTreeEntry{invoiceFileName, name, spec}))
+	return nil
+}
+
+// isInvoiceSpec reports whether fs is an embedded file specification named
+// like a Factur-X / ZUGFeRD invoice.
+func isInvoiceSpec(fs pdf.PDFDict) bool {
+	for _, key := range []string{"UF", "F"} {
+		if s, ok := fs.Entries[key].(pdf.PDFString); ok {
+			_, isInvoice := verify.InvoiceNamespace(pdf.DecodePDFTextString([]byte(s.Value)))
+			return isInvoice
+		}
+	}
+	return false
+}
+
+// nameTreeEntry is one leaf entry of a name tree: key holds the key's
+// bytes, by which the tree is ordered, and keyObj the key as written.
+type nameTreeEntry struct {
+	key    string
+	keyObj pdf.PDFValue
+	value  pdf.PDFValue
+}
+
+// collectNameTree appends every leaf entry of the name tree rooted at node
+// to out.
+func collectNameTree(node pdf.PDFDict, depth int, out *[]nameTreeEntry) {
+	if depth > 32 {
+		return
+	}
+	if names, ok := node.Entries["Names"].(pdf.PDFArray); ok {
+		for i := 0; i+1 < len(names); i += 2 {
+			switch key := names[i].(type) {
+			case pdf.PDFString:
+				*out = append(*out, nameTreeEntry{key.Value, key, names[i+1]})
+			case pdf.PDFHexString:
+				*out = append(*out, nameTreeEntry{string(pdf.DecodePDFHexStringBytes(key.Value)), key, names[i+1]})
+			}
+		}
+	}
+	kids, _ := node.Entries["Kids"].(pdf.PDFArray)
+	for _, kid := range kids {
+		if kd, ok := kid.(pdf.PDFDict); ok {
+			collectNameTree(kd, depth+1, out)
+		}
+	}
+}
+
+// flatNameTree returns a single-node name tree holding entries in key order.
+func flatNameTree(entries []nameTreeEntry) pdf.PDFDict {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	arr := make(pdf.PDFArray, 0, 2*len(entries))
+	for _, e := range entries {
+		arr = append(arr, e.keyObj, e.value)
+	}
+	tree := pdf.NewPDFDict()
+	tree.Entries["Names"] = arr
+	return tree
+}
@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+)
+
+// ciiInvoice returns a minimal Cross Industry Invoice declaring guideline.
+func ciiInvoice(guideline string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">
+<rsm:ExchangedDocumentContext><ram:GuidelineSpecifiedDocumentContextParameter><ram:ID>` + guideline + `</ram:ID></ram:GuidelineSpecifiedDocumentContextParameter></rsm:ExchangedDocumentContext>
+<rsm:ExchangedDocument><ram:ID>INV-1</ram:ID><ram:TypeCode>380</ram:TypeCode></rsm:ExchangedDocument>
+</rsm:CrossIndustryInvoice>
+`)
+}
+
+// TestRunInvoice converts a plain one-page document with an EN 16931
+// invoice and confirms the output is a valid Factur-X file.
+func TestRunInvoice(t *testing.T) {
+	trailer := transparentOCTrailer()
+	delete(trailer.Entries["Root"].(pdf.PDFDict).Entries, "OCProperties")
+	cr, err := RunInvoice(openTrailer(t, trailer), ciiInvoice("urn:cen.eu:en16931:2017"), pdf.FacturX)
+	if err != nil {
+		t.Fatalf("RunInvoice: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("Factur-X conversion left residuals: %v", cr.Residual())
+	}
+
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	xmp, err := out.XMPMetadata()
+	if err != nil {
+		t.Fatalf("XMPMetadata: %v", err)
+	}
+	if !strings.Contains(string(xmp), "<fx:ConformanceLevel>EN 16931</fx:ConformanceLevel>") {
+		t.Errorf("XMP lacks the EN 16931 conformance level:\n%s", xmp)
+	}
+	g, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	spec, name, ok := verify.InvoiceAttachment(g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict))
+	if !ok || name != "factur-x.xml" {
+		t.Fatalf("InvoiceAttachment = %q, %v; want factur-x.xml", name, ok)
+	}
+	if rel := spec.Entries["AFRelationship"]; rel != (pdf.PDFName{Value: "Alternative"}) {
+		t.Errorf("AFRelationship = %v, want Alternative", rel)
+	}
+}
+
+// TestRunInvoiceReplacesInvoice attaches a MINIMUM invoice to a document
+// that already embeds one alongside another attachment: the old invoice is
+// replaced, the other attachment kept.
+func TestRunInvoiceReplacesInvoice(t *testing.T) {
+	trailer := attachmentTrailer()
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	tree := root.Entries["Names"].(pdf.PDFDict).Entries["EmbeddedFiles"].(pdf.PDFDict)
+	old := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type": pdf.PDFName{Value: "Filespec"},
+		"F":    pdf.PDFString{Value: "factur-x.xml"},
+		"EF":   pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: []byte("<old/>")}}},
+	}}
+	tree.Entries["Names"] = append(pdf.PDFArray{pdf.PDFString{Value: "factur-x.xml"}, old}, tree.Entries["Names"].(pdf.PDFArray)...)
+
+	cr, err := RunInvoice(openTrailer(t, trailer), ciiInvoice("urn:factur-x.eu:1p0:minimum"), pdf.FacturX)
+	if err != nil {
+		t.Fatalf("RunInvoice: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("Factur-X conversion left residuals: %v", cr.Residual())
+	}
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	g, err := out.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	root = g.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict)
+	names := root.Entries["Names"].(pdf.PDFDict).Entries["EmbeddedFiles"].(pdf.PDFDict).Entries["Names"].(pdf.PDFArray)
+	if len(names) != 4 || names[0] != (pdf.PDFString{Value: "factur-x.xml"}) || names[2] != (pdf.PDFString{Value: "invoice.xml"}) {
+		t.Fatalf("EmbeddedFiles = %v, want factur-x.xml and invoice.xml", names)
+	}
+	spec := names[1].(pdf.PDFDict)
+	if rel := spec.Entries["AFRelationship"]; rel != (pdf.PDFName{Value: "Data"}) {
+		t.Errorf("MINIMUM invoice AFRelationship = %v, want Data", rel)
+	}
+	stm, _ := verify.InvoiceFile(spec)
+	if data, err := pdf.DecodeStream(stm); err != nil || !strings.Contains(string(data), "minimum") {
+		t.Errorf("embedded invoice = %q, %v; want the new XML", data, err)
+	}
+}
+
+func TestRunInvoiceRejects(t *testing.T) {
+	if _, err := RunInvoice(openTrailer(t, attachmentTrailer()), ciiInvoice("urn:cen.eu:en16931:2017"), pdf.PDFA_2B); err == nil {
+		t.Error("RunInvoice(PDFA_2B) succeeded; Factur-X requires PDF/A-3")
+	}
+	if _, err := RunInvoice(openTrailer(t, attachmentTrailer()), []byte("<Invoice/>"), pdf.FacturX); err == nil {
+		t.Error("RunInvoice accepted XML that is not a Cross Industry Invoice")
+	}
+}
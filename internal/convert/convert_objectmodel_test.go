@@ -2,6 +2,7 @@ package convert
 
 import (
 	"bytes"
+	"slices"
 	"testing"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
@@ -142,3 +143,106 @@ func hasIssueForCheck(issues []pdf.PDFError, c pdf.Check) bool {
 	}
 	return false
 }
+
+// TestConvertObjectModelIncrementalSave: with IncrementalSave the same
+// /Trapped repair leaves the input bytes intact and appends an update that
+// rewrites the Info dict but not the untouched page tree and content,
+// still verifying clean.
+func TestConvertObjectModelIncrementalSave(t *testing.T) {
+	data := buildOnePageDoc(t, func(trailer, _, _ pdf.PDFDict) {
+		info := pdf.NewPDFDict()
+		info.Entries["Trapped"] = pdf.PDFName{Value: "Maybe"}
+		info.Entries["_ref"] = pdf.PDFRef{ObjNum: 5}
+		trailer.Entries["Info"] = info
+	})
+	p := pdf.PDF.Clone()
+	p.IncrementalSave = true
+
+	cr, err := ConvertBytes(data, p)
+	if err != nil {
+		t.Fatalf("ConvertBytes: %v", err)
+	}
+	if !cr.Result.Valid {
+		t.Fatalf("residual %v", issueClauses(cr.Residual()))
+	}
+	if !bytes.HasPrefix(cr.Output, data) {
+		t.Fatal("output does not begin with the input bytes")
+	}
+
+	out, err := pdf.OpenBytes(cr.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	defer out.Close()
+	revs, err := out.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("output has %d revisions, want 2", len(revs))
+	}
+	if changed := revs[1].Changed; !slices.Contains(changed, 5) || slices.ContainsFunc(changed, func(n int) bool { return n >= 2 && n <= 4 }) {
+		t.Errorf("update changed %v, want Info (5) and not the page tree or content (2-4)", changed)
+	}
+	info, err := out.ResolveReference(pdf.PDFRef{ObjNum: 5})
+	if err != nil {
+		t.Fatalf("ResolveReference(5): %v", err)
+	}
+	if _, still := info.(pdf.PDFDict).Entries["Trapped"]; still {
+		t.Error("Trapped must be deleted from the updated Info dict")
+	}
+	if cr.FullRewrite {
+		t.Error("FullRewrite set for an update the header allows")
+	}
+}
+
+// TestConvertIncrementalSaveReverifies converts the veraPDF pass fixtures to
+// PDF/A-2b with IncrementalSave and checks that each output Convert judged
+// valid verifies valid from its own bytes, where objects the update replaced
+// must resolve to their replacements.
+func TestConvertIncrementalSaveReverifies(t *testing.T) {
+	fixtures := passFixtures(t)
+	if fixtures == nil {
+		t.Skip("veraPDF corpus not present")
+	}
+	p := pdf.PDFA_2B.Clone()
+	p.IncrementalSave = true
+	converted := 0
+	for _, path := range fixtures {
+		cr, err := Convert(path, p)
+		if err != nil || !cr.Result.Valid {
+			continue
+		}
+		converted++
+		res, err := verify.VerifyBytes(cr.Output, p)
+		if err != nil || !res.Valid {
+			t.Errorf("%s: re-verify = %v, %v", path, issueClauses(res.Issues), err)
+		}
+	}
+	if converted == 0 {
+		t.Fatal("no fixture converted valid")
+	}
+}
+
+// TestConvertIncrementalSaveNeedsHeader checks that IncrementalSave to
+// PDF/A-4, whose header shall declare PDF 2.0, falls back to a full rewrite
+// of a PDF 1.4 file and reports it.
+func TestConvertIncrementalSaveNeedsHeader(t *testing.T) {
+	data := buildOnePageDoc(t, nil)
+	p := pdf.PDFA_4.Clone()
+	p.IncrementalSave = true
+
+	cr, err := ConvertBytes(data, p)
+	if err != nil {
+		t.Fatalf("ConvertBytes: %v", err)
+	}
+	if !cr.FullRewrite {
+		t.Error("FullRewrite not set")
+	}
+	if !bytes.HasPrefix(cr.Output, []byte("%PDF-2.0")) || bytes.HasPrefix(cr.Output, data) {
+		t.Errorf("output begins %q, want a fresh PDF 2.0 file", cr.Output[:min(len(cr.Output), 8)])
+	}
+	if slices.Contains(issueClauses(cr.Residual()), "6.1.2") {
+		t.Errorf("residual %v includes the file header", issueClauses(cr.Residual()))
+	}
+}
@@ -2,6 +2,8 @@ package convert
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -10,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/pdfgen"
 
 	"github.com/voidrab/gopdfrab/internal/verify"
 )
@@ -241,7 +244,7 @@ func cidSubstitutionPossible(t *testing.T, path string) bool {
 		if possible || (d.Entries["Type"] != pdf.PDFName{Value: "Font"}) || (d.Entries["Subtype"] != pdf.PDFName{Value: "Type0"}) {
 			return
 		}
-		if _, ok := cidFontSubstitutionEligible(d); ok {
+		if _, ok := cidFontSubstitutionEligible(d, nil); ok {
 			possible = true
 		}
 	})
@@ -472,6 +475,95 @@ func TestConvertNeverBreaksConformantInput(t *testing.T) {
 	}
 }
 
+// TestConvertLinearized converts with Linearize set, to PDF/A-1b and to
+// PDF/A-2b, whose output otherwise uses object streams: the output opens
+// with the linearization dictionary, has no object streams and verifies
+// as the unlinearized output does. Outside -short, every conformant corpus
+// file is converted too.
+func TestConvertLinearized(t *testing.T) {
+	inputs := map[string][]byte{"one page": buildOnePageDoc(t, nil)}
+	if !testing.Short() {
+		for _, path := range passFixtures(t) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inputs[filepath.Base(path)] = data
+		}
+	}
+	for _, level := range []*pdf.Profile{pdf.PDFA_1B, pdf.PDFA_2B} {
+		p := level.Clone()
+		p.Linearize = true
+		for name, data := range inputs {
+			t.Run(string(level.Level)+"/"+name, func(t *testing.T) {
+				cr, err := ConvertBytes(data, p)
+				if err != nil {
+					t.Fatalf("ConvertBytes: %v", err)
+				}
+				plain, err := ConvertBytes(data, level)
+				if err != nil {
+					t.Fatalf("ConvertBytes(unlinearized): %v", err)
+				}
+				if got, want := issueClauses(cr.Residual()), issueClauses(plain.Residual()); !slices.Equal(got, want) {
+					t.Errorf("linearized residual %v, unlinearized %v", got, want)
+				}
+				if head := cr.Output[:min(len(cr.Output), 1024)]; !bytes.Contains(head, []byte("/Linearized 1")) {
+					t.Error("no linearization dictionary in the first 1024 bytes")
+				}
+				if bytes.Contains(cr.Output, []byte("/ObjStm")) {
+					t.Error("linearized output has object streams")
+				}
+			})
+		}
+	}
+}
+
+// TestConvertDeduplicate checks that Deduplicate merges a page's two
+// identical images, reports the saving, and leaves the residuals as they
+// are without it.
+func TestConvertDeduplicate(t *testing.T) {
+	data := buildOnePageDoc(t, func(_, _, page pdf.PDFDict) {
+		xobjects := pdf.NewPDFDict()
+		for i, name := range []string{"Im0", "Im1"} {
+			image := pdf.NewPDFDict()
+			image.Entries["_ref"] = pdf.PDFRef{ObjNum: 10 + i}
+			image.Entries["Type"] = pdf.PDFName{Value: "XObject"}
+			image.Entries["Subtype"] = pdf.PDFName{Value: "Image"}
+			image.Entries["Width"] = pdf.PDFInteger(64)
+			image.Entries["Height"] = pdf.PDFInteger(64)
+			image.Entries["BitsPerComponent"] = pdf.PDFInteger(8)
+			image.Entries["ColorSpace"] = pdf.PDFName{Value: "DeviceGray"}
+			image.HasStream = true
+			image.RawStream = bytes.Repeat([]byte{0x80}, 64*64)
+			xobjects.Entries[name] = image
+		}
+		page.Entries["Resources"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{"XObject": xobjects}}
+	})
+	p := pdf.PDFA_2B.Clone()
+	p.Deduplicate = true
+	cr, err := ConvertBytes(data, p)
+	if err != nil {
+		t.Fatalf("ConvertBytes: %v", err)
+	}
+	plain, err := ConvertBytes(data, pdf.PDFA_2B)
+	if err != nil {
+		t.Fatalf("ConvertBytes(plain): %v", err)
+	}
+	if cr.Deduplicated != 1 || cr.DeduplicatedBytes < 64*64 {
+		t.Errorf("Deduplicated = %d objects, %d bytes; want the second image's 1 and at least %d",
+			cr.Deduplicated, cr.DeduplicatedBytes, 64*64)
+	}
+	if plain.Deduplicated != 0 {
+		t.Errorf("plain conversion deduplicated %d objects", plain.Deduplicated)
+	}
+	if len(cr.Output) >= len(plain.Output) {
+		t.Errorf("deduplicated output is %d bytes, plain %d", len(cr.Output), len(plain.Output))
+	}
+	if got, want := issueClauses(cr.Residual()), issueClauses(plain.Residual()); !slices.Equal(got, want) {
+		t.Errorf("deduplicated residual %v, plain %v", got, want)
+	}
+}
+
 // TestConvertIsDeterministic converts the corpus fixture that historically
 // flaked (isartor-6-9-t01-fail-a, residual 6.3.2/InvalidProgram in ~1 of 3
 // full-suite runs) several times in one process and asserts every run agrees:
@@ -505,6 +597,68 @@ func TestConvertIsDeterministic(t *testing.T) {
 	}
 }
 
+// countdownContext is done once its Done method has been called n times,
+// to cancel a conversion part way through.
+type countdownContext struct {
+	context.Context
+	n    int
+	done chan struct{}
+}
+
+func (c *countdownContext) Done() <-chan struct{} {
+	if c.n--; c.n == 0 {
+		close(c.done)
+	}
+	return c.done
+}
+
+func (c *countdownContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// TestConvertContextCancelled cancels a conversion ever later, until it
+// finishes: each cancelled run returns the context's error and no Output,
+// and the run that finishes matches Convert.
+func TestConvertContextCancelled(t *testing.T) {
+	path := "../../tests/Isartor/PDFA-1b/6.9 Interactive Forms/isartor-6-9-t01-fail-a.pdf"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip("Isartor suite not present")
+	}
+	want, err := Convert(path, pdf.PDFA_1B)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	for n := 1; ; n *= 2 {
+		ctx := &countdownContext{Context: context.Background(), n: n, done: make(chan struct{})}
+		cr, err := ConvertContext(ctx, path, pdf.PDFA_1B)
+		if errors.Is(err, context.Canceled) {
+			if cr.Output != nil {
+				t.Errorf("n=%d: cancelled conversion returned Output", n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("n=%d: ConvertContext: %v", n, err)
+		}
+		if !bytes.Equal(cr.Output, want.Output) {
+			t.Errorf("n=%d: finished ConvertContext output differs from Convert's", n)
+		}
+		break
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := ConvertAllContext(ctx, []string{path}, pdf.PDFA_1B)
+	if err != nil || !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("ConvertAllContext(cancelled) = %v, %v, want context.Canceled", results, err)
+	}
+}
+
 // minConvertedFully is a regression floor on how many of both corpora's
 // "fail" fixtures Convert turns fully conformant: all 510, since brute-force
 // recovery of unparseable /Prev xref sections cleared the last hold-out.
@@ -631,7 +785,7 @@ func TestFlattenAllPages(t *testing.T) {
 	}}
 	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": root}}
 
-	if !flattenAllPages(&trailer) {
+	if !flattenAllPages(nil, &trailer) {
 		t.Fatalf("flattenAllPages returned false, want true (a renderable page was present)")
 	}
 
@@ -646,10 +800,44 @@ func TestFlattenAllPages(t *testing.T) {
 	}
 }
 
+// TestFlattenAllPagesRasterLimit confirms a page whose canvas would exceed
+// the Reader's RasterPixels limit is left as it is, with the breach recorded
+// on the Reader so the run stops.
+func TestFlattenAllPagesRasterLimit(t *testing.T) {
+	page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":     pdf.PDFName{Value: "Page"},
+		"Contents": pdf.PDFDict{HasStream: true, RawStream: []byte("0 0 10 10 re f")},
+	}}
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":  pdf.PDFName{Value: "Catalog"},
+		"Pages": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "Pages"}, "Kids": pdf.PDFArray{page}}},
+	}}}}
+	b := pdfgen.NewBuilder("%PDF-1.7\n")
+	b.Obj(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	b.Obj(2, "<< /Type /Pages /Kids [] /Count 0 >>")
+	doc, err := pdf.OpenBytes(b.FinishClassic("<< /Size 3 /Root 1 0 R >>"))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	doc.SetLimits(pdf.Limits{RasterPixels: 1000})
+
+	if flattenAllPages(doc, &trailer) {
+		t.Error("flattenAllPages flattened a page over the RasterPixels limit")
+	}
+	if _, ok := page.Entries["Resources"]; ok {
+		t.Error("page over the limit was rewritten")
+	}
+	var le *pdf.LimitError
+	if err := doc.Err(); !errors.As(err, &le) || le.Limit != "RasterPixels" {
+		t.Errorf("doc.Err() = %v, want a RasterPixels LimitError", err)
+	}
+}
+
 // TestFlattenAllPagesNoPages checks the no-pages-resolved short-circuit.
 func TestFlattenAllPagesNoPages(t *testing.T) {
 	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}
-	if flattenAllPages(&trailer) {
+	if flattenAllPages(nil, &trailer) {
 		t.Error("flattenAllPages on a trailer with no Root/Pages returned true, want false")
 	}
 }
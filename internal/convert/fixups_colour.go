@@ -36,7 +36,7 @@ var colourModelN = map[string]int{"rgb": 3, "cmyk": 4}
 
 // injectOutputIntent ensures the document's catalog has a PDF/A OutputIntent
 // backed by an embedded ICC profile.
-func injectOutputIntent(trailer *pdf.PDFDict, doc *pdf.Reader) error {
+func injectOutputIntent(trailer *pdf.PDFDict, doc *pdf.Reader, _ *pdf.Profile) error {
 	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
 	if !ok {
 		return fmt.Errorf("injectOutputIntent: Root is not a dictionary")
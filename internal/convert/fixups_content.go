@@ -34,8 +34,9 @@ func init() {
 // contentLimitsFixer remediates Checks.Colour.UndefinedOperator,
 // Checks.Colour.RenderingIntent (the ri operator only), and the 6.1.12/6.1.6
 // scalar-limit checks, mirroring scanContent/validateHexString/
-// validateArchitecturalLimits in reverse.
-type contentLimitsFixer struct{}
+// validateArchitecturalLimits in reverse. doc, the run's Reader, supplies
+// the limits content streams are decoded under.
+type contentLimitsFixer struct{ doc *pdf.Reader }
 
 func (contentLimitsFixer) Applies(c pdf.Check) bool {
 	switch c {
@@ -48,7 +49,7 @@ func (contentLimitsFixer) Applies(c pdf.Check) bool {
 	return false
 }
 
-func (contentLimitsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f contentLimitsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
 
 	walkScalars(*trailer, map[uintptr]bool{}, func(v pdf.PDFValue) (pdf.PDFValue, bool) {
@@ -59,7 +60,7 @@ func (contentLimitsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, err
 		return fixed, ok
 	})
 
-	if walkContentStreams(trailer, rewriteOperatorsAndLimits) {
+	if walkContentStreams(trailer, rewriteOperatorsAndLimits, f.doc) {
 		changed = true
 	}
 
@@ -71,7 +72,7 @@ func (contentLimitsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, err
 // the owning dict), and targets that are genuinely content-bearing streams
 // get the content rewrite -- never any other stream, whose bytes the
 // content scanner would corrupt.
-func (contentLimitsFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
+func (f contentLimitsFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
 	targets, ok := p.dictsForIssues(issues)
 	if !ok {
 		return false, false, nil
@@ -83,7 +84,7 @@ func (contentLimitsFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (change
 		if !d.HasStream || !p.isContentStream(d) {
 			continue
 		}
-		updated, ok := rewriteContentStreamDict(d, rewriteOperatorsAndLimits)
+		updated, ok := rewriteContentStreamDict(d, rewriteOperatorsAndLimits, f.doc)
 		if !ok {
 			continue
 		}
@@ -136,11 +137,11 @@ func rewriteOperatorsAndLimits(op string, operands []pdf.PDFValue, changed *bool
 // scanned one (dropping an op always counts as a change).
 type contentOpRewriter func(op string, operands []pdf.PDFValue, changed *bool) (newOp writer.ContentOp, keep bool)
 
-// rewriteContentStreamDict decodes dict's content stream, applies rewrite to
-// every scanned op, and re-encodes the stream only if rewrite actually
-// changed something.
-func rewriteContentStreamDict(dict pdf.PDFDict, rewrite contentOpRewriter) (pdf.PDFDict, bool) {
-	data, err := pdf.DecodeStream(dict)
+// rewriteContentStreamDict decodes dict's content stream under doc's
+// limits, applies rewrite to every scanned op, and re-encodes the stream
+// only if rewrite actually changed something.
+func rewriteContentStreamDict(dict pdf.PDFDict, rewrite contentOpRewriter, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	data, err := doc.DecodeStreamLimited(dict)
 	if err != nil {
 		return dict, false
 	}
@@ -173,7 +174,7 @@ func rewriteContentStreamDict(dict pdf.PDFDict, rewrite contentOpRewriter) (pdf.
 // Pattern, Form XObject, Type3 CharProcs -- the same dispatch
 // validateContentStreams (checks_content.go) uses, and reports whether
 // anything changed.
-func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter) bool {
+func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter, doc *pdf.Reader) bool {
 	changed := false
 	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
 		switch {
@@ -181,7 +182,7 @@ func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter) bool {
 			switch contents := d.Entries["Contents"].(type) {
 			case pdf.PDFDict:
 				if contents.HasStream {
-					if fixed, ok := rewriteContentStreamDict(contents, rewrite); ok {
+					if fixed, ok := rewriteContentStreamDict(contents, rewrite, doc); ok {
 						d.Entries["Contents"] = fixed
 						changed = true
 					}
@@ -192,7 +193,7 @@ func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter) bool {
 					if !ok || !cd.HasStream {
 						continue
 					}
-					if fixed, ok := rewriteContentStreamDict(cd, rewrite); ok {
+					if fixed, ok := rewriteContentStreamDict(cd, rewrite, doc); ok {
 						contents[i] = fixed
 						changed = true
 					}
@@ -202,7 +203,7 @@ func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter) bool {
 
 		case d.Entries["PatternType"] == pdf.PDFInteger(1) && d.HasStream,
 			(d.Entries["Subtype"] == pdf.PDFName{Value: "Form"}) && d.HasStream:
-			if fixed, ok := rewriteContentStreamDict(d, rewrite); ok {
+			if fixed, ok := rewriteContentStreamDict(d, rewrite, doc); ok {
 				changed = true
 				return fixed, true
 			}
@@ -215,7 +216,7 @@ func walkContentStreams(trailer *pdf.PDFDict, rewrite contentOpRewriter) bool {
 					if !ok || !pd.HasStream {
 						continue
 					}
-					if fixed, ok := rewriteContentStreamDict(pd, rewrite); ok {
+					if fixed, ok := rewriteContentStreamDict(pd, rewrite, doc); ok {
 						procs.Entries[k] = fixed
 						changed = true
 					}
@@ -137,7 +137,7 @@ func TestWalkContentStreamsDispatch(t *testing.T) {
 		}},
 	}}
 
-	if !walkContentStreams(&trailer, rewriteOperatorsAndLimits) {
+	if !walkContentStreams(&trailer, rewriteOperatorsAndLimits, nil) {
 		t.Fatalf("walkContentStreams reported no change across an array-Contents page, a Pattern, a Form, and a Type3 glyph")
 	}
 
@@ -164,7 +164,7 @@ func TestRewriteContentStreamDictDropsUndefinedOperator(t *testing.T) {
 	dict.HasStream = true
 	dict.RawStream = src
 
-	fixed, changed := rewriteContentStreamDict(dict, rewriteOperatorsAndLimits)
+	fixed, changed := rewriteContentStreamDict(dict, rewriteOperatorsAndLimits, nil)
 	if !changed {
 		t.Fatalf("rewriteContentStreamDict reported no change for a stream with an undefined operator")
 	}
@@ -15,8 +15,9 @@ import (
 // instead: resolve every use of it to a literal RGB colour -- reusing
 // ResolveColor/resolveSeparation (colorspace.go), which already evaluates a
 // DeviceN space's tint transform -- and delete the resource entries that
-// named it.
-type deviceNColorantsFixer struct{}
+// named it. doc, the run's Reader, supplies the limits the content streams
+// and images are decoded under.
+type deviceNColorantsFixer struct{ doc *pdf.Reader }
 
 func init() {
 	registerFixer(deviceNColorantsFixer{})
@@ -26,12 +27,12 @@ func (deviceNColorantsFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.DeviceNColorants
 }
 
-func (deviceNColorantsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f deviceNColorantsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
-	if rewriteDeviceNContentUsage(trailer) {
+	if rewriteDeviceNContentUsage(trailer, f.doc) {
 		changed = true
 	}
-	if rewriteDeviceNImageDicts(trailer) {
+	if rewriteDeviceNImageDicts(trailer, f.doc) {
 		changed = true
 	}
 	if pruneDeadDeviceNColorSpaceEntries(trailer) {
@@ -61,7 +62,7 @@ func isOversizedDeviceN(v pdf.PDFValue) bool {
 // rewrites cs/CS+scn/SCN usage of an oversized DeviceN space into a literal
 // rg/RG, mirroring the Form-recursion computeResourceUsage (fixups_limits.go)
 // already uses.
-func rewriteDeviceNContentUsage(trailer *pdf.PDFDict) bool {
+func rewriteDeviceNContentUsage(trailer *pdf.PDFDict, doc *pdf.Reader) bool {
 	changed := false
 	visited := map[uintptr]bool{}
 	visitedForm := map[uintptr]bool{}
@@ -77,7 +78,7 @@ func rewriteDeviceNContentUsage(trailer *pdf.PDFDict) bool {
 			visited[ptr] = true
 			if (val.Entries["Type"] == pdf.PDFName{Value: "Page"}) {
 				resources, _ := val.Entries["Resources"].(pdf.PDFDict)
-				rewriteDeviceNPageContents(val, resources, visitedForm, &changed)
+				rewriteDeviceNPageContents(val, resources, visitedForm, &changed, doc)
 				return
 			}
 			for _, child := range val.Entries {
@@ -98,11 +99,11 @@ func rewriteDeviceNContentUsage(trailer *pdf.PDFDict) bool {
 	return changed
 }
 
-func rewriteDeviceNPageContents(page, resources pdf.PDFDict, visitedForm map[uintptr]bool, changed *bool) {
+func rewriteDeviceNPageContents(page, resources pdf.PDFDict, visitedForm map[uintptr]bool, changed *bool, doc *pdf.Reader) {
 	switch v := page.Entries["Contents"].(type) {
 	case pdf.PDFDict:
 		if v.HasStream {
-			if fixed, ok := rewriteDeviceNStream(v, resources, visitedForm); ok {
+			if fixed, ok := rewriteDeviceNStream(v, resources, visitedForm, doc); ok {
 				page.Entries["Contents"] = fixed
 				*changed = true
 			}
@@ -113,7 +114,7 @@ func rewriteDeviceNPageContents(page, resources pdf.PDFDict, visitedForm map[uin
 			if !ok || !d.HasStream {
 				continue
 			}
-			if fixed, ok := rewriteDeviceNStream(d, resources, visitedForm); ok {
+			if fixed, ok := rewriteDeviceNStream(d, resources, visitedForm, doc); ok {
 				v[i] = fixed
 				*changed = true
 			}
@@ -129,9 +130,10 @@ func rewriteDeviceNPageContents(page, resources pdf.PDFDict, visitedForm map[uin
 // every scn/SCN call falls in). A cs/CS selecting an oversized DeviceN space
 // is dropped, and the scn/SCN call(s) that use it are replaced with a
 // literal rg/RG resolved via ResolveColor. Recurses into any Form XObject
-// invoked via Do, using that Form's own /Resources.
-func rewriteDeviceNStream(dict, resources pdf.PDFDict, visitedForm map[uintptr]bool) (pdf.PDFDict, bool) {
-	data, err := pdf.DecodeStream(dict)
+// invoked via Do, using that Form's own /Resources. Streams are decoded under
+// doc's limits.
+func rewriteDeviceNStream(dict, resources pdf.PDFDict, visitedForm map[uintptr]bool, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	data, err := doc.DecodeStreamLimited(dict)
 	if err != nil {
 		return dict, false
 	}
@@ -168,7 +170,7 @@ func rewriteDeviceNStream(dict, resources pdf.PDFDict, visitedForm map[uintptr]b
 				return
 			}
 		case "Do":
-			if _, ok := recurseDeviceNForm(operands, resources, visitedForm); ok {
+			if _, ok := recurseDeviceNForm(operands, resources, visitedForm, doc); ok {
 				modified = true
 			}
 		}
@@ -191,7 +193,7 @@ func rewriteDeviceNStream(dict, resources pdf.PDFDict, visitedForm map[uintptr]b
 // recurseDeviceNForm follows a Do operator's Form XObject reference (if any)
 // and rewrites its content in place via rewriteDeviceNStream, guarded by
 // visitedForm against revisiting a Form shared by multiple Do calls.
-func recurseDeviceNForm(operands []pdf.PDFValue, resources pdf.PDFDict, visitedForm map[uintptr]bool) (pdf.PDFDict, bool) {
+func recurseDeviceNForm(operands []pdf.PDFValue, resources pdf.PDFDict, visitedForm map[uintptr]bool, doc *pdf.Reader) (pdf.PDFDict, bool) {
 	if len(operands) == 0 {
 		return pdf.PDFDict{}, false
 	}
@@ -216,7 +218,7 @@ func recurseDeviceNForm(operands []pdf.PDFValue, resources pdf.PDFDict, visitedF
 	if subResources.Entries == nil {
 		subResources = resources
 	}
-	fixed, ok := rewriteDeviceNStream(xobj, subResources, visitedForm)
+	fixed, ok := rewriteDeviceNStream(xobj, subResources, visitedForm, doc)
 	if !ok {
 		return pdf.PDFDict{}, false
 	}
@@ -226,11 +228,11 @@ func recurseDeviceNForm(operands []pdf.PDFValue, resources pdf.PDFDict, visitedF
 
 // rewriteDeviceNImageDicts rewrites every Image XObject whose inline
 // /ColorSpace is an oversized DeviceN array into a plain, opaque DeviceRGB
-// image: decoding its samples via DecodeImageRGBA (which already resolves
-// DeviceN pixels through ResolveColor) and repacking them, the same
-// in-place bake pattern bakeSoftMaskOut (fixups_transparency.go) uses for
-// /SMask.
-func rewriteDeviceNImageDicts(trailer *pdf.PDFDict) bool {
+// image: decoding its samples under doc's limits via decodeImageRGBA (which
+// already resolves DeviceN pixels through ResolveColor) and repacking them,
+// the same in-place bake pattern bakeSoftMaskOut (fixups_transparency.go)
+// uses for /SMask.
+func rewriteDeviceNImageDicts(trailer *pdf.PDFDict, doc *pdf.Reader) bool {
 	changed := false
 	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
 		if (d.Entries["Subtype"] != pdf.PDFName{Value: "Image"}) {
@@ -239,7 +241,7 @@ func rewriteDeviceNImageDicts(trailer *pdf.PDFDict) bool {
 		if !isOversizedDeviceN(d.Entries["ColorSpace"]) {
 			return d, false
 		}
-		img, err := DecodeImageRGBA(d, pdf.PDFDict{})
+		img, err := decodeImageRGBA(d, pdf.PDFDict{}, doc)
 		if err != nil {
 			return d, false
 		}
@@ -145,31 +145,31 @@ func TestRecurseDeviceNFormEdgeCases(t *testing.T) {
 		}},
 	}}
 
-	if _, ok := recurseDeviceNForm(nil, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm(nil, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(no operands) ok = true, want false")
 	}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFInteger(1)}, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFInteger(1)}, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(non-name operand) ok = true, want false")
 	}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resourcesNoXObject, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resourcesNoXObject, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(no XObject resources) ok = true, want false")
 	}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Missing"}}, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Missing"}}, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(unknown target) ok = true, want false")
 	}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "NotForm"}}, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "NotForm"}}, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(non-Form target) ok = true, want false")
 	}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "NoStream"}}, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "NoStream"}}, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(streamless Form) ok = true, want false")
 	}
 	visited := map[uintptr]bool{pdf.ValuePointer(plainForm.Entries): true}
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resources, visited); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resources, visited, nil); ok {
 		t.Error("recurseDeviceNForm(already-visited Form) ok = true, want false")
 	}
 	// plainForm has no own /Resources and no oversized-DeviceN usage: the
 	// inheritance fallback runs, but rewriteDeviceNStream reports no change.
-	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resources, map[uintptr]bool{}); ok {
+	if _, ok := recurseDeviceNForm([]pdf.PDFValue{pdf.PDFName{Value: "Fm1"}}, resources, map[uintptr]bool{}, nil); ok {
 		t.Error("recurseDeviceNForm(Form needing no rewrite) ok = true, want false")
 	}
 }
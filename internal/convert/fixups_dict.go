@@ -1,6 +1,9 @@
 package convert
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/voidrab/gopdfrab/internal/pdf"
 
 	"github.com/voidrab/gopdfrab/internal/verify"
@@ -11,8 +14,10 @@ import (
 // converter plan: actions (6.6), ExtGState transparency keys (6.2.8/6.4),
 // annotation flags (6.5.3), interactive forms (6.9), image/form XObject
 // metadata keys (6.2.4-6.2.7), PostScript form XObjects (6.2.5/6.2.7), and
-// optional content (6.1.13). Each Fixer walks the whole graph via walkDicts,
-// mirroring, in reverse, the exact detection logic in checks_dict.go so a
+// optional content (6.1.13), and the PDF/A-4 catalog version and document
+// information dictionary (6.1.2/6.1.3). Each Fixer walks the whole graph via
+// walkDicts, mirroring, in reverse, the exact detection logic in
+// checks_dict.go so a
 // Fixer only ever "fixes" what its matching check would actually flag.
 //
 // Deliberately out of scope here (see checks_dict.go and the converter
@@ -31,7 +36,9 @@ func init() {
 	registerFixer(imageMetadataFixer{})
 	registerFixer(postScriptXObjectFixer{})
 	registerFixer(optionalContentFixer{})
+	registerFixer(ocConfigFixer{})
 	registerFixer(viewerPrefFixer{})
+	registerFixer(pdfa4DocumentFixer{})
 }
 
 // walkDicts calls fn for every pdf.PDFDict reachable from v, recursing into dict
@@ -161,7 +168,14 @@ func (actionFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bo
 // does not touch Checks.Transparency.TransparencyGroup or ImageWithSoftMask
 // (a different detection function, and a "harder" fix per the converter
 // plan: removing the key is easy but changes rendered appearance).
-type extGStateFixer struct{}
+//
+// For a part 2 target (part >= 2) transparency is permitted: soft masks and
+// alpha are kept, only a non-standard blend mode is reset to Normal, and the
+// 6.2.5 halftone rules (no HTP, type 1 or 5 halftones without HalftoneName
+// or primary-colourant TransferFunction) are repaired instead.
+type extGStateFixer struct {
+	part int
+}
 
 func (extGStateFixer) Applies(c pdf.Check) bool {
 	switch c {
@@ -171,7 +185,11 @@ func (extGStateFixer) Applies(c pdf.Check) bool {
 		pdf.Checks.Transparency.SoftMaskExtGState,
 		pdf.Checks.Transparency.BlendMode,
 		pdf.Checks.Transparency.StrokingAlpha,
-		pdf.Checks.Transparency.NonStrokingAlpha:
+		pdf.Checks.Transparency.NonStrokingAlpha,
+		pdf.Checks.PDFA2.Colour.HalftonePhase,
+		pdf.Checks.PDFA2.Colour.HalftoneType,
+		pdf.Checks.PDFA2.Colour.HalftoneName,
+		pdf.Checks.PDFA2.Colour.HalftoneTransferFunction:
 		return true
 	}
 	return false
@@ -181,12 +199,12 @@ func (f extGStateFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error
 	return runDictVisitor(trailer, f.prepare)
 }
 
-func (extGStateFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
+func (f extGStateFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
 	return func(d pdf.PDFDict) {
 		if t, ok := d.Entries["Type"].(pdf.PDFName); ok && t.Value != "ExtGState" {
 			return
 		}
-		if !verify.HasAnyKey(d, "TR", "TR2", "SMask", "BM", "CA", "ca", "RI") {
+		if !verify.HasAnyKey(d, "TR", "TR2", "SMask", "BM", "CA", "ca", "RI", "HTP", "HT") {
 			return
 		}
 
@@ -204,6 +222,10 @@ func (extGStateFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict),
 			delete(d.Entries, "RI")
 			*changed = true
 		}
+		if f.part >= 2 {
+			fixExtGStatePdfA2(d, changed)
+			return
+		}
 		if sm, ok := d.Entries["SMask"]; ok {
 			if name, isName := sm.(pdf.PDFName); !isName || name.Value != "None" {
 				d.Entries["SMask"] = pdf.PDFName{Value: "None"}
@@ -229,20 +251,71 @@ func (extGStateFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict),
 	}, true
 }
 
+// fixExtGStatePdfA2 is extGStateFixer's part 2 counterpart to the
+// transparency resets: it keeps SMask and alpha, and repairs the blend mode
+// and halftone rules validateExtGStatePdfA2 enforces.
+func fixExtGStatePdfA2(d pdf.PDFDict, changed *bool) {
+	if bm, ok := d.Entries["BM"]; ok && !verify.IsStandardBlendMode(bm) {
+		d.Entries["BM"] = pdf.PDFName{Value: "Normal"}
+		*changed = true
+	}
+	if _, ok := d.Entries["HTP"]; ok {
+		delete(d.Entries, "HTP")
+		*changed = true
+	}
+	ht, ok := d.Entries["HT"].(pdf.PDFDict)
+	if !ok {
+		return
+	}
+	// An unusable halftone falls back to the device default, which is what
+	// a conforming reader renders for an ExtGState without HT.
+	if typ, _ := ht.Entries["HalftoneType"].(pdf.PDFInteger); typ != 1 && typ != 5 {
+		delete(d.Entries, "HT")
+		*changed = true
+		return
+	}
+	if _, ok := ht.Entries["HalftoneName"]; ok {
+		delete(ht.Entries, "HalftoneName")
+		*changed = true
+	}
+	if typ, _ := ht.Entries["HalftoneType"].(pdf.PDFInteger); typ == 1 {
+		if _, ok := ht.Entries["TransferFunction"]; ok {
+			delete(ht.Entries, "TransferFunction")
+			*changed = true
+		}
+		return
+	}
+	for k, v := range ht.Entries {
+		comp, ok := v.(pdf.PDFDict)
+		if !ok || !verify.PrimaryColourants[k] {
+			continue
+		}
+		for _, key := range []string{"TransferFunction", "HalftoneName"} {
+			if _, ok := comp.Entries[key]; ok {
+				delete(comp.Entries, key)
+				*changed = true
+			}
+		}
+	}
+}
+
 // --- 6.5.3 Annotations ---
 
 // annotationFlagsFixer remediates the annotation flag-bit and opacity
 // checks, mirroring the relevant part of validateAnnotation in
 // checks_dict.go. It deliberately does not touch DisallowedSubtype,
 // ColourWithoutIntent, or the appearance-stream checks (resource synthesis
-// or harder per the converter plan).
-type annotationFlagsFixer struct{}
+// or harder per the converter plan). For a part 2 target it also clears
+// ToggleNoView and leaves CA alone, since PDF/A-2 permits annotation opacity.
+type annotationFlagsFixer struct {
+	part int
+}
 
 func (annotationFlagsFixer) Applies(c pdf.Check) bool {
 	switch c {
 	case pdf.Checks.Annotation.PrintFlagNotSet, pdf.Checks.Annotation.HiddenFlagSet,
 		pdf.Checks.Annotation.InvisibleFlagSet, pdf.Checks.Annotation.NoViewFlagSet,
-		pdf.Checks.Annotation.OpacityNotOne:
+		pdf.Checks.Annotation.OpacityNotOne, pdf.Checks.PDFA2.Annotation.ToggleNoViewFlagSet:
 		return true
 	}
 	return false
@@ -252,7 +325,7 @@ func (f annotationFlagsFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool,
 	return runDictVisitor(trailer, f.prepare)
 }
 
-func (annotationFlagsFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
+func (f annotationFlagsFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
 	return func(d pdf.PDFDict) {
 		if (d.Entries["Type"] != pdf.PDFName{Value: "Annot"}) {
 			return
@@ -264,10 +337,16 @@ func (annotationFlagsFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDF
 		}
 		want := flags | verify.AnnotFlagPrint
 		want &^= verify.AnnotFlagHidden | verify.AnnotFlagInvisible | verify.AnnotFlagNoView
+		if f.part >= 2 {
+			want &^= verify.AnnotFlagToggleNoView
+		}
 		if want != flags {
 			d.Entries["F"] = pdf.PDFInteger(want)
 			*changed = true
 		}
+		if f.part >= 2 {
+			return
+		}
 
 		if ca, ok := d.Entries["CA"]; ok {
 			if f, num := verify.AsFloat(ca); num && f != 1.0 {
@@ -340,8 +419,9 @@ func (formFixer) prepare(trailer *pdf.PDFDict, changed *bool) (func(pdf.PDFDict)
 // so the inline case is folded in here rather than given its own. It
 // deliberately does not touch FormPostScript, FormPSEntry, FormSubtype2PS,
 // or PostScriptXObject (PostScript-related checks already disabled in the
-// default PDFA_1B profile; see profile.go).
-type imageMetadataFixer struct{}
+// default PDFA_1B profile; see profile.go). doc, the run's Reader, supplies
+// the limits content streams are decoded under.
+type imageMetadataFixer struct{ doc *pdf.Reader }
 
 func (imageMetadataFixer) Applies(c pdf.Check) bool {
 	switch c {
@@ -353,7 +433,7 @@ func (imageMetadataFixer) Applies(c pdf.Check) bool {
 	return false
 }
 
-func (imageMetadataFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
+func (f imageMetadataFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
 	changed := false
 	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
 		subtype, ok := d.Entries["Subtype"].(pdf.PDFName)
@@ -389,7 +469,7 @@ func (imageMetadataFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool
 			}
 		}
 	})
-	if walkContentStreams(trailer, fixInlineImageInterpolate) {
+	if walkContentStreams(trailer, fixInlineImageInterpolate, f.doc) {
 		changed = true
 	}
 	return changed, nil
@@ -456,6 +536,94 @@ func (optionalContentFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bo
 	return true, nil
 }
 
+// --- PDF/A-2 6.9 Optional content configurations ---
+
+// ocConfigFixer remediates the PDF/A-2 optional content configuration
+// checks, mirroring verifyOptionalContentConfigs in checks_pdfa2.go: it names
+// every unnamed or duplicate-named configuration, drops AS, and appends any
+// optional content group an Order array omits. Unlike optionalContentFixer
+// it keeps the document's optional content.
+type ocConfigFixer struct{}
+
+func (ocConfigFixer) Applies(c pdf.Check) bool {
+	switch c {
+	case pdf.Checks.PDFA2.OptionalContent.OCConfigName,
+		pdf.Checks.PDFA2.OptionalContent.OCConfigNameDuplicate,
+		pdf.Checks.PDFA2.OptionalContent.OCConfigAS,
+		pdf.Checks.PDFA2.OptionalContent.OCOrderIncomplete:
+		return true
+	}
+	return false
+}
+
+func (ocConfigFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return false, nil
+	}
+	oc, ok := root.Entries["OCProperties"].(pdf.PDFDict)
+	if !ok {
+		return false, nil
+	}
+	var configs []pdf.PDFDict
+	if dflt, ok := oc.Entries["D"].(pdf.PDFDict); ok {
+		configs = append(configs, dflt)
+	}
+	if arr, ok := oc.Entries["Configs"].(pdf.PDFArray); ok {
+		for _, c := range arr {
+			if cd, ok := c.(pdf.PDFDict); ok {
+				configs = append(configs, cd)
+			}
+		}
+	}
+	groups, _ := oc.Entries["OCGs"].(pdf.PDFArray)
+
+	changed := false
+	seen := map[string]bool{}
+	for i, cfg := range configs {
+		name, named := ocConfigName(cfg.Entries["Name"])
+		if !named || seen[name] {
+			name = fmt.Sprintf("Configuration %d", i+1)
+			for n := i + 1; seen[name]; n++ {
+				name = fmt.Sprintf("Configuration %d.%d", i+1, n)
+			}
+			cfg.Entries["Name"] = pdf.PDFString{Value: name}
+			changed = true
+		}
+		seen[name] = true
+		if _, ok := cfg.Entries["AS"]; ok {
+			delete(cfg.Entries, "AS")
+			changed = true
+		}
+		order, ok := cfg.Entries["Order"].(pdf.PDFArray)
+		if !ok {
+			continue
+		}
+		listed := map[uintptr]bool{}
+		verify.CollectOrderGroups(order, listed, 0)
+		for _, g := range groups {
+			gd, ok := g.(pdf.PDFDict)
+			if ok && !listed[pdf.ValuePointer(gd.Entries)] {
+				order = append(order, gd)
+				listed[pdf.ValuePointer(gd.Entries)] = true
+				changed = true
+			}
+		}
+		cfg.Entries["Order"] = order
+	}
+	return changed, nil
+}
+
+// ocConfigName returns a configuration's decoded Name, and whether it has
+// one at all.
+func ocConfigName(v pdf.PDFValue) (string, bool) {
+	switch v.(type) {
+	case pdf.PDFString, pdf.PDFHexString:
+		return pdf.DecodeInfoTextString(v), true
+	}
+	return "", false
+}
+
 // --- 6.1.2 ViewerPreferences (post-1.4 keys) ---
 
 // viewerPrefFixer removes ViewerPreferences keys introduced after PDF 1.4
@@ -484,3 +652,49 @@ func (viewerPrefFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error)
 	}
 	return changed, nil
 }
+
+// --- PDF/A-4 6.1.2 / 6.1.3 Catalog version and document information ---
+
+// pdfa4DocumentFixer remediates the part 4 catalog and trailer rules: it
+// drops a catalog Version that is not 2.n (the writer's PDF 2.0 header then
+// governs), drops the Info dictionary when the catalog has no PieceInfo, and
+// otherwise trims it to ModDate. The regenerated XMP packet already carries
+// every Info entry it discards.
+type pdfa4DocumentFixer struct{}
+
+func (pdfa4DocumentFixer) Applies(c pdf.Check) bool {
+	switch c {
+	case pdf.Checks.PDFA4.Structure.CatalogVersion,
+		pdf.Checks.PDFA4.Structure.InfoDictWithoutPieceInfo,
+		pdf.Checks.PDFA4.Structure.InfoDictKeys:
+		return true
+	}
+	return false
+}
+
+func (pdfa4DocumentFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return false, nil
+	}
+	changed := false
+	if v, ok := root.Entries["Version"].(pdf.PDFName); ok && !strings.HasPrefix(v.Value, "2.") {
+		delete(root.Entries, "Version")
+		changed = true
+	}
+	info, ok := trailer.Entries["Info"].(pdf.PDFDict)
+	if !ok {
+		return changed, nil
+	}
+	if root.Entries["PieceInfo"] == nil {
+		delete(trailer.Entries, "Info")
+		return true, nil
+	}
+	for k := range info.Entries {
+		if k != "ModDate" && k != "_ref" {
+			delete(info.Entries, k)
+			changed = true
+		}
+	}
+	return changed, nil
+}
@@ -162,6 +162,109 @@ func TestAnnotationFlagsFixer(t *testing.T) {
 	}
 }
 
+func TestExtGStateFixerPdfA2(t *testing.T) {
+	ht := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"HalftoneType": pdf.PDFInteger(5),
+		"HalftoneName": pdf.PDFString{Value: "x"},
+		"Cyan":         pdf.PDFDict{Entries: map[string]pdf.PDFValue{"TransferFunction": pdf.PDFName{Value: "Identity"}}},
+		"Spot":         pdf.PDFDict{Entries: map[string]pdf.PDFValue{"TransferFunction": pdf.PDFName{Value: "Identity"}}},
+	}}
+	gs := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":  pdf.PDFName{Value: "ExtGState"},
+		"SMask": pdf.PDFName{Value: "Foo"},
+		"BM":    pdf.PDFName{Value: "Weird"},
+		"ca":    pdf.PDFReal(0.5),
+		"HTP":   pdf.PDFArray{},
+		"HT":    ht,
+	}}
+	trailer := trailerWith("GS", gs)
+	changed, err := extGStateFixer{part: 2}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("extGStateFixer.Fix = %v, %v; want changed", changed, err)
+	}
+	if gs.Entries["SMask"] != (pdf.PDFName{Value: "Foo"}) || gs.Entries["ca"] != pdf.PDFReal(0.5) {
+		t.Error("part 2 fix touched SMask or alpha")
+	}
+	if gs.Entries["BM"] != (pdf.PDFName{Value: "Normal"}) {
+		t.Error("non-standard BM not normalized to Normal")
+	}
+	if _, ok := gs.Entries["HTP"]; ok {
+		t.Error("HTP not removed")
+	}
+	if _, ok := ht.Entries["HalftoneName"]; ok {
+		t.Error("HalftoneName not removed")
+	}
+	if ht.Entries["Cyan"].(pdf.PDFDict).Entries["TransferFunction"] != nil {
+		t.Error("primary colourant TransferFunction not removed")
+	}
+	if ht.Entries["Spot"].(pdf.PDFDict).Entries["TransferFunction"] == nil {
+		t.Error("spot colourant TransferFunction removed")
+	}
+
+	gs.Entries["HT"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{"HalftoneType": pdf.PDFInteger(6)}}
+	if _, err := (extGStateFixer{part: 2}).Fix(&trailer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gs.Entries["HT"]; ok {
+		t.Error("type 6 halftone not removed")
+	}
+}
+
+func TestAnnotationFlagsFixerPdfA2(t *testing.T) {
+	annot := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type": pdf.PDFName{Value: "Annot"},
+		"F":    pdf.PDFInteger(verify.AnnotFlagToggleNoView),
+		"CA":   pdf.PDFReal(0.5),
+	}}
+	trailer := trailerWith("Annot0", annot)
+	changed, err := annotationFlagsFixer{part: 2}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("annotationFlagsFixer.Fix = %v, %v", changed, err)
+	}
+	if annot.Entries["CA"] != pdf.PDFReal(0.5) {
+		t.Error("part 2 fix changed annotation CA")
+	}
+	if f := int(annot.Entries["F"].(pdf.PDFInteger)); f != verify.AnnotFlagPrint {
+		t.Errorf("F = %d, want Print only", f)
+	}
+}
+
+func TestOCConfigFixer(t *testing.T) {
+	ocg := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "OCG"}}}
+	dflt := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Name":  pdf.PDFString{Value: "Configuration 2"},
+		"Order": pdf.PDFArray{},
+		"AS":    pdf.PDFArray{},
+	}}
+	alt := pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}
+	root := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"OCProperties": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"OCGs":    pdf.PDFArray{ocg},
+			"D":       dflt,
+			"Configs": pdf.PDFArray{alt},
+		}},
+	}}
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": root}}
+	changed, err := ocConfigFixer{}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("ocConfigFixer.Fix = %v, %v", changed, err)
+	}
+	if _, ok := dflt.Entries["AS"]; ok {
+		t.Error("AS not removed")
+	}
+	if order := dflt.Entries["Order"].(pdf.PDFArray); len(order) != 1 {
+		t.Errorf("Order = %v, want the missing OCG appended", order)
+	}
+	// The generated name must not collide with the default's.
+	if name, _ := alt.Entries["Name"].(pdf.PDFString); name.Value == "" || name.Value == "Configuration 2" {
+		t.Errorf("alternate configuration Name = %q, want a fresh unique name", name.Value)
+	}
+
+	if changed, _ := (ocConfigFixer{}).Fix(&trailer, nil); changed {
+		t.Error("second Fix reported a change")
+	}
+}
+
 func TestFormFixer(t *testing.T) {
 	widget := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"Type": pdf.PDFName{Value: "Annot"}, "Subtype": pdf.PDFName{Value: "Widget"},
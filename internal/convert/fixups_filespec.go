@@ -1,20 +1,33 @@
 package convert
 
 import (
+	"path"
+	"strings"
+
 	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
 )
 
 func init() {
 	registerFixer(fileSpecFixer{})
 }
 
+// fileSpecFixer removes external file references from streams and embedded
+// files altogether: PDF/A-1 forbids them and PDF/A-2, PDF/A-4 and PDF/A-4e
+// only admit PDF/A attachments, which cannot be produced from an arbitrary
+// file. A PDF/A-3b or PDF/A-4f conversion substitutes associatedFileFixer,
+// which keeps the attachments.
 type fileSpecFixer struct{}
 
 func (fileSpecFixer) Applies(c pdf.Check) bool {
 	switch c {
 	case pdf.Checks.Structure.EmbeddedFileSpec, pdf.Checks.Structure.EmbeddedFiles,
 		pdf.Checks.Structure.StreamFileSpec, pdf.Checks.Structure.StreamFileFilter,
-		pdf.Checks.Structure.StreamFileDecodeParams:
+		pdf.Checks.Structure.StreamFileDecodeParams,
+		pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileSpecKeys, pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA,
+		pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileSpecKeys, pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileMIMEType,
+		pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileModDate, pdf.Checks.PDFA3.EmbeddedFile.AFRelationship,
+		pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileNotAssociated:
 		return true
 	}
 	return false
@@ -34,14 +47,199 @@ func (fileSpecFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict),
 			delete(d.Entries, "EmbeddedFiles")
 			*changed = true
 		}
-		if !d.HasStream {
-			return
+		stripStreamFileKeys(d, changed)
+	}, true
+}
+
+// stripStreamFileKeys deletes the keys by which a stream's data would live in
+// an external file (6.1.7 in every part).
+func stripStreamFileKeys(d pdf.PDFDict, changed *bool) {
+	if !d.HasStream {
+		return
+	}
+	for _, key := range []string{"F", "FFilter", "FDecodeParms"} {
+		if _, ok := d.Entries[key]; ok {
+			delete(d.Entries, key)
+			*changed = true
 		}
-		for _, key := range []string{"F", "FFilter", "FDecodeParms"} {
-			if _, ok := d.Entries[key]; ok {
-				delete(d.Entries, key)
-				*changed = true
+	}
+}
+
+// associatedFileFixer is fileSpecFixer for a target level that permits
+// arbitrary associated files (pdf.LevelType.AllowsAssociatedFiles): rather
+// than deleting attachments it repairs each into a conforming associated
+// file (repairAssociatedFiles), so attached data such as an invoice's source
+// XML survives the conversion. It is not registered; buildLocalFixers
+// substitutes it for fileSpecFixer.
+type associatedFileFixer struct{}
+
+func (associatedFileFixer) Applies(c pdf.Check) bool {
+	return fileSpecFixer{}.Applies(c)
+}
+
+func (associatedFileFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	return repairAssociatedFiles(trailer), nil
+}
+
+// defaultAttachmentDate is the embedded file ModDate used when neither the
+// file's Params nor the document Info dictionary carries a date to reuse,
+// keeping conversion output reproducible.
+const defaultAttachmentDate = "D:19700101000000Z"
+
+// repairAssociatedFiles brings every embedded file specification in the
+// document up to ISO 19005-3 6.8: F and UF are filled in from each other, a
+// missing or unknown AFRelationship becomes Unspecified, each embedded file
+// stream gets a MIME type Subtype (guessed from the file name, replacing one
+// that is not a MIME type) and a Params
+// ModDate, and any specification no AF array lists is appended to the
+// catalog's AF array, associating it with the document as a whole.
+func repairAssociatedFiles(trailer *pdf.PDFDict) bool {
+	changed := false
+	associated := map[uintptr]bool{}
+	var specs []pdf.PDFDict
+	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
+		stripStreamFileKeys(d, &changed)
+		if af, ok := d.Entries["AF"].(pdf.PDFArray); ok {
+			for _, e := range af {
+				if fs, ok := e.(pdf.PDFDict); ok {
+					associated[pdf.ValuePointer(fs.Entries)] = true
+				}
 			}
 		}
-	}, true
+		if _, ok := d.Entries["EF"].(pdf.PDFDict); ok && !d.HasStream {
+			specs = append(specs, d)
+		}
+	})
+	if len(specs) == 0 {
+		return changed
+	}
+
+	fallbackDate := attachmentDate(*trailer)
+	for _, spec := range specs {
+		if repairFileSpec(spec, fallbackDate) {
+			changed = true
+		}
+	}
+
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return changed
+	}
+	af, _ := root.Entries["AF"].(pdf.PDFArray)
+	nextObjNum := 0
+	for _, spec := range specs {
+		if associated[pdf.ValuePointer(spec.Entries)] {
+			continue
+		}
+		// The spec is now referenced from two places; a direct dict would be
+		// written out twice as two distinct objects.
+		if _, ok := spec.Entries["_ref"]; !ok {
+			if nextObjNum == 0 {
+				nextObjNum = nextAvailableObjNum(*trailer)
+			}
+			spec.Entries["_ref"] = pdf.PDFRef{ObjNum: nextObjNum}
+			nextObjNum++
+		}
+		af = append(af, spec)
+		changed = true
+	}
+	if changed {
+		root.Entries["AF"] = af
+	}
+	return changed
+}
+
+// attachmentDate returns the ModDate to give an embedded file that carries
+// no date of its own: the document's Info ModDate, else its CreationDate,
+// else defaultAttachmentDate.
+func attachmentDate(trailer pdf.PDFDict) string {
+	date := defaultAttachmentDate
+	if info, ok := trailer.Entries["Info"].(pdf.PDFDict); ok {
+		for _, key := range []string{"CreationDate", "ModDate"} {
+			if s, ok := info.Entries[key].(pdf.PDFString); ok && strings.HasPrefix(s.Value, "D:") {
+				date = s.Value
+			}
+		}
+	}
+	return date
+}
+
+// repairFileSpec fills in the part 3 keys of one embedded file specification,
+// reporting whether it changed anything. fallbackDate is the ModDate of an
+// embedded file with no CreationDate of its own.
+func repairFileSpec(spec pdf.PDFDict, fallbackDate string) bool {
+	changed := false
+	f, hasF := spec.Entries["F"].(pdf.PDFString)
+	uf, hasUF := spec.Entries["UF"].(pdf.PDFString)
+	switch {
+	case hasF && !hasUF:
+		spec.Entries["UF"] = f
+		uf = f
+		changed = true
+	case hasUF && !hasF:
+		spec.Entries["F"] = uf
+		changed = true
+	case !hasF && !hasUF:
+		uf = pdf.PDFString{Value: "attachment"}
+		spec.Entries["F"], spec.Entries["UF"] = uf, uf
+		changed = true
+	}
+	if rel, ok := spec.Entries["AFRelationship"].(pdf.PDFName); !ok || !verify.IsAFRelationship(rel.Value) {
+		spec.Entries["AFRelationship"] = pdf.PDFName{Value: "Unspecified"}
+		changed = true
+	}
+
+	ef := spec.Entries["EF"].(pdf.PDFDict)
+	for k, v := range ef.Entries {
+		stm, ok := v.(pdf.PDFDict)
+		if !ok || !stm.HasStream || k == "_ref" {
+			continue
+		}
+		if sub, ok := stm.Entries["Subtype"].(pdf.PDFName); !ok || !verify.IsMIMEType(string(pdf.DecodePDFName(sub.Value))) {
+			stm.Entries["Subtype"] = pdf.PDFName{Value: mimeTypeName(uf.Value)}
+			changed = true
+		}
+		params, ok := stm.Entries["Params"].(pdf.PDFDict)
+		if !ok {
+			params = pdf.NewPDFDict()
+			stm.Entries["Params"] = params
+		}
+		if _, ok := params.Entries["ModDate"].(pdf.PDFString); !ok {
+			date := fallbackDate
+			if s, ok := params.Entries["CreationDate"].(pdf.PDFString); ok && strings.HasPrefix(s.Value, "D:") {
+				date = s.Value
+			}
+			params.Entries["ModDate"] = pdf.PDFString{Value: date}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// attachmentMIMETypes maps the file extensions commonly attached to PDF/A-3
+// documents to their MIME types. The table is fixed rather than taken from
+// the mime package, whose answers depend on the host's mime.types files.
+var attachmentMIMETypes = map[string]string{
+	".csv":  "text/csv",
+	".htm":  "text/html",
+	".html": "text/html",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".json": "application/json",
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".txt":  "text/plain",
+	".xml":  "text/xml",
+	".zip":  "application/zip",
+}
+
+// mimeTypeName returns the Subtype name for an embedded file called
+// filename: its MIME type with the slash escaped as a name requires
+// (ISO 32000-1 7.3.5), defaulting to application/octet-stream.
+func mimeTypeName(filename string) string {
+	typ, ok := attachmentMIMETypes[strings.ToLower(path.Ext(filename))]
+	if !ok {
+		typ = "application/octet-stream"
+	}
+	return strings.ReplaceAll(typ, "/", "#2F")
 }
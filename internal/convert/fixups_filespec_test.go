@@ -28,3 +28,69 @@ func TestFileSpecFixer(t *testing.T) {
 		}
 	}
 }
+
+func TestAssociatedFileFixer(t *testing.T) {
+	stm := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F": pdf.PDFString{Value: "ext.xml"}}, HasStream: true, RawStream: []byte("<a/>")}
+	spec := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":           pdf.PDFName{Value: "Filespec"},
+		"F":              pdf.PDFString{Value: "Invoice.XML"},
+		"AFRelationship": pdf.PDFName{Value: "Bogus"},
+		"EF":             pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F": stm}},
+	}}
+	names := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"EmbeddedFiles": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"Names": pdf.PDFArray{pdf.PDFString{Value: "invoice"}, spec},
+		}},
+	}}
+	trailer := trailerWith("Names", names)
+	trailer.Entries["Info"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{"ModDate": pdf.PDFString{Value: "D:20240102030405Z"}}}
+
+	changed, err := associatedFileFixer{}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("fileSpecFixer.Fix = %v, %v", changed, err)
+	}
+	if _, ok := names.Entries["EmbeddedFiles"]; !ok {
+		t.Fatal("EmbeddedFiles removed for a part 3 target")
+	}
+	if spec.Entries["UF"] != spec.Entries["F"] {
+		t.Errorf("UF = %v, want it copied from F", spec.Entries["UF"])
+	}
+	if (spec.Entries["AFRelationship"] != pdf.PDFName{Value: "Unspecified"}) {
+		t.Errorf("AFRelationship = %v, want Unspecified", spec.Entries["AFRelationship"])
+	}
+	if (stm.Entries["Subtype"] != pdf.PDFName{Value: "text#2Fxml"}) {
+		t.Errorf("Subtype = %v, want text#2Fxml", stm.Entries["Subtype"])
+	}
+	if _, ok := stm.Entries["F"]; ok {
+		t.Error("external file reference on the embedded stream kept")
+	}
+	params, _ := stm.Entries["Params"].(pdf.PDFDict)
+	if (params.Entries["ModDate"] != pdf.PDFString{Value: "D:20240102030405Z"}) {
+		t.Errorf("Params ModDate = %v, want the Info date", params.Entries["ModDate"])
+	}
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	af, _ := root.Entries["AF"].(pdf.PDFArray)
+	if len(af) != 1 || pdf.ValuePointer(af[0].(pdf.PDFDict).Entries) != pdf.ValuePointer(spec.Entries) {
+		t.Fatalf("catalog AF = %v, want the file specification", af)
+	}
+	if _, ok := spec.Entries["_ref"].(pdf.PDFRef); !ok {
+		t.Error("a specification shared with AF must become indirect")
+	}
+
+	if changed, _ := (associatedFileFixer{}).Fix(&trailer, nil); changed {
+		t.Error("second pass changed an already repaired document")
+	}
+}
+
+func TestMimeTypeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"factur-x.xml": "text#2Fxml",
+		"SCAN.JPG":     "image#2Fjpeg",
+		"data.bin":     "application#2Foctet-stream",
+		"noext":        "application#2Foctet-stream",
+	} {
+		if got := mimeTypeName(name); got != want {
+			t.Errorf("mimeTypeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -62,8 +62,9 @@ func (fontDictFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict),
 // CIDSystemInfo is authoritative -- it describes the glyph data actually
 // embedded -- so a mismatched CMap CIDSystemInfo is overwritten to match it;
 // a mismatched dictionary /WMode is overwritten to match the value the CMap
-// stream itself declares.
-type type0FontFixer struct{}
+// stream itself declares. doc, the run's Reader, supplies the limits the CMap
+// streams are decoded under.
+type type0FontFixer struct{ doc *pdf.Reader }
 
 func (type0FontFixer) Applies(c pdf.Check) bool {
 	switch c {
@@ -77,7 +78,7 @@ func (f type0FontFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error
 	return runDictVisitor(trailer, f.prepare)
 }
 
-func (type0FontFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
+func (f type0FontFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict), bool) {
 	return func(d pdf.PDFDict) {
 		if (d.Entries["Type"] != pdf.PDFName{Value: "Font"}) {
 			return
@@ -106,7 +107,7 @@ func (type0FontFixer) prepare(_ *pdf.PDFDict, changed *bool) (func(pdf.PDFDict),
 		if !ok {
 			return
 		}
-		data, err := pdf.DecodeStream(cmap)
+		data, err := f.doc.DecodeStreamLimited(cmap)
 		if err != nil {
 			return
 		}
@@ -21,8 +21,8 @@ import (
 func init() {
 	registerFixer(fontMetricFixer{})
 	registerFixer(fontSubsetMetaFixer{})
-	registerPreemptiveVisitor(func(*pdf.PDFDict, *pdf.Reader) func(pdf.PDFDict) {
-		return promoteEmptyGlyphsInFont
+	registerPreemptiveVisitor(func(_ *pdf.PDFDict, doc *pdf.Reader, _ *pdf.Profile) func(pdf.PDFDict) {
+		return func(d pdf.PDFDict) { promoteEmptyGlyphsInFont(d, doc) }
 	})
 }
 
@@ -30,12 +30,12 @@ func init() {
 // program so its blank glyphs are explicit zero-contour records. The shared
 // pre-emptive walk drives promoteEmptyGlyphsInFont per dict; this standalone
 // form remains for direct use.
-func promoteEmptyGlyphsInFonts(trailer *pdf.PDFDict, _ *pdf.Reader) error {
-	walkDicts(*trailer, map[uintptr]bool{}, promoteEmptyGlyphsInFont)
+func promoteEmptyGlyphsInFonts(trailer *pdf.PDFDict, doc *pdf.Reader) error {
+	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) { promoteEmptyGlyphsInFont(d, doc) })
 	return nil
 }
 
-func promoteEmptyGlyphsInFont(d pdf.PDFDict) {
+func promoteEmptyGlyphsInFont(d pdf.PDFDict, doc *pdf.Reader) {
 	if (d.Entries["Subtype"] != pdf.PDFName{Value: "CIDFontType2"}) {
 		return
 	}
@@ -47,7 +47,7 @@ func promoteEmptyGlyphsInFont(d pdf.PDFDict) {
 	if !ok || !ff.HasStream {
 		return
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return
 	}
@@ -65,17 +65,18 @@ func promoteEmptyGlyphsInFont(d pdf.PDFDict) {
 // fontMetricFixer remediates Checks.Font.AdvanceWidthMismatch by recomputing
 // PDF /Widths (simple TrueType, Type1, Type1C, Type3) or /W (CIDFontType2,
 // CIDFontType0) entries from the embedded font program, mirroring the
-// detection in checks_font.go/checks_font_program.go.
-type fontMetricFixer struct{}
+// detection in checks_font.go/checks_font_program.go. doc, the run's
+// Reader, supplies the limits the programs are decoded under.
+type fontMetricFixer struct{ doc *pdf.Reader }
 
 func (fontMetricFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Font.AdvanceWidthMismatch
 }
 
-func (fontMetricFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f fontMetricFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
 	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
-		if fixFontMetricsDict(d) {
+		if fixFontMetricsDict(d, f.doc) {
 			changed = true
 		}
 	})
@@ -84,13 +85,13 @@ func (fontMetricFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error)
 
 // fixTargeted repairs only the font dicts the issues reference; the verifier
 // reports every mismatching font per pass, so this covers all violations.
-func (fontMetricFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
+func (f fontMetricFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
 	targets, ok := p.dictsForIssues(issues)
 	if !ok {
 		return false, false, nil
 	}
 	for _, d := range targets {
-		if fixFontMetricsDict(d) {
+		if fixFontMetricsDict(d, f.doc) {
 			changed = true
 		}
 	}
@@ -100,7 +101,7 @@ func (fontMetricFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed,
 // fixFontMetricsDict recomputes d's width metadata from its embedded font
 // program if d is a font dict; it re-checks the predicate so a stale or
 // already-fixed target is a no-op.
-func fixFontMetricsDict(d pdf.PDFDict) bool {
+func fixFontMetricsDict(d pdf.PDFDict, doc *pdf.Reader) bool {
 	if (d.Entries["Type"] != pdf.PDFName{Value: "Font"}) {
 		return false
 	}
@@ -110,25 +111,25 @@ func fixFontMetricsDict(d pdf.PDFDict) bool {
 	switch subtype.Value {
 	case "TrueType":
 		if ff, ok := desc.Entries["FontFile2"].(pdf.PDFDict); ok {
-			return fixSimpleTrueTypeWidths(d, ff)
+			return fixSimpleTrueTypeWidths(d, ff, doc)
 		}
 	case "Type1", "MMType1":
 		if ff, ok := desc.Entries["FontFile"].(pdf.PDFDict); ok {
 			pdfEnc, _ := d.Entries["Encoding"].(pdf.PDFName)
-			return fixType1Widths(d, ff, pdfEnc.Value)
+			return fixType1Widths(d, ff, pdfEnc.Value, doc)
 		} else if ff, ok := desc.Entries["FontFile3"].(pdf.PDFDict); ok {
-			return fixType1CWidths(d, ff)
+			return fixType1CWidths(d, ff, doc)
 		}
 	case "CIDFontType2":
 		if ff, ok := desc.Entries["FontFile2"].(pdf.PDFDict); ok {
-			return fixCIDTrueTypeWidths(d, ff)
+			return fixCIDTrueTypeWidths(d, ff, doc)
 		}
 	case "CIDFontType0":
 		if ff, ok := desc.Entries["FontFile3"].(pdf.PDFDict); ok {
-			return fixCIDCFFWidths(d, ff)
+			return fixCIDCFFWidths(d, ff, doc)
 		}
 	case "Type3":
-		return fixType3Widths(d)
+		return fixType3Widths(d, doc)
 	}
 	return false
 }
@@ -136,13 +137,13 @@ func fixFontMetricsDict(d pdf.PDFDict) bool {
 // fixSimpleTrueTypeWidths rewrites mismatched /Widths entries to the
 // embedded TrueType program's hmtx advance width, mirroring
 // validateSimpleTrueTypeMetrics (checks_font_program.go).
-func fixSimpleTrueTypeWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
+func fixSimpleTrueTypeWidths(v pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
 	firstChar, fcOK := v.Entries["FirstChar"].(pdf.PDFInteger)
 	widths, wOK := v.Entries["Widths"].(pdf.PDFArray)
 	if !fcOK || !wOK {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -199,13 +200,13 @@ func fixSimpleTrueTypeWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
 // fixType1Widths rewrites mismatched /Widths entries to the embedded Type1
 // program's advance width, mirroring validateType1Metrics
 // (checks_font_program.go).
-func fixType1Widths(v pdf.PDFDict, ff pdf.PDFDict, pdfEncoding string) bool {
+func fixType1Widths(v pdf.PDFDict, ff pdf.PDFDict, pdfEncoding string, doc *pdf.Reader) bool {
 	firstChar, fcOK := v.Entries["FirstChar"].(pdf.PDFInteger)
 	widths, wOK := v.Entries["Widths"].(pdf.PDFArray)
 	if !fcOK || !wOK {
 		return false
 	}
-	fontData, err := pdf.DecodeStream(ff)
+	fontData, err := doc.DecodeStreamLimited(ff)
 	if err != nil || len(fontData) == 0 {
 		return false
 	}
@@ -245,13 +246,13 @@ func fixType1Widths(v pdf.PDFDict, ff pdf.PDFDict, pdfEncoding string) bool {
 // fixType1CWidths rewrites mismatched /Widths entries to the embedded CFF
 // program's charstring advance width, mirroring validateType1CMetrics
 // (checks_font_program.go).
-func fixType1CWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
+func fixType1CWidths(v pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
 	firstChar, fcOK := v.Entries["FirstChar"].(pdf.PDFInteger)
 	widths, wOK := v.Entries["Widths"].(pdf.PDFArray)
 	if !fcOK || !wOK {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil || len(data) == 0 {
 		return false
 	}
@@ -291,12 +292,12 @@ func fixType1CWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
 // fixCIDCFFWidths rewrites mismatched /W entries to the embedded CID-keyed
 // CFF program's charstring advance width, mirroring validateCIDCFFMetrics
 // (checks_font_program.go).
-func fixCIDCFFWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
+func fixCIDCFFWidths(v pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
 	w, ok := v.Entries["W"].(pdf.PDFArray)
 	if !ok {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -325,12 +326,12 @@ func fixCIDCFFWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
 // fixCIDTrueTypeWidths rewrites mismatched /W entries to the embedded
 // TrueType program's hmtx advance width, mirroring validateCIDTrueTypeMetrics
 // (checks_font_program.go).
-func fixCIDTrueTypeWidths(v pdf.PDFDict, ff pdf.PDFDict) bool {
+func fixCIDTrueTypeWidths(v pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
 	w, ok := v.Entries["W"].(pdf.PDFArray)
 	if !ok {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -379,7 +380,7 @@ func buildCIDWidthsArray(pairs [][2]int) pdf.PDFArray {
 // fixType3Widths rewrites mismatched /Widths entries to each glyph
 // procedure's own d0/d1 width, mirroring validateType3Metrics
 // (checks_font.go).
-func fixType3Widths(v pdf.PDFDict) bool {
+func fixType3Widths(v pdf.PDFDict, doc *pdf.Reader) bool {
 	firstChar, fcOK := v.Entries["FirstChar"].(pdf.PDFInteger)
 	widths, wOK := v.Entries["Widths"].(pdf.PDFArray)
 	charProcs, cpOK := v.Entries["CharProcs"].(pdf.PDFDict)
@@ -423,7 +424,7 @@ func fixType3Widths(v pdf.PDFDict) bool {
 		if !ok || !proc.HasStream {
 			continue
 		}
-		data, err := pdf.DecodeStream(proc)
+		data, err := doc.DecodeStreamLimited(proc)
 		if err != nil {
 			continue
 		}
@@ -445,8 +446,9 @@ func fixType3Widths(v pdf.PDFDict) bool {
 // Checks.Font.CIDSubsetCIDSet by synthesizing the missing/incomplete
 // /CharSet or /CIDSet from the glyphs actually present in the embedded
 // program, mirroring validateType1SubsetCoverage's CharSet-presence check
-// and validateCIDSetBitmap (checks_font.go/checks_font_program.go).
-type fontSubsetMetaFixer struct{}
+// and validateCIDSetBitmap (checks_font.go/checks_font_program.go). doc, the
+// run's Reader, supplies the limits the programs are decoded under.
+type fontSubsetMetaFixer struct{ doc *pdf.Reader }
 
 func (fontSubsetMetaFixer) Applies(c pdf.Check) bool {
 	switch c {
@@ -456,10 +458,10 @@ func (fontSubsetMetaFixer) Applies(c pdf.Check) bool {
 	return false
 }
 
-func (fontSubsetMetaFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f fontSubsetMetaFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
 	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
-		if fixFontSubsetMetaDict(d) {
+		if fixFontSubsetMetaDict(d, f.doc) {
 			changed = true
 		}
 	})
@@ -468,13 +470,13 @@ func (fontSubsetMetaFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, er
 
 // fixTargeted regenerates subset metadata only for the font dicts the issues
 // reference, falling back to the full walk when any issue lacks a ref.
-func (fontSubsetMetaFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
+func (f fontSubsetMetaFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
 	targets, ok := p.dictsForIssues(issues)
 	if !ok {
 		return false, false, nil
 	}
 	for _, d := range targets {
-		if fixFontSubsetMetaDict(d) {
+		if fixFontSubsetMetaDict(d, f.doc) {
 			changed = true
 		}
 	}
@@ -484,7 +486,7 @@ func (fontSubsetMetaFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (chang
 // fixFontSubsetMetaDict synthesizes /CharSet or /CIDSet for a subset font
 // dict; it re-checks the predicate so a stale or already-fixed target is a
 // no-op.
-func fixFontSubsetMetaDict(d pdf.PDFDict) bool {
+func fixFontSubsetMetaDict(d pdf.PDFDict, doc *pdf.Reader) bool {
 	if (d.Entries["Type"] != pdf.PDFName{Value: "Font"}) {
 		return false
 	}
@@ -500,14 +502,14 @@ func fixFontSubsetMetaDict(d pdf.PDFDict) bool {
 	subtype, _ := d.Entries["Subtype"].(pdf.PDFName)
 	switch subtype.Value {
 	case "Type1", "MMType1":
-		return fixType1CharSet(desc)
+		return fixType1CharSet(desc, doc)
 	case "CIDFontType0":
 		if ff, ok := desc.Entries["FontFile3"].(pdf.PDFDict); ok {
-			return fixCFFCIDSet(desc, ff)
+			return fixCFFCIDSet(desc, ff, doc)
 		}
 	case "CIDFontType2":
 		if ff, ok := desc.Entries["FontFile2"].(pdf.PDFDict); ok {
-			return fixTrueTypeCIDSet(d, desc, ff)
+			return fixTrueTypeCIDSet(d, desc, ff, doc)
 		}
 	}
 	return false
@@ -518,19 +520,19 @@ func fixFontSubsetMetaDict(d pdf.PDFDict) bool {
 // name-keyed CFF program's charset (FontFile3, "Type1C") -- when CharSet is
 // missing, empty, or lists a glyph the program does not define, mirroring the
 // checks in validateFontDict/ValidateType1SubsetCoverage.
-func fixType1CharSet(desc pdf.PDFDict) bool {
+func fixType1CharSet(desc pdf.PDFDict, doc *pdf.Reader) bool {
 	var names []string
 	switch {
 	case desc.Entries["FontFile"] != nil:
 		ff := desc.Entries["FontFile"].(pdf.PDFDict)
-		fontData, err := pdf.DecodeStream(ff)
+		fontData, err := doc.DecodeStreamLimited(ff)
 		if err != nil || len(fontData) == 0 {
 			return false
 		}
 		names = verify.Type1GlyphNames(fontData)
 	case desc.Entries["FontFile3"] != nil:
 		ff := desc.Entries["FontFile3"].(pdf.PDFDict)
-		data, err := pdf.DecodeStream(ff)
+		data, err := doc.DecodeStreamLimited(ff)
 		if err != nil {
 			return false
 		}
@@ -600,8 +602,8 @@ func cidSetComplete(bitmap []byte, cids []int) bool {
 
 // fixCFFCIDSet synthesizes or completes /CIDSet from the CID-keyed CFF
 // program's charset, mirroring validateCIDSetBitmap (checks_font_program.go).
-func fixCFFCIDSet(desc pdf.PDFDict, ff pdf.PDFDict) bool {
-	data, err := pdf.DecodeStream(ff)
+func fixCFFCIDSet(desc pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -616,7 +618,7 @@ func fixCFFCIDSet(desc pdf.PDFDict, ff pdf.PDFDict) bool {
 	}
 
 	if current, ok := desc.Entries["CIDSet"].(pdf.PDFDict); ok && current.HasStream {
-		if existing, err := pdf.DecodeStream(current); err == nil && cidSetComplete(existing, cids) {
+		if existing, err := doc.DecodeStreamLimited(current); err == nil && cidSetComplete(existing, cids) {
 			return false
 		}
 	}
@@ -632,13 +634,13 @@ func fixCFFCIDSet(desc pdf.PDFDict, ff pdf.PDFDict) bool {
 // fixTrueTypeCIDSet synthesizes or completes /CIDSet from the glyphs present
 // in a CIDFontType2 program. it handles both a missing CIDSet and an existing
 // one that omits a present glyph.
-func fixTrueTypeCIDSet(d, desc pdf.PDFDict, ff pdf.PDFDict) bool {
+func fixTrueTypeCIDSet(d, desc pdf.PDFDict, ff pdf.PDFDict, doc *pdf.Reader) bool {
 	// Only safe when CID==GID (the spec default); a stream CIDToGIDMap means
 	// CIDs don't correspond to GIDs directly.
 	if c2g := d.Entries["CIDToGIDMap"]; c2g != nil && c2g != (pdf.PDFName{Value: "Identity"}) {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -662,7 +664,7 @@ func fixTrueTypeCIDSet(d, desc pdf.PDFDict, ff pdf.PDFDict) bool {
 	}
 
 	if current, ok := desc.Entries["CIDSet"].(pdf.PDFDict); ok && current.HasStream {
-		if existing, err := pdf.DecodeStream(current); err == nil && cidSetComplete(existing, cids) {
+		if existing, err := doc.DecodeStreamLimited(current); err == nil && cidSetComplete(existing, cids) {
 			return false
 		}
 	}
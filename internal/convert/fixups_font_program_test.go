@@ -305,7 +305,7 @@ func TestFixCIDCFFWidthsCorrectsMismatch(t *testing.T) {
 		"W": pdf.PDFArray{pdf.PDFInteger(1), pdf.PDFArray{pdf.PDFInteger(500), pdf.PDFInteger(500)}},
 	}}
 
-	if !fixCIDCFFWidths(v, ff) {
+	if !fixCIDCFFWidths(v, ff, nil) {
 		t.Fatalf("fixCIDCFFWidths = false, want true (500/500 mismatches the embedded 600/700)")
 	}
 	want := map[int]int{1: 600, 2: 700}
@@ -316,7 +316,7 @@ func TestFixCIDCFFWidthsCorrectsMismatch(t *testing.T) {
 	}
 
 	// Idempotent: the now-correct widths should no longer trigger a change.
-	if fixCIDCFFWidths(v, ff) {
+	if fixCIDCFFWidths(v, ff, nil) {
 		t.Error("fixCIDCFFWidths on already-corrected widths = true, want false")
 	}
 }
@@ -324,7 +324,7 @@ func TestFixCIDCFFWidthsCorrectsMismatch(t *testing.T) {
 // TestFixCIDCFFWidthsNoOpWithoutW covers the missing-/W short-circuit.
 func TestFixCIDCFFWidthsNoOpWithoutW(t *testing.T) {
 	ff := pdf.PDFDict{HasStream: true, RawStream: buildMinimalCIDCFF()}
-	if fixCIDCFFWidths(pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}, ff) {
+	if fixCIDCFFWidths(pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}, ff, nil) {
 		t.Error("fixCIDCFFWidths without /W = true, want false")
 	}
 }
@@ -340,7 +340,7 @@ func TestFixTrueTypeCIDSetSkipsNonIdentityCIDToGIDMap(t *testing.T) {
 	desc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}
 	ff := pdf.PDFDict{HasStream: true, RawStream: ttf}
 
-	if fixTrueTypeCIDSet(d, desc, ff) {
+	if fixTrueTypeCIDSet(d, desc, ff, nil) {
 		t.Error("fixTrueTypeCIDSet with a stream CIDToGIDMap = true, want false")
 	}
 	if desc.Entries["CIDSet"] != nil {
@@ -356,10 +356,10 @@ func TestFixTrueTypeCIDSetNoOpWhenAlreadyComplete(t *testing.T) {
 	desc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{}}
 	ff := pdf.PDFDict{HasStream: true, RawStream: ttf}
 
-	if !fixTrueTypeCIDSet(d, desc, ff) {
+	if !fixTrueTypeCIDSet(d, desc, ff, nil) {
 		t.Fatal("sanity: first pass should populate CIDSet")
 	}
-	if fixTrueTypeCIDSet(d, desc, ff) {
+	if fixTrueTypeCIDSet(d, desc, ff, nil) {
 		t.Error("fixTrueTypeCIDSet on an already-complete CIDSet = true, want false")
 	}
 }
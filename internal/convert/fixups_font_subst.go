@@ -4,10 +4,9 @@ import (
 	_ "embed"
 	"encoding/binary"
 	"fmt"
-	"regexp"
 	"sort"
-	"strconv"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
 	"github.com/voidrab/gopdfrab/internal/verify"
@@ -318,7 +317,7 @@ func substituteCoversUsage(d pdf.PDFDict, usedCodes map[uintptr]map[int]bool, co
 // substituteSimpleFont rebuilds d in place as a non-symbolic TrueType font
 // embedding a subsetted bundled Liberation face, preserving FirstChar/
 // LastChar/Encoding so existing content-stream codes keep working.
-func substituteSimpleFont(d pdf.PDFDict, usedCodes map[uintptr]map[int]bool, sharedDescs map[uintptr]bool, nextObjNum *int) bool {
+func substituteSimpleFont(d pdf.PDFDict, usedCodes map[uintptr]map[int]bool, sharedDescs map[uintptr]bool, nextObjNum *int, doc *pdf.Reader) bool {
 	desc, ok := d.Entries["FontDescriptor"].(pdf.PDFDict)
 	if !ok || desc.Entries == nil {
 		return false
@@ -340,7 +339,7 @@ func substituteSimpleFont(d pdf.PDFDict, usedCodes map[uintptr]map[int]bool, sha
 	// The substitute keeps the content-stream bytes, so every used code must
 	// mean the same thing under the declared encoding as it originally did;
 	// otherwise a symbolic substitute preserves the codes' meanings directly.
-	origTable, baseKnown := originalSimpleFontCodeToUnicode(d)
+	origTable, baseKnown := originalSimpleFontCodeToUnicode(d, doc)
 	if !encodingRewritePreservesMeaning(d, usedCodes, origTable, codeToUnicode) {
 		return substituteSimpleFontSymbolic(d, usedCodes, origTable, baseKnown, sharedDescs, nextObjNum)
 	}
@@ -584,8 +583,18 @@ func substituteBaseFontName(face liberationFace, original string) string {
 // for a simple font, so text extraction keeps working after a symbolic
 // substitution removes the name encoding.
 func buildToUnicodeStream(codeUnicode map[int]uint16) (pdf.PDFDict, bool) {
-	codes := make([]int, 0, len(codeUnicode))
-	for cc := range codeUnicode {
+	codeText := make(map[int]string, len(codeUnicode))
+	for cc, u := range codeUnicode {
+		codeText[cc] = string(utf16.Decode([]uint16{u}))
+	}
+	return buildToUnicodeTextStream(codeText)
+}
+
+// buildToUnicodeTextStream is buildToUnicodeStream for destinations of any
+// length: ligatures and characters outside the BMP.
+func buildToUnicodeTextStream(codeText map[int]string) (pdf.PDFDict, bool) {
+	codes := make([]int, 0, len(codeText))
+	for cc := range codeText {
 		codes = append(codes, cc)
 	}
 	sort.Ints(codes)
@@ -603,7 +612,11 @@ func buildToUnicodeStream(codeUnicode map[int]uint16) (pdf.PDFDict, bool) {
 		}
 		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
 		for _, cc := range codes[start:end] {
-			fmt.Fprintf(&b, "<%02X> <%04X>\n", cc, codeUnicode[cc])
+			fmt.Fprintf(&b, "<%02X> <", cc)
+			for _, unit := range utf16.Encode([]rune(codeText[cc])) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
 		}
 		b.WriteString("endbfchar\n")
 	}
@@ -641,7 +654,7 @@ func applySubstituteDescriptor(desc pdf.PDFDict, tables map[string][]byte, progr
 
 // cidFontSubstitutionEligible reports whether a Type0 font carries a
 // directly-recoverable code/CID->Unicode mapping.
-func cidFontSubstitutionEligible(type0 pdf.PDFDict) (map[int]uint16, bool) {
+func cidFontSubstitutionEligible(type0 pdf.PDFDict, doc *pdf.Reader) (map[int]uint16, bool) {
 	enc, _ := type0.Entries["Encoding"].(pdf.PDFName)
 	if enc.Value != "Identity-H" && enc.Value != "Identity-V" {
 		return nil, false
@@ -650,7 +663,7 @@ func cidFontSubstitutionEligible(type0 pdf.PDFDict) (map[int]uint16, bool) {
 	if !ok || !toUni.HasStream {
 		return nil, false
 	}
-	data, err := pdf.DecodeStream(toUni)
+	data, err := doc.DecodeStreamLimited(toUni)
 	if err != nil {
 		return nil, false
 	}
@@ -694,7 +707,7 @@ func cidFontNeedsSubstitution(cid, desc pdf.PDFDict, usedCIDs map[uintptr]map[in
 
 // substituteCIDFont rebuilds a Type0 font's descendant in place as a
 // CIDFontType2 embedding a subsetted bundled Liberation face.
-func substituteCIDFont(type0, cid pdf.PDFDict, usedCIDs map[uintptr]map[int]bool, sharedDescs map[uintptr]bool, nextObjNum *int) bool {
+func substituteCIDFont(type0, cid pdf.PDFDict, usedCIDs map[uintptr]map[int]bool, sharedDescs map[uintptr]bool, nextObjNum *int, doc *pdf.Reader) bool {
 	desc, ok := cid.Entries["FontDescriptor"].(pdf.PDFDict)
 	if !ok || desc.Entries == nil {
 		return false
@@ -702,7 +715,7 @@ func substituteCIDFont(type0, cid pdf.PDFDict, usedCIDs map[uintptr]map[int]bool
 	if !cidFontNeedsSubstitution(cid, desc, usedCIDs) {
 		return false
 	}
-	cidToUnicode, ok := cidFontSubstitutionEligible(type0)
+	cidToUnicode, ok := cidFontSubstitutionEligible(type0, doc)
 	if !ok {
 		return false
 	}
@@ -816,7 +829,7 @@ func substituteCIDFont(type0, cid pdf.PDFDict, usedCIDs map[uintptr]map[int]bool
 	cid.Entries["W"] = buildCIDWidthsArray(widthPairs)
 	if ff, ok := desc.Entries["FontFile2"].(pdf.PDFDict); ok {
 		delete(desc.Entries, "CIDSet")
-		fixTrueTypeCIDSet(cid, desc, ff)
+		fixTrueTypeCIDSet(cid, desc, ff, doc)
 	}
 	cid.Entries["DW"] = pdf.PDFInteger(0)
 	return true
@@ -824,65 +837,17 @@ func substituteCIDFont(type0, cid pdf.PDFDict, usedCIDs map[uintptr]map[int]bool
 
 // --- /ToUnicode CMap parsing ---
 
-var (
-	toUnicodeBfCharBlockRe  = regexp.MustCompile(`(?s)beginbfchar(.*?)endbfchar`)
-	toUnicodeBfRangeBlockRe = regexp.MustCompile(`(?s)beginbfrange(.*?)endbfrange`)
-	toUnicodeBfCharEntryRe  = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>`)
-	toUnicodeBfRangeEntryRe = regexp.MustCompile(`(?s)<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(?:<([0-9A-Fa-f]+)>|\[([^\]]*)\])`)
-	toUnicodeHexTokenRe     = regexp.MustCompile(`<([0-9A-Fa-f]+)>`)
-)
-
-// hexToUnicode takes the first UTF-16 code unit of a ToUnicode destination
-// hex string as its Unicode value, ignoring surrogate pairs/ligatures.
-func hexToUnicode(hex string) (uint16, bool) {
-	b := pdf.DecodePDFHexStringBytes(hex)
-	if len(b) < 2 {
-		return 0, false
-	}
-	return binary.BigEndian.Uint16(b[:2]), true
-}
-
 // parseToUnicodeCMap extracts a code->Unicode mapping from a /ToUnicode
-// CMap stream's bfchar/bfrange blocks (PDF 32000-1, 9.10.3).
+// CMap stream (PDF 32000-1, 9.10.3), keeping the first UTF-16 code unit of
+// each destination: a ligature maps to its first character, a character
+// outside the BMP to its high surrogate.
 func parseToUnicodeCMap(data []byte) map[int]uint16 {
 	result := map[int]uint16{}
-	s := string(data)
-	for _, block := range toUnicodeBfCharBlockRe.FindAllStringSubmatch(s, -1) {
-		for _, m := range toUnicodeBfCharEntryRe.FindAllStringSubmatch(block[1], -1) {
-			code, err := strconv.ParseInt(m[1], 16, 32)
-			if err != nil {
-				continue
-			}
-			if u, ok := hexToUnicode(m[2]); ok {
-				result[int(code)] = u
-			}
+	pdf.ParseToUnicodeCMap(data).Each(func(code uint32, _ int, text string) {
+		if units := utf16.Encode([]rune(text)); len(units) > 0 {
+			result[int(code)] = units[0]
 		}
-	}
-	for _, block := range toUnicodeBfRangeBlockRe.FindAllStringSubmatch(s, -1) {
-		for _, m := range toUnicodeBfRangeEntryRe.FindAllStringSubmatch(block[1], -1) {
-			lo, errLo := strconv.ParseInt(m[1], 16, 32)
-			hi, errHi := strconv.ParseInt(m[2], 16, 32)
-			if errLo != nil || errHi != nil || hi < lo {
-				continue
-			}
-			if m[3] != "" {
-				base, ok := hexToUnicode(m[3])
-				if !ok {
-					continue
-				}
-				for c := lo; c <= hi; c++ {
-					result[int(c)] = base + uint16(c-lo)
-				}
-			} else if m[4] != "" {
-				dsts := toUnicodeHexTokenRe.FindAllStringSubmatch(m[4], -1)
-				for i, c := 0, lo; i < len(dsts) && c <= hi; i, c = i+1, c+1 {
-					if u, ok := hexToUnicode(dsts[i][1]); ok {
-						result[int(c)] = u
-					}
-				}
-			}
-		}
-	}
+	})
 	return result
 }
 
@@ -949,7 +914,7 @@ func (f fontSubstitutionFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError)
 		if !hadDescriptor {
 			d.Entries["FontDescriptor"] = pdf.NewPDFDict()
 		}
-		if substituteSimpleFont(d, usedCodes, sharedDescs, &nextObjNum) {
+		if substituteSimpleFont(d, usedCodes, sharedDescs, &nextObjNum, f.doc) {
 			changed = true
 		} else if !hadDescriptor {
 			delete(d.Entries, "FontDescriptor")
@@ -957,7 +922,7 @@ func (f fontSubstitutionFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError)
 	}
 	for _, d := range composite {
 		if cid := verify.DescendantCIDFont(d); cid.Entries != nil {
-			if substituteCIDFont(d, cid, usedCIDs, sharedDescs, &nextObjNum) {
+			if substituteCIDFont(d, cid, usedCIDs, sharedDescs, &nextObjNum, f.doc) {
 				changed = true
 			}
 		}
@@ -1007,7 +972,7 @@ func (f trueTypeEncodingFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError)
 				delete(d.Entries, "Encoding")
 				changed = true
 			}
-			if trimSymbolicCmap(desc) {
+			if trimSymbolicCmap(desc, f.doc) {
 				changed = true
 			}
 			return
@@ -1016,7 +981,7 @@ func (f trueTypeEncodingFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError)
 			// The font keeps its program and content bytes, so the replacement
 			// name encoding must preserve what every used code meant; when
 			// neither does, leave the violation for the raster fallback.
-			orig, _ := originalSimpleFontCodeToUnicode(d)
+			orig, _ := originalSimpleFontCodeToUnicode(d, f.doc)
 			for _, cand := range [...]struct {
 				name  string
 				table [256]uint16
@@ -1037,12 +1002,12 @@ func (f trueTypeEncodingFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError)
 
 // trimSymbolicCmap reduces desc's embedded FontFile2's cmap to a single
 // subtable in place, leaving glyph data untouched.
-func trimSymbolicCmap(desc pdf.PDFDict) bool {
+func trimSymbolicCmap(desc pdf.PDFDict, doc *pdf.Reader) bool {
 	ff, ok := desc.Entries["FontFile2"].(pdf.PDFDict)
 	if !ok || !ff.HasStream {
 		return false
 	}
-	data, err := pdf.DecodeStream(ff)
+	data, err := doc.DecodeStreamLimited(ff)
 	if err != nil {
 		return false
 	}
@@ -173,7 +173,7 @@ func TestCIDFontSubstitutionEligible(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			got, ok := cidFontSubstitutionEligible(c.d)
+			got, ok := cidFontSubstitutionEligible(c.d, nil)
 			if ok != c.ok {
 				t.Fatalf("cidFontSubstitutionEligible ok = %v, want %v", ok, c.ok)
 			}
@@ -184,16 +184,6 @@ func TestCIDFontSubstitutionEligible(t *testing.T) {
 	}
 }
 
-// TestHexToUnicode covers the direct-value and too-short-to-decode paths.
-func TestHexToUnicode(t *testing.T) {
-	if got, ok := hexToUnicode("0041"); !ok || got != 0x0041 {
-		t.Errorf("hexToUnicode(0041) = (%04X, %v), want (0041, true)", got, ok)
-	}
-	if _, ok := hexToUnicode("41"); ok {
-		t.Error("hexToUnicode(41) (single byte) ok = true, want false")
-	}
-}
-
 // TestParseToUnicodeCMap covers all three bfchar/bfrange forms the parser
 // supports (single bfchar entries are already exercised via
 // assertSymbolicSubstitute): a bfrange with a single hex base (incrementing
@@ -422,7 +412,7 @@ func TestTrimSymbolicCmap(t *testing.T) {
 	desc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"FontFile2": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: data},
 	}}
-	if !trimSymbolicCmap(desc) {
+	if !trimSymbolicCmap(desc, nil) {
 		t.Fatal("trimSymbolicCmap on a multi-subtable face = false, want true")
 	}
 	ff := desc.Entries["FontFile2"].(pdf.PDFDict)
@@ -438,7 +428,7 @@ func TestTrimSymbolicCmap(t *testing.T) {
 	desc2 := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
 		"FontFile2": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: trimmed},
 	}}
-	if trimSymbolicCmap(desc2) {
+	if trimSymbolicCmap(desc2, nil) {
 		t.Error("trimSymbolicCmap on an already-single-subtable face = true, want false")
 	}
 }
@@ -183,15 +183,16 @@ func fixInlineImageInterpolate(op string, operands []pdf.PDFValue, changed *bool
 
 // inlineImageLZWFixer remediates Checks.Structure.InlineImageLZWFilter by
 // decoding an inline image's LZW-filtered data and re-encoding it as Flate,
-// mirroring checkInlineImageFilter (checks_content.go) in reverse.
-type inlineImageLZWFixer struct{}
+// mirroring checkInlineImageFilter (checks_content.go) in reverse. doc, the
+// run's Reader, supplies the limits content streams are decoded under.
+type inlineImageLZWFixer struct{ doc *pdf.Reader }
 
 func (inlineImageLZWFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.InlineImageLZWFilter
 }
 
-func (inlineImageLZWFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
-	return walkContentStreams(trailer, fixInlineImageLZW), nil
+func (f inlineImageLZWFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	return walkContentStreams(trailer, fixInlineImageLZW, f.doc), nil
 }
 
 // fixInlineImageLZW re-encodes an inline image's data from LZW to Flate,
@@ -21,12 +21,21 @@ func init() {
 	// The Kids rebalance joins the shared pre-emptive walk; the structure
 	// drop runs after that walk, so it never sees an oversized Kids array
 	// the rebalance could have split (the struct tree reaches Pages nodes
-	// via Pg references).
-	registerPreemptiveVisitor(func(trailer *pdf.PDFDict, _ *pdf.Reader) func(pdf.PDFDict) {
+	// via Pg references). PDF/A-2 has no array limit, so the structure drop
+	// is part 1 only, and a level A target, which requires the structure
+	// tree, gets the split instead.
+	registerPreemptiveVisitor(func(trailer *pdf.PDFDict, _ *pdf.Reader, _ *pdf.Profile) func(pdf.PDFDict) {
 		return pagesKidsRebalanceVisitor(trailer, nil)
 	})
-	registerPreemptiveAfterFixup(func(trailer *pdf.PDFDict, _ *pdf.Reader) error {
-		dropOversizedStructure(trailer)
+	registerPreemptiveAfterFixup(func(trailer *pdf.PDFDict, _ *pdf.Reader, p *pdf.Profile) error {
+		if targetPart(p) >= 2 {
+			return nil
+		}
+		if p != nil && p.Level.RequiresLogicalStructure() {
+			splitOversizedStructure(trailer)
+		} else {
+			dropOversizedStructure(trailer)
+		}
 		return nil
 	})
 }
@@ -63,20 +72,28 @@ const pagesTreeChunkSize = 4096
 // for documents with very many pages. Page discovery (buildPageIndex,
 // document.go) and any other /Kids walker already recurse through
 // arbitrary nesting depth, so this never changes page count, order, or any
-// page's content; it only restructures the tree.
-type pagesTreeArrayFixer struct{}
+// page's content; it only restructures the tree. keepStructure is set for a
+// level A target (see buildLocalFixers).
+type pagesTreeArrayFixer struct {
+	keepStructure bool
+}
 
 func (pagesTreeArrayFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.ArrayTooLarge
 }
 
-func (pagesTreeArrayFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
+func (f pagesTreeArrayFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
 	changed := false
 	walkDicts(*trailer, map[uintptr]bool{}, pagesKidsRebalanceVisitor(trailer, &changed))
 	// The logical structure tree's per-page parent arrays (positional MCID ->
 	// element maps) can exceed the array limit and cannot be split. PDF/A-1b
-	// (level B) does not require structure, so drop it rather than rasterize.
-	if dropOversizedStructure(trailer) {
+	// (level B) does not require structure, so drop it rather than rasterize;
+	// level A requires it, so split what can be split and leave the rest.
+	restructure := dropOversizedStructure
+	if f.keepStructure {
+		restructure = splitOversizedStructure
+	}
+	if restructure(trailer) {
 		changed = true
 	}
 	return changed, nil
@@ -128,6 +145,118 @@ func dropOversizedStructure(trailer *pdf.PDFDict) bool {
 	return true
 }
 
+// structureChunkSize is how many entries each number-tree leaf or NonStruct
+// group splitOversizedStructure creates holds, well under
+// maxPDFArrayElements.
+const structureChunkSize = 4096
+
+// splitOversizedStructure is dropOversizedStructure for a level A target,
+// which must keep its structure tree. It splits an oversized ParentTree Nums
+// array into a number tree of leaf Kids, and groups the kids of a structure
+// element whose K array is oversized into NonStruct elements, which carry no
+// semantics of their own. A K array holding marked-content or object
+// references is left alone (regrouping those would orphan the ParentTree's
+// parent pointers), as are the per-page parent arrays.
+func splitOversizedStructure(trailer *pdf.PDFDict) bool {
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return false
+	}
+	st, ok := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	if !ok || !hasOversizedArray(st, map[uintptr]bool{}) {
+		return false
+	}
+	nextObjNum := nextAvailableObjNum(*trailer)
+	changed := false
+
+	if pt, ok := st.Entries["ParentTree"].(pdf.PDFDict); ok {
+		if nums, ok := pt.Entries["Nums"].(pdf.PDFArray); ok && len(nums) > maxPDFArrayElements {
+			pt.Entries["Kids"] = splitNumberTree(nums, &nextObjNum)
+			delete(pt.Entries, "Nums")
+			changed = true
+		}
+	}
+
+	visited := map[uintptr]bool{}
+	var walk func(elem pdf.PDFDict)
+	walk = func(elem pdf.PDFDict) {
+		ptr := pdf.ValuePointer(elem.Entries)
+		if visited[ptr] {
+			return
+		}
+		visited[ptr] = true
+		kids, ok := elem.Entries["K"].(pdf.PDFArray)
+		if ok && len(kids) > maxPDFArrayElements && allStructElems(kids) {
+			kids = groupStructKids(elem, kids, &nextObjNum)
+			elem.Entries["K"] = kids
+			changed = true
+		}
+		switch k := elem.Entries["K"].(type) {
+		case pdf.PDFDict:
+			walk(k)
+		case pdf.PDFArray:
+			for _, kid := range k {
+				if kd, ok := kid.(pdf.PDFDict); ok {
+					walk(kd)
+				}
+			}
+		}
+	}
+	walk(st)
+	return changed
+}
+
+// splitNumberTree splits a number tree's flat key/value Nums array into leaf
+// nodes of structureChunkSize entries, each with the Limits of its keys, and
+// returns them as the root's new Kids.
+func splitNumberTree(nums pdf.PDFArray, nextObjNum *int) pdf.PDFArray {
+	var kids pdf.PDFArray
+	for i := 0; i < len(nums)-1; i += structureChunkSize {
+		chunk := append(pdf.PDFArray{}, nums[i:min(i+structureChunkSize, len(nums)&^1)]...)
+		leaf := pdf.NewPDFDict()
+		leaf.Entries["_ref"] = pdf.PDFRef{ObjNum: *nextObjNum}
+		*nextObjNum++
+		leaf.Entries["Nums"] = chunk
+		leaf.Entries["Limits"] = pdf.PDFArray{chunk[0], chunk[len(chunk)-2]}
+		kids = append(kids, leaf)
+	}
+	return kids
+}
+
+// allStructElems reports whether every item is a structure element
+// dictionary (one with an S entry).
+func allStructElems(items pdf.PDFArray) bool {
+	for _, item := range items {
+		d, ok := item.(pdf.PDFDict)
+		if !ok || d.Entries["S"] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// groupStructKids wraps kids, the structure elements under parent, into
+// NonStruct elements of structureChunkSize kids each, re-pointing every
+// kid's P to its new group, and returns the groups as parent's new K.
+func groupStructKids(parent pdf.PDFDict, kids pdf.PDFArray, nextObjNum *int) pdf.PDFArray {
+	var out pdf.PDFArray
+	for i := 0; i < len(kids); i += structureChunkSize {
+		chunk := append(pdf.PDFArray{}, kids[i:min(i+structureChunkSize, len(kids))]...)
+		group := pdf.NewPDFDict()
+		group.Entries["_ref"] = pdf.PDFRef{ObjNum: *nextObjNum}
+		*nextObjNum++
+		group.Entries["Type"] = pdf.PDFName{Value: "StructElem"}
+		group.Entries["S"] = pdf.PDFName{Value: "NonStruct"}
+		group.Entries["P"] = parent
+		group.Entries["K"] = chunk
+		for _, kid := range chunk {
+			kid.(pdf.PDFDict).Entries["P"] = group
+		}
+		out = append(out, group)
+	}
+	return out
+}
+
 // hasOversizedArray reports whether v, or anything reachable from it, is an
 // array exceeding maxPDFArrayElements. /Parent and /P back-pointers are skipped
 // to avoid walking back out of the subtree being inspected.
@@ -230,15 +359,16 @@ var resourceCategories = []string{"Font", "XObject", "ColorSpace", "Pattern", "S
 // construction, since a dropped entry was never selected by anything. If
 // pruning every unused entry still isn't enough to get under the limit (more
 // used entries than the limit allows), it's left as residual rather than
-// risk breaking a live reference.
-type resourceDictPruneFixer struct{}
+// risk breaking a live reference. doc, the run's Reader, supplies the limits
+// the scanned content streams are decoded under.
+type resourceDictPruneFixer struct{ doc *pdf.Reader }
 
 func (resourceDictPruneFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.DictTooLarge
 }
 
-func (resourceDictPruneFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
-	usage := computeResourceUsage(*trailer)
+func (f resourceDictPruneFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
+	usage := computeResourceUsage(*trailer, f.doc)
 	changed := false
 	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
 		for _, cat := range resourceCategories {
@@ -288,10 +418,12 @@ func pruneUnusedResourceEntries(sub pdf.PDFDict, used map[string]bool) bool {
 
 // resourceUsage maps a /Resources sub-dictionary's Entries-map pointer to
 // the set of key names actually selected by a resource-referencing operator
-// in some content stream reachable from it.
+// in some content stream reachable from it. doc supplies the limits the
+// content streams are decoded under.
 type resourceUsage struct {
 	used        map[uintptr]map[string]bool
 	visitedForm map[uintptr]bool
+	doc         *pdf.Reader
 }
 
 // computeResourceUsage walks every Page's content (and, recursively, any
@@ -301,8 +433,8 @@ type resourceUsage struct {
 // scn/SCN, sh, gs or BDC/DP operator respectively. Tiling patterns' own
 // content isn't recursed into -- no corpus fixture needs that -- so usage
 // inside a pattern's paint procedure isn't tracked here.
-func computeResourceUsage(graph pdf.PDFValue) map[uintptr]map[string]bool {
-	ru := &resourceUsage{used: map[uintptr]map[string]bool{}, visitedForm: map[uintptr]bool{}}
+func computeResourceUsage(graph pdf.PDFValue, doc *pdf.Reader) map[uintptr]map[string]bool {
+	ru := &resourceUsage{used: map[uintptr]map[string]bool{}, visitedForm: map[uintptr]bool{}, doc: doc}
 	visited := map[uintptr]bool{}
 
 	var walk func(v pdf.PDFValue)
@@ -341,14 +473,14 @@ func collectResourceUsageFromContents(contents pdf.PDFValue, resources pdf.PDFDi
 	switch v := contents.(type) {
 	case pdf.PDFDict:
 		if v.HasStream {
-			if data, err := pdf.DecodeStream(v); err == nil {
+			if data, err := ru.doc.DecodeStreamLimited(v); err == nil {
 				collectResourceUsageFromBytes(data, resources, ru)
 			}
 		}
 	case pdf.PDFArray:
 		for _, item := range v {
 			if d, ok := item.(pdf.PDFDict); ok && d.HasStream {
-				if data, err := pdf.DecodeStream(d); err == nil {
+				if data, err := ru.doc.DecodeStreamLimited(d); err == nil {
 					collectResourceUsageFromBytes(data, resources, ru)
 				}
 			}
@@ -402,7 +534,7 @@ func collectResourceUsageFromBytes(data []byte, resources pdf.PDFDict, ru *resou
 			if subResources.Entries == nil {
 				subResources = resources
 			}
-			if subData, err := pdf.DecodeStream(xobj); err == nil {
+			if subData, err := ru.doc.DecodeStreamLimited(xobj); err == nil {
 				collectResourceUsageFromBytes(subData, subResources, ru)
 			}
 		case "Tf":
@@ -463,13 +595,14 @@ const maxNameLength = 127
 // to a short, collision-free replacement, with every content-stream
 // operator referencing the old name (in a /Resources category that key
 // belongs to) rewritten to the new one so resource lookups still resolve.
-type nameTooLongFixer struct{}
+// doc, the run's Reader, supplies the limits those streams are decoded under.
+type nameTooLongFixer struct{ doc *pdf.Reader }
 
 func (nameTooLongFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.NameTooLong
 }
 
-func (nameTooLongFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
+func (f nameTooLongFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool, error) {
 	changed := false
 
 	walkScalars(*trailer, map[uintptr]bool{}, func(v pdf.PDFValue) (pdf.PDFValue, bool) {
@@ -487,7 +620,7 @@ func (nameTooLongFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool,
 		}
 	})
 	if len(renames) > 0 {
-		renameResourceReferences(trailer, renames)
+		renameResourceReferences(trailer, renames, f.doc)
 	}
 
 	return changed, nil
@@ -496,7 +629,7 @@ func (nameTooLongFixer) Fix(trailer *pdf.PDFDict, issues []pdf.PDFError) (bool,
 // fixTargeted remediates both NameTooLong flavours on just the dicts the
 // issues reference: overlong keys are renamed, and overlong name values
 // owned by the dict (entries and their arrays) are truncated.
-func (nameTooLongFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
+func (f nameTooLongFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
 	targets, ok := p.dictsForIssues(issues)
 	if !ok {
 		return false, false, nil
@@ -511,7 +644,7 @@ func (nameTooLongFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed,
 		}
 	}
 	if len(renames) > 0 {
-		renameResourceReferences(p.trailer, renames)
+		renameResourceReferences(p.trailer, renames, f.doc)
 	}
 	return changed, true, nil
 }
@@ -573,7 +706,7 @@ func shortenDictKey(d pdf.PDFDict, k string) string {
 // gs, cs/CS, scn/SCN, sh, BDC/DP -- the same operators
 // collectResourceUsageFromBytes recognises) selecting one of the renamed
 // keys to use its replacement instead, via walkResourceAwareContent.
-func renameResourceReferences(trailer *pdf.PDFDict, renames map[uintptr]map[string]string) {
+func renameResourceReferences(trailer *pdf.PDFDict, renames map[uintptr]map[string]string, doc *pdf.Reader) {
 	walkResourceAwareContent(trailer, func(op string, operands []pdf.PDFValue, resources pdf.PDFDict, changed *bool) {
 		category, fromEnd := resourceOperatorTarget(op)
 		if category == "" || fromEnd >= len(operands) {
@@ -596,7 +729,7 @@ func renameResourceReferences(trailer *pdf.PDFDict, renames map[uintptr]map[stri
 			operands[idx] = pdf.PDFName{Value: newName}
 			*changed = true
 		}
-	})
+	}, doc)
 }
 
 // resourceOperatorTarget reports which /Resources category a resource-
@@ -634,17 +767,18 @@ const maxCMapCID = 65535
 // clamping any cidrange/cidchar CID value over 65535 down to 65535 directly
 // within the CMap's PostScript stream bytes, mirroring
 // checkCMapCIDLimits' own token-position state machine so it only ever
-// touches the exact values that check would flag.
-type cmapCIDClampFixer struct{}
+// touches the exact values that check would flag. doc, the run's Reader,
+// supplies the limits the CMap streams are decoded under.
+type cmapCIDClampFixer struct{ doc *pdf.Reader }
 
 func (cmapCIDClampFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.CMapCIDOutOfRange
 }
 
-func (cmapCIDClampFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f cmapCIDClampFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
 	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
-		updated, ok := clampCMapStreamDict(d)
+		updated, ok := clampCMapStreamDict(d, f.doc)
 		if ok {
 			changed = true
 		}
@@ -655,13 +789,13 @@ func (cmapCIDClampFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, erro
 
 // fixTargeted clamps only the CMap streams the issues reference, writing the
 // rewritten dict back into every referencing slot via the pass index.
-func (cmapCIDClampFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
+func (f cmapCIDClampFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed, handled bool, err error) {
 	targets, ok := p.dictsForIssues(issues)
 	if !ok {
 		return false, false, nil
 	}
 	for _, d := range targets {
-		updated, ok := clampCMapStreamDict(d)
+		updated, ok := clampCMapStreamDict(d, f.doc)
 		if !ok {
 			continue
 		}
@@ -673,11 +807,12 @@ func (cmapCIDClampFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (changed
 
 // clampCMapStreamDict returns a copy of d with out-of-range CIDs clamped in
 // its decoded stream, or ok=false when d is not a CMap stream or needs no fix.
-func clampCMapStreamDict(d pdf.PDFDict) (pdf.PDFDict, bool) {
+// The stream is decoded under doc's limits.
+func clampCMapStreamDict(d pdf.PDFDict, doc *pdf.Reader) (pdf.PDFDict, bool) {
 	if (d.Entries["Type"] != pdf.PDFName{Value: "CMap"}) || !d.HasStream {
 		return d, false
 	}
-	data, err := pdf.DecodeStream(d)
+	data, err := doc.DecodeStreamLimited(d)
 	if err != nil {
 		return d, false
 	}
@@ -762,8 +897,9 @@ type resourceOpRewriter func(op string, operands []pdf.PDFValue, resources pdf.P
 // which has no Resources context, this exists specifically for rewrites
 // that need to know which resource dictionary a name operand selects from
 // (renameResourceReferences, above). Tiling patterns' own content isn't
-// recursed into, matching computeResourceUsage's same scope.
-func walkResourceAwareContent(trailer *pdf.PDFDict, rewrite resourceOpRewriter) bool {
+// recursed into, matching computeResourceUsage's same scope. Streams are
+// decoded under doc's limits.
+func walkResourceAwareContent(trailer *pdf.PDFDict, rewrite resourceOpRewriter, doc *pdf.Reader) bool {
 	changed := false
 	visited := map[uintptr]bool{}
 	visitedForm := map[uintptr]bool{}
@@ -779,7 +915,7 @@ func walkResourceAwareContent(trailer *pdf.PDFDict, rewrite resourceOpRewriter)
 			visited[ptr] = true
 			if val.Entries["Type"] == (pdf.PDFName{Value: "Page"}) {
 				resources, _ := val.Entries["Resources"].(pdf.PDFDict)
-				rewritePageContents(val, resources, rewrite, visitedForm, &changed)
+				rewritePageContents(val, resources, rewrite, visitedForm, &changed, doc)
 				return
 			}
 			for _, child := range val.Entries {
@@ -800,11 +936,11 @@ func walkResourceAwareContent(trailer *pdf.PDFDict, rewrite resourceOpRewriter)
 	return changed
 }
 
-func rewritePageContents(page, resources pdf.PDFDict, rewrite resourceOpRewriter, visitedForm map[uintptr]bool, changed *bool) {
+func rewritePageContents(page, resources pdf.PDFDict, rewrite resourceOpRewriter, visitedForm map[uintptr]bool, changed *bool, doc *pdf.Reader) {
 	switch v := page.Entries["Contents"].(type) {
 	case pdf.PDFDict:
 		if v.HasStream {
-			if fixed, ok := rewriteResourceAwareStream(v, resources, rewrite, visitedForm); ok {
+			if fixed, ok := rewriteResourceAwareStream(v, resources, rewrite, visitedForm, doc); ok {
 				page.Entries["Contents"] = fixed
 				*changed = true
 			}
@@ -815,7 +951,7 @@ func rewritePageContents(page, resources pdf.PDFDict, rewrite resourceOpRewriter
 			if !ok || !d.HasStream {
 				continue
 			}
-			if fixed, ok := rewriteResourceAwareStream(d, resources, rewrite, visitedForm); ok {
+			if fixed, ok := rewriteResourceAwareStream(d, resources, rewrite, visitedForm, doc); ok {
 				v[i] = fixed
 				*changed = true
 			}
@@ -823,8 +959,8 @@ func rewritePageContents(page, resources pdf.PDFDict, rewrite resourceOpRewriter
 	}
 }
 
-func rewriteResourceAwareStream(dict, resources pdf.PDFDict, rewrite resourceOpRewriter, visitedForm map[uintptr]bool) (pdf.PDFDict, bool) {
-	data, err := pdf.DecodeStream(dict)
+func rewriteResourceAwareStream(dict, resources pdf.PDFDict, rewrite resourceOpRewriter, visitedForm map[uintptr]bool, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	data, err := doc.DecodeStreamLimited(dict)
 	if err != nil {
 		return dict, false
 	}
@@ -859,7 +995,7 @@ func rewriteResourceAwareStream(dict, resources pdf.PDFDict, rewrite resourceOpR
 		if subResources.Entries == nil {
 			subResources = resources
 		}
-		if fixed, ok := rewriteResourceAwareStream(xobj, subResources, rewrite, visitedForm); ok {
+		if fixed, ok := rewriteResourceAwareStream(xobj, subResources, rewrite, visitedForm, doc); ok {
 			xobjects.Entries[name.Value] = fixed
 			modified = true
 		}
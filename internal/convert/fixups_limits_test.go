@@ -111,6 +111,98 @@ func TestDropOversizedStructureNoOp(t *testing.T) {
 	}
 }
 
+// TestSplitOversizedStructure covers the level A counterpart of
+// dropOversizedStructure: an oversized ParentTree becomes a number tree and
+// an oversized K array of structure elements is grouped into NonStruct
+// elements, while the tree itself and the MarkInfo survive.
+func TestSplitOversizedStructure(t *testing.T) {
+	n := maxPDFArrayElements + 1
+	st := pdf.NewPDFDict()
+	doc := pdf.NewPDFDict()
+	doc.Entries["S"] = pdf.PDFName{Value: "Document"}
+	doc.Entries["P"] = st
+	kids := make(pdf.PDFArray, n)
+	nums := make(pdf.PDFArray, 0, 2*n)
+	for i := range kids {
+		p := pdf.NewPDFDict()
+		p.Entries["S"] = pdf.PDFName{Value: "P"}
+		p.Entries["P"] = doc
+		kids[i] = p
+		nums = append(nums, pdf.PDFInteger(i), p)
+	}
+	doc.Entries["K"] = kids
+	parentTree := pdf.NewPDFDict()
+	parentTree.Entries["Nums"] = nums
+	st.Entries["K"] = doc
+	st.Entries["ParentTree"] = parentTree
+	root := pdf.NewPDFDict()
+	root.Entries["StructTreeRoot"] = st
+	root.Entries["MarkInfo"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Marked": pdf.PDFBoolean(true)}}
+	trailer := pdf.NewPDFDict()
+	trailer.Entries["Root"] = root
+
+	changed, err := pagesTreeArrayFixer{keepStructure: true}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("Fix = %v, %v; want changed", changed, err)
+	}
+	if root.Entries["StructTreeRoot"] == nil || root.Entries["MarkInfo"] == nil {
+		t.Fatal("structure tree dropped for a level A target")
+	}
+	if hasOversizedArray(st, map[uintptr]bool{}) {
+		t.Error("structure tree still holds an oversized array")
+	}
+
+	leaves := parentTree.Entries["Kids"].(pdf.PDFArray)
+	total := 0
+	for _, l := range leaves {
+		leaf := l.(pdf.PDFDict)
+		leafNums := leaf.Entries["Nums"].(pdf.PDFArray)
+		limits := leaf.Entries["Limits"].(pdf.PDFArray)
+		if limits[0] != leafNums[0] || limits[1] != leafNums[len(leafNums)-2] {
+			t.Errorf("leaf Limits %v do not bound its keys", limits)
+		}
+		total += len(leafNums)
+	}
+	if total != 2*n || parentTree.Entries["Nums"] != nil {
+		t.Errorf("ParentTree leaves hold %d entries, want %d and no root Nums", total, 2*n)
+	}
+
+	groups := doc.Entries["K"].(pdf.PDFArray)
+	count := 0
+	for _, g := range groups {
+		group := g.(pdf.PDFDict)
+		if group.Entries["S"] != (pdf.PDFName{Value: "NonStruct"}) || pdf.ValuePointer(group.Entries["P"].(pdf.PDFDict).Entries) != pdf.ValuePointer(doc.Entries) {
+			t.Fatalf("group %v is not a NonStruct child of Document", group.Entries["S"])
+		}
+		for _, k := range group.Entries["K"].(pdf.PDFArray) {
+			if pdf.ValuePointer(k.(pdf.PDFDict).Entries["P"].(pdf.PDFDict).Entries) != pdf.ValuePointer(group.Entries) {
+				t.Fatal("grouped element's P does not point at its group")
+			}
+			count++
+		}
+	}
+	if count != n {
+		t.Errorf("groups hold %d elements, want %d", count, n)
+	}
+}
+
+// TestSplitOversizedStructureLeavesReferences confirms a K array holding
+// marked-content references is not regrouped, which would detach them from
+// the ParentTree's parent pointers.
+func TestSplitOversizedStructureLeavesReferences(t *testing.T) {
+	mcids := make(pdf.PDFArray, maxPDFArrayElements+1)
+	for i := range mcids {
+		mcids[i] = pdf.PDFInteger(i)
+	}
+	st := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"K": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"S": pdf.PDFName{Value: "P"}, "K": mcids,
+	}}}}
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"StructTreeRoot": st}}}}
+	if splitOversizedStructure(&trailer) {
+		t.Error("splitOversizedStructure regrouped marked-content references")
+	}
+}
+
 func TestCountPageLeaves(t *testing.T) {
 	items := pdf.PDFArray{
 		pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "Page"}}},
@@ -168,7 +260,7 @@ func TestComputeResourceUsage(t *testing.T) {
 		}},
 	}}
 
-	used := computeResourceUsage(trailer)
+	used := computeResourceUsage(trailer, nil)
 	if set := used[pdf.ValuePointer(fontSub.Entries)]; set == nil || !set["F1"] {
 		t.Errorf("Font F1 not marked used: %v", used[pdf.ValuePointer(fontSub.Entries)])
 	}
@@ -258,11 +350,11 @@ func TestNameTooLongFixerRenamesResourceReferencesAcrossContentShapes(t *testing
 // that isn't a stream-bearing /Type /CMap must be left untouched.
 func TestClampCMapStreamDictSkipsNonCMap(t *testing.T) {
 	notCMap := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "Font"}}, HasStream: true, RawStream: []byte("1 begincidchar\n<0041> 70000\nendcidchar\n")}
-	if _, ok := clampCMapStreamDict(notCMap); ok {
+	if _, ok := clampCMapStreamDict(notCMap, nil); ok {
 		t.Error("clampCMapStreamDict on a non-CMap dict = true, want false")
 	}
 	noStream := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Type": pdf.PDFName{Value: "CMap"}}}
-	if _, ok := clampCMapStreamDict(noStream); ok {
+	if _, ok := clampCMapStreamDict(noStream, nil); ok {
 		t.Error("clampCMapStreamDict on a streamless CMap dict = true, want false")
 	}
 }
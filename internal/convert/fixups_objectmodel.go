@@ -133,7 +133,7 @@ func (f indirectRequiredFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (b
 		if !ok {
 			continue
 		}
-		kd := keyDefFor(detail.TypeName, detail.Key)
+		kd := p.keyDefFor(detail.TypeName, detail.Key)
 		if kd == nil || kd.IndirectReference != arlington.IndirectRequired {
 			continue
 		}
@@ -269,7 +269,7 @@ func (f constraintFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (bool, b
 		if !ok {
 			continue
 		}
-		kd := keyDefFor(detail.TypeName, detail.Key)
+		kd := p.keyDefFor(detail.TypeName, detail.Key)
 		if kd == nil || kd.SpecialCase == nil || d.Entries[detail.Key] == nil {
 			continue
 		}
@@ -421,7 +421,7 @@ func (missingRequiredKeyFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (b
 		if !ok {
 			continue
 		}
-		kd := namedKeyDef(detail.TypeName, detail.Key)
+		kd := p.namedKeyDef(detail.TypeName, detail.Key)
 		if kd == nil {
 			continue
 		}
@@ -468,15 +468,16 @@ func arrayElemTarget(p *fixPass, iss pdf.PDFError) (arr pdf.PDFArray, kd *arling
 	if !isArr || idx >= len(arr) {
 		return nil, nil, 0, false
 	}
-	if kd = keyDefFor(detail.TypeName, detail.Key); kd == nil {
+	if kd = p.keyDefFor(detail.TypeName, detail.Key); kd == nil {
 		return nil, nil, 0, false
 	}
 	return arr, kd, idx, true
 }
 
-// namedKeyDef returns typeName's schema row for key, or nil when either is unknown.
-func namedKeyDef(typeName, key string) *arlington.KeyDef {
-	ot, ok := arlington.Type(typeName)
+// namedKeyDef returns typeName's schema row for key in the pass's object model, or nil when
+// either is unknown.
+func (p *fixPass) namedKeyDef(typeName, key string) *arlington.KeyDef {
+	ot, ok := p.objectModel().Type(typeName)
 	if !ok {
 		return nil
 	}
@@ -568,7 +569,7 @@ func (wrongValueTypeFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (bool,
 		if !ok {
 			continue
 		}
-		kd := keyDefFor(detail.TypeName, detail.Key)
+		kd := p.keyDefFor(detail.TypeName, detail.Key)
 		if kd == nil || len(kd.Types) == 0 {
 			continue
 		}
@@ -591,11 +592,11 @@ func (wrongValueTypeFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (bool,
 
 // keyDefFor returns the schema row governing key on typeName: the named row if one exists,
 // else the type's wildcard row, else nil.
-func keyDefFor(typeName, key string) *arlington.KeyDef {
-	if kd := namedKeyDef(typeName, key); kd != nil {
+func (p *fixPass) keyDefFor(typeName, key string) *arlington.KeyDef {
+	if kd := p.namedKeyDef(typeName, key); kd != nil {
 		return kd
 	}
-	ot, ok := arlington.Type(typeName)
+	ot, ok := p.objectModel().Type(typeName)
 	if !ok {
 		return nil
 	}
@@ -706,7 +707,7 @@ func (f disallowedValueFixer) fixTargeted(p *fixPass, issues []pdf.PDFError) (bo
 		if !ok {
 			continue
 		}
-		kd := keyDefFor(detail.TypeName, detail.Key)
+		kd := p.keyDefFor(detail.TypeName, detail.Key)
 		if kd == nil {
 			continue
 		}
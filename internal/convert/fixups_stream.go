@@ -1,7 +1,7 @@
 package convert
 
 import (
-	"fmt"
+	"slices"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
 	"github.com/voidrab/gopdfrab/internal/writer"
@@ -9,21 +9,24 @@ import (
 
 func init() {
 	registerFixer(lzwStreamFixer{})
+	registerFixer(jpxStreamFixer{})
 }
 
-type lzwStreamFixer struct{}
+// lzwStreamFixer re-encodes LZWDecode streams, which PDF/A forbids, as
+// Flate. doc, the run's Reader, supplies the limits they are decoded under.
+type lzwStreamFixer struct{ doc *pdf.Reader }
 
 func (lzwStreamFixer) Applies(c pdf.Check) bool {
 	return c == pdf.Checks.Structure.StreamLZWFilter
 }
 
-func (lzwStreamFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f lzwStreamFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	changed := false
 	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
 		if !d.HasStream || !hasLZWFilter(d.Entries["Filter"]) {
 			return d, false
 		}
-		plaintext, err := lzwStreamPlaintext(d)
+		plaintext, err := f.doc.DecodeStreamLimited(d)
 		if err != nil {
 			return d, false
 		}
@@ -47,58 +50,34 @@ func hasLZWFilter(filter pdf.PDFValue) bool {
 	return false
 }
 
-// lzwStreamPlaintext decodes a stream using LZWDecode, running all filters and predictors
-// to return the uncompressed plaintext. It duplicates the standard decoder chain logic so that
-// verification paths remain unaffected by this fixer-specific decoder.
-func lzwStreamPlaintext(dict pdf.PDFDict) ([]byte, error) {
-	data := dict.RawStream
-	for _, f := range pdf.FilterNames(dict.Entries["Filter"]) {
-		switch f {
-		case "FlateDecode", "Fl":
-			out, err := pdf.InflateZlib(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "ASCIIHexDecode", "AHx":
-			out, err := pdf.DecodeASCIIHex(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "ASCII85Decode", "A85":
-			out, err := pdf.DecodeASCII85(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "LZWDecode", "LZW":
-			out, err := pdf.DecodeLZW(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		default:
-			return nil, fmt.Errorf("unsupported filter %q", f)
-		}
-	}
+// jpxStreamFixer transcodes JPXDecode images, which PDF/A-1 cannot carry,
+// to 8-bit Flate samples in the image's colour space (see
+// decodeJPXSamples). Images whose JPEG 2000 data does not decode are left
+// for the raster backstop. doc, the run's Reader, supplies the limits they
+// are decoded under.
+type jpxStreamFixer struct{ doc *pdf.Reader }
 
-	parms := pdf.StreamDecodeParms(dict)
-	predictor := pdf.DictInt(parms, "Predictor", 1)
-	if predictor == 1 {
-		return data, nil
-	}
-	columns := pdf.DictInt(parms, "Columns", 1)
-	colors := pdf.DictInt(parms, "Colors", 1)
-	bpc := pdf.DictInt(parms, "BitsPerComponent", 8)
-	switch {
-	case predictor == 2:
-		return pdf.UndoTIFFPredictor(data, columns, colors, bpc)
-	case predictor >= 10:
-		return pdf.UndoPNGPredictor(data, columns, colors, bpc)
-	default:
-		return nil, fmt.Errorf("unsupported predictor %d", predictor)
-	}
+func (jpxStreamFixer) Applies(c pdf.Check) bool {
+	return c == pdf.Checks.Structure.StreamJPXFilter
+}
+
+func (f jpxStreamFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	changed := false
+	walkStreamDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
+		if !d.HasStream || !slices.Contains(pdf.FilterNames(d.Entries["Filter"]), "JPXDecode") {
+			return d, false
+		}
+		samples, out, err := decodeJPXSamples(d, pdf.PDFDict{}, f.doc)
+		if err != nil {
+			return d, false
+		}
+		if err := writer.SetStreamFlate(&out, samples); err != nil {
+			return d, false
+		}
+		changed = true
+		return out, true
+	})
+	return changed, nil
 }
 
 // walkStreamDicts calls fix for every pdf.PDFDict found within v's dictionary entries or array elements,
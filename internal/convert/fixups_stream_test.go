@@ -3,9 +3,9 @@ package convert
 import (
 	"bytes"
 	"compress/lzw"
-	"compress/zlib"
 	"encoding/ascii85"
 	"encoding/hex"
+	"errors"
 	"testing"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
@@ -120,6 +120,40 @@ func TestLZWStreamFixerDecodesAndSetsFlate(t *testing.T) {
 	}
 }
 
+// TestLZWStreamFixerDecodesUnderLimits checks the run's lzwStreamFixer
+// decodes under the Reader's limits: a stream over StreamBytes, or past what
+// is left of TotalBytes, is left as it is, with the breach recorded on the
+// Reader so the run stops.
+func TestLZWStreamFixerDecodesUnderLimits(t *testing.T) {
+	plaintext := []byte("0 0 0 rg 0 0 100 100 re f")
+	for _, tc := range []struct {
+		limit  string
+		limits pdf.Limits
+	}{
+		{"StreamBytes", pdf.Limits{StreamBytes: 10}},
+		{"TotalBytes", pdf.Limits{TotalBytes: 10}},
+	} {
+		t.Run(tc.limit, func(t *testing.T) {
+			doc := openTrailer(t, onePageTrailer())
+			doc.SetLimits(tc.limits)
+			fixer := buildLocalFixers(deviceColourFixer{}, doc, pdf.PDFA_1B)[pdf.Checks.Structure.StreamLZWFilter]
+
+			trailer := lzwStreamTrailer(encodeLZW(t, plaintext), pdf.PDFDict{})
+			changed, err := fixer.Fix(&trailer, nil)
+			if err != nil {
+				t.Fatalf("Fix: %v", err)
+			}
+			if changed {
+				t.Error("changed = true, want false (stream decodes past the limit)")
+			}
+			var le *pdf.LimitError
+			if err := doc.Err(); !errors.As(err, &le) || le.Limit != tc.limit {
+				t.Errorf("doc.Err() = %v, want a %s LimitError", err, tc.limit)
+			}
+		})
+	}
+}
+
 func TestLZWStreamFixerUndoesPredictor(t *testing.T) {
 	// Two 4-byte "rows" with TIFF predictor 2 (horizontal differencing).
 	plaintext := []byte{10, 20, 30, 40, 5, 5, 5, 5}
@@ -160,113 +194,6 @@ func TestLZWStreamFixerUndoesPredictor(t *testing.T) {
 	}
 }
 
-// TestLZWStreamPlaintextChainedFilters drives the ASCII85Decode branch and
-// the multi-filter loop: /Filter [ASCII85Decode LZWDecode] undoes ASCII85
-// first (outermost, applied last when encoding), then LZW.
-func TestLZWStreamPlaintextChainedFilters(t *testing.T) {
-	plaintext := []byte("0 0 0 rg 0 0 100 100 re f")
-	lzwed := encodeLZW(t, plaintext)
-	encoded := encodeASCII85(lzwed)
-
-	dict := pdf.PDFDict{
-		Entries: map[string]pdf.PDFValue{
-			"Filter": pdf.PDFArray{pdf.PDFName{Value: "ASCII85Decode"}, pdf.PDFName{Value: "LZWDecode"}},
-		},
-		HasStream: true, RawStream: encoded,
-	}
-	got, err := lzwStreamPlaintext(dict)
-	if err != nil {
-		t.Fatalf("lzwStreamPlaintext: %v", err)
-	}
-	if string(got) != string(plaintext) {
-		t.Errorf("lzwStreamPlaintext = %q, want %q", got, plaintext)
-	}
-}
-
-// TestLZWStreamPlaintextASCIIHexAndPNGPredictor drives the ASCIIHexDecode
-// branch and the PNG (predictor >= 10) reconstruction path, using the
-// trivial "None" (filter type 0) row so no per-row math is needed.
-func TestLZWStreamPlaintextASCIIHexAndPNGPredictor(t *testing.T) {
-	plaintext := []byte{10, 20, 30, 40, 5, 5, 5, 5}
-	var predicted []byte
-	for rowStart := 0; rowStart < len(plaintext); rowStart += 4 {
-		predicted = append(predicted, 0) // filter type 0: None
-		predicted = append(predicted, plaintext[rowStart:rowStart+4]...)
-	}
-	hexed := encodeASCIIHex(predicted)
-
-	dict := pdf.PDFDict{
-		Entries: map[string]pdf.PDFValue{
-			"Filter": pdf.PDFName{Value: "ASCIIHexDecode"},
-			"DecodeParms": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
-				"Predictor": pdf.PDFInteger(15), "Columns": pdf.PDFInteger(4), "Colors": pdf.PDFInteger(1),
-			}},
-		},
-		HasStream: true, RawStream: hexed,
-	}
-	got, err := lzwStreamPlaintext(dict)
-	if err != nil {
-		t.Fatalf("lzwStreamPlaintext: %v", err)
-	}
-	if string(got) != string(plaintext) {
-		t.Errorf("lzwStreamPlaintext = %v, want %v", got, plaintext)
-	}
-}
-
-// TestLZWStreamPlaintextFlateThenLZW drives the FlateDecode branch of the
-// decoder chain: /Filter [FlateDecode LZWDecode] undoes Flate first
-// (outermost), then LZW.
-func TestLZWStreamPlaintextFlateThenLZW(t *testing.T) {
-	plaintext := []byte("0 0 0 rg 0 0 100 100 re f")
-	lzwed := encodeLZW(t, plaintext)
-	var buf bytes.Buffer
-	zw := zlib.NewWriter(&buf)
-	if _, err := zw.Write(lzwed); err != nil {
-		t.Fatalf("zlib Write: %v", err)
-	}
-	if err := zw.Close(); err != nil {
-		t.Fatalf("zlib Close: %v", err)
-	}
-
-	dict := pdf.PDFDict{
-		Entries: map[string]pdf.PDFValue{
-			"Filter": pdf.PDFArray{pdf.PDFName{Value: "FlateDecode"}, pdf.PDFName{Value: "LZWDecode"}},
-		},
-		HasStream: true, RawStream: buf.Bytes(),
-	}
-	got, err := lzwStreamPlaintext(dict)
-	if err != nil {
-		t.Fatalf("lzwStreamPlaintext: %v", err)
-	}
-	if string(got) != string(plaintext) {
-		t.Errorf("lzwStreamPlaintext = %q, want %q", got, plaintext)
-	}
-}
-
-// TestLZWStreamPlaintextUnsupportedFilterOrPredictor covers the two error
-// branches: a filter name outside the decoder chain's switch, and a
-// predictor value that is neither 1 (none), 2 (TIFF), nor >= 10 (PNG).
-func TestLZWStreamPlaintextUnsupportedFilterOrPredictor(t *testing.T) {
-	unsupportedFilter := pdf.PDFDict{
-		Entries:   map[string]pdf.PDFValue{"Filter": pdf.PDFName{Value: "CCITTFaxDecode"}},
-		HasStream: true, RawStream: []byte("whatever"),
-	}
-	if _, err := lzwStreamPlaintext(unsupportedFilter); err == nil {
-		t.Error("lzwStreamPlaintext with an unsupported filter = nil error, want an error")
-	}
-
-	unsupportedPredictor := pdf.PDFDict{
-		Entries: map[string]pdf.PDFValue{
-			"Filter":      pdf.PDFName{Value: "LZWDecode"},
-			"DecodeParms": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Predictor": pdf.PDFInteger(3)}},
-		},
-		HasStream: true, RawStream: encodeLZW(t, []byte("abc")),
-	}
-	if _, err := lzwStreamPlaintext(unsupportedPredictor); err == nil {
-		t.Error("lzwStreamPlaintext with an unsupported predictor = nil error, want an error")
-	}
-}
-
 // TestLZWStreamFixerRoundTripsThroughWriter checks the fixer's output
 // survives a real WriteDocument -> Open -> decode round trip as a plain
 // Flate-encoded stream, with no LZWDecode filter remaining.
@@ -300,3 +227,127 @@ func TestLZWStreamFixerRoundTripsThroughWriter(t *testing.T) {
 	gotPage := assertOnePageGraph(t, graph)
 	assertContentStream(t, gotPage, string(plaintext))
 }
+
+func TestJPXStreamFixerAppliesOnlyToStreamJPXFilter(t *testing.T) {
+	fixer := jpxStreamFixer{}
+	for _, c := range pdf.AllChecks() {
+		want := c == pdf.Checks.Structure.StreamJPXFilter
+		if got := fixer.Applies(c); got != want {
+			t.Errorf("Applies(%s/%d) = %v, want %v", c.Clause(), c.Subclause(), got, want)
+		}
+	}
+}
+
+// jpxImageTrailer builds a one-page graph whose page resources hold a
+// JPXDecode image XObject with the given entries.
+func jpxImageTrailer(entries map[string]pdf.PDFValue, data []byte) pdf.PDFDict {
+	entries["_ref"] = pdf.PDFRef{ObjNum: 4}
+	entries["Subtype"] = pdf.PDFName{Value: "Image"}
+	entries["Filter"] = pdf.PDFName{Value: "JPXDecode"}
+	image := pdf.PDFDict{Entries: entries, HasStream: true, RawStream: data}
+	page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref":     pdf.PDFRef{ObjNum: 3},
+		"Type":     pdf.PDFName{Value: "Page"},
+		"MediaBox": pdf.PDFArray{pdf.PDFInteger(0), pdf.PDFInteger(0), pdf.PDFInteger(100), pdf.PDFInteger(100)},
+		"Resources": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Im1": image}},
+		}},
+	}}
+	pages := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 2}, "Type": pdf.PDFName{Value: "Pages"}, "Kids": pdf.PDFArray{page}, "Count": pdf.PDFInteger(1)}}
+	catalog := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "Pages": pages}}
+	return pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": catalog}}
+}
+
+func jpxTrailerImage(trailer pdf.PDFDict) pdf.PDFDict {
+	page := trailer.Entries["Root"].(pdf.PDFDict).Entries["Pages"].(pdf.PDFDict).Entries["Kids"].(pdf.PDFArray)[0].(pdf.PDFDict)
+	return page.Entries["Resources"].(pdf.PDFDict).Entries["XObject"].(pdf.PDFDict).Entries["Im1"].(pdf.PDFDict)
+}
+
+func TestJPXStreamFixerTranscodesToFlate(t *testing.T) {
+	trailer := jpxImageTrailer(map[string]pdf.PDFValue{
+		"Width": pdf.PDFInteger(2), "Height": pdf.PDFInteger(2),
+		"SMaskInData": pdf.PDFInteger(1),
+		"Decode":      pdf.PDFArray{pdf.PDFInteger(1), pdf.PDFInteger(0)},
+	}, jpxTestRGB)
+
+	fixer := jpxStreamFixer{}
+	changed, err := fixer.Fix(&trailer, nil)
+	if err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if !changed {
+		t.Fatal("changed = false, want true (image used JPXDecode)")
+	}
+
+	img := jpxTrailerImage(trailer)
+	if (img.Entries["Filter"] != pdf.PDFName{Value: "FlateDecode"}) {
+		t.Errorf("Filter = %v, want /FlateDecode", img.Entries["Filter"])
+	}
+	if (img.Entries["ColorSpace"] != pdf.PDFName{Value: "DeviceRGB"}) {
+		t.Errorf("ColorSpace = %v, want /DeviceRGB from the JPEG 2000 data", img.Entries["ColorSpace"])
+	}
+	if bpc := pdf.DictInt(img, "BitsPerComponent", 0); bpc != 8 {
+		t.Errorf("BitsPerComponent = %d, want 8", bpc)
+	}
+	for _, k := range []string{"SMaskInData", "Decode"} {
+		if img.Entries[k] != nil {
+			t.Errorf("%s kept on the transcoded image", k)
+		}
+	}
+	if (img.Entries["_ref"] != pdf.PDFRef{ObjNum: 4}) {
+		t.Errorf("_ref = %v, want the image's own reference", img.Entries["_ref"])
+	}
+	samples, err := pdf.DecodeStream(img)
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	want := []byte{255, 0, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255}
+	if !bytes.Equal(samples, want) {
+		t.Errorf("samples = %v, want %v", samples, want)
+	}
+
+	changed, err = fixer.Fix(&trailer, nil)
+	if err != nil {
+		t.Fatalf("Fix (second pass): %v", err)
+	}
+	if changed {
+		t.Error("changed = true on second pass, want false (fixer must be idempotent)")
+	}
+}
+
+func TestJPXStreamFixerKeepsPaletteIndices(t *testing.T) {
+	indexed := pdf.PDFArray{
+		pdf.PDFName{Value: "Indexed"}, pdf.PDFName{Value: "DeviceGray"}, pdf.PDFInteger(3),
+		pdf.PDFString{Value: "\x00\x10\x20\x30"},
+	}
+	trailer := jpxImageTrailer(map[string]pdf.PDFValue{
+		"Width": pdf.PDFInteger(4), "Height": pdf.PDFInteger(1),
+		"ColorSpace": indexed,
+	}, jpxTestIndices)
+	if _, err := (jpxStreamFixer{}).Fix(&trailer, nil); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	samples, err := pdf.DecodeStream(jpxTrailerImage(trailer))
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	if want := []byte{0, 1, 2, 3}; !bytes.Equal(samples, want) {
+		t.Errorf("samples = %v, want palette indices %v", samples, want)
+	}
+}
+
+func TestJPXStreamFixerLeavesUndecodableImage(t *testing.T) {
+	trailer := jpxImageTrailer(map[string]pdf.PDFValue{
+		"Width": pdf.PDFInteger(2), "Height": pdf.PDFInteger(2),
+	}, []byte("not a codestream"))
+	changed, err := (jpxStreamFixer{}).Fix(&trailer, nil)
+	if err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if changed {
+		t.Error("changed = true for undecodable JPEG 2000 data")
+	}
+	if (jpxTrailerImage(trailer).Entries["Filter"] != pdf.PDFName{Value: "JPXDecode"}) {
+		t.Error("undecodable image lost its JPXDecode filter")
+	}
+}
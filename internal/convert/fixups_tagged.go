@@ -0,0 +1,139 @@
+package convert
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+)
+
+// This file registers Fixers for the level A logical structure rules (6.8).
+// They repair a tagged document's bookkeeping -- MarkInfo, role map, Lang
+// syntax -- but never invent structure: an untagged document, or a Figure
+// without an alternate description, needs its author and stays residual.
+// The Unicode character map fixer level A shares with level U is in
+// fixups_unicode.go.
+
+func init() {
+	registerFixer(markInfoFixer{})
+	registerFixer(roleMapFixer{})
+	registerFixer(langFixer{})
+}
+
+// --- 6.8.2.2 MarkInfo ---
+
+// markInfoFixer sets MarkInfo /Marked true on a catalog that already has a
+// structure tree.
+type markInfoFixer struct{}
+
+func (markInfoFixer) Applies(c pdf.Check) bool {
+	return c == pdf.Checks.LogicalStructure.TaggedMarkInfo
+}
+
+func (markInfoFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok || root.Entries["StructTreeRoot"] == nil {
+		return false, nil
+	}
+	markInfo, ok := root.Entries["MarkInfo"].(pdf.PDFDict)
+	if !ok {
+		markInfo = pdf.NewPDFDict()
+		root.Entries["MarkInfo"] = markInfo
+	}
+	if marked, _ := markInfo.Entries["Marked"].(pdf.PDFBoolean); marked {
+		return false, nil
+	}
+	markInfo.Entries["Marked"] = pdf.PDFBoolean(true)
+	return true, nil
+}
+
+// --- 6.8.3.4 Role map ---
+
+// roleMapFixer maps every structure type that does not resolve to a
+// standard type: to the standard type it differs from only in case, if any
+// (a /figure is a Figure), and otherwise to NonStruct, which groups content
+// without asserting any semantics. A circular entry is broken the same way.
+type roleMapFixer struct{}
+
+func (roleMapFixer) Applies(c pdf.Check) bool {
+	return c == pdf.Checks.LogicalStructure.RoleMapStandardType ||
+		c == pdf.Checks.LogicalStructure.RoleMapCircular
+}
+
+func (roleMapFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	st, ok := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	if !ok {
+		return false, nil
+	}
+	roleMap, ok := st.Entries["RoleMap"].(pdf.PDFDict)
+	if !ok {
+		roleMap = pdf.NewPDFDict()
+	}
+	changed := false
+	// remap maps the type typ's role map chain ends at -- an unmapped type,
+	// or one on a cycle -- which repairs every type leading to it.
+	remap := func(typ string) {
+		resolved, circular := verify.ResolveStructType(typ, roleMap)
+		if !circular && verify.StandardStructureTypes[resolved] {
+			return
+		}
+		roleMap.Entries[resolved] = pdf.PDFName{Value: nearestStandardType(resolved)}
+		changed = true
+	}
+	for _, typ := range slices.Sorted(maps.Keys(roleMap.Entries)) {
+		if typ != "_ref" {
+			remap(typ)
+		}
+	}
+	verify.WalkStructElems(st, func(_ pdf.PDFDict, typ string) {
+		remap(typ)
+	})
+	if changed {
+		st.Entries["RoleMap"] = roleMap
+	}
+	return changed, nil
+}
+
+// nearestStandardType returns the standard structure type typ differs from
+// only in case, or NonStruct.
+func nearestStandardType(typ string) string {
+	for std := range verify.StandardStructureTypes {
+		if strings.EqualFold(std, typ) {
+			return std
+		}
+	}
+	return "NonStruct"
+}
+
+// --- 6.8.4 Natural language ---
+
+// langFixer repairs Lang entries that are not language identifiers: an
+// underscore separator becomes a hyphen (en_US is en-US) and surrounding
+// space is trimmed; a value still invalid after that is removed, which
+// declares the language unknown.
+type langFixer struct{}
+
+func (langFixer) Applies(c pdf.Check) bool {
+	return c == pdf.Checks.LogicalStructure.LangIdentifier
+}
+
+func (langFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	changed := false
+	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
+		lang, ok := d.Entries["Lang"]
+		if !ok || verify.ValidLang(lang) {
+			return
+		}
+		fixed := pdf.PDFString{Value: strings.ReplaceAll(strings.TrimSpace(pdf.DecodeInfoTextString(lang)), "_", "-")}
+		if verify.ValidLang(fixed) {
+			d.Entries["Lang"] = fixed
+		} else {
+			delete(d.Entries, "Lang")
+		}
+		changed = true
+	})
+	return changed, nil
+}
@@ -0,0 +1,104 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+)
+
+// taggedCatalog returns a trailer whose catalog carries a structure tree with
+// one element of each given type, and the role map rm.
+func taggedCatalog(rm map[string]string, types ...string) (pdf.PDFDict, pdf.PDFDict) {
+	st := pdf.NewPDFDict()
+	var kids pdf.PDFArray
+	for _, typ := range types {
+		e := pdf.NewPDFDict()
+		e.Entries["S"] = pdf.PDFName{Value: typ}
+		e.Entries["P"] = st
+		kids = append(kids, e)
+	}
+	st.Entries["K"] = kids
+	if rm != nil {
+		roleMap := pdf.NewPDFDict()
+		for k, v := range rm {
+			roleMap.Entries[k] = pdf.PDFName{Value: v}
+		}
+		st.Entries["RoleMap"] = roleMap
+	}
+	root := pdf.NewPDFDict()
+	root.Entries["StructTreeRoot"] = st
+	trailer := pdf.NewPDFDict()
+	trailer.Entries["Root"] = root
+	return trailer, st
+}
+
+func TestMarkInfoFixer(t *testing.T) {
+	trailer, _ := taggedCatalog(nil)
+	if changed, _ := (markInfoFixer{}).Fix(&trailer, nil); !changed {
+		t.Fatal("changed = false, want MarkInfo added")
+	}
+	mi := trailer.Entries["Root"].(pdf.PDFDict).Entries["MarkInfo"].(pdf.PDFDict)
+	if mi.Entries["Marked"] != pdf.PDFBoolean(true) {
+		t.Errorf("MarkInfo = %v, want Marked true", mi)
+	}
+	if changed, _ := (markInfoFixer{}).Fix(&trailer, nil); changed {
+		t.Error("second Fix changed an already marked catalog")
+	}
+
+	// Without a structure tree, marking the document would be a false claim.
+	untagged := pdf.NewPDFDict()
+	untagged.Entries["Root"] = pdf.NewPDFDict()
+	if changed, _ := (markInfoFixer{}).Fix(&untagged, nil); changed {
+		t.Error("MarkInfo set on a document without a structure tree")
+	}
+}
+
+func TestRoleMapFixer(t *testing.T) {
+	trailer, st := taggedCatalog(map[string]string{"A": "B", "B": "A", "Para": "Paragraph"}, "Para", "figure", "A", "P")
+	changed, err := roleMapFixer{}.Fix(&trailer, nil)
+	if err != nil || !changed {
+		t.Fatalf("Fix = %v, %v; want changed", changed, err)
+	}
+	rm := st.Entries["RoleMap"].(pdf.PDFDict)
+	for _, typ := range []string{"Para", "figure", "A", "B"} {
+		resolved, circular := verify.ResolveStructType(typ, rm)
+		if circular || !verify.StandardStructureTypes[resolved] {
+			t.Errorf("/%s resolves to /%s (circular %v), want a standard type", typ, resolved, circular)
+		}
+	}
+	if got, _ := verify.ResolveStructType("figure", rm); got != "Figure" {
+		t.Errorf("/figure resolves to /%s, want Figure", got)
+	}
+	if got, _ := verify.ResolveStructType("Para", rm); got != "NonStruct" {
+		t.Errorf("/Para resolves to /%s, want NonStruct", got)
+	}
+	if _, ok := rm.Entries["P"]; ok {
+		t.Error("standard type P was remapped")
+	}
+}
+
+func TestLangFixer(t *testing.T) {
+	root := pdf.NewPDFDict()
+	root.Entries["Lang"] = pdf.PDFString{Value: " en_GB "}
+	elem := pdf.NewPDFDict()
+	elem.Entries["Lang"] = pdf.PDFString{Value: "British English"}
+	ok := pdf.NewPDFDict()
+	ok.Entries["Lang"] = pdf.PDFString{Value: "fr"}
+	root.Entries["Elems"] = pdf.PDFArray{elem, ok}
+	trailer := pdf.NewPDFDict()
+	trailer.Entries["Root"] = root
+
+	if changed, _ := (langFixer{}).Fix(&trailer, nil); !changed {
+		t.Fatal("changed = false, want Lang repaired")
+	}
+	if got := root.Entries["Lang"]; got != (pdf.PDFString{Value: "en-GB"}) {
+		t.Errorf("catalog Lang = %v, want en-GB", got)
+	}
+	if _, kept := elem.Entries["Lang"]; kept {
+		t.Error("unrepairable Lang kept")
+	}
+	if got := ok.Entries["Lang"]; got != (pdf.PDFString{Value: "fr"}) {
+		t.Errorf("valid Lang changed to %v", got)
+	}
+}
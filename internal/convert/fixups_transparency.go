@@ -13,8 +13,11 @@ import (
 // Checks.Transparency.ImageWithSoftMask by rasterizing only the smallest
 // self-contained object carrying the violation -- a Form XObject's own
 // content for a transparency group, or a single Image XObject's samples for
-// a soft mask -- never the whole page.
-type transparencyFlattener struct{}
+// a soft mask -- never the whole page. doc, the run's Reader, supplies the
+// RasterPixels limit it renders under.
+type transparencyFlattener struct {
+	doc *pdf.Reader
+}
 
 func init() {
 	registerFixer(transparencyFlattener{})
@@ -37,7 +40,7 @@ const flattenDPI = 150
 // page that inherits no /MediaBox anywhere up its Pages-tree ancestry.
 var defaultMediaBox = [4]float64{0, 0, 612, 792}
 
-func (transparencyFlattener) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+func (f transparencyFlattener) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
 	targets := collectTransparencyTargets(*trailer)
 
 	unique := uniqueByDict(targets)
@@ -62,17 +65,17 @@ func (transparencyFlattener) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool,
 				t := unique[i]
 				switch t.kind {
 				case "image":
-					fixed, ok := bakeSoftMaskOut(t.dict, t.resources)
+					fixed, ok := bakeSoftMaskOut(t.dict, t.resources, f.doc)
 					results[i] = result{fixed: fixed, ok: ok}
 				case "form":
 					// A provably transparency-free group composites the same
 					// without /Group; deleting it (serially, below) skips the
 					// whole rasterization.
-					if canDropGroupSafely(t.dict) {
+					if canDropGroupSafely(t.dict, f.doc) {
 						results[i] = result{fixed: t.dict, ok: true, dropGroup: true}
 						continue
 					}
-					fixed, ok := flattenFormToImage(t.dict, t.resources)
+					fixed, ok := flattenFormToImage(t.dict, t.resources, f.doc)
 					results[i] = result{fixed: fixed, ok: ok}
 				case "page":
 					_, had := t.dict.Entries["Group"]
@@ -301,18 +304,21 @@ func hasSoftMask(img pdf.PDFDict) bool {
 // backdrop -- gopdfrab has no way to know what the image was meant to be
 // composited over without rendering everything beneath it -- and rewrites
 // img in place as a flat, opaque DeviceRGB image with /SMask removed.
-// Leaves img untouched (ok=false) if either decode fails.
-func bakeSoftMaskOut(img pdf.PDFDict, resources pdf.PDFDict) (pdf.PDFDict, bool) {
-	base, err := DecodeImageRGBA(img, resources)
+// Leaves img untouched (ok=false) if either decode fails; one over doc's
+// RasterPixels limit is recorded on doc.
+func bakeSoftMaskOut(img pdf.PDFDict, resources pdf.PDFDict, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	base, err := decodeImageRGBA(img, resources, doc)
 	if err != nil {
+		recordLimit(doc, err)
 		return img, false
 	}
 	smaskDict, ok := img.Entries["SMask"].(pdf.PDFDict)
 	if !ok {
 		return img, false
 	}
-	smask, err := DecodeImageRGBA(smaskDict, resources)
+	smask, err := decodeImageRGBA(smaskDict, resources, doc)
 	if err != nil {
+		recordLimit(doc, err)
 		return img, false
 	}
 
@@ -390,9 +396,10 @@ func smaskFullyOpaque(smask *image.RGBA) bool {
 
 // canDropGroupSafely reports whether a form's content provably uses no
 // transparency, so deleting /Group composites identically: no gs, no Do, no
-// inline images, and no pattern fill/stroke anywhere in the stream.
-func canDropGroupSafely(form pdf.PDFDict) bool {
-	data, err := pdf.DecodeStream(form)
+// inline images, and no pattern fill/stroke anywhere in the stream. The
+// content is decoded under doc's limits.
+func canDropGroupSafely(form pdf.PDFDict, doc *pdf.Reader) bool {
+	data, err := doc.DecodeStreamLimited(form)
 	if err != nil {
 		return false
 	}
@@ -418,9 +425,10 @@ func canDropGroupSafely(form pdf.PDFDict) bool {
 // /Group. The Form's own identity, /Matrix and every existing /Do reference
 // to it are untouched, so it keeps composing into the page exactly as
 // before -- it now just paints a flat image instead of a transparency group.
-// A render failure leaves the Form untouched (ok=false).
-func flattenFormToImage(form pdf.PDFDict, resources pdf.PDFDict) (pdf.PDFDict, bool) {
-	canvas, bbox, err := renderFormContent(form, resources, flattenDPI)
+// A render failure leaves the Form untouched (ok=false); one over doc's
+// RasterPixels limit is recorded on doc.
+func flattenFormToImage(form pdf.PDFDict, resources pdf.PDFDict, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	canvas, bbox, err := renderFormContent(form, resources, flattenDPI, doc)
 	if err != nil {
 		return form, false
 	}
@@ -471,9 +479,10 @@ func flattenFormToImage(form pdf.PDFDict, resources pdf.PDFDict) (pdf.PDFDict, b
 // when /Group sits directly on the Page dict itself, with no narrower Form
 // XObject to target instead. A render failure (e.g. an unresolvable graph or
 // an unsupported image codec) leaves page untouched, reporting no change
-// rather than erroring the whole Convert.
-func flattenPageToImage(page pdf.PDFDict, resources pdf.PDFDict, mediaBox [4]float64) bool {
-	canvas, err := RenderPage(page, resources, mediaBox, flattenDPI)
+// rather than erroring the whole Convert; one over doc's RasterPixels limit
+// is recorded on doc, which stops the run.
+func flattenPageToImage(page pdf.PDFDict, resources pdf.PDFDict, mediaBox [4]float64, doc *pdf.Reader) bool {
+	canvas, err := renderPage(page, resources, mediaBox, flattenDPI, doc)
 	if err != nil {
 		return false
 	}
@@ -39,25 +39,25 @@ func TestCanDropGroupSafely(t *testing.T) {
 		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: []byte(content)}
 	}
 
-	if canDropGroupSafely(pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Filter": pdf.PDFName{Value: "LZWDecode"}}, HasStream: true, RawStream: []byte{0xFF}}) {
+	if canDropGroupSafely(pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Filter": pdf.PDFName{Value: "FooDecode"}}, HasStream: true, RawStream: []byte{0xFF}}, nil) {
 		t.Error("canDropGroupSafely on an undecodable stream = true, want false")
 	}
-	if !canDropGroupSafely(streamOf("1 0 0 rg 0 0 10 10 re f")) {
+	if !canDropGroupSafely(streamOf("1 0 0 rg 0 0 10 10 re f"), nil) {
 		t.Error("canDropGroupSafely on plain fill content = false, want true")
 	}
-	if canDropGroupSafely(streamOf("/GS1 gs")) {
+	if canDropGroupSafely(streamOf("/GS1 gs"), nil) {
 		t.Error("canDropGroupSafely with a gs operator = true, want false")
 	}
-	if canDropGroupSafely(streamOf("/Fm1 Do")) {
+	if canDropGroupSafely(streamOf("/Fm1 Do"), nil) {
 		t.Error("canDropGroupSafely with a Do operator = true, want false")
 	}
-	if canDropGroupSafely(streamOf("BI /W 1 /H 1 /BPC 8 /CS /G ID \x00 EI\n")) {
+	if canDropGroupSafely(streamOf("BI /W 1 /H 1 /BPC 8 /CS /G ID \x00 EI\n"), nil) {
 		t.Error("canDropGroupSafely with an inline image = true, want false")
 	}
-	if canDropGroupSafely(streamOf("/P1 scn")) {
+	if canDropGroupSafely(streamOf("/P1 scn"), nil) {
 		t.Error("canDropGroupSafely with a pattern (named) scn fill = true, want false")
 	}
-	if !canDropGroupSafely(streamOf("1 0 0 scn")) {
+	if !canDropGroupSafely(streamOf("1 0 0 scn"), nil) {
 		t.Error("canDropGroupSafely with a plain numeric scn fill = false, want true")
 	}
 }
@@ -0,0 +1,109 @@
+package convert
+
+import (
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+)
+
+// This file registers the Fixer for the Unicode character map rules of
+// levels A and U (ISO 19005-1 6.3.8, ISO 19005-2 6.2.11.7): it gives a simple
+// font a ToUnicode CMap derived from its encoding, or completes an existing
+// one from it.
+
+func init() {
+	registerFixer(toUnicodeFixer{})
+}
+
+// toUnicodeFixer gives a simple font without a Unicode mapping a ToUnicode
+// CMap derived from its encoding: the Encoding and Differences glyph names,
+// or the built-in encoding of the standard Symbol and ZapfDingbats fonts. A
+// font that has a ToUnicode CMap gets it rewritten with entries mapping to
+// U+0000, U+FEFF or U+FFFE dropped and the codes it leaves unmapped filled
+// in from the encoding. A code whose glyph has no known Unicode value is
+// left out, and a Type 0 font, whose CIDs carry no glyph names, stays
+// residual. doc, the run's Reader, supplies the limits the existing CMaps are
+// decoded under.
+type toUnicodeFixer struct{ doc *pdf.Reader }
+
+func (toUnicodeFixer) Applies(c pdf.Check) bool {
+	switch c {
+	case pdf.Checks.Font.ToUnicodeMissing, pdf.Checks.PDFA2.Font.UnmappableCharCode,
+		pdf.Checks.PDFA2.Font.InvalidUnicodeValue:
+		return true
+	}
+	return false
+}
+
+func (f toUnicodeFixer) Fix(trailer *pdf.PDFDict, _ []pdf.PDFError) (bool, error) {
+	changed := false
+	walkDicts(*trailer, map[uintptr]bool{}, func(d pdf.PDFDict) {
+		if (d.Entries["Type"] != pdf.PDFName{Value: "Font"}) {
+			return
+		}
+		subtype, _ := d.Entries["Subtype"].(pdf.PDFName)
+		switch subtype.Value {
+		case "Type1", "MMType1", "TrueType", "Type3":
+		default:
+			return
+		}
+		if completeToUnicode(d, f.doc) {
+			changed = true
+		}
+	})
+	return changed, nil
+}
+
+// completeToUnicode writes simple font d's ToUnicode CMap when it lacks a
+// Unicode mapping or its ToUnicode CMap is missing or misstating codes its
+// encoding maps, reporting whether it changed d. The existing CMap is decoded
+// under doc's limits.
+func completeToUnicode(d pdf.PDFDict, doc *pdf.Reader) bool {
+	existing := map[int]string{}
+	stm, hasToUnicode := d.Entries["ToUnicode"].(pdf.PDFDict)
+	if hasToUnicode {
+		data, err := doc.DecodeStreamLimited(stm)
+		if err != nil {
+			return false
+		}
+		pdf.ParseToUnicodeCMap(data).Each(func(code uint32, _ int, text string) {
+			if code < 256 {
+				existing[int(code)] = text
+			}
+		})
+	} else if verify.HasUnicodeMapping(d) {
+		return false
+	}
+
+	table := verify.SimpleFontUnicode(d)
+	first, last := 0, 255
+	if fc, ok := d.Entries["FirstChar"].(pdf.PDFInteger); ok {
+		first = max(int(fc), 0)
+	}
+	if lc, ok := d.Entries["LastChar"].(pdf.PDFInteger); ok {
+		last = min(int(lc), 255)
+	}
+	codeText := map[int]string{}
+	repaired := false
+	for cc, text := range existing {
+		if text != "" && !verify.InvalidUnicodeValue(text) {
+			codeText[cc] = text
+		} else {
+			repaired = true
+		}
+	}
+	for cc := first; cc <= last; cc++ {
+		if _, ok := codeText[cc]; !ok && table[cc] != 0 {
+			codeText[cc] = string(rune(table[cc]))
+			repaired = true
+		}
+	}
+	if !repaired || len(codeText) == 0 {
+		return false
+	}
+	toUni, ok := buildToUnicodeTextStream(codeText)
+	if !ok {
+		return false
+	}
+	d.Entries["ToUnicode"] = toUni
+	return true
+}
@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// TestToUnicodeFixer, the PDF/A-1a case moved here with the fixer, checks
+// that simple fonts get a ToUnicode CMap covering their mappable codes and
+// that a Type 0 font is left alone.
+func TestToUnicodeFixer(t *testing.T) {
+	font := func(baseFont string, enc pdf.PDFValue) pdf.PDFDict {
+		f := pdf.NewPDFDict()
+		f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+		f.Entries["Subtype"] = pdf.PDFName{Value: "Type1"}
+		f.Entries["BaseFont"] = pdf.PDFName{Value: baseFont}
+		f.Entries["FirstChar"] = pdf.PDFInteger(65)
+		f.Entries["LastChar"] = pdf.PDFInteger(66)
+		if enc != nil {
+			f.Entries["Encoding"] = enc
+		}
+		return f
+	}
+	diffs := pdf.NewPDFDict()
+	diffs.Entries["Differences"] = pdf.PDFArray{pdf.PDFInteger(65), pdf.PDFName{Value: "alpha"}, pdf.PDFName{Value: "g17"}}
+	custom := font("Custom", diffs)
+	symbol := font("ABCDEF+Symbol", nil)
+	type0 := pdf.NewPDFDict()
+	type0.Entries["Type"] = pdf.PDFName{Value: "Font"}
+	type0.Entries["Subtype"] = pdf.PDFName{Value: "Type0"}
+
+	root := pdf.NewPDFDict()
+	root.Entries["Fonts"] = pdf.PDFArray{custom, symbol, type0}
+	trailer := pdf.NewPDFDict()
+	trailer.Entries["Root"] = root
+
+	if changed, _ := (toUnicodeFixer{}).Fix(&trailer, nil); !changed {
+		t.Fatal("changed = false, want ToUnicode synthesized")
+	}
+	for name, f := range map[string]pdf.PDFDict{"Custom": custom, "Symbol": symbol} {
+		if !verify.HasUnicodeMapping(f) {
+			t.Errorf("%s: no ToUnicode after Fix", name)
+		}
+	}
+	data, err := pdf.DecodeStream(custom.Entries["ToUnicode"].(pdf.PDFDict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseToUnicodeCMap(data); len(got) != 1 || got[65] != 0x03B1 {
+		t.Errorf("Custom ToUnicode = %v, want only <41> -> alpha", got)
+	}
+	if type0.Entries["ToUnicode"] != nil {
+		t.Error("ToUnicode invented for a Type 0 font")
+	}
+}
+
+func TestToUnicodeFixerCompletesCMap(t *testing.T) {
+	f := pdf.NewPDFDict()
+	f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+	f.Entries["Subtype"] = pdf.PDFName{Value: "TrueType"}
+	f.Entries["BaseFont"] = pdf.PDFName{Value: "Arial"}
+	f.Entries["Encoding"] = pdf.PDFName{Value: "WinAnsiEncoding"}
+	f.Entries["FirstChar"] = pdf.PDFInteger(65)
+	f.Entries["LastChar"] = pdf.PDFInteger(67)
+	stm := pdf.NewPDFDict()
+	cmap := "1 beginbfchar <41> <FFFE> endbfchar 1 beginbfchar <43> <00660069> endbfchar"
+	if err := writer.SetStreamFlate(&stm, []byte(cmap)); err != nil {
+		t.Fatal(err)
+	}
+	f.Entries["ToUnicode"] = stm
+	trailer := pdf.NewPDFDict()
+	trailer.Entries["Root"] = f
+
+	if changed, _ := (toUnicodeFixer{}).Fix(&trailer, nil); !changed {
+		t.Fatal("changed = false, want ToUnicode rebuilt")
+	}
+	data, err := pdf.DecodeStream(f.Entries["ToUnicode"].(pdf.PDFDict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := pdf.ParseToUnicodeCMap(data)
+	for code, want := range map[uint32]string{0x41: "A", 0x42: "B", 0x43: "fi"} {
+		if got, _ := m.Lookup(code, 1); got != want {
+			t.Errorf("Lookup(%02X) = %q, want %q", code, got, want)
+		}
+	}
+
+	// The rebuilt CMap is complete, so a second pass leaves it alone.
+	if changed, _ := (toUnicodeFixer{}).Fix(&trailer, nil); changed {
+		t.Error("second Fix changed a complete ToUnicode")
+	}
+}
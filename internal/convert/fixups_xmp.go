@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
 )
 
 func init() {
@@ -17,9 +18,10 @@ func init() {
 }
 
 // regenerateXMP replaces the document's XMP metadata (Root/Metadata) with a
-// freshly-built, minimal packet that satisfies clause 6.7: a correct PDF/A-1b
-// identifier (pdfaid:part=1, pdfaid:conformance=B), no xpacket
-// bytes/encoding attributes, an unfiltered stream, and -- for every Info
+// freshly-built, minimal packet that satisfies clause 6.7: a correct PDF/A
+// identifier for p's level (pdfaid:part=1, 2 or 3 with pdfaid:conformance=A
+// or B; pdfaid:part=4 with pdfaid:rev and the E/F variant letter, if any), no
+// xpacket bytes/encoding attributes, an unfiltered stream, and -- for every Info
 // dictionary entry that has a PDF/A-recognized XMP counterpart -- a
 // synchronized dc:/xmp:/pdf: property in its required container shape (see
 // checks_xmp.go's xmpNSSchemas/xmpLangAltProps). This is applied
@@ -28,7 +30,9 @@ func init() {
 // an arbitrary existing one into compliance, and doing so resolves the large
 // majority of clause 6.7's many sub-checks (and the Info/XMP sync checks,
 // 6.7.3/6.1.5, since the packet is generated directly from Info) in one pass.
-func regenerateXMP(trailer *pdf.PDFDict, _ *pdf.Reader) error {
+// A Factur-X / ZUGFeRD invoice embedded for a level that keeps associated
+// files is described afresh too (see invoiceXMP).
+func regenerateXMP(trailer *pdf.PDFDict, doc *pdf.Reader, p *pdf.Profile) error {
 	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
 	if !ok {
 		return fmt.Errorf("regenerateXMP: Root is not a dictionary")
@@ -36,7 +40,12 @@ func regenerateXMP(trailer *pdf.PDFDict, _ *pdf.Reader) error {
 
 	normalizeInfoDict(trailer)
 	info, _ := trailer.Entries["Info"].(pdf.PDFDict)
-	xmp := buildXMPPacket(info)
+	xmp := buildXMPPacket(info, targetPart(p), targetConformance(p))
+	if p != nil && p.Level.AllowsAssociatedFiles() {
+		if fx := invoiceXMP(root, doc); fx != "" {
+			xmp = strings.Replace(xmp, "</rdf:RDF>", fx+"</rdf:RDF>", 1)
+		}
+	}
 
 	meta, _ := root.Entries["Metadata"].(pdf.PDFDict)
 	delete(meta.Entries, "Filter")
@@ -62,7 +71,7 @@ func regenerateXMP(trailer *pdf.PDFDict, _ *pdf.Reader) error {
 // PDF/A-1b requires exactly one metadata stream (Root/Metadata); non-catalog
 // /Type /Metadata streams violate 6.7.5 when they lack an xpacket wrapper.
 func stripEmbeddedMetadata(trailer *pdf.PDFDict, doc *pdf.Reader) error {
-	if visit := stripEmbeddedMetadataVisitor(trailer, doc); visit != nil {
+	if visit := stripEmbeddedMetadataVisitor(trailer, doc, nil); visit != nil {
 		walkDicts(*trailer, map[uintptr]bool{}, visit)
 	}
 	return nil
@@ -70,7 +79,7 @@ func stripEmbeddedMetadata(trailer *pdf.PDFDict, doc *pdf.Reader) error {
 
 // stripEmbeddedMetadataVisitor is stripEmbeddedMetadata's per-dict visitor
 // for the shared pre-emptive walk; nil when there is no catalog to protect.
-func stripEmbeddedMetadataVisitor(trailer *pdf.PDFDict, _ *pdf.Reader) func(pdf.PDFDict) {
+func stripEmbeddedMetadataVisitor(trailer *pdf.PDFDict, _ *pdf.Reader, _ *pdf.Profile) func(pdf.PDFDict) {
 	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
 	if !ok {
 		return nil
@@ -213,8 +222,10 @@ func infoString(info pdf.PDFDict, key string) string {
 
 // buildXMPPacket builds a minimal, schema-correct XMP packet synchronized
 // with info's Title/Subject/Author/Creator/Producer/Keywords/CreationDate/
-// ModDate (whichever are present), plus the mandatory PDF/A-1b identifier.
-func buildXMPPacket(info pdf.PDFDict) string {
+// ModDate (whichever are present), plus the mandatory PDF/A identifier for
+// the given part and conformance level. Part 4 identifies its revision in
+// pdfaid:rev and omits pdfaid:conformance unless conformance is non-empty.
+func buildXMPPacket(info pdf.PDFDict, part int, conformance string) string {
 	title := infoString(info, "Title")
 	subject := infoString(info, "Subject")
 	author := infoString(info, "Author")
@@ -230,8 +241,13 @@ func buildXMPPacket(info pdf.PDFDict) string {
 	b.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
 
 	b.WriteString(`<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">` + "\n")
-	b.WriteString("<pdfaid:part>1</pdfaid:part>\n")
-	b.WriteString("<pdfaid:conformance>B</pdfaid:conformance>\n")
+	fmt.Fprintf(&b, "<pdfaid:part>%d</pdfaid:part>\n", part)
+	if part >= 4 {
+		b.WriteString("<pdfaid:rev>2020</pdfaid:rev>\n")
+	}
+	if conformance != "" {
+		fmt.Fprintf(&b, "<pdfaid:conformance>%s</pdfaid:conformance>\n", conformance)
+	}
 	b.WriteString("</rdf:Description>\n")
 
 	// dc:title/dc:description/dc:creator must be Alt/Seq containers, which
@@ -275,6 +291,61 @@ func buildXMPPacket(info pdf.PDFDict) string {
 	return b.String()
 }
 
+// invoiceXMP returns the rdf:Description elements describing the
+// Factur-X / ZUGFeRD invoice embedded in the document with catalog root --
+// its fx: properties and the PDF/A extension schema declaring them -- or ""
+// if the document embeds no invoice with a readable profile level. The
+// invoice is decoded under doc's limits.
+func invoiceXMP(root pdf.PDFDict, doc *pdf.Reader) string {
+	spec, name, ok := verify.InvoiceAttachment(root)
+	if !ok {
+		return ""
+	}
+	stm, ok := verify.InvoiceFile(spec)
+	if !ok {
+		return ""
+	}
+	data, err := doc.DecodeStreamLimited(stm)
+	if err != nil {
+		return ""
+	}
+	level, err := verify.InvoiceConformanceLevel(data)
+	if err != nil {
+		return ""
+	}
+	ns, _ := verify.InvoiceNamespace(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<rdf:Description rdf:about="" xmlns:fx="%s">`+"\n", ns)
+	b.WriteString("<fx:DocumentType>INVOICE</fx:DocumentType>\n")
+	fmt.Fprintf(&b, "<fx:DocumentFileName>%s</fx:DocumentFileName>\n", xmlEscapeText(name))
+	b.WriteString("<fx:Version>1.0</fx:Version>\n")
+	fmt.Fprintf(&b, "<fx:ConformanceLevel>%s</fx:ConformanceLevel>\n", level)
+	b.WriteString("</rdf:Description>\n")
+
+	b.WriteString(`<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/"` +
+		` xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">` + "\n")
+	b.WriteString("<pdfaExtension:schemas><rdf:Bag><rdf:li rdf:parseType=\"Resource\">\n")
+	b.WriteString("<pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>\n")
+	fmt.Fprintf(&b, "<pdfaSchema:namespaceURI>%s</pdfaSchema:namespaceURI>\n", ns)
+	b.WriteString("<pdfaSchema:prefix>fx</pdfaSchema:prefix>\n")
+	b.WriteString("<pdfaSchema:property><rdf:Seq>\n")
+	for _, prop := range []struct{ name, description string }{
+		{"DocumentFileName", "The name of the embedded XML document"},
+		{"DocumentType", "The type of the hybrid document in capital letters, e.g. INVOICE or ORDER"},
+		{"Version", "The actual version of the standard applying to the embedded XML document"},
+		{"ConformanceLevel", "The conformance level of the embedded XML document"},
+	} {
+		fmt.Fprintf(&b, `<rdf:li rdf:parseType="Resource"><pdfaProperty:name>%s</pdfaProperty:name>`+
+			`<pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category>`+
+			`<pdfaProperty:description>%s</pdfaProperty:description></rdf:li>`+"\n", prop.name, prop.description)
+	}
+	b.WriteString("</rdf:Seq></pdfaSchema:property>\n")
+	b.WriteString("</rdf:li></rdf:Bag></pdfaExtension:schemas>\n")
+	b.WriteString("</rdf:Description>\n")
+	return b.String()
+}
+
 // writeLangAltProp writes a LangAlt-container property (dc:title,
 // dc:description), required by xmpLangAltProps to carry xml:lang on every
 // rdf:li, or nothing at all if value is empty.
@@ -77,7 +77,7 @@ func TestBuildXMPPacketKeepsParens(t *testing.T) {
 	info := pdf.NewPDFDict()
 	info.Entries["Title"] = pdf.PDFString{Value: "Doc (v2)"}
 
-	xmp := buildXMPPacket(info)
+	xmp := buildXMPPacket(info, 1, "B")
 	if !strings.Contains(xmp, "(v2)") {
 		t.Errorf("buildXMPPacket XMP does not contain (v2): %s", xmp)
 	}
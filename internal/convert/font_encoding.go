@@ -47,7 +47,7 @@ func fontFlagsSymbolic(d pdf.PDFDict) bool {
 // under the font's original encoding, before any fixer rewrites it. A zero
 // entry means the code has no known meaning; baseKnown distinguishes "the
 // encoding is known and the code renders .notdef" from "unknowable".
-func originalSimpleFontCodeToUnicode(d pdf.PDFDict) (table [256]uint16, baseKnown bool) {
+func originalSimpleFontCodeToUnicode(d pdf.PDFDict, doc *pdf.Reader) (table [256]uint16, baseKnown bool) {
 	applyBase := func(name string) {
 		baseKnown = true
 		switch name {
@@ -100,7 +100,7 @@ func originalSimpleFontCodeToUnicode(d pdf.PDFDict) (table [256]uint16, baseKnow
 
 	// A /ToUnicode CMap authoritatively fills codes still unresolved.
 	if toUni, ok := d.Entries["ToUnicode"].(pdf.PDFDict); ok && toUni.HasStream {
-		if data, err := pdf.DecodeStream(toUni); err == nil {
+		if data, err := doc.DecodeStreamLimited(toUni); err == nil {
 			for code, u := range parseToUnicodeCMap(data) {
 				if code >= 0 && code < 256 && table[code] == 0 {
 					table[code] = u
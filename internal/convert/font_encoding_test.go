@@ -103,6 +103,6 @@ func TestOriginalCodeToUnicodeToUnicodeFallback(t *testing.T) {
 }
 
 func mustTable(d pdf.PDFDict) [256]uint16 {
-	table, _ := originalSimpleFontCodeToUnicode(d)
+	table, _ := originalSimpleFontCodeToUnicode(d, nil)
 	return table
 }
@@ -0,0 +1,339 @@
+package convert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/verify"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// OptimizeOptions selects the rewrites Optimize makes. The zero value only
+// recompresses: it re-deflates Flate streams at the best compression level
+// and converts ASCIIHex- and ASCII85-encoded streams to binary Flate.
+type OptimizeOptions struct {
+	// MaxDPI downsamples an image whose largest placement draws it at more
+	// than MaxDPI pixels per inch to that resolution. 0 keeps every image's
+	// resolution.
+	MaxDPI float64
+	// DCTQuality, from 1 to 100, re-encodes 8-bit RGB images of at least
+	// DCTMinPixels pixels as DCTDecode (JPEG) at that quality where that
+	// shrinks them. 0 leaves images in their encoding.
+	DCTQuality   int
+	DCTMinPixels int
+}
+
+// OptimizeResult is Optimize's output and the verification of it, as for
+// Convert, and a count of the streams it rewrote.
+type OptimizeResult struct {
+	ConvertResult
+
+	// Recompressed counts streams re-deflated or converted from ASCII to
+	// Flate, Downsampled and Reencoded the images reduced to MaxDPI and
+	// re-encoded as DCT. Reverted counts rewrites undone because the
+	// output then failed a check of the profile the input passed.
+	Recompressed int
+	Downsampled  int
+	Reencoded    int
+	Reverted     int
+}
+
+// Optimize reads the PDF at path and rewrites its streams to take less
+// space (see OptimizeOptions), keeping only rewrites that introduce no
+// violation of p -- typically the profile the file was converted to. It is
+// meant for Convert's output, but any document is optimized and verified
+// against p as it is.
+func Optimize(path string, p *pdf.Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	doc, err := pdf.Open(path)
+	if err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+	defer doc.Close()
+	return RunOptimize(doc, p, opts)
+}
+
+// OptimizeBytes is Optimize for an in-memory PDF, such as a ConvertResult's
+// Output.
+func OptimizeBytes(data []byte, p *pdf.Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	doc, err := pdf.OpenBytes(data)
+	if err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+	defer doc.Close()
+	return RunOptimize(doc, p, opts)
+}
+
+// RunOptimize is Optimize for an already-open document, under p's Limits.
+// Like Run, it resolves the whole object graph, whatever p's
+// ObjectCacheLimit.
+// Rewritten streams are verified against p together: any object that then
+// has an issue it did not have before gets its original stream back, and
+// an issue no rewritten object accounts for undoes every rewrite.
+func RunOptimize(doc *pdf.Reader, p *pdf.Profile, opts OptimizeOptions) (OptimizeResult, error) {
+	if p.Level.VerifyOnly() {
+		return OptimizeResult{}, fmt.Errorf("convert: %s is a verification-only level", p.Level)
+	}
+	if opts.DCTQuality < 0 || opts.DCTQuality > 100 {
+		return OptimizeResult{}, fmt.Errorf("convert: DCT quality %d is outside 1 to 100", opts.DCTQuality)
+	}
+	p = wholeGraphProfile(p)
+	defer doc.ApplyLimits(p.Limits)()
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+	trailer, ok := graph.(pdf.PDFDict)
+	if !ok {
+		return OptimizeResult{}, fmt.Errorf("convert: resolved graph is not a dictionary")
+	}
+	var or OptimizeResult
+	if err := stripEncryption(&trailer, doc, &or.ConvertResult); err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+
+	before, _, objs, err := inHeapVerify(doc, trailer, p)
+	if err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+	baseline := issueCounts(before.Issues, objs)
+
+	o := &optimizer{
+		doc:   doc,
+		opts:  opts,
+		saved: map[uintptr]savedStream{},
+		kinds: map[uintptr]rewriteKind{},
+		raw:   map[uintptr][]byte{},
+	}
+	if opts.MaxDPI > 0 {
+		o.placements, o.pinned = imagePlacements(trailer, doc)
+	}
+	o.rewrite(trailer)
+	if err := doc.Err(); err != nil {
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+
+	var parts verify.Parts
+	for {
+		or.Result, parts, objs, err = inHeapVerify(doc, trailer, p)
+		if err != nil {
+			return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+		}
+		culprits, all := o.culprits(or.Result.Issues, objs, baseline)
+		if all {
+			culprits = slices.Sorted(maps.Keys(o.saved))
+		}
+		if len(culprits) == 0 {
+			break
+		}
+		o.revert(trailer, culprits)
+		or.Reverted += len(culprits)
+	}
+	for _, kind := range o.kinds {
+		switch kind {
+		case rewriteRecompressed:
+			or.Recompressed++
+		case rewriteDownsampled:
+			or.Downsampled++
+		case rewriteReencoded:
+			or.Reencoded++
+		}
+	}
+	if err := serializeAndVerify(doc, trailer, nil, &or.ConvertResult, p, parts, true); err != nil {
+		if cerr := doc.Err(); cerr != nil {
+			return OptimizeResult{}, fmt.Errorf("convert: %w", cerr)
+		}
+		return OptimizeResult{}, fmt.Errorf("convert: %w", err)
+	}
+	return or, nil
+}
+
+// issueKey identifies an issue for comparing verifies of one graph before
+// and after rewriting: its check, page, and the object it is reported
+// against, by Entries pointer since that survives renumbering.
+type issueKey struct {
+	check pdf.Check
+	page  int
+	obj   uintptr
+}
+
+func issueKeyOf(iss pdf.PDFError, objs map[int]pdf.PDFValue) issueKey {
+	key := issueKey{check: iss.Check(), page: iss.Page()}
+	if ref, ok := iss.ObjectRef(); ok {
+		if d, ok := objs[ref.ObjNum].(pdf.PDFDict); ok {
+			key.obj = pdf.ValuePointer(d.Entries)
+		}
+	}
+	return key
+}
+
+func issueCounts(issues []pdf.PDFError, objs map[int]pdf.PDFValue) map[issueKey]int {
+	counts := map[issueKey]int{}
+	for _, iss := range issues {
+		counts[issueKeyOf(iss, objs)]++
+	}
+	return counts
+}
+
+// rewriteKind records which rewrite an optimizer made to a stream.
+type rewriteKind int
+
+const (
+	rewriteRecompressed rewriteKind = iota
+	rewriteDownsampled
+	rewriteReencoded
+)
+
+// savedStream is a stream as it was before an optimizer rewrote it.
+type savedStream struct {
+	raw     []byte
+	entries map[string]pdf.PDFValue
+}
+
+// optimizer rewrites the streams of one graph, remembering each one's
+// original so a rewrite can be undone. A rewrite changes a stream's
+// Entries in place, so the stream keeps the identity (Entries pointer)
+// its issues and every reference to it go by.
+type optimizer struct {
+	// doc is the run's Reader, whose limits the streams are decoded under.
+	doc  *pdf.Reader
+	opts OptimizeOptions
+
+	// placements holds each placed image's largest drawn width and height
+	// in points, and pinned the images also drawn where placements are not
+	// tracked; see imagePlacements.
+	placements map[uintptr][2]float64
+	pinned     map[uintptr]bool
+
+	saved map[uintptr]savedStream
+	kinds map[uintptr]rewriteKind
+	// raw holds each rewritten stream's new data, which every reference to
+	// a stream shared by several parents must be given.
+	raw map[uintptr][]byte
+}
+
+// rewrite rewrites every stream reachable from trailer.
+func (o *optimizer) rewrite(trailer pdf.PDFDict) {
+	seen := map[uintptr]bool{}
+	walkStreamDicts(trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
+		ptr := pdf.ValuePointer(d.Entries)
+		if raw, ok := o.raw[ptr]; ok {
+			d.RawStream = raw
+			return d, true
+		}
+		if !d.HasStream || seen[ptr] {
+			return d, false
+		}
+		seen[ptr] = true
+		out, kind, ok := o.rewriteStream(d)
+		if !ok {
+			return d, false
+		}
+		o.saved[ptr] = savedStream{raw: d.RawStream, entries: maps.Clone(d.Entries)}
+		o.kinds[ptr] = kind
+		o.raw[ptr] = out.RawStream
+		replaceEntries(d.Entries, out.Entries)
+		d.RawStream = out.RawStream
+		return d, true
+	})
+}
+
+// replaceEntries makes dst's entries those of src, keeping dst's _ref,
+// which renumbering may have changed since src was taken.
+func replaceEntries(dst, src map[string]pdf.PDFValue) {
+	ref, hasRef := dst["_ref"]
+	clear(dst)
+	maps.Copy(dst, src)
+	if hasRef {
+		dst["_ref"] = ref
+	}
+}
+
+// rewriteStream returns d rewritten by the first rewrite that applies.
+func (o *optimizer) rewriteStream(d pdf.PDFDict) (pdf.PDFDict, rewriteKind, bool) {
+	if (d.Entries["Subtype"] == pdf.PDFName{Value: "Image"}) {
+		if out, kind, ok := o.optimizeImage(d); ok {
+			return out, kind, true
+		}
+	}
+	if out, ok := recompressStream(d, o.doc); ok {
+		return out, rewriteRecompressed, true
+	}
+	return d, 0, false
+}
+
+// recompressibleFilters are the filters recompressStream replaces: Flate
+// and the ASCII encodings, which only inflate binary data.
+var recompressibleFilters = map[string]bool{
+	"FlateDecode": true, "Fl": true, "ASCIIHexDecode": true, "AHx": true, "ASCII85Decode": true, "A85": true,
+}
+
+// recompressStream re-deflates at the best compression level a stream
+// whose filters are all recompressibleFilters, if that makes it smaller.
+// XMP metadata, which PDF/A readers may expect unfiltered, and external
+// streams are left alone. The stream is decoded under doc's limits.
+func recompressStream(d pdf.PDFDict, doc *pdf.Reader) (pdf.PDFDict, bool) {
+	filters := pdf.FilterNames(d.Entries["Filter"])
+	if len(filters) == 0 || d.Entries["F"] != nil || (d.Entries["Type"] == pdf.PDFName{Value: "Metadata"}) {
+		return d, false
+	}
+	for _, f := range filters {
+		if !recompressibleFilters[f] {
+			return d, false
+		}
+	}
+	data, err := doc.DecodeStreamLimited(d)
+	if err != nil {
+		return d, false
+	}
+	out := pdf.PDFDict{Entries: maps.Clone(d.Entries)}
+	if err := writer.SetStreamFlateBest(&out, data); err != nil || len(out.RawStream) >= len(d.RawStream) {
+		return d, false
+	}
+	return out, true
+}
+
+// culprits returns the rewritten streams issues has an issue against that
+// baseline did not, and whether there is a new issue no rewritten stream
+// accounts for.
+func (o *optimizer) culprits(issues []pdf.PDFError, objs map[int]pdf.PDFValue, baseline map[issueKey]int) ([]uintptr, bool) {
+	counts := issueCounts(issues, objs)
+	var out []uintptr
+	all := false
+	for key, n := range counts {
+		if n <= baseline[key] {
+			continue
+		}
+		if _, ok := o.saved[key.obj]; ok {
+			out = append(out, key.obj)
+			continue
+		}
+		all = true
+	}
+	slices.Sort(out)
+	return out, all
+}
+
+// revert restores the streams ptrs to their originals throughout trailer.
+func (o *optimizer) revert(trailer pdf.PDFDict, ptrs []uintptr) {
+	undo := map[uintptr][]byte{}
+	for _, ptr := range ptrs {
+		undo[ptr] = o.saved[ptr].raw
+	}
+	walkStreamDicts(trailer, map[uintptr]bool{}, func(d pdf.PDFDict) (pdf.PDFDict, bool) {
+		ptr := pdf.ValuePointer(d.Entries)
+		raw, ok := undo[ptr]
+		if !ok {
+			return d, false
+		}
+		if s, ok := o.saved[ptr]; ok {
+			replaceEntries(d.Entries, s.entries)
+			delete(o.saved, ptr)
+			delete(o.kinds, ptr)
+			delete(o.raw, ptr)
+		}
+		d.RawStream = raw
+		return d, true
+	})
+}
@@ -0,0 +1,385 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// optimizeImage downsamples d to MaxDPI and re-encodes it as DCT as
+// opts ask. Only unmasked 8-bit images in a colour space whose samples
+// average meaningfully are touched: no stencil masks, Indexed images or
+// colour-key masks, whose averaged samples would pick other colours.
+func (o *optimizer) optimizeImage(d pdf.PDFDict) (pdf.PDFDict, rewriteKind, bool) {
+	if d.Entries["ImageMask"] == pdf.PDFBoolean(true) || pdf.DictInt(d, "BitsPerComponent", 0) != 8 {
+		return d, 0, false
+	}
+	if _, ok := d.Entries["Mask"].(pdf.PDFArray); ok {
+		return d, 0, false
+	}
+	// A soft mask with /Matte must keep the image's dimensions.
+	if smask, ok := d.Entries["SMask"].(pdf.PDFDict); ok && smask.Entries["Matte"] != nil {
+		return d, 0, false
+	}
+	family, ncomp := imageColourFamily(d.Entries["ColorSpace"])
+	if ncomp == 0 {
+		return d, 0, false
+	}
+	width, height := pdf.DictInt(d, "Width", 0), pdf.DictInt(d, "Height", 0)
+	if width <= 0 || height <= 0 {
+		return d, 0, false
+	}
+	newWidth, newHeight := o.targetSize(d, width, height)
+	downsample := newWidth < width || newHeight < height
+	dct := o.opts.DCTQuality > 0 && ncomp == 3 && family != "Lab" && newWidth*newHeight >= o.opts.DCTMinPixels
+	if !downsample && !dct {
+		return d, 0, false
+	}
+
+	samples, wasDCT, ok := imageSamples(d, ncomp, width, height, o.doc)
+	if !ok {
+		return d, 0, false
+	}
+	if downsample {
+		samples = downsampleSamples(samples, ncomp, width, height, newWidth, newHeight)
+	}
+	out := pdf.PDFDict{Entries: maps.Clone(d.Entries)}
+	out.Entries["Width"], out.Entries["Height"] = pdf.PDFInteger(newWidth), pdf.PDFInteger(newHeight)
+	quality := o.opts.DCTQuality
+	if quality == 0 && wasDCT {
+		quality = jpeg.DefaultQuality // a downsampled JPEG stays a JPEG
+	}
+	if dct || wasDCT {
+		if err := setStreamDCT(&out, samples, ncomp, newWidth, newHeight, quality); err != nil {
+			return d, 0, false
+		}
+	} else if err := writer.SetStreamFlateBest(&out, samples); err != nil {
+		return d, 0, false
+	}
+	if len(out.RawStream) >= len(d.RawStream) {
+		return d, 0, false
+	}
+	if downsample {
+		return out, rewriteDownsampled, true
+	}
+	return out, rewriteReencoded, true
+}
+
+// targetSize returns the size d is to be downsampled to: its size at
+// MaxDPI over its largest placement, or its own size if it is drawn no
+// larger than that, never placed, or drawn somewhere untracked.
+func (o *optimizer) targetSize(d pdf.PDFDict, width, height int) (int, int) {
+	ptr := pdf.ValuePointer(d.Entries)
+	size, ok := o.placements[ptr]
+	if o.opts.MaxDPI <= 0 || !ok || o.pinned[ptr] {
+		return width, height
+	}
+	fit := func(pixels int, points float64) int {
+		return max(1, min(pixels, int(math.Ceil(points*o.opts.MaxDPI/72))))
+	}
+	return fit(width, size[0]), fit(height, size[1])
+}
+
+// imageColourFamily returns an image colour space's family and component
+// count, or 0 components for one whose samples optimizeImage leaves alone.
+func imageColourFamily(cs pdf.PDFValue) (string, int) {
+	var family string
+	switch v := cs.(type) {
+	case pdf.PDFName:
+		family = v.Value
+	case pdf.PDFArray:
+		if len(v) > 0 {
+			name, _ := v[0].(pdf.PDFName)
+			family = name.Value
+		}
+	}
+	switch family {
+	case "DeviceGray", "G", "CalGray", "DeviceRGB", "RGB", "CalRGB", "DeviceCMYK", "CMYK", "ICCBased", "Lab":
+		return family, pdf.ColorSpaceComponents(cs)
+	}
+	return family, 0
+}
+
+// imageSamples decodes d's width*height*ncomp samples, and whether they
+// came from JPEG data, which only a gray or RGB image's may. The stream is
+// decoded under doc's limits.
+func imageSamples(d pdf.PDFDict, ncomp, width, height int, doc *pdf.Reader) ([]byte, bool, bool) {
+	data, codec, err := doc.DecodeStreamToCodecLimited(d)
+	if err != nil {
+		return nil, false, false
+	}
+	switch codec {
+	case "":
+	case "DCTDecode":
+		if ncomp == 4 {
+			return nil, false, false
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil || img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+			return nil, false, false
+		}
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		data = packRGBSamples(rgba)
+		if ncomp == 1 {
+			for i := range width * height {
+				data[i] = data[3*i]
+			}
+			data = data[:width*height]
+		}
+		return data, true, true
+	default:
+		return nil, false, false
+	}
+	if len(data) < width*height*ncomp {
+		return nil, false, false
+	}
+	return data[:width*height*ncomp], false, true
+}
+
+// downsampleSamples box-filters ncomp-component samples from width*height
+// to newWidth*newHeight, each output sample the mean of the source samples
+// its pixel covers.
+func downsampleSamples(samples []byte, ncomp, width, height, newWidth, newHeight int) []byte {
+	out := make([]byte, 0, newWidth*newHeight*ncomp)
+	sums := make([]int, ncomp)
+	for y := range newHeight {
+		y0, y1 := y*height/newHeight, max(y*height/newHeight+1, (y+1)*height/newHeight)
+		for x := range newWidth {
+			x0, x1 := x*width/newWidth, max(x*width/newWidth+1, (x+1)*width/newWidth)
+			clear(sums)
+			for sy := y0; sy < y1; sy++ {
+				row := samples[(sy*width+x0)*ncomp : (sy*width+x1)*ncomp]
+				for i, v := range row {
+					sums[i%ncomp] += int(v)
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			for _, s := range sums {
+				out = append(out, byte((s+n/2)/n))
+			}
+		}
+	}
+	return out
+}
+
+// setStreamDCT stores gray or RGB samples in d as a DCTDecode stream at
+// quality.
+func setStreamDCT(d *pdf.PDFDict, samples []byte, ncomp, width, height, quality int) error {
+	var img image.Image
+	if ncomp == 1 {
+		img = &image.Gray{Pix: samples, Stride: width, Rect: image.Rect(0, 0, width, height)}
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := range width * height {
+			copy(rgba.Pix[4*i:], samples[3*i:3*i+3])
+			rgba.Pix[4*i+3] = 0xFF
+		}
+		img = rgba
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	d.RawStream = buf.Bytes()
+	d.HasStream = true
+	d.Entries["Filter"] = pdf.PDFName{Value: "DCTDecode"}
+	delete(d.Entries, "DecodeParms")
+	delete(d.Entries, "DP")
+	return nil
+}
+
+// imagePlacements scans the content of every page, and of the form
+// XObjects, tiling patterns and annotation appearances it draws, for images
+// painted with Do, returning the largest width and height each is drawn at
+// in default user space units (points). Images a Type 3 glyph may draw are
+// also returned pinned: a glyph's size depends on text state the scan does
+// not track. The content is decoded under doc's limits.
+func imagePlacements(trailer pdf.PDFDict, doc *pdf.Reader) (map[uintptr][2]float64, map[uintptr]bool) {
+	s := &placementScanner{sizes: map[uintptr][2]float64{}, pinned: map[uintptr]bool{}, active: map[uintptr]bool{}, doc: doc}
+	for _, page := range orderedPages(trailer) {
+		base := IdentityMatrix
+		if unit, ok := pdf.PDFNumberToFloat(page.dict.Entries["UserUnit"]); ok && unit > 0 {
+			base = Matrix{A: unit, D: unit}
+		}
+		if data, err := pageContentBytes(page.dict, doc); err == nil {
+			s.scan(data, page.resources, base)
+		}
+		annots, _ := page.dict.Entries["Annots"].(pdf.PDFArray)
+		for _, a := range annots {
+			annot, ok := a.(pdf.PDFDict)
+			if !ok {
+				continue
+			}
+			ap, _ := annot.Entries["AP"].(pdf.PDFDict)
+			for _, key := range []string{"N", "R", "D"} {
+				for _, form := range appearanceStreams(ap.Entries[key]) {
+					s.scanForm(form, page.resources, appearanceMatrix(annot, form).Mul(base))
+				}
+			}
+		}
+	}
+	return s.sizes, s.pinned
+}
+
+// appearanceStreams returns an appearance entry's streams: the entry
+// itself, or each state's in an appearance subdictionary.
+func appearanceStreams(v pdf.PDFValue) []pdf.PDFDict {
+	d, ok := v.(pdf.PDFDict)
+	if !ok {
+		return nil
+	}
+	if d.HasStream {
+		return []pdf.PDFDict{d}
+	}
+	var out []pdf.PDFDict
+	for _, k := range slices.Sorted(maps.Keys(d.Entries)) {
+		if s, ok := d.Entries[k].(pdf.PDFDict); ok && s.HasStream {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// appearanceMatrix returns the matrix that fits form's transformed bounding
+// box to annot's Rect (ISO 32000-1 12.5.5), which form's own Matrix is
+// applied before; the identity if either is missing or degenerate.
+func appearanceMatrix(annot, form pdf.PDFDict) Matrix {
+	rect, err := pdf.FloatArray(annot.Entries["Rect"])
+	bbox, berr := pdf.FloatArray(form.Entries["BBox"])
+	if err != nil || berr != nil || len(rect) != 4 || len(bbox) != 4 {
+		return IdentityMatrix
+	}
+	fm := formMatrix(form)
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, c := range [][2]float64{{bbox[0], bbox[1]}, {bbox[2], bbox[1]}, {bbox[0], bbox[3]}, {bbox[2], bbox[3]}} {
+		p := fm.Apply(Point{X: c[0], Y: c[1]})
+		minX, minY, maxX, maxY = math.Min(minX, p.X), math.Min(minY, p.Y), math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	if maxX <= minX || maxY <= minY {
+		return IdentityMatrix
+	}
+	sx := math.Abs(rect[2]-rect[0]) / (maxX - minX)
+	sy := math.Abs(rect[3]-rect[1]) / (maxY - minY)
+	return Matrix{A: sx, D: sy, E: math.Min(rect[0], rect[2]) - minX*sx, F: math.Min(rect[1], rect[3]) - minY*sy}
+}
+
+// formMatrix returns a form XObject's or pattern's /Matrix, the identity
+// if it has none.
+func formMatrix(d pdf.PDFDict) Matrix {
+	if m, err := pdf.FloatArray(d.Entries["Matrix"]); err == nil && len(m) == 6 {
+		return Matrix{A: m[0], B: m[1], C: m[2], D: m[3], E: m[4], F: m[5]}
+	}
+	return IdentityMatrix
+}
+
+// placementScanner accumulates imagePlacements' results. active holds the
+// forms and patterns being scanned, so one drawing itself is not rescanned
+// forever. doc supplies the limits the content is decoded under.
+type placementScanner struct {
+	sizes  map[uintptr][2]float64
+	pinned map[uintptr]bool
+	active map[uintptr]bool
+	doc    *pdf.Reader
+}
+
+// scan tracks the CTM through content, whose default space ctm maps to the
+// page's, recording every image it draws from resources.
+func (s *placementScanner) scan(content []byte, resources pdf.PDFDict, ctm Matrix) {
+	s.scanResources(resources, ctm)
+	var stack []Matrix
+	xobjects, _ := resources.Entries["XObject"].(pdf.PDFDict)
+	pdf.NewContentScanner(content).Scan(func(op string, operands []pdf.PDFValue) {
+		switch op {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if n := len(stack); n > 0 {
+				ctm, stack = stack[n-1], stack[:n-1]
+			}
+		case "cm":
+			if a := numericOperands(operands); len(a) == 6 {
+				ctm = Matrix{A: a[0], B: a[1], C: a[2], D: a[3], E: a[4], F: a[5]}.Mul(ctm)
+			}
+		case "Do":
+			if len(operands) == 0 {
+				return
+			}
+			name, _ := operands[len(operands)-1].(pdf.PDFName)
+			xobj, ok := xobjects.Entries[name.Value].(pdf.PDFDict)
+			if !ok || !xobj.HasStream {
+				return
+			}
+			switch xobj.Entries["Subtype"] {
+			case pdf.PDFName{Value: "Image"}:
+				s.place(xobj, ctm)
+			case pdf.PDFName{Value: "Form"}:
+				s.scanForm(xobj, resources, ctm)
+			}
+		}
+	})
+}
+
+// scanForm scans a form XObject drawn with ctm, under its own resources or
+// else parent's.
+func (s *placementScanner) scanForm(form, parent pdf.PDFDict, ctm Matrix) {
+	ptr := pdf.ValuePointer(form.Entries)
+	if s.active[ptr] || len(s.active) > maxPlacementDepth {
+		return
+	}
+	s.active[ptr] = true
+	defer delete(s.active, ptr)
+	resources, ok := form.Entries["Resources"].(pdf.PDFDict)
+	if !ok {
+		resources = parent
+	}
+	if data, err := s.doc.DecodeStreamLimited(form); err == nil {
+		s.scan(data, resources, formMatrix(form).Mul(ctm))
+	}
+}
+
+// maxPlacementDepth bounds how deeply nested forms and patterns are scanned.
+const maxPlacementDepth = 32
+
+// scanResources scans the tiling patterns in resources, whose pattern space
+// base maps to the page's, and pins the images Type 3 glyphs drawn with
+// resources may draw.
+func (s *placementScanner) scanResources(resources pdf.PDFDict, base Matrix) {
+	patterns, _ := resources.Entries["Pattern"].(pdf.PDFDict)
+	for _, k := range slices.Sorted(maps.Keys(patterns.Entries)) {
+		if pattern, ok := patterns.Entries[k].(pdf.PDFDict); ok && pattern.HasStream {
+			s.scanForm(pattern, resources, base)
+		}
+	}
+	fonts, _ := resources.Entries["Font"].(pdf.PDFDict)
+	for _, f := range fonts.Entries {
+		font, ok := f.(pdf.PDFDict)
+		if !ok || (font.Entries["Subtype"] != pdf.PDFName{Value: "Type3"}) {
+			continue
+		}
+		glyphResources, ok := font.Entries["Resources"].(pdf.PDFDict)
+		if !ok {
+			glyphResources = resources
+		}
+		xobjects, _ := glyphResources.Entries["XObject"].(pdf.PDFDict)
+		for _, x := range xobjects.Entries {
+			if xobj, ok := x.(pdf.PDFDict); ok {
+				s.pinned[pdf.ValuePointer(xobj.Entries)] = true
+			}
+		}
+	}
+}
+
+// place records image drawn with ctm, which maps its unit square.
+func (s *placementScanner) place(image pdf.PDFDict, ctm Matrix) {
+	ptr := pdf.ValuePointer(image.Entries)
+	size := s.sizes[ptr]
+	s.sizes[ptr] = [2]float64{max(size[0], math.Hypot(ctm.A, ctm.B)), max(size[1], math.Hypot(ctm.C, ctm.D))}
+}
@@ -0,0 +1,63 @@
+package convert
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// TestDownsampleSamples checks box filtering by a whole and a fractional
+// factor.
+func TestDownsampleSamples(t *testing.T) {
+	gray := []byte{
+		0, 10, 20, 30,
+		40, 50, 60, 70,
+	}
+	if got := downsampleSamples(gray, 1, 4, 2, 2, 1); !slices.Equal(got, []byte{25, 45}) {
+		t.Errorf("4x2 to 2x1 = %v, want [25 45]", got)
+	}
+	rgb := []byte{0, 0, 0, 30, 60, 90, 255, 255, 255}
+	if got := downsampleSamples(rgb, 3, 3, 1, 2, 1); !slices.Equal(got, []byte{0, 0, 0, 143, 158, 173}) {
+		t.Errorf("3x1 to 2x1 = %v, want [0 0 0 143 158 173]", got)
+	}
+}
+
+// TestImagePlacements checks that an image's size is its largest placement
+// across the page and a scaled form, and that an image a Type 3 font can
+// draw is pinned.
+func TestImagePlacements(t *testing.T) {
+	stream := func(content string, entries map[string]pdf.PDFValue) pdf.PDFDict {
+		return pdf.PDFDict{Entries: entries, HasStream: true, RawStream: []byte(content)}
+	}
+	image := stream("", map[string]pdf.PDFValue{"Subtype": pdf.PDFName{Value: "Image"}})
+	glyphImage := stream("", map[string]pdf.PDFValue{"Subtype": pdf.PDFName{Value: "Image"}})
+	form := stream("q 50 0 0 20 0 0 cm /Im0 Do Q", map[string]pdf.PDFValue{
+		"Subtype": pdf.PDFName{Value: "Form"},
+		"Matrix":  pdf.PDFArray{pdf.PDFInteger(4), pdf.PDFInteger(0), pdf.PDFInteger(0), pdf.PDFInteger(4), pdf.PDFInteger(0), pdf.PDFInteger(0)},
+	})
+	page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":     pdf.PDFName{Value: "Page"},
+		"Contents": stream("q 100 0 0 100 0 0 cm /Im0 Do Q /Fm0 Do", nil),
+		"Resources": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Im0": image, "Fm0": form}},
+			"Font": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"T3": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"Subtype": pdf.PDFName{Value: "Type3"},
+				"Resources": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+					"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"G0": glyphImage}},
+				}},
+			}}}},
+		}},
+	}}
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Pages": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Kids": pdf.PDFArray{page}}},
+	}}}}
+
+	sizes, pinned := imagePlacements(trailer, nil)
+	if got := sizes[pdf.ValuePointer(image.Entries)]; got != [2]float64{200, 100} {
+		t.Errorf("Im0 size = %v, want the form's 200 wide and the page's 100 high", got)
+	}
+	if !pinned[pdf.ValuePointer(glyphImage.Entries)] || pinned[pdf.ValuePointer(image.Entries)] {
+		t.Errorf("pinned = %v, want only the Type 3 glyph's image", pinned)
+	}
+}
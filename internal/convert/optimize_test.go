@@ -0,0 +1,166 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// optimizeTestDoc returns a one-page document whose ASCIIHex content draws
+// Im0, a 600x600 gradient, one inch square, and Im1, 300x300 of RGB noise
+// smoothed to look photographic, four inches square.
+func optimizeTestDoc(t *testing.T) []byte {
+	t.Helper()
+	return buildOnePageDoc(t, func(_, _, page pdf.PDFDict) {
+		content := page.Entries["Contents"].(pdf.PDFDict)
+		content.RawStream = encodeASCIIHex([]byte("q 72 0 0 72 0 0 cm /Im0 Do Q q 288 0 0 288 100 100 cm /Im1 Do Q"))
+		content.Entries["Filter"] = pdf.PDFName{Value: "ASCIIHexDecode"}
+		page.Entries["Contents"] = content
+
+		image := func(ref, size int, pixel func(x, y int) [3]byte) pdf.PDFDict {
+			samples := make([]byte, 0, size*size*3)
+			for y := range size {
+				for x := range size {
+					p := pixel(x, y)
+					samples = append(samples, p[:]...)
+				}
+			}
+			d := pdf.NewPDFDict()
+			d.Entries["_ref"] = pdf.PDFRef{ObjNum: ref}
+			d.Entries["Type"] = pdf.PDFName{Value: "XObject"}
+			d.Entries["Subtype"] = pdf.PDFName{Value: "Image"}
+			d.Entries["Width"], d.Entries["Height"] = pdf.PDFInteger(size), pdf.PDFInteger(size)
+			d.Entries["BitsPerComponent"] = pdf.PDFInteger(8)
+			d.Entries["ColorSpace"] = pdf.PDFName{Value: "DeviceRGB"}
+			if err := writer.SetStreamFlateFast(&d, samples); err != nil {
+				t.Fatal(err)
+			}
+			return d
+		}
+		rng := rand.New(rand.NewPCG(1, 2))
+		page.Entries["Resources"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"Im0": image(10, 600, func(x, y int) [3]byte { return [3]byte{byte(x / 3), byte(y / 3), 128} }),
+				"Im1": image(11, 300, func(x, y int) [3]byte {
+					n := byte(rng.IntN(16))
+					return [3]byte{byte(x) + n, byte(y) + n, byte(x+y) + n}
+				}),
+			}},
+		}}
+	})
+}
+
+// TestOptimize checks each rewrite on optimizeTestDoc: the content stream
+// turned binary Flate, Im0 downsampled to 150 dpi, Im1, at 75 dpi, left its
+// size but re-encoded as DCT, and no new issue against the profile.
+func TestOptimize(t *testing.T) {
+	data := optimizeTestDoc(t)
+	before, err := OptimizeBytes(data, pdf.PDFA_2B, OptimizeOptions{})
+	if err != nil {
+		t.Fatalf("OptimizeBytes(zero options): %v", err)
+	}
+	if before.Recompressed == 0 || before.Downsampled != 0 || before.Reencoded != 0 {
+		t.Errorf("zero options rewrote %d, %d, %d streams; want only recompression",
+			before.Recompressed, before.Downsampled, before.Reencoded)
+	}
+
+	or, err := OptimizeBytes(data, pdf.PDFA_2B, OptimizeOptions{MaxDPI: 150, DCTQuality: 75, DCTMinPixels: 300 * 300})
+	if err != nil {
+		t.Fatalf("OptimizeBytes: %v", err)
+	}
+	if or.Downsampled != 1 || or.Reencoded != 1 || or.Reverted != 0 {
+		t.Errorf("downsampled %d, re-encoded %d, reverted %d; want 1, 1, 0", or.Downsampled, or.Reencoded, or.Reverted)
+	}
+	if len(or.Output) >= len(before.Output) {
+		t.Errorf("output is %d bytes, %d with only recompression", len(or.Output), len(before.Output))
+	}
+	if got, want := issueClauses(or.Residual()), issueClauses(before.Residual()); !slices.Equal(got, want) {
+		t.Errorf("residual %v, want %v as recompressed only", got, want)
+	}
+
+	doc, err := pdf.OpenBytes(or.Output)
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	page := graph.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict).Entries["Pages"].(pdf.PDFDict).Entries["Kids"].(pdf.PDFArray)[0].(pdf.PDFDict)
+	content := page.Entries["Contents"].(pdf.PDFDict)
+	if got := pdf.FilterNames(content.Entries["Filter"]); !slices.Equal(got, []string{"FlateDecode"}) {
+		t.Errorf("content filters = %v, want [FlateDecode]", got)
+	}
+	if text, err := pdf.DecodeStream(content); err != nil || !bytes.Contains(text, []byte("/Im1 Do")) {
+		t.Errorf("content = %q, %v", text, err)
+	}
+	xobjects := page.Entries["Resources"].(pdf.PDFDict).Entries["XObject"].(pdf.PDFDict)
+	im0, im1 := xobjects.Entries["Im0"].(pdf.PDFDict), xobjects.Entries["Im1"].(pdf.PDFDict)
+	if w, h := pdf.DictInt(im0, "Width", 0), pdf.DictInt(im0, "Height", 0); w != 150 || h != 150 {
+		t.Errorf("Im0 is %dx%d, want 150x150", w, h)
+	}
+	if samples, err := pdf.DecodeStream(im0); err != nil || len(samples) != 150*150*3 {
+		t.Errorf("Im0 decodes to %d bytes, %v; want %d", len(samples), err, 150*150*3)
+	}
+	if got := pdf.FilterNames(im1.Entries["Filter"]); !slices.Equal(got, []string{"DCTDecode"}) || pdf.DictInt(im1, "Width", 0) != 300 {
+		t.Errorf("Im1 is %d wide with filters %v, want 300 and [DCTDecode]", pdf.DictInt(im1, "Width", 0), got)
+	}
+}
+
+// TestOptimizeLimits checks the optimizer decodes under the profile's
+// Limits: Im0's 1080000 samples are over StreamBytes, whether recompressed
+// or downsampled, which stops the run with a *pdf.LimitError.
+func TestOptimizeLimits(t *testing.T) {
+	data := optimizeTestDoc(t)
+	p := pdf.PDFA_2B.Clone()
+	p.Limits = pdf.Limits{StreamBytes: 500000}
+	for _, opts := range []OptimizeOptions{{}, {MaxDPI: 150}} {
+		_, err := OptimizeBytes(data, p, opts)
+		var le *pdf.LimitError
+		if !errors.As(err, &le) || le.Limit != "StreamBytes" {
+			t.Errorf("OptimizeBytes(%+v) = %v, want a StreamBytes LimitError", opts, err)
+		}
+	}
+}
+
+// TestOptimizerRevert checks that reverting a rewritten stream restores its
+// data and entries at every reference to it, keeping its current _ref.
+func TestOptimizerRevert(t *testing.T) {
+	stream := pdf.NewPDFDict()
+	stream.Entries["_ref"] = pdf.PDFRef{ObjNum: 5}
+	stream.Entries["Filter"] = pdf.PDFName{Value: "ASCIIHexDecode"}
+	stream.HasStream = true
+	stream.RawStream = encodeASCIIHex(bytes.Repeat([]byte("0 0 m 1 1 l S\n"), 50))
+	original := stream.RawStream
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"A": pdf.PDFArray{stream, stream}, "B": stream,
+	}}
+	o := &optimizer{saved: map[uintptr]savedStream{}, kinds: map[uintptr]rewriteKind{}, raw: map[uintptr][]byte{}}
+	o.rewrite(trailer)
+	refs := func() []pdf.PDFDict {
+		a := trailer.Entries["A"].(pdf.PDFArray)
+		return []pdf.PDFDict{a[0].(pdf.PDFDict), a[1].(pdf.PDFDict), trailer.Entries["B"].(pdf.PDFDict)}
+	}
+	for i, d := range refs() {
+		if d.Entries["Filter"] != (pdf.PDFName{Value: "FlateDecode"}) || bytes.Equal(d.RawStream, original) {
+			t.Fatalf("reference %d was not recompressed: %v", i, d.Entries["Filter"])
+		}
+	}
+
+	stream.Entries["_ref"] = pdf.PDFRef{ObjNum: 9}
+	o.revert(trailer, []uintptr{pdf.ValuePointer(stream.Entries)})
+	for i, d := range refs() {
+		if d.Entries["Filter"] != (pdf.PDFName{Value: "ASCIIHexDecode"}) || !bytes.Equal(d.RawStream, original) {
+			t.Errorf("reference %d was not reverted: %v", i, d.Entries["Filter"])
+		}
+	}
+	if stream.Entries["_ref"] != (pdf.PDFRef{ObjNum: 9}) || len(o.saved) != 0 || len(o.kinds) != 0 {
+		t.Errorf("after revert _ref = %v, saved %d, kinds %d; want 9, 0, 0", stream.Entries["_ref"], len(o.saved), len(o.kinds))
+	}
+}
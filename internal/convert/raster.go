@@ -2,6 +2,7 @@ package convert
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"image"
 	"math"
@@ -17,13 +18,21 @@ import (
 // f/F/f*/S/s/B/B*/b/b*/n), colour (g/G/rg/RG/k/K/cs/CS/sc/SC/scn/SCN), alpha
 // (gs ExtGState ca/CA), Form/Image XObjects (Do, recursing into Forms and
 // compositing Images including their own /SMask), and text (BT/ET/Tf/Td/TD/
-// Tm/T*/Tj/TJ/'/"). Clipping (W/W*) is approximated as a bounding box.
+// Tm/T*/Tj/TJ/'/"). Clipping (W/W*) is approximated as a bounding box. A
+// canvas, or an image painted on it, over pdf.DefaultLimits().RasterPixels is
+// a *pdf.LimitError.
 func RenderPage(page pdf.PDFDict, resources pdf.PDFDict, mediaBox [4]float64, dpi int) (*image.RGBA, error) {
-	content, err := pageContentBytes(page)
+	return renderPage(page, resources, mediaBox, dpi, nil)
+}
+
+// renderPage is RenderPage under doc's RasterPixels limit, recording a
+// breach on doc (see pdf.Reader.RecordLimit). doc may be nil.
+func renderPage(page pdf.PDFDict, resources pdf.PDFDict, mediaBox [4]float64, dpi int, doc *pdf.Reader) (*image.RGBA, error) {
+	content, err := pageContentBytes(page, doc)
 	if err != nil {
 		return nil, err
 	}
-	return renderContent(content, resources, mediaBox, dpi)
+	return renderContent(content, resources, mediaBox, dpi, doc)
 }
 
 // renderFormContent rasterizes a Form XObject's own /BBox + content in
@@ -32,29 +41,36 @@ func RenderPage(page pdf.PDFDict, resources pdf.PDFDict, mediaBox [4]float64, dp
 // paints once flattened, since only its content is being replaced, not its
 // identity or placement. Returns the rendered buffer and the BBox it was
 // rendered against (needed to place the replacement image back into it).
-func renderFormContent(form pdf.PDFDict, resources pdf.PDFDict, dpi int) (*image.RGBA, [4]float64, error) {
+// Like renderPage, it applies doc's RasterPixels limit.
+func renderFormContent(form pdf.PDFDict, resources pdf.PDFDict, dpi int, doc *pdf.Reader) (*image.RGBA, [4]float64, error) {
 	bbox, err := pdf.FloatArray(form.Entries["BBox"])
 	if err != nil || len(bbox) != 4 {
 		return nil, [4]float64{}, fmt.Errorf("raster: missing or invalid Form /BBox")
 	}
 	box := [4]float64{bbox[0], bbox[1], bbox[2], bbox[3]}
-	content, err := pdf.DecodeStream(form)
+	content, err := doc.DecodeStreamLimited(form)
 	if err != nil {
 		return nil, [4]float64{}, err
 	}
-	canvas, err := renderContent(content, resources, box, dpi)
+	canvas, err := renderContent(content, resources, box, dpi, doc)
 	return canvas, box, err
 }
 
 // renderContent is the shared core behind RenderPage and renderFormContent:
 // it rasterizes content into a fresh opaque-white canvas sized from bounds
 // (a user-space rect) at dpi, then runs the graphics-state machine over it.
-func renderContent(content []byte, resources pdf.PDFDict, bounds [4]float64, dpi int) (*image.RGBA, error) {
-	width := int(math.Ceil((bounds[2] - bounds[0]) * float64(dpi) / 72))
-	height := int(math.Ceil((bounds[3] - bounds[1]) * float64(dpi) / 72))
-	if width <= 0 || height <= 0 || width > 20000 || height > 20000 {
+// A canvas or image over doc's RasterPixels limit is a *pdf.LimitError,
+// recorded on doc.
+func renderContent(content []byte, resources pdf.PDFDict, bounds [4]float64, dpi int, doc *pdf.Reader) (*image.RGBA, error) {
+	w := math.Ceil((bounds[2] - bounds[0]) * float64(dpi) / 72)
+	h := math.Ceil((bounds[3] - bounds[1]) * float64(dpi) / 72)
+	if !(w > 0 && h > 0) || w > math.MaxInt32 || h > math.MaxInt32 {
 		return nil, fmt.Errorf("raster: degenerate or oversized bounds")
 	}
+	if max := doc.Limits().RasterPixels; max > 0 && w*h > float64(max) {
+		return nil, doc.RecordLimit(&pdf.LimitError{Limit: "RasterPixels", Max: max})
+	}
+	width, height := int(w), int(h)
 	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
 	// Opaque white backdrop via doubling copies (memmove) instead of a
 	// per-byte loop -- the canvas fill was a measurable share of small
@@ -73,18 +89,22 @@ func renderContent(content []byte, resources pdf.PDFDict, bounds [4]float64, dpi
 		ctm: base, fillAlpha: 1, strokeAlpha: 1, lineWidth: 1, hScale: 1,
 		clip: [4]float64{0, 0, float64(width), float64(height)},
 	}
-	r := &renderer{canvas: canvas, fontCache: map[uintptr]*fontInfo{}}
+	r := &renderer{canvas: canvas, fontCache: map[uintptr]*fontInfo{}, doc: doc}
 	r.execContent(content, resources, gs)
+	if r.err != nil {
+		return nil, r.err
+	}
 	return canvas, nil
 }
 
 // pageContentBytes concatenates a page's /Contents stream(s) (a single
-// stream or an array of streams, per the spec, joined by whitespace).
-func pageContentBytes(page pdf.PDFDict) ([]byte, error) {
+// stream or an array of streams, per the spec, joined by whitespace),
+// decoded under doc's limits.
+func pageContentBytes(page pdf.PDFDict, doc *pdf.Reader) ([]byte, error) {
 	var out []byte
 	switch c := page.Entries["Contents"].(type) {
 	case pdf.PDFDict:
-		data, err := pdf.DecodeStream(c)
+		data, err := doc.DecodeStreamLimited(c)
 		if err != nil {
 			return nil, err
 		}
@@ -95,7 +115,7 @@ func pageContentBytes(page pdf.PDFDict) ([]byte, error) {
 			if !ok {
 				continue
 			}
-			data, err := pdf.DecodeStream(d)
+			data, err := doc.DecodeStreamLimited(d)
 			if err != nil {
 				return nil, err
 			}
@@ -132,6 +152,11 @@ type renderer struct {
 	canvas    *image.RGBA
 	fontCache map[uintptr]*fontInfo
 	depth     int
+	// doc supplies the RasterPixels limit images are decoded under; nil
+	// applies pdf.DefaultLimits. err is the limit an image exceeded, which
+	// fails the render rather than leaving the image out.
+	doc *pdf.Reader
+	err error
 }
 
 // pathBuilder accumulates the current path's subpaths in user space, kept
@@ -535,8 +560,9 @@ func (r *renderer) doXObject(operands []pdf.PDFValue, resources pdf.PDFDict, gs
 			fm := Matrix{A: m[0], B: m[1], C: m[2], D: m[3], E: m[4], F: m[5]}
 			childGS.ctm = fm.Mul(gs.ctm)
 		}
-		data, err := pdf.DecodeStream(xobj)
+		data, err := r.doc.DecodeStreamLimited(xobj)
 		if err != nil {
+			r.noteLimit(err)
 			return
 		}
 		r.execContent(data, formRes, childGS)
@@ -545,12 +571,30 @@ func (r *renderer) doXObject(operands []pdf.PDFValue, resources pdf.PDFDict, gs
 	}
 }
 
+// noteLimit keeps err as the render's error if it is a limit breach.
+func (r *renderer) noteLimit(err error) {
+	if r.err == nil {
+		r.err = recordLimit(r.doc, err)
+	}
+}
+
+// recordLimit records err on doc (see pdf.Reader.RecordLimit) if it is a
+// limit breach, returning the recorded breach, or nil if err is not one.
+func recordLimit(doc *pdf.Reader, err error) error {
+	var le *pdf.LimitError
+	if errors.As(err, &le) {
+		return doc.RecordLimit(le)
+	}
+	return nil
+}
+
 // paintImage maps an Image XObject's unit square through the CTM, sampling
 // the decoded RGBA (and, if present, its /SMask's luminosity as a per-pixel
 // alpha multiplier) into the canvas with nearest-neighbour resampling.
 func (r *renderer) paintImage(xobj pdf.PDFDict, resources pdf.PDFDict, gs *renderState) {
-	img, err := DecodeImageRGBA(xobj, resources)
+	img, err := decodeImageRGBA(xobj, resources, r.doc)
 	if err != nil {
+		r.noteLimit(err)
 		return
 	}
 	w, h := img.Bounds().Dx(), img.Bounds().Dy()
@@ -561,9 +605,11 @@ func (r *renderer) paintImage(xobj pdf.PDFDict, resources pdf.PDFDict, gs *rende
 	var smask *image.RGBA
 	var smW, smH int
 	if sm, ok := xobj.Entries["SMask"].(pdf.PDFDict); ok {
-		if decoded, err := DecodeImageRGBA(sm, resources); err == nil {
+		if decoded, err := decodeImageRGBA(sm, resources, r.doc); err == nil {
 			smask = decoded
 			smW, smH = decoded.Bounds().Dx(), decoded.Bounds().Dy()
+		} else {
+			r.noteLimit(err)
 		}
 	}
 
@@ -725,18 +771,18 @@ func (r *renderer) fontInfoFor(font pdf.PDFDict) *fontInfo {
 	if fi, ok := r.fontCache[key]; ok {
 		return fi
 	}
-	fi := buildFontInfo(font)
+	fi := buildFontInfo(font, r.doc)
 	r.fontCache[key] = fi
 	return fi
 }
 
-func buildFontInfo(font pdf.PDFDict) *fontInfo {
+func buildFontInfo(font pdf.PDFDict, doc *pdf.Reader) *fontInfo {
 	if df, ok := font.Entries["DescendantFonts"].(pdf.PDFArray); ok && len(df) > 0 {
 		if desc, ok := df[0].(pdf.PDFDict); ok {
-			return buildCompositeFontInfo(desc)
+			return buildCompositeFontInfo(desc, doc)
 		}
 	}
-	return buildSimpleFontInfo(font)
+	return buildSimpleFontInfo(font, doc)
 }
 
 // buildCompositeFontInfo handles Type0/Identity-H fonts: 2-byte codes are
@@ -744,7 +790,7 @@ func buildFontInfo(font pdf.PDFDict) *fontInfo {
 // outlines come directly from the descendant's embedded program by GID --
 // no name/cmap resolution needed, since both TrueType loca/glyf and CFF
 // CharStrings INDEX are already GID-ordered.
-func buildCompositeFontInfo(desc pdf.PDFDict) *fontInfo {
+func buildCompositeFontInfo(desc pdf.PDFDict, doc *pdf.Reader) *fontInfo {
 	fi := &fontInfo{bytesPerCode: 2, defaultWidth: 1000, widths: map[int]float64{}}
 	if dw, ok := pdf.PDFNumberToFloat(desc.Entries["DW"]); ok {
 		fi.defaultWidth = dw
@@ -757,7 +803,7 @@ func buildCompositeFontInfo(desc pdf.PDFDict) *fontInfo {
 
 	cidToGID := func(cid int) int { return cid }
 	if c2g, ok := desc.Entries["CIDToGIDMap"].(pdf.PDFDict); ok && c2g.HasStream {
-		if data, err := pdf.DecodeStream(c2g); err == nil {
+		if data, err := doc.DecodeStreamLimited(c2g); err == nil {
 			cidToGID = func(cid int) int {
 				if cid*2+2 > len(data) {
 					return 0
@@ -769,7 +815,7 @@ func buildCompositeFontInfo(desc pdf.PDFDict) *fontInfo {
 
 	desc2, _ := desc.Entries["FontDescriptor"].(pdf.PDFDict)
 	if ff2, ok := desc2.Entries["FontFile2"].(pdf.PDFDict); ok {
-		data, err := pdf.DecodeStream(ff2)
+		data, err := doc.DecodeStreamLimited(ff2)
 		if err == nil {
 			if tables, ok := verify.ParseSfnt(data); ok {
 				fi.glyphFor = func(code int) (GlyphPath, bool) {
@@ -780,7 +826,7 @@ func buildCompositeFontInfo(desc pdf.PDFDict) *fontInfo {
 		}
 	}
 	if ff3, ok := desc2.Entries["FontFile3"].(pdf.PDFDict); ok {
-		data, err := pdf.DecodeStream(ff3)
+		data, err := doc.DecodeStreamLimited(ff3)
 		if err == nil {
 			cff := extractCFFBytes(data)
 			if cff != nil {
@@ -798,7 +844,7 @@ func buildCompositeFontInfo(desc pdf.PDFDict) *fontInfo {
 // buildSimpleFontInfo handles Type1/TrueType/MMType1 simple fonts: single
 // byte codes map to glyph names via Encoding (BaseEncoding + Differences),
 // then to outlines via the embedded font program.
-func buildSimpleFontInfo(font pdf.PDFDict) *fontInfo {
+func buildSimpleFontInfo(font pdf.PDFDict, doc *pdf.Reader) *fontInfo {
 	fi := &fontInfo{bytesPerCode: 1, widths: map[int]float64{}}
 	firstChar, fcOK := font.Entries["FirstChar"].(pdf.PDFInteger)
 	if !fcOK {
@@ -820,7 +866,7 @@ func buildSimpleFontInfo(font pdf.PDFDict) *fontInfo {
 	names := resolveSimpleEncoding(font.Entries["Encoding"])
 
 	if ff, ok := desc.Entries["FontFile"].(pdf.PDFDict); ok {
-		data, err := pdf.DecodeStream(ff)
+		data, err := doc.DecodeStreamLimited(ff)
 		if err == nil {
 			fi.glyphFor = func(code int) (GlyphPath, bool) {
 				if code < 0 || code > 255 || names[code] == "" {
@@ -832,7 +878,7 @@ func buildSimpleFontInfo(font pdf.PDFDict) *fontInfo {
 		}
 	}
 	if ff2, ok := desc.Entries["FontFile2"].(pdf.PDFDict); ok {
-		data, err := pdf.DecodeStream(ff2)
+		data, err := doc.DecodeStreamLimited(ff2)
 		if err == nil {
 			if tables, ok := verify.ParseSfnt(data); ok {
 				gidMap := verify.ParseCmapFormat4(verify.TTWindowsBMPCmap(tables))
@@ -844,7 +890,7 @@ func buildSimpleFontInfo(font pdf.PDFDict) *fontInfo {
 		}
 	}
 	if ff3, ok := desc.Entries["FontFile3"].(pdf.PDFDict); ok {
-		data, err := pdf.DecodeStream(ff3)
+		data, err := doc.DecodeStreamLimited(ff3)
 		if err == nil {
 			if cff := extractCFFBytes(data); cff != nil {
 				fi.glyphFor = func(code int) (GlyphPath, bool) {
@@ -859,7 +905,7 @@ func buildSimpleFontInfo(font pdf.PDFDict) *fontInfo {
 	// No usable embedded program: render through the bundled faces when the
 	// codes' original meaning is known (e.g. standard symbol fonts), so a
 	// rasterized page does not silently drop those glyphs.
-	if origTable, baseKnown := originalSimpleFontCodeToUnicode(font); baseKnown {
+	if origTable, baseKnown := originalSimpleFontCodeToUnicode(font, doc); baseKnown {
 		type parsedFace struct {
 			tables map[string][]byte
 			cmap   map[uint16]uint16
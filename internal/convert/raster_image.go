@@ -2,10 +2,10 @@ package convert
 
 import (
 	"bytes"
-	"fmt"
 	"image"
 	"image/draw"
 	"image/jpeg"
+	"maps"
 
 	"github.com/voidrab/gopdfrab/internal/pdf"
 )
@@ -13,19 +13,25 @@ import (
 // DecodeImageRGBA decodes an Image XObject dictionary's samples into an RGBA
 // buffer suitable for compositing during page rendering.
 //
-// FlateDecode/LZWDecode/ASCII-filtered raw samples (with PNG/TIFF predictor
-// undo), DCTDecode (via the standard library's image/jpeg) and CCITTFaxDecode
-// (Group 3/4, ccitt.go) are supported. JBIG2Decode/JPXDecode have no decoder
-// in this codebase -- those JBIG2/JPEG2000 codecs are large standalone
-// efforts out of scope here -- so an image using one of them, or a CCITT
-// image that fails to decode, is painted as a flat mid-gray placeholder
-// instead of failing the page.
+// Every filter chain pdf.DecodeStream decodes is supported, plus DCTDecode
+// (via the standard library's image/jpeg) and JPXDecode (via pdf.DecodeJPX).
+// A CCITT, JBIG2 or JPEG 2000 image that fails to decode is painted as a
+// flat mid-gray placeholder instead of failing the page. An image over
+// pdf.DefaultLimits().RasterPixels is a *pdf.LimitError.
 func DecodeImageRGBA(dict pdf.PDFDict, resources pdf.PDFDict) (*image.RGBA, error) {
+	return decodeImageRGBA(dict, resources, nil)
+}
+
+// decodeImageRGBA is DecodeImageRGBA under doc's Limits. doc may be nil.
+func decodeImageRGBA(dict pdf.PDFDict, resources pdf.PDFDict, doc *pdf.Reader) (*image.RGBA, error) {
 	width := pdf.DictInt(dict, "Width", 0)
 	height := pdf.DictInt(dict, "Height", 0)
 	if width <= 0 || height <= 0 {
 		return nil, errInvalidImageDims
 	}
+	if max := doc.Limits().RasterPixels; max > 0 && float64(width)*float64(height) > float64(max) {
+		return nil, &pdf.LimitError{Limit: "RasterPixels", Max: max}
+	}
 
 	filters := pdf.FilterNames(dict.Entries["Filter"])
 	last := ""
@@ -35,17 +41,28 @@ func DecodeImageRGBA(dict pdf.PDFDict, resources pdf.PDFDict) (*image.RGBA, erro
 
 	switch last {
 	case "DCTDecode", "DCT":
-		return decodeJPEGImage(dict)
-	case "CCITTFaxDecode", "CCF":
-		if img, err := decodeCCITTImage(dict, resources, width, height); err == nil {
+		return decodeJPEGImage(dict, doc)
+	case "CCITTFaxDecode", "CCF", "JBIG2Decode":
+		img, err := decodeBilevelImage(dict, resources, width, height, doc)
+		if err == nil {
 			return img, nil
 		}
+		if le := recordLimit(doc, err); le != nil {
+			return nil, le
+		}
 		return placeholderImage(width, height), nil
-	case "JBIG2Decode", "JPXDecode":
+	case "JPXDecode":
+		img, err := decodeJPXImage(dict, resources, doc)
+		if err == nil {
+			return img, nil
+		}
+		if le := recordLimit(doc, err); le != nil {
+			return nil, le
+		}
 		return placeholderImage(width, height), nil
 	}
 
-	data, err := decodeImageRawSamples(dict)
+	data, err := doc.DecodeStreamLimited(dict)
 	if err != nil {
 		return nil, err
 	}
@@ -58,43 +75,6 @@ func (e imageDecodeError) Error() string { return string(e) }
 
 const errInvalidImageDims = imageDecodeError("raster_image: invalid /Width or /Height")
 
-// decodeImageRawSamples decodes an image stream's filter chain (FlateDecode,
-// LZWDecode, ASCIIHex/85Decode) and undoes any PNG/TIFF predictor, leaving
-// raw packed component samples.
-func decodeImageRawSamples(dict pdf.PDFDict) ([]byte, error) {
-	filters := pdf.FilterNames(dict.Entries["Filter"])
-	hasLZW := false
-	for _, f := range filters {
-		if f == "LZWDecode" || f == "LZW" {
-			hasLZW = true
-		}
-	}
-
-	var data []byte
-	var err error
-	if hasLZW {
-		data, err = pdf.DecodeLZW(dict.RawStream)
-	} else {
-		data, err = pdf.DecodeStream(dict)
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	parms := pdf.StreamDecodeParms(dict)
-	predictor := pdf.DictInt(parms, "Predictor", 1)
-	if predictor == 1 {
-		return data, nil
-	}
-	columns := pdf.DictInt(parms, "Columns", pdf.DictInt(dict, "Width", 1))
-	colors := pdf.DictInt(parms, "Colors", 1)
-	bpc := pdf.DictInt(parms, "BitsPerComponent", pdf.DictInt(dict, "BitsPerComponent", 8))
-	if predictor == 2 {
-		return pdf.UndoTIFFPredictor(data, columns, colors, bpc)
-	}
-	return pdf.UndoPNGPredictor(data, columns, colors, bpc)
-}
-
 // unpackSamplesToRGBA reads width*height pixels of packed component samples
 // (bitsPerComponent-wide, row-padded to a byte boundary per the PDF spec)
 // and resolves each pixel's colour via ResolveColor.
@@ -365,66 +345,25 @@ func indexedHead(cs pdf.PDFValue) (pdf.PDFArray, bool) {
 	return arr, ok && (name.Value == "Indexed" || name.Value == "I")
 }
 
-// decodeCCITTImage decodes a CCITTFaxDecode image into RGBA, running any
-// preceding ASCII filters, decoding the fax bitstream (ccitt.go) into packed
-// 1-bpc samples and resolving them through the normal sample path.
-func decodeCCITTImage(dict pdf.PDFDict, resources pdf.PDFDict, width, height int) (*image.RGBA, error) {
-	data, err := ccittEncodedBytes(dict)
-	if err != nil {
-		return nil, err
-	}
-	parms := pdf.StreamDecodeParms(dict)
-	p := pdf.CCITTParams{
-		Columns:   pdf.DictInt(parms, "Columns", 1728),
-		Rows:      pdf.DictInt(parms, "Rows", 0),
-		K:         pdf.DictInt(parms, "K", 0),
-		ByteAlign: parms.Entries["EncodedByteAlign"] == pdf.PDFBoolean(true),
-		BlackIs1:  parms.Entries["BlackIs1"] == pdf.PDFBoolean(true),
-	}
-	if p.Columns <= 0 {
-		p.Columns = width
-	}
-	if p.Rows <= 0 {
-		p.Rows = height
-	}
-	raw, err := pdf.DecodeCCITT(data, p)
+// decodeBilevelImage decodes a CCITTFaxDecode or JBIG2Decode image into
+// RGBA: the fax or JBIG2 bitstream decodes to packed 1-bpc samples resolved
+// through the normal sample path.
+func decodeBilevelImage(dict pdf.PDFDict, resources pdf.PDFDict, width, height int, doc *pdf.Reader) (*image.RGBA, error) {
+	raw, err := doc.DecodeStreamLimited(dict)
 	if err != nil {
 		return nil, err
 	}
 	return unpackSamplesToRGBA(dict, resources, raw, width, height)
 }
 
-// ccittEncodedBytes returns the bytes feeding the CCITTFaxDecode filter,
-// undoing any ASCII filters applied before it in the chain.
-func ccittEncodedBytes(dict pdf.PDFDict) ([]byte, error) {
-	data := dict.RawStream
-	for _, f := range pdf.FilterNames(dict.Entries["Filter"]) {
-		switch f {
-		case "ASCIIHexDecode", "AHx":
-			out, err := pdf.DecodeASCIIHex(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "ASCII85Decode", "A85":
-			out, err := pdf.DecodeASCII85(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "CCITTFaxDecode", "CCF":
-			return data, nil
-		default:
-			return nil, fmt.Errorf("ccitt: unexpected filter %q before CCITTFaxDecode", f)
-		}
+// decodeJPEGImage decodes a DCTDecode image stream, after any filters
+// applied over the JPEG data, using the standard library's JPEG decoder.
+func decodeJPEGImage(dict pdf.PDFDict, doc *pdf.Reader) (*image.RGBA, error) {
+	data, _, err := doc.DecodeStreamToCodecLimited(dict)
+	if err != nil {
+		return nil, err
 	}
-	return data, nil
-}
-
-// decodeJPEGImage decodes a DCTDecode image stream using the standard
-// library's JPEG decoder.
-func decodeJPEGImage(dict pdf.PDFDict) (*image.RGBA, error) {
-	img, err := jpeg.Decode(bytes.NewReader(dict.RawStream))
+	img, err := jpeg.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -437,6 +376,93 @@ func decodeJPEGImage(dict pdf.PDFDict) (*image.RGBA, error) {
 	return out, nil
 }
 
+// decodeJPXImage decodes a JPXDecode image stream into RGBA through the
+// normal sample path, at the dimensions of the JPEG 2000 data.
+func decodeJPXImage(dict pdf.PDFDict, resources pdf.PDFDict, doc *pdf.Reader) (*image.RGBA, error) {
+	samples, d, err := decodeJPXSamples(dict, resources, doc)
+	if err != nil {
+		return nil, err
+	}
+	width, height := pdf.DictInt(d, "Width", 0), pdf.DictInt(d, "Height", 0)
+	return unpackSamplesToRGBA(d, resources, samples, width, height)
+}
+
+// decodeJPXSamples decodes a JPXDecode image stream into the samples of an
+// equivalent unfiltered image, returned with that image's dictionary: a
+// copy of dict at 8 bits per component and the JPEG 2000 data's
+// dimensions, without Decode or SMaskInData, which JPXDecode images
+// ignore or take from the data. An image without /ColorSpace gets the
+// device space matching the data's colour channels. Opacity and channels
+// beyond the colour space's are dropped; an Indexed image keeps its
+// palette indices rather than 8-bit rescaled ones.
+func decodeJPXSamples(dict pdf.PDFDict, resources pdf.PDFDict, doc *pdf.Reader) ([]byte, pdf.PDFDict, error) {
+	if dict.Entries["ImageMask"] == pdf.PDFBoolean(true) {
+		return nil, pdf.PDFDict{}, imageDecodeError("raster_image: JPXDecode image mask")
+	}
+	data, _, err := doc.DecodeStreamToCodecLimited(dict)
+	if err != nil {
+		return nil, pdf.PDFDict{}, err
+	}
+	img, err := pdf.DecodeJPX(data)
+	if err != nil {
+		return nil, pdf.PDFDict{}, err
+	}
+	colours := img.Components
+	if img.Alpha {
+		colours--
+	}
+
+	out := dict
+	out.Entries = maps.Clone(dict.Entries)
+	out.Entries["Width"] = pdf.PDFInteger(img.Width)
+	out.Entries["Height"] = pdf.PDFInteger(img.Height)
+	out.Entries["BitsPerComponent"] = pdf.PDFInteger(8)
+	delete(out.Entries, "Decode")
+	delete(out.Entries, "SMaskInData")
+	cs := resolveImageColorSpace(dict, resources)
+	if cs == nil {
+		switch colours {
+		case 1:
+			cs = pdf.PDFName{Value: "DeviceGray"}
+		case 3:
+			cs = pdf.PDFName{Value: "DeviceRGB"}
+		case 4:
+			cs = pdf.PDFName{Value: "DeviceCMYK"}
+		default:
+			return nil, pdf.PDFDict{}, imageDecodeError("raster_image: JPXDecode image has no device colour space")
+		}
+		out.Entries["ColorSpace"] = cs
+	}
+	ncomp := pdf.ColorSpaceComponents(cs)
+	if colours < ncomp {
+		return nil, pdf.PDFDict{}, imageDecodeError("raster_image: JPXDecode image has too few colour channels")
+	}
+
+	var unscale []byte
+	if _, isIndexed := indexedHead(cs); isIndexed {
+		if img.Depths[0] > 8 {
+			return nil, pdf.PDFDict{}, imageDecodeError("raster_image: JPXDecode palette indices wider than 8 bits")
+		}
+		if top := 1<<img.Depths[0] - 1; top < 255 {
+			unscale = make([]byte, 256)
+			for v := range unscale {
+				unscale[v] = byte((v*top + 127) / 255)
+			}
+		}
+	}
+
+	samples := make([]byte, img.Width*img.Height*ncomp)
+	for i := range img.Width * img.Height {
+		copy(samples[i*ncomp:(i+1)*ncomp], img.Pix[i*img.Components:])
+	}
+	if unscale != nil {
+		for i, v := range samples {
+			samples[i] = unscale[v]
+		}
+	}
+	return samples, out, nil
+}
+
 // placeholderImage paints a flat mid-gray rectangle, the fallback used for
 // image codecs this package cannot decode.
 func placeholderImage(width, height int) *image.RGBA {
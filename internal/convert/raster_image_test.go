@@ -163,6 +163,117 @@ func TestDecodeImageRGBAJPEGRoundTrip(t *testing.T) {
 	}
 }
 
+func TestDecodeImageRGBAJBIG2(t *testing.T) {
+	// An 8x2 JBIG2 page whose default pixel is black, its top row
+	// replaced by one all-white MMR row.
+	stream := []byte{
+		0, 0, 0, 0, 48, 0, 1, 0, 0, 0, 19, // page information
+		0, 0, 0, 8, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0x44, 0, 0,
+		0, 0, 0, 1, 38, 0, 1, 0, 0, 0, 19, // immediate generic region
+		0, 0, 0, 8, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 4, 1, 0x80,
+	}
+	dict := pdf.PDFDict{
+		Entries: map[string]pdf.PDFValue{
+			"Width": pdf.PDFInteger(8), "Height": pdf.PDFInteger(2),
+			"BitsPerComponent": pdf.PDFInteger(1),
+			"ColorSpace":       pdf.PDFName{Value: "DeviceGray"},
+			"Filter":           pdf.PDFName{Value: "JBIG2Decode"},
+		},
+		HasStream: true,
+		RawStream: stream,
+	}
+	img, err := DecodeImageRGBA(dict, pdf.PDFDict{})
+	if err != nil {
+		t.Fatalf("DecodeImageRGBA: %v", err)
+	}
+	if r, g, b := pixelAt(t, img, 3, 0); r != 255 || g != 255 || b != 255 {
+		t.Errorf("top row pixel = (%d,%d,%d), want white", r, g, b)
+	}
+	if r, g, b := pixelAt(t, img, 3, 1); r != 0 || g != 0 || b != 0 {
+		t.Errorf("bottom row pixel = (%d,%d,%d), want black", r, g, b)
+	}
+}
+
+// jpxTestRGB is a lossless 2x2 JPEG 2000 codestream of 8-bit RGB samples:
+// red, green, blue, white.
+var jpxTestRGB = []byte{
+	0xFF, 0x4F, 0xFF, 0x51, 0x00, 0x2F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x07, 0x01, 0x01, 0x07, 0x01, 0x01,
+	0x07, 0x01, 0x01, 0xFF, 0x52, 0x00, 0x0C, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x02, 0x02, 0x00,
+	0x01, 0xFF, 0x5C, 0x00, 0x07, 0x40, 0x48, 0x50, 0x50, 0x58, 0xFF, 0x90, 0x00, 0x0A, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x21, 0x00, 0x01, 0xFF, 0x93, 0x00, 0x00, 0x00, 0x91, 0xF9, 0x81, 0x00, 0x00,
+	0xDF, 0xC7, 0xE0, 0x02, 0x00, 0x00, 0xA3, 0xF0, 0x01, 0x00, 0x00, 0xFF, 0xD9,
+}
+
+// jpxTestIndices is a 4x1 JPEG 2000 codestream of 2-bit samples 0, 1, 2,
+// 3, for use under an Indexed colour space.
+var jpxTestIndices = []byte{
+	0xFF, 0x4F, 0xFF, 0x51, 0x00, 0x29, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01, 0x01, 0xFF, 0x52, 0x00,
+	0x0C, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x00, 0x01, 0xFF, 0x5C, 0x00, 0x04, 0x40,
+	0x18, 0xFF, 0x90, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x01, 0xFF, 0x93, 0xCE,
+	0x84, 0x06, 0x69, 0xFF, 0xD9,
+}
+
+func TestDecodeImageRGBAJPX(t *testing.T) {
+	t.Run("device colour space from the data", func(t *testing.T) {
+		// No /ColorSpace and a stale /BitsPerComponent: both come from
+		// the JPEG 2000 data.
+		dict := imageDict(map[string]pdf.PDFValue{
+			"Width": pdf.PDFInteger(2), "Height": pdf.PDFInteger(2),
+			"BitsPerComponent": pdf.PDFInteger(4),
+			"Filter":           pdf.PDFName{Value: "JPXDecode"},
+		}, jpxTestRGB)
+		img, err := DecodeImageRGBA(dict, pdf.PDFDict{})
+		if err != nil {
+			t.Fatalf("DecodeImageRGBA: %v", err)
+		}
+		want := [][3]uint8{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {255, 255, 255}}
+		for i, w := range want {
+			if r, g, b := pixelAt(t, img, i%2, i/2); [3]uint8{r, g, b} != w {
+				t.Errorf("pixel %d = (%d,%d,%d), want %v", i, r, g, b, w)
+			}
+		}
+	})
+
+	t.Run("indexed", func(t *testing.T) {
+		dict := imageDict(map[string]pdf.PDFValue{
+			"Width": pdf.PDFInteger(4), "Height": pdf.PDFInteger(1),
+			"ColorSpace": pdf.PDFArray{
+				pdf.PDFName{Value: "Indexed"}, pdf.PDFName{Value: "DeviceGray"}, pdf.PDFInteger(3),
+				pdf.PDFString{Value: "\x00\x10\x20\x30"},
+			},
+			"Filter": pdf.PDFName{Value: "JPXDecode"},
+		}, jpxTestIndices)
+		img, err := DecodeImageRGBA(dict, pdf.PDFDict{})
+		if err != nil {
+			t.Fatalf("DecodeImageRGBA: %v", err)
+		}
+		for x, want := range []uint8{0x00, 0x10, 0x20, 0x30} {
+			if r, _, _ := pixelAt(t, img, x, 0); r != want {
+				t.Errorf("pixel %d = %#x, want palette entry %#x", x, r, want)
+			}
+		}
+	})
+
+	t.Run("corrupt data is a placeholder", func(t *testing.T) {
+		dict := imageDict(map[string]pdf.PDFValue{
+			"Width": pdf.PDFInteger(2), "Height": pdf.PDFInteger(2),
+			"ColorSpace": pdf.PDFName{Value: "DeviceRGB"},
+			"Filter":     pdf.PDFName{Value: "JPXDecode"},
+		}, jpxTestRGB[:20])
+		img, err := DecodeImageRGBA(dict, pdf.PDFDict{})
+		if err != nil {
+			t.Fatalf("DecodeImageRGBA: %v", err)
+		}
+		if r, g, b := pixelAt(t, img, 1, 1); r != 127 || g != 127 || b != 127 {
+			t.Errorf("pixel = (%d,%d,%d), want the mid-gray placeholder", r, g, b)
+		}
+	})
+}
+
 func TestDecodeImageRGBAUnsupportedCodecPlaceholder(t *testing.T) {
 	dict := pdf.PDFDict{
 		Entries: map[string]pdf.PDFValue{
@@ -372,114 +483,3 @@ func TestColorRGBA64Clamps(t *testing.T) {
 		t.Errorf("b = %d, want %d", b, wantB)
 	}
 }
-
-// TestCcittEncodedBytes covers ccittEncodedBytes' pre-filter branches
-// (ASCIIHexDecode and ASCII85Decode before the CCITT filter) and the
-// unexpected-filter error path.
-func TestCcittEncodedBytes(t *testing.T) {
-	payload := []byte{0x98, 0x01}
-
-	t.Run("ASCIIHexDecode prefilter", func(t *testing.T) {
-		dict := pdf.PDFDict{
-			Entries:   map[string]pdf.PDFValue{"Filter": pdf.PDFArray{pdf.PDFName{Value: "ASCIIHexDecode"}, pdf.PDFName{Value: "CCITTFaxDecode"}}},
-			RawStream: encodeASCIIHex(payload),
-		}
-		got, err := ccittEncodedBytes(dict)
-		if err != nil {
-			t.Fatalf("ccittEncodedBytes: %v", err)
-		}
-		if !bytes.Equal(got, payload) {
-			t.Errorf("got %x, want %x", got, payload)
-		}
-	})
-
-	t.Run("ASCII85Decode prefilter", func(t *testing.T) {
-		dict := pdf.PDFDict{
-			Entries:   map[string]pdf.PDFValue{"Filter": pdf.PDFArray{pdf.PDFName{Value: "ASCII85Decode"}, pdf.PDFName{Value: "CCITTFaxDecode"}}},
-			RawStream: encodeASCII85(payload),
-		}
-		got, err := ccittEncodedBytes(dict)
-		if err != nil {
-			t.Fatalf("ccittEncodedBytes: %v", err)
-		}
-		if !bytes.Equal(got, payload) {
-			t.Errorf("got %x, want %x", got, payload)
-		}
-	})
-
-	t.Run("unexpected filter before CCITT", func(t *testing.T) {
-		dict := pdf.PDFDict{
-			Entries:   map[string]pdf.PDFValue{"Filter": pdf.PDFArray{pdf.PDFName{Value: "FlateDecode"}, pdf.PDFName{Value: "CCITTFaxDecode"}}},
-			RawStream: payload,
-		}
-		if _, err := ccittEncodedBytes(dict); err == nil {
-			t.Error("ccittEncodedBytes: want error for unexpected pre-filter, got nil")
-		}
-	})
-}
-
-// TestDecodeImageRawSamplesLZWAndPredictors covers the LZW-filter branch and
-// the TIFF (predictor 2) / PNG (predictor >=10) predictor-undo paths, none
-// of which the colour-space-focused DecodeImageRGBA tests exercise.
-func TestDecodeImageRawSamplesLZWAndPredictors(t *testing.T) {
-	t.Run("LZWDecode filter", func(t *testing.T) {
-		want := []byte{1, 2, 3, 4, 5, 6}
-		dict := pdf.PDFDict{
-			Entries:   map[string]pdf.PDFValue{"Filter": pdf.PDFName{Value: "LZWDecode"}},
-			RawStream: encodeLZW(t, want),
-		}
-		got, err := decodeImageRawSamples(dict)
-		if err != nil {
-			t.Fatalf("decodeImageRawSamples: %v", err)
-		}
-		if !bytes.Equal(got, want) {
-			t.Errorf("got %v, want %v", got, want)
-		}
-	})
-
-	t.Run("TIFF predictor", func(t *testing.T) {
-		// 2 columns, 1 colour, 8bpc: row [10, 5] TIFF-delta-encoded is [10, 5]
-		// itself (each sample is a delta from the previous, first is raw).
-		dict := pdf.PDFDict{
-			Entries: map[string]pdf.PDFValue{
-				"Width": pdf.PDFInteger(2),
-				"DecodeParms": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
-					"Predictor": pdf.PDFInteger(2), "Columns": pdf.PDFInteger(2), "Colors": pdf.PDFInteger(1), "BitsPerComponent": pdf.PDFInteger(8),
-				}},
-			},
-			HasStream: true,
-			RawStream: []byte{10, 5},
-		}
-		got, err := decodeImageRawSamples(dict)
-		if err != nil {
-			t.Fatalf("decodeImageRawSamples: %v", err)
-		}
-		want := []byte{10, 15}
-		if !bytes.Equal(got, want) {
-			t.Errorf("got %v, want %v", got, want)
-		}
-	})
-
-	t.Run("PNG predictor", func(t *testing.T) {
-		// PNG predictor 12 (up): a 1-byte-per-pixel filter tag (0=None)
-		// followed by the raw row, decodes to the row unchanged.
-		dict := pdf.PDFDict{
-			Entries: map[string]pdf.PDFValue{
-				"Width": pdf.PDFInteger(2),
-				"DecodeParms": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
-					"Predictor": pdf.PDFInteger(12), "Columns": pdf.PDFInteger(2), "Colors": pdf.PDFInteger(1), "BitsPerComponent": pdf.PDFInteger(8),
-				}},
-			},
-			HasStream: true,
-			RawStream: []byte{0x00, 7, 9},
-		}
-		got, err := decodeImageRawSamples(dict)
-		if err != nil {
-			t.Fatalf("decodeImageRawSamples: %v", err)
-		}
-		want := []byte{7, 9}
-		if !bytes.Equal(got, want) {
-			t.Errorf("got %v, want %v", got, want)
-		}
-	})
-}
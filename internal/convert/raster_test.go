@@ -303,7 +303,7 @@ func TestPageContentBytesArrayAndErrors(t *testing.T) {
 				pdf.PDFDict{HasStream: true, RawStream: []byte("5 5 10 10 re f")},
 			},
 		}}
-		got, err := pageContentBytes(page)
+		got, err := pageContentBytes(page, nil)
 		if err != nil {
 			t.Fatalf("pageContentBytes: %v", err)
 		}
@@ -320,7 +320,7 @@ func TestPageContentBytesArrayAndErrors(t *testing.T) {
 				HasStream: true, RawStream: []byte{0xFF, 0xFF, 0xFF},
 			},
 		}}
-		if _, err := pageContentBytes(page); err == nil {
+		if _, err := pageContentBytes(page, nil); err == nil {
 			t.Error("pageContentBytes: want error for undecodable stream, got nil")
 		}
 	})
@@ -334,7 +334,7 @@ func TestPageContentBytesArrayAndErrors(t *testing.T) {
 				},
 			},
 		}}
-		if _, err := pageContentBytes(page); err == nil {
+		if _, err := pageContentBytes(page, nil); err == nil {
 			t.Error("pageContentBytes: want error for undecodable stream in array, got nil")
 		}
 	})
@@ -399,7 +399,7 @@ func TestBuildSimpleFontInfoFontFile3AndFallback(t *testing.T) {
 			"FontFile3": pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: cff},
 		}}
 		font := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"FontDescriptor": desc}}
-		fi := buildSimpleFontInfo(font)
+		fi := buildSimpleFontInfo(font, nil)
 		if _, ok := fi.glyphFor(1); !ok {
 			t.Error("glyphFor(1) via FontFile3 CFF = false, want true (code-as-GID approximation)")
 		}
@@ -410,7 +410,7 @@ func TestBuildSimpleFontInfoFontFile3AndFallback(t *testing.T) {
 			"BaseFont": pdf.PDFName{Value: "Helvetica"},
 			"Encoding": pdf.PDFName{Value: "WinAnsiEncoding"},
 		}}
-		fi := buildSimpleFontInfo(font)
+		fi := buildSimpleFontInfo(font, nil)
 		if _, ok := fi.glyphFor('A'); !ok {
 			t.Error("glyphFor('A') via bundled-face fallback = false, want true")
 		}
@@ -419,7 +419,7 @@ func TestBuildSimpleFontInfoFontFile3AndFallback(t *testing.T) {
 	t.Run("no usable program or encoding", func(t *testing.T) {
 		desc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Flags": pdf.PDFInteger(4)}} // symbolic
 		font := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"FontDescriptor": desc}}
-		fi := buildSimpleFontInfo(font)
+		fi := buildSimpleFontInfo(font, nil)
 		if _, ok := fi.glyphFor('A'); ok {
 			t.Error("glyphFor('A') with no program/encoding = true, want false")
 		}
@@ -3,10 +3,9 @@ package pdf
 import "fmt"
 
 // This file implements a CCITT Group 3/Group 4 (ITU-T T.4/T.6) fax decoder,
-// the codec behind PDF's CCITTFaxDecode filter. It is used only by the
-// rasterizer (raster_image.go) to reproduce bilevel fax images faithfully
-// when a page is flattened; a decode failure falls back to a placeholder, so
-// it never affects conformance, only fidelity.
+// the codec behind PDF's CCITTFaxDecode filter, which DecodeStream runs. The
+// rasterizer uses it to reproduce bilevel fax images faithfully when a page
+// is flattened; there a decode failure falls back to a placeholder.
 
 // CCITTParams holds the CCITTFaxDecode parameters that drive decoding.
 type CCITTParams struct {
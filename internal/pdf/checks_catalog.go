@@ -5,13 +5,41 @@ import (
 	"strconv"
 )
 
+// Spec identifies the standard whose clause numbering a Check follows.
+type Spec string
+
+const (
+	// SpecPDF is ISO 32000, the generic object model the objmodel checks
+	// enforce independent of any PDF/A part.
+	SpecPDF Spec = "ISO 32000"
+	// SpecPDFA1 is ISO 19005-1 (PDF/A-1).
+	SpecPDFA1 Spec = "ISO 19005-1"
+	// SpecPDFA2 is ISO 19005-2 (PDF/A-2).
+	SpecPDFA2 Spec = "ISO 19005-2"
+	// SpecPDFA3 is ISO 19005-3 (PDF/A-3).
+	SpecPDFA3 Spec = "ISO 19005-3"
+	// SpecPDFA4 is ISO 19005-4 (PDF/A-4), shared by its 4e and 4f variants.
+	SpecPDFA4 Spec = "ISO 19005-4"
+	// SpecPDFUA1 is ISO 14289-1 (PDF/UA-1), the accessibility standard.
+	SpecPDFUA1 Spec = "ISO 14289-1"
+	// SpecPDFX1A is ISO 15930-4 (PDF/X-1a:2003), CMYK print exchange.
+	SpecPDFX1A Spec = "ISO 15930-4"
+	// SpecPDFX4 is ISO 15930-7 (PDF/X-4), print exchange with transparency
+	// and colour-managed RGB.
+	SpecPDFX4 Spec = "ISO 15930-7"
+	// SpecFacturX is Factur-X 1.0 (ZUGFeRD 2.x), the hybrid e-invoice
+	// format layered on PDF/A-3.
+	SpecFacturX Spec = "Factur-X 1.0"
+)
+
 // Check is a named, selectable PDF/A validation rule, identified by a
-// (clause, subclause) pair and grouped into categories under Checks.
+// (spec, clause, subclause) triple and grouped into categories under Checks.
 type Check struct {
 	id          int    // unique sequential ID (never 0 for registered checks)
 	name        string // CamelCase identifier
 	description string // human-readable rule summary
-	clause      string // PDF/A-1 clause, e.g. "6.4"
+	spec        Spec   // standard the clause number refers to
+	clause      string // clause within spec, e.g. "6.4"
 	subclause   int    // internal sub-rule number within the clause
 }
 
@@ -21,7 +49,10 @@ func (c Check) Name() string { return c.name }
 // Description returns a human-readable summary of what this check enforces.
 func (c Check) Description() string { return c.description }
 
-// Clause returns the PDF/A-1 specification clause number (e.g. "6.1.2").
+// Spec returns the standard this check's clause number refers to.
+func (c Check) Spec() Spec { return c.spec }
+
+// Clause returns the specification clause number (e.g. "6.1.2") within Spec.
 func (c Check) Clause() string { return c.clause }
 
 // Subclause returns the internal sub-rule index within the clause.
@@ -69,6 +100,7 @@ type structureChecks struct {
 	// 6.1.10 LZW compression
 	StreamLZWFilter      Check
 	InlineImageLZWFilter Check
+	StreamJPXFilter      Check
 	// 6.1.11 Embedded files
 	EmbeddedFileSpec Check
 	EmbeddedFiles    Check
@@ -169,7 +201,7 @@ type fontChecks struct {
 	SimpleNotEmbedded Check
 	CIDNotEmbedded    Check
 	// 6.3.8 ToUnicode (Level A)
-	ToUnicodeMissing Check // TODO level A
+	ToUnicodeMissing Check
 	// 6.3.5 Subset coverage
 	SubsetGlyphCoverage Check
 	Type1SubsetCharSet  Check
@@ -243,14 +275,15 @@ type metadataChecks struct {
 
 type logicalStructureChecks struct {
 	// 6.8.2.2 Tagged PDF
-	TaggedMarkInfo Check // TODO level A
+	TaggedMarkInfo       Check
+	AlternateDescription Check
 	// 6.8.3.3 Structure tree
-	StructTreeRoot Check // TODO level A
+	StructTreeRoot Check
 	// 6.8.3.4 Role map
-	RoleMapStandardType Check // TODO level A
-	RoleMapCircular     Check // TODO level A
+	RoleMapStandardType Check
+	RoleMapCircular     Check
 	// 6.8.4 Natural language
-	LangIdentifier Check // TODO level A
+	LangIdentifier Check
 }
 
 type formChecks struct {
@@ -277,6 +310,7 @@ type objectModelChecks struct {
 	IndirectRequired        Check
 	KeyIntroducedAfterPDF14 Check
 	ConstraintViolated      Check
+	KeyIntroducedAfterPDF17 Check
 }
 
 type checksRegistry struct {
@@ -291,14 +325,51 @@ type checksRegistry struct {
 	Metadata         metadataChecks
 	Form             formChecks
 	ObjectModel      objectModelChecks
+
+	// PDFA2 holds the ISO 19005-2 clause-numbered checks. A rule PDF/A-2
+	// carried over from PDF/A-1 keeps its PDF/A-1 name, so CheckIn can map a
+	// finding from the shared verifier onto its PDF/A-2 counterpart.
+	PDFA2 pdfa2Checks
+
+	// PDFA3 holds the ISO 19005-3 checks: the PDFA2 rules under the same
+	// names, with clause 6.8 replaced by the associated-file rules.
+	PDFA3 pdfa3Checks
+
+	// PDFA4 holds the ISO 19005-4 checks: the PDFA2 rules under the same
+	// names renumbered to part 4's clauses, plus its header, document
+	// information dictionary and pdfaid:rev rules.
+	PDFA4 pdfa4Checks
+
+	// PDFUA1 holds the ISO 14289-1 (PDF/UA-1) checks. Rules shared with the
+	// PDF/A-1 level A verifier keep its check names.
+	PDFUA1 pdfua1Checks
+
+	// PDFX1A and PDFX4 hold the ISO 15930-4 (PDF/X-1a) and ISO 15930-7
+	// (PDF/X-4) checks. Rules the PDF/A verifier enforces for them keep its
+	// check names.
+	PDFX1A pdfx1aChecks
+	PDFX4  pdfx4Checks
+
+	// FacturX holds the Factur-X / ZUGFeRD hybrid invoice checks, which a
+	// profile enables on top of a PDF/A-3 level (see pdf.FacturX).
+	FacturX facturXChecks
 }
 
 var Checks checksRegistry
 
+// catalogByPair maps a (clause, subclause) pair to the first check registered
+// for it, which is the PDF/A-1 (or objmodel) check; see CheckByClause.
 var catalogByPair map[string]Check
 
+// catalogBySpec maps a (spec, clause, subclause) triple to its check.
+var catalogBySpec map[string]Check
+
+// catalogByName maps a name to the first check registered under it.
 var catalogByName map[string]Check
 
+// catalogBySpecName maps a spec and a name to the check of that name.
+var catalogBySpecName map[Spec]map[string]Check
+
 var allChecksCatalog []Check
 
 var checkIDCounter int
@@ -311,16 +382,47 @@ func AllChecks() []Check {
 }
 
 // CheckByClause looks up the registered check for a specific (clause,
-// subclause) pair, e.g. CheckByClause("6.3.4", 1). ok is false if no check is
-// registered for that pair.
+// subclause) pair, e.g. CheckByClause("6.3.4", 1), in PDF/A-1 numbering (or
+// the objmodel clause). ok is false if no check is registered for that pair;
+// use CheckBySpecClause for other parts.
 func CheckByClause(clause string, subclause int) (c Check, ok bool) {
 	key := clause + "/" + strconv.Itoa(subclause)
 	c, ok = catalogByPair[key]
 	return c, ok
 }
 
+// CheckBySpecClause looks up the registered check for a (clause, subclause)
+// pair in spec's numbering, e.g. CheckBySpecClause(SpecPDFA2, "6.2.10", 1).
+func CheckBySpecClause(spec Spec, clause string, subclause int) (c Check, ok bool) {
+	c, ok = catalogBySpec[string(spec)+"|"+clause+"/"+strconv.Itoa(subclause)]
+	return c, ok
+}
+
+// ChecksForSpec returns every check registered under spec, in catalog order.
+func ChecksForSpec(spec Spec) []Check {
+	var out []Check
+	for _, c := range allChecksCatalog {
+		if c.spec == spec {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// CheckIn returns c's counterpart in spec: the check registered under spec
+// with c's name. Objmodel checks are spec-independent and map to themselves.
+// ok is false when spec has no such rule, e.g. PDF/A-1's XRefStream under
+// PDF/A-2, which permits cross-reference streams.
+func CheckIn(spec Spec, c Check) (Check, bool) {
+	if c.spec == spec || c.spec == SpecPDF {
+		return c, true
+	}
+	out, ok := catalogBySpecName[spec][c.name]
+	return out, ok
+}
+
 // ChecksForClause returns every registered check under the given clause
-// (e.g. "6.3.4"), in catalog order.
+// (e.g. "6.3.4") in any spec, in catalog order.
 func ChecksForClause(clause string) []Check {
 	var out []Check
 	for _, c := range allChecksCatalog {
@@ -331,21 +433,47 @@ func ChecksForClause(clause string) []Check {
 	return out
 }
 
+// newCheck registers a PDF/A-1 check, or an objmodel check when clause is
+// ObjectModelClause.
 func newCheck(name, description, clause string, subclause int) Check {
+	spec := SpecPDFA1
+	if clause == ObjectModelClause {
+		spec = SpecPDF
+	}
+	return newSpecCheck(spec, name, description, clause, subclause)
+}
+
+// newSpecCheck registers a check numbered by spec's clauses. Names must be
+// unique within a spec, and (clause, subclause) pairs within a spec.
+func newSpecCheck(spec Spec, name, description, clause string, subclause int) Check {
 	checkIDCounter++
 	c := Check{
 		id:          checkIDCounter,
 		name:        name,
 		description: description,
+		spec:        spec,
 		clause:      clause,
 		subclause:   subclause,
 	}
-	key := clause + "/" + strconv.Itoa(subclause)
-	if _, exists := catalogByPair[key]; exists {
+	pair := clause + "/" + strconv.Itoa(subclause)
+	key := string(spec) + "|" + pair
+	if _, exists := catalogBySpec[key]; exists {
 		panic(fmt.Sprintf("checks_catalog: duplicate registration for %s", key))
 	}
-	catalogByPair[key] = c
-	catalogByName[name] = c
+	if _, exists := catalogBySpecName[spec][name]; exists {
+		panic(fmt.Sprintf("checks_catalog: duplicate name %s in %s", name, spec))
+	}
+	catalogBySpec[key] = c
+	if _, exists := catalogByPair[pair]; !exists {
+		catalogByPair[pair] = c
+	}
+	if _, exists := catalogByName[name]; !exists {
+		catalogByName[name] = c
+	}
+	if catalogBySpecName[spec] == nil {
+		catalogBySpecName[spec] = make(map[string]Check)
+	}
+	catalogBySpecName[spec][name] = c
 	allChecksCatalog = append(allChecksCatalog, c)
 	return c
 }
@@ -353,7 +481,8 @@ func newCheck(name, description, clause string, subclause int) Check {
 // CheckByName looks up a registered check by its CamelCase identifier (e.g.
 // "ObjectFraming"), used to map a pdf.Reader's parse-time StructError
 // diagnostics -- which only carry that name, to avoid this package's check
-// registry being a dependency of the pdf package -- back to a
+// registry being a dependency of the pdf package -- back to a check. A name
+// shared by several parts resolves to its PDF/A-1 check; see CheckIn.
 func CheckByName(name string) (c Check, ok bool) {
 	c, ok = catalogByName[name]
 	return c, ok
@@ -361,7 +490,9 @@ func CheckByName(name string) (c Check, ok bool) {
 
 func init() {
 	catalogByPair = make(map[string]Check)
+	catalogBySpec = make(map[string]Check)
 	catalogByName = make(map[string]Check)
+	catalogBySpecName = make(map[Spec]map[string]Check)
 
 	Checks = checksRegistry{
 		Structure: structureChecks{
@@ -473,6 +604,10 @@ func init() {
 				"InlineImageLZWFilter",
 				"Inline images must not use the LZW filter",
 				"6.1.10", 2),
+			StreamJPXFilter: newCheck(
+				"StreamJPXFilter",
+				"Stream objects must not use the JPXDecode filter, which PDF 1.4 does not define",
+				"6.1.10", 3),
 			EmbeddedFileSpec: newCheck(
 				"EmbeddedFileSpec",
 				"Dictionaries must not contain an EF (embedded file specification) key",
@@ -788,6 +923,10 @@ func init() {
 				"TaggedMarkInfo",
 				"The document catalog must include a MarkInfo dictionary with Marked set to true (Level A)",
 				"6.8.2.2", 1),
+			AlternateDescription: newCheck(
+				"AlternateDescription",
+				"Figure and Formula structure elements must carry an Alt or ActualText entry describing them (Level A)",
+				"6.8.2.2", 2),
 			StructTreeRoot: newCheck(
 				"StructTreeRoot",
 				"The document catalog must contain a StructTreeRoot entry describing the structure hierarchy (Level A)",
@@ -1023,6 +1162,18 @@ func init() {
 				"ConstraintViolated",
 				"A key's value violates an ISO 32000 object-model consistency constraint (an Arlington SpecialCase rule, e.g. coupled array lengths)",
 				ObjectModelClause, 6),
+			KeyIntroducedAfterPDF17: newCheck(
+				"KeyIntroducedAfterPDF17",
+				"A dictionary contains a key the ISO 32000 object model introduced after PDF 1.7",
+				ObjectModelClause, 7),
 		},
+
+		PDFA2: newPDFA2Checks(),
 	}
+	Checks.PDFA3 = newPDFA3Checks(Checks.PDFA2)
+	Checks.PDFA4 = newPDFA4Checks(Checks.PDFA2)
+	Checks.PDFUA1 = newPDFUA1Checks()
+	Checks.PDFX1A = newPDFX1aChecks()
+	Checks.PDFX4 = newPDFX4Checks(Checks.PDFX1A)
+	Checks.FacturX = newFacturXChecks()
 }
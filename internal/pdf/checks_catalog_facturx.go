@@ -0,0 +1,90 @@
+package pdf
+
+// Factur-X / ZUGFeRD check catalog: the hybrid e-invoice rules layered on a
+// PDF/A-3 file. A Factur-X invoice is a PDF/A-3 document carrying its
+// structured invoice, a UN/CEFACT Cross Industry Invoice XML file, as an
+// associated file, and describing that file in fx: XMP properties declared
+// through a PDF/A extension schema. ZUGFeRD 2.x is the same format under
+// its German name, with zugferd-invoice.xml (2.0) or xrechnung.xml (2.1,
+// XRECHNUNG profile) as alternative file names. The PDF/A-3 rules keep
+// their Checks.PDFA3 numbering; only the invoice rules are numbered here,
+// after the technical chapter of the Factur-X specification.
+
+type facturXInvoiceChecks struct {
+	// 7.2 Embedded invoice
+	InvoiceMissing       Check
+	InvoiceMultiple      Check
+	InvoiceNotAssociated Check
+	InvoiceRelationship  Check
+	InvoiceMIMEType      Check
+	InvoiceXML           Check
+}
+
+type facturXMetadataChecks struct {
+	// 7.3 XMP metadata
+	InvoiceProperties       Check
+	InvoiceFileName         Check
+	InvoiceConformanceLevel Check
+	InvoiceExtensionSchema  Check
+}
+
+// facturXChecks groups the Factur-X / ZUGFeRD checks.
+type facturXChecks struct {
+	Invoice  facturXInvoiceChecks
+	Metadata facturXMetadataChecks
+}
+
+// newFacturXChecks registers the Factur-X catalog.
+func newFacturXChecks() facturXChecks {
+	fx := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecFacturX, name, description, clause, subclause)
+	}
+
+	return facturXChecks{
+		Invoice: facturXInvoiceChecks{
+			InvoiceMissing: fx(
+				"InvoiceMissing",
+				"The document shall embed its invoice as factur-x.xml (or, for ZUGFeRD, zugferd-invoice.xml or xrechnung.xml)",
+				"7.2", 1),
+			InvoiceMultiple: fx(
+				"InvoiceMultiple",
+				"The document shall embed only one invoice XML file",
+				"7.2", 2),
+			InvoiceNotAssociated: fx(
+				"InvoiceNotAssociated",
+				"The invoice file specification shall be listed in the catalog's AF array and in the EmbeddedFiles name tree",
+				"7.2", 3),
+			InvoiceRelationship: fx(
+				"InvoiceRelationship",
+				"The invoice file specification's AFRelationship shall be Alternative or Data",
+				"7.2", 4),
+			InvoiceMIMEType: fx(
+				"InvoiceMIMEType",
+				"The invoice embedded file stream's Subtype shall be text/xml",
+				"7.2", 5),
+			InvoiceXML: fx(
+				"InvoiceXML",
+				"The invoice shall be a well-formed UN/CEFACT Cross Industry Invoice XML document",
+				"7.2", 6),
+		},
+
+		Metadata: facturXMetadataChecks{
+			InvoiceProperties: fx(
+				"InvoiceProperties",
+				"The XMP metadata shall carry the fx:DocumentType INVOICE, fx:DocumentFileName, fx:Version and fx:ConformanceLevel properties",
+				"7.3", 1),
+			InvoiceFileName: fx(
+				"InvoiceFileName",
+				"fx:DocumentFileName shall name the embedded invoice file",
+				"7.3", 2),
+			InvoiceConformanceLevel: fx(
+				"InvoiceConformanceLevel",
+				"fx:ConformanceLevel shall be a Factur-X profile name matching the invoice's guideline identifier",
+				"7.3", 3),
+			InvoiceExtensionSchema: fx(
+				"InvoiceExtensionSchema",
+				"The XMP metadata shall declare the fx: namespace in a PDF/A extension schema with prefix fx and its four properties",
+				"7.3", 4),
+		},
+	}
+}
@@ -0,0 +1,931 @@
+package pdf
+
+// PDF/A-2 (ISO 19005-2:2011) check catalog. A rule carried over from PDF/A-1
+// keeps the PDF/A-1 check's name under its PDF/A-2 clause number, which is
+// how CheckIn maps the shared verifier's findings onto this catalog. PDF/A-1
+// rules PDF/A-2 dropped (cross-reference streams, optional content,
+// transparency, array/dictionary size limits, post-1.4 viewer preferences,
+// embedded files) have no counterpart here.
+
+type pdfa2StructureChecks struct {
+	// 6.1.2 File header
+	FileHeaderSignature     Check
+	FileHeaderComment       Check
+	FileHeaderCommentLength Check
+	FileHeaderCommentBytes  Check
+	// 6.1.3 File trailer
+	TrailerID      Check
+	TrailerEncrypt Check
+	TrailerEOF     Check
+	// 6.1.4 Cross-reference table
+	XRefKeyword                Check
+	XRefSubsectionHeader       Check
+	XRefSubsectionHeaderFormat Check
+	// 6.1.6 String objects
+	HexStringInvalidChar Check
+	HexStringOddLength   Check
+	// 6.1.7.1 Stream objects
+	StreamFileSpec          Check
+	StreamFileFilter        Check
+	StreamFileDecodeParams  Check
+	StreamKeywordEOL        Check
+	EndstreamEOL            Check
+	StreamLengthIncludesEOL Check
+	StreamLengthMismatch    Check
+	// 6.1.7.2 Filters
+	StreamLZWFilter   Check
+	StreamCryptFilter Check
+	// 6.1.8 Name objects
+	NameNotUTF8 Check
+	// 6.1.9 Indirect objects
+	GraphResolutionFailure Check
+	ObjectFraming          Check
+	// 6.1.10 Inline image dictionaries
+	InlineImageLZWFilter Check
+	// 6.1.12 Permissions
+	PermissionsKeys Check
+	// 6.1.13 Implementation limits
+	IntegerOutOfRange       Check
+	RealOutOfRange          Check
+	StringTooLong           Check
+	NameTooLong             Check
+	IndirectObjectsExceeded Check
+	GraphicsStateNesting    Check
+	DeviceNColorants        Check
+	CMapCIDOutOfRange       Check
+	PageBoundaryOutOfRange  Check
+}
+
+type pdfa2ColourChecks struct {
+	// 6.2.2 Content streams
+	UndefinedOperator Check
+	// 6.2.3 Output intent
+	OutputIntentNotArray          Check
+	OutputIntentNotDict           Check
+	OutputIntentInvalidS          Check
+	OutputIntentWrongS            Check
+	OutputIntentMissingIdentifier Check
+	OutputIntentMultipleProfiles  Check
+	OutputIntentUnresolvedProfile Check
+	OutputIntentInvalidProfile    Check
+	OutputIntentMissingN          Check
+	OutputIntentInvalidN          Check
+	OutputIntentICCVersion        Check
+	// 6.2.4.2 ICCBased colour spaces
+	ICCBasedComponentsMismatch Check
+	// 6.2.4.3 Uncalibrated colour spaces
+	DeviceColourSpaceUsage    Check
+	DeviceColourContentStream Check
+	// 6.2.4.4 Separation and DeviceN colour spaces
+	SeparationAlternateColour Check
+	// 6.2.5 Extended graphics state
+	TransferFunction         Check
+	DefaultTransferFunction  Check
+	HalftonePhase            Check
+	HalftoneType             Check
+	HalftoneName             Check
+	HalftoneTransferFunction Check
+	// 6.2.6 Rendering intents
+	RenderingIntent          Check
+	ExtGStateRenderingIntent Check
+	ImageRenderingIntent     Check
+}
+
+type pdfa2ImageChecks struct {
+	// 6.2.8 Image dictionaries
+	ImageAlternates           Check
+	ImageOPI                  Check
+	ImageInterpolate          Check
+	ImageBitsPerComponent     Check
+	ImageMaskBitsPerComponent Check
+	// 6.2.8.3 JPEG2000
+	JPXChannels          Check
+	JPXColourSpecBoxes   Check
+	JPXBitDepth          Check
+	JPXCIEJabColourSpace Check
+	// 6.2.9 XObjects
+	FormOPI           Check
+	FormSubtype2PS    Check
+	FormPSEntry       Check
+	ReferenceXObject  Check
+	PostScriptXObject Check
+}
+
+type pdfa2TransparencyChecks struct {
+	// 6.2.10 Transparency
+	BlendMode                      Check
+	TransparencyGroupNoColourSpace Check
+}
+
+type pdfa2FontChecks struct {
+	// 6.2.11.2 General
+	FontType            Check
+	InvalidSubtype      Check
+	FontBaseFont        Check
+	SimpleFontFirstChar Check
+	SimpleFontLastChar  Check
+	SimpleFontWidths    Check
+	FontFileSubtype     Check
+	InvalidProgram      Check
+	// 6.2.11.3 Composite fonts
+	CIDSystemInfoMismatch Check
+	CIDToGIDMapMissing    Check
+	CMapNotEmbedded       Check
+	CMapWModeInconsistent Check
+	// 6.2.11.4 Embedding
+	SimpleNotEmbedded   Check
+	CIDNotEmbedded      Check
+	SubsetGlyphCoverage Check
+	Type1SubsetCharSet  Check
+	CIDSubsetCIDSet     Check
+	// 6.2.11.5 Font metrics
+	AdvanceWidthMismatch Check
+	// 6.2.11.6 TrueType encodings
+	TrueTypeEncoding         Check
+	SymbolicTrueTypeEncoding Check
+	SymbolicTrueTypeCmap     Check
+	// 6.2.11.7 Unicode character maps (levels A and U)
+	ToUnicodeMissing    Check
+	UnmappableCharCode  Check
+	InvalidUnicodeValue Check
+}
+
+type pdfa2AnnotationChecks struct {
+	// 6.3.1 Annotation types
+	DisallowedSubtype Check
+	// 6.3.2 Annotation flags
+	PrintFlagNotSet     Check
+	HiddenFlagSet       Check
+	InvisibleFlagSet    Check
+	NoViewFlagSet       Check
+	ToggleNoViewFlagSet Check
+	// 6.3.3 Annotation appearances
+	MissingAppearance      Check
+	AppearanceMissingN     Check
+	AppearanceExtraEntries Check
+	AppearanceNNotStream   Check
+}
+
+type pdfa2FormChecks struct {
+	// 6.4.1 Interactive form fields
+	FieldAction             Check
+	FieldAdditionalActions  Check
+	NeedAppearances         Check
+	WidgetMissingAppearance Check
+	// 6.4.2 XFA
+	XFA            Check
+	NeedsRendering Check
+}
+
+type pdfa2ActionChecks struct {
+	// 6.5.1 Action types
+	ForbiddenActionType   Check
+	DisallowedNamedAction Check
+	// 6.5.2 Trigger events
+	AdditionalActions Check
+}
+
+type pdfa2MetadataChecks struct {
+	// 6.6.2.1 Metadata streams
+	MetadataMissing          Check
+	MetadataFiltered         Check
+	XPacketBytesAttribute    Check
+	XPacketEncodingAttribute Check
+	ObjectXMPNoXPacket       Check
+	XMPStreamUnreadable      Check
+	XMPNotWellFormed         Check
+	// 6.6.2.3.1 Properties
+	MetadataPropertyType       Check
+	MetadataUndeclaredProperty Check
+	XMPNoCorrespondingType     Check
+	// 6.6.2.3.3 Extension schemas
+	ExtSchemaNamespace         Check
+	ExtSchemaWrongPrefixURI    Check
+	ExtSchemasNotBag           Check
+	ExtPropertyMultipleName    Check
+	ExtPropertyMissingField    Check
+	ExtPropertyComplexAsSimple Check
+	ExtTypeInvalid             Check
+	ExtFieldInvalid            Check
+	ExtPropertyUndocumented    Check
+	ExtPropertyUndefinedType   Check
+	// 6.6.3 Document information dictionary
+	InfoXMPSync         Check
+	InfoDictUnreadable  Check
+	InfoDictXMPMismatch Check
+	// 6.6.4 Version and conformance identification
+	PDFAIdentifierMissing           Check
+	PDFAIdentifierNamespace         Check
+	PDFAConformanceLevel            Check
+	PDFAPartNumber                  Check
+	PDFAIdentifierUndefinedProperty Check
+}
+
+type pdfa2EmbeddedFileChecks struct {
+	// 6.8 Embedded files
+	EmbeddedFileSpecKeys Check
+	EmbeddedFileNotPDFA  Check
+}
+
+type pdfa2OptionalContentChecks struct {
+	// 6.9 Optional content
+	OCConfigName          Check
+	OCConfigNameDuplicate Check
+	OCOrderIncomplete     Check
+	OCConfigAS            Check
+}
+
+type pdfa2DocumentChecks struct {
+	// 6.10 Alternate presentations and transitions
+	AlternatePresentations Check
+	PresSteps              Check
+	// 6.11 Document requirements
+	Requirements Check
+}
+
+// pdfa2Checks groups the ISO 19005-2 checks the same way checksRegistry
+// groups the PDF/A-1 ones, plus the families PDF/A-2 introduced.
+type pdfa2Checks struct {
+	Structure       pdfa2StructureChecks
+	Colour          pdfa2ColourChecks
+	Image           pdfa2ImageChecks
+	Transparency    pdfa2TransparencyChecks
+	Font            pdfa2FontChecks
+	Annotation      pdfa2AnnotationChecks
+	Form            pdfa2FormChecks
+	Action          pdfa2ActionChecks
+	Metadata        pdfa2MetadataChecks
+	EmbeddedFile    pdfa2EmbeddedFileChecks
+	OptionalContent pdfa2OptionalContentChecks
+	Document        pdfa2DocumentChecks
+}
+
+// newPDFA2Checks registers the PDF/A-2 catalog. Called from the catalog's
+// init, after the PDF/A-1 and objmodel groups.
+func newPDFA2Checks() pdfa2Checks {
+	a2 := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecPDFA2, name, description, clause, subclause)
+	}
+
+	return pdfa2Checks{
+		Structure: pdfa2StructureChecks{
+			FileHeaderSignature: a2(
+				"FileHeaderSignature",
+				"The file header shall begin at byte zero and consist of %PDF-1.n, with n from 0 to 7",
+				"6.1.2", 1),
+			FileHeaderComment: a2(
+				"FileHeaderComment",
+				"The file header line shall be immediately followed by a comment line",
+				"6.1.2", 2),
+			FileHeaderCommentLength: a2(
+				"FileHeaderCommentLength",
+				"The header comment shall contain at least four bytes after the %",
+				"6.1.2", 3),
+			FileHeaderCommentBytes: a2(
+				"FileHeaderCommentBytes",
+				"The first four bytes of the header comment shall each be greater than 127",
+				"6.1.2", 4),
+			TrailerID: a2(
+				"TrailerID",
+				"The file trailer shall contain the ID keyword; a linearized file's trailers shall agree on it",
+				"6.1.3", 1),
+			TrailerEncrypt: a2(
+				"TrailerEncrypt",
+				"The Encrypt key shall not be present in the trailer dictionary",
+				"6.1.3", 2),
+			TrailerEOF: a2(
+				"TrailerEOF",
+				"No data shall follow the last end-of-file marker except a single optional end-of-line marker",
+				"6.1.3", 3),
+			XRefKeyword: a2(
+				"XRefKeyword",
+				"A cross-reference table's xref keyword shall be followed by a single end-of-line marker",
+				"6.1.4", 1),
+			XRefSubsectionHeader: a2(
+				"XRefSubsectionHeader",
+				"The xref keyword and the first subsection header shall be separated by a single end-of-line marker",
+				"6.1.4", 2),
+			XRefSubsectionHeaderFormat: a2(
+				"XRefSubsectionHeaderFormat",
+				"Cross-reference subsection headers shall separate start and count by a single space",
+				"6.1.4", 3),
+			HexStringInvalidChar: a2(
+				"HexStringInvalidChar",
+				"Hexadecimal strings shall contain only the characters 0-9, A-F and a-f",
+				"6.1.6", 1),
+			HexStringOddLength: a2(
+				"HexStringOddLength",
+				"Hexadecimal strings shall contain an even number of non-white-space characters",
+				"6.1.6", 2),
+			StreamFileSpec: a2(
+				"StreamFileSpec",
+				"A stream dictionary shall not contain the F key",
+				"6.1.7.1", 1),
+			StreamFileFilter: a2(
+				"StreamFileFilter",
+				"A stream dictionary shall not contain the FFilter key",
+				"6.1.7.1", 2),
+			StreamFileDecodeParams: a2(
+				"StreamFileDecodeParams",
+				"A stream dictionary shall not contain the FDecodeParms key",
+				"6.1.7.1", 3),
+			StreamKeywordEOL: a2(
+				"StreamKeywordEOL",
+				"The stream keyword shall be followed by CR LF or LF",
+				"6.1.7.1", 4),
+			EndstreamEOL: a2(
+				"EndstreamEOL",
+				"The endstream keyword shall be preceded by an end-of-line marker",
+				"6.1.7.1", 5),
+			StreamLengthIncludesEOL: a2(
+				"StreamLengthIncludesEOL",
+				"The stream Length shall not count the end-of-line marker before endstream",
+				"6.1.7.1", 6),
+			StreamLengthMismatch: a2(
+				"StreamLengthMismatch",
+				"The stream Length shall match the number of bytes between stream and endstream",
+				"6.1.7.1", 7),
+			StreamLZWFilter: a2(
+				"StreamLZWFilter",
+				"Streams shall not use the LZWDecode filter",
+				"6.1.7.2", 1),
+			StreamCryptFilter: a2(
+				"StreamCryptFilter",
+				"Streams shall not use the Crypt filter unless its decode parameters name the Identity crypt filter",
+				"6.1.7.2", 2),
+			NameNotUTF8: a2(
+				"NameNotUTF8",
+				"The bytes of a name object, after expanding #XX escapes, shall be valid UTF-8",
+				"6.1.8", 1),
+			GraphResolutionFailure: a2(
+				"GraphResolutionFailure",
+				"The document's object graph could not be resolved",
+				"6.1.9", 0),
+			ObjectFraming: a2(
+				"ObjectFraming",
+				"Indirect object framing (obj/endobj keywords and separators) shall be well-formed",
+				"6.1.9", 1),
+			InlineImageLZWFilter: a2(
+				"InlineImageLZWFilter",
+				"Inline images shall not use the LZWDecode filter",
+				"6.1.10", 1),
+			PermissionsKeys: a2(
+				"PermissionsKeys",
+				"The Perms dictionary shall contain no keys other than UR3 and DocMDP",
+				"6.1.12", 1),
+			IntegerOutOfRange: a2(
+				"IntegerOutOfRange",
+				"Integers shall be in the range -2^31 to 2^31-1",
+				"6.1.13", 1),
+			RealOutOfRange: a2(
+				"RealOutOfRange",
+				"The magnitude of a real number shall not exceed 3.403 x 10^38",
+				"6.1.13", 2),
+			StringTooLong: a2(
+				"StringTooLong",
+				"Strings shall not exceed 32767 bytes",
+				"6.1.13", 3),
+			NameTooLong: a2(
+				"NameTooLong",
+				"Names shall not exceed 127 bytes",
+				"6.1.13", 4),
+			IndirectObjectsExceeded: a2(
+				"IndirectObjectsExceeded",
+				"A file shall contain no more than 8,388,607 indirect objects",
+				"6.1.13", 5),
+			GraphicsStateNesting: a2(
+				"GraphicsStateNesting",
+				"q/Q graphics state nesting shall not exceed 28 levels",
+				"6.1.13", 6),
+			DeviceNColorants: a2(
+				"DeviceNColorants",
+				"A DeviceN colour space shall have no more than 32 colorants",
+				"6.1.13", 7),
+			CMapCIDOutOfRange: a2(
+				"CMapCIDOutOfRange",
+				"CIDs shall not exceed 65535",
+				"6.1.13", 8),
+			PageBoundaryOutOfRange: a2(
+				"PageBoundaryOutOfRange",
+				"Page boundaries shall measure between 3 and 14400 default user space units in each direction",
+				"6.1.13", 9),
+		},
+
+		Colour: pdfa2ColourChecks{
+			UndefinedOperator: a2(
+				"UndefinedOperator",
+				"Content streams shall not contain operators undefined in ISO 32000-1, even between BX and EX",
+				"6.2.2", 1),
+			OutputIntentNotArray: a2(
+				"OutputIntentNotArray",
+				"The OutputIntents value shall be an array",
+				"6.2.3", 1),
+			OutputIntentNotDict: a2(
+				"OutputIntentNotDict",
+				"Each OutputIntents entry shall be a dictionary",
+				"6.2.3", 2),
+			OutputIntentInvalidS: a2(
+				"OutputIntentInvalidS",
+				"An output intent's S entry shall be a name",
+				"6.2.3", 3),
+			OutputIntentWrongS: a2(
+				"OutputIntentWrongS",
+				"A PDF/A output intent's S entry shall be GTS_PDFA1",
+				"6.2.3", 4),
+			OutputIntentMissingIdentifier: a2(
+				"OutputIntentMissingIdentifier",
+				"An output intent shall contain OutputConditionIdentifier",
+				"6.2.3", 5),
+			OutputIntentMultipleProfiles: a2(
+				"OutputIntentMultipleProfiles",
+				"All output intents with a DestOutputProfile shall reference the same ICC profile stream",
+				"6.2.3", 6),
+			OutputIntentUnresolvedProfile: a2(
+				"OutputIntentUnresolvedProfile",
+				"An output intent's DestOutputProfile shall be present and resolvable",
+				"6.2.3", 7),
+			OutputIntentInvalidProfile: a2(
+				"OutputIntentInvalidProfile",
+				"An output intent's DestOutputProfile shall be an ICC profile stream",
+				"6.2.3", 8),
+			OutputIntentMissingN: a2(
+				"OutputIntentMissingN",
+				"The DestOutputProfile stream shall contain N",
+				"6.2.3", 9),
+			OutputIntentInvalidN: a2(
+				"OutputIntentInvalidN",
+				"The DestOutputProfile N shall be 1, 3 or 4",
+				"6.2.3", 10),
+			OutputIntentICCVersion: a2(
+				"OutputIntentICCVersion",
+				"The DestOutputProfile shall be a valid ICC profile of version 4.x or earlier with an output or display device class",
+				"6.2.3", 11),
+			ICCBasedComponentsMismatch: a2(
+				"ICCBasedComponentsMismatch",
+				"An ICC profile stream's N shall match the profile's colour space",
+				"6.2.4.2", 1),
+			DeviceColourSpaceUsage: a2(
+				"DeviceColourSpaceUsage",
+				"Device colour spaces shall only be used when covered by the output intent or a Default colour space",
+				"6.2.4.3", 1),
+			DeviceColourContentStream: a2(
+				"DeviceColourContentStream",
+				"Content stream device colour operators shall only be used when covered by the output intent or a Default colour space",
+				"6.2.4.3", 2),
+			SeparationAlternateColour: a2(
+				"SeparationAlternateColour",
+				"Separation and DeviceN alternate colour spaces shall obey the uncalibrated colour space rules",
+				"6.2.4.4", 1),
+			TransferFunction: a2(
+				"TransferFunction",
+				"An ExtGState dictionary shall not contain the TR key",
+				"6.2.5", 1),
+			DefaultTransferFunction: a2(
+				"DefaultTransferFunction",
+				"An ExtGState dictionary shall not contain a TR2 key other than Default",
+				"6.2.5", 2),
+			HalftonePhase: a2(
+				"HalftonePhase",
+				"An ExtGState dictionary shall not contain the HTP key",
+				"6.2.5", 3),
+			HalftoneType: a2(
+				"HalftoneType",
+				"Halftone dictionaries shall have a HalftoneType of 1 or 5",
+				"6.2.5", 4),
+			HalftoneName: a2(
+				"HalftoneName",
+				"Halftone dictionaries shall not contain a HalftoneName key",
+				"6.2.5", 5),
+			HalftoneTransferFunction: a2(
+				"HalftoneTransferFunction",
+				"Halftone dictionaries shall not contain a TransferFunction key except for non-primary colorants",
+				"6.2.5", 6),
+			RenderingIntent: a2(
+				"RenderingIntent",
+				"The ri operator shall only use the four standard rendering intents",
+				"6.2.6", 1),
+			ExtGStateRenderingIntent: a2(
+				"ExtGStateRenderingIntent",
+				"An ExtGState RI entry shall be one of the four standard rendering intents",
+				"6.2.6", 2),
+			ImageRenderingIntent: a2(
+				"ImageRenderingIntent",
+				"An image's Intent entry shall be one of the four standard rendering intents",
+				"6.2.6", 3),
+		},
+
+		Image: pdfa2ImageChecks{
+			ImageAlternates: a2(
+				"ImageAlternates",
+				"An image dictionary shall not contain the Alternates key",
+				"6.2.8", 1),
+			ImageOPI: a2(
+				"ImageOPI",
+				"An image dictionary shall not contain the OPI key",
+				"6.2.8", 2),
+			ImageInterpolate: a2(
+				"ImageInterpolate",
+				"An image's Interpolate entry shall not be true",
+				"6.2.8", 3),
+			ImageBitsPerComponent: a2(
+				"ImageBitsPerComponent",
+				"An image's BitsPerComponent shall be 1, 2, 4, 8 or 16",
+				"6.2.8", 4),
+			ImageMaskBitsPerComponent: a2(
+				"ImageMaskBitsPerComponent",
+				"An image mask's BitsPerComponent shall be 1",
+				"6.2.8", 5),
+			JPXChannels: a2(
+				"JPXChannels",
+				"JPEG2000 image data shall have 1, 3 or 4 colour channels",
+				"6.2.8.3", 1),
+			JPXColourSpecBoxes: a2(
+				"JPXColourSpecBoxes",
+				"If a JPEG2000 image has several colour specification boxes, exactly one shall have APPROX 1 and be used",
+				"6.2.8.3", 2),
+			JPXBitDepth: a2(
+				"JPXBitDepth",
+				"JPEG2000 image bit depth shall be 1 to 38, the same for every channel",
+				"6.2.8.3", 3),
+			JPXCIEJabColourSpace: a2(
+				"JPXCIEJabColourSpace",
+				"JPEG2000 images shall not use the enumerated CIEJab colour space (19)",
+				"6.2.8.3", 4),
+			FormOPI: a2(
+				"FormOPI",
+				"A form XObject shall not contain the OPI key",
+				"6.2.9", 1),
+			FormSubtype2PS: a2(
+				"FormSubtype2PS",
+				"A form XObject shall not contain Subtype2 with value PS",
+				"6.2.9", 2),
+			FormPSEntry: a2(
+				"FormPSEntry",
+				"A form XObject shall not contain the PS key",
+				"6.2.9", 3),
+			ReferenceXObject: a2(
+				"ReferenceXObject",
+				"A form XObject shall not be a reference XObject (Ref key)",
+				"6.2.9", 4),
+			PostScriptXObject: a2(
+				"PostScriptXObject",
+				"A file shall not contain PostScript XObjects",
+				"6.2.9", 5),
+		},
+
+		Transparency: pdfa2TransparencyChecks{
+			BlendMode: a2(
+				"BlendMode",
+				"Only the blend modes defined in ISO 32000-1 shall be used",
+				"6.2.10", 1),
+			TransparencyGroupNoColourSpace: a2(
+				"TransparencyGroupNoColourSpace",
+				"Without a PDF/A output intent, a page that uses transparency shall have a Group with a CS entry",
+				"6.2.10", 2),
+		},
+
+		Font: pdfa2FontChecks{
+			FontType: a2(
+				"FontType",
+				"A font dictionary shall have Type Font",
+				"6.2.11.2", 1),
+			InvalidSubtype: a2(
+				"InvalidSubtype",
+				"A font dictionary shall have a valid Subtype",
+				"6.2.11.2", 2),
+			FontBaseFont: a2(
+				"FontBaseFont",
+				"Non-Type3 font dictionaries shall contain BaseFont",
+				"6.2.11.2", 3),
+			SimpleFontFirstChar: a2(
+				"SimpleFontFirstChar",
+				"Non-standard simple fonts shall contain FirstChar",
+				"6.2.11.2", 4),
+			SimpleFontLastChar: a2(
+				"SimpleFontLastChar",
+				"Non-standard simple fonts shall contain LastChar",
+				"6.2.11.2", 5),
+			SimpleFontWidths: a2(
+				"SimpleFontWidths",
+				"Non-standard simple fonts shall contain Widths of length LastChar-FirstChar+1",
+				"6.2.11.2", 6),
+			FontFileSubtype: a2(
+				"FontFileSubtype",
+				"FontFile3 streams shall have a Subtype matching the font type",
+				"6.2.11.2", 7),
+			InvalidProgram: a2(
+				"InvalidProgram",
+				"Embedded font programs shall be well-formed",
+				"6.2.11.2", 8),
+			CIDSystemInfoMismatch: a2(
+				"CIDSystemInfoMismatch",
+				"A CIDFont's CIDSystemInfo shall be compatible with the CMap's",
+				"6.2.11.3.1", 1),
+			CIDToGIDMapMissing: a2(
+				"CIDToGIDMapMissing",
+				"A CIDFontType2 font shall contain a CIDToGIDMap",
+				"6.2.11.3.2", 1),
+			CMapNotEmbedded: a2(
+				"CMapNotEmbedded",
+				"Non-standard CMaps shall be embedded",
+				"6.2.11.3.3", 1),
+			CMapWModeInconsistent: a2(
+				"CMapWModeInconsistent",
+				"An embedded CMap's WMode shall match its dictionary's WMode",
+				"6.2.11.3.3", 2),
+			SimpleNotEmbedded: a2(
+				"SimpleNotEmbedded",
+				"Font programs for simple fonts used for rendering shall be embedded",
+				"6.2.11.4", 1),
+			CIDNotEmbedded: a2(
+				"CIDNotEmbedded",
+				"Font programs for CIDFonts used for rendering shall be embedded",
+				"6.2.11.4", 2),
+			SubsetGlyphCoverage: a2(
+				"SubsetGlyphCoverage",
+				"Embedded font programs shall define every glyph referenced for rendering",
+				"6.2.11.4.1", 1),
+			Type1SubsetCharSet: a2(
+				"Type1SubsetCharSet",
+				"A Type 1 font subset's CharSet, if present, shall list every glyph in the program",
+				"6.2.11.4.2", 1),
+			CIDSubsetCIDSet: a2(
+				"CIDSubsetCIDSet",
+				"A CIDFont subset's CIDSet, if present, shall identify every CID in the program",
+				"6.2.11.4.2", 2),
+			AdvanceWidthMismatch: a2(
+				"AdvanceWidthMismatch",
+				"Font dictionary widths shall match the embedded program's advance widths",
+				"6.2.11.5", 1),
+			TrueTypeEncoding: a2(
+				"TrueTypeEncoding",
+				"Non-symbolic TrueType fonts shall use MacRomanEncoding or WinAnsiEncoding",
+				"6.2.11.6", 1),
+			SymbolicTrueTypeEncoding: a2(
+				"SymbolicTrueTypeEncoding",
+				"Symbolic TrueType fonts shall not specify an Encoding",
+				"6.2.11.6", 2),
+			SymbolicTrueTypeCmap: a2(
+				"SymbolicTrueTypeCmap",
+				"Symbolic TrueType font programs shall contain exactly one cmap subtable or a (3,0) subtable",
+				"6.2.11.6", 3),
+			ToUnicodeMissing: a2(
+				"ToUnicodeMissing",
+				"Every font shall map its character codes to Unicode (levels A and U)",
+				"6.2.11.7", 1),
+			UnmappableCharCode: a2(
+				"UnmappableCharCode",
+				"Every character code used for rendering shall map to a Unicode value (levels A and U)",
+				"6.2.11.7", 2),
+			InvalidUnicodeValue: a2(
+				"InvalidUnicodeValue",
+				"Unicode values in a ToUnicode CMap shall be greater than zero and not U+FEFF or U+FFFE (levels A and U)",
+				"6.2.11.7", 3),
+		},
+
+		Annotation: pdfa2AnnotationChecks{
+			DisallowedSubtype: a2(
+				"DisallowedSubtype",
+				"Annotation types not defined in ISO 32000-1, and the 3D, Sound, Screen and Movie types, shall not be used",
+				"6.3.1", 1),
+			PrintFlagNotSet: a2(
+				"PrintFlagNotSet",
+				"Except for Popup annotations, an annotation's F key shall be present with the Print flag set",
+				"6.3.2", 1),
+			HiddenFlagSet: a2(
+				"HiddenFlagSet",
+				"An annotation's Hidden flag shall be clear",
+				"6.3.2", 2),
+			InvisibleFlagSet: a2(
+				"InvisibleFlagSet",
+				"An annotation's Invisible flag shall be clear",
+				"6.3.2", 3),
+			NoViewFlagSet: a2(
+				"NoViewFlagSet",
+				"An annotation's NoView flag shall be clear",
+				"6.3.2", 4),
+			ToggleNoViewFlagSet: a2(
+				"ToggleNoViewFlagSet",
+				"An annotation's ToggleNoView flag shall be clear",
+				"6.3.2", 5),
+			MissingAppearance: a2(
+				"MissingAppearance",
+				"Annotations other than Popup, Link and zero-size ones shall have an appearance dictionary",
+				"6.3.3", 1),
+			AppearanceMissingN: a2(
+				"AppearanceMissingN",
+				"An annotation's appearance dictionary shall contain N",
+				"6.3.3", 2),
+			AppearanceExtraEntries: a2(
+				"AppearanceExtraEntries",
+				"An annotation's appearance dictionary shall contain only N",
+				"6.3.3", 3),
+			AppearanceNNotStream: a2(
+				"AppearanceNNotStream",
+				"An appearance N entry shall be a stream, or a subdictionary for Btn widgets",
+				"6.3.3", 4),
+		},
+
+		Form: pdfa2FormChecks{
+			FieldAction: a2(
+				"FieldAction",
+				"Widget annotations shall not contain an A (action) entry",
+				"6.4.1", 1),
+			FieldAdditionalActions: a2(
+				"FieldAdditionalActions",
+				"Form fields and widget annotations shall not contain an AA entry",
+				"6.4.1", 2),
+			NeedAppearances: a2(
+				"NeedAppearances",
+				"The AcroForm NeedAppearances entry shall not be true",
+				"6.4.1", 3),
+			WidgetMissingAppearance: a2(
+				"WidgetMissingAppearance",
+				"Form field widget annotations shall have an appearance dictionary",
+				"6.4.1", 4),
+			XFA: a2(
+				"XFA",
+				"The AcroForm shall not contain an XFA entry",
+				"6.4.2", 1),
+			NeedsRendering: a2(
+				"NeedsRendering",
+				"The document catalog NeedsRendering entry shall not be true",
+				"6.4.2", 2),
+		},
+
+		Action: pdfa2ActionChecks{
+			ForbiddenActionType: a2(
+				"ForbiddenActionType",
+				"Launch, Sound, Movie, ResetForm, ImportData, Hide, SetOCGState, Rendition, Trans, GoTo3DView and JavaScript actions shall not be used",
+				"6.5.1", 1),
+			DisallowedNamedAction: a2(
+				"DisallowedNamedAction",
+				"Named actions other than NextPage, PrevPage, FirstPage and LastPage shall not be used",
+				"6.5.1", 2),
+			AdditionalActions: a2(
+				"AdditionalActions",
+				"The document catalog and page dictionaries shall not contain an AA entry",
+				"6.5.2", 1),
+		},
+
+		Metadata: pdfa2MetadataChecks{
+			MetadataMissing: a2(
+				"MetadataMissing",
+				"The document catalog shall contain a Metadata stream",
+				"6.6.2.1", 1),
+			MetadataFiltered: a2(
+				"MetadataFiltered",
+				"The catalog Metadata stream shall not specify a Filter",
+				"6.6.2.1", 2),
+			XPacketBytesAttribute: a2(
+				"XPacketBytesAttribute",
+				"The xpacket header shall not contain a bytes attribute",
+				"6.6.2.1", 3),
+			XPacketEncodingAttribute: a2(
+				"XPacketEncodingAttribute",
+				"The xpacket header shall not contain an encoding attribute",
+				"6.6.2.1", 4),
+			ObjectXMPNoXPacket: a2(
+				"ObjectXMPNoXPacket",
+				"Non-catalog XMP metadata streams shall be wrapped in xpacket processing instructions",
+				"6.6.2.1", 5),
+			XMPStreamUnreadable: a2(
+				"XMPStreamUnreadable",
+				"The XMP metadata stream shall be readable",
+				"6.6.2.1", 6),
+			XMPNotWellFormed: a2(
+				"XMPNotWellFormed",
+				"The XMP metadata shall be well-formed XML",
+				"6.6.2.1", 7),
+			MetadataPropertyType: a2(
+				"MetadataPropertyType",
+				"Predefined XMP properties shall use their defined value types",
+				"6.6.2.3.1", 1),
+			MetadataUndeclaredProperty: a2(
+				"MetadataUndeclaredProperty",
+				"Custom-namespace XMP properties require an extension schema declaration",
+				"6.6.2.3.1", 2),
+			XMPNoCorrespondingType: a2(
+				"XMPNoCorrespondingType",
+				"XMP property does not correspond to its defined type",
+				"6.6.2.3.1", 3),
+			ExtSchemaNamespace: a2(
+				"ExtSchemaNamespace",
+				"Extension-schema namespace URIs shall use their conventional prefixes",
+				"6.6.2.3.3", 1),
+			ExtSchemaWrongPrefixURI: a2(
+				"ExtSchemaWrongPrefixURI",
+				"Extension-schema prefixes shall be bound to their designated namespace URIs",
+				"6.6.2.3.3", 2),
+			ExtSchemasNotBag: a2(
+				"ExtSchemasNotBag",
+				"The pdfaExtension:schemas container shall be an rdf:Bag",
+				"6.6.2.3.3", 3),
+			ExtPropertyMultipleName: a2(
+				"ExtPropertyMultipleName",
+				"Each extension schema property entry shall have exactly one pdfaProperty:name",
+				"6.6.2.3.3", 4),
+			ExtPropertyMissingField: a2(
+				"ExtPropertyMissingField",
+				"Extension schema property entries shall provide name, valueType, category and description",
+				"6.6.2.3.3", 5),
+			ExtPropertyComplexAsSimple: a2(
+				"ExtPropertyComplexAsSimple",
+				"Properties declared with a complex value type shall use rdf:parseType='Resource'",
+				"6.6.2.3.3", 6),
+			ExtTypeInvalid: a2(
+				"ExtTypeInvalid",
+				"Extension schema value-type entries shall provide typeName, namespaceURI, prefix and description",
+				"6.6.2.3.3", 7),
+			ExtFieldInvalid: a2(
+				"ExtFieldInvalid",
+				"Extension schema field entries shall provide a valid name, valueType and description",
+				"6.6.2.3.3", 8),
+			ExtPropertyUndocumented: a2(
+				"ExtPropertyUndocumented",
+				"Properties used under a custom namespace shall be documented in the extension schema",
+				"6.6.2.3.3", 9),
+			ExtPropertyUndefinedType: a2(
+				"ExtPropertyUndefinedType",
+				"Extension schema properties shall reference only built-in or defined value types",
+				"6.6.2.3.3", 10),
+			InfoXMPSync: a2(
+				"InfoXMPSync",
+				"Document information dictionary entries shall be equivalent to their XMP counterparts",
+				"6.6.3", 1),
+			InfoDictUnreadable: a2(
+				"InfoDictUnreadable",
+				"The trailer Info entry shall be a dictionary",
+				"6.6.3", 2),
+			InfoDictXMPMismatch: a2(
+				"InfoDictXMPMismatch",
+				"Document information dictionary entries shall be text strings or dates, Trapped a name",
+				"6.6.3", 3),
+			PDFAIdentifierMissing: a2(
+				"PDFAIdentifierMissing",
+				"XMP metadata shall contain the pdfaid namespace with pdfaid:part and pdfaid:conformance",
+				"6.6.4", 1),
+			PDFAIdentifierNamespace: a2(
+				"PDFAIdentifierNamespace",
+				"The pdfaid namespace URI shall be the PDF/A identifier namespace",
+				"6.6.4", 2),
+			PDFAConformanceLevel: a2(
+				"PDFAConformanceLevel",
+				"The pdfaid:conformance value shall be 'A', 'B' or 'U'",
+				"6.6.4", 3),
+			PDFAPartNumber: a2(
+				"PDFAPartNumber",
+				"The pdfaid:part value shall be '2'",
+				"6.6.4", 4),
+			PDFAIdentifierUndefinedProperty: a2(
+				"PDFAIdentifierUndefinedProperty",
+				"The pdfaid namespace shall only contain the part, conformance, amd and corr properties",
+				"6.6.4", 5),
+		},
+
+		EmbeddedFile: pdfa2EmbeddedFileChecks{
+			EmbeddedFileSpecKeys: a2(
+				"EmbeddedFileSpecKeys",
+				"A file specification dictionary for an embedded file shall contain the F and UF keys",
+				"6.8", 1),
+			EmbeddedFileNotPDFA: a2(
+				"EmbeddedFileNotPDFA",
+				"Embedded files shall be PDF/A-1 or PDF/A-2 conforming files",
+				"6.8", 2),
+		},
+
+		OptionalContent: pdfa2OptionalContentChecks{
+			OCConfigName: a2(
+				"OCConfigName",
+				"Each optional content configuration dictionary shall contain the Name key",
+				"6.9", 1),
+			OCConfigNameDuplicate: a2(
+				"OCConfigNameDuplicate",
+				"Optional content configuration names shall be unique",
+				"6.9", 2),
+			OCOrderIncomplete: a2(
+				"OCOrderIncomplete",
+				"An optional content configuration's Order array shall reference every optional content group",
+				"6.9", 3),
+			OCConfigAS: a2(
+				"OCConfigAS",
+				"Optional content configuration dictionaries shall not contain the AS key",
+				"6.9", 4),
+		},
+
+		Document: pdfa2DocumentChecks{
+			AlternatePresentations: a2(
+				"AlternatePresentations",
+				"The names dictionary shall not contain the AlternatePresentations key",
+				"6.10", 1),
+			PresSteps: a2(
+				"PresSteps",
+				"Page dictionaries shall not contain the PresSteps key",
+				"6.10", 2),
+			Requirements: a2(
+				"Requirements",
+				"The document catalog shall not contain the Requirements key",
+				"6.11", 1),
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package pdf
+
+// PDF/A-3 (ISO 19005-3:2012) check catalog. Part 3 is part 2 with a single
+// change: clause 6.8 permits embedded files of any format, provided each is
+// an associated file -- described by a MIME type and a modification date,
+// qualified by AFRelationship, and listed in an AF array. Every other rule
+// keeps its PDF/A-2 name and clause number, so the part 3 groups reuse the
+// part 2 group types and are registered as copies of them.
+
+type pdfa3EmbeddedFileChecks struct {
+	// 6.8 Embedded files
+	EmbeddedFileSpecKeys      Check
+	EmbeddedFileMIMEType      Check
+	EmbeddedFileModDate       Check
+	AFRelationship            Check
+	EmbeddedFileNotAssociated Check
+}
+
+// pdfa3Checks groups the ISO 19005-3 checks like pdfa2Checks.
+type pdfa3Checks struct {
+	Structure       pdfa2StructureChecks
+	Colour          pdfa2ColourChecks
+	Image           pdfa2ImageChecks
+	Transparency    pdfa2TransparencyChecks
+	Font            pdfa2FontChecks
+	Annotation      pdfa2AnnotationChecks
+	Form            pdfa2FormChecks
+	Action          pdfa2ActionChecks
+	Metadata        pdfa2MetadataChecks
+	EmbeddedFile    pdfa3EmbeddedFileChecks
+	OptionalContent pdfa2OptionalContentChecks
+	Document        pdfa2DocumentChecks
+}
+
+// newPDFA3Checks registers the PDF/A-3 catalog from the already registered
+// PDF/A-2 one. Called from the catalog's init, after newPDFA2Checks.
+func newPDFA3Checks(a2 pdfa2Checks) pdfa3Checks {
+	a3 := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecPDFA3, name, description, clause, subclause)
+	}
+
+	return pdfa3Checks{
+		Structure:    respecGroup(SpecPDFA3, a2.Structure),
+		Colour:       respecGroup(SpecPDFA3, a2.Colour),
+		Image:        respecGroup(SpecPDFA3, a2.Image),
+		Transparency: respecGroup(SpecPDFA3, a2.Transparency),
+		Font:         respecGroup(SpecPDFA3, a2.Font),
+		Annotation:   respecGroup(SpecPDFA3, a2.Annotation),
+		Form:         respecGroup(SpecPDFA3, a2.Form),
+		Action:       respecGroup(SpecPDFA3, a2.Action),
+		Metadata:     respecGroup(SpecPDFA3, a2.Metadata),
+
+		EmbeddedFile: pdfa3EmbeddedFileChecks{
+			EmbeddedFileSpecKeys: a3(
+				"EmbeddedFileSpecKeys",
+				"The file specification dictionary for an embedded file shall contain the F and UF keys",
+				"6.8", 1),
+			EmbeddedFileMIMEType: a3(
+				"EmbeddedFileMIMEType",
+				"An embedded file stream shall contain a Subtype key whose value is a valid MIME type",
+				"6.8", 2),
+			EmbeddedFileModDate: a3(
+				"EmbeddedFileModDate",
+				"An embedded file stream's Params dictionary shall contain the ModDate key",
+				"6.8", 3),
+			AFRelationship: a3(
+				"AFRelationship",
+				"The file specification dictionary for an embedded file shall contain an AFRelationship key with a value defined by ISO 19005-3",
+				"6.8", 4),
+			EmbeddedFileNotAssociated: a3(
+				"EmbeddedFileNotAssociated",
+				"Every embedded file shall be an associated file, listed in the AF array of the catalog or of the object it relates to",
+				"6.8", 5),
+		},
+
+		OptionalContent: respecGroup(SpecPDFA3, a2.OptionalContent),
+		Document:        respecGroup(SpecPDFA3, a2.Document),
+	}
+}
+
+// respecGroup registers a copy of every check in group, a struct whose
+// fields are all Checks, under spec with the same name, description and
+// clause number.
+func respecGroup[T any](spec Spec, group T) T {
+	return respecGroupAs(spec, group, func(clause string) string { return clause })
+}
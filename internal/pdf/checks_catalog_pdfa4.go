@@ -0,0 +1,264 @@
+package pdf
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PDF/A-4 (ISO 19005-4:2020) check catalog. Part 4 is based on PDF 2.0
+// rather than PDF 1.7 and drops the a/b/u conformance levels: a file
+// conforms to PDF/A-4, or to its 4e (engineering) or 4f (arbitrary
+// embedded files) variant, and identifies the revision of the standard in
+// pdfaid:rev instead. Most rules are carried over from part 2 under the
+// same names; ISO 19005-4 inserts 6.5 Digital signatures, so every clause
+// from Actions on moves down one section (part 2's 6.6 Metadata is part 4's
+// 6.7, and so on). The file header and document information dictionary
+// rules change, and the embedded file rules of parts 2 and 3 merge into one
+// group whose applicability depends on the variant.
+
+type pdfa4StructureChecks struct {
+	// 6.1.2 File header
+	FileHeaderSignature     Check
+	FileHeaderComment       Check
+	FileHeaderCommentLength Check
+	FileHeaderCommentBytes  Check
+	CatalogVersion          Check
+	// 6.1.3 File trailer
+	TrailerID                Check
+	TrailerEncrypt           Check
+	TrailerEOF               Check
+	InfoDictWithoutPieceInfo Check
+	InfoDictKeys             Check
+	// 6.1.4 Cross-reference table
+	XRefKeyword                Check
+	XRefSubsectionHeader       Check
+	XRefSubsectionHeaderFormat Check
+	// 6.1.6 String objects
+	HexStringInvalidChar Check
+	HexStringOddLength   Check
+	// 6.1.7.1 Stream objects
+	StreamFileSpec          Check
+	StreamFileFilter        Check
+	StreamFileDecodeParams  Check
+	StreamKeywordEOL        Check
+	EndstreamEOL            Check
+	StreamLengthIncludesEOL Check
+	StreamLengthMismatch    Check
+	// 6.1.7.2 Filters
+	StreamLZWFilter   Check
+	StreamCryptFilter Check
+	// 6.1.8 Name objects
+	NameNotUTF8 Check
+	// 6.1.9 Indirect objects
+	GraphResolutionFailure Check
+	ObjectFraming          Check
+	// 6.1.10 Inline image dictionaries
+	InlineImageLZWFilter Check
+	// 6.1.12 Permissions
+	PermissionsKeys Check
+	// 6.1.13 Implementation limits
+	IntegerOutOfRange       Check
+	RealOutOfRange          Check
+	StringTooLong           Check
+	NameTooLong             Check
+	IndirectObjectsExceeded Check
+	GraphicsStateNesting    Check
+	DeviceNColorants        Check
+	CMapCIDOutOfRange       Check
+	PageBoundaryOutOfRange  Check
+}
+
+type pdfa4MetadataChecks struct {
+	// 6.7.2.1 Metadata streams
+	MetadataMissing          Check
+	MetadataFiltered         Check
+	XPacketBytesAttribute    Check
+	XPacketEncodingAttribute Check
+	ObjectXMPNoXPacket       Check
+	XMPStreamUnreadable      Check
+	XMPNotWellFormed         Check
+	// 6.7.2.3.1 Properties
+	MetadataPropertyType       Check
+	MetadataUndeclaredProperty Check
+	XMPNoCorrespondingType     Check
+	// 6.7.2.3.3 Extension schemas
+	ExtSchemaNamespace         Check
+	ExtSchemaWrongPrefixURI    Check
+	ExtSchemasNotBag           Check
+	ExtPropertyMultipleName    Check
+	ExtPropertyMissingField    Check
+	ExtPropertyComplexAsSimple Check
+	ExtTypeInvalid             Check
+	ExtFieldInvalid            Check
+	ExtPropertyUndocumented    Check
+	ExtPropertyUndefinedType   Check
+	// 6.7.3 Document information dictionary
+	InfoXMPSync         Check
+	InfoDictUnreadable  Check
+	InfoDictXMPMismatch Check
+	// 6.7.4 Version identification
+	PDFAIdentifierMissing           Check
+	PDFAIdentifierNamespace         Check
+	PDFAConformanceLevel            Check
+	PDFAPartNumber                  Check
+	PDFAIdentifierUndefinedProperty Check
+	PDFARevision                    Check
+}
+
+type pdfa4EmbeddedFileChecks struct {
+	// 6.9 Embedded files
+	EmbeddedFileSpecKeys      Check
+	EmbeddedFileNotPDFA       Check
+	EmbeddedFileMIMEType      Check
+	EmbeddedFileModDate       Check
+	AFRelationship            Check
+	EmbeddedFileNotAssociated Check
+}
+
+// pdfa4Checks groups the ISO 19005-4 checks like pdfa2Checks.
+type pdfa4Checks struct {
+	Structure       pdfa4StructureChecks
+	Colour          pdfa2ColourChecks
+	Image           pdfa2ImageChecks
+	Transparency    pdfa2TransparencyChecks
+	Font            pdfa2FontChecks
+	Annotation      pdfa2AnnotationChecks
+	Form            pdfa2FormChecks
+	Action          pdfa2ActionChecks
+	Metadata        pdfa4MetadataChecks
+	EmbeddedFile    pdfa4EmbeddedFileChecks
+	OptionalContent pdfa2OptionalContentChecks
+	Document        pdfa2DocumentChecks
+}
+
+// newPDFA4Checks registers the PDF/A-4 catalog from the already registered
+// PDF/A-2 one. Called from the catalog's init, after newPDFA2Checks.
+func newPDFA4Checks(a2 pdfa2Checks) pdfa4Checks {
+	a4 := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecPDFA4, name, description, clause, subclause)
+	}
+
+	var c pdfa4Checks
+
+	c.Structure.FileHeaderSignature = a4(
+		"FileHeaderSignature",
+		"The file header shall begin at byte zero and consist of %PDF-2.n",
+		"6.1.2", 1)
+	c.Structure.CatalogVersion = a4(
+		"CatalogVersion",
+		"The Version key of the document catalog, if present, shall have a value of 2.n",
+		"6.1.2", 5)
+	c.Structure.InfoDictWithoutPieceInfo = a4(
+		"InfoDictWithoutPieceInfo",
+		"The trailer shall not contain an Info key unless the document catalog contains a PieceInfo key",
+		"6.1.3", 4)
+	c.Structure.InfoDictKeys = a4(
+		"InfoDictKeys",
+		"The document information dictionary, if present, shall contain only the ModDate key",
+		"6.1.3", 5)
+	respecInto(&c.Structure, a2.Structure, SpecPDFA4, pdfa4Clause)
+
+	c.Colour = respecGroupAs(SpecPDFA4, a2.Colour, pdfa4Clause)
+	c.Image = respecGroupAs(SpecPDFA4, a2.Image, pdfa4Clause)
+	c.Transparency = respecGroupAs(SpecPDFA4, a2.Transparency, pdfa4Clause)
+	c.Font = respecGroupAs(SpecPDFA4, a2.Font, pdfa4Clause)
+	c.Annotation = respecGroupAs(SpecPDFA4, a2.Annotation, pdfa4Clause)
+	c.Form = respecGroupAs(SpecPDFA4, a2.Form, pdfa4Clause)
+	c.Action = respecGroupAs(SpecPDFA4, a2.Action, pdfa4Clause)
+
+	c.Metadata.PDFAIdentifierMissing = a4(
+		"PDFAIdentifierMissing",
+		"XMP metadata shall contain the pdfaid namespace with pdfaid:part and pdfaid:rev",
+		"6.7.4", 1)
+	c.Metadata.PDFAConformanceLevel = a4(
+		"PDFAConformanceLevel",
+		"The pdfaid:conformance value, if present, shall be 'E' or 'F'",
+		"6.7.4", 3)
+	c.Metadata.PDFAPartNumber = a4(
+		"PDFAPartNumber",
+		"The pdfaid:part value shall be '4'",
+		"6.7.4", 4)
+	c.Metadata.PDFAIdentifierUndefinedProperty = a4(
+		"PDFAIdentifierUndefinedProperty",
+		"The pdfaid namespace shall only contain the part, rev, conformance, amd and corr properties",
+		"6.7.4", 5)
+	c.Metadata.PDFARevision = a4(
+		"PDFARevision",
+		"The pdfaid:rev value shall be the four digit year of the ISO 19005-4 revision",
+		"6.7.4", 6)
+	respecInto(&c.Metadata, a2.Metadata, SpecPDFA4, pdfa4Clause)
+
+	c.EmbeddedFile = pdfa4EmbeddedFileChecks{
+		EmbeddedFileSpecKeys: a4(
+			"EmbeddedFileSpecKeys",
+			"The file specification dictionary for an embedded file shall contain the F and UF keys",
+			"6.9", 1),
+		EmbeddedFileNotPDFA: a4(
+			"EmbeddedFileNotPDFA",
+			"Except in PDF/A-4f, every embedded file shall be a PDF/A file",
+			"6.9", 2),
+		EmbeddedFileMIMEType: a4(
+			"EmbeddedFileMIMEType",
+			"An embedded file stream shall contain a Subtype key whose value is a valid MIME type",
+			"6.9", 3),
+		EmbeddedFileModDate: a4(
+			"EmbeddedFileModDate",
+			"An embedded file stream's Params dictionary shall contain the ModDate key",
+			"6.9", 4),
+		AFRelationship: a4(
+			"AFRelationship",
+			"The file specification dictionary for an embedded file shall contain an AFRelationship key",
+			"6.9", 5),
+		EmbeddedFileNotAssociated: a4(
+			"EmbeddedFileNotAssociated",
+			"Every embedded file shall be an associated file, listed in the AF array of the catalog or of the object it relates to",
+			"6.9", 6),
+	}
+
+	c.OptionalContent = respecGroupAs(SpecPDFA4, a2.OptionalContent, pdfa4Clause)
+	c.Document = respecGroupAs(SpecPDFA4, a2.Document, pdfa4Clause)
+	return c
+}
+
+// pdfa4Clause maps an ISO 19005-2 clause number onto ISO 19005-4's, which
+// inserts 6.5 Digital signatures: sections 6.5 and later move down one.
+func pdfa4Clause(clause string) string {
+	parts := strings.SplitN(clause, ".", 3)
+	if len(parts) < 2 || parts[0] != "6" {
+		return clause
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 5 {
+		return clause
+	}
+	parts[1] = strconv.Itoa(n + 1)
+	return strings.Join(parts, ".")
+}
+
+// respecGroupAs is respecGroup with each clause number mapped by clause.
+func respecGroupAs[T any](spec Spec, group T, clause func(string) string) T {
+	var out T
+	respecInto(&out, group, spec, clause)
+	return out
+}
+
+// respecInto registers under spec, for every Check field of *dst not yet
+// set, a copy of src's check of the same field name with its clause mapped
+// by clause. src is a check group struct; fields it lacks stay unset.
+func respecInto[T any](dst *T, src any, spec Spec, clause func(string) string) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src)
+	for i := range d.NumField() {
+		f := d.Field(i)
+		if f.Interface().(Check).ID() != 0 {
+			continue
+		}
+		sf := s.FieldByName(d.Type().Field(i).Name)
+		if !sf.IsValid() {
+			continue
+		}
+		c := sf.Interface().(Check)
+		f.Set(reflect.ValueOf(newSpecCheck(spec, c.name, c.description, clause(c.clause), c.subclause)))
+	}
+}
@@ -0,0 +1,253 @@
+package pdf
+
+// PDF/UA-1 (ISO 14289-1:2014) check catalog: the machine-checkable rules of
+// the accessibility standard, in the spirit of the Matterhorn Protocol's
+// failure conditions. PDF/UA-1 is not a PDF/A part; its checks share the
+// registry so profiles, results and clause lookups work the same way. A
+// rule the PDF/A-1 level A verifier already enforces (MarkInfo, structure
+// tree, role map, Lang syntax) keeps that check's name, so CheckIn maps the
+// shared verifier's findings onto this catalog.
+
+type pdfua1DocumentChecks struct {
+	// 5 Version identification
+	PDFUAIdentifier Check
+	// 6.2 Conforming files
+	GraphResolutionFailure Check
+	// 7.1 General
+	MetadataTitle   Check
+	DisplayDocTitle Check
+	// 7.10 Optional content
+	OCConfigName Check
+	OCConfigAS   Check
+	// 7.11 Embedded files
+	EmbeddedFileSpecKeys Check
+	// 7.15 XFA
+	DynamicXFA Check
+	// 7.16 Security
+	EncryptAccessibility Check
+	// 7.20 XObjects
+	ReferenceXObject Check
+}
+
+type pdfua1TaggedChecks struct {
+	// 7.1 General
+	ContentNotTagged        Check
+	ArtifactInTaggedContent Check
+	TaggedContentInArtifact Check
+	RoleMapStandardType     Check
+	StandardTypeRemapped    Check
+	RoleMapCircular         Check
+	MarkInfoSuspects        Check
+	TaggedMarkInfo          Check
+	StructTreeRoot          Check
+}
+
+type pdfua1StructureChecks struct {
+	// 7.2 Text
+	NaturalLanguage Check
+	LangIdentifier  Check
+	// 7.3 Graphics
+	FigureAlternateText Check
+	// 7.4 Headings
+	HeadingSequence  Check
+	HeadingMixed     Check
+	HeadingMultipleH Check
+	// 7.5 Tables
+	TableStructure Check
+	// 7.6 Lists
+	ListStructure Check
+	// 7.7 Mathematical expressions
+	FormulaAlternateText Check
+	// 7.9 Notes and references
+	NoteID Check
+}
+
+type pdfua1AnnotationChecks struct {
+	// 7.18.1 General
+	AnnotationNotTagged Check
+	AnnotationContents  Check
+	// 7.18.3 Tab order
+	PageTabOrder Check
+	// 7.18.4 Widget annotations
+	WidgetNotInForm Check
+}
+
+type pdfua1FontChecks struct {
+	// 7.21.4.1 Embedding
+	FontNotEmbedded Check
+	// 7.21.7 Unicode character maps
+	UnicodeMapping Check
+}
+
+// pdfua1Checks groups the ISO 14289-1 checks.
+type pdfua1Checks struct {
+	Document   pdfua1DocumentChecks
+	Tagged     pdfua1TaggedChecks
+	Structure  pdfua1StructureChecks
+	Annotation pdfua1AnnotationChecks
+	Font       pdfua1FontChecks
+}
+
+// newPDFUA1Checks registers the PDF/UA-1 catalog.
+func newPDFUA1Checks() pdfua1Checks {
+	ua := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecPDFUA1, name, description, clause, subclause)
+	}
+
+	return pdfua1Checks{
+		Document: pdfua1DocumentChecks{
+			PDFUAIdentifier: ua(
+				"PDFUAIdentifier",
+				"The catalog's XMP metadata shall contain the pdfuaid:part property with the value 1",
+				"5", 1),
+			GraphResolutionFailure: ua(
+				"GraphResolutionFailure",
+				"The file shall conform to ISO 32000-1: its object graph shall resolve",
+				"6.2", 1),
+			MetadataTitle: ua(
+				"MetadataTitle",
+				"The catalog's XMP metadata shall contain a dc:title entry naming the document",
+				"7.1", 8),
+			DisplayDocTitle: ua(
+				"DisplayDocTitle",
+				"The catalog's ViewerPreferences shall contain DisplayDocTitle with the value true",
+				"7.1", 9),
+			OCConfigName: ua(
+				"OCConfigName",
+				"Every optional content configuration dictionary shall contain a Name entry",
+				"7.10", 1),
+			OCConfigAS: ua(
+				"OCConfigAS",
+				"An optional content configuration dictionary shall not contain the AS key",
+				"7.10", 2),
+			EmbeddedFileSpecKeys: ua(
+				"EmbeddedFileSpecKeys",
+				"The file specification dictionary for an embedded file shall contain the F and UF keys",
+				"7.11", 1),
+			DynamicXFA: ua(
+				"DynamicXFA",
+				"A document shall not contain a dynamic XFA form (NeedsRendering true)",
+				"7.15", 1),
+			EncryptAccessibility: ua(
+				"EncryptAccessibility",
+				"The encryption dictionary's P entry shall permit content extraction for accessibility (bit 10)",
+				"7.16", 1),
+			ReferenceXObject: ua(
+				"ReferenceXObject",
+				"Reference XObjects shall not be used",
+				"7.20", 1),
+		},
+
+		Tagged: pdfua1TaggedChecks{
+			ContentNotTagged: ua(
+				"ContentNotTagged",
+				"Content shall be either tagged in the structure tree or marked as an Artifact",
+				"7.1", 1),
+			ArtifactInTaggedContent: ua(
+				"ArtifactInTaggedContent",
+				"Content marked as an Artifact shall not appear inside tagged content",
+				"7.1", 2),
+			TaggedContentInArtifact: ua(
+				"TaggedContentInArtifact",
+				"Tagged content shall not appear inside content marked as an Artifact",
+				"7.1", 3),
+			RoleMapStandardType: ua(
+				"RoleMapStandardType",
+				"Every structure type shall be a standard type or be role mapped to one",
+				"7.1", 4),
+			StandardTypeRemapped: ua(
+				"StandardTypeRemapped",
+				"A standard structure type shall not be remapped in the role map",
+				"7.1", 5),
+			RoleMapCircular: ua(
+				"RoleMapCircular",
+				"Role map entries shall not be circular",
+				"7.1", 6),
+			MarkInfoSuspects: ua(
+				"MarkInfoSuspects",
+				"The catalog's MarkInfo Suspects entry shall not be true",
+				"7.1", 7),
+			TaggedMarkInfo: ua(
+				"TaggedMarkInfo",
+				"The catalog shall contain a MarkInfo dictionary with Marked true",
+				"7.1", 10),
+			StructTreeRoot: ua(
+				"StructTreeRoot",
+				"The catalog shall contain a StructTreeRoot",
+				"7.1", 11),
+		},
+
+		Structure: pdfua1StructureChecks{
+			NaturalLanguage: ua(
+				"NaturalLanguage",
+				"The catalog shall declare the document's natural language in a non-empty Lang entry",
+				"7.2", 1),
+			LangIdentifier: ua(
+				"LangIdentifier",
+				"Every Lang entry shall be a language identifier",
+				"7.2", 2),
+			FigureAlternateText: ua(
+				"FigureAlternateText",
+				"Figure structure elements shall have an Alt or ActualText entry",
+				"7.3", 1),
+			HeadingSequence: ua(
+				"HeadingSequence",
+				"Numbered headings shall start at H1 and not skip a level when descending",
+				"7.4.2", 1),
+			HeadingMixed: ua(
+				"HeadingMixed",
+				"A document shall not use both H and numbered H1-H6 headings",
+				"7.4.4", 1),
+			HeadingMultipleH: ua(
+				"HeadingMultipleH",
+				"A structure element shall contain at most one H element",
+				"7.4.4", 2),
+			TableStructure: ua(
+				"TableStructure",
+				"Table elements shall nest as Table > (THead, TBody, TFoot) > TR > (TH, TD)",
+				"7.5", 1),
+			ListStructure: ua(
+				"ListStructure",
+				"List elements shall nest as L > LI > (Lbl, LBody)",
+				"7.6", 1),
+			FormulaAlternateText: ua(
+				"FormulaAlternateText",
+				"Formula structure elements shall have an Alt or ActualText entry",
+				"7.7", 1),
+			NoteID: ua(
+				"NoteID",
+				"Note structure elements shall have an ID entry",
+				"7.9", 1),
+		},
+
+		Annotation: pdfua1AnnotationChecks{
+			AnnotationNotTagged: ua(
+				"AnnotationNotTagged",
+				"Annotations other than Popup and PrinterMark shall be tagged, through a StructParent entry",
+				"7.18.1", 1),
+			AnnotationContents: ua(
+				"AnnotationContents",
+				"Annotations other than Widget, Popup and PrinterMark shall have a Contents entry or an Alt entry on their structure element",
+				"7.18.1", 2),
+			PageTabOrder: ua(
+				"PageTabOrder",
+				"A page with annotations shall contain Tabs with the value S",
+				"7.18.3", 1),
+			WidgetNotInForm: ua(
+				"WidgetNotInForm",
+				"Widget annotations shall be nested in a Form structure element",
+				"7.18.4", 1),
+		},
+
+		Font: pdfua1FontChecks{
+			FontNotEmbedded: ua(
+				"FontNotEmbedded",
+				"Fonts used for rendering shall have their font programs embedded",
+				"7.21.4.1", 1),
+			UnicodeMapping: ua(
+				"UnicodeMapping",
+				"Every character code a font shows shall map to Unicode",
+				"7.21.7", 1),
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package pdf
+
+// PDF/X check catalogs: PDF/X-1a:2003 (ISO 15930-4) and PDF/X-4 (ISO
+// 15930-7), the print-production exchange standards. Like PDF/UA-1 they are
+// not PDF/A parts, but their verifier runs the PDF/A one underneath: the
+// output intent colour coverage, font embedding and transparency rules a
+// PDF/X file must meet are PDF/A-1's (for X-1a) or PDF/A-2's (for X-4)
+// under another output intent subtype, so those checks keep their PDF/A
+// names and CheckIn maps the shared verifier's findings onto these
+// catalogs. X-4 carries the X-1a rules except its colour space and
+// transparency prohibitions, under the same clause numbers.
+
+type pdfxDocumentChecks struct {
+	// 6.1 File structure
+	GraphResolutionFailure Check
+	Encrypted              Check
+	VersionIdentifier      Check
+	TrappedKey             Check
+}
+
+type pdfxOutputIntentChecks struct {
+	// 6.2.2 Output intent
+	PDFXOutputIntent          Check
+	PDFXOutputIntentMultiple  Check
+	OutputConditionIdentifier Check
+	DestOutputProfile         Check
+	OutputProfileClass        Check
+}
+
+type pdfx1aColourChecks struct {
+	// 6.2.3 Colour spaces
+	DeviceColourSpaceUsage    Check
+	DeviceColourContentStream Check
+	ColourSpaceNotAllowed     Check
+}
+
+type pdfx4ColourChecks struct {
+	// 6.2.3 Colour spaces
+	DeviceColourSpaceUsage    Check
+	DeviceColourContentStream Check
+}
+
+type pdfx1aTransparencyChecks struct {
+	// 6.2.4 Transparency
+	SoftMaskExtGState Check
+	BlendMode         Check
+	StrokingAlpha     Check
+	NonStrokingAlpha  Check
+	TransparencyGroup Check
+	ImageWithSoftMask Check
+}
+
+type pdfxFontChecks struct {
+	// 6.3 Fonts
+	SimpleNotEmbedded Check
+	CIDNotEmbedded    Check
+}
+
+type pdfxPageChecks struct {
+	// 6.4 Page boundaries
+	TrimOrArtBox  Check
+	TrimAndArtBox Check
+	BoxNesting    Check
+}
+
+// pdfx1aChecks groups the ISO 15930-4 checks.
+type pdfx1aChecks struct {
+	Document     pdfxDocumentChecks
+	OutputIntent pdfxOutputIntentChecks
+	Colour       pdfx1aColourChecks
+	Transparency pdfx1aTransparencyChecks
+	Font         pdfxFontChecks
+	Page         pdfxPageChecks
+}
+
+// pdfx4Checks groups the ISO 15930-7 checks.
+type pdfx4Checks struct {
+	Document     pdfxDocumentChecks
+	OutputIntent pdfxOutputIntentChecks
+	Colour       pdfx4ColourChecks
+	Font         pdfxFontChecks
+	Page         pdfxPageChecks
+}
+
+// newPDFX1aChecks registers the PDF/X-1a catalog.
+func newPDFX1aChecks() pdfx1aChecks {
+	x := func(name, description, clause string, subclause int) Check {
+		return newSpecCheck(SpecPDFX1A, name, description, clause, subclause)
+	}
+
+	return pdfx1aChecks{
+		Document: pdfxDocumentChecks{
+			GraphResolutionFailure: x(
+				"GraphResolutionFailure",
+				"The file shall conform to the PDF Reference: its object graph shall resolve",
+				"6.1", 1),
+			Encrypted: x(
+				"Encrypted",
+				"The file shall not be encrypted",
+				"6.1", 2),
+			VersionIdentifier: x(
+				"VersionIdentifier",
+				"The file shall identify the PDF/X version it conforms to (GTS_PDFXVersion)",
+				"6.1", 3),
+			TrappedKey: x(
+				"TrappedKey",
+				"The file shall declare whether it has been trapped, with a Trapped value of True or False",
+				"6.1", 4),
+		},
+
+		OutputIntent: pdfxOutputIntentChecks{
+			PDFXOutputIntent: x(
+				"PDFXOutputIntent",
+				"The catalog shall contain an OutputIntents array with a GTS_PDFX output intent",
+				"6.2.2", 1),
+			PDFXOutputIntentMultiple: x(
+				"PDFXOutputIntentMultiple",
+				"The OutputIntents array shall contain only one GTS_PDFX output intent",
+				"6.2.2", 2),
+			OutputConditionIdentifier: x(
+				"OutputConditionIdentifier",
+				"The GTS_PDFX output intent shall contain an OutputConditionIdentifier",
+				"6.2.2", 3),
+			DestOutputProfile: x(
+				"DestOutputProfile",
+				"The GTS_PDFX output intent shall embed a DestOutputProfile unless it names a registered characterization",
+				"6.2.2", 4),
+			OutputProfileClass: x(
+				"OutputProfileClass",
+				"The destination output profile shall be an output device (prtr) profile of a permitted colour space",
+				"6.2.2", 5),
+		},
+
+		Colour: pdfx1aColourChecks{
+			DeviceColourSpaceUsage: x(
+				"DeviceColourSpaceUsage",
+				"A device colour space shall only be used when the output intent's colour space matches it",
+				"6.2.3", 1),
+			DeviceColourContentStream: x(
+				"DeviceColourContentStream",
+				"Content stream colour operators shall only use device colour matching the output intent",
+				"6.2.3", 2),
+			ColourSpaceNotAllowed: x(
+				"ColourSpaceNotAllowed",
+				"Only DeviceCMYK, DeviceGray, Separation and DeviceN colour spaces, and Indexed or Pattern spaces over them, shall be used",
+				"6.2.3", 3),
+		},
+
+		Transparency: pdfx1aTransparencyChecks{
+			SoftMaskExtGState: x(
+				"SoftMaskExtGState",
+				"An ExtGState SMask entry shall be None",
+				"6.2.4", 1),
+			BlendMode: x(
+				"BlendMode",
+				"An ExtGState BM entry shall be Normal or Compatible",
+				"6.2.4", 2),
+			StrokingAlpha: x(
+				"StrokingAlpha",
+				"An ExtGState CA entry shall be 1.0",
+				"6.2.4", 3),
+			NonStrokingAlpha: x(
+				"NonStrokingAlpha",
+				"An ExtGState ca entry shall be 1.0",
+				"6.2.4", 4),
+			TransparencyGroup: x(
+				"TransparencyGroup",
+				"A Group dictionary shall not have the S value Transparency",
+				"6.2.4", 5),
+			ImageWithSoftMask: x(
+				"ImageWithSoftMask",
+				"An image XObject shall not contain an SMask other than None",
+				"6.2.4", 6),
+		},
+
+		Font: pdfxFontChecks{
+			SimpleNotEmbedded: x(
+				"SimpleNotEmbedded",
+				"Every font used for rendering shall embed its font program",
+				"6.3", 1),
+			CIDNotEmbedded: x(
+				"CIDNotEmbedded",
+				"Every CIDFont used for rendering shall embed its font program",
+				"6.3", 2),
+		},
+
+		Page: pdfxPageChecks{
+			TrimOrArtBox: x(
+				"TrimOrArtBox",
+				"Every page shall have a TrimBox or an ArtBox",
+				"6.4", 1),
+			TrimAndArtBox: x(
+				"TrimAndArtBox",
+				"A page shall not have both a TrimBox and an ArtBox",
+				"6.4", 2),
+			BoxNesting: x(
+				"BoxNesting",
+				"The BleedBox shall lie within the MediaBox, and the TrimBox or ArtBox within the BleedBox",
+				"6.4", 3),
+		},
+	}
+}
+
+// newPDFX4Checks registers the PDF/X-4 catalog from the PDF/X-1a one.
+func newPDFX4Checks(x1a pdfx1aChecks) pdfx4Checks {
+	c := pdfx4Checks{
+		Document:     respecGroup(SpecPDFX4, x1a.Document),
+		OutputIntent: respecGroup(SpecPDFX4, x1a.OutputIntent),
+		Font:         respecGroup(SpecPDFX4, x1a.Font),
+		Page:         respecGroup(SpecPDFX4, x1a.Page),
+	}
+	respecInto(&c.Colour, x1a.Colour, SpecPDFX4, func(clause string) string { return clause })
+	return c
+}
@@ -2,6 +2,7 @@ package pdf
 
 import (
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -21,9 +22,9 @@ func TestCatalog_AllChecksUnique(t *testing.T) {
 		}
 		seenIDs[c.ID()] = true
 
-		pair := c.Clause() + "/" + strconv.Itoa(c.Subclause())
+		pair := string(c.Spec()) + "|" + c.Clause() + "/" + strconv.Itoa(c.Subclause())
 		if seenPairs[pair] {
-			t.Errorf("duplicate (clause, subclause) pair %s for check %q", pair, c.Name())
+			t.Errorf("duplicate (spec, clause, subclause) %s for check %q", pair, c.Name())
 		}
 		seenPairs[pair] = true
 
@@ -98,3 +99,171 @@ func TestChecksForClause(t *testing.T) {
 		t.Errorf("ChecksForClause(bogus) = %v, want empty", got)
 	}
 }
+
+func TestCheckBySpecClause(t *testing.T) {
+	c, ok := CheckBySpecClause(SpecPDFA2, "6.2.10", 1)
+	if !ok || c != Checks.PDFA2.Transparency.BlendMode {
+		t.Errorf("CheckBySpecClause(A-2, 6.2.10, 1) = %v, %v; want BlendMode", c, ok)
+	}
+	if c.Spec() != SpecPDFA2 {
+		t.Errorf("Spec() = %q, want %q", c.Spec(), SpecPDFA2)
+	}
+	// 6.1.8/1 is ObjectFraming in part 1 but NameNotUTF8 in part 2.
+	if c, _ := CheckByClause("6.1.8", 1); c != Checks.Structure.ObjectFraming {
+		t.Errorf("CheckByClause(6.1.8, 1) = %q, want the PDF/A-1 ObjectFraming", c.Name())
+	}
+	if c, _ := CheckBySpecClause(SpecPDFA2, "6.1.8", 1); c != Checks.PDFA2.Structure.NameNotUTF8 {
+		t.Errorf("CheckBySpecClause(A-2, 6.1.8, 1) = %q, want NameNotUTF8", c.Name())
+	}
+	if _, ok := CheckBySpecClause(SpecPDFA2, "9.9.9", 1); ok {
+		t.Error("CheckBySpecClause(bogus) should be false")
+	}
+}
+
+func TestChecksForSpec(t *testing.T) {
+	for _, spec := range []Spec{SpecPDF, SpecPDFA1, SpecPDFA2, SpecPDFA3} {
+		got := ChecksForSpec(spec)
+		if len(got) == 0 {
+			t.Errorf("ChecksForSpec(%q) is empty", spec)
+		}
+		for _, c := range got {
+			if c.Spec() != spec {
+				t.Errorf("ChecksForSpec(%q) returned %q with spec %q", spec, c.Name(), c.Spec())
+			}
+		}
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	for _, tc := range []struct {
+		in   Check
+		want Check
+		ok   bool
+	}{
+		{Checks.Structure.ObjectFraming, Checks.PDFA2.Structure.ObjectFraming, true},
+		{Checks.Font.AdvanceWidthMismatch, Checks.PDFA2.Font.AdvanceWidthMismatch, true},
+		{Checks.ObjectModel.MissingRequiredKey, Checks.ObjectModel.MissingRequiredKey, true},
+		{Checks.PDFA2.Transparency.BlendMode, Checks.PDFA2.Transparency.BlendMode, true},
+		// Dropped in part 2: no counterpart.
+		{Checks.Transparency.ImageWithSoftMask, Check{}, false},
+		{Checks.Structure.XRefStream, Check{}, false},
+	} {
+		got, ok := CheckIn(SpecPDFA2, tc.in)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("CheckIn(A-2, %q) = %q, %v; want %q, %v", tc.in.Name(), got.Name(), ok, tc.want.Name(), tc.ok)
+		}
+	}
+}
+
+// TestPDFA3Catalog checks part 3 carries every part 2 rule under its name
+// and clause, except the 6.8 embedded-file rules it replaces.
+func TestPDFA3Catalog(t *testing.T) {
+	for _, c2 := range ChecksForSpec(SpecPDFA2) {
+		c3, ok := CheckIn(SpecPDFA3, c2)
+		if c2.Clause() == "6.8" {
+			continue
+		}
+		if !ok || c3.Clause() != c2.Clause() || c3.Subclause() != c2.Subclause() || c3.Spec() != SpecPDFA3 {
+			t.Errorf("CheckIn(A-3, %q) = %v, %v", c2.Name(), c3, ok)
+		}
+	}
+	if c, ok := CheckIn(SpecPDFA3, Checks.Structure.ObjectFraming); !ok || c != Checks.PDFA3.Structure.ObjectFraming {
+		t.Errorf("CheckIn(A-3, ObjectFraming) = %v, %v", c, ok)
+	}
+	if _, ok := CheckIn(SpecPDFA3, Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA); ok {
+		t.Error("part 3 permits embedded files of any format")
+	}
+	if c, _ := CheckBySpecClause(SpecPDFA3, "6.8", 4); c != Checks.PDFA3.EmbeddedFile.AFRelationship {
+		t.Errorf("CheckBySpecClause(A-3, 6.8, 4) = %q, want AFRelationship", c.Name())
+	}
+}
+
+// TestPDFA4Catalog checks part 4 carries every part 2 rule under its name,
+// renumbered past the inserted 6.5, and defines the part 3 associated-file
+// rules alongside part 2's embedded PDF/A rule.
+func TestPDFA4Catalog(t *testing.T) {
+	for _, c2 := range ChecksForSpec(SpecPDFA2) {
+		c4, ok := CheckIn(SpecPDFA4, c2)
+		if !ok || c4.Spec() != SpecPDFA4 {
+			t.Errorf("CheckIn(A-4, %q) = %v, %v", c2.Name(), c4, ok)
+			continue
+		}
+		if c2.Clause() != "6.8" && (c4.Clause() != pdfa4Clause(c2.Clause()) || c4.Subclause() != c2.Subclause()) {
+			t.Errorf("CheckIn(A-4, %q) numbered %s/%d", c2.Name(), c4.Clause(), c4.Subclause())
+		}
+	}
+	for _, c3 := range ChecksForSpec(SpecPDFA3) {
+		if _, ok := CheckIn(SpecPDFA4, c3); !ok {
+			t.Errorf("part 4 lacks part 3's %q", c3.Name())
+		}
+	}
+	for in, want := range map[string]string{
+		"6.1.2": "6.1.2", "6.4.1": "6.4.1", "6.5.1": "6.6.1", "6.6.2.3.1": "6.7.2.3.1", "6.10": "6.11",
+	} {
+		if got := pdfa4Clause(in); got != want {
+			t.Errorf("pdfa4Clause(%s) = %s, want %s", in, got, want)
+		}
+	}
+	if c, ok := CheckIn(SpecPDFA4, Checks.Structure.FileHeaderSignature); !ok || !strings.Contains(c.Description(), "%PDF-2.n") {
+		t.Errorf("CheckIn(A-4, FileHeaderSignature) = %v, %v; want the PDF 2.0 header rule", c, ok)
+	}
+	if c, _ := CheckBySpecClause(SpecPDFA4, "6.7.4", 6); c != Checks.PDFA4.Metadata.PDFARevision {
+		t.Errorf("CheckBySpecClause(A-4, 6.7.4, 6) = %q, want PDFARevision", c.Name())
+	}
+}
+
+func TestPDFUA1Catalog(t *testing.T) {
+	for _, c := range []Check{
+		Checks.LogicalStructure.TaggedMarkInfo, Checks.LogicalStructure.StructTreeRoot,
+		Checks.LogicalStructure.RoleMapStandardType, Checks.LogicalStructure.RoleMapCircular,
+		Checks.LogicalStructure.LangIdentifier, Checks.PDFA2.OptionalContent.OCConfigName,
+		Checks.PDFA2.OptionalContent.OCConfigAS,
+	} {
+		if ua, ok := CheckIn(SpecPDFUA1, c); !ok || ua.Spec() != SpecPDFUA1 {
+			t.Errorf("CheckIn(UA-1, %q) = %v, %v", c.Name(), ua, ok)
+		}
+	}
+	// Level A's single alternate-description rule is split in PDF/UA-1.
+	if _, ok := CheckIn(SpecPDFUA1, Checks.LogicalStructure.AlternateDescription); ok {
+		t.Error("CheckIn(UA-1, AlternateDescription) should have no counterpart")
+	}
+	if c, ok := CheckBySpecClause(SpecPDFUA1, "7.1", 1); !ok || c != Checks.PDFUA1.Tagged.ContentNotTagged {
+		t.Errorf("CheckBySpecClause(UA-1, 7.1, 1) = %v, %v", c, ok)
+	}
+}
+
+func TestPDFXCatalog(t *testing.T) {
+	for _, c := range []Check{
+		Checks.Font.SimpleNotEmbedded, Checks.Transparency.BlendMode,
+		Checks.Colour.DeviceColourContentStream, Checks.Structure.GraphResolutionFailure,
+	} {
+		if x, ok := CheckIn(SpecPDFX1A, c); !ok || x.Spec() != SpecPDFX1A {
+			t.Errorf("CheckIn(X-1a, %q) = %v, %v", c.Name(), x, ok)
+		}
+	}
+	if x, ok := CheckIn(SpecPDFX4, Checks.PDFA2.Font.SimpleNotEmbedded); !ok || x != Checks.PDFX4.Font.SimpleNotEmbedded {
+		t.Errorf("CheckIn(X-4, SimpleNotEmbedded) = %v, %v", x, ok)
+	}
+	// PDF/X-4 permits transparency and the archival rules have no PDF/X
+	// counterpart.
+	for _, c := range []Check{Checks.PDFA2.Transparency.BlendMode, Checks.Metadata.MetadataMissing} {
+		if _, ok := CheckIn(SpecPDFX4, c); ok {
+			t.Errorf("CheckIn(X-4, %q) should have no counterpart", c.Name())
+		}
+	}
+	if c, ok := CheckBySpecClause(SpecPDFX4, "6.4", 3); !ok || c != Checks.PDFX4.Page.BoxNesting {
+		t.Errorf("CheckBySpecClause(X-4, 6.4, 3) = %v, %v", c, ok)
+	}
+}
+
+func TestFacturXCatalog(t *testing.T) {
+	if got := len(ChecksForSpec(SpecFacturX)); got != 10 {
+		t.Errorf("ChecksForSpec(Factur-X) has %d checks, want 10", got)
+	}
+	if c, ok := CheckBySpecClause(SpecFacturX, "7.3", 4); !ok || c != Checks.FacturX.Metadata.InvoiceExtensionSchema {
+		t.Errorf("CheckBySpecClause(Factur-X, 7.3, 4) = %v, %v", c, ok)
+	}
+	if Checks.FacturX.Invoice.InvoiceMissing.Spec() != SpecFacturX {
+		t.Errorf("InvoiceMissing numbered by %s", Checks.FacturX.Invoice.InvoiceMissing.Spec())
+	}
+}
@@ -0,0 +1,251 @@
+package pdf
+
+import "unicode/utf16"
+
+// CodespaceRange is one begincodespacerange entry of a CMap: the codes of
+// len(Low) bytes whose every byte lies between the corresponding bytes of
+// Low and High (ISO 32000-1, 9.7.6.2).
+type CodespaceRange struct {
+	Low, High []byte
+}
+
+// ToUnicodeCMap is a parsed ToUnicode CMap (ISO 32000-1, 9.10.3): the
+// bfchar and bfrange mappings from character codes to Unicode text. A code
+// is identified by its value and its length in bytes, so <41> and <0041> are
+// distinct codes.
+type ToUnicodeCMap struct {
+	Codespace []CodespaceRange
+	chars     map[cmapCode]string
+	ranges    []cmapRange
+}
+
+type cmapCode struct {
+	n    int
+	code uint32
+}
+
+// cmapRange is one bfrange entry. Its destination is either base, the UTF-16
+// text of lo whose last code unit is incremented for each following code, or
+// dsts, one destination per code.
+type cmapRange struct {
+	n      int
+	lo, hi uint32
+	base   []uint16
+	dsts   []string
+}
+
+// maxCMapRangeExpansion bounds how many bfrange codes Each expands in total,
+// so malformed ranges like <00000000> <FFFFFFFF> cannot stall a caller. A
+// two-byte CMap needs at most 1<<16.
+const maxCMapRangeExpansion = 1 << 16
+
+// ParseToUnicodeCMap parses the decoded data of a ToUnicode CMap stream.
+// Parsing is lenient: tokens outside the codespacerange, bfchar and bfrange
+// blocks (the PostScript CMap boilerplate) are skipped, as are malformed
+// entries, so a damaged CMap yields whatever mappings it still carries.
+func ParseToUnicodeCMap(data []byte) *ToUnicodeCMap {
+	m := &ToUnicodeCMap{chars: map[cmapCode]string{}}
+	next := NewLexerBytes(data, 0).NextToken
+	for {
+		t := next()
+		switch t.Type {
+		case TokenEOF:
+			return m
+		case TokenKeyword:
+			switch t.Value {
+			case "begincodespacerange":
+				m.parseCodespace(next)
+			case "beginbfchar":
+				m.parseBfChar(next)
+			case "beginbfrange":
+				m.parseBfRange(next)
+			}
+		}
+	}
+}
+
+// cmapBytes returns the bytes a source code or destination token stands for.
+// Besides hex strings it accepts literal strings, which some producers write
+// for destinations.
+func cmapBytes(t Token) ([]byte, bool) {
+	switch t.Type {
+	case TokenHexString:
+		return DecodePDFHexStringBytes(t.Value), true
+	case TokenString:
+		return []byte(t.Value), true
+	}
+	return nil, false
+}
+
+// cmapCodeOf returns src as a code of len(src) bytes; codes longer than four
+// bytes do not exist in any CMap and are rejected.
+func cmapCodeOf(src []byte) (cmapCode, bool) {
+	if len(src) == 0 || len(src) > 4 {
+		return cmapCode{}, false
+	}
+	var code uint32
+	for _, b := range src {
+		code = code<<8 | uint32(b)
+	}
+	return cmapCode{n: len(src), code: code}, true
+}
+
+// utf16Units returns the UTF-16BE code units of a destination. A
+// one-byte destination, which some producers write for Latin text, is taken
+// as the code unit it zero-extends to.
+func utf16Units(dst []byte) []uint16 {
+	if len(dst) == 1 {
+		return []uint16{uint16(dst[0])}
+	}
+	units := make([]uint16, len(dst)/2)
+	for i := range units {
+		units[i] = uint16(dst[2*i])<<8 | uint16(dst[2*i+1])
+	}
+	return units
+}
+
+func (m *ToUnicodeCMap) parseCodespace(next func() Token) {
+	for {
+		t := next()
+		if t.Type == TokenEOF || t.Type == TokenKeyword {
+			return // endcodespacerange, or a truncated block
+		}
+		lo, ok := cmapBytes(t)
+		if !ok {
+			continue
+		}
+		hi, ok := cmapBytes(next())
+		if ok && len(lo) == len(hi) && len(lo) > 0 && len(lo) <= 4 {
+			m.Codespace = append(m.Codespace, CodespaceRange{Low: lo, High: hi})
+		}
+	}
+}
+
+func (m *ToUnicodeCMap) parseBfChar(next func() Token) {
+	for {
+		t := next()
+		if t.Type == TokenEOF || t.Type == TokenKeyword {
+			return
+		}
+		src, ok := cmapBytes(t)
+		if !ok {
+			continue
+		}
+		dst, ok := cmapBytes(next())
+		if !ok {
+			continue
+		}
+		if code, ok := cmapCodeOf(src); ok {
+			m.chars[code] = string(utf16.Decode(utf16Units(dst)))
+		}
+	}
+}
+
+func (m *ToUnicodeCMap) parseBfRange(next func() Token) {
+	for {
+		t := next()
+		if t.Type == TokenEOF || t.Type == TokenKeyword {
+			return
+		}
+		loBytes, ok := cmapBytes(t)
+		if !ok {
+			continue
+		}
+		hiBytes, ok := cmapBytes(next())
+		if !ok {
+			continue
+		}
+		lo, okLo := cmapCodeOf(loBytes)
+		hi, okHi := cmapCodeOf(hiBytes)
+		valid := okLo && okHi && lo.n == hi.n && lo.code <= hi.code
+		r := cmapRange{n: lo.n, lo: lo.code, hi: hi.code}
+
+		dst := next()
+		switch dst.Type {
+		case TokenArrayStart:
+			for {
+				e := next()
+				if e.Type == TokenArrayEnd || e.Type == TokenEOF || e.Type == TokenKeyword {
+					break
+				}
+				if b, ok := cmapBytes(e); ok {
+					r.dsts = append(r.dsts, string(utf16.Decode(utf16Units(b))))
+				}
+			}
+		default:
+			b, ok := cmapBytes(dst)
+			if !ok || len(b) == 0 {
+				valid = false
+			}
+			r.base = utf16Units(b)
+		}
+		if valid {
+			m.ranges = append(m.ranges, r)
+		}
+	}
+}
+
+// rangeText returns the text r maps code to.
+func (r cmapRange) rangeText(code uint32) (string, bool) {
+	off := code - r.lo
+	if r.base == nil {
+		if off >= uint32(len(r.dsts)) {
+			return "", false
+		}
+		return r.dsts[off], true
+	}
+	units := append([]uint16(nil), r.base...)
+	units[len(units)-1] += uint16(off)
+	return string(utf16.Decode(units)), true
+}
+
+// Lookup returns the Unicode text code maps to, code being n bytes long. A
+// code without a mapping of that length falls back to a mapping of the same
+// value at another length, tolerating the common producer error of writing a
+// simple font's one-byte codes as <0041>.
+func (m *ToUnicodeCMap) Lookup(code uint32, n int) (string, bool) {
+	if s, ok := m.lookupLen(code, n); ok {
+		return s, true
+	}
+	for other := 1; other <= 4; other++ {
+		if other == n {
+			continue
+		}
+		if s, ok := m.lookupLen(code, other); ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+func (m *ToUnicodeCMap) lookupLen(code uint32, n int) (string, bool) {
+	if s, ok := m.chars[cmapCode{n: n, code: code}]; ok {
+		return s, true
+	}
+	// Later ranges override earlier ones, as later bfchar entries do.
+	for i := len(m.ranges) - 1; i >= 0; i-- {
+		r := m.ranges[i]
+		if r.n == n && code >= r.lo && code <= r.hi {
+			return r.rangeText(code)
+		}
+	}
+	return "", false
+}
+
+// Each calls fn for every mapping of m: each bfrange code (at most
+// maxCMapRangeExpansion of them) and then each bfchar code, so a bfchar entry
+// is reported after any range it overrides.
+func (m *ToUnicodeCMap) Each(fn func(code uint32, n int, text string)) {
+	budget := maxCMapRangeExpansion
+	for _, r := range m.ranges {
+		for off := uint32(0); off <= r.hi-r.lo && budget > 0; off++ {
+			budget--
+			if s, ok := r.rangeText(r.lo + off); ok {
+				fn(r.lo+off, r.n, s)
+			}
+		}
+	}
+	for c, s := range m.chars {
+		fn(c.code, c.n, s)
+	}
+}
@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"slices"
+	"testing"
+)
+
+const testToUnicodeCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+3 beginbfchar
+<0003> <0020>
+<0011> <D835DC00>
+<0012> <00660069>
+endbfchar
+2 beginbfrange
+<0024> <0026> <0041>
+<0030> <0032> [<0061> <0062>]
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+
+func TestParseToUnicodeCMap(t *testing.T) {
+	m := ParseToUnicodeCMap([]byte(testToUnicodeCMap))
+	if len(m.Codespace) != 1 || len(m.Codespace[0].Low) != 2 {
+		t.Errorf("Codespace = %v, want one two-byte range", m.Codespace)
+	}
+	for _, tc := range []struct {
+		code uint32
+		n    int
+		want string
+		ok   bool
+	}{
+		{0x0003, 2, " ", true},
+		{0x0011, 2, "\U0001D400", true}, // surrogate pair
+		{0x0012, 2, "fi", true},         // ligature
+		{0x0024, 2, "A", true},
+		{0x0026, 2, "C", true},
+		{0x0031, 2, "b", true},
+		{0x0032, 2, "", false}, // array shorter than the range
+		{0x0027, 2, "", false},
+		{0x24, 1, "A", true}, // one-byte lookup falls back to the two-byte code
+	} {
+		got, ok := m.Lookup(tc.code, tc.n)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Lookup(%04X, %d) = %q, %v; want %q, %v", tc.code, tc.n, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	n := 0
+	m.Each(func(uint32, int, string) { n++ })
+	if n != 8 {
+		t.Errorf("Each visited %d mappings, want 8", n)
+	}
+}
+
+func TestParseToUnicodeCMapMalformed(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":           "",
+		"truncated block": "1 beginbfchar <41>",
+		"reversed range":  "1 beginbfrange <42> <41> <0041> endbfrange",
+		"mixed lengths":   "1 beginbfrange <41> <0042> <0041> endbfrange",
+		"oversized code":  "1 beginbfchar <0102030405> <0041> endbfchar",
+		"stray tokens":    "1 beginbfchar { } ) <41> <0041> endbfchar",
+	} {
+		m := ParseToUnicodeCMap([]byte(data))
+		got, ok := m.Lookup(0x41, 1)
+		if want := name == "stray tokens"; ok != want {
+			t.Errorf("%s: Lookup(41) = %q, %v; want found = %v", name, got, ok, want)
+		}
+	}
+
+	// A huge range is kept lazily and Each expands only its head.
+	m := ParseToUnicodeCMap([]byte("1 beginbfrange <00000000> <FFFFFFFF> <0041> endbfrange"))
+	if s, ok := m.Lookup(0xFFFFFFFF, 4); !ok || s == "" {
+		t.Errorf("Lookup(FFFFFFFF) = %q, %v; want a mapping", s, ok)
+	}
+	n := 0
+	m.Each(func(uint32, int, string) { n++ })
+	if n != maxCMapRangeExpansion {
+		t.Errorf("Each visited %d codes, want %d", n, maxCMapRangeExpansion)
+	}
+}
+
+// TestUTF16Units covers the direct-value path hexToUnicode was tested on
+// before the parser moved here, and the one-byte destination it used to
+// reject and now zero-extends.
+func TestUTF16Units(t *testing.T) {
+	for _, tc := range []struct {
+		dst  []byte
+		want []uint16
+	}{
+		{[]byte{0x00, 0x41}, []uint16{0x0041}},
+		{[]byte{0x41}, []uint16{0x0041}},
+		{[]byte{0xD8, 0x35, 0xDC, 0x00}, []uint16{0xD835, 0xDC00}},
+	} {
+		if got := utf16Units(tc.dst); !slices.Equal(got, tc.want) {
+			t.Errorf("utf16Units(% X) = %04X, want %04X", tc.dst, got, tc.want)
+		}
+	}
+}
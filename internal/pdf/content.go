@@ -2,6 +2,7 @@ package pdf
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"sync"
@@ -29,17 +30,20 @@ func FilterNames(filter PDFValue) []string {
 
 var zlibReaderPool = sync.Pool{}
 
-// maxInflateOutput caps decoded output so a flate bomb cannot OOM. Var, not
-// const, only so tests can lower it.
-var maxInflateOutput int64 = 256 << 20
-
 // inflateBufPool holds *bytes.Buffer scratch space for InflateZlib, reused
 // across calls so its backing array grows to a working size once instead of
 // reallocating/copying on every decode (unlike a fresh io.ReadAll per call).
 var inflateBufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
 
-// inflateZlib decodes a zlib (FlateDecode/Fl) stream using a pooled decoder.
+// InflateZlib decodes a zlib (FlateDecode/Fl) stream using a pooled
+// decoder. Output past DefaultLimits().StreamBytes is a *LimitError, so a
+// flate bomb cannot exhaust memory.
 func InflateZlib(data []byte) ([]byte, error) {
+	return inflateZlib(data, defaultLimits.StreamBytes)
+}
+
+// inflateZlib is InflateZlib with a cap of max output bytes; 0 means none.
+func inflateZlib(data []byte, max int64) ([]byte, error) {
 	br := bytes.NewReader(data)
 
 	var zr io.ReadCloser
@@ -64,8 +68,18 @@ func InflateZlib(data []byte) ([]byte, error) {
 	if need := min(len(data)*4, maxInflatePrealloc); buf.Cap() < need {
 		buf.Grow(need - buf.Len())
 	}
-	_, err := buf.ReadFrom(io.LimitReader(zr, maxInflateOutput))
+	var src io.Reader = zr
+	if max > 0 {
+		// One byte past the cap tells an overlong stream from one that fills
+		// it exactly.
+		src = io.LimitReader(zr, max+1)
+	}
+	_, err := buf.ReadFrom(src)
 	zlibReaderPool.Put(zr)
+	if max > 0 && int64(buf.Len()) > max {
+		inflateBufPool.Put(buf)
+		return nil, &LimitError{Limit: "StreamBytes", Max: max}
+	}
 
 	// A truncated or checksum-broken zlib stream (common in malformed PDFs)
 	// still yields a usable prefix; return what inflated rather than
@@ -82,40 +96,6 @@ func InflateZlib(data []byte) ([]byte, error) {
 	return out, nil
 }
 
-// decodeStream returns the decoded bytes of a stream dictionary, applying
-// FlateDecode, ASCIIHexDecode, and ASCII85Decode filters as needed.
-func DecodeStream(dict PDFDict) ([]byte, error) {
-	if !dict.HasStream {
-		return nil, fmt.Errorf("object is not a stream")
-	}
-	data := dict.RawStream
-	for _, f := range FilterNames(dict.Entries["Filter"]) {
-		switch f {
-		case "FlateDecode", "Fl":
-			out, err := InflateZlib(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "ASCIIHexDecode", "AHx":
-			out, err := DecodeASCIIHex(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		case "ASCII85Decode", "A85":
-			out, err := DecodeASCII85(data)
-			if err != nil {
-				return nil, err
-			}
-			data = out
-		default:
-			return nil, fmt.Errorf("unsupported filter %q", f)
-		}
-	}
-	return data, nil
-}
-
 // StreamKey identifies a stream's raw (undecoded) bytes by content identity:
 // the RawStream slice's data pointer and length. A fixer that rewrites a
 // stream always assigns a fresh RawStream slice (SetStreamFlate et al.), so
@@ -266,12 +246,21 @@ type ContentScanner struct {
 	lex   *Lexer
 	stack []PDFValue
 	data  []byte
+	done  <-chan struct{}
 }
 
 func NewContentScanner(data []byte) *ContentScanner {
 	return &ContentScanner{lex: NewLexerBytes(data, 0), data: data}
 }
 
+// NewContentScannerContext is NewContentScanner for a scan that stops early,
+// before the next operator, once ctx is done.
+func NewContentScannerContext(ctx context.Context, data []byte) *ContentScanner {
+	cs := NewContentScanner(data)
+	cs.done = ctx.Done()
+	return cs
+}
+
 // ScannedOp is one content-stream operator paired with the operands collected
 // before it, as an owned (non-aliasing) snapshot of what ContentScanner.Scan
 // reports -- see TokenizeContent.
@@ -286,8 +275,12 @@ type ScannedOp struct {
 // here to remain valid after Scan returns (the PDFValues themselves, e.g. a
 // TJ array, are still shared by reference -- consumers only read them).
 func TokenizeContent(data []byte) []ScannedOp {
+	return tokenize(NewContentScanner(data))
+}
+
+func tokenize(cs *ContentScanner) []ScannedOp {
 	var ops []ScannedOp
-	NewContentScanner(data).Scan(func(op string, operands []PDFValue) {
+	cs.Scan(func(op string, operands []PDFValue) {
 		ops = append(ops, ScannedOp{Op: op, Operands: append([]PDFValue(nil), operands...)})
 	})
 	return ops
@@ -333,6 +326,13 @@ func (cs *ContentScanner) Scan(fn func(op string, operands []PDFValue)) {
 				cs.stack = append(cs.stack, dict)
 			}
 		case TokenKeyword:
+			if cs.done != nil {
+				select {
+				case <-cs.done:
+					return
+				default:
+				}
+			}
 			op := tok.Value
 			if op == "BI" {
 				cs.scanInlineImage(fn)
@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"context"
+	"time"
+)
+
+// SetContext makes ctx govern d's long-running work -- resolving the object
+// graph and tokenizing content streams -- and the verify and convert passes
+// run over d, which poll Err and stop early once ctx is done. It returns
+// the previous context, so a caller can restore it; nil means none. The
+// context is d's, not a pass's: SetContext must not be called while
+// another pass runs over d (see Reader).
+func (d *Reader) SetContext(ctx context.Context) context.Context {
+	prev := d.ctx
+	d.ctx = ctx
+	return prev
+}
+
+// Context returns the context set by SetContext, or context.Background if
+// there is none.
+func (d *Reader) Context() context.Context {
+	if d == nil || d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// Err reports why d's work should stop: the *LimitError for the first of
+// its Limits d exceeded, or else the error of d's context once it is done.
+// It returns nil before either happens, and does not block.
+func (d *Reader) Err() error {
+	if d == nil {
+		return nil
+	}
+	if err := d.limitErr.Load(); err != nil {
+		return err
+	}
+	if !d.deadline.IsZero() && time.Now().After(d.deadline) {
+		return d.exceed("Duration", int64(d.Limits().Duration))
+	}
+	if d.ctx == nil {
+		return nil
+	}
+	select {
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestReaderContext confirms SetContext returns the previous context, and
+// that Err reports a cancelled one and stops graph resolution.
+func TestReaderContext(t *testing.T) {
+	d := openLazyTestPDF(t)
+	if err := d.Err(); err != nil {
+		t.Fatalf("Err with no context = %v, want nil", err)
+	}
+	if d.Context() != context.Background() {
+		t.Error("Context with none set is not context.Background")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if prev := d.SetContext(ctx); prev != nil {
+		t.Errorf("SetContext returned %v, want nil", prev)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatalf("Err before cancel = %v, want nil", err)
+	}
+	cancel()
+	if err := d.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Err after cancel = %v, want context.Canceled", err)
+	}
+	if _, err := d.ResolveGraph(); !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveGraph after cancel = %v, want context.Canceled", err)
+	}
+	if _, err := d.ResolveDetached(PDFRef{ObjNum: 2}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveDetached after cancel = %v, want context.Canceled", err)
+	}
+	if prev := d.SetContext(nil); prev != ctx {
+		t.Error("SetContext did not return the context it replaced")
+	}
+	if _, err := d.ResolveGraph(); err != nil {
+		t.Errorf("ResolveGraph once the context is cleared: %v", err)
+	}
+}
+
+// TestContentScannerContext confirms a scan stops at the first operator
+// after its context is done, and a scan cut short is not cached.
+func TestContentScannerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var ops []string
+	NewContentScannerContext(ctx, []byte("q 1 0 0 1 0 0 cm Q q Q")).Scan(func(op string, _ []PDFValue) {
+		ops = append(ops, op)
+		if len(ops) == 2 {
+			cancel()
+		}
+	})
+	if got := strings.Join(ops, " "); got != "q cm" {
+		t.Errorf("scanned %q, want the scan stopped after cm", got)
+	}
+
+	d := openLazyTestPDF(t)
+	d.SetContext(ctx)
+	stream := PDFDict{Entries: map[string]PDFValue{"Length": PDFInteger(3)}, HasStream: true, RawStream: []byte("q Q")}
+	if _, err := d.ScanStreamCached(stream); !errors.Is(err, context.Canceled) {
+		t.Errorf("ScanStreamCached after cancel = %v, want context.Canceled", err)
+	}
+	if len(d.scanCache) != 0 {
+		t.Error("cancelled scan was cached")
+	}
+}
@@ -3,6 +3,7 @@ package pdf_test
 import (
 	"bytes"
 	"compress/zlib"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -297,7 +298,7 @@ func TestParseFunctionRejectsMalformed(t *testing.T) {
 }
 
 // TestCrasher_FlateBomb: a highly compressible stream inflated without bound
-// (fixed: maxInflateOutput cap).
+// (fixed: Limits.StreamBytes, reported as a *LimitError).
 func TestCrasher_FlateBomb(t *testing.T) {
 	restore := pdf.SetMaxInflateOutput(1024)
 	defer restore()
@@ -312,11 +313,9 @@ func TestCrasher_FlateBomb(t *testing.T) {
 	}
 
 	out, err := pdf.InflateZlib(compressed.Bytes())
-	if err != nil {
-		t.Fatalf("InflateZlib: %v", err)
-	}
-	if int64(len(out)) > 1024 {
-		t.Errorf("decoded %d bytes, want <= 1024", len(out))
+	var le *pdf.LimitError
+	if !errors.As(err, &le) || le.Limit != "StreamBytes" || le.Max != 1024 {
+		t.Fatalf("InflateZlib = %d bytes, %v; want a StreamBytes 1024 LimitError", len(out), err)
 	}
 }
 
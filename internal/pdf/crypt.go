@@ -0,0 +1,580 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Standard security handler decryption (ISO 32000-2 7.6.3 / 7.6.4),
+// revisions 2 to 6: RC4 with 40- to 128-bit keys, AES-128 and AES-256.
+// The file key is derived once, when the document is opened, from the
+// Encrypt dictionary and a password (or, for the public-key handler, a
+// recipient certificate; see crypt_pubsec.go); from then on every classically-stored
+// object is decrypted as parseClassicReference reads it, so the resolved
+// graph, RawStream and DecodeStream all see plaintext. Objects inside an
+// object stream are never decrypted individually -- the object stream
+// itself was -- and neither are cross-reference streams or the Encrypt
+// dictionary.
+
+// ErrIncorrectPassword is returned when opening a document protected by the
+// Standard security handler with a password that is neither its user nor
+// its owner password. Open tries the empty user password.
+var ErrIncorrectPassword = errors.New("incorrect password for encrypted document")
+
+// passwordPadding is the 32-byte string padding or replacing R2-R4
+// passwords (Algorithm 2, step a).
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// cryptMethod is a crypt filter's method (CFM): how strings and streams it
+// covers are encrypted.
+type cryptMethod int
+
+const (
+	cryptNone  cryptMethod = iota // Identity, or CFM None
+	cryptRC4                      // V2
+	cryptAESV2                    // AES-128-CBC
+	cryptAESV3                    // AES-256-CBC
+)
+
+// securityHandler decrypts a document's objects with its file key.
+type securityHandler struct {
+	key []byte
+	// r is the Standard handler revision, p the permission flags.
+	r int
+	p int32
+	// owner records whether the password given was the owner password.
+	owner bool
+
+	// stm and str are the default methods for streams and strings; filters
+	// names the document's crypt filters for streams selecting one through
+	// a Crypt filter.
+	stm, str cryptMethod
+	filters  map[string]cryptMethod
+
+	encryptMetadata bool
+	// encryptObjNum is the Encrypt dictionary's object number, 0 if direct.
+	encryptObjNum int
+}
+
+// credential authenticates a reader against an encrypted document's
+// security handler: a password for the Standard handler, or a recipient
+// certificate and its private key for the public-key handler.
+type credential struct {
+	password string
+	cert     *x509.Certificate
+	key      crypto.Decrypter
+}
+
+// initSecurity sets up decryption when the trailer has an Encrypt
+// dictionary for the Standard or the public-key security handler,
+// authenticating with cred. An Encrypt dictionary this package cannot
+// interpret (another handler, a malformed one) leaves the document as it
+// is, undecrypted, for the verifier to report.
+func (d *Reader) initSecurity(cred credential) error {
+	trailer := d.EffectiveTrailer()
+	encRef := trailer.Entries["Encrypt"]
+	if encRef == nil {
+		return nil
+	}
+	v, err := d.resolveShallow(encRef)
+	if err != nil {
+		return nil
+	}
+	enc, ok := v.(PDFDict)
+	if !ok {
+		return nil
+	}
+	var h *securityHandler
+	switch f, _ := enc.Entries["Filter"].(PDFName); f.Value {
+	case "Standard":
+		h, err = newStandardHandler(enc, fileID(trailer), cred.password)
+	case "Adobe.PubSec":
+		h, err = newPubSecHandler(enc, cred.cert, cred.key)
+	default:
+		return nil
+	}
+	if err != nil {
+		if errors.Is(err, ErrIncorrectPassword) || errors.Is(err, ErrNotRecipient) {
+			return err
+		}
+		return nil
+	}
+	if ref, ok := encRef.(PDFRef); ok {
+		h.encryptObjNum = ref.ObjNum
+	}
+	d.crypt = h
+	return nil
+}
+
+// Encrypted reports whether the document is encrypted with the Standard or
+// the public-key security handler and is being decrypted as it is read.
+func (d *Reader) Encrypted() bool { return d.crypt != nil }
+
+// fileID returns the first element of the trailer's ID array as bytes.
+func fileID(trailer PDFDict) []byte {
+	ids, _ := trailer.Entries["ID"].(PDFArray)
+	if len(ids) == 0 {
+		return nil
+	}
+	return stringBytes(ids[0])
+}
+
+// stringBytes returns the bytes of a literal or hexadecimal string.
+func stringBytes(v PDFValue) []byte {
+	switch s := v.(type) {
+	case PDFString:
+		return []byte(s.Value)
+	case PDFHexString:
+		return DecodePDFHexStringBytes(s.Value)
+	}
+	return nil
+}
+
+// newStandardHandler derives the file key from the Standard security
+// handler's Encrypt dictionary enc, authenticating password.
+func newStandardHandler(enc PDFDict, id []byte, password string) (*securityHandler, error) {
+	v := DictInt(enc, "V", 0)
+	r := DictInt(enc, "R", 0)
+	o := stringBytes(enc.Entries["O"])
+	u := stringBytes(enc.Entries["U"])
+	p, ok := enc.Entries["P"].(PDFInteger)
+	if !ok {
+		return nil, errors.New("Encrypt dictionary lacks P")
+	}
+	h := &securityHandler{r: r, p: int32(p), encryptMetadata: true}
+	if b, ok := enc.Entries["EncryptMetadata"].(PDFBoolean); ok {
+		h.encryptMetadata = bool(b)
+	}
+
+	switch v {
+	case 1, 2:
+		h.stm, h.str = cryptRC4, cryptRC4
+	case 4, 5:
+		if err := h.readCryptFilters(enc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption algorithm V %d", v)
+	}
+
+	switch r {
+	case 2, 3, 4:
+		if len(o) < 32 || len(u) < 32 {
+			return nil, errors.New("Encrypt dictionary O or U is too short")
+		}
+		n := 5
+		if r >= 3 {
+			n = DictInt(enc, "Length", 40) / 8
+			if v == 4 {
+				n = 16
+			}
+			if n < 5 || n > 16 {
+				return nil, fmt.Errorf("invalid key length %d bytes", n)
+			}
+		}
+		pw := padPassword(pdfDocPassword(password))
+		if key := h.legacyFileKey(pw, o[:32], id, n); h.userKeyMatches(key, u, id) {
+			h.key = key
+			return h, nil
+		}
+		// Not the user password: recover the user password from O.
+		user := h.ownerDecryptO(pw, o[:32], n)
+		if key := h.legacyFileKey(user, o[:32], id, n); h.userKeyMatches(key, u, id) {
+			h.key, h.owner = key, true
+			return h, nil
+		}
+		return nil, ErrIncorrectPassword
+	case 5, 6:
+		if len(o) < 48 || len(u) < 48 {
+			return nil, errors.New("Encrypt dictionary O or U is too short")
+		}
+		oe := stringBytes(enc.Entries["OE"])
+		ue := stringBytes(enc.Entries["UE"])
+		if len(oe) < 32 || len(ue) < 32 {
+			return nil, errors.New("Encrypt dictionary OE or UE is too short")
+		}
+		pw := []byte(password)
+		if len(pw) > 127 {
+			pw = pw[:127]
+		}
+		pwHash := hashR6
+		if r == 5 {
+			pwHash = hashR5
+		}
+		if bytes.Equal(pwHash(pw, o[32:40], u[:48]), o[:32]) {
+			key, err := aes256UnwrapKey(pwHash(pw, o[40:48], u[:48]), oe[:32])
+			if err != nil {
+				return nil, err
+			}
+			h.key, h.owner = key, true
+			return h, nil
+		}
+		if bytes.Equal(pwHash(pw, u[32:40], nil), u[:32]) {
+			key, err := aes256UnwrapKey(pwHash(pw, u[40:48], nil), ue[:32])
+			if err != nil {
+				return nil, err
+			}
+			h.key = key
+			return h, nil
+		}
+		return nil, ErrIncorrectPassword
+	}
+	return nil, fmt.Errorf("unsupported security handler revision R %d", r)
+}
+
+// readCryptFilters reads the CF, StmF and StrF entries of a V4 or V5
+// Encrypt dictionary.
+func (h *securityHandler) readCryptFilters(enc PDFDict) error {
+	h.filters = map[string]cryptMethod{"Identity": cryptNone}
+	cf, _ := enc.Entries["CF"].(PDFDict)
+	for name, v := range cf.Entries {
+		fd, ok := v.(PDFDict)
+		if !ok {
+			continue
+		}
+		switch m, _ := fd.Entries["CFM"].(PDFName); m.Value {
+		case "V2":
+			h.filters[name] = cryptRC4
+		case "AESV2":
+			h.filters[name] = cryptAESV2
+		case "AESV3":
+			h.filters[name] = cryptAESV3
+		case "", "None":
+			h.filters[name] = cryptNone
+		default:
+			return fmt.Errorf("unsupported crypt filter method %s", m.Value)
+		}
+	}
+	pick := func(key string) (cryptMethod, error) {
+		name, ok := enc.Entries[key].(PDFName)
+		if !ok {
+			return cryptNone, nil
+		}
+		m, ok := h.filters[name.Value]
+		if !ok {
+			return cryptNone, fmt.Errorf("%s names undefined crypt filter %s", key, name.Value)
+		}
+		return m, nil
+	}
+	var err error
+	if h.stm, err = pick("StmF"); err != nil {
+		return err
+	}
+	h.str, err = pick("StrF")
+	return err
+}
+
+// pdfDocPassword encodes an R2-R4 password in PDFDocEncoding, dropping
+// characters it cannot represent.
+func pdfDocPassword(password string) []byte {
+	out := make([]byte, 0, len(password))
+	for _, r := range password {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		default:
+			for i, c := range pdfDocEncoding80s {
+				if c == r {
+					out = append(out, byte(0x80+i))
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// padPassword truncates or pads pw to 32 bytes (Algorithm 2, step a).
+func padPassword(pw []byte) []byte {
+	out := make([]byte, 32)
+	n := copy(out, pw)
+	copy(out[n:], passwordPadding)
+	return out
+}
+
+// legacyFileKey computes an R2-R4 file key of n bytes from a padded
+// password (Algorithm 2).
+func (h *securityHandler) legacyFileKey(padded, o, id []byte, n int) []byte {
+	m := md5.New()
+	m.Write(padded)
+	m.Write(o)
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], uint32(h.p))
+	m.Write(p[:])
+	m.Write(id)
+	if h.r >= 4 && !h.encryptMetadata {
+		m.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+	key := m.Sum(nil)
+	if h.r >= 3 {
+		for range 50 {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+	return key[:n]
+}
+
+// userKeyMatches reports whether key, derived from a candidate user
+// password, reproduces the Encrypt dictionary's U value (Algorithms 4-6).
+func (h *securityHandler) userKeyMatches(key, u, id []byte) bool {
+	if h.r == 2 {
+		return bytes.Equal(rc4Crypt(key, passwordPadding), u[:32])
+	}
+	m := md5.New()
+	m.Write(passwordPadding)
+	m.Write(id)
+	out := m.Sum(nil)
+	for i := range 20 {
+		out = rc4Crypt(xorKey(key, byte(i)), out)
+	}
+	return bytes.Equal(out[:16], u[:16])
+}
+
+// ownerDecryptO recovers the padded user password from O with the owner
+// password padded (Algorithm 7).
+func (h *securityHandler) ownerDecryptO(padded, o []byte, n int) []byte {
+	sum := md5.Sum(padded)
+	key := sum[:]
+	if h.r >= 3 {
+		for range 50 {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	key = key[:n]
+	if h.r == 2 {
+		return rc4Crypt(key, o)
+	}
+	out := o
+	for i := 19; i >= 0; i-- {
+		out = rc4Crypt(xorKey(key, byte(i)), out)
+	}
+	return out
+}
+
+// xorKey returns key with every byte XORed with b.
+func xorKey(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i, k := range key {
+		out[i] = k ^ b
+	}
+	return out
+}
+
+// rc4Crypt returns data encrypted (or decrypted) with RC4 under key.
+func rc4Crypt(key, data []byte) []byte {
+	c, err := rc4.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// hashR5 is the R5 password hash: SHA-256 of password, salt and udata.
+func hashR5(pw, salt, udata []byte) []byte {
+	s := sha256.New()
+	s.Write(pw)
+	s.Write(salt)
+	s.Write(udata)
+	return s.Sum(nil)
+}
+
+// hashR6 is the R6 password hash (Algorithm 2.B).
+func hashR6(pw, salt, udata []byte) []byte {
+	k := hashR5(pw, salt, udata)
+	var e []byte
+	for i := 0; i < 64 || int(e[len(e)-1]) > i-32; i++ {
+		k1 := make([]byte, 0, 64*(len(pw)+len(k)+len(udata)))
+		for range 64 {
+			k1 = append(k1, pw...)
+			k1 = append(k1, k...)
+			k1 = append(k1, udata...)
+		}
+		block, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		var sum int
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var hf hash.Hash
+		switch sum % 3 {
+		case 0:
+			hf = sha256.New()
+		case 1:
+			hf = sha512.New384()
+		default:
+			hf = sha512.New()
+		}
+		hf.Write(e)
+		k = hf.Sum(nil)
+	}
+	return k[:32]
+}
+
+// aes256UnwrapKey decrypts an R5/R6 OE or UE value into the file key:
+// AES-256 in CBC mode with a zero IV and no padding.
+func aes256UnwrapKey(key, wrapped []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, wrapped[:32])
+	return out, nil
+}
+
+// objectKey returns the key for object ref's strings and streams under
+// method m (Algorithm 1): AES-256 uses the file key as is.
+func (h *securityHandler) objectKey(ref PDFRef, m cryptMethod) []byte {
+	if m == cryptAESV3 {
+		return h.key
+	}
+	buf := make([]byte, 0, len(h.key)+9)
+	buf = append(buf, h.key...)
+	buf = append(buf, byte(ref.ObjNum), byte(ref.ObjNum>>8), byte(ref.ObjNum>>16),
+		byte(ref.GenNum), byte(ref.GenNum>>8))
+	if m == cryptAESV2 {
+		buf = append(buf, "sAlT"...)
+	}
+	sum := md5.Sum(buf)
+	return sum[:min(len(h.key)+5, 16)]
+}
+
+// decrypt returns data, encrypted under method m for object ref, in
+// plaintext.
+func (h *securityHandler) decrypt(ref PDFRef, m cryptMethod, data []byte) ([]byte, error) {
+	switch m {
+	case cryptRC4:
+		return rc4Crypt(h.objectKey(ref, m), data), nil
+	case cryptAESV2, cryptAESV3:
+		return aesCBCDecrypt(h.objectKey(ref, m), data)
+	}
+	return data, nil
+}
+
+// aesCBCDecrypt decrypts data, a 16-byte IV followed by AES-CBC ciphertext
+// with PKCS#5 padding.
+func aesCBCDecrypt(key, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("AES ciphertext length %d is not a positive multiple of the block size", len(data))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	if pad := int(out[len(out)-1]); pad >= 1 && pad <= aes.BlockSize && pad <= len(out) {
+		out = out[:len(out)-pad]
+	}
+	return out, nil
+}
+
+// decryptObject decrypts the strings and stream of v, the classically
+// stored object ref.
+func (h *securityHandler) decryptObject(ref PDFRef, v PDFValue) (PDFValue, error) {
+	if ref.ObjNum == h.encryptObjNum && h.encryptObjNum != 0 {
+		return v, nil
+	}
+	if dict, ok := v.(PDFDict); ok {
+		if t, _ := dict.Entries["Type"].(PDFName); t.Value == "XRef" {
+			return v, nil
+		}
+		if dict.HasStream {
+			m := h.streamMethod(dict)
+			if t, _ := dict.Entries["Type"].(PDFName); t.Value == "Metadata" && !h.encryptMetadata {
+				m = cryptNone
+			}
+			if m != cryptNone {
+				data, err := h.decrypt(ref, m, dict.RawStream)
+				if err != nil {
+					return nil, fmt.Errorf("object %d: %w", ref.ObjNum, err)
+				}
+				dict.RawStream = data
+			}
+		}
+		v = dict
+	}
+	return h.decryptStrings(ref, v, 0), nil
+}
+
+// streamMethod returns the method a stream is encrypted with: that of the
+// crypt filter its Crypt filter names, if any, else the StmF default.
+func (h *securityHandler) streamMethod(dict PDFDict) cryptMethod {
+	filters := FilterNames(dict.Entries["Filter"])
+	for i, f := range filters {
+		if f != "Crypt" {
+			continue
+		}
+		name := "Identity"
+		var parms PDFValue = dict.Entries["DecodeParms"]
+		if arr, ok := parms.(PDFArray); ok {
+			parms = nil
+			if i < len(arr) {
+				parms = arr[i]
+			}
+		}
+		if p, ok := parms.(PDFDict); ok {
+			if n, ok := p.Entries["Name"].(PDFName); ok {
+				name = n.Value
+			}
+		}
+		return h.filters[name]
+	}
+	return h.stm
+}
+
+// decryptStrings decrypts every string within v in place. The Contents of
+// a signature dictionary is exempt (ISO 32000-2 7.6.2).
+func (h *securityHandler) decryptStrings(ref PDFRef, v PDFValue, depth int) PDFValue {
+	if h.str == cryptNone || depth > maxParseDepth {
+		return v
+	}
+	switch x := v.(type) {
+	case PDFString:
+		if out, err := h.decrypt(ref, h.str, []byte(x.Value)); err == nil {
+			return PDFString{Value: string(out)}
+		}
+	case PDFHexString:
+		if out, err := h.decrypt(ref, h.str, DecodePDFHexStringBytes(x.Value)); err == nil {
+			return PDFHexString{Value: strings.ToUpper(hex.EncodeToString(out))}
+		}
+	case PDFArray:
+		for i := range x {
+			x[i] = h.decryptStrings(ref, x[i], depth+1)
+		}
+	case PDFDict:
+		t, _ := x.Entries["Type"].(PDFName)
+		sig := t.Value == "Sig" || t.Value == "DocTimeStamp"
+		for k, e := range x.Entries {
+			if k == "_ref" || (sig && k == "Contents") {
+				continue
+			}
+			x.Entries[k] = h.decryptStrings(ref, e, depth+1)
+		}
+	}
+	return v
+}
@@ -0,0 +1,271 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Public-key security handler decryption (Adobe.PubSec, ISO 32000-2
+// 7.6.5). Each Recipients string is a CMS EnvelopedData holding, for the
+// recipients it lists, a 20-byte seed and the 4-byte permissions. The seed
+// is unwrapped with a recipient's private key, and the file key is the
+// SHA-1 (SHA-256 for AES-256) digest of the seed and every Recipients
+// string. Decryption from there is the Standard handler's.
+
+// ErrNotRecipient is returned when opening a document encrypted with the
+// public-key security handler without a certificate and key among its
+// recipients.
+var ErrNotRecipient = errors.New("certificate is not a recipient of the encrypted document")
+
+var (
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidDESEDE3CBC    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// cmsContentInfo, cmsEnvelopedData, cmsKeyTransRecipient and
+// cmsIssuerAndSerial are the parts of RFC 5652 a Recipients string uses.
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type cmsEnvelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo cmsEncryptedContentInfo
+}
+
+type cmsEncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type cmsKeyTransRecipient struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type cmsIssuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+// newPubSecHandler derives the file key from the public-key handler's
+// Encrypt dictionary enc with the recipient certificate cert and its key.
+func newPubSecHandler(enc PDFDict, cert *x509.Certificate, key crypto.Decrypter) (*securityHandler, error) {
+	v := DictInt(enc, "V", 0)
+	h := &securityHandler{encryptMetadata: true}
+	if b, ok := enc.Entries["EncryptMetadata"].(PDFBoolean); ok {
+		h.encryptMetadata = bool(b)
+	}
+
+	var recipients PDFValue
+	n := DictInt(enc, "Length", 40) / 8
+	switch v {
+	case 1, 2:
+		h.stm, h.str = cryptRC4, cryptRC4
+		recipients = enc.Entries["Recipients"]
+	case 4, 5:
+		if err := h.readCryptFilters(enc); err != nil {
+			return nil, err
+		}
+		// The file key comes from the default stream filter's recipients.
+		cf, _ := enc.Entries["CF"].(PDFDict)
+		name, _ := enc.Entries["StmF"].(PDFName)
+		fd, ok := cf.Entries[name.Value].(PDFDict)
+		if !ok {
+			return nil, fmt.Errorf("StmF names no crypt filter with recipients")
+		}
+		recipients = fd.Entries["Recipients"]
+		switch h.stm {
+		case cryptAESV2:
+			n = 16
+		case cryptAESV3:
+			n = 32
+		default:
+			// The public-key handler gives crypt filter lengths in bits.
+			if l := DictInt(fd, "Length", 128); l > 32 {
+				n = l / 8
+			} else {
+				n = l
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption algorithm V %d", v)
+	}
+	if n < 5 || n > 32 {
+		return nil, fmt.Errorf("invalid key length %d bytes", n)
+	}
+
+	var envelopes [][]byte
+	switch r := recipients.(type) {
+	case PDFArray:
+		for _, e := range r {
+			if b := stringBytes(e); b != nil {
+				envelopes = append(envelopes, b)
+			}
+		}
+	case PDFString, PDFHexString:
+		envelopes = append(envelopes, stringBytes(r))
+	}
+	if len(envelopes) == 0 {
+		return nil, errors.New("Encrypt dictionary has no Recipients")
+	}
+	if cert == nil || key == nil {
+		return nil, ErrNotRecipient
+	}
+
+	var seed []byte
+	for _, env := range envelopes {
+		content, err := openEnvelope(env, cert, key)
+		if errors.Is(err, ErrNotRecipient) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(content) < 24 {
+			return nil, fmt.Errorf("recipient seed is %d bytes, want 24", len(content))
+		}
+		seed = content[:20]
+		h.p = int32(binary.BigEndian.Uint32(content[20:24]))
+		break
+	}
+	if seed == nil {
+		return nil, ErrNotRecipient
+	}
+
+	digest := sha1.New()
+	if h.stm == cryptAESV3 {
+		digest = sha256.New()
+	}
+	digest.Write(seed)
+	for _, env := range envelopes {
+		digest.Write(env)
+	}
+	if !h.encryptMetadata {
+		digest.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+	sum := digest.Sum(nil)
+	h.key = sum[:min(n, len(sum))]
+	return h, nil
+}
+
+// openEnvelope decrypts the CMS EnvelopedData env for the recipient cert,
+// returning ErrNotRecipient if it has no key transport entry for cert.
+func openEnvelope(env []byte, cert *x509.Certificate, key crypto.Decrypter) ([]byte, error) {
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(env, &ci); err != nil {
+		return nil, fmt.Errorf("Recipients entry: %w", err)
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("Recipients entry is not CMS EnvelopedData")
+	}
+	var ed cmsEnvelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, fmt.Errorf("Recipients entry: %w", err)
+	}
+
+	var cek []byte
+	for _, raw := range ed.RecipientInfos {
+		var ktri cmsKeyTransRecipient
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ktri); err != nil {
+			// Key agreement and other recipient kinds.
+			continue
+		}
+		if !recipientMatches(ktri.RID, cert) {
+			continue
+		}
+		if !ktri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAEncryption) {
+			return nil, fmt.Errorf("unsupported key transport algorithm %v", ktri.KeyEncryptionAlgorithm.Algorithm)
+		}
+		k, err := key.Decrypt(rand.Reader, ktri.EncryptedKey, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unwrap recipient key: %w", err)
+		}
+		cek = k
+		break
+	}
+	if cek == nil {
+		return nil, ErrNotRecipient
+	}
+
+	eci := ed.EncryptedContentInfo
+	ciphertext := eci.EncryptedContent.Bytes
+	if eci.EncryptedContent.IsCompound {
+		// Constructed (segmented) OCTET STRING: concatenate the segments.
+		var buf []byte
+		rest := ciphertext
+		for len(rest) > 0 {
+			var seg []byte
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &seg); err != nil {
+				return nil, fmt.Errorf("Recipients entry: %w", err)
+			}
+			buf = append(buf, seg...)
+		}
+		ciphertext = buf
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("Recipients entry: content encryption IV: %w", err)
+	}
+
+	var block cipher.Block
+	var err error
+	alg := eci.ContentEncryptionAlgorithm.Algorithm
+	switch {
+	case alg.Equal(oidDESEDE3CBC):
+		block, err = des.NewTripleDESCipher(cek)
+	case alg.Equal(oidAES128CBC), alg.Equal(oidAES192CBC), alg.Equal(oidAES256CBC):
+		block, err = aes.NewCipher(cek)
+	default:
+		return nil, fmt.Errorf("unsupported content encryption algorithm %v", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("Recipients entry: malformed encrypted content")
+	}
+	out := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+	if pad := int(out[len(out)-1]); pad >= 1 && pad <= block.BlockSize() {
+		out = out[:len(out)-pad]
+	}
+	return out, nil
+}
+
+// recipientMatches reports whether a RecipientIdentifier names cert: by
+// issuer and serial number, or by subject key identifier ([0]).
+func recipientMatches(rid asn1.RawValue, cert *x509.Certificate) bool {
+	if rid.Class == asn1.ClassContextSpecific && rid.Tag == 0 {
+		return len(cert.SubjectKeyId) > 0 && bytes.Equal(rid.Bytes, cert.SubjectKeyId)
+	}
+	var ias cmsIssuerAndSerial
+	if _, err := asn1.Unmarshal(rid.FullBytes, &ias); err != nil || ias.Serial == nil {
+		return false
+	}
+	return bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.Serial.Cmp(cert.SerialNumber) == 0
+}
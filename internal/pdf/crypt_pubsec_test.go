@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testRecipient returns a self-signed certificate and its RSA key.
+func testRecipient(t *testing.T, serial int64) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("recipient %d", serial)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// testEnvelope returns a CMS EnvelopedData carrying content for the given
+// recipients, encrypted with AES-256-CBC.
+func testEnvelope(t *testing.T, content []byte, recipients ...*x509.Certificate) []byte {
+	t.Helper()
+	cek := bytes.Repeat([]byte{0x42}, 32)
+	iv := bytes.Repeat([]byte{0x24}, aes.BlockSize)
+	pad := aes.BlockSize - len(content)%aes.BlockSize
+	plain := append(append([]byte{}, content...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	block, _ := aes.NewCipher(cek)
+	ciphertext := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plain)
+
+	var infos []asn1.RawValue
+	for _, cert := range recipients {
+		wrapped, err := rsa.EncryptPKCS1v15(rand.Reader, cert.PublicKey.(*rsa.PublicKey), cek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rid, err := asn1.Marshal(cmsIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber})
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := asn1.Marshal(cmsKeyTransRecipient{
+			RID:                    asn1.RawValue{FullBytes: rid},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedKey:           wrapped,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, asn1.RawValue{FullBytes: info})
+	}
+	ivDER, _ := asn1.Marshal(iv)
+	ed, err := asn1.Marshal(cmsEnvelopedData{
+		RecipientInfos: infos,
+		EncryptedContentInfo: cmsEncryptedContentInfo{
+			ContentType:                asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1},
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivDER}},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci, err := asn1.Marshal(cmsContentInfo{ContentType: oidEnvelopedData, Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: ed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ci
+}
+
+func TestPublicKeySecurityHandler(t *testing.T) {
+	alice, aliceKey := testRecipient(t, 1)
+	bob, bobKey := testRecipient(t, 2)
+	eve, eveKey := testRecipient(t, 3)
+
+	seed := bytes.Repeat([]byte{0x07}, 20)
+	perms := []byte{0xFF, 0xFF, 0xF0, 0xC4} // big-endian P = -3900
+	// One envelope per recipient, as Acrobat writes them.
+	envelopes := [][]byte{
+		testEnvelope(t, append(append([]byte{}, seed...), perms...), alice),
+		testEnvelope(t, append(append([]byte{}, seed...), perms...), bob),
+	}
+	recipients := fmt.Sprintf("[<%X> <%X>]", envelopes[0], envelopes[1])
+
+	for _, tc := range []struct {
+		name     string
+		v        int
+		method   cryptMethod
+		encDict  string
+		identity bool
+	}{
+		{"RC4 128-bit s4", 2, cryptRC4,
+			"<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s4 /V 2 /Length 128 /Recipients " + recipients + " >>", false},
+		{"AES-128 s5", 4, cryptAESV2,
+			"<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 4 /CF << /DefaultCryptFilter << /CFM /AESV2 /Length 128 /Recipients " + recipients + " >> >> /StmF /DefaultCryptFilter /StrF /DefaultCryptFilter >>", true},
+		{"AES-256 s5", 5, cryptAESV3,
+			"<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 5 /CF << /DefaultCryptFilter << /CFM /AESV3 /Length 256 /Recipients " + recipients + " >> >> /StmF /DefaultCryptFilter /StrF /DefaultCryptFilter >>", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The file key, derived independently of newPubSecHandler.
+			var key []byte
+			if tc.method == cryptAESV3 {
+				s := sha256.New()
+				s.Write(seed)
+				s.Write(envelopes[0])
+				s.Write(envelopes[1])
+				key = s.Sum(nil)
+			} else {
+				s := sha1.New()
+				s.Write(seed)
+				s.Write(envelopes[0])
+				s.Write(envelopes[1])
+				key = s.Sum(nil)[:16]
+			}
+			h := &securityHandler{key: key, stm: tc.method, str: tc.method, encryptMetadata: true}
+			data := buildEncryptedPDF(h, tc.encDict, tc.identity)
+
+			for _, r := range []struct {
+				cert *x509.Certificate
+				key  *rsa.PrivateKey
+			}{{alice, aliceKey}, {bob, bobKey}} {
+				d, err := OpenBytesWithCertificate(data, r.cert, r.key)
+				if err != nil {
+					t.Fatalf("%s: OpenBytesWithCertificate: %v", r.cert.Subject.CommonName, err)
+				}
+				if !d.Encrypted() || d.crypt.p != -3900 {
+					t.Errorf("%s: Encrypted = %v, P = %d; want true, -3900", r.cert.Subject.CommonName, d.Encrypted(), d.crypt.p)
+				}
+				checkDecrypted(t, d, tc.identity)
+				d.Close()
+			}
+
+			if _, err := OpenBytesWithCertificate(data, eve, eveKey); !errors.Is(err, ErrNotRecipient) {
+				t.Errorf("non-recipient: err = %v, want ErrNotRecipient", err)
+			}
+			if _, err := OpenBytes(data); !errors.Is(err, ErrNotRecipient) {
+				t.Errorf("no certificate: err = %v, want ErrNotRecipient", err)
+			}
+		})
+	}
+}
+
+// TestOpenPublicKeyFile opens an AES-256 public-key file written outside
+// this package: its Recipients envelope was made by openssl cms for the
+// testdata certificate, and its strings and streams encrypted with the
+// file key a SHA-256 of the seed and envelope computed with Python's
+// hashlib.
+func TestOpenPublicKeyFile(t *testing.T) {
+	pair, err := tls.LoadX509KeyPair("testdata/recipient.crt", "testdata/recipient.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenWithCertificate("testdata/aes256-pubsec.pdf", cert, pair.PrivateKey.(crypto.Decrypter))
+	if err != nil {
+		t.Fatalf("OpenWithCertificate: %v", err)
+	}
+	defer d.Close()
+	wantKey, _ := hex.DecodeString("A65A5E088D9F5B2610A3F68CDE3D62460E762B50AF1F31D3DB847A8642982E7D")
+	if !bytes.Equal(d.crypt.key, wantKey) || d.crypt.p != -3904 {
+		t.Errorf("file key = %X, P = %d; want %X, -3904", d.crypt.key, d.crypt.p, wantKey)
+	}
+	checkKnownAnswerFile(t, d, "PubSec known-answer file", "(AES-256 PubSec) Tj")
+}
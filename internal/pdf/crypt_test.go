@@ -0,0 +1,388 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testEncryption describes a Standard security handler configuration for
+// encryptedTestPDF.
+type testEncryption struct {
+	v, r, length    int
+	cfm             string // V4/V5 crypt filter method
+	user, owner     string
+	encryptMetadata bool
+}
+
+var testFileID = []byte("0123456789abcdef")
+
+const (
+	testTitle   = "Encrypted title"
+	testContent = "BT /F1 12 Tf (secret) Tj ET"
+	testXMP     = "<?xpacket begin=\"\"?><x:xmpmeta xmlns:x=\"adobe:ns:meta/\"/><?xpacket end=\"r\"?>"
+)
+
+// encryptedTestPDF returns a document encrypted per e: a catalog, an empty
+// page tree, an Info dictionary with a Title, a Metadata stream, a content
+// stream and a stream selecting the Identity crypt filter (V4/V5 only).
+func encryptedTestPDF(t *testing.T, e testEncryption) []byte {
+	t.Helper()
+	h := &securityHandler{r: e.r, p: -4, encryptMetadata: e.encryptMetadata}
+	var encDict string
+	switch e.r {
+	case 2, 3, 4:
+		n := e.length / 8
+		o := testOwnerEntry(e.r, n, e.user, e.owner)
+		h.key = h.legacyFileKey(padPassword([]byte(e.user)), o, testFileID, n)
+		u := testUserEntry(h, testFileID)
+		encDict = fmt.Sprintf("/V %d /R %d /Length %d /O <%X> /U <%X>", e.v, e.r, e.length, o, u)
+	case 5, 6:
+		pwHash := hashR6
+		if e.r == 5 {
+			pwHash = hashR5
+		}
+		h.key = bytes.Repeat([]byte{0x5A}, 32)
+		uSalts := []byte("uvalsaltukeysalt")
+		u := append(pwHash([]byte(e.user), uSalts[:8], nil), uSalts...)
+		ue := aesWrap(pwHash([]byte(e.user), uSalts[8:], nil), h.key)
+		oSalts := []byte("ovalsaltokeysalt")
+		o := append(pwHash([]byte(e.owner), oSalts[:8], u), oSalts...)
+		oe := aesWrap(pwHash([]byte(e.owner), oSalts[8:], u), h.key)
+		encDict = fmt.Sprintf("/V 5 /R %d /Length 256 /O <%X> /U <%X> /OE <%X> /UE <%X>", e.r, o, u, oe, ue)
+	}
+	if e.v >= 4 {
+		encDict += fmt.Sprintf(" /CF << /StdCF << /CFM /%s /Length %d >> >> /StmF /StdCF /StrF /StdCF", e.cfm, e.length/8)
+		if !e.encryptMetadata {
+			encDict += " /EncryptMetadata false"
+		}
+	}
+	switch e.cfm {
+	case "AESV2":
+		h.stm, h.str = cryptAESV2, cryptAESV2
+	case "AESV3":
+		h.stm, h.str = cryptAESV3, cryptAESV3
+	default:
+		h.stm, h.str = cryptRC4, cryptRC4
+	}
+	return buildEncryptedPDF(h, "<< /Filter /Standard "+encDict+" /P -4 >>", e.v >= 4)
+}
+
+// buildEncryptedPDF returns the encryptedTestPDF document encrypted with
+// h's key and methods, with encDict as its Encrypt dictionary; identity
+// adds the stream selecting the Identity crypt filter.
+func buildEncryptedPDF(h *securityHandler, encDict string, identity bool) []byte {
+	enc := func(objNum int, m cryptMethod, data string) []byte {
+		return testEncrypt(h, PDFRef{ObjNum: objNum}, m, []byte(data))
+	}
+	stream := func(dict string, data []byte) string {
+		return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+	}
+	metaMethod := h.stm
+	if !h.encryptMetadata {
+		metaMethod = cryptNone
+	}
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 4 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+		fmt.Sprintf("<< /Title <%X> >>", enc(3, h.str, testTitle)),
+		stream("/Type /Metadata /Subtype /XML", enc(4, metaMethod, testXMP)),
+		stream("", enc(5, h.stm, testContent)),
+		encDict,
+	}
+	if identity {
+		objs = append(objs, stream("/Filter /Crypt /DecodeParms << /Name /Identity >>", []byte(testContent)))
+	}
+	body, xrefOffset := buildClassicXRefBody(objs)
+	body += fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R /Encrypt 6 0 R /ID [<%X> <%X>] >>\n", len(objs)+1, testFileID, testFileID)
+	body += fmt.Sprintf("startxref\n%d\n%%%%EOF", xrefOffset)
+	return []byte(body)
+}
+
+// testOwnerEntry computes an R2-R4 O value (Algorithm 3).
+func testOwnerEntry(r, n int, user, owner string) []byte {
+	if owner == "" {
+		owner = user
+	}
+	sum := md5.Sum(padPassword([]byte(owner)))
+	key := sum[:]
+	if r >= 3 {
+		for range 50 {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	key = key[:n]
+	out := rc4Crypt(key, padPassword([]byte(user)))
+	if r >= 3 {
+		for i := 1; i <= 19; i++ {
+			out = rc4Crypt(xorKey(key, byte(i)), out)
+		}
+	}
+	return out
+}
+
+// testUserEntry computes an R2-R4 U value (Algorithms 4 and 5).
+func testUserEntry(h *securityHandler, id []byte) []byte {
+	if h.r == 2 {
+		return rc4Crypt(h.key, passwordPadding)
+	}
+	m := md5.New()
+	m.Write(passwordPadding)
+	m.Write(id)
+	out := rc4Crypt(h.key, m.Sum(nil))
+	for i := 1; i <= 19; i++ {
+		out = rc4Crypt(xorKey(h.key, byte(i)), out)
+	}
+	return append(out, make([]byte, 16)...)
+}
+
+// aesWrap encrypts a 32-byte file key with AES-256-CBC, zero IV, no padding.
+func aesWrap(key, fileKey []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, 32)
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, fileKey)
+	return out
+}
+
+// testEncrypt encrypts data for object ref under method m.
+func testEncrypt(h *securityHandler, ref PDFRef, m cryptMethod, data []byte) []byte {
+	switch m {
+	case cryptRC4:
+		return rc4Crypt(h.objectKey(ref, m), data)
+	case cryptAESV2, cryptAESV3:
+		pad := aes.BlockSize - len(data)%aes.BlockSize
+		plain := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+		iv := bytes.Repeat([]byte{0x11}, aes.BlockSize)
+		block, _ := aes.NewCipher(h.objectKey(ref, m))
+		out := make([]byte, len(plain))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+		return append(iv, out...)
+	}
+	return data
+}
+
+func TestStandardSecurityHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		enc  testEncryption
+	}{
+		{"RC4 40-bit R2", testEncryption{v: 1, r: 2, length: 40, encryptMetadata: true}},
+		{"RC4 128-bit R3", testEncryption{v: 2, r: 3, length: 128, encryptMetadata: true}},
+		{"RC4 crypt filter R4", testEncryption{v: 4, r: 4, length: 128, cfm: "V2", encryptMetadata: true}},
+		{"AES-128 R4", testEncryption{v: 4, r: 4, length: 128, cfm: "AESV2", encryptMetadata: true}},
+		{"AES-128 R4 plaintext metadata", testEncryption{v: 4, r: 4, length: 128, cfm: "AESV2"}},
+		{"AES-256 R5", testEncryption{v: 5, r: 5, length: 256, cfm: "AESV3", encryptMetadata: true}},
+		{"AES-256 R6", testEncryption{v: 5, r: 6, length: 256, cfm: "AESV3", encryptMetadata: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, pw := range []struct {
+				name, user, owner, open string
+				owned                   bool
+			}{
+				{"empty user password", "", "owner", "", false},
+				{"user password", "user", "owner", "user", false},
+				{"owner password", "user", "owner", "owner", true},
+			} {
+				e := tc.enc
+				e.user, e.owner = pw.user, pw.owner
+				data := encryptedTestPDF(t, e)
+				d, err := OpenBytesWithPassword(data, pw.open)
+				if err != nil {
+					t.Fatalf("%s: OpenBytesWithPassword: %v", pw.name, err)
+				}
+				defer d.Close()
+				if !d.Encrypted() || d.crypt.owner != pw.owned {
+					t.Errorf("%s: Encrypted = %v, owner = %v", pw.name, d.Encrypted(), d.crypt.owner)
+				}
+				checkDecrypted(t, d, e.v >= 4)
+			}
+
+			e := tc.enc
+			e.user, e.owner = "user", "owner"
+			if _, err := OpenBytes(encryptedTestPDF(t, e)); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("OpenBytes without the user password: err = %v, want ErrIncorrectPassword", err)
+			}
+			if _, err := OpenBytesWithPassword(encryptedTestPDF(t, e), "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("OpenBytesWithPassword(wrong): err = %v, want ErrIncorrectPassword", err)
+			}
+		})
+	}
+}
+
+// checkDecrypted asserts that the strings and streams of an
+// encryptedTestPDF document read as plaintext.
+func checkDecrypted(t *testing.T, d *Reader, identity bool) {
+	t.Helper()
+	info, err := d.GetMetadata()
+	if err != nil || info["Title"] != testTitle {
+		t.Errorf("Info Title = %q, %v; want %q", info["Title"], err, testTitle)
+	}
+	xmp, err := d.XMPMetadata()
+	if err != nil || string(xmp) != testXMP {
+		t.Errorf("XMP = %q, %v; want %q", xmp, err, testXMP)
+	}
+	objs := []int{5}
+	if identity {
+		objs = append(objs, 7)
+	}
+	for _, n := range objs {
+		v, err := d.ResolveReference(PDFRef{ObjNum: n})
+		if err != nil {
+			t.Fatalf("ResolveReference(%d): %v", n, err)
+		}
+		data, err := DecodeStream(v.(PDFDict))
+		if err != nil || string(data) != testContent {
+			t.Errorf("object %d decodes to %q, %v; want %q", n, data, err, testContent)
+		}
+	}
+	// The Encrypt dictionary itself is never decrypted.
+	enc, _ := d.ResolveReference(PDFRef{ObjNum: 6})
+	raw, err := d.parseClassicObject(PDFRef{ObjNum: 6}, d.xrefTable[6])
+	if err != nil || !EqualPDFValue(enc, raw) {
+		t.Errorf("Encrypt dictionary was altered: %v", err)
+	}
+}
+
+// TestOpenEncryptedIsartorFile opens a real RC4 128-bit (R3) file with an
+// empty user password.
+func TestOpenEncryptedIsartorFile(t *testing.T) {
+	d, err := Open("../../tests/Isartor/PDFA-1b/6.1 File structure/6.1.3 File trailer/isartor-6-1-3-t02-fail-a.pdf")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+	if !d.Encrypted() {
+		t.Fatal("Encrypted() = false")
+	}
+	xmp, err := d.XMPMetadata()
+	if err != nil || !strings.HasPrefix(string(xmp), "<?xpacket") {
+		t.Errorf("XMP does not decrypt: %.40q, %v", xmp, err)
+	}
+}
+
+// TestPasswordHashKnownAnswers checks hashR5 and hashR6 against U, UE, O
+// and OE values computed outside this package, by a reference
+// implementation of Algorithms 2.A and 2.B over Python's hashlib and the
+// openssl command, for user password "user", owner password "owner", the
+// salts below and a file key of the bytes 0x20 to 0x3F.
+func TestPasswordHashKnownAnswers(t *testing.T) {
+	fileKey := make([]byte, 32)
+	for i := range fileKey {
+		fileKey[i] = byte(0x20 + i)
+	}
+	for _, tc := range []struct {
+		name         string
+		hash         func(pw, salt, udata []byte) []byte
+		u, ue, o, oe string
+	}{
+		{"R5", hashR5,
+			"E4AC0E826663E92DA54F6F1F61769BF4A82DEB282B56274FFE722AD867EA8D61555653616C743031554B53616C743031",
+			"D5826432BF7E18B14E48874C4D09ECD06E1F1B2CDB17BE07CAB460A172DEDBF2",
+			"015D857D0F1DA64DCC16542C7965109A71D63A110D9EEB4F4B3002FFBB9E65674F5653616C7430314F4B53616C743031",
+			"6D8E8C9B72E3625414CC05A9C659580960B8237CDAA4340A36966B9E0EC36452"},
+		{"R6", hashR6,
+			"F277A69409D2F0D93E85C24D5A030BD32EB999B622BF89EA9E0C4361C0F00454555653616C743031554B53616C743031",
+			"30A7100ECC15D4F51FDE36635F3B18A97FAE85C208BE192401BB952E7C5238DB",
+			"F22028B87F6081F678531EECC20C8A43FFB07208B2DCDF42334B2694DB3AEFC34F5653616C7430314F4B53616C743031",
+			"DF4CDD1D5F8CEC5C80B6DA31EA369E17CC6ED9741DDB2DF7BE425D5FBB1F73AF"},
+	} {
+		u, _ := hex.DecodeString(tc.u)
+		ue, _ := hex.DecodeString(tc.ue)
+		o, _ := hex.DecodeString(tc.o)
+		oe, _ := hex.DecodeString(tc.oe)
+		for _, pw := range []struct {
+			name            string
+			password, entry []byte
+			wrapped, udata  []byte
+		}{
+			{"user", []byte("user"), u, ue, nil},
+			{"owner", []byte("owner"), o, oe, u},
+		} {
+			if got := tc.hash(pw.password, pw.entry[32:40], pw.udata); !bytes.Equal(got, pw.entry[:32]) {
+				t.Errorf("%s %s: validation hash = %X, want %X", tc.name, pw.name, got, pw.entry[:32])
+			}
+			key, err := aes256UnwrapKey(tc.hash(pw.password, pw.entry[40:48], pw.udata), pw.wrapped)
+			if err != nil || !bytes.Equal(key, fileKey) {
+				t.Errorf("%s %s: unwrapped file key = %X, %v; want %X", tc.name, pw.name, key, err, fileKey)
+			}
+		}
+	}
+}
+
+// TestOpenEncryptedR6File opens an AES-256 (R6) file written outside this
+// package, with the same passwords and file key as
+// TestPasswordHashKnownAnswers and a /Perms entry, by either password.
+func TestOpenEncryptedR6File(t *testing.T) {
+	for _, pw := range []string{"user", "owner"} {
+		d, err := OpenWithPassword("testdata/aes256-r6.pdf", pw)
+		if err != nil {
+			t.Fatalf("OpenWithPassword(%q): %v", pw, err)
+		}
+		if !d.Encrypted() || d.crypt.owner != (pw == "owner") || d.crypt.p != -3904 {
+			t.Errorf("%s: Encrypted = %v, owner = %v, P = %d", pw, d.Encrypted(), d.crypt.owner, d.crypt.p)
+		}
+		checkKnownAnswerFile(t, d, "R6 known-answer file", "(AES-256 R6) Tj")
+		d.Close()
+	}
+	if _, err := Open("testdata/aes256-r6.pdf"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Open without a password: err = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+// checkKnownAnswerFile asserts that a testdata known-answer file's Info
+// Title and page content (object 4) decrypt to title and text.
+func checkKnownAnswerFile(t *testing.T, d *Reader, title, text string) {
+	t.Helper()
+	info, err := d.GetMetadata()
+	if err != nil || info["Title"] != title {
+		t.Errorf("Info Title = %q, %v; want %q", info["Title"], err, title)
+	}
+	v, err := d.ResolveReference(PDFRef{ObjNum: 4})
+	if err != nil {
+		t.Fatalf("ResolveReference(4): %v", err)
+	}
+	if data, err := DecodeStream(v.(PDFDict)); err != nil || !strings.Contains(string(data), text) {
+		t.Errorf("content = %q, %v; want it to show %s", data, err, text)
+	}
+}
+
+// TestUnsupportedSecurityHandlerLeftEncrypted opens documents whose Encrypt
+// dictionary is not a usable Standard handler: they open undecrypted.
+func TestUnsupportedSecurityHandlerLeftEncrypted(t *testing.T) {
+	for _, enc := range []string{
+		"<< /Filter /Adobe.PubSec /V 4 /R 4 >>",
+		"<< /Filter /Standard /V 3 /R 3 /O <00> /U <00> /P -4 >>",
+		"<< /Filter /Standard /V 2 /R 3 /O <00> /U <00> /P -4 >>",
+		"<< >>",
+	} {
+		data, xrefOffset := buildClassicXRefBody([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Count 0 /Kids [] >>",
+			enc,
+		})
+		data += "trailer\n<< /Size 4 /Root 1 0 R /Encrypt 3 0 R /ID [<00> <00>] >>\n"
+		data += fmt.Sprintf("startxref\n%d\n%%%%EOF", xrefOffset)
+		d, err := OpenBytes([]byte(data))
+		if err != nil {
+			t.Errorf("%s: OpenBytes: %v", enc, err)
+			continue
+		}
+		if d.Encrypted() {
+			t.Errorf("%s: Encrypted() = true", enc)
+		}
+		d.Close()
+	}
+}
+
+func TestPDFDocPassword(t *testing.T) {
+	if got := pdfDocPassword("aé•"); !bytes.Equal(got, []byte{'a', 0xE9, 0x80}) {
+		t.Errorf("pdfDocPassword = %s, want 61e980", hex.EncodeToString(got))
+	}
+}
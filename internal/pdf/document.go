@@ -2,6 +2,10 @@ package pdf
 
 import (
 	"bytes"
+	"container/list"
+	"context"
+	"crypto"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -10,12 +14,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 )
 
 // fileSource is the byte source a Reader parses and verifies from: a file on
-// disk (Open) or an in-memory buffer (OpenBytes), so a freshly-written PDF can
-// be re-verified without a temp-file round-trip.
+// disk (Open), an in-memory buffer (OpenBytes), so a freshly-written PDF can
+// be re-verified without a temp-file round-trip, or a caller's io.ReaderAt
+// (OpenReaderAt).
 type fileSource interface {
 	io.Reader
 	io.ReaderAt
@@ -28,6 +35,12 @@ type fileSource interface {
 // Validation lives above this package; Reader only records the structural
 // parse diagnostics (see PDFError) it discovers as a side effect of
 // reading -- e.g. malformed stream framing -- for that layer to interpret.
+//
+// A Reader is not safe for concurrent use, except for Err and
+// DecodeStreamCachedConcurrent, which a pass calls from its own workers:
+// its caches are unguarded, and the context (SetContext) and limits
+// (SetLimits, ApplyLimits) governing a pass are fields of the Reader that
+// the pass sets and restores. Run one pass over a Reader at a time.
 type Reader struct {
 	file       fileSource
 	size       int64
@@ -74,6 +87,13 @@ type Reader struct {
 	resolvedGraph PDFValue
 	graphResolved bool
 
+	// cacheLimit, when positive, caps objCache in bounded mode, lru
+	// ordering its object numbers from most to least recently used. See
+	// SetObjectCacheLimit.
+	cacheLimit int
+	lru        *list.List
+	lruElems   map[int]*list.Element
+
 	// danglingScanRan dedupes the one-shot fill-in scan parseReference runs
 	// before resolving a reference with no xref entry to null.
 	danglingScanRan bool
@@ -93,6 +113,28 @@ type Reader struct {
 	// changes between iterations, e.g. OutputIntent coverage, so only the
 	// token list -- never a check's result -- is safe to cache here).
 	scanCache map[StreamKey][]ScannedOp
+
+	// limits, set by SetLimits (limited records that it was), cap d's
+	// work; deadline ends its Duration, decodedTotal counts its decoded
+	// stream bytes, and limitErr holds the first limit exceeded. See
+	// limits.go.
+	limits       Limits
+	limited      bool
+	deadline     time.Time
+	decodedTotal atomic.Int64
+	limitErr     atomic.Pointer[LimitError]
+
+	// crypt decrypts objects as they are parsed when the document is
+	// encrypted with the Standard security handler; nil otherwise. See
+	// crypt.go.
+	crypt *securityHandler
+	// cred is what d was opened with, for OpenRevision to open an earlier
+	// revision the same way.
+	cred credential
+
+	// ctx, when set, cancels graph resolution and content tokenizing, and
+	// the verify and convert passes over d. See SetContext.
+	ctx context.Context
 }
 
 // DecodeStreamCached decodes dict's stream, memoizing the result by content
@@ -101,12 +143,12 @@ type Reader struct {
 func (d *Reader) DecodeStreamCached(dict PDFDict) ([]byte, error) {
 	key, ok := StreamKeyOf(dict)
 	if !ok {
-		return DecodeStream(dict)
+		return d.decode(dict)
 	}
 	if data, ok := d.decodedCache[key]; ok {
 		return data, nil
 	}
-	data, err := DecodeStream(dict)
+	data, err := d.decode(dict)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +166,7 @@ func (d *Reader) DecodeStreamCached(dict PDFDict) ([]byte, error) {
 func (d *Reader) DecodeStreamCachedConcurrent(dict PDFDict) ([]byte, error) {
 	key, ok := StreamKeyOf(dict)
 	if !ok {
-		return DecodeStream(dict)
+		return d.decode(dict)
 	}
 	d.decodedMu.Lock()
 	data, hit := d.decodedCache[key]
@@ -132,7 +174,7 @@ func (d *Reader) DecodeStreamCachedConcurrent(dict PDFDict) ([]byte, error) {
 	if hit {
 		return data, nil
 	}
-	data, err := DecodeStream(dict)
+	data, err := d.decode(dict)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +209,7 @@ func (d *Reader) AdoptStreamCaches(src *Reader) {
 func (d *Reader) ScanStreamCached(dict PDFDict) ([]ScannedOp, error) {
 	key, ok := StreamKeyOf(dict)
 	if !ok {
-		data, err := DecodeStream(dict)
+		data, err := d.decode(dict)
 		if err != nil {
 			return nil, err
 		}
@@ -180,7 +222,11 @@ func (d *Reader) ScanStreamCached(dict PDFDict) ([]ScannedOp, error) {
 	if err != nil {
 		return nil, err
 	}
-	ops := TokenizeContent(data)
+	// A scan cut short by cancellation is incomplete, so it is not cached.
+	ops := tokenize(NewContentScannerContext(d.Context(), data))
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
 	if d.scanCache == nil {
 		d.scanCache = map[StreamKey][]ScannedOp{}
 	}
@@ -237,8 +283,28 @@ func NewRawReader(file interface {
 	return &Reader{file: file, trailer: trailer, size: size, xrefOffset: xrefOffset}
 }
 
-// Open initializes the PDF document at path.
-func Open(path string) (*Reader, error) {
+// Open initializes the PDF document at path. An encrypted document is
+// opened with the empty user password.
+func Open(path string) (*Reader, error) { return OpenWithPassword(path, "") }
+
+// OpenWithPassword is Open for a document encrypted with the Standard
+// security handler, authenticating password as its user or owner password.
+// It returns an error wrapping ErrIncorrectPassword if password is neither.
+func OpenWithPassword(path, password string) (*Reader, error) {
+	return openFile(path, credential{password: password})
+}
+
+// OpenWithCertificate is Open for a document encrypted with the public-key
+// security handler (Adobe.PubSec) for the recipient cert, whose private key
+// key unwraps the document's key. It returns an error wrapping
+// ErrNotRecipient if cert is not among the document's recipients.
+func OpenWithCertificate(path string, cert *x509.Certificate, key crypto.Decrypter) (*Reader, error) {
+	return openFile(path, credential{cert: cert, key: key})
+}
+
+// openFile opens the PDF at path, authenticating with cred if it is
+// encrypted.
+func openFile(path string, cred credential) (*Reader, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -256,7 +322,7 @@ func Open(path string) (*Reader, error) {
 		return nil, err
 	}
 
-	return newDocument(f, info.Size(), data, unmap)
+	return newDocument(f, info.Size(), data, unmap, cred)
 }
 
 // bytesFileSource adapts a *bytes.Reader (which has no Close) to fileSource.
@@ -267,13 +333,49 @@ func (bytesFileSource) Close() error { return nil }
 // OpenBytes initializes a Reader from an in-memory PDF, parsing the same way
 // Open does but without touching disk -- used to re-verify freshly-written
 // bytes during conversion.
-func OpenBytes(data []byte) (*Reader, error) {
-	return newDocument(bytesFileSource{bytes.NewReader(data)}, int64(len(data)), data, nil)
+func OpenBytes(data []byte) (*Reader, error) { return OpenBytesWithPassword(data, "") }
+
+// OpenBytesWithPassword is OpenWithPassword for an in-memory PDF.
+func OpenBytesWithPassword(data []byte, password string) (*Reader, error) {
+	return newDocument(bytesFileSource{bytes.NewReader(data)}, int64(len(data)), data, nil, credential{password: password})
+}
+
+// OpenBytesWithCertificate is OpenWithCertificate for an in-memory PDF.
+func OpenBytesWithCertificate(data []byte, cert *x509.Certificate, key crypto.Decrypter) (*Reader, error) {
+	return newDocument(bytesFileSource{bytes.NewReader(data)}, int64(len(data)), data, nil, credential{cert: cert, key: key})
+}
+
+// readerAtSource adapts a caller's io.ReaderAt to fileSource through an
+// io.SectionReader. Close leaves the io.ReaderAt open: the caller owns it.
+type readerAtSource struct{ *io.SectionReader }
+
+func (readerAtSource) Close() error { return nil }
+
+// OpenReaderAt initializes a Reader from the size bytes of r, such as a
+// range-reading client for object storage, parsing the same way Open does
+// without first copying them into memory: the structure, objects and
+// streams are read from r as they are needed. Only a damaged
+// cross-reference table, which is rebuilt by scanning the file, reads the
+// whole of it. r must stay readable until the Reader is closed; closing the
+// Reader does not close r.
+func OpenReaderAt(r io.ReaderAt, size int64) (*Reader, error) {
+	return OpenReaderAtWithPassword(r, size, "")
+}
+
+// OpenReaderAtWithPassword is OpenWithPassword for an io.ReaderAt.
+func OpenReaderAtWithPassword(r io.ReaderAt, size int64, password string) (*Reader, error) {
+	return newDocument(readerAtSource{io.NewSectionReader(r, 0, size)}, size, nil, nil, credential{password: password})
+}
+
+// OpenReaderAtWithCertificate is OpenWithCertificate for an io.ReaderAt.
+func OpenReaderAtWithCertificate(r io.ReaderAt, size int64, cert *x509.Certificate, key crypto.Decrypter) (*Reader, error) {
+	return newDocument(readerAtSource{io.NewSectionReader(r, 0, size)}, size, nil, nil, credential{cert: cert, key: key})
 }
 
 // newDocument parses a Reader's structure from an already-opened byte source
-// of the given size, shared by Open and OpenBytes.
-func newDocument(src fileSource, size int64, data []byte, unmap func() error) (*Reader, error) {
+// of the given size, shared by Open, OpenBytes and OpenReaderAt, and sets up
+// decryption with cred if the document is encrypted.
+func newDocument(src fileSource, size int64, data []byte, unmap func() error, cred credential) (*Reader, error) {
 	header := make([]byte, 8)
 	if _, err := src.ReadAt(header, 0); err != nil {
 		src.Close()
@@ -286,6 +388,7 @@ func newDocument(src fileSource, size int64, data []byte, unmap func() error) (*
 		header: header,
 		data:   data,
 		unmap:  unmap,
+		cred:   cred,
 	}
 
 	if err := doc.initializeStructure(); err != nil {
@@ -293,6 +396,15 @@ func newDocument(src fileSource, size int64, data []byte, unmap func() error) (*
 		return nil, fmt.Errorf("failed to parse structure: %w", err)
 	}
 
+	if err := doc.initSecurity(cred); err != nil {
+		doc.Close()
+		return nil, err
+	}
+	if doc.crypt != nil {
+		// Objects parsed while reading the structure were not decrypted.
+		doc.objCache, doc.objStmCache = nil, nil
+	}
+
 	return doc, nil
 }
 
@@ -578,8 +690,6 @@ func (d *Reader) EffectiveTrailer() PDFDict {
 }
 
 func (d *Reader) BuildPageIndex(graph PDFValue) (map[int]int, error) {
-	index := make(map[int]int)
-
 	graphDict, ok := graph.(PDFDict)
 	if !ok {
 		return nil, fmt.Errorf("document graph is not a dictionary")
@@ -596,7 +706,16 @@ func (d *Reader) BuildPageIndex(graph PDFValue) (map[int]int, error) {
 	if pages == nil {
 		return nil, fmt.Errorf("dict Pages is nil")
 	}
+	_, index, err := d.walkPageTree(pages)
+	return index, err
+}
 
+// walkPageTree walks the page tree below pages, resolving unresolved nodes
+// shallowly, and returns the indirect pages in document order with each
+// one's page number.
+func (d *Reader) walkPageTree(pages PDFValue) ([]PDFRef, map[int]int, error) {
+	var refs []PDFRef
+	index := make(map[int]int)
 	pageNum := 0
 
 	// A malformed page tree can be cyclic (a Kids entry referring back to an
@@ -610,6 +729,15 @@ func (d *Reader) BuildPageIndex(graph PDFValue) (map[int]int, error) {
 		if depth > maxPageTreeDepth {
 			return fmt.Errorf("page tree exceeds maximum depth")
 		}
+		if ref, ok := node.(PDFRef); ok {
+			if seen[ref.ObjNum] {
+				return nil
+			}
+			var err error
+			if node, err = d.ResolveReference(ref); err != nil {
+				return err
+			}
+		}
 		dict, ok := node.(PDFDict)
 		if !ok {
 			return nil
@@ -623,8 +751,12 @@ func (d *Reader) BuildPageIndex(graph PDFValue) (map[int]int, error) {
 
 		if (dict.Entries["Type"] == PDFName{Value: "Page"}) {
 			pageNum++
+			if max := d.Limits().Pages; max > 0 && pageNum > max {
+				return d.exceed("Pages", int64(max))
+			}
 			if ref, ok := dict.Entries["_ref"].(PDFRef); ok {
 				index[ref.ObjNum] = pageNum
+				refs = append(refs, ref)
 			}
 			return nil
 		}
@@ -640,7 +772,7 @@ func (d *Reader) BuildPageIndex(graph PDFValue) (map[int]int, error) {
 	}
 
 	err := walk(pages, 0)
-	return index, err
+	return refs, index, err
 }
 
 // Close releases all resources held by the Reader.
@@ -752,7 +884,8 @@ func (d *Reader) XMPMetadata() ([]byte, error) {
 
 // ClaimedConformance returns the PDF/A part and conformance level the
 // document's XMP metadata claims (e.g. "1", "B"), read from the pdfaid
-// namespace. This reflects what the file claims, not whether it actually
+// namespace. A PDF/A-4 file may declare no conformance level, which yields
+// "4" and "". This reflects what the file claims, not whether it actually
 // validates — use Verify or IsPDFA to check actual compliance.
 func (d *Reader) ClaimedConformance() (part, conformance string, err error) {
 	data, _, err := d.RawXMP()
@@ -766,6 +899,9 @@ func (d *Reader) ClaimedConformance() (part, conformance string, err error) {
 		return "", "", errors.New("no PDF/A part identifier in XMP metadata")
 	}
 	conformance, hasConf := FirstRegexpGroup(PDFAConfRe, xmp)
+	if !hasConf && part == "4" {
+		return part, "", nil
+	}
 	if !hasConf {
 		return part, "", errors.New("no PDF/A conformance level in XMP metadata")
 	}
@@ -802,7 +938,15 @@ func (d *Reader) ResolveGraph() (PDFValue, error) {
 	if d.graphResolved {
 		return d.resolvedGraph, nil
 	}
-	g, err := d.resolveInPlace(d.EffectiveTrailer())
+	var g PDFValue
+	var err error
+	if d.cacheLimit > 0 {
+		var res Detached
+		res, err = d.ResolveDetached(d.EffectiveTrailer(), nil)
+		g = res.Root
+	} else {
+		g, err = d.resolveInPlace(d.EffectiveTrailer())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -821,9 +965,14 @@ func (d *Reader) SeedResolvedGraph(graph PDFDict, objs map[int]PDFValue) {
 // resolvePath walks a PDF object following path elements, which may be
 // dictionary keys or array indices. node must already be a resolved object.
 func (d *Reader) resolvePath(node PDFValue, path []string) (PDFValue, error) {
-	current, err := d.ResolveObject(node)
-	if err != nil {
-		return nil, err
+	// In bounded mode the path is followed shallowly; only the value it
+	// leads to is resolved.
+	current := node
+	var err error
+	if d.cacheLimit == 0 {
+		if current, err = d.ResolveObject(node); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, key := range path {
@@ -860,8 +1009,13 @@ func (d *Reader) resolvePath(node PDFValue, path []string) (PDFValue, error) {
 	return d.ResolveObject(current)
 }
 
-// resolveInPlace returns obj fully resolved.
+// resolveInPlace returns obj fully resolved. In bounded mode it returns a
+// detached copy instead, leaving page tree nodes other than obj unresolved.
 func (d *Reader) resolveInPlace(obj PDFValue) (PDFValue, error) {
+	if d.cacheLimit > 0 {
+		res, err := d.ResolveDetached(obj, stopAtPageTree)
+		return res.Root, err
+	}
 	return d.resolveInPlaceDepth(obj, 0)
 }
 
@@ -875,6 +1029,9 @@ func (d *Reader) resolveInPlaceDepth(obj PDFValue, depth int) (PDFValue, error)
 	}
 	switch v := obj.(type) {
 	case PDFRef:
+		if err := d.Err(); err != nil {
+			return nil, err
+		}
 		target, err := d.ResolveReference(v)
 		if err != nil {
 			return nil, err
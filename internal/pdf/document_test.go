@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -166,6 +168,66 @@ func TestOpenEmptyFile(t *testing.T) {
 	}
 }
 
+// rangeReader stands in for a range-reading object storage client: an
+// io.ReaderAt over data that counts the bytes read through it.
+type rangeReader struct {
+	data []byte
+	read atomic.Int64
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := bytes.NewReader(r.data).ReadAt(p, off)
+	r.read.Add(int64(n))
+	return n, err
+}
+
+// TestOpenReaderAt confirms a Reader opened over an io.ReaderAt walks the
+// page tree without reading a large content stream, then reads it as
+// OpenBytes does once decoded.
+func TestOpenReaderAt(t *testing.T) {
+	content := "BT " + strings.Repeat("% padding\n", 100000) + "ET"
+	path := writeMinimalPDF(t, []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}, "<< /Size 5 /Root 1 0 R >>")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read pdf: %v", err)
+	}
+
+	src := &rangeReader{data: data}
+	d, err := OpenReaderAt(src, int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReaderAt: %v", err)
+	}
+	defer d.Close()
+	refs, _, err := d.PageRefs()
+	if err != nil || len(refs) != 1 {
+		t.Fatalf("PageRefs = %v, %v; want one page", refs, err)
+	}
+	if n := src.read.Load(); n > int64(len(data))/10 {
+		t.Errorf("opening and walking the page tree read %d of %d bytes", n, len(data))
+	}
+
+	got, err := d.DecodeStreamCached(stream(t, d, 4))
+	if err != nil {
+		t.Fatalf("DecodeStreamCached: %v", err)
+	}
+	mem, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	want, err := mem.DecodeStreamCached(stream(t, mem, 4))
+	if err != nil {
+		t.Fatalf("DecodeStreamCached over OpenBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) || string(got) != content {
+		t.Errorf("stream read through OpenReaderAt = %d bytes, want the %d OpenBytes decodes", len(got), len(want))
+	}
+}
+
 // TestGetVersionBranches covers every branch of GetVersion via a bare Reader.
 func TestGetVersionBranches(t *testing.T) {
 	cases := []struct {
@@ -621,6 +683,28 @@ func TestClaimedConformanceAndRawXMP(t *testing.T) {
 			t.Errorf("ClaimedConformance = %q, %q, %v; want (1, B, nil)", part, conf, err)
 		}
 	})
+
+	t.Run("part 4 without conformance", func(t *testing.T) {
+		xmp := `<x><pdfaid:part>4</pdfaid:part><pdfaid:rev>2020</pdfaid:rev></x>`
+		path := writeMinimalPDF(t,
+			[]string{
+				"<< /Type /Catalog /Pages 2 0 R /Metadata 3 0 R >>",
+				"<< /Type /Pages /Count 1 /Kids [] >>",
+				buildMetadataStreamObj(xmp),
+			},
+			"<< /Size 4 /Root 1 0 R >>",
+		)
+		doc, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		defer doc.Close()
+
+		part, conf, err := doc.ClaimedConformance()
+		if part != "4" || conf != "" || err != nil {
+			t.Errorf("ClaimedConformance = %q, %q, %v; want (4, \"\", nil)", part, conf, err)
+		}
+	})
 }
 
 // TestRecoverTrailerFromXRefStream covers the resolve-error continue branch,
@@ -39,6 +39,14 @@ func (e PDFError) WithObjModelDetail(d ObjModelDetail) PDFError {
 	return e
 }
 
+// WithCheck returns a copy of e reported against c instead, keeping its
+// messages, page and object. Used to renumber a finding shared between PDF/A
+// parts into the numbering of the part being verified (see CheckIn).
+func (e PDFError) WithCheck(c Check) PDFError {
+	e.check = c
+	return e
+}
+
 func (e PDFError) String() string {
 	var b strings.Builder
 
@@ -21,3 +21,18 @@ func TestObjModelDetail(t *testing.T) {
 		t.Error("WithObjModelDetail must not mutate its receiver")
 	}
 }
+
+func TestWithCheck(t *testing.T) {
+	ref := PDFRef{ObjNum: 7}
+	base := NewError(Checks.Structure.ObjectFraming, []error{errors.New("x")}, 3, &ref)
+	moved := base.WithCheck(Checks.PDFA2.Structure.ObjectFraming)
+	if moved.Check() != Checks.PDFA2.Structure.ObjectFraming {
+		t.Errorf("Check() = %q (%s), want the PDF/A-2 ObjectFraming", moved.Check().Name(), moved.Check().Spec())
+	}
+	if got, ok := moved.ObjectRef(); !ok || got != ref || moved.Page() != 3 || moved.Messages()[0] != "x" {
+		t.Errorf("WithCheck lost the finding's location or messages: %v", moved)
+	}
+	if base.Check() != Checks.Structure.ObjectFraming {
+		t.Error("WithCheck must not mutate its receiver")
+	}
+}
@@ -0,0 +1,200 @@
+package pdf
+
+import "fmt"
+
+// DecodeStream returns the decoded bytes of a stream dictionary, running
+// its whole filter chain (ISO 32000-2 7.4): FlateDecode, LZWDecode,
+// ASCIIHexDecode, ASCII85Decode, RunLengthDecode, CCITTFaxDecode and
+// JBIG2Decode, each with its own DecodeParms entry and, for Flate and LZW,
+// any PNG or TIFF predictor. A stream ending in an image codec this package
+// does not decode (DCTDecode, JPXDecode) is an error; see
+// DecodeStreamToCodec. A stream decoding to more than
+// DefaultLimits().StreamBytes is a *LimitError; a Reader's cached decodes
+// apply its own Limits instead.
+func DecodeStream(dict PDFDict) ([]byte, error) {
+	data, codec, err := DecodeStreamToCodec(dict)
+	if err != nil {
+		return nil, err
+	}
+	if codec != "" {
+		return nil, fmt.Errorf("unsupported filter %q", codec)
+	}
+	return data, nil
+}
+
+// DecodeStreamToCodec is DecodeStream for image data: decoding stops at an
+// image codec it does not decode, returning the data still in that codec's
+// format and the codec's name ("DCTDecode" or "JPXDecode"), or "" for a
+// fully decoded stream.
+func DecodeStreamToCodec(dict PDFDict) ([]byte, string, error) {
+	return decodeStreamToCodec(dict, defaultLimits.StreamBytes)
+}
+
+// decodeStreamToCodec is DecodeStreamToCodec with a cap of max decoded bytes
+// after any filter; 0 means none. Flate, LZW and RunLength stop at the cap
+// rather than decoding past it.
+func decodeStreamToCodec(dict PDFDict, max int64) ([]byte, string, error) {
+	if !dict.HasStream {
+		return nil, "", fmt.Errorf("object is not a stream")
+	}
+	filters := FilterNames(dict.Entries["Filter"])
+	data := dict.RawStream
+	for i, f := range filters {
+		parms := filterParms(dict, i, len(filters))
+		var err error
+		switch f {
+		case "FlateDecode", "Fl":
+			if data, err = inflateZlib(data, max); err == nil {
+				data, err = undoPredictor(dict, parms, data)
+			}
+		case "LZWDecode", "LZW":
+			if data, err = decodeLZW(data, DictInt(parms, "EarlyChange", 1) != 0, max); err == nil {
+				data, err = undoPredictor(dict, parms, data)
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = DecodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = DecodeASCII85(data)
+		case "RunLengthDecode", "RL":
+			data, err = decodeRunLength(data, max)
+		case "CCITTFaxDecode", "CCF":
+			data, err = DecodeCCITT(data, ccittParams(dict, parms))
+		case "JBIG2Decode":
+			data, err = decodeJBIG2Filter(data, parms)
+		case "DCTDecode", "DCT", "JPXDecode":
+			if i != len(filters)-1 {
+				return nil, "", fmt.Errorf("filter %q follows image codec %s", filters[i+1], f)
+			}
+			if f == "DCT" {
+				f = "DCTDecode"
+			}
+			return data, f, nil
+		case "Crypt":
+			// Decrypted when the stream was read (see crypt.go).
+		default:
+			return nil, "", fmt.Errorf("unsupported filter %q", f)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if max > 0 && int64(len(data)) > max {
+			return nil, "", &LimitError{Limit: "StreamBytes", Max: max}
+		}
+	}
+	return data, "", nil
+}
+
+// filterParms returns the DecodeParms (or DP) dictionary for the i-th of n
+// filters: the i-th element of a DecodeParms array, or a lone dictionary,
+// which belongs to the last filter when a writer gave several filters a
+// single dictionary.
+func filterParms(dict PDFDict, i, n int) PDFDict {
+	parms := dict.Entries["DecodeParms"]
+	if parms == nil {
+		parms = dict.Entries["DP"]
+	}
+	switch p := parms.(type) {
+	case PDFDict:
+		if i == n-1 {
+			return p
+		}
+	case PDFArray:
+		if i < len(p) {
+			if d, ok := p[i].(PDFDict); ok {
+				return d
+			}
+		}
+	}
+	return PDFDict{}
+}
+
+// undoPredictor reverses the PNG (Predictor >= 10) or TIFF (Predictor 2)
+// predictor parms records for a Flate or LZW filter. Columns and
+// BitsPerComponent fall back to an image's Width and BitsPerComponent, as
+// lenient readers do for images written without them.
+func undoPredictor(dict, parms PDFDict, data []byte) ([]byte, error) {
+	predictor := DictInt(parms, "Predictor", 1)
+	if predictor == 1 {
+		return data, nil
+	}
+	columns := DictInt(parms, "Columns", DictInt(dict, "Width", 1))
+	colors := DictInt(parms, "Colors", 1)
+	bpc := DictInt(parms, "BitsPerComponent", DictInt(dict, "BitsPerComponent", 8))
+	switch {
+	case predictor == 2:
+		return UndoTIFFPredictor(data, columns, colors, bpc)
+	case predictor >= 10:
+		return UndoPNGPredictor(data, columns, colors, bpc)
+	default:
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+}
+
+// ccittParams reads a CCITTFaxDecode filter's parameters, taking a missing
+// Rows from the image's Height.
+func ccittParams(dict, parms PDFDict) CCITTParams {
+	p := CCITTParams{
+		Columns:   DictInt(parms, "Columns", 1728),
+		Rows:      DictInt(parms, "Rows", 0),
+		K:         DictInt(parms, "K", 0),
+		ByteAlign: parms.Entries["EncodedByteAlign"] == PDFBoolean(true),
+		BlackIs1:  parms.Entries["BlackIs1"] == PDFBoolean(true),
+	}
+	if p.Rows <= 0 {
+		p.Rows = DictInt(dict, "Height", 0)
+	}
+	return p
+}
+
+// decodeJBIG2Filter decodes JBIG2Decode data with the segments of the
+// JBIG2Globals stream parms names.
+func decodeJBIG2Filter(data []byte, parms PDFDict) ([]byte, error) {
+	var globals []byte
+	if g, ok := parms.Entries["JBIG2Globals"].(PDFDict); ok && g.HasStream {
+		var err error
+		if globals, err = DecodeStream(g); err != nil {
+			return nil, fmt.Errorf("JBIG2Globals: %w", err)
+		}
+	}
+	return DecodeJBIG2(data, globals)
+}
+
+// DecodeRunLength decodes a RunLengthDecode stream: a length byte n of 0 to
+// 127 copies the next n+1 bytes, 129 to 255 repeats the next byte 257-n
+// times, and 128 ends the data. Output past DefaultLimits().StreamBytes is a
+// *LimitError.
+func DecodeRunLength(data []byte) ([]byte, error) {
+	return decodeRunLength(data, defaultLimits.StreamBytes)
+}
+
+// decodeRunLength is DecodeRunLength with a cap of max output bytes; 0 means
+// none.
+func decodeRunLength(data []byte, max int64) ([]byte, error) {
+	out := make([]byte, 0, len(data)*2)
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n == 128:
+			return out, nil
+		case n < 128:
+			if i+n+1 > len(data) {
+				return nil, fmt.Errorf("RunLengthDecode: literal run of %d bytes past end of data", n+1)
+			}
+			out = append(out, data[i:i+n+1]...)
+			i += n + 1
+		default:
+			if i >= len(data) {
+				return nil, fmt.Errorf("RunLengthDecode: repeat run past end of data")
+			}
+			for range 257 - n {
+				out = append(out, data[i])
+			}
+			i++
+		}
+		if max > 0 && int64(len(out)) > max {
+			return nil, &LimitError{Limit: "StreamBytes", Max: max}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"fmt"
+	"testing"
+)
+
+// packLZWWidths packs codes MSB-first at the given per-code widths.
+func packLZWWidths(codes, widths []int) []byte {
+	var out []byte
+	var buf uint64
+	var nbits int
+	for i, code := range codes {
+		buf = buf<<widths[i] | uint64(code)
+		nbits += widths[i]
+		for nbits >= 8 {
+			out = append(out, byte(buf>>(nbits-8)))
+			nbits -= 8
+		}
+	}
+	if nbits > 0 {
+		out = append(out, byte(buf<<(8-nbits)))
+	}
+	return out
+}
+
+// lzwLiterals returns n literal codes and EOD with the code widths an
+// encoder with the given EarlyChange uses: each code after the first adds
+// a table entry, and the width grows when the next code reaches 512 (one
+// sooner with EarlyChange).
+func lzwLiterals(n, early int) (codes, widths []int, plain []byte) {
+	width, next := 9, lzwFirstCode
+	for i := range n + 1 {
+		code := lzwEOD
+		if i < n {
+			code = i % 256
+			plain = append(plain, byte(code))
+		}
+		codes = append(codes, code)
+		widths = append(widths, width)
+		if i > 0 {
+			next++
+			switch next + early {
+			case 512:
+				width = 10
+			case 1024:
+				width = 11
+			}
+		}
+	}
+	return codes, widths, plain
+}
+
+func TestDecodeStreamFilterChain(t *testing.T) {
+	flate := func(raw []byte) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(raw)
+		zw.Close()
+		return buf.Bytes()
+	}
+	a85 := func(raw []byte) []byte {
+		enc := make([]byte, ascii85.MaxEncodedLen(len(raw)))
+		return append(enc[:ascii85.Encode(enc, raw)], "~>"...)
+	}
+	name := func(n string) PDFName { return PDFName{Value: n} }
+	parms := func(kv ...any) PDFDict {
+		d := NewPDFDict()
+		for i := 0; i < len(kv); i += 2 {
+			d.Entries[kv[i].(string)] = kv[i+1].(PDFValue)
+		}
+		return d
+	}
+
+	lzwText := packLZWCodes([]int{'B', 'T', lzwEOD}, 9)
+	codes, widths, literals := lzwLiterals(600, 0)
+	lateLZW := packLZWWidths(codes, widths)
+	// Two rows of PNG Up: [1 2] then [+10 +10].
+	predicted := []byte{2, 1, 2, 2, 10, 10}
+
+	for _, tc := range []struct {
+		name    string
+		entries map[string]PDFValue
+		raw     []byte
+		want    []byte
+	}{
+		{"ASCII85 then LZW", map[string]PDFValue{"Filter": PDFArray{name("A85"), name("LZW")}}, a85(lzwText), []byte("BT")},
+		{"Flate then LZW", map[string]PDFValue{"Filter": PDFArray{name("FlateDecode"), name("LZWDecode")}}, flate(lzwText), []byte("BT")},
+		{"LZW EarlyChange 0", map[string]PDFValue{"Filter": name("LZWDecode"), "DecodeParms": parms("EarlyChange", PDFInteger(0))}, lateLZW, literals},
+		{"RunLength", map[string]PDFValue{"Filter": name("RunLengthDecode")}, []byte{2, 'a', 'b', 'c', 254, 'z', 128}, []byte("abczzz")},
+		{"per-filter parms", map[string]PDFValue{
+			"Filter":      PDFArray{name("ASCIIHexDecode"), name("FlateDecode")},
+			"DecodeParms": PDFArray{nil, parms("Predictor", PDFInteger(12), "Columns", PDFInteger(2))},
+		}, []byte(fmt.Sprintf("%X>", flate(predicted))), []byte{1, 2, 11, 12}},
+		{"lone parms belong to the last filter", map[string]PDFValue{
+			"Filter":      PDFArray{name("ASCII85Decode"), name("FlateDecode")},
+			"DecodeParms": parms("Predictor", PDFInteger(2), "Columns", PDFInteger(3)),
+		}, a85(flate([]byte{1, 2, 3})), []byte{1, 3, 6}},
+		{"predictor Columns from Width", map[string]PDFValue{
+			"Filter": name("Fl"), "Width": PDFInteger(2), "DecodeParms": parms("Predictor", PDFInteger(12)),
+		}, flate(predicted), []byte{1, 2, 11, 12}},
+		{"CCITT", map[string]PDFValue{
+			"Filter":      PDFArray{name("AHx"), name("CCF")},
+			"DecodeParms": PDFArray{nil, parms("Columns", PDFInteger(8), "Rows", PDFInteger(1))},
+		}, []byte("98>"), []byte{0xFF}},
+		{"CCITT Rows from Height", map[string]PDFValue{
+			"Filter": name("CCITTFaxDecode"), "Height": PDFInteger(2), "DecodeParms": parms("Columns", PDFInteger(8)),
+		}, []byte{0x98}, []byte{0xFF, 0xFF}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := PDFDict{Entries: tc.entries, HasStream: true, RawStream: tc.raw}
+			got, err := DecodeStream(d)
+			if err != nil || !bytes.Equal(got, tc.want) {
+				t.Errorf("DecodeStream = %.40v, %v; want %.40v", got, err, tc.want)
+			}
+		})
+	}
+
+	// Read with the default EarlyChange, the late-growing codes misparse.
+	if got, err := DecodeLZW(lateLZW); err == nil && bytes.Equal(got, literals) {
+		t.Error("EarlyChange 0 data decoded identically with EarlyChange 1")
+	}
+}
+
+func TestDecodeStreamToCodec(t *testing.T) {
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xD9}
+	for _, tc := range []struct {
+		name      string
+		filter    PDFValue
+		raw       []byte
+		want      []byte
+		wantCodec string
+		wantErr   bool
+	}{
+		{"DCT", PDFName{Value: "DCT"}, jpeg, jpeg, "DCTDecode", false},
+		{"ASCIIHex over JPX", PDFArray{PDFName{Value: "ASCIIHexDecode"}, PDFName{Value: "JPXDecode"}}, []byte("FFD8FFD9>"), jpeg, "JPXDecode", false},
+		{"JBIG2 is decoded", PDFName{Value: "JBIG2Decode"}, []byte{1}, nil, "", true},
+		{"no codec", PDFName{Value: "ASCIIHexDecode"}, []byte("41>"), []byte("A"), "", false},
+		{"filter after codec", PDFArray{PDFName{Value: "DCTDecode"}, PDFName{Value: "FlateDecode"}}, jpeg, nil, "", true},
+		{"unknown filter", PDFName{Value: "FooDecode"}, jpeg, nil, "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := PDFDict{Entries: map[string]PDFValue{"Filter": tc.filter}, HasStream: true, RawStream: tc.raw}
+			got, codec, err := DecodeStreamToCodec(d)
+			if (err != nil) != tc.wantErr || codec != tc.wantCodec || !bytes.Equal(got, tc.want) {
+				t.Errorf("DecodeStreamToCodec = %x, %q, %v; want %x, %q, error %v", got, codec, err, tc.want, tc.wantCodec, tc.wantErr)
+			}
+			if _, err := DecodeStream(d); (err == nil) != (tc.wantCodec == "" && !tc.wantErr) {
+				t.Errorf("DecodeStream error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeRunLength(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      []byte
+		want    string
+		wantErr bool
+	}{
+		{"literal and repeat", []byte{1, 'h', 'i', 255, '!', 128, 'x'}, "hi!!", false},
+		{"no EOD", []byte{0, 'a'}, "a", false},
+		{"empty", nil, "", false},
+		{"truncated literal", []byte{3, 'a'}, "", true},
+		{"truncated repeat", []byte{200}, "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeRunLength(tc.in)
+			if (err != nil) != tc.wantErr || (!tc.wantErr && string(got) != tc.want) {
+				t.Errorf("DecodeRunLength = %q, %v; want %q, error %v", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
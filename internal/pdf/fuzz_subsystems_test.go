@@ -19,7 +19,7 @@ import (
 func FuzzDecodeStream(f *testing.F) {
 	f.Add([]byte("q\nQ\n"))
 	f.Add([]byte("789c030000000001")) // near-empty zlib-ish
-	filters := []string{"FlateDecode", "ASCIIHexDecode", "ASCII85Decode", "LZWDecode", "BogusDecode"}
+	filters := []string{"FlateDecode", "ASCIIHexDecode", "ASCII85Decode", "LZWDecode", "RunLengthDecode", "CCITTFaxDecode", "DCTDecode", "BogusDecode"}
 	f.Fuzz(func(t *testing.T, data []byte) {
 		if len(data) > 1<<20 {
 			return
@@ -77,6 +77,57 @@ func FuzzDecodeLZW(f *testing.F) {
 	})
 }
 
+func FuzzDecodeRunLength(f *testing.F) {
+	f.Add([]byte{2, 'a', 'b', 'c', 254, 'z', 128})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 1<<20 {
+			return
+		}
+		pdf.DecodeRunLength(data)
+	})
+}
+
+// FuzzDecodeJBIG2 fuzzes the embedded stream and the globals, seeded with
+// a page information segment and an MMR generic region.
+func FuzzDecodeJBIG2(f *testing.F) {
+	f.Add([]byte{
+		0, 0, 0, 0, 48, 0, 1, 0, 0, 0, 19,
+		0, 0, 0, 8, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0x44, 0, 0,
+		0, 0, 0, 1, 38, 0, 1, 0, 0, 0, 19,
+		0, 0, 0, 8, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 4, 1, 0x80,
+	}, []byte(nil))
+	f.Fuzz(func(t *testing.T, data, globals []byte) {
+		if len(data)+len(globals) > 1<<16 {
+			return
+		}
+		pdf.DecodeJBIG2(data, globals)
+	})
+}
+
+// FuzzDecodeJPX fuzzes the JPEG 2000 decoder, seeded with a lossless 4x4
+// codestream. Images the header declares larger than 256x256 are skipped
+// so the target exercises decoding rather than the size limit.
+func FuzzDecodeJPX(f *testing.F) {
+	f.Add([]byte{
+		0xFF, 0x4F, 0xFF, 0x51, 0x00, 0x29, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x04,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x04,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x07, 0x01, 0x01, 0xFF, 0x52, 0x00,
+		0x0C, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFF, 0x5C, 0x00, 0x07, 0x40,
+		0x48, 0x50, 0x50, 0x58, 0xFF, 0x90, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x25, 0x00, 0x01,
+		0xFF, 0x93, 0xCF, 0xC0, 0x14, 0x07, 0xCF, 0x96, 0x35, 0x59, 0xC1, 0xF5, 0x02, 0x41, 0xF5, 0x02,
+		0x00, 0x0C, 0xFF, 0x61, 0x3F, 0x0B, 0x3C, 0x63, 0x3F, 0xFF, 0xD9,
+	})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 1<<16 {
+			return
+		}
+		if info, err := pdf.ParseJPXHeader(data); err == nil && info.Width*info.Height*info.Components > 1<<16 {
+			return
+		}
+		pdf.DecodeJPX(data)
+	})
+}
+
 // FuzzDecodeCCITT fuzzes both the encoded data and the DecodeParms, with params
 // bounded so the target exercises decoding logic rather than a legitimate
 // (already-guarded) huge-allocation rejection.
@@ -137,6 +188,22 @@ func FuzzTokenizeContent(f *testing.F) {
 	})
 }
 
+// --- ToUnicode CMaps --------------------------------------------------------
+
+func FuzzParseToUnicodeCMap(f *testing.F) {
+	f.Add([]byte("1 begincodespacerange <00> <FF> endcodespacerange 1 beginbfchar <41> <0041> endbfchar"))
+	f.Add([]byte("1 beginbfrange <0000> <FFFF> [<0041> <D835DC00>] endbfrange"))
+	f.Add([]byte("1 beginbfrange <00000000> <FFFFFFFF> <FFFF> endbfrange"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 1<<20 {
+			return
+		}
+		m := pdf.ParseToUnicodeCMap(data)
+		m.Lookup(0x41, 1)
+		m.Each(func(uint32, int, string) {})
+	})
+}
+
 func clampInt(v, lo, hi int) int {
 	if v < lo {
 		return lo
@@ -0,0 +1,547 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// This file implements a JBIG2 (ITU-T T.88) decoder, the codec behind PDF's
+// JBIG2Decode filter, which DecodeStream runs. It reads the embedded stream
+// format PDF uses (ISO 32000-2 7.4.7): segments without a file header, with
+// any segments shared between images in a separate JBIG2Globals stream. It
+// decodes generic regions (arithmetic or MMR), symbol dictionaries and text
+// regions (arithmetic or Huffman, with the standard or custom tables), and
+// generic refinement regions, which between them cover what JBIG2 encoders
+// for scanned documents produce; halftone regions are reported as
+// unsupported. The rasterizer uses it to reproduce JBIG2 scans when a page
+// is flattened; there a decode failure falls back to a placeholder.
+
+type jbig2Error string
+
+func (e jbig2Error) Error() string { return string(e) }
+
+func jbig2Errorf(format string, args ...any) error {
+	return jbig2Error("jbig2: " + fmt.Sprintf(format, args...))
+}
+
+// JBIG2 segment types (T.88 7.3).
+const (
+	jbig2SymbolDictionary          = 0
+	jbig2IntermediateText          = 4
+	jbig2ImmediateText             = 6
+	jbig2ImmediateLosslessText     = 7
+	jbig2PatternDictionary         = 16
+	jbig2IntermediateHalftone      = 20
+	jbig2ImmediateHalftone         = 22
+	jbig2ImmediateLosslessHalftone = 23
+	jbig2IntermediateGeneric       = 36
+	jbig2ImmediateGeneric          = 38
+	jbig2ImmediateLosslessGeneric  = 39
+	jbig2IntermediateRefinement    = 40
+	jbig2ImmediateRefinement       = 42
+	jbig2ImmediateLosslessRefine   = 43
+	jbig2PageInformation           = 48
+	jbig2EndOfPage                 = 49
+	jbig2EndOfStripe               = 50
+	jbig2EndOfFile                 = 51
+	jbig2Tables                    = 53
+)
+
+// maxJBIG2Symbols bounds the symbol and symbol-instance counts segments
+// declare, each of which costs the decoder a bitmap or a composite.
+const maxJBIG2Symbols = 1 << 20
+
+// jbig2Segment is a segment header (T.88 7.2) and its data.
+type jbig2Segment struct {
+	number   uint32
+	typ      int
+	referred []uint32
+	data     []byte
+}
+
+// parseJBIG2Segments splits data in the sequential organization into its
+// segments.
+func parseJBIG2Segments(data []byte) ([]jbig2Segment, error) {
+	var segs []jbig2Segment
+	r := jbig2Reader{data: data}
+	for r.pos < len(data) {
+		var s jbig2Segment
+		s.number = r.u32()
+		flags := r.u8()
+		s.typ = int(flags & 0x3F)
+		count := int(r.u8())
+		if count>>5 == 7 {
+			r.pos--
+			count = int(r.u32() & 0x1FFFFFFF)
+			r.pos += (count + 8) / 8
+		} else {
+			count >>= 5
+		}
+		if count > len(data) {
+			return nil, jbig2Errorf("segment %d refers to %d segments", s.number, count)
+		}
+		for range count {
+			switch {
+			case s.number <= 256:
+				s.referred = append(s.referred, uint32(r.u8()))
+			case s.number <= 65536:
+				s.referred = append(s.referred, uint32(r.u16()))
+			default:
+				s.referred = append(s.referred, r.u32())
+			}
+		}
+		if flags&0x40 != 0 {
+			r.u32()
+		} else {
+			r.u8()
+		}
+		length := r.u32()
+		if r.err != nil {
+			return nil, jbig2Errorf("truncated segment header")
+		}
+		if length == 0xFFFFFFFF {
+			return nil, jbig2Errorf("segment %d has unknown length", s.number)
+		}
+		if uint64(length) > uint64(len(data)-r.pos) {
+			return nil, jbig2Errorf("segment %d data past end of stream", s.number)
+		}
+		s.data = data[r.pos : r.pos+int(length)]
+		r.pos += int(length)
+		segs = append(segs, s)
+		if s.typ == jbig2EndOfFile {
+			break
+		}
+	}
+	return segs, nil
+}
+
+// jbig2Reader reads the big-endian fields of segment headers and data,
+// recording rather than panicking on truncation.
+type jbig2Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *jbig2Reader) next(n int) []byte {
+	if r.err != nil || r.pos+n > len(r.data) {
+		r.err = jbig2Errorf("truncated segment")
+		r.pos = len(r.data)
+		return make([]byte, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *jbig2Reader) u8() byte    { return r.next(1)[0] }
+func (r *jbig2Reader) u16() uint16 { return binary.BigEndian.Uint16(r.next(2)) }
+func (r *jbig2Reader) u32() uint32 { return binary.BigEndian.Uint32(r.next(4)) }
+func (r *jbig2Reader) i8() int     { return int(int8(r.u8())) }
+
+// at reads n adaptive template pixels.
+func (r *jbig2Reader) at(n int) []jbig2Pixel {
+	at := make([]jbig2Pixel, n)
+	for i := range at {
+		at[i].x = r.i8()
+		at[i].y = r.i8()
+	}
+	return at
+}
+
+// jbig2RegionInfo is a region segment information field (T.88 7.4.1).
+type jbig2RegionInfo struct {
+	w, h, x, y int
+	combOp     int
+}
+
+func (r *jbig2Reader) regionInfo() jbig2RegionInfo {
+	var ri jbig2RegionInfo
+	ri.w = int(r.u32())
+	ri.h = int(r.u32())
+	ri.x = int(r.u32())
+	ri.y = int(r.u32())
+	ri.combOp = int(r.u8() & 7)
+	return ri
+}
+
+// jbig2Decoder holds the state of decoding one page: its bitmap, and the
+// symbol dictionaries, intermediate regions and Huffman tables later
+// segments refer to.
+type jbig2Decoder struct {
+	page          *jbig2Bitmap
+	pageDefault   byte
+	pageOp        int
+	pageOverride  bool
+	stripedHeight bool
+	budget        jbig2Budget
+	symbols       map[uint32][]*jbig2Bitmap
+	regions       map[uint32]*jbig2Bitmap
+	tables        map[uint32]*jbig2HuffTable
+}
+
+// DecodeJBIG2 decodes an embedded JBIG2 stream and the segments of its
+// JBIG2Globals stream (nil for none) into the page's samples as a PDF
+// image reads them: packed 1-bit-per-pixel rows, row-padded to a byte
+// boundary, with 0 for black.
+func DecodeJBIG2(data, globals []byte) ([]byte, error) {
+	gsegs, err := parseJBIG2Segments(globals)
+	if err != nil {
+		return nil, err
+	}
+	segs, err := parseJBIG2Segments(data)
+	if err != nil {
+		return nil, err
+	}
+	d := &jbig2Decoder{
+		budget:  maxJBIG2Work,
+		symbols: map[uint32][]*jbig2Bitmap{},
+		regions: map[uint32]*jbig2Bitmap{},
+		tables:  map[uint32]*jbig2HuffTable{},
+	}
+	for _, s := range append(gsegs, segs...) {
+		if err := d.segment(s); err != nil {
+			return nil, err
+		}
+		if s.typ == jbig2EndOfPage {
+			break
+		}
+	}
+	if d.page == nil {
+		return nil, jbig2Errorf("no page information segment")
+	}
+	page := d.page
+	rowBytes := (page.w + 7) / 8
+	out := make([]byte, rowBytes*page.h)
+	for y := range page.h {
+		row := out[y*rowBytes : (y+1)*rowBytes]
+		for x := range page.w {
+			if page.pix[y*page.w+x] == 0 {
+				row[x>>3] |= 0x80 >> (x & 7)
+			}
+		}
+		// Padding bits past the last column read as white too.
+		for x := page.w; x < rowBytes*8; x++ {
+			row[x>>3] |= 0x80 >> (x & 7)
+		}
+	}
+	return out, nil
+}
+
+// segment decodes one segment into the page or the decoder's state.
+func (d *jbig2Decoder) segment(s jbig2Segment) error {
+	r := &jbig2Reader{data: s.data}
+	switch s.typ {
+	case jbig2PageInformation:
+		return d.pageInformation(r)
+	case jbig2EndOfStripe:
+		end := int(r.u32()) + 1
+		if r.err != nil {
+			return r.err
+		}
+		if d.page != nil && d.stripedHeight && end > d.page.h {
+			return d.growPage(end)
+		}
+		return nil
+	case jbig2SymbolDictionary:
+		return d.symbolDictionary(s, r)
+	case jbig2IntermediateText, jbig2ImmediateText, jbig2ImmediateLosslessText:
+		return d.textRegion(s, r)
+	case jbig2IntermediateGeneric, jbig2ImmediateGeneric, jbig2ImmediateLosslessGeneric:
+		return d.genericRegion(s, r)
+	case jbig2IntermediateRefinement, jbig2ImmediateRefinement, jbig2ImmediateLosslessRefine:
+		return d.refinementRegion(s, r)
+	case jbig2PatternDictionary, jbig2IntermediateHalftone, jbig2ImmediateHalftone, jbig2ImmediateLosslessHalftone:
+		return jbig2Errorf("halftone segments are not supported")
+	case jbig2Tables:
+		return d.tableSegment(s, r)
+	}
+	return nil
+}
+
+func (d *jbig2Decoder) pageInformation(r *jbig2Reader) error {
+	w := int(r.u32())
+	h := r.u32()
+	r.u32() // X resolution
+	r.u32() // Y resolution
+	flags := r.u8()
+	r.u16() // striping information
+	if r.err != nil {
+		return r.err
+	}
+	if h == 0xFFFFFFFF {
+		// Striped with the height still unknown: the page grows as
+		// regions arrive, and end-of-stripe segments give its extent.
+		d.stripedHeight = true
+		h = 0
+	}
+	page, err := newJBIG2Bitmap(w, int(h))
+	if err != nil {
+		return err
+	}
+	d.page = page
+	d.pageDefault = flags >> 2 & 1
+	d.pageOp = int(flags >> 3 & 3)
+	d.pageOverride = flags&0x40 != 0
+	if d.pageDefault != 0 {
+		for i := range page.pix {
+			page.pix[i] = 1
+		}
+	}
+	return nil
+}
+
+// place stores an intermediate region's bitmap for later refinement, or
+// draws an immediate region onto the page.
+func (d *jbig2Decoder) place(s jbig2Segment, ri jbig2RegionInfo, b *jbig2Bitmap) error {
+	switch s.typ {
+	case jbig2IntermediateText, jbig2IntermediateGeneric, jbig2IntermediateRefinement:
+		d.regions[s.number] = b
+		return nil
+	}
+	if d.page == nil {
+		return jbig2Errorf("region segment %d precedes the page information", s.number)
+	}
+	if d.stripedHeight && ri.y+b.h > d.page.h {
+		if err := d.growPage(ri.y + b.h); err != nil {
+			return err
+		}
+	}
+	op := d.pageOp
+	if d.pageOverride {
+		op = ri.combOp
+	}
+	d.page.compose(b, ri.x, ri.y, op)
+	return nil
+}
+
+// growPage extends a page of initially unknown height to h rows.
+func (d *jbig2Decoder) growPage(h int) error {
+	grown, err := newJBIG2Bitmap(d.page.w, h)
+	if err != nil {
+		return err
+	}
+	if err := d.budget.charge(len(grown.pix)); err != nil {
+		return err
+	}
+	copy(grown.pix, d.page.pix)
+	if d.pageDefault != 0 {
+		for i := len(d.page.pix); i < len(grown.pix); i++ {
+			grown.pix[i] = 1
+		}
+	}
+	d.page = grown
+	return nil
+}
+
+// genericRegion decodes a generic region segment (T.88 7.4.6).
+func (d *jbig2Decoder) genericRegion(s jbig2Segment, r *jbig2Reader) error {
+	ri := r.regionInfo()
+	flags := r.u8()
+	mmr := flags&1 != 0
+	tmpl := int(flags >> 1 & 3)
+	tpgdon := flags&8 != 0
+	var at []jbig2Pixel
+	if !mmr {
+		at = r.at(genericATCount[tmpl])
+	}
+	if r.err != nil {
+		return r.err
+	}
+	data := r.data[r.pos:]
+	var b *jbig2Bitmap
+	var err error
+	if mmr {
+		if err := d.budget.charge(ri.w * ri.h); err != nil {
+			return err
+		}
+		b, err = decodeJBIG2MMR(data, ri.w, ri.h)
+	} else {
+		b, err = newJBIG2Arith(data, &d.budget).decodeGeneric(ri.w, ri.h, tmpl, tpgdon, at)
+	}
+	if err != nil {
+		return err
+	}
+	return d.place(s, ri, b)
+}
+
+// decodeJBIG2MMR decodes an MMR-coded generic region, which is CCITT Group
+// 4 data with 1 bits for black.
+func decodeJBIG2MMR(data []byte, w, h int) (*jbig2Bitmap, error) {
+	b, err := newJBIG2Bitmap(w, h)
+	if err != nil {
+		return nil, err
+	}
+	if w == 0 || h == 0 {
+		return b, nil
+	}
+	packed, err := DecodeCCITT(data, CCITTParams{Columns: w, Rows: h, K: -1, BlackIs1: true})
+	if err != nil {
+		return nil, err
+	}
+	rowBytes := (w + 7) / 8
+	for y := 0; y < h && (y+1)*rowBytes <= len(packed); y++ {
+		for x := range w {
+			b.pix[y*w+x] = packed[y*rowBytes+x>>3] >> (7 - x&7) & 1
+		}
+	}
+	return b, nil
+}
+
+// refinementRegion decodes a generic refinement region segment (T.88
+// 7.4.7) against the intermediate region it refers to, or else the part of
+// the page it covers.
+func (d *jbig2Decoder) refinementRegion(s jbig2Segment, r *jbig2Reader) error {
+	ri := r.regionInfo()
+	flags := r.u8()
+	tmpl := int(flags & 1)
+	tpgron := flags&2 != 0
+	var at []jbig2Pixel
+	if tmpl == 0 {
+		at = r.at(2)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	var ref *jbig2Bitmap
+	for _, n := range s.referred {
+		if b, ok := d.regions[n]; ok {
+			ref = b
+			delete(d.regions, n)
+		}
+	}
+	fromPage := ref == nil
+	if fromPage {
+		if d.page == nil {
+			return jbig2Errorf("refinement segment %d has no reference", s.number)
+		}
+		var err error
+		if ref, err = newJBIG2Bitmap(ri.w, ri.h); err != nil {
+			return err
+		}
+		for y := range ri.h {
+			for x := range ri.w {
+				ref.pix[y*ri.w+x] = d.page.at(ri.x+x, ri.y+y)
+			}
+		}
+	}
+	b, err := newJBIG2Arith(r.data[r.pos:], &d.budget).decodeRefinement(ri.w, ri.h, tmpl, ref, 0, 0, tpgron, at)
+	if err != nil {
+		return err
+	}
+	if fromPage && s.typ != jbig2IntermediateRefinement {
+		// Refining the page replaces the pixels it was coded against.
+		d.page.compose(b, ri.x, ri.y, jbig2OpReplace)
+		return nil
+	}
+	return d.place(s, ri, b)
+}
+
+// referredSymbols concatenates the exported symbols of the symbol
+// dictionaries a segment refers to.
+func (d *jbig2Decoder) referredSymbols(s jbig2Segment) []*jbig2Bitmap {
+	var syms []*jbig2Bitmap
+	for _, n := range s.referred {
+		syms = append(syms, d.symbols[n]...)
+	}
+	return syms
+}
+
+// symbolDictionary decodes a symbol dictionary segment (T.88 7.4.2).
+func (d *jbig2Decoder) symbolDictionary(s jbig2Segment, r *jbig2Reader) error {
+	flags := r.u16()
+	p := jbig2SymbolDict{
+		huffman:     flags&1 != 0,
+		refAgg:      flags&2 != 0,
+		template:    int(flags >> 10 & 3),
+		refTemplate: int(flags >> 12 & 1),
+	}
+	if !p.huffman {
+		p.at = r.at(genericATCount[p.template])
+	}
+	if p.refAgg && p.refTemplate == 0 {
+		p.refAT = r.at(2)
+	}
+	p.numExported = int(r.u32())
+	p.numNewSymbols = int(r.u32())
+	if r.err != nil {
+		return r.err
+	}
+	in := d.referredSymbols(s)
+	if p.numExported > len(in)+p.numNewSymbols || p.numNewSymbols > maxJBIG2Symbols {
+		return jbig2Errorf("symbol dictionary %d: bad symbol counts", s.number)
+	}
+	var c jbig2Coder = newJBIG2Arith(r.data[r.pos:], &d.budget)
+	var h *jbig2Huff
+	if p.huffman {
+		var err error
+		if h, err = d.symbolHuffman(s, flags, r.data[r.pos:]); err != nil {
+			return err
+		}
+		c = h
+	}
+	syms, err := decodeSymbols(c, p, in)
+	if h != nil && h.bits.err != nil {
+		return h.bits.err
+	}
+	if err != nil {
+		return err
+	}
+	d.symbols[s.number] = syms
+	return nil
+}
+
+// textRegion decodes a text region segment (T.88 7.4.3).
+func (d *jbig2Decoder) textRegion(s jbig2Segment, r *jbig2Reader) error {
+	ri := r.regionInfo()
+	flags := r.u16()
+	huffman := flags&1 != 0
+	var huffFlags uint16
+	if huffman {
+		huffFlags = r.u16()
+	}
+	logStrips := int(flags >> 2 & 3)
+	p := jbig2TextRegion{
+		w:           ri.w,
+		h:           ri.h,
+		refine:      flags&2 != 0,
+		strips:      1 << logStrips,
+		refCorner:   int(flags >> 4 & 3),
+		transposed:  flags&0x40 != 0,
+		combOp:      int(flags >> 7 & 3),
+		defPixel:    byte(flags >> 9 & 1),
+		dsOffset:    int(flags >> 10 & 0x1F),
+		refTemplate: int(flags >> 15 & 1),
+	}
+	if p.dsOffset > 15 {
+		p.dsOffset -= 32
+	}
+	if p.refine && p.refTemplate == 0 {
+		p.refAT = r.at(2)
+	}
+	p.numInstances = int(r.u32())
+	if r.err != nil {
+		return r.err
+	}
+	syms := d.referredSymbols(s)
+	if p.numInstances > maxJBIG2Symbols || p.numInstances > 0 && len(syms) == 0 {
+		return jbig2Errorf("text region %d: bad symbol instances", s.number)
+	}
+	var c jbig2Coder = newJBIG2Arith(r.data[r.pos:], &d.budget)
+	var h *jbig2Huff
+	if huffman {
+		var err error
+		if h, err = d.textHuffman(s, huffFlags, logStrips, len(syms), r.data[r.pos:]); err != nil {
+			return err
+		}
+		c = h
+	}
+	b, err := decodeText(c, p, syms, symbolCodeLen(len(syms)))
+	if h != nil && h.bits.err != nil {
+		return h.bits.err
+	}
+	if err != nil {
+		return err
+	}
+	return d.place(s, ri, b)
+}
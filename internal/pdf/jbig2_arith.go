@@ -0,0 +1,462 @@
+package pdf
+
+import "sort"
+
+// This file holds the arithmetic-coding half of the JBIG2 decoder (ITU-T
+// T.88): the MQ decoder (Annex E), the integer and symbol-ID decoding
+// procedures (Annex A) and the generic and generic refinement region
+// decoding procedures (6.2, 6.3) that symbol dictionaries and text regions
+// are built from.
+
+// mqState is one row of the MQ coder's probability estimation table
+// (T.88 Table E.1).
+type mqState struct {
+	qe         uint32
+	nmps, nlps uint8
+	switchMPS  bool
+}
+
+var mqTable = [47]mqState{
+	{0x5601, 1, 1, true}, {0x3401, 2, 6, false}, {0x1801, 3, 9, false}, {0x0AC1, 4, 12, false},
+	{0x0521, 5, 29, false}, {0x0221, 38, 33, false}, {0x5601, 7, 6, true}, {0x5401, 8, 14, false},
+	{0x4801, 9, 14, false}, {0x3801, 10, 14, false}, {0x3001, 11, 17, false}, {0x2401, 12, 18, false},
+	{0x1C01, 13, 20, false}, {0x1601, 29, 21, false}, {0x5601, 15, 14, true}, {0x5401, 16, 14, false},
+	{0x5101, 17, 15, false}, {0x4801, 18, 16, false}, {0x3801, 19, 17, false}, {0x3401, 20, 18, false},
+	{0x3001, 21, 19, false}, {0x2801, 22, 19, false}, {0x2401, 23, 20, false}, {0x2201, 24, 21, false},
+	{0x1C01, 25, 22, false}, {0x1801, 26, 23, false}, {0x1601, 27, 24, false}, {0x1401, 28, 25, false},
+	{0x1201, 29, 26, false}, {0x1101, 30, 27, false}, {0x0AC1, 31, 28, false}, {0x09C1, 32, 29, false},
+	{0x08A1, 33, 30, false}, {0x0521, 34, 31, false}, {0x0441, 35, 32, false}, {0x02A1, 36, 33, false},
+	{0x0221, 37, 34, false}, {0x0141, 38, 35, false}, {0x0111, 39, 36, false}, {0x0085, 40, 37, false},
+	{0x0049, 41, 38, false}, {0x0025, 42, 39, false}, {0x0015, 43, 40, false}, {0x0009, 44, 41, false},
+	{0x0005, 45, 42, false}, {0x0001, 45, 43, false}, {0x5601, 46, 46, false},
+}
+
+// mqDecoder is the MQ arithmetic decoder of T.88 Annex E. Contexts are
+// byte slices holding each context's state index shifted left once, with
+// its MPS in the low bit. Reading past the end of the data supplies 1
+// bits, as the decoder does on reaching the 0xFF marker that ends it.
+type mqDecoder struct {
+	data []byte
+	pos  int
+	c    uint32
+	a    uint32
+	ct   int
+}
+
+func newMQDecoder(data []byte) *mqDecoder {
+	d := &mqDecoder{data: data}
+	d.c = uint32(d.byteAt(0)) << 16
+	d.byteIn()
+	d.c <<= 7
+	d.ct -= 7
+	d.a = 0x8000
+	return d
+}
+
+func (d *mqDecoder) byteAt(i int) byte {
+	if i < len(d.data) {
+		return d.data[i]
+	}
+	return 0xFF
+}
+
+// byteIn is BYTEIN (E.3.4), which stops consuming at a marker code.
+func (d *mqDecoder) byteIn() {
+	if d.byteAt(d.pos) == 0xFF {
+		if d.byteAt(d.pos+1) > 0x8F {
+			d.c += 0xFF00
+			d.ct = 8
+		} else {
+			d.pos++
+			d.c += uint32(d.byteAt(d.pos)) << 9
+			d.ct = 7
+		}
+	} else {
+		d.pos++
+		d.c += uint32(d.byteAt(d.pos)) << 8
+		d.ct = 8
+	}
+}
+
+// decode is DECODE (E.3.2) in context cx of contexts.
+func (d *mqDecoder) decode(contexts []byte, cx int) int {
+	st := contexts[cx]
+	idx, mps := st>>1, int(st&1)
+	s := &mqTable[idx]
+	d.a -= s.qe
+	var bit int
+	if d.c>>16 < s.qe {
+		// LPS_EXCHANGE
+		if d.a < s.qe {
+			bit = mps
+			idx = s.nmps
+		} else {
+			bit = 1 - mps
+			if s.switchMPS {
+				mps = 1 - mps
+			}
+			idx = s.nlps
+		}
+		d.a = s.qe
+	} else {
+		d.c -= s.qe << 16
+		if d.a&0x8000 != 0 {
+			return mps
+		}
+		// MPS_EXCHANGE
+		if d.a < s.qe {
+			bit = 1 - mps
+			if s.switchMPS {
+				mps = 1 - mps
+			}
+			idx = s.nlps
+		} else {
+			bit = mps
+			idx = s.nmps
+		}
+	}
+	for d.a&0x8000 == 0 {
+		if d.ct == 0 {
+			d.byteIn()
+		}
+		d.a <<= 1
+		d.c <<= 1
+		d.ct--
+	}
+	contexts[cx] = idx<<1 | byte(mps)
+	return bit
+}
+
+// Integer arithmetic decoding procedures (T.88 Table A.1).
+const (
+	iaDH = iota
+	iaDW
+	iaEX
+	iaAI
+	iaDT
+	iaFS
+	iaDS
+	iaIT
+	iaRI
+	iaRDW
+	iaRDH
+	iaRDX
+	iaRDY
+	numIAProcs
+)
+
+// jbig2Arith is the arithmetic decoding state of one segment: the decoder
+// and every context set its procedures use, shared when a symbol
+// dictionary decodes refinement/aggregate symbols as a text region. budget
+// is the page's remaining work (see maxJBIG2Work).
+type jbig2Arith struct {
+	d      *mqDecoder
+	gb     []byte
+	gr     []byte
+	ia     [numIAProcs][]byte
+	iaid   []byte
+	budget *jbig2Budget
+}
+
+func newJBIG2Arith(data []byte, budget *jbig2Budget) *jbig2Arith {
+	a := &jbig2Arith{d: newMQDecoder(data), gb: make([]byte, 1<<16), gr: make([]byte, 1<<13), budget: budget}
+	for i := range a.ia {
+		a.ia[i] = make([]byte, 512)
+	}
+	return a
+}
+
+// decodeInt is the integer decoding procedure (A.2) for proc; ok is false
+// for the out-of-band value.
+func (a *jbig2Arith) decodeInt(proc int) (v int, ok bool) {
+	cx := a.ia[proc]
+	prev := 1
+	bits := func(n int) int {
+		v := 0
+		for range n {
+			bit := a.d.decode(cx, prev)
+			if prev < 256 {
+				prev = prev<<1 | bit
+			} else {
+				prev = (prev<<1|bit)&511 | 256
+			}
+			v = v<<1 | bit
+		}
+		return v
+	}
+	sign := bits(1)
+	switch {
+	case bits(1) == 0:
+		v = bits(2)
+	case bits(1) == 0:
+		v = bits(4) + 4
+	case bits(1) == 0:
+		v = bits(6) + 20
+	case bits(1) == 0:
+		v = bits(8) + 84
+	case bits(1) == 0:
+		v = bits(12) + 340
+	default:
+		v = bits(32) + 4436
+	}
+	if sign == 1 {
+		if v == 0 {
+			return 0, false
+		}
+		return -v, true
+	}
+	return v, true
+}
+
+// decodeID is the IAID symbol ID decoding procedure (A.3) for codeLen-bit
+// symbol codes.
+func (a *jbig2Arith) decodeID(codeLen int) int {
+	if len(a.iaid) != 1<<(codeLen+1) {
+		a.iaid = make([]byte, 1<<(codeLen+1))
+	}
+	prev := 1
+	for range codeLen {
+		prev = prev<<1 | a.d.decode(a.iaid, prev)
+	}
+	return prev - 1<<codeLen
+}
+
+// jbig2Bitmap is a bilevel bitmap, one byte per pixel, 1 for black.
+type jbig2Bitmap struct {
+	w, h int
+	pix  []byte
+}
+
+// maxJBIG2Pixels bounds every bitmap the decoder allocates, since the
+// dimensions come straight from segment headers. maxJBIG2Work bounds the
+// pixels a page decodes and draws in all: arithmetic coding can describe
+// an enormous bitmap, or one drawn a million times, in a few bytes.
+const (
+	maxJBIG2Pixels = 1 << 27
+	maxJBIG2Work   = 1 << 27
+)
+
+func newJBIG2Bitmap(w, h int) (*jbig2Bitmap, error) {
+	if w < 0 || h < 0 || int64(w)*int64(h) > maxJBIG2Pixels {
+		return nil, jbig2Errorf("bitmap %dx%d too large", w, h)
+	}
+	return &jbig2Bitmap{w: w, h: h, pix: make([]byte, w*h)}, nil
+}
+
+// jbig2Budget is the pixels of work a page has left.
+type jbig2Budget int
+
+// charge spends n pixels of the budget.
+func (b *jbig2Budget) charge(n int) error {
+	if n > int(*b) {
+		return jbig2Errorf("page exceeds %d pixels of decoding work", maxJBIG2Work)
+	}
+	*b -= jbig2Budget(n)
+	return nil
+}
+
+// newBitmap allocates a bitmap the segment is about to decode.
+func (a *jbig2Arith) newBitmap(w, h int) (*jbig2Bitmap, error) {
+	b, err := newJBIG2Bitmap(w, h)
+	if err != nil {
+		return nil, err
+	}
+	return b, a.budget.charge(w * h)
+}
+
+func (a *jbig2Arith) charge(n int) error { return a.budget.charge(n) }
+
+// at returns the pixel at (x, y), 0 outside the bitmap.
+func (b *jbig2Bitmap) at(x, y int) byte {
+	if x < 0 || y < 0 || x >= b.w || y >= b.h {
+		return 0
+	}
+	return b.pix[y*b.w+x]
+}
+
+// Combination operators (T.88 7.4.1.5, 7.4.3.1.1).
+const (
+	jbig2OpOr = iota
+	jbig2OpAnd
+	jbig2OpXor
+	jbig2OpXnor
+	jbig2OpReplace
+)
+
+// compose combines src onto b with its top-left pixel at (x, y), dropping
+// pixels that fall outside b.
+func (b *jbig2Bitmap) compose(src *jbig2Bitmap, x, y, op int) {
+	for sy := range src.h {
+		dy := y + sy
+		if dy < 0 || dy >= b.h {
+			continue
+		}
+		for sx := range src.w {
+			dx := x + sx
+			if dx < 0 || dx >= b.w {
+				continue
+			}
+			s, d := src.pix[sy*src.w+sx], &b.pix[dy*b.w+dx]
+			switch op {
+			case jbig2OpOr:
+				*d |= s
+			case jbig2OpAnd:
+				*d &= s
+			case jbig2OpXor:
+				*d ^= s
+			case jbig2OpXnor:
+				*d = 1 - (*d ^ s)
+			default:
+				*d = s
+			}
+		}
+	}
+}
+
+// jbig2Pixel is a template pixel offset.
+type jbig2Pixel struct{ x, y int }
+
+// genericTemplates are the fixed pixels of generic region templates 0-3
+// (T.88 Figures 3-6); the adaptive pixels come from the segment.
+var genericTemplates = [4][]jbig2Pixel{
+	{{-1, -2}, {0, -2}, {1, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1}, {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}},
+	{{-1, -2}, {0, -2}, {1, -2}, {2, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1}, {-3, 0}, {-2, 0}, {-1, 0}},
+	{{-1, -2}, {0, -2}, {1, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {-2, 0}, {-1, 0}},
+	{{-3, -1}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}},
+}
+
+// genericATCount is the number of adaptive template pixels per template.
+var genericATCount = [4]int{4, 1, 1, 1}
+
+// genericSLTPContext is the context TPGDON decodes SLTP in (6.2.5.7).
+var genericSLTPContext = [4]int{0x9B25, 0x0795, 0x00E5, 0x0195}
+
+// decodeGeneric is the arithmetic generic region decoding procedure (6.2.5)
+// with template tmpl, adaptive pixels at and typical prediction tpgdon.
+func (a *jbig2Arith) decodeGeneric(w, h, tmpl int, tpgdon bool, at []jbig2Pixel) (*jbig2Bitmap, error) {
+	b, err := a.newBitmap(w, h)
+	if err != nil {
+		return nil, err
+	}
+	// The context packs the template pixels in raster order, first pixel
+	// most significant, which reproduces T.88's context numbering.
+	pixels := append(append([]jbig2Pixel(nil), genericTemplates[tmpl]...), at...)
+	sort.SliceStable(pixels, func(i, j int) bool {
+		if pixels[i].y != pixels[j].y {
+			return pixels[i].y < pixels[j].y
+		}
+		return pixels[i].x < pixels[j].x
+	})
+	// Away from the edges every template pixel is inside the bitmap, and
+	// is read at a fixed offset from the pixel being decoded.
+	offsets := make([]int, len(pixels))
+	var minX, maxX, minY, maxY int
+	for i, p := range pixels {
+		offsets[i] = p.y*w + p.x
+		minX, maxX = min(minX, p.x), max(maxX, p.x)
+		minY, maxY = min(minY, p.y), max(maxY, p.y)
+	}
+	ltp := 0
+	for y := range h {
+		row := b.pix[y*w : (y+1)*w]
+		if tpgdon {
+			ltp ^= a.d.decode(a.gb, genericSLTPContext[tmpl])
+			if ltp == 1 {
+				if y > 0 {
+					copy(row, b.pix[(y-1)*w:y*w])
+				}
+				continue
+			}
+		}
+		inner := y+minY >= 0 && y+maxY < h
+		for x := range w {
+			cx := 0
+			if inner && x+minX >= 0 && x+maxX < w {
+				i := y*w + x
+				for _, off := range offsets {
+					cx = cx<<1 | int(b.pix[i+off])
+				}
+			} else {
+				for _, p := range pixels {
+					cx = cx<<1 | int(b.at(x+p.x, y+p.y))
+				}
+			}
+			row[x] = byte(a.d.decode(a.gb, cx))
+		}
+	}
+	return b, nil
+}
+
+// refinementTemplates are the pixels of generic refinement templates 0 and
+// 1 (T.88 Figures 12, 13): those of the bitmap being decoded, then those of
+// the reference bitmap. Template 0 adds one adaptive pixel to each.
+var refinementTemplates = [2]struct{ coding, reference []jbig2Pixel }{
+	{
+		[]jbig2Pixel{{0, -1}, {1, -1}, {-1, 0}},
+		[]jbig2Pixel{{0, -1}, {1, -1}, {-1, 0}, {0, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}},
+	},
+	{
+		[]jbig2Pixel{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}},
+		[]jbig2Pixel{{0, -1}, {-1, 0}, {0, 0}, {1, 0}, {0, 1}, {1, 1}},
+	},
+}
+
+// refinementSLTPContext is the context TPGRON decodes SLTP in (6.3.5.6).
+var refinementSLTPContext = [2]int{0x0020, 0x0008}
+
+// decodeRefinement is the generic refinement region decoding procedure
+// (6.3.5): a w x h bitmap coded against ref, offset by (dx, dy).
+func (a *jbig2Arith) decodeRefinement(w, h, tmpl int, ref *jbig2Bitmap, dx, dy int, tpgron bool, at []jbig2Pixel) (*jbig2Bitmap, error) {
+	b, err := a.newBitmap(w, h)
+	if err != nil {
+		return nil, err
+	}
+	t := refinementTemplates[tmpl&1]
+	coding, reference := t.coding, t.reference
+	if tmpl&1 == 0 {
+		coding = append(append([]jbig2Pixel(nil), coding...), at[0])
+		reference = append(append([]jbig2Pixel(nil), reference...), at[1])
+	}
+	ltp := 0
+	for y := range h {
+		if tpgron {
+			ltp ^= a.d.decode(a.gr, refinementSLTPContext[tmpl&1])
+		}
+		for x := range w {
+			rx, ry := x-dx, y-dy
+			if ltp == 1 {
+				// TPGRPIX: a pixel whose reference neighbourhood is
+				// uniform takes the reference's value.
+				v := ref.at(rx, ry)
+				uniform := true
+				for ny := -1; ny <= 1 && uniform; ny++ {
+					for nx := -1; nx <= 1; nx++ {
+						if ref.at(rx+nx, ry+ny) != v {
+							uniform = false
+							break
+						}
+					}
+				}
+				if uniform {
+					b.pix[y*w+x] = v
+					continue
+				}
+			}
+			cx := 0
+			for _, p := range coding {
+				cx = cx<<1 | int(b.at(x+p.x, y+p.y))
+			}
+			for _, p := range reference {
+				cx = cx<<1 | int(ref.at(rx+p.x, ry+p.y))
+			}
+			b.pix[y*w+x] = byte(a.d.decode(a.gr, cx))
+		}
+	}
+	return b, nil
+}
+
+// refine is decodeRefinement for the symbol dictionary and text region
+// procedures, which never use TPGRON.
+func (a *jbig2Arith) refine(w, h, tmpl int, ref *jbig2Bitmap, dx, dy int, at []jbig2Pixel) (*jbig2Bitmap, error) {
+	return a.decodeRefinement(w, h, tmpl, ref, dx, dy, false, at)
+}
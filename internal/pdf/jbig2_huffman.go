@@ -0,0 +1,523 @@
+package pdf
+
+// This file holds the Huffman half of the JBIG2 decoder: the standard
+// tables of T.88 Annex B, code table segments (7.4.13), and jbig2Huff,
+// which feeds the symbol dictionary and text region procedures of
+// jbig2_text.go when a segment is Huffman-coded.
+
+// jbig2HuffLine is a line of a Huffman table (B.2): a prefLen-bit prefix
+// code followed by rangeLen bits of offset from rangeLow, counted down
+// from it on the lower range line. A prefLen of 0 leaves the line unused.
+type jbig2HuffLine struct {
+	prefLen, rangeLen, rangeLow int
+	lower                       bool
+	oob                         bool
+}
+
+// jbig2HuffCode is a prefix code of a given bit length.
+type jbig2HuffCode struct {
+	len  int
+	code uint32
+}
+
+// jbig2HuffTable is a Huffman table with its prefix codes assigned.
+type jbig2HuffTable struct {
+	lines  []jbig2HuffLine
+	codes  map[jbig2HuffCode]int
+	maxLen int
+}
+
+// newJBIG2HuffTable assigns the lines' prefix codes (B.3): shorter codes
+// first, and lines of one length in table order.
+func newJBIG2HuffTable(lines []jbig2HuffLine) (*jbig2HuffTable, error) {
+	t := &jbig2HuffTable{lines: lines, codes: map[jbig2HuffCode]int{}}
+	var count [33]int
+	for _, l := range lines {
+		if l.prefLen > 32 {
+			return nil, jbig2Errorf("Huffman table with %d-bit codes", l.prefLen)
+		}
+		count[l.prefLen]++
+		t.maxLen = max(t.maxLen, l.prefLen)
+	}
+	first := 0
+	for n := 1; n <= t.maxLen; n++ {
+		if n > 1 {
+			first = (first + count[n-1]) << 1
+		}
+		code := first
+		for i, l := range lines {
+			if l.prefLen != n {
+				continue
+			}
+			if code >= 1<<n {
+				return nil, jbig2Errorf("Huffman table has too many %d-bit codes", n)
+			}
+			t.codes[jbig2HuffCode{n, uint32(code)}] = i
+			code++
+		}
+	}
+	return t, nil
+}
+
+// jbig2StandardTable builds one of the tables of Annex B from its rows of
+// {PREFLEN, RANGELEN, RANGELOW} as the spec lists them, the last two the
+// lower and upper range lines when lower is set, and the PREFLEN of its
+// out-of-band value, 0 for none.
+func jbig2StandardTable(lower bool, oob int, rows [][3]int) *jbig2HuffTable {
+	var lines []jbig2HuffLine
+	for i, r := range rows {
+		lines = append(lines, jbig2HuffLine{prefLen: r[0], rangeLen: r[1], rangeLow: r[2], lower: lower && i == len(rows)-2})
+	}
+	if oob > 0 {
+		lines = append(lines, jbig2HuffLine{prefLen: oob, oob: true})
+	}
+	t, err := newJBIG2HuffTable(lines)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// jbig2StandardTables are the standard Huffman tables B.1 to B.15.
+var jbig2StandardTables = [15]*jbig2HuffTable{
+	// B.1
+	jbig2StandardTable(false, 0, [][3]int{
+		{1, 4, 0}, {2, 8, 16}, {3, 16, 272}, {3, 32, 65808},
+	}),
+	// B.2
+	jbig2StandardTable(false, 6, [][3]int{
+		{1, 0, 0}, {2, 0, 1}, {3, 0, 2}, {4, 3, 3}, {5, 6, 11}, {6, 32, 75},
+	}),
+	// B.3
+	jbig2StandardTable(true, 6, [][3]int{
+		{8, 8, -256}, {1, 0, 0}, {2, 0, 1}, {3, 0, 2}, {4, 3, 3}, {5, 6, 11},
+		{8, 32, -257}, {7, 32, 75},
+	}),
+	// B.4
+	jbig2StandardTable(false, 0, [][3]int{
+		{1, 0, 1}, {2, 0, 2}, {3, 0, 3}, {4, 3, 4}, {5, 6, 12}, {5, 32, 76},
+	}),
+	// B.5
+	jbig2StandardTable(true, 0, [][3]int{
+		{7, 8, -255}, {1, 0, 1}, {2, 0, 2}, {3, 0, 3}, {4, 3, 4}, {5, 6, 12},
+		{7, 32, -256}, {6, 32, 76},
+	}),
+	// B.6
+	jbig2StandardTable(true, 0, [][3]int{
+		{5, 10, -2048}, {4, 9, -1024}, {4, 8, -512}, {4, 7, -256}, {5, 6, -128},
+		{5, 5, -64}, {4, 5, -32}, {2, 7, 0}, {3, 7, 128}, {3, 8, 256}, {4, 9, 512},
+		{4, 10, 1024}, {6, 32, -2049}, {6, 32, 2048},
+	}),
+	// B.7
+	jbig2StandardTable(true, 0, [][3]int{
+		{4, 9, -1024}, {3, 8, -512}, {4, 7, -256}, {5, 6, -128}, {5, 5, -64},
+		{4, 5, -32}, {4, 5, 0}, {5, 5, 32}, {5, 6, 64}, {4, 7, 128}, {3, 8, 256},
+		{3, 9, 512}, {3, 10, 1024}, {5, 32, -1025}, {5, 32, 2048},
+	}),
+	// B.8
+	jbig2StandardTable(true, 2, [][3]int{
+		{8, 3, -15}, {9, 1, -7}, {8, 1, -5}, {9, 0, -3}, {7, 0, -2}, {4, 0, -1},
+		{2, 1, 0}, {5, 0, 2}, {6, 0, 3}, {3, 4, 4}, {6, 1, 20}, {4, 4, 22},
+		{4, 5, 38}, {5, 6, 70}, {5, 7, 134}, {6, 7, 262}, {7, 8, 390},
+		{6, 10, 646}, {9, 32, -16}, {9, 32, 1670},
+	}),
+	// B.9
+	jbig2StandardTable(true, 2, [][3]int{
+		{8, 4, -31}, {9, 2, -15}, {8, 2, -11}, {9, 1, -7}, {7, 1, -5}, {4, 1, -3},
+		{3, 1, -1}, {3, 1, 1}, {5, 1, 3}, {6, 1, 5}, {3, 5, 7}, {6, 2, 39},
+		{4, 5, 43}, {4, 6, 75}, {5, 7, 139}, {5, 8, 267}, {6, 8, 523}, {7, 9, 779},
+		{6, 11, 1291}, {9, 32, -32}, {9, 32, 3339},
+	}),
+	// B.10
+	jbig2StandardTable(true, 2, [][3]int{
+		{7, 4, -21}, {8, 0, -5}, {7, 0, -4}, {5, 0, -3}, {2, 2, -2}, {5, 0, 2},
+		{6, 0, 3}, {7, 0, 4}, {8, 0, 5}, {2, 6, 6}, {5, 5, 70}, {6, 5, 102},
+		{6, 6, 134}, {6, 7, 198}, {6, 8, 326}, {6, 9, 582}, {6, 10, 1094},
+		{7, 11, 2118}, {8, 32, -22}, {8, 32, 4166},
+	}),
+	// B.11
+	jbig2StandardTable(false, 0, [][3]int{
+		{1, 0, 1}, {2, 1, 2}, {4, 0, 4}, {4, 1, 5}, {5, 1, 7}, {5, 2, 9},
+		{6, 2, 13}, {7, 2, 17}, {7, 3, 21}, {7, 4, 29}, {7, 5, 45}, {7, 6, 77},
+		{7, 32, 141},
+	}),
+	// B.12
+	jbig2StandardTable(false, 0, [][3]int{
+		{1, 0, 1}, {2, 0, 2}, {3, 1, 3}, {5, 0, 5}, {5, 1, 6}, {6, 1, 8},
+		{7, 0, 10}, {7, 1, 11}, {7, 2, 13}, {7, 3, 17}, {7, 4, 25}, {8, 5, 41},
+		{8, 32, 73},
+	}),
+	// B.13
+	jbig2StandardTable(false, 0, [][3]int{
+		{1, 0, 1}, {3, 0, 2}, {4, 0, 3}, {5, 0, 4}, {4, 1, 5}, {3, 3, 7},
+		{6, 1, 15}, {6, 2, 17}, {6, 3, 21}, {6, 4, 29}, {6, 5, 45}, {7, 6, 77},
+		{7, 32, 141},
+	}),
+	// B.14
+	jbig2StandardTable(false, 0, [][3]int{
+		{3, 0, -2}, {3, 0, -1}, {1, 0, 0}, {3, 0, 1}, {3, 0, 2},
+	}),
+	// B.15
+	jbig2StandardTable(true, 0, [][3]int{
+		{7, 4, -24}, {6, 2, -8}, {5, 1, -4}, {4, 0, -2}, {3, 0, -1}, {1, 0, 0},
+		{3, 0, 1}, {4, 0, 2}, {5, 1, 3}, {6, 2, 5}, {7, 4, 9}, {7, 32, -25},
+		{7, 32, 25},
+	}),
+}
+
+// jbig2Bits reads the data of a Huffman-coded segment most significant bit
+// first, recording rather than panicking on truncation.
+type jbig2Bits struct {
+	data []byte
+	pos  int
+	bit  int
+	err  error
+}
+
+// read reads an n-bit unsigned value.
+func (b *jbig2Bits) read(n int) int {
+	v := 0
+	for range n {
+		if b.pos >= len(b.data) {
+			if b.err == nil {
+				b.err = jbig2Errorf("truncated segment")
+			}
+			return 0
+		}
+		v = v<<1 | int(b.data[b.pos]>>(7-b.bit)&1)
+		if b.bit++; b.bit == 8 {
+			b.pos, b.bit = b.pos+1, 0
+		}
+	}
+	return v
+}
+
+// align skips the bits left in the current byte.
+func (b *jbig2Bits) align() {
+	if b.bit > 0 {
+		b.pos, b.bit = b.pos+1, 0
+	}
+}
+
+// chunk aligns to a byte boundary and returns the n bytes there.
+func (b *jbig2Bits) chunk(n int) []byte {
+	b.align()
+	if n < 0 || n > len(b.data)-b.pos {
+		if b.err == nil {
+			b.err = jbig2Errorf("truncated segment")
+		}
+		b.pos = len(b.data)
+		return nil
+	}
+	c := b.data[b.pos : b.pos+n]
+	b.pos += n
+	return c
+}
+
+// decode reads a value coded with table t; ok is false for the out-of-band
+// value, and for a code t lacks, which records an error.
+func (b *jbig2Bits) decode(t *jbig2HuffTable) (v int, ok bool) {
+	code := uint32(0)
+	for n := 1; n <= t.maxLen && b.err == nil; n++ {
+		code = code<<1 | uint32(b.read(1))
+		i, found := t.codes[jbig2HuffCode{n, code}]
+		if !found {
+			continue
+		}
+		switch l := t.lines[i]; {
+		case l.oob:
+			return 0, false
+		case l.lower:
+			return l.rangeLow - b.read(l.rangeLen), true
+		default:
+			return l.rangeLow + b.read(l.rangeLen), true
+		}
+	}
+	if b.err == nil {
+		b.err = jbig2Errorf("bad Huffman code")
+	}
+	return 0, false
+}
+
+// symbolIDTable reads a text region's symbol ID Huffman table (7.4.3.1.7)
+// for n symbols: the code length of each, run-length coded with a table
+// that itself comes first.
+func (b *jbig2Bits) symbolIDTable(n int) (*jbig2HuffTable, error) {
+	runCodes := make([]jbig2HuffLine, 35)
+	for i := range runCodes {
+		runCodes[i] = jbig2HuffLine{prefLen: b.read(4), rangeLow: i}
+	}
+	runTable, err := newJBIG2HuffTable(runCodes)
+	if err != nil {
+		return nil, err
+	}
+	var lines []jbig2HuffLine
+	for len(lines) < n {
+		run, ok := b.decode(runTable)
+		if !ok {
+			return nil, b.err
+		}
+		repeat, prefLen := 1, run
+		switch run {
+		case 32:
+			if len(lines) == 0 {
+				return nil, jbig2Errorf("symbol ID table repeats no code length")
+			}
+			repeat, prefLen = 3+b.read(2), lines[len(lines)-1].prefLen
+		case 33:
+			repeat, prefLen = 3+b.read(3), 0
+		case 34:
+			repeat, prefLen = 11+b.read(7), 0
+		}
+		for range min(repeat, n-len(lines)) {
+			lines = append(lines, jbig2HuffLine{prefLen: prefLen, rangeLow: len(lines)})
+		}
+	}
+	b.align()
+	if b.err != nil {
+		return nil, b.err
+	}
+	return newJBIG2HuffTable(lines)
+}
+
+// tableSegment decodes a code table segment (7.4.13, B.2), a custom table
+// for the Huffman-coded segments that refer to it.
+func (d *jbig2Decoder) tableSegment(s jbig2Segment, r *jbig2Reader) error {
+	flags := r.u8()
+	low := int(int32(r.u32()))
+	high := int(int32(r.u32()))
+	if r.err != nil {
+		return r.err
+	}
+	prefBits, rangeBits := int(flags>>1&7)+1, int(flags>>4&7)+1
+	b := &jbig2Bits{data: r.data[r.pos:]}
+	var lines []jbig2HuffLine
+	for cur := low; b.err == nil; {
+		l := jbig2HuffLine{prefLen: b.read(prefBits), rangeLen: b.read(rangeBits), rangeLow: cur}
+		if l.rangeLen > 32 {
+			return jbig2Errorf("table segment %d: %d-bit range", s.number, l.rangeLen)
+		}
+		lines = append(lines, l)
+		if cur += 1 << l.rangeLen; cur >= high {
+			break
+		}
+	}
+	lines = append(lines,
+		jbig2HuffLine{prefLen: b.read(prefBits), rangeLen: 32, rangeLow: low - 1, lower: true},
+		jbig2HuffLine{prefLen: b.read(prefBits), rangeLen: 32, rangeLow: high})
+	if flags&1 != 0 {
+		lines = append(lines, jbig2HuffLine{prefLen: b.read(prefBits), oob: true})
+	}
+	if b.err != nil {
+		return b.err
+	}
+	t, err := newJBIG2HuffTable(lines)
+	if err != nil {
+		return err
+	}
+	d.tables[s.number] = t
+	return nil
+}
+
+// jbig2CustomTable marks the selector value that takes a table from the
+// segment's referred table segments.
+const jbig2CustomTable = -1
+
+// jbig2TableSelection resolves a segment's Huffman table selectors, which
+// pick a standard table or, in turn, the table segments it refers to.
+type jbig2TableSelection struct {
+	custom []*jbig2HuffTable
+	err    error
+}
+
+func (d *jbig2Decoder) tableSelection(s jbig2Segment) *jbig2TableSelection {
+	sel := &jbig2TableSelection{}
+	for _, n := range s.referred {
+		if t, ok := d.tables[n]; ok {
+			sel.custom = append(sel.custom, t)
+		}
+	}
+	return sel
+}
+
+// pick returns the table selector value v chooses among options, each the
+// number of a standard table, jbig2CustomTable, or 0 where v is invalid.
+func (sel *jbig2TableSelection) pick(v int, options ...int) *jbig2HuffTable {
+	switch opt := options[v]; {
+	case opt > 0:
+		return jbig2StandardTables[opt-1]
+	case opt == jbig2CustomTable && len(sel.custom) > 0:
+		t := sel.custom[0]
+		sel.custom = sel.custom[1:]
+		return t
+	case opt == jbig2CustomTable:
+		sel.err = jbig2Errorf("segment refers to too few table segments")
+	default:
+		sel.err = jbig2Errorf("bad Huffman table selection %d", v)
+	}
+	return jbig2StandardTables[0]
+}
+
+// jbig2Huff is the Huffman decoding state of one segment, the counterpart
+// of jbig2Arith: a table for each integer procedure it uses, the tables of
+// collective bitmap and refinement sizes, and the symbol ID table (nil to
+// read fixed-length IDs). The segment's refinements are arithmetic-coded,
+// each in its own run of bytes, sharing the contexts in gr.
+type jbig2Huff struct {
+	bits      *jbig2Bits
+	tables    [numIAProcs]*jbig2HuffTable
+	bmSize    *jbig2HuffTable
+	rSize     *jbig2HuffTable
+	ids       *jbig2HuffTable
+	logStrips int
+	gr        []byte
+	budget    *jbig2Budget
+}
+
+func newJBIG2Huff(data []byte, budget *jbig2Budget) *jbig2Huff {
+	return &jbig2Huff{bits: &jbig2Bits{data: data}, gr: make([]byte, 1<<13), budget: budget}
+}
+
+// symbolHuffman sets up the decoding of a Huffman-coded symbol dictionary:
+// the tables its flags select (7.4.2.1.1), and the fixed ones of its
+// refinement/aggregate symbols (6.5.8.2, Table 17).
+func (d *jbig2Decoder) symbolHuffman(s jbig2Segment, flags uint16, data []byte) (*jbig2Huff, error) {
+	h := newJBIG2Huff(data, &d.budget)
+	sel := d.tableSelection(s)
+	h.tables[iaDH] = sel.pick(int(flags>>2&3), 4, 5, 0, jbig2CustomTable)
+	h.tables[iaDW] = sel.pick(int(flags>>4&3), 2, 3, 0, jbig2CustomTable)
+	h.bmSize = sel.pick(int(flags>>6&1), 1, jbig2CustomTable)
+	h.tables[iaAI] = sel.pick(int(flags>>7&1), 1, jbig2CustomTable)
+	if sel.err != nil {
+		return nil, sel.err
+	}
+	h.tables[iaEX] = jbig2StandardTables[0]
+	h.tables[iaFS] = jbig2StandardTables[5]
+	h.tables[iaDS] = jbig2StandardTables[7]
+	h.tables[iaDT] = jbig2StandardTables[10]
+	for _, proc := range []int{iaRDW, iaRDH, iaRDX, iaRDY} {
+		h.tables[proc] = jbig2StandardTables[14]
+	}
+	h.rSize = jbig2StandardTables[0]
+	return h, nil
+}
+
+// textHuffman sets up the decoding of a Huffman-coded text region: the
+// tables its Huffman flags select (7.4.3.1.2), and its symbol ID table for
+// numSyms symbols, read from the start of data.
+func (d *jbig2Decoder) textHuffman(s jbig2Segment, flags uint16, logStrips, numSyms int, data []byte) (*jbig2Huff, error) {
+	h := newJBIG2Huff(data, &d.budget)
+	h.logStrips = logStrips
+	sel := d.tableSelection(s)
+	h.tables[iaFS] = sel.pick(int(flags&3), 6, 7, 0, jbig2CustomTable)
+	h.tables[iaDS] = sel.pick(int(flags>>2&3), 8, 9, 10, jbig2CustomTable)
+	h.tables[iaDT] = sel.pick(int(flags>>4&3), 11, 12, 13, jbig2CustomTable)
+	for i, proc := range []int{iaRDW, iaRDH, iaRDX, iaRDY} {
+		h.tables[proc] = sel.pick(int(flags>>(6+2*i)&3), 14, 15, 0, jbig2CustomTable)
+	}
+	h.rSize = sel.pick(int(flags>>14&1), 1, jbig2CustomTable)
+	if sel.err != nil {
+		return nil, sel.err
+	}
+	var err error
+	h.ids, err = h.bits.symbolIDTable(numSyms)
+	return h, err
+}
+
+// decodeInt decodes the value of an integer procedure with its table,
+// except the text region's T offset and refinement flag, which are plain
+// bits (6.4.9, 6.4.11).
+func (h *jbig2Huff) decodeInt(proc int) (int, bool) {
+	switch proc {
+	case iaIT:
+		return h.bits.read(h.logStrips), true
+	case iaRI:
+		return h.bits.read(1), true
+	}
+	return h.bits.decode(h.tables[proc])
+}
+
+// decodeID decodes a symbol ID with the symbol ID table, or as a
+// codeLen-bit number when there is none.
+func (h *jbig2Huff) decodeID(codeLen int) int {
+	if h.ids == nil {
+		return h.bits.read(codeLen)
+	}
+	id, _ := h.bits.decode(h.ids)
+	return id
+}
+
+// refine decodes a refinement coded in the byte-aligned run whose size
+// comes first (6.4.11).
+func (h *jbig2Huff) refine(w, ht, tmpl int, ref *jbig2Bitmap, dx, dy int, at []jbig2Pixel) (*jbig2Bitmap, error) {
+	size, _ := h.bits.decode(h.rSize)
+	data := h.bits.chunk(size)
+	if h.bits.err != nil {
+		return nil, h.bits.err
+	}
+	a := &jbig2Arith{d: newMQDecoder(data), gr: h.gr, budget: h.budget}
+	return a.decodeRefinement(w, ht, tmpl, ref, dx, dy, false, at)
+}
+
+func (h *jbig2Huff) newBitmap(w, ht int) (*jbig2Bitmap, error) {
+	b, err := newJBIG2Bitmap(w, ht)
+	if err != nil {
+		return nil, err
+	}
+	return b, h.budget.charge(w * ht)
+}
+
+func (h *jbig2Huff) charge(n int) error { return h.budget.charge(n) }
+
+// collectiveBitmap decodes a height class collective bitmap (6.5.9), the
+// class's symbols side by side, uncompressed or MMR-coded, and cuts it
+// into symbols of the given widths.
+func (h *jbig2Huff) collectiveBitmap(widths []int, height int) ([]*jbig2Bitmap, error) {
+	total := 0
+	for _, w := range widths {
+		if total += w; total > maxJBIG2Pixels {
+			return nil, jbig2Errorf("symbol dictionary: height class %d pixels wide", total)
+		}
+	}
+	size, _ := h.bits.decode(h.bmSize)
+	if total == 0 {
+		h.bits.chunk(size)
+		return nil, h.bits.err
+	}
+	all, err := h.newBitmap(total, height)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		// Stored uncompressed, each row padded to a byte boundary.
+		rowBytes := (total + 7) / 8
+		data := h.bits.chunk(rowBytes * height)
+		if h.bits.err != nil {
+			return nil, h.bits.err
+		}
+		for y := range height {
+			for x := range total {
+				all.pix[y*total+x] = data[y*rowBytes+x>>3] >> (7 - x&7) & 1
+			}
+		}
+	} else {
+		data := h.bits.chunk(size)
+		if h.bits.err != nil {
+			return nil, h.bits.err
+		}
+		if all, err = decodeJBIG2MMR(data, total, height); err != nil {
+			return nil, err
+		}
+	}
+	syms := make([]*jbig2Bitmap, len(widths))
+	x := 0
+	for i, w := range widths {
+		sym, _ := newJBIG2Bitmap(w, height)
+		for y := range height {
+			copy(sym.pix[y*w:(y+1)*w], all.pix[y*total+x:])
+		}
+		syms[i] = sym
+		x += w
+	}
+	return syms, nil
+}
@@ -0,0 +1,776 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestMQDecoderConformance decodes the arithmetic coder test sequence of
+// ITU-T T.88 Annex H.2, every bit in one context.
+func TestMQDecoderConformance(t *testing.T) {
+	plain := []byte{
+		0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x00, 0xC0, 0x03, 0x52, 0x87, 0x2A, 0xAA, 0xAA, 0xAA, 0xAA,
+		0x82, 0xC0, 0x20, 0x00, 0xFC, 0xD7, 0x9E, 0xF6, 0xBF, 0x7F, 0xED, 0x90, 0x4F, 0x46, 0xA3, 0xBF,
+	}
+	coded := []byte{
+		0x84, 0xC7, 0x3B, 0xFC, 0xE1, 0xA1, 0x43, 0x04, 0x02, 0x20, 0x00, 0x00, 0x41, 0x0D, 0xBB, 0x86,
+		0xF4, 0x31, 0x7F, 0xFF, 0x88, 0xFF, 0x37, 0x47, 0x1A, 0xDB, 0x6A, 0xDF, 0xFF, 0xAC,
+	}
+	d := newMQDecoder(coded)
+	cx := make([]byte, 1)
+	got := make([]byte, len(plain))
+	for i := range len(plain) * 8 {
+		got[i/8] |= byte(d.decode(cx, 0)) << (7 - i%8)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decoded % X\nwant    % X", got, plain)
+	}
+
+	e := &mqEncoder{}
+	ecx := make([]byte, 1)
+	for i := range len(plain) * 8 {
+		e.encode(ecx, 0, int(plain[i/8]>>(7-i%8)&1))
+	}
+	if enc := e.flush(); !bytes.Equal(enc, coded) {
+		t.Errorf("test encoder produced % X\nwant % X", enc, coded)
+	}
+}
+
+// mqEncoder is the MQ encoder of T.88 Annex E.2, which the tests use to
+// build arithmetic-coded segments.
+type mqEncoder struct {
+	out []byte
+	c   uint32
+	a   uint32
+	ct  int
+}
+
+func (e *mqEncoder) init() {
+	if e.a == 0 {
+		e.a, e.ct, e.out = 0x8000, 12, []byte{0}
+	}
+}
+
+func (e *mqEncoder) encode(contexts []byte, cx, bit int) {
+	e.init()
+	st := contexts[cx]
+	idx, mps := st>>1, int(st&1)
+	s := mqTable[idx]
+	e.a -= s.qe
+	if bit == mps {
+		if e.a&0x8000 != 0 {
+			e.c += s.qe
+			return
+		}
+		if e.a < s.qe {
+			e.a = s.qe
+		} else {
+			e.c += s.qe
+		}
+		idx = s.nmps
+	} else {
+		if e.a < s.qe {
+			e.c += s.qe
+		} else {
+			e.a = s.qe
+		}
+		if s.switchMPS {
+			mps = 1 - mps
+		}
+		idx = s.nlps
+	}
+	contexts[cx] = idx<<1 | byte(mps)
+	for e.a&0x8000 == 0 {
+		e.a <<= 1
+		e.c <<= 1
+		e.ct--
+		if e.ct == 0 {
+			e.byteOut()
+		}
+	}
+}
+
+func (e *mqEncoder) byteOut() {
+	b := &e.out[len(e.out)-1]
+	if *b == 0xFF {
+		e.out = append(e.out, byte(e.c>>20))
+		e.c &= 0xFFFFF
+		e.ct = 7
+		return
+	}
+	if e.c < 0x8000000 {
+		e.out = append(e.out, byte(e.c>>19))
+		e.c &= 0x7FFFF
+		e.ct = 8
+		return
+	}
+	*b++
+	if *b == 0xFF {
+		e.c &= 0x7FFFFFF
+		e.out = append(e.out, byte(e.c>>20))
+		e.c &= 0xFFFFF
+		e.ct = 7
+		return
+	}
+	e.out = append(e.out, byte(e.c>>19))
+	e.c &= 0x7FFFF
+	e.ct = 8
+}
+
+func (e *mqEncoder) flush() []byte {
+	e.init()
+	temp := e.c + e.a
+	e.c |= 0xFFFF
+	if e.c >= temp {
+		e.c -= 0x8000
+	}
+	e.c <<= uint(e.ct)
+	e.byteOut()
+	e.c <<= uint(e.ct)
+	e.byteOut()
+	if e.out[len(e.out)-1] != 0xFF {
+		e.out = append(e.out, 0xFF)
+	}
+	return append(e.out[1:], 0xAC)
+}
+
+// jbig2TestEncoder mirrors jbig2Arith for building test segments.
+type jbig2TestEncoder struct {
+	e    mqEncoder
+	gb   []byte
+	gr   []byte
+	ia   [numIAProcs][]byte
+	iaid []byte
+}
+
+func newJBIG2TestEncoder() *jbig2TestEncoder {
+	enc := &jbig2TestEncoder{gb: make([]byte, 1<<16), gr: make([]byte, 1<<13)}
+	for i := range enc.ia {
+		enc.ia[i] = make([]byte, 512)
+	}
+	return enc
+}
+
+func (enc *jbig2TestEncoder) int(proc, v int, oob bool) {
+	cx := enc.ia[proc]
+	prev := 1
+	bits := func(n int, v int) {
+		for i := n - 1; i >= 0; i-- {
+			bit := v >> i & 1
+			enc.e.encode(cx, prev, bit)
+			if prev < 256 {
+				prev = prev<<1 | bit
+			} else {
+				prev = (prev<<1|bit)&511 | 256
+			}
+		}
+	}
+	if oob {
+		bits(1, 1)
+		bits(3, 0)
+		return
+	}
+	sign := 0
+	if v < 0 {
+		sign, v = 1, -v
+	}
+	bits(1, sign)
+	for _, r := range []struct{ prefix, n, lo int }{{0, 1, 0}, {2, 2, 4}, {6, 3, 20}, {14, 4, 84}, {30, 5, 340}} {
+		hi := r.lo + 1<<[]int{2, 4, 6, 8, 12}[r.n-1]
+		if v < hi {
+			bits(r.n, r.prefix)
+			bits([]int{2, 4, 6, 8, 12}[r.n-1], v-r.lo)
+			return
+		}
+	}
+	bits(5, 31)
+	bits(32, v-4436)
+}
+
+func (enc *jbig2TestEncoder) id(codeLen, v int) {
+	if enc.iaid == nil {
+		enc.iaid = make([]byte, 1<<(codeLen+1))
+	}
+	prev := 1
+	for i := codeLen - 1; i >= 0; i-- {
+		bit := v >> i & 1
+		enc.e.encode(enc.iaid, prev, bit)
+		prev = prev<<1 | bit
+	}
+}
+
+func (enc *jbig2TestEncoder) generic(b *jbig2Bitmap, tmpl int, tpgdon bool, at []jbig2Pixel) {
+	pixels := append(append([]jbig2Pixel(nil), genericTemplates[tmpl]...), at...)
+	sort.SliceStable(pixels, func(i, j int) bool {
+		return pixels[i].y < pixels[j].y || pixels[i].y == pixels[j].y && pixels[i].x < pixels[j].x
+	})
+	ltp := 0
+	for y := range b.h {
+		if tpgdon {
+			same := y > 0 && bytes.Equal(b.pix[y*b.w:(y+1)*b.w], b.pix[(y-1)*b.w:y*b.w])
+			if y == 0 {
+				same = !bytes.ContainsRune(b.pix[:b.w], 1)
+			}
+			typical := 0
+			if same {
+				typical = 1
+			}
+			enc.e.encode(enc.gb, genericSLTPContext[tmpl], typical^ltp)
+			ltp = typical
+			if ltp == 1 {
+				continue
+			}
+		}
+		for x := range b.w {
+			cx := 0
+			for _, p := range pixels {
+				cx = cx<<1 | int(b.at(x+p.x, y+p.y))
+			}
+			enc.e.encode(enc.gb, cx, int(b.pix[y*b.w+x]))
+		}
+	}
+}
+
+func (enc *jbig2TestEncoder) refinement(b, ref *jbig2Bitmap, tmpl, dx, dy int, at []jbig2Pixel) {
+	t := refinementTemplates[tmpl]
+	coding, reference := t.coding, t.reference
+	if tmpl == 0 {
+		coding = append(append([]jbig2Pixel(nil), coding...), at[0])
+		reference = append(append([]jbig2Pixel(nil), reference...), at[1])
+	}
+	for y := range b.h {
+		for x := range b.w {
+			cx := 0
+			for _, p := range coding {
+				cx = cx<<1 | int(b.at(x+p.x, y+p.y))
+			}
+			for _, p := range reference {
+				cx = cx<<1 | int(ref.at(x-dx+p.x, y-dy+p.y))
+			}
+			enc.e.encode(enc.gr, cx, int(b.pix[y*b.w+x]))
+		}
+	}
+}
+
+// jbig2TestBits mirrors jbig2Bits for building Huffman-coded test
+// segments.
+type jbig2TestBits struct {
+	out []byte
+	n   int
+}
+
+func (w *jbig2TestBits) write(n, v int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.out = append(w.out, 0)
+		}
+		w.out[len(w.out)-1] |= byte(v>>i&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+// bytes writes data at the next byte boundary.
+func (w *jbig2TestBits) bytes(data []byte) {
+	w.out = append(w.out, data...)
+	w.n = len(w.out) * 8
+}
+
+// chunk writes the size of data with t, then data.
+func (w *jbig2TestBits) chunk(t *jbig2HuffTable, data []byte) {
+	w.huff(t, len(data), false)
+	w.bytes(data)
+}
+
+func (w *jbig2TestBits) huff(t *jbig2HuffTable, v int, oob bool) {
+	for code, i := range t.codes {
+		l := t.lines[i]
+		switch {
+		case oob || l.oob:
+			if oob != l.oob {
+				continue
+			}
+		case l.lower && v <= l.rangeLow:
+			v = l.rangeLow - v
+		case !l.lower && v >= l.rangeLow && (l.rangeLen == 32 || v < l.rangeLow+1<<l.rangeLen):
+			v -= l.rangeLow
+		default:
+			continue
+		}
+		w.write(code.len, int(code.code))
+		if !oob {
+			w.write(l.rangeLen, v)
+		}
+		return
+	}
+	panic("value outside the Huffman table")
+}
+
+// jbig2TestBitString reads a string of '0' and '1', spaces ignored.
+func jbig2TestBitString(s string) *jbig2Bits {
+	var w jbig2TestBits
+	for _, c := range strings.ReplaceAll(s, " ", "") {
+		w.write(1, int(c-'0'))
+	}
+	return &jbig2Bits{data: w.out}
+}
+
+// parseTestBitmap reads rows of '#' (black) and '.' (white).
+func parseTestBitmap(rows ...string) *jbig2Bitmap {
+	b := &jbig2Bitmap{w: len(rows[0]), h: len(rows)}
+	for _, row := range rows {
+		for _, c := range row {
+			if c == '#' {
+				b.pix = append(b.pix, 1)
+			} else {
+				b.pix = append(b.pix, 0)
+			}
+		}
+	}
+	return b
+}
+
+// jbig2Seg encodes a segment with a one-byte page association.
+func jbig2Seg(number uint32, typ byte, referred []byte, data []byte) []byte {
+	var out []byte
+	out = binary.BigEndian.AppendUint32(out, number)
+	out = append(out, typ, byte(len(referred))<<5)
+	out = append(out, referred...)
+	out = append(out, 1)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	return append(out, data...)
+}
+
+func jbig2PageInfoSeg(number uint32, w, h int, flags byte) []byte {
+	var data []byte
+	data = binary.BigEndian.AppendUint32(data, uint32(w))
+	data = binary.BigEndian.AppendUint32(data, uint32(h))
+	data = append(data, make([]byte, 8)...)
+	data = append(data, flags, 0, 0)
+	return jbig2Seg(number, jbig2PageInformation, nil, data)
+}
+
+func jbig2RegionInfoField(w, h, x, y int, op byte) []byte {
+	var data []byte
+	for _, v := range []int{w, h, x, y} {
+		data = binary.BigEndian.AppendUint32(data, uint32(v))
+	}
+	return append(data, op)
+}
+
+// pdfSamples is the packed, 0-is-black form of b DecodeJBIG2 returns.
+func pdfSamples(b *jbig2Bitmap) []byte {
+	rowBytes := (b.w + 7) / 8
+	out := bytes.Repeat([]byte{0xFF}, rowBytes*b.h)
+	for y := range b.h {
+		for x := range b.w {
+			if b.pix[y*b.w+x] == 1 {
+				out[y*rowBytes+x/8] &^= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return out
+}
+
+var jbig2TestGlyph = parseTestBitmap(
+	".##########.",
+	"#....##....#",
+	"#...#..#...#",
+	"#..#....#..#",
+	"#...#..#...#",
+	"#....##....#",
+	"#..........#",
+	"#..........#",
+	"#..........#",
+	".##########.",
+)
+
+func TestDecodeJBIG2GenericRegion(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl   int
+		tpgdon bool
+		at     []jbig2Pixel
+	}{
+		{0, false, []jbig2Pixel{{3, -1}, {-3, -1}, {2, -2}, {-2, -2}}},
+		{0, true, []jbig2Pixel{{2, -1}, {-3, 0}, {1, -2}, {-2, -2}}},
+		{1, true, []jbig2Pixel{{3, -1}}},
+		{2, false, []jbig2Pixel{{2, -1}}},
+		{3, true, []jbig2Pixel{{-1, -2}}},
+	} {
+		enc := newJBIG2TestEncoder()
+		enc.generic(jbig2TestGlyph, tc.tmpl, tc.tpgdon, tc.at)
+		data := jbig2RegionInfoField(12, 10, 2, 1, 0)
+		flags := byte(tc.tmpl << 1)
+		if tc.tpgdon {
+			flags |= 8
+		}
+		data = append(data, flags)
+		for _, p := range tc.at {
+			data = append(data, byte(int8(p.x)), byte(int8(p.y)))
+		}
+		data = append(data, enc.e.flush()...)
+		stream := append(jbig2PageInfoSeg(0, 16, 12, 0), jbig2Seg(1, jbig2ImmediateLosslessGeneric, nil, data)...)
+
+		got, err := DecodeJBIG2(stream, nil)
+		want, _ := newJBIG2Bitmap(16, 12)
+		want.compose(jbig2TestGlyph, 2, 1, jbig2OpOr)
+		if err != nil || !bytes.Equal(got, pdfSamples(want)) {
+			t.Errorf("template %d, TPGDON %v: DecodeJBIG2 = % X, %v; want % X", tc.tmpl, tc.tpgdon, got, err, pdfSamples(want))
+		}
+	}
+}
+
+func TestDecodeJBIG2MMRRegion(t *testing.T) {
+	// Two all-white 8-pixel Group 4 rows, a V0 code each, replace the top
+	// of a page whose default pixel is black.
+	data := append(jbig2RegionInfoField(8, 2, 0, 0, jbig2OpReplace), 1, 0xC0)
+	stream := append(jbig2PageInfoSeg(0, 8, 3, 1<<2|1<<6), jbig2Seg(1, jbig2ImmediateGeneric, nil, data)...)
+	got, err := DecodeJBIG2(stream, nil)
+	if want := []byte{0xFF, 0xFF, 0x00}; err != nil || !bytes.Equal(got, want) {
+		t.Errorf("DecodeJBIG2 = % X, %v; want % X", got, err, want)
+	}
+}
+
+var (
+	jbig2TestBar    = parseTestBitmap("#", "#", "#", "#", "#", "#", "#", "#", "#", "#")
+	jbig2TestFilled = parseTestBitmap(
+		".##########.",
+		"#....##....#",
+		"#...####...#",
+		"#..######..#",
+		"#...####...#",
+		"#....##....#",
+		"#..........#",
+		"#..........#",
+		"#..........#",
+		".##########.",
+	)
+	jbig2TestTwoBars = parseTestBitmap("#.#", "#.#", "#.#", "#.#", "#.#", "#.#", "#.#", "#.#", "#.#", "#.#")
+	jbig2TestSolid   = parseTestBitmap("###", "###", "###", "###", "###", "###", "###", "###", "###", "###")
+)
+
+// TestDecodeJBIG2SymbolText decodes a text region drawing symbols from two
+// dictionaries: one in the globals, coded generically, and one coding a
+// refinement of a global symbol and an aggregate of two. The region
+// refines one of its instances.
+func TestDecodeJBIG2SymbolText(t *testing.T) {
+	defaultAT := []byte{3, 0xFF, 0xFD, 0xFF, 2, 0xFE, 0xFE, 0xFE}
+	at := []jbig2Pixel{{3, -1}, {-3, -1}, {2, -2}, {-2, -2}}
+	refAT := []jbig2Pixel{{-1, -1}, {-1, -1}}
+
+	// Globals: bar and the glyph, both exported.
+	enc := newJBIG2TestEncoder()
+	enc.int(iaDH, 10, false)
+	enc.int(iaDW, 1, false)
+	enc.generic(jbig2TestBar, 0, false, at)
+	enc.int(iaDW, 11, false)
+	enc.generic(jbig2TestGlyph, 0, false, at)
+	enc.int(iaDW, 0, true)
+	enc.int(iaEX, 0, false)
+	enc.int(iaEX, 2, false)
+	dict := append([]byte{0, 0}, defaultAT...)
+	dict = append(dict, 0, 0, 0, 2, 0, 0, 0, 2)
+	globals := jbig2Seg(0, jbig2SymbolDictionary, nil, append(dict, enc.e.flush()...))
+
+	// Refinement/aggregate dictionary: filled refines the glyph (ID 1),
+	// twoBars aggregates two bars (ID 0). Symbol IDs span 4 symbols.
+	enc = newJBIG2TestEncoder()
+	enc.int(iaDH, 10, false)
+	enc.int(iaDW, 12, false)
+	enc.int(iaAI, 1, false)
+	enc.id(2, 1)
+	enc.int(iaRDX, 0, false)
+	enc.int(iaRDY, 0, false)
+	enc.refinement(jbig2TestFilled, jbig2TestGlyph, 0, 0, 0, refAT)
+	enc.int(iaDW, -9, false)
+	enc.int(iaAI, 2, false)
+	enc.int(iaDT, 0, false) // initial STRIPT
+	enc.int(iaDT, 0, false)
+	enc.int(iaFS, 0, false)
+	enc.id(2, 0)
+	enc.int(iaRI, 0, false)
+	enc.int(iaDS, 2, false)
+	enc.id(2, 0)
+	enc.int(iaRI, 0, false)
+	enc.int(iaDS, 0, true)
+	enc.int(iaDW, 0, true)
+	enc.int(iaEX, 2, false)
+	enc.int(iaEX, 2, false)
+	dict = append([]byte{0, 2}, defaultAT...)
+	dict = append(dict, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 2, 0, 0, 0, 2)
+	stream := jbig2PageInfoSeg(1, 40, 12, 0)
+	stream = append(stream, jbig2Seg(2, jbig2SymbolDictionary, []byte{0}, append(dict, enc.e.flush()...))...)
+
+	// Text region over symbols [bar glyph filled twoBars], TOPLEFT,
+	// refinement template 1: glyph, filled, then twoBars refined solid.
+	enc = newJBIG2TestEncoder()
+	enc.int(iaDT, 0, false)
+	enc.int(iaDT, 1, false)
+	enc.int(iaFS, 1, false)
+	enc.id(2, 1)
+	enc.int(iaRI, 0, false)
+	enc.int(iaDS, 2, false)
+	enc.id(2, 2)
+	enc.int(iaRI, 0, false)
+	enc.int(iaDS, 2, false)
+	enc.id(2, 3)
+	enc.int(iaRI, 1, false)
+	for _, proc := range []int{iaRDW, iaRDH, iaRDX, iaRDY} {
+		enc.int(proc, 0, false)
+	}
+	enc.refinement(jbig2TestSolid, jbig2TestTwoBars, 1, 0, 0, nil)
+	enc.int(iaDS, 0, true)
+	text := jbig2RegionInfoField(40, 12, 0, 0, 0)
+	text = append(text, 0x80, 0x12) // SBRTEMPLATE 1, REFCORNER TOPLEFT, SBREFINE
+	text = append(text, 0, 0, 0, 3)
+	stream = append(stream, jbig2Seg(3, jbig2ImmediateText, []byte{0, 2}, append(text, enc.e.flush()...))...)
+
+	want, _ := newJBIG2Bitmap(40, 12)
+	want.compose(jbig2TestGlyph, 1, 1, jbig2OpOr)
+	want.compose(jbig2TestFilled, 14, 1, jbig2OpOr)
+	want.compose(jbig2TestSolid, 27, 1, jbig2OpOr)
+
+	got, err := DecodeJBIG2(stream, globals)
+	if err != nil || !bytes.Equal(got, pdfSamples(want)) {
+		t.Fatalf("DecodeJBIG2 = % X, %v\nwant % X", got, err, pdfSamples(want))
+	}
+
+	parms := NewPDFDict()
+	parms.Entries["JBIG2Globals"] = PDFDict{Entries: map[string]PDFValue{}, HasStream: true, RawStream: globals}
+	d := PDFDict{Entries: map[string]PDFValue{"Filter": PDFName{Value: "JBIG2Decode"}, "DecodeParms": parms}, HasStream: true, RawStream: stream}
+	if got, err := DecodeStream(d); err != nil || !bytes.Equal(got, pdfSamples(want)) {
+		t.Errorf("DecodeStream = % X, %v", got, err)
+	}
+	delete(parms.Entries, "JBIG2Globals")
+	if _, err := DecodeStream(d); err == nil {
+		t.Error("DecodeStream without the globals succeeded")
+	}
+}
+
+// TestJBIG2StandardHuffmanTables decodes codes the tables of T.88 Annex B
+// list, including their lower range, upper range and out-of-band lines.
+func TestJBIG2StandardHuffmanTables(t *testing.T) {
+	for _, tc := range []struct {
+		table int
+		bits  string
+		want  int
+		oob   bool
+	}{
+		{1, "0 0101", 5, false},
+		{1, "10 00000001", 17, false},
+		{1, "111 " + strings.Repeat("1", 32), 65808 + 1<<32 - 1, false},
+		{2, "1110 111", 10, false},
+		{2, "111111", 0, true},
+		{3, "11111110 00000000", -256, false},
+		{3, fmt.Sprintf("11111111 %032b", 3), -260, false},
+		{3, "111110", 0, true},
+		{6, "11100 0000000000", -2048, false},
+		{8, "01", 0, true},
+		{8, fmt.Sprintf("111111110 %032b", 0), -16, false},
+		{10, "10", 0, true},
+		{11, fmt.Sprintf("1111111 %032b", 0), 141, false},
+		{14, "100", -2, false},
+		{15, fmt.Sprintf("1111110 %032b", 4), -29, false},
+	} {
+		b := jbig2TestBitString(tc.bits)
+		v, ok := b.decode(jbig2StandardTables[tc.table-1])
+		if v != tc.want || ok == tc.oob || b.err != nil || b.pos*8+b.bit != len(strings.ReplaceAll(tc.bits, " ", "")) {
+			t.Errorf("B.%d: decode(%s) = %d, %v, %v; want %d, OOB %v", tc.table, tc.bits, v, ok, b.err, tc.want, tc.oob)
+		}
+	}
+}
+
+// TestJBIG2SymbolIDTable reads a run-length coded symbol ID table
+// (7.4.3.1.7) and decodes with it.
+func TestJBIG2SymbolIDTable(t *testing.T) {
+	// Run codes 2, 3, 32 and 33 get the prefix codes 00, 01, 10 and 11.
+	var w jbig2TestBits
+	for i := range 35 {
+		n := 0
+		if i == 2 || i == 3 || i == 32 || i == 33 {
+			n = 2
+		}
+		w.write(4, n)
+	}
+	w.write(2, 3) // three unused symbols
+	w.write(3, 0)
+	w.write(2, 1) // a 3-bit code
+	w.write(2, 2) // five more
+	w.write(2, 2)
+	w.write(2, 0) // a 2-bit code
+	w.bytes([]byte{0xA5})
+	b := &jbig2Bits{data: w.out}
+	table, err := b.symbolIDTable(10)
+	if err != nil || b.read(8) != 0xA5 {
+		t.Fatalf("symbolIDTable = %v, not followed by the next byte", err)
+	}
+	b = jbig2TestBitString("00 111 010")
+	for _, want := range []int{9, 8, 3} {
+		if id, ok := b.decode(table); id != want || !ok {
+			t.Errorf("decode = %d, %v; want %d", id, ok, want)
+		}
+	}
+}
+
+// TestDecodeJBIG2HuffmanSymbolText is TestDecodeJBIG2SymbolText with each
+// segment Huffman-coded: the globals code their height classes as an MMR
+// and an uncompressed collective bitmap, and the page's dictionary and
+// text region take symbol widths and S deltas from a custom table. The
+// text region codes symbol IDs with its own table.
+func TestDecodeJBIG2HuffmanSymbolText(t *testing.T) {
+	std := func(n int) *jbig2HuffTable { return jbig2StandardTables[n-1] }
+	refAT := []jbig2Pixel{{-1, -1}, {-1, -1}}
+
+	// Globals: two blank 4x2 symbols in an MMR-coded height class, then
+	// bar and the glyph stored uncompressed. Both of the latter exported.
+	var w jbig2TestBits
+	w.huff(std(4), 2, false)
+	w.huff(std(2), 4, false)
+	w.huff(std(2), 0, false)
+	w.huff(std(2), 0, true)
+	w.chunk(std(1), []byte{0xC0})
+	w.huff(std(4), 8, false)
+	w.huff(std(2), 1, false)
+	w.huff(std(2), 11, false)
+	w.huff(std(2), 0, true)
+	w.huff(std(1), 0, false)
+	collective, _ := newJBIG2Bitmap(13, 10)
+	collective.compose(jbig2TestBar, 0, 0, jbig2OpOr)
+	collective.compose(jbig2TestGlyph, 1, 0, jbig2OpOr)
+	packed := pdfSamples(collective)
+	for i := range packed {
+		packed[i] ^= 0xFF
+	}
+	w.bytes(packed)
+	w.huff(std(1), 2, false)
+	w.huff(std(1), 2, false)
+	globals := jbig2Seg(0, jbig2SymbolDictionary, nil, append([]byte{0, 1, 0, 0, 0, 2, 0, 0, 0, 4}, w.out...))
+
+	// A custom table with out-of-band: -16 to 15 as a 2-bit prefix code
+	// and 5 bits, lower and upper ranges beyond.
+	custom, _ := newJBIG2HuffTable([]jbig2HuffLine{
+		{prefLen: 2, rangeLen: 5, rangeLow: -16},
+		{prefLen: 3, rangeLen: 32, rangeLow: -17, lower: true},
+		{prefLen: 3, rangeLen: 32, rangeLow: 16},
+		{prefLen: 2, oob: true},
+	})
+	w = jbig2TestBits{}
+	for _, f := range [][2]int{{2, 2}, {3, 5}, {2, 3}, {2, 3}, {2, 2}} {
+		w.write(f[0], f[1])
+	}
+	table := append([]byte{0x23, 0xFF, 0xFF, 0xFF, 0xF0, 0, 0, 0, 16}, w.out...)
+	stream := jbig2PageInfoSeg(1, 40, 12, 0)
+	stream = append(stream, jbig2Seg(2, jbig2Tables, nil, table)...)
+
+	// Refinement/aggregate dictionary, widths from the custom table:
+	// filled refines the glyph (ID 1), twoBars aggregates two bars (ID
+	// 0). Each refinement is arithmetic-coded in a run of its own.
+	enc := newJBIG2TestEncoder()
+	w = jbig2TestBits{}
+	w.huff(std(4), 10, false)
+	w.huff(custom, 12, false)
+	w.huff(std(1), 1, false)
+	w.write(2, 1)
+	w.huff(std(15), 0, false)
+	w.huff(std(15), 0, false)
+	enc.refinement(jbig2TestFilled, jbig2TestGlyph, 0, 0, 0, refAT)
+	w.chunk(std(1), enc.e.flush())
+	w.huff(custom, -9, false)
+	w.huff(std(1), 2, false)
+	w.huff(std(11), 1, false) // initial STRIPT
+	w.huff(std(11), 1, false)
+	w.huff(std(6), 0, false)
+	w.write(2, 0)
+	w.write(1, 0)
+	w.huff(std(8), 2, false)
+	w.write(2, 0)
+	w.write(1, 0)
+	w.huff(std(8), 0, true)
+	w.huff(custom, 0, true)
+	w.huff(std(1), 2, false)
+	w.huff(std(1), 2, false)
+	dict := []byte{0, 0x33, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 2, 0, 0, 0, 2}
+	stream = append(stream, jbig2Seg(3, jbig2SymbolDictionary, []byte{0, 2}, append(dict, w.out...))...)
+
+	// Text region over symbols [bar glyph filled twoBars] in two strips,
+	// S deltas from the custom table, RDX from B.15: glyph, filled, then
+	// twoBars refined solid. The symbol ID table gives the symbols codes
+	// of 3, 1, 3 and 2 bits, run codes 1 to 3 having 2, 2 and 1.
+	enc = newJBIG2TestEncoder()
+	w = jbig2TestBits{}
+	runLens := make([]int, 35)
+	runLens[1], runLens[2], runLens[3] = 2, 2, 1
+	var runLines []jbig2HuffLine
+	for i, n := range runLens {
+		w.write(4, n)
+		runLines = append(runLines, jbig2HuffLine{prefLen: n, rangeLow: i})
+	}
+	runs, _ := newJBIG2HuffTable(runLines)
+	var idLines []jbig2HuffLine
+	for i, n := range []int{3, 1, 3, 2} {
+		w.huff(runs, n, false)
+		idLines = append(idLines, jbig2HuffLine{prefLen: n, rangeLow: i})
+	}
+	w.bytes(nil)
+	ids, _ := newJBIG2HuffTable(idLines)
+	w.huff(std(11), 1, false) // initial STRIPT
+	w.huff(std(11), 1, false)
+	w.huff(std(6), 1, false)
+	w.write(1, 1) // CURT
+	w.huff(ids, 1, false)
+	w.write(1, 0)
+	w.huff(custom, 2, false)
+	w.write(1, 1)
+	w.huff(ids, 2, false)
+	w.write(1, 0)
+	w.huff(custom, 2, false)
+	w.write(1, 1)
+	w.huff(ids, 3, false)
+	w.write(1, 1)
+	for _, n := range []int{14, 14, 15, 14} {
+		w.huff(std(n), 0, false)
+	}
+	enc.refinement(jbig2TestSolid, jbig2TestTwoBars, 1, 0, 0, nil)
+	w.chunk(std(1), enc.e.flush())
+	w.huff(custom, 0, true)
+	text := jbig2RegionInfoField(40, 12, 0, 0, 0)
+	text = append(text, 0x80, 0x17) // SBRTEMPLATE 1, REFCORNER TOPLEFT, 2 strips, SBREFINE, SBHUFF
+	text = append(text, 0x04, 0x0C) // RDX B.15, DS custom
+	text = append(text, 0, 0, 0, 3)
+	stream = append(stream, jbig2Seg(4, jbig2ImmediateText, []byte{0, 2, 3}, append(text, w.out...))...)
+
+	want, _ := newJBIG2Bitmap(40, 12)
+	want.compose(jbig2TestGlyph, 1, 1, jbig2OpOr)
+	want.compose(jbig2TestFilled, 14, 1, jbig2OpOr)
+	want.compose(jbig2TestSolid, 27, 1, jbig2OpOr)
+	if got, err := DecodeJBIG2(stream, globals); err != nil || !bytes.Equal(got, pdfSamples(want)) {
+		t.Fatalf("DecodeJBIG2 = % X, %v\nwant % X", got, err, pdfSamples(want))
+	}
+}
+
+func TestDecodeJBIG2Errors(t *testing.T) {
+	page := jbig2PageInfoSeg(0, 8, 8, 0)
+	for _, tc := range []struct {
+		name, want string
+		data       []byte
+	}{
+		{"empty", "no page information", nil},
+		{"truncated header", "truncated", page[:8]},
+		{"data past end", "past end", append(page, jbig2Seg(1, jbig2ImmediateGeneric, nil, make([]byte, 5))[:14]...)},
+		{"halftone", "halftone", append(page, jbig2Seg(1, jbig2ImmediateHalftone, nil, nil)...)},
+		{"Huffman table missing", "too few table segments", append(page, jbig2Seg(1, jbig2ImmediateText, nil, append(jbig2RegionInfoField(8, 8, 0, 0, 0), 0, 1, 0, 3, 0, 0, 0, 0))...)},
+		{"Huffman table selection", "table selection", jbig2Seg(1, jbig2SymbolDictionary, nil, []byte{0, 9, 0, 0, 0, 0, 0, 0, 0, 0})},
+		{"Huffman table truncated", "truncated", jbig2Seg(1, jbig2Tables, nil, []byte{0x23, 0, 0, 0, 0, 0, 0, 0, 16})},
+		{"huge page", "too large", jbig2PageInfoSeg(0, 1<<20, 1<<20, 0)},
+		{"region before page", "precedes", jbig2Seg(1, jbig2ImmediateGeneric, nil, append(jbig2RegionInfoField(1, 1, 0, 0, 0), 1, 0x80))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecodeJBIG2(tc.data, nil); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("DecodeJBIG2 error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package pdf
+
+import "math/bits"
+
+// This file holds the JBIG2 symbol dictionary (T.88 6.5) and text region
+// (6.4) decoding procedures. Both read their values through a jbig2Coder,
+// arithmetic (jbig2Arith) or Huffman (jbig2Huff) as the segment's flags
+// choose; the procedures are otherwise the same but for how a Huffman
+// dictionary codes its plain symbols.
+
+// jbig2Coder decodes the values of the symbol dictionary and text region
+// procedures.
+type jbig2Coder interface {
+	// decodeInt decodes the value of an integer procedure (iaDH...);
+	// ok is false for the out-of-band value.
+	decodeInt(proc int) (v int, ok bool)
+	// decodeID decodes a symbol ID among codeLen-bit symbol codes.
+	decodeID(codeLen int) int
+	// refine decodes a w x h refinement of ref (6.3.5) without TPGRON.
+	refine(w, h, tmpl int, ref *jbig2Bitmap, dx, dy int, at []jbig2Pixel) (*jbig2Bitmap, error)
+	newBitmap(w, h int) (*jbig2Bitmap, error)
+	charge(n int) error
+}
+
+// symbolCodeLen is the bit length of symbol IDs among n symbols.
+func symbolCodeLen(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// jbig2SymbolDict holds the parameters of a symbol dictionary segment.
+type jbig2SymbolDict struct {
+	huffman       bool
+	refAgg        bool
+	template      int
+	at            []jbig2Pixel
+	refTemplate   int
+	refAT         []jbig2Pixel
+	numExported   int
+	numNewSymbols int
+}
+
+// decodeSymbols decodes the dictionary's new symbols, each height class a
+// run of symbols of one height (6.5.5), then the export flags choosing
+// which of in and the new symbols the dictionary exports (6.5.10). A
+// Huffman dictionary without refinement/aggregate coding codes each height
+// class's symbols together, after their widths (6.5.9).
+func decodeSymbols(c jbig2Coder, p jbig2SymbolDict, in []*jbig2Bitmap) ([]*jbig2Bitmap, error) {
+	codeLen := symbolCodeLen(len(in) + p.numNewSymbols)
+	var fresh []*jbig2Bitmap
+	height := 0
+	for len(fresh) < p.numNewSymbols {
+		dh, ok := c.decodeInt(iaDH)
+		if !ok {
+			return nil, jbig2Errorf("symbol dictionary: out-of-band height class delta")
+		}
+		height += dh
+		width := 0
+		var widths []int
+		for {
+			dw, ok := c.decodeInt(iaDW)
+			if !ok {
+				break
+			}
+			if len(fresh)+len(widths) >= p.numNewSymbols {
+				return nil, jbig2Errorf("symbol dictionary: more than %d new symbols", p.numNewSymbols)
+			}
+			width += dw
+			if width <= 0 || height <= 0 {
+				return nil, jbig2Errorf("symbol dictionary: symbol %dx%d", width, height)
+			}
+			var sym *jbig2Bitmap
+			var err error
+			switch {
+			case p.refAgg:
+				sym, err = decodeAggregate(c, p, width, height, codeLen, append(in[:len(in):len(in)], fresh...))
+			case p.huffman:
+				widths = append(widths, width)
+				continue
+			default:
+				sym, err = c.(*jbig2Arith).decodeGeneric(width, height, p.template, false, p.at)
+			}
+			if err != nil {
+				return nil, err
+			}
+			fresh = append(fresh, sym)
+		}
+		if p.huffman && !p.refAgg {
+			syms, err := c.(*jbig2Huff).collectiveBitmap(widths, height)
+			if err != nil {
+				return nil, err
+			}
+			fresh = append(fresh, syms...)
+		}
+	}
+
+	all := append(in[:len(in):len(in)], fresh...)
+	var exported []*jbig2Bitmap
+	export := false
+	for i := 0; i < len(all); {
+		run, ok := c.decodeInt(iaEX)
+		if !ok || run < 0 || run > len(all)-i {
+			return nil, jbig2Errorf("symbol dictionary: bad export run")
+		}
+		if export {
+			exported = append(exported, all[i:i+run]...)
+		}
+		i += run
+		export = !export
+	}
+	if len(exported) != p.numExported {
+		return nil, jbig2Errorf("symbol dictionary: exported %d symbols, declared %d", len(exported), p.numExported)
+	}
+	return exported, nil
+}
+
+// decodeAggregate decodes a refinement/aggregate symbol (6.5.8.2): a
+// refinement of one known symbol, or a small text region of several.
+func decodeAggregate(c jbig2Coder, p jbig2SymbolDict, w, h, codeLen int, syms []*jbig2Bitmap) (*jbig2Bitmap, error) {
+	n, ok := c.decodeInt(iaAI)
+	if !ok || n <= 0 {
+		return nil, jbig2Errorf("symbol dictionary: bad aggregate instance count")
+	}
+	if n > 1 {
+		return decodeText(c, jbig2TextRegion{
+			w: w, h: h, numInstances: n, strips: 1, refCorner: jbig2TopLeft,
+			refine: true, refTemplate: p.refTemplate, refAT: p.refAT,
+		}, syms, codeLen)
+	}
+	id := c.decodeID(codeLen)
+	rdx, _ := c.decodeInt(iaRDX)
+	rdy, _ := c.decodeInt(iaRDY)
+	if id >= len(syms) {
+		return nil, jbig2Errorf("symbol dictionary: symbol ID %d out of range", id)
+	}
+	return c.refine(w, h, p.refTemplate, syms[id], rdx, rdy, p.refAT)
+}
+
+// Text region reference corners (T.88 7.4.3.1.1).
+const (
+	jbig2BottomLeft = iota
+	jbig2TopLeft
+	jbig2BottomRight
+	jbig2TopRight
+)
+
+// jbig2TextRegion holds the parameters of a text region.
+type jbig2TextRegion struct {
+	w, h         int
+	numInstances int
+	strips       int
+	refCorner    int
+	transposed   bool
+	combOp       int
+	defPixel     byte
+	dsOffset     int
+	refine       bool
+	refTemplate  int
+	refAT        []jbig2Pixel
+}
+
+// decodeText is the text region decoding procedure (6.4.5): strips of
+// symbol instances, each placed relative to the previous one, drawn into a
+// w x h region with the region's combination operator.
+func decodeText(c jbig2Coder, p jbig2TextRegion, syms []*jbig2Bitmap, codeLen int) (*jbig2Bitmap, error) {
+	region, err := c.newBitmap(p.w, p.h)
+	if err != nil {
+		return nil, err
+	}
+	if p.defPixel != 0 {
+		for i := range region.pix {
+			region.pix[i] = 1
+		}
+	}
+	dt, _ := c.decodeInt(iaDT)
+	stripT := -dt * p.strips
+	firstS := 0
+	for n := 0; n < p.numInstances; {
+		dt, ok := c.decodeInt(iaDT)
+		if !ok {
+			return nil, jbig2Errorf("text region: out-of-band strip delta")
+		}
+		stripT += dt * p.strips
+		dfs, _ := c.decodeInt(iaFS)
+		firstS += dfs
+		curS := firstS
+		for first := true; ; first = false {
+			if !first {
+				ids, ok := c.decodeInt(iaDS)
+				if !ok {
+					break
+				}
+				curS += ids + p.dsOffset
+			}
+			if n >= p.numInstances {
+				return nil, jbig2Errorf("text region: more than %d symbol instances", p.numInstances)
+			}
+			curT := 0
+			if p.strips > 1 {
+				curT, _ = c.decodeInt(iaIT)
+			}
+			t := stripT + curT
+			id := c.decodeID(codeLen)
+			if id >= len(syms) {
+				return nil, jbig2Errorf("text region: symbol ID %d out of range", id)
+			}
+			sym := syms[id]
+			if p.refine {
+				if ri, _ := c.decodeInt(iaRI); ri != 0 {
+					rdw, _ := c.decodeInt(iaRDW)
+					rdh, _ := c.decodeInt(iaRDH)
+					rdx, _ := c.decodeInt(iaRDX)
+					rdy, _ := c.decodeInt(iaRDY)
+					sym, err = c.refine(sym.w+rdw, sym.h+rdh, p.refTemplate, sym, rdw>>1+rdx, rdh>>1+rdy, p.refAT)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			// CURS advances over the instance before or after placing
+			// it, depending on which corner is the reference point.
+			right := p.refCorner == jbig2TopRight || p.refCorner == jbig2BottomRight
+			bottom := p.refCorner == jbig2BottomLeft || p.refCorner == jbig2BottomRight
+			extent := sym.w
+			if p.transposed {
+				extent = sym.h
+			}
+			leading := right
+			if p.transposed {
+				leading = bottom
+			}
+			if leading {
+				curS += extent - 1
+			}
+			x, y := curS, t
+			if p.transposed {
+				x, y = t, curS
+			}
+			if right {
+				x -= sym.w - 1
+			}
+			if bottom {
+				y -= sym.h - 1
+			}
+			if err := c.charge(sym.w * sym.h); err != nil {
+				return nil, err
+			}
+			region.compose(sym, x, y, p.combOp)
+			if !leading {
+				curS += extent - 1
+			}
+			n++
+		}
+	}
+	return region, nil
+}
@@ -0,0 +1,200 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// JPXColourSpec is one 'colr' (colour specification) box of a JP2 file
+// (ISO/IEC 15444-1 I.5.3.3).
+type JPXColourSpec struct {
+	Method     int // METH: 1 enumerated, 2 restricted ICC, 3 any ICC (JPX)
+	Precedence int
+	Approx     int // APPROX: 1 means the specification is accurate
+	EnumCS     int // enumerated colour space, valid only when Method == 1
+}
+
+// JPXInfo is the header information of a JPXDecode stream: the image
+// geometry, per-component bit depths and any colour specification boxes.
+// A raw codestream (no JP2 wrapper) carries no colour specifications.
+type JPXInfo struct {
+	Width, Height int
+	Components    int
+	BitDepths     []int
+	ColourSpecs   []JPXColourSpec
+}
+
+// JPX enumerated colour spaces (ISO/IEC 15444-2 Table M.25) referred to by
+// PDF/A.
+const (
+	JPXEnumSRGB      = 16
+	JPXEnumGreyscale = 17
+	JPXEnumSYCC      = 18
+	JPXEnumCIEJab    = 19
+)
+
+var jp2Signature = []byte{0x0D, 0x0A, 0x87, 0x0A}
+
+// ParseJPXHeader reads the header of a JPEG 2000 stream: either a JP2/JPX
+// file (box structure, ISO/IEC 15444-1 Annex I) or a bare codestream
+// starting with the SOC and SIZ markers. Only headers are parsed; the
+// compressed image data is never decoded.
+func ParseJPXHeader(data []byte) (JPXInfo, error) {
+	if len(data) >= 4 && data[0] == 0xFF && data[1] == 0x4F {
+		return parseJ2KSIZ(data)
+	}
+	var info JPXInfo
+	sawSignature, sawHeader := false, false
+	err := walkJP2Boxes(data, func(typ string, body []byte) error {
+		switch typ {
+		case "jP  ":
+			if len(body) != 4 || string(body) != string(jp2Signature) {
+				return errors.New("jpx: bad JP2 signature box")
+			}
+			sawSignature = true
+		case "jp2h":
+			sawHeader = true
+			return parseJP2Header(body, &info)
+		case "jp2c":
+			if info.Components == 0 {
+				cs, err := parseJ2KSIZ(body)
+				if err != nil {
+					return err
+				}
+				info.Width, info.Height, info.Components, info.BitDepths = cs.Width, cs.Height, cs.Components, cs.BitDepths
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return JPXInfo{}, err
+	}
+	if !sawSignature {
+		return JPXInfo{}, errors.New("jpx: missing JP2 signature box")
+	}
+	if !sawHeader && info.Components == 0 {
+		return JPXInfo{}, errors.New("jpx: missing JP2 header box")
+	}
+	return info, nil
+}
+
+// walkJP2Boxes calls fn for each top-level box in data, in order.
+func walkJP2Boxes(data []byte, fn func(typ string, body []byte) error) error {
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return errors.New("jpx: truncated box header")
+		}
+		length := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		hdr := uint64(8)
+		switch length {
+		case 0:
+			length = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return errors.New("jpx: truncated extended box length")
+			}
+			length, hdr = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if length < hdr || length > uint64(len(data)) {
+			return fmt.Errorf("jpx: box %q length %d out of range", typ, length)
+		}
+		if err := fn(typ, data[hdr:length]); err != nil {
+			return err
+		}
+		data = data[length:]
+	}
+	return nil
+}
+
+// parseJP2Header fills info from the sub-boxes of a 'jp2h' superbox.
+func parseJP2Header(body []byte, info *JPXInfo) error {
+	bpc := -1
+	var bpcc []byte
+	palette := 0
+	err := walkJP2Boxes(body, func(typ string, b []byte) error {
+		switch typ {
+		case "ihdr":
+			if len(b) < 14 {
+				return errors.New("jpx: truncated image header box")
+			}
+			info.Height = int(binary.BigEndian.Uint32(b))
+			info.Width = int(binary.BigEndian.Uint32(b[4:]))
+			info.Components = int(binary.BigEndian.Uint16(b[8:]))
+			bpc = int(b[10])
+		case "bpcc":
+			bpcc = b
+		case "colr":
+			if len(b) < 3 {
+				return errors.New("jpx: truncated colour specification box")
+			}
+			cs := JPXColourSpec{Method: int(b[0]), Precedence: int(int8(b[1])), Approx: int(b[2])}
+			if cs.Method == 1 {
+				if len(b) < 7 {
+					return errors.New("jpx: truncated enumerated colour space")
+				}
+				cs.EnumCS = int(binary.BigEndian.Uint32(b[3:]))
+			}
+			info.ColourSpecs = append(info.ColourSpecs, cs)
+		case "pclr":
+			// A palette maps the single index component to NPC channels.
+			if len(b) < 3 {
+				return errors.New("jpx: truncated palette box")
+			}
+			palette = int(b[2])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if bpc < 0 {
+		return errors.New("jpx: missing image header box")
+	}
+	info.BitDepths = make([]int, info.Components)
+	for i := range info.BitDepths {
+		switch {
+		case bpc != 0xFF:
+			info.BitDepths[i] = bpc&0x7F + 1
+		case i < len(bpcc):
+			info.BitDepths[i] = int(bpcc[i]&0x7F) + 1
+		}
+	}
+	if palette > 0 {
+		info.Components = palette
+	}
+	return nil
+}
+
+// parseJ2KSIZ reads the image geometry from a codestream's SIZ marker
+// segment, which must directly follow SOC (ISO/IEC 15444-1 A.5.1).
+func parseJ2KSIZ(data []byte) (JPXInfo, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0x4F || data[2] != 0xFF || data[3] != 0x51 {
+		return JPXInfo{}, errors.New("jpx: codestream does not start with SOC, SIZ")
+	}
+	seg := data[4:]
+	if len(seg) < 38 {
+		return JPXInfo{}, errors.New("jpx: truncated SIZ marker segment")
+	}
+	lsiz := int(binary.BigEndian.Uint16(seg))
+	if lsiz > len(seg) {
+		return JPXInfo{}, errors.New("jpx: truncated SIZ marker segment")
+	}
+	xsiz, ysiz := binary.BigEndian.Uint32(seg[4:]), binary.BigEndian.Uint32(seg[8:])
+	xo, yo := binary.BigEndian.Uint32(seg[12:]), binary.BigEndian.Uint32(seg[16:])
+	csiz := int(binary.BigEndian.Uint16(seg[36:]))
+	if 38+3*csiz > lsiz || xo > xsiz || yo > ysiz {
+		return JPXInfo{}, errors.New("jpx: malformed SIZ marker segment")
+	}
+	info := JPXInfo{
+		Width:      int(xsiz - xo),
+		Height:     int(ysiz - yo),
+		Components: csiz,
+		BitDepths:  make([]int, csiz),
+	}
+	for i := range csiz {
+		info.BitDepths[i] = int(seg[38+3*i]&0x7F) + 1
+	}
+	return info, nil
+}
@@ -0,0 +1,1208 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// This file holds the JPEG 2000 decoder behind JPXDecode (ISO/IEC 15444-1):
+// codestream and tile-part parsing, dequantization, the inverse wavelet
+// and component transforms, and the JP2 palette and channel definition
+// boxes. Tier-1 is in jpx_t1.go and tier-2 in jpx_t2.go. Packed packet
+// headers (PPM/PPT) are not supported.
+
+// JPXImage is a decoded JPEG 2000 image with every channel scaled to
+// 8 bits.
+type JPXImage struct {
+	Width, Height int
+	// Components is the number of interleaved channels per pixel in Pix:
+	// the colour channels, then the opacity channel when Alpha is set.
+	Components int
+	Alpha      bool
+	// EnumCS is the enumerated colour space of the JP2 colour
+	// specification (JPXEnum*), or 0 for a bare codestream or an ICC
+	// colour space. sYCC data is converted to sRGB and reported as such.
+	EnumCS int
+	// Depths is each channel's bit depth in the JPEG 2000 data, before
+	// rescaling to 8 bits (capped at 16).
+	Depths []int
+	Pix    []byte
+}
+
+// maxJPXSamples bounds the samples of a decoded image, summed over its
+// components.
+const maxJPXSamples = 1 << 26
+
+// errJPXEndOfData stops packet decoding when a tile's data runs out; the
+// code-blocks decoded so far still make an image.
+var errJPXEndOfData = errors.New("jpx: end of tile data")
+
+// Progression orders (Table A.16).
+const (
+	jpxLRCP = iota
+	jpxRLCP
+	jpxRPCL
+	jpxPCRL
+	jpxCPRL
+)
+
+// jpxComponent is one component of the SIZ marker segment.
+type jpxComponent struct {
+	prec   int
+	signed bool
+	dx, dy int
+}
+
+// jpxCoding is the coding style of one component (SPcod/SPcoc).
+type jpxCoding struct {
+	levels     int
+	cbw, cbh   int
+	style      int
+	reversible bool
+	precincts  []int // per resolution level: PPx | PPy<<4
+}
+
+// jpxQuant is the quantization of one component (QCD/QCC).
+type jpxQuant struct {
+	style, guard int
+	steps        [][2]int // exponent, mantissa per subband
+}
+
+// jpxPOC is one progression order change (A.6.6): packets of layers below
+// layers, resolution levels [r0, r1) and components [c0, c1).
+type jpxPOC struct {
+	r0, c0, layers, r1, c1, order int
+}
+
+// jpxParams holds the coding parameters in effect for a tile.
+type jpxParams struct {
+	order    int
+	layers   int
+	mct      bool
+	sop, eph bool
+	coding   []jpxCoding
+	quant    []jpxQuant
+	roi      []int
+	poc      []jpxPOC
+}
+
+// jpxHeader tracks which components a header's COC and QCC segments set,
+// so its COD and QCD leave them alone.
+type jpxHeader struct {
+	p              *jpxParams
+	cocSet, qccSet []bool
+	pocSeen        bool
+	sawCOD, sawQCD bool
+}
+
+// jpxTile collects one tile's parameters and the data of its tile-parts.
+type jpxTile struct {
+	hdr  *jpxHeader
+	data []byte
+}
+
+// jpxCodestream is a parsed codestream: image and tile geometry,
+// components, and the tiles met so far.
+type jpxCodestream struct {
+	x0, y0, x1, y1   int
+	tx0, ty0, tw, th int
+	ntx, nty         int
+	comps            []jpxComponent
+	main             *jpxHeader
+	tiles            []*jpxTile
+	planes           [][]int32
+	planeW, planeH   []int
+	planeX0, planeY0 []int
+	planePrec        []int
+}
+
+// DecodeJPX decodes a JPEG 2000 image: a JP2/JPX file or a bare
+// codestream. Palette and component mapping boxes are applied; channel
+// definitions order the colour channels and mark an opacity channel.
+// Components subsampled relative to the image are upsampled.
+func DecodeJPX(data []byte) (*JPXImage, error) {
+	code, meta, err := splitJP2(data)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := decodeJ2K(code)
+	if err != nil {
+		return nil, err
+	}
+	return cs.image(meta)
+}
+
+// decodeJ2K parses and decodes a codestream into per-component planes.
+func decodeJ2K(data []byte) (*jpxCodestream, error) {
+	if len(data) < 4 || binary.BigEndian.Uint16(data) != 0xFF4F || binary.BigEndian.Uint16(data[2:]) != 0xFF51 {
+		return nil, errors.New("jpx: codestream does not start with SOC, SIZ")
+	}
+	cs := &jpxCodestream{}
+	pos := 2
+	for {
+		m, seg, next, err := jpxMarkerSegment(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		if m == 0xFF90 {
+			break
+		}
+		if m == 0xFF51 {
+			if err := cs.parseSIZ(seg); err != nil {
+				return nil, err
+			}
+		} else if cs.main == nil {
+			return nil, errors.New("jpx: SIZ must follow SOC")
+		} else if err := cs.applyMarker(cs.main, m, seg); err != nil {
+			return nil, err
+		}
+		pos = next
+	}
+	if !cs.main.sawCOD || !cs.main.sawQCD {
+		return nil, errors.New("jpx: main header lacks COD or QCD")
+	}
+	if err := cs.readTileParts(data, pos); err != nil {
+		return nil, err
+	}
+	for i, t := range cs.tiles {
+		if t == nil {
+			continue
+		}
+		if err := cs.decodeTile(i, t); err != nil {
+			return nil, err
+		}
+		cs.tiles[i] = nil
+	}
+	return cs, nil
+}
+
+// jpxMarkerSegment reads the marker at pos and, for markers with
+// parameters, its segment; next is the position after both.
+func jpxMarkerSegment(data []byte, pos int) (m uint16, seg []byte, next int, err error) {
+	if pos+2 > len(data) {
+		return 0, nil, 0, errors.New("jpx: truncated codestream header")
+	}
+	m = binary.BigEndian.Uint16(data[pos:])
+	if m>>8 != 0xFF {
+		return 0, nil, 0, fmt.Errorf("jpx: expected marker at offset %d", pos)
+	}
+	if m == 0xFF93 || m == 0xFFD9 {
+		return m, nil, pos + 2, nil
+	}
+	if pos+4 > len(data) {
+		return 0, nil, 0, errors.New("jpx: truncated marker segment")
+	}
+	n := int(binary.BigEndian.Uint16(data[pos+2:]))
+	if n < 2 || pos+2+n > len(data) {
+		return 0, nil, 0, fmt.Errorf("jpx: marker %04X segment length %d out of range", m, n)
+	}
+	return m, data[pos+4 : pos+2+n], pos + 2 + n, nil
+}
+
+// parseSIZ reads the image and tile size marker segment (A.5.1).
+func (cs *jpxCodestream) parseSIZ(seg []byte) error {
+	if len(seg) < 36 {
+		return errors.New("jpx: truncated SIZ marker segment")
+	}
+	u := func(i int) int { return int(binary.BigEndian.Uint32(seg[i:])) }
+	cs.x1, cs.y1, cs.x0, cs.y0 = u(2), u(6), u(10), u(14)
+	cs.tw, cs.th, cs.tx0, cs.ty0 = u(18), u(22), u(26), u(30)
+	n := int(binary.BigEndian.Uint16(seg[34:]))
+	if n == 0 || len(seg) < 36+3*n {
+		return errors.New("jpx: malformed SIZ marker segment")
+	}
+	if cs.x1 <= cs.x0 || cs.y1 <= cs.y0 || cs.tw == 0 || cs.th == 0 ||
+		cs.tx0 > cs.x0 || cs.ty0 > cs.y0 || cs.tx0+cs.tw <= cs.x0 || cs.ty0+cs.th <= cs.y0 {
+		return errors.New("jpx: inconsistent SIZ geometry")
+	}
+	cs.ntx = ceilDiv(cs.x1-cs.tx0, cs.tw)
+	cs.nty = ceilDiv(cs.y1-cs.ty0, cs.th)
+	if cs.ntx > 1<<16 || cs.nty > 1<<16 || cs.ntx*cs.nty > 1<<16 {
+		return fmt.Errorf("jpx: %d x %d tiles", cs.ntx, cs.nty)
+	}
+	samples := 0
+	for i := range n {
+		c := jpxComponent{
+			prec:   int(seg[36+3*i]&0x7F) + 1,
+			signed: seg[36+3*i]&0x80 != 0,
+			dx:     int(seg[37+3*i]),
+			dy:     int(seg[38+3*i]),
+		}
+		if c.prec > 38 || c.dx == 0 || c.dy == 0 {
+			return fmt.Errorf("jpx: component %d: precision %d, subsampling %dx%d", i, c.prec, c.dx, c.dy)
+		}
+		cs.comps = append(cs.comps, c)
+		w := ceilDiv(cs.x1, c.dx) - ceilDiv(cs.x0, c.dx)
+		h := ceilDiv(cs.y1, c.dy) - ceilDiv(cs.y0, c.dy)
+		if w <= 0 || h <= 0 || w > maxJPXSamples || h > maxJPXSamples {
+			return fmt.Errorf("jpx: component %d of %dx%d samples", i, w, h)
+		}
+		if samples += w * h; samples > maxJPXSamples {
+			return fmt.Errorf("jpx: image of %dx%d exceeds %d samples", cs.x1-cs.x0, cs.y1-cs.y0, maxJPXSamples)
+		}
+		cs.planes = append(cs.planes, make([]int32, w*h))
+		cs.planeW = append(cs.planeW, w)
+		cs.planeH = append(cs.planeH, h)
+		cs.planeX0 = append(cs.planeX0, ceilDiv(cs.x0, c.dx))
+		cs.planeY0 = append(cs.planeY0, ceilDiv(cs.y0, c.dy))
+		cs.planePrec = append(cs.planePrec, min(c.prec, 16))
+	}
+	cs.tiles = make([]*jpxTile, cs.ntx*cs.nty)
+	cs.main = &jpxHeader{
+		p: &jpxParams{
+			coding: make([]jpxCoding, n),
+			quant:  make([]jpxQuant, n),
+			roi:    make([]int, n),
+		},
+		cocSet: make([]bool, n),
+		qccSet: make([]bool, n),
+	}
+	return nil
+}
+
+// readTileParts collects the tile-parts from pos on: each SOT marker
+// segment, the tile-part header up to SOD, then the tile-part's data.
+// Truncated data ends the codestream early.
+func (cs *jpxCodestream) readTileParts(data []byte, pos int) error {
+	for pos+2 <= len(data) {
+		m, seg, next, err := jpxMarkerSegment(data, pos)
+		if err != nil {
+			return err
+		}
+		if m == 0xFFD9 {
+			return nil
+		}
+		if m != 0xFF90 || len(seg) < 8 {
+			return fmt.Errorf("jpx: expected SOT at offset %d", pos)
+		}
+		idx := int(binary.BigEndian.Uint16(seg))
+		psot := int(binary.BigEndian.Uint32(seg[2:]))
+		if idx >= len(cs.tiles) {
+			return fmt.Errorf("jpx: tile index %d out of range", idx)
+		}
+		end := len(data)
+		if psot != 0 {
+			end = min(pos+psot, len(data))
+		} else if binary.BigEndian.Uint16(data[len(data)-2:]) == 0xFFD9 {
+			end = len(data) - 2
+		}
+		t := cs.tiles[idx]
+		if t == nil {
+			t = &jpxTile{hdr: cs.main.forTile()}
+			cs.tiles[idx] = t
+		}
+		for pos = next; ; pos = next {
+			m, seg, next, err = jpxMarkerSegment(data, pos)
+			if err != nil {
+				return err
+			}
+			if m == 0xFF93 {
+				break
+			}
+			if err := cs.applyMarker(t.hdr, m, seg); err != nil {
+				return err
+			}
+		}
+		if next < end {
+			t.data = append(t.data, data[next:end]...)
+		}
+		pos = max(end, next)
+	}
+	return nil
+}
+
+// forTile starts a tile header from the main header's parameters.
+func (h *jpxHeader) forTile() *jpxHeader {
+	p := *h.p
+	p.coding = slices.Clone(p.coding)
+	p.quant = slices.Clone(p.quant)
+	p.roi = slices.Clone(p.roi)
+	return &jpxHeader{
+		p:      &p,
+		cocSet: make([]bool, len(p.coding)),
+		qccSet: make([]bool, len(p.coding)),
+		sawCOD: true, sawQCD: true,
+	}
+}
+
+// applyMarker applies a main or tile-part header marker segment to h.
+// COC and QCC take precedence over COD and QCD of the same header, and a
+// tile's segments over the main header's.
+func (cs *jpxCodestream) applyMarker(h *jpxHeader, m uint16, seg []byte) error {
+	p := h.p
+	comp := func() (int, []byte, error) {
+		if len(cs.comps) < 257 {
+			if len(seg) < 1 {
+				return 0, nil, errors.New("jpx: truncated marker segment")
+			}
+			c := int(seg[0])
+			if c >= len(cs.comps) {
+				return 0, nil, fmt.Errorf("jpx: component %d out of range", c)
+			}
+			return c, seg[1:], nil
+		}
+		if len(seg) < 2 {
+			return 0, nil, errors.New("jpx: truncated marker segment")
+		}
+		c := int(binary.BigEndian.Uint16(seg))
+		if c >= len(cs.comps) {
+			return 0, nil, fmt.Errorf("jpx: component %d out of range", c)
+		}
+		return c, seg[2:], nil
+	}
+	switch m {
+	case 0xFF52: // COD
+		if len(seg) < 5 {
+			return errors.New("jpx: truncated COD marker segment")
+		}
+		coding, err := parseJPXCoding(seg[0], seg[5:])
+		if err != nil {
+			return err
+		}
+		p.order, p.layers, p.mct = int(seg[1]), int(binary.BigEndian.Uint16(seg[2:])), seg[4] != 0
+		p.sop, p.eph = seg[0]&2 != 0, seg[0]&4 != 0
+		if p.order > jpxCPRL || p.layers == 0 {
+			return fmt.Errorf("jpx: progression order %d with %d layers", p.order, p.layers)
+		}
+		for c := range p.coding {
+			if !h.cocSet[c] {
+				p.coding[c] = coding
+			}
+		}
+		h.sawCOD = true
+	case 0xFF53: // COC
+		c, rest, err := comp()
+		if err != nil {
+			return err
+		}
+		if len(rest) < 1 {
+			return errors.New("jpx: truncated COC marker segment")
+		}
+		if p.coding[c], err = parseJPXCoding(rest[0], rest[1:]); err != nil {
+			return err
+		}
+		h.cocSet[c] = true
+	case 0xFF5C: // QCD
+		q, err := parseJPXQuant(seg)
+		if err != nil {
+			return err
+		}
+		for c := range p.quant {
+			if !h.qccSet[c] {
+				p.quant[c] = q
+			}
+		}
+		h.sawQCD = true
+	case 0xFF5D: // QCC
+		c, rest, err := comp()
+		if err != nil {
+			return err
+		}
+		if p.quant[c], err = parseJPXQuant(rest); err != nil {
+			return err
+		}
+		h.qccSet[c] = true
+	case 0xFF5E: // RGN
+		c, rest, err := comp()
+		if err != nil {
+			return err
+		}
+		if len(rest) < 2 {
+			return errors.New("jpx: truncated RGN marker segment")
+		}
+		p.roi[c] = int(rest[1])
+	case 0xFF5F: // POC
+		if !h.pocSeen {
+			p.poc, h.pocSeen = nil, true
+		}
+		cw := 1
+		if len(cs.comps) >= 257 {
+			cw = 2
+		}
+		n := 5 + 2*cw
+		for ; len(seg) >= n; seg = seg[n:] {
+			get := func(i int) int {
+				if cw == 1 {
+					return int(seg[i])
+				}
+				return int(binary.BigEndian.Uint16(seg[i:]))
+			}
+			v := jpxPOC{
+				r0: int(seg[0]), c0: get(1),
+				layers: int(binary.BigEndian.Uint16(seg[1+cw:])),
+				r1:     int(seg[3+cw]), c1: get(4 + cw),
+				order: int(seg[4+2*cw]),
+			}
+			if cw == 1 && v.c1 == 0 {
+				v.c1 = 256
+			}
+			if v.order > jpxCPRL {
+				return fmt.Errorf("jpx: progression order %d", v.order)
+			}
+			p.poc = append(p.poc, v)
+		}
+	case 0xFF60, 0xFF61:
+		return errors.New("jpx: packed packet headers (PPM/PPT) not supported")
+	}
+	return nil
+}
+
+// parseJPXCoding reads the coding style parameters SPcod/SPcoc; flags is
+// Scod/Scoc, whose low bit signals explicit precinct sizes.
+func parseJPXCoding(flags byte, sp []byte) (jpxCoding, error) {
+	if len(sp) < 5 {
+		return jpxCoding{}, errors.New("jpx: truncated coding style")
+	}
+	c := jpxCoding{
+		levels:     int(sp[0]),
+		cbw:        int(sp[1]) + 2,
+		cbh:        int(sp[2]) + 2,
+		style:      int(sp[3]),
+		reversible: sp[4] == 1,
+	}
+	if c.levels > 32 || c.cbw > 10 || c.cbh > 10 || c.cbw+c.cbh > 12 {
+		return jpxCoding{}, fmt.Errorf("jpx: %d decomposition levels, code-blocks 2^%d x 2^%d", c.levels, c.cbw, c.cbh)
+	}
+	c.precincts = make([]int, c.levels+1)
+	for r := range c.precincts {
+		c.precincts[r] = 0xFF
+		if flags&1 != 0 {
+			if 5+r >= len(sp) {
+				return jpxCoding{}, errors.New("jpx: truncated precinct sizes")
+			}
+			c.precincts[r] = int(sp[5+r])
+			if r > 0 && (sp[5+r]&0x0F == 0 || sp[5+r]>>4 == 0) {
+				return jpxCoding{}, errors.New("jpx: precinct size 1 above resolution level 0")
+			}
+		}
+	}
+	return c, nil
+}
+
+// parseJPXQuant reads a QCD/QCC body from Sqcd on.
+func parseJPXQuant(seg []byte) (jpxQuant, error) {
+	if len(seg) < 1 {
+		return jpxQuant{}, errors.New("jpx: truncated quantization")
+	}
+	q := jpxQuant{style: int(seg[0] & 0x1F), guard: int(seg[0] >> 5)}
+	switch q.style {
+	case 0:
+		for _, b := range seg[1:] {
+			q.steps = append(q.steps, [2]int{int(b >> 3), 0})
+		}
+	case 1, 2:
+		for s := seg[1:]; len(s) >= 2; s = s[2:] {
+			v := int(binary.BigEndian.Uint16(s))
+			q.steps = append(q.steps, [2]int{v >> 11, v & 0x7FF})
+		}
+	default:
+		return jpxQuant{}, fmt.Errorf("jpx: quantization style %d", q.style)
+	}
+	if len(q.steps) == 0 {
+		return jpxQuant{}, errors.New("jpx: no quantization step sizes")
+	}
+	return q, nil
+}
+
+// step returns the exponent and mantissa of subband orient at resolution
+// level r, deriving them from the LL values for scalar derived
+// quantization (E.1.1.2).
+func (q jpxQuant) step(levels, r, orient int) (int, int, error) {
+	if q.style == 1 {
+		nb := levels
+		if r > 0 {
+			nb = levels - r + 1
+		}
+		return q.steps[0][0] - levels + nb, q.steps[0][1], nil
+	}
+	i := 0
+	if r > 0 {
+		i = 3*(r-1) + orient
+	}
+	if i >= len(q.steps) {
+		return 0, 0, fmt.Errorf("jpx: no step size for subband %d", i)
+	}
+	return q.steps[i][0], q.steps[i][1], nil
+}
+
+// jpxCodeBlock is one code-block and the coded data packets gave it.
+type jpxCodeBlock struct {
+	x0, y0, x1, y1 int
+	included       bool
+	zeroPlanes     int
+	lblock         int
+	segs           []jpxSegment
+}
+
+// jpxPrecinct is one precinct of a subband: its code-blocks and the tag
+// trees coding their inclusion and zero bit-planes.
+type jpxPrecinct struct {
+	blocks    []jpxCodeBlock
+	incl, zbp *jpxTagTree
+}
+
+// jpxBand is one subband of a resolution level.
+type jpxBand struct {
+	orient         int
+	x0, y0, x1, y1 int
+	planes         int
+	step           float64
+	precincts      []jpxPrecinct
+	coeffs         []float32
+}
+
+// jpxRes is one resolution level of a tile-component.
+type jpxRes struct {
+	x0, y0, x1, y1 int
+	ppx, ppy       int
+	pw, ph         int
+	bands          []*jpxBand
+	nextLayer      []int
+}
+
+// jpxTileComp is one component of a tile.
+type jpxTileComp struct {
+	x0, y0, x1, y1 int
+	coding         jpxCoding
+	roi            int
+	res            []*jpxRes
+}
+
+// decodeTile decodes tile idx into the component planes.
+func (cs *jpxCodestream) decodeTile(idx int, t *jpxTile) error {
+	p := t.hdr.p
+	px, py := idx%cs.ntx, idx/cs.ntx
+	tx0 := max(cs.tx0+px*cs.tw, cs.x0)
+	ty0 := max(cs.ty0+py*cs.th, cs.y0)
+	tx1 := min(cs.tx0+(px+1)*cs.tw, cs.x1)
+	ty1 := min(cs.ty0+(py+1)*cs.th, cs.y1)
+	tcs := make([]*jpxTileComp, len(cs.comps))
+	for c, comp := range cs.comps {
+		tc, err := newJPXTileComp(comp, p.coding[c], p.quant[c], p.roi[c],
+			ceilDiv(tx0, comp.dx), ceilDiv(ty0, comp.dy), ceilDiv(tx1, comp.dx), ceilDiv(ty1, comp.dy))
+		if err != nil {
+			return err
+		}
+		tcs[c] = tc
+	}
+
+	d := &jpxPacketReader{data: t.data, sop: p.sop, eph: p.eph}
+	volumes := p.poc
+	if len(volumes) == 0 {
+		volumes = []jpxPOC{{layers: p.layers, r1: 33, c1: len(cs.comps), order: p.order}}
+	}
+	for _, v := range volumes {
+		v.layers = min(v.layers, p.layers)
+		v.c1 = min(v.c1, len(cs.comps))
+		err := cs.eachPacket(tcs, v, tx0, ty0, func(l, r, c, k int) error {
+			res := tcs[c].res[r]
+			if l != res.nextLayer[k] {
+				return nil
+			}
+			res.nextLayer[k]++
+			return d.packet(res, k, l, tcs[c].coding.style)
+		})
+		if errors.Is(err, errJPXEndOfData) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	samples := make([][]float32, len(tcs))
+	for c, tc := range tcs {
+		samples[c] = tc.reconstruct()
+	}
+	if p.mct && len(tcs) >= 3 && tcs[0].sameSize(tcs[1]) && tcs[0].sameSize(tcs[2]) {
+		inverseMCT(samples[0], samples[1], samples[2], tcs[0].coding.reversible)
+	}
+	for c, tc := range tcs {
+		cs.store(c, tc, samples[c])
+	}
+	return nil
+}
+
+// newJPXTileComp lays out the resolution levels, subbands, precincts and
+// code-blocks of the tile-component [x0, x1) x [y0, y1) (B.5-B.7).
+func newJPXTileComp(comp jpxComponent, coding jpxCoding, q jpxQuant, roi, x0, y0, x1, y1 int) (*jpxTileComp, error) {
+	tc := &jpxTileComp{x0: x0, y0: y0, x1: x1, y1: y1, coding: coding, roi: roi}
+	nl := coding.levels
+	for r := 0; r <= nl; r++ {
+		s := nl - r
+		res := &jpxRes{
+			x0: ceilDiv(x0, 1<<s), y0: ceilDiv(y0, 1<<s),
+			x1: ceilDiv(x1, 1<<s), y1: ceilDiv(y1, 1<<s),
+			ppx: coding.precincts[r] & 0x0F, ppy: coding.precincts[r] >> 4,
+		}
+		if res.x1 > res.x0 {
+			res.pw = ceilDiv(res.x1, 1<<res.ppx) - res.x0>>res.ppx
+		}
+		if res.y1 > res.y0 {
+			res.ph = ceilDiv(res.y1, 1<<res.ppy) - res.y0>>res.ppy
+		}
+		if res.pw*res.ph > maxJPXSamples {
+			return nil, errors.New("jpx: too many precincts")
+		}
+		res.nextLayer = make([]int, res.pw*res.ph)
+		orients := []int{jpxLL}
+		if r > 0 {
+			orients = []int{jpxHL, jpxLH, jpxHH}
+		}
+		for _, o := range orients {
+			b := &jpxBand{orient: o}
+			if r == 0 {
+				b.x0, b.y0, b.x1, b.y1 = res.x0, res.y0, res.x1, res.y1
+			} else {
+				nb := nl - r + 1
+				xo, yo := o&1, o>>1
+				b.x0 = ceilDiv(x0-xo<<(nb-1), 1<<nb)
+				b.y0 = ceilDiv(y0-yo<<(nb-1), 1<<nb)
+				b.x1 = ceilDiv(x1-xo<<(nb-1), 1<<nb)
+				b.y1 = ceilDiv(y1-yo<<(nb-1), 1<<nb)
+			}
+			exp, mant, err := q.step(nl, r, o)
+			if err != nil {
+				return nil, err
+			}
+			b.planes = q.guard + exp - 1 + roi
+			if b.planes > 30 {
+				return nil, fmt.Errorf("jpx: %d magnitude bit-planes", b.planes)
+			}
+			gain := [4]int{0, 1, 1, 2}[o]
+			b.step = 1
+			if !coding.reversible {
+				b.step = (1 + float64(mant)/2048) * math.Ldexp(1, comp.prec+gain-exp)
+			}
+			b.coeffs = make([]float32, (b.x1-b.x0)*(b.y1-b.y0))
+			if err := b.layoutPrecincts(res, r > 0, coding); err != nil {
+				return nil, err
+			}
+			res.bands = append(res.bands, b)
+		}
+		tc.res = append(tc.res, res)
+	}
+	return tc, nil
+}
+
+// layoutPrecincts divides the band into the resolution level's precincts
+// and each precinct into code-blocks, anchored at the origin.
+func (b *jpxBand) layoutPrecincts(res *jpxRes, halve bool, coding jpxCoding) error {
+	pbx, pby := res.ppx, res.ppy
+	if halve {
+		pbx, pby = pbx-1, pby-1
+	}
+	cbw, cbh := min(coding.cbw, pbx), min(coding.cbh, pby)
+	b.precincts = make([]jpxPrecinct, res.pw*res.ph)
+	for k := range b.precincts {
+		sx := (res.x0>>res.ppx + k%res.pw) << pbx
+		sy := (res.y0>>res.ppy + k/res.pw) << pby
+		px0, py0 := max(sx, b.x0), max(sy, b.y0)
+		px1, py1 := min(sx+1<<pbx, b.x1), min(sy+1<<pby, b.y1)
+		if px1 <= px0 || py1 <= py0 {
+			b.precincts[k].incl, b.precincts[k].zbp = newJPXTagTree(0, 0), newJPXTagTree(0, 0)
+			continue
+		}
+		cx0, cy0 := px0>>cbw, py0>>cbh
+		cw, ch := ceilDiv(px1, 1<<cbw)-cx0, ceilDiv(py1, 1<<cbh)-cy0
+		prc := &b.precincts[k]
+		prc.incl, prc.zbp = newJPXTagTree(cw, ch), newJPXTagTree(cw, ch)
+		prc.blocks = make([]jpxCodeBlock, cw*ch)
+		for j := range prc.blocks {
+			bx, by := (cx0+j%cw)<<cbw, (cy0+j/cw)<<cbh
+			prc.blocks[j] = jpxCodeBlock{
+				x0: max(bx, px0), y0: max(by, py0),
+				x1: min(bx+1<<cbw, px1), y1: min(by+1<<cbh, py1),
+			}
+		}
+	}
+	return nil
+}
+
+// reconstruct runs tier-1 on each code-block, dequantizes the
+// coefficients (E.1) and applies the inverse wavelet transform (F.3),
+// returning the tile-component's samples before the DC level shift.
+func (tc *jpxTileComp) reconstruct() []float32 {
+	for _, res := range tc.res {
+		for _, b := range res.bands {
+			bw := b.x1 - b.x0
+			for _, prc := range b.precincts {
+				for _, cb := range prc.blocks {
+					if len(cb.segs) == 0 {
+						continue
+					}
+					w, h := cb.x1-cb.x0, cb.y1-cb.y0
+					mag := decodeCodeBlock(w, h, cb.segs, cb.zeroPlanes, b.planes, b.orient, tc.coding.style)
+					for y := range h {
+						row := b.coeffs[(cb.y0-b.y0+y)*bw+cb.x0-b.x0:]
+						for x := range w {
+							row[x] = b.dequantize(mag[y*w+x], tc.roi, tc.coding.reversible)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	a := tc.res[0].bands[0].coeffs
+	for r := 1; r < len(tc.res); r++ {
+		a = tc.synthesize(tc.res[r], a)
+	}
+	return a
+}
+
+// dequantize turns a tier-1 coefficient, a magnitude in half units with
+// the coefficient's sign, into its reconstructed value. With a region of
+// interest shift, magnitudes at or above the shift are scaled back down
+// (Annex H).
+func (b *jpxBand) dequantize(v int32, roi int, reversible bool) float32 {
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	if roi > 0 && v >= 2<<roi {
+		v >>= roi
+	}
+	var f float64
+	if reversible {
+		f = float64(v >> 1)
+	} else {
+		f = float64(v) / 2 * b.step
+	}
+	if neg {
+		f = -f
+	}
+	return float32(f)
+}
+
+// synthesize interleaves the previous level's samples ll with the three
+// subbands of res and filters rows then columns (F.3.2), returning res's
+// samples.
+func (tc *jpxTileComp) synthesize(res *jpxRes, ll []float32) []float32 {
+	w, h := res.x1-res.x0, res.y1-res.y0
+	out := make([]float32, w*h)
+	if w == 0 || h == 0 {
+		return out
+	}
+	xl, yl := res.x0&1, res.y0&1
+	place := func(src []float32, bw, bh, xo, yo int) {
+		for y := range bh {
+			oy := 2*y + yo
+			for x := range bw {
+				out[oy*w+2*x+xo] = src[y*bw+x]
+			}
+		}
+	}
+	lw, lh := ceilDiv(res.x1, 2)-ceilDiv(res.x0, 2), ceilDiv(res.y1, 2)-ceilDiv(res.y0, 2)
+	place(ll, lw, lh, xl, yl)
+	for _, b := range res.bands {
+		xo, yo := xl, yl
+		if b.orient&1 != 0 {
+			xo = 1 - xl
+		}
+		if b.orient&2 != 0 {
+			yo = 1 - yl
+		}
+		place(b.coeffs, b.x1-b.x0, b.y1-b.y0, xo, yo)
+		b.coeffs = nil
+	}
+
+	rev := tc.coding.reversible
+	line := make([]float64, max(w, h)+2*jpxPad)
+	for y := range h {
+		row := out[y*w : (y+1)*w]
+		for x, v := range row {
+			line[jpxPad+x] = float64(v)
+		}
+		synth1D(line[:w+2*jpxPad], res.x0, rev)
+		for x := range row {
+			row[x] = float32(line[jpxPad+x])
+		}
+	}
+	for x := range w {
+		for y := range h {
+			line[jpxPad+y] = float64(out[y*w+x])
+		}
+		synth1D(line[:h+2*jpxPad], res.y0, rev)
+		for y := range h {
+			out[y*w+x] = float32(line[jpxPad+y])
+		}
+	}
+	return out
+}
+
+// jpxPad is the symmetric extension synth1D gives a line on each side,
+// enough for the four lifting steps of the 9/7 filter.
+const jpxPad = 4
+
+// Lifting parameters of the irreversible 9/7 filter (Table F.4).
+const (
+	jpxAlpha = -1.586134342059924
+	jpxBeta  = -0.052980118572961
+	jpxGamma = 0.882911075530934
+	jpxDelta = 0.443506852043971
+	jpxK     = 1.230174104914001
+)
+
+// synth1D is the one-dimensional subband reconstruction 1D_SR (F.3.6) of
+// the samples line[jpxPad:len(line)-jpxPad], the first at absolute index
+// i0, whose parity places the low-pass samples. The padding is filled by
+// periodic symmetric extension.
+func synth1D(line []float64, i0 int, reversible bool) {
+	n := len(line) - 2*jpxPad
+	if n == 1 {
+		if i0&1 == 1 {
+			line[jpxPad] /= 2
+			if reversible {
+				line[jpxPad] = math.Trunc(line[jpxPad])
+			}
+		}
+		return
+	}
+	period := 2 * (n - 1)
+	reflect := func(i int) int {
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - i
+		}
+		return i
+	}
+	for k := 1; k <= jpxPad; k++ {
+		line[jpxPad-k] = line[jpxPad+reflect(-k)]
+		line[jpxPad+n-1+k] = line[jpxPad+reflect(n-1+k)]
+	}
+	// even reports whether line[k] is a low-pass sample; jpxPad is even.
+	even := func(k int) bool { return (i0+k)&1 == 0 }
+	last := len(line) - 1
+	if reversible {
+		for k := 1; k < last; k++ {
+			if even(k) {
+				line[k] -= math.Floor((line[k-1] + line[k+1] + 2) / 4)
+			}
+		}
+		for k := 1; k < last; k++ {
+			if !even(k) {
+				line[k] += math.Floor((line[k-1] + line[k+1]) / 2)
+			}
+		}
+		return
+	}
+	for k := range line {
+		if even(k) {
+			line[k] *= jpxK
+		} else {
+			line[k] /= jpxK
+		}
+	}
+	for _, s := range []struct {
+		even bool
+		c    float64
+	}{{true, jpxDelta}, {false, jpxGamma}, {true, jpxBeta}, {false, jpxAlpha}} {
+		for k := 1; k < last; k++ {
+			if even(k) == s.even {
+				line[k] -= s.c * (line[k-1] + line[k+1])
+			}
+		}
+	}
+}
+
+// inverseMCT undoes the multiple component transformation of the first
+// three components (Annex G): the reversible RCT or the irreversible ICT.
+func inverseMCT(y0, y1, y2 []float32, reversible bool) {
+	for i := range y0 {
+		a, b, c := float64(y0[i]), float64(y1[i]), float64(y2[i])
+		var r, g, bl float64
+		if reversible {
+			g = a - math.Floor((b+c)/4)
+			r, bl = c+g, b+g
+		} else {
+			r = a + 1.402*c
+			g = a - 0.34413*b - 0.71414*c
+			bl = a + 1.772*b
+		}
+		y0[i], y1[i], y2[i] = float32(r), float32(g), float32(bl)
+	}
+}
+
+func (tc *jpxTileComp) sameSize(o *jpxTileComp) bool {
+	return tc.x1-tc.x0 == o.x1-o.x0 && tc.y1-tc.y0 == o.y1-o.y0
+}
+
+// store level shifts a tile-component's samples (G.1.2), clamps them to
+// the component's precision and writes them into its plane, precisions
+// above 16 bits reduced to 16.
+func (cs *jpxCodestream) store(c int, tc *jpxTileComp, samples []float32) {
+	comp := cs.comps[c]
+	shift := math.Ldexp(1, comp.prec-1)
+	top := math.Ldexp(1, comp.prec) - 1
+	drop := comp.prec - cs.planePrec[c]
+	w := tc.x1 - tc.x0
+	pw := cs.planeW[c]
+	for y := tc.y0; y < tc.y1; y++ {
+		row := cs.planes[c][(y-cs.planeY0[c])*pw:]
+		for x := tc.x0; x < tc.x1; x++ {
+			v := math.Round(float64(samples[(y-tc.y0)*w+x-tc.x0]) + shift)
+			row[x-cs.planeX0[c]] = int32(int64(max(0, min(top, v))) >> drop)
+		}
+	}
+}
+
+// jp2Meta is what DecodeJPX needs from a JP2 header: the enumerated
+// colour space and the palette, component mapping and channel definition
+// boxes (I.5.3).
+type jp2Meta struct {
+	enumCS  int
+	palette *jp2Palette
+	cmap    [][3]int // component, mapping type, palette column
+	cdef    [][3]int // channel, type, association
+}
+
+// jp2Palette is a 'pclr' box: entries rows of len(depths) columns.
+type jp2Palette struct {
+	depths  []int
+	entries [][]int
+}
+
+// splitJP2 returns the codestream of a JP2/JPX file and its header
+// metadata; a bare codestream is returned as is.
+func splitJP2(data []byte) ([]byte, jp2Meta, error) {
+	var meta jp2Meta
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0x4F {
+		return data, meta, nil
+	}
+	var code []byte
+	sawColour := false
+	err := walkJP2Boxes(data, func(typ string, body []byte) error {
+		switch typ {
+		case "jp2c":
+			if code == nil {
+				code = body
+			}
+		case "jp2h":
+			return walkJP2Boxes(body, func(typ string, b []byte) error {
+				switch typ {
+				case "colr":
+					if !sawColour && len(b) >= 7 && b[0] == 1 {
+						meta.enumCS = int(binary.BigEndian.Uint32(b[3:]))
+					}
+					sawColour = true
+				case "pclr":
+					p, err := parseJP2Palette(b)
+					if err != nil {
+						return err
+					}
+					meta.palette = p
+				case "cmap":
+					for ; len(b) >= 4; b = b[4:] {
+						meta.cmap = append(meta.cmap, [3]int{int(binary.BigEndian.Uint16(b)), int(b[2]), int(b[3])})
+					}
+				case "cdef":
+					if len(b) < 2 {
+						return errors.New("jpx: truncated channel definition box")
+					}
+					for b = b[2:]; len(b) >= 6; b = b[6:] {
+						meta.cdef = append(meta.cdef, [3]int{
+							int(binary.BigEndian.Uint16(b)), int(binary.BigEndian.Uint16(b[2:])), int(binary.BigEndian.Uint16(b[4:])),
+						})
+					}
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, meta, err
+	}
+	if code == nil {
+		return nil, meta, errors.New("jpx: no contiguous codestream box")
+	}
+	return code, meta, nil
+}
+
+// parseJP2Palette reads a 'pclr' box (I.5.3.4).
+func parseJP2Palette(b []byte) (*jp2Palette, error) {
+	if len(b) < 3 {
+		return nil, errors.New("jpx: truncated palette box")
+	}
+	ne, npc := int(binary.BigEndian.Uint16(b)), int(b[2])
+	if ne == 0 || npc == 0 || len(b) < 3+npc {
+		return nil, errors.New("jpx: malformed palette box")
+	}
+	p := &jp2Palette{}
+	size := 0
+	for _, d := range b[3 : 3+npc] {
+		bits := int(d&0x7F) + 1
+		if bits > 16 {
+			return nil, fmt.Errorf("jpx: %d-bit palette column", bits)
+		}
+		p.depths = append(p.depths, bits)
+		size += (bits + 7) / 8
+	}
+	b = b[3+npc:]
+	if len(b) < ne*size {
+		return nil, errors.New("jpx: truncated palette box")
+	}
+	for range ne {
+		row := make([]int, npc)
+		for j, bits := range p.depths {
+			if bits > 8 {
+				row[j], b = int(binary.BigEndian.Uint16(b)), b[2:]
+			} else {
+				row[j], b = int(b[0]), b[1:]
+			}
+		}
+		p.entries = append(p.entries, row)
+	}
+	return p, nil
+}
+
+// jpxChannel is one output channel: a component plane, optionally looked
+// up in a palette column.
+type jpxChannel struct {
+	comp   int
+	column int // palette column, or -1
+}
+
+// image assembles the decoded planes into an 8-bit interleaved image,
+// applying meta's palette, component mapping and channel definitions.
+func (cs *jpxCodestream) image(meta jp2Meta) (*JPXImage, error) {
+	var chans []jpxChannel
+	switch {
+	case meta.cmap != nil:
+		for _, m := range meta.cmap {
+			ch := jpxChannel{comp: m[0], column: -1}
+			if m[1] == 1 {
+				if meta.palette == nil || m[2] >= len(meta.palette.depths) {
+					return nil, errors.New("jpx: component mapping refers to a missing palette column")
+				}
+				ch.column = m[2]
+			}
+			if ch.comp >= len(cs.comps) {
+				return nil, fmt.Errorf("jpx: component mapping refers to component %d", ch.comp)
+			}
+			chans = append(chans, ch)
+		}
+	case meta.palette != nil:
+		for j := range meta.palette.depths {
+			chans = append(chans, jpxChannel{comp: 0, column: j})
+		}
+	default:
+		for c := range cs.comps {
+			chans = append(chans, jpxChannel{comp: c, column: -1})
+		}
+	}
+
+	img := &JPXImage{Width: cs.x1 - cs.x0, Height: cs.y1 - cs.y0, EnumCS: meta.enumCS}
+	if meta.cdef != nil {
+		colour := make([]jpxChannel, len(chans))
+		n := 0
+		var alpha *jpxChannel
+		for _, d := range meta.cdef {
+			if d[0] >= len(chans) {
+				continue
+			}
+			switch {
+			case d[1] == 0 && d[2] >= 1 && d[2] <= len(chans):
+				colour[d[2]-1] = chans[d[0]]
+				n = max(n, d[2])
+			case (d[1] == 1 || d[1] == 2) && alpha == nil:
+				alpha = &chans[d[0]]
+			}
+		}
+		if n > 0 {
+			chans = colour[:n]
+			if alpha != nil {
+				chans = append(chans, *alpha)
+				img.Alpha = true
+			}
+		}
+	}
+	img.Components = len(chans)
+
+	planes := make([][]byte, len(chans))
+	img.Depths = make([]int, len(chans))
+	for i, ch := range chans {
+		planes[i] = cs.channel(ch, meta.palette)
+		img.Depths[i] = cs.planePrec[ch.comp]
+		if ch.column >= 0 {
+			img.Depths[i] = meta.palette.depths[ch.column]
+		}
+	}
+	if img.EnumCS == JPXEnumSYCC && len(chans) >= 3 {
+		syccToRGB(planes[0], planes[1], planes[2])
+		img.EnumCS = JPXEnumSRGB
+	}
+	n := img.Width * img.Height
+	img.Pix = make([]byte, n*len(planes))
+	for i, p := range planes {
+		for j, v := range p {
+			img.Pix[j*len(planes)+i] = v
+		}
+	}
+	return img, nil
+}
+
+// channel renders one channel at image resolution, 8 bits per sample.
+func (cs *jpxCodestream) channel(ch jpxChannel, palette *jp2Palette) []byte {
+	w, h := cs.x1-cs.x0, cs.y1-cs.y0
+	comp := cs.comps[ch.comp]
+	plane, pw, ph := cs.planes[ch.comp], cs.planeW[ch.comp], cs.planeH[ch.comp]
+	prec := cs.planePrec[ch.comp]
+	var lut []byte
+	if ch.column >= 0 {
+		prec = palette.depths[ch.column]
+		lut = make([]byte, len(palette.entries))
+		for i, e := range palette.entries {
+			lut[i] = scaleTo8(e[ch.column], prec)
+		}
+	}
+	out := make([]byte, w*h)
+	for y := range h {
+		sy := min(max((cs.y0+y)/comp.dy-cs.planeY0[ch.comp], 0), ph-1)
+		for x := range w {
+			sx := min(max((cs.x0+x)/comp.dx-cs.planeX0[ch.comp], 0), pw-1)
+			v := int(plane[sy*pw+sx])
+			if lut != nil {
+				out[y*w+x] = lut[min(v, len(lut)-1)]
+			} else {
+				out[y*w+x] = scaleTo8(v, prec)
+			}
+		}
+	}
+	return out
+}
+
+// scaleTo8 rescales a prec-bit sample to 8 bits.
+func scaleTo8(v, prec int) byte {
+	if prec == 8 {
+		return byte(v)
+	}
+	top := 1<<prec - 1
+	return byte((v*255 + top/2) / top)
+}
+
+// syccToRGB converts sYCC samples to sRGB in place (IEC 61966-2-1 Amd. 1).
+func syccToRGB(y, cb, cr []byte) {
+	for i := range y {
+		l, b, r := float64(y[i]), float64(cb[i])-128, float64(cr[i])-128
+		y[i] = clampByte(l + 1.402*r)
+		cb[i] = clampByte(l - 0.344136*b - 0.714136*r)
+		cr[i] = clampByte(l + 1.772*b)
+	}
+}
+
+func clampByte(v float64) byte {
+	return byte(max(0, min(255, math.Round(v))))
+}
+
+// ceilDiv is a/b rounded up, for b > 0 and a > -b.
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
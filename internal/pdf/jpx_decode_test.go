@@ -0,0 +1,895 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+// jpxTestSamples is a deterministic test image: gradients with a little
+// texture, w*h samples of prec bits for each of comps components.
+func jpxTestSamples(w, h, comps, prec int) [][]int {
+	out := make([][]int, comps)
+	top := 1<<prec - 1
+	for c := range out {
+		out[c] = make([]int, w*h)
+		for y := range h {
+			for x := range w {
+				v := (x*(c+1)*top/max(w-1, 1) + y*top/max(h-1, 1)) / 2
+				v += (x*7 + y*13 + c*5) % 11 * top / 64
+				out[c][y*w+x] = min(v, top)
+			}
+		}
+	}
+	return out
+}
+
+// jpxInterleave8 is the JPXImage.Pix expected for samples of prec bits.
+func jpxInterleave8(samples [][]int, prec int) []byte {
+	out := make([]byte, len(samples[0])*len(samples))
+	for c, plane := range samples {
+		for i, v := range plane {
+			out[i*len(samples)+c] = scaleTo8(v, prec)
+		}
+	}
+	return out
+}
+
+func TestDecodeJPXLossless(t *testing.T) {
+	tests := []struct {
+		name string
+		p    jpxTestParams
+	}{
+		{"grey, offset, small code-blocks", jpxTestParams{w: 37, h: 29, x0: 3, y0: 5, comps: 1, prec: 8, levels: 3, cbw: 2, cbh: 3}},
+		{"grey, tiles", jpxTestParams{w: 40, h: 33, x0: 7, y0: 1, tw: 16, th: 16, comps: 1, prec: 8, levels: 2, cbw: 3, cbh: 3}},
+		{"single column and row tiles", jpxTestParams{w: 9, h: 9, x0: 0, y0: 0, tw: 8, th: 8, comps: 1, prec: 8, levels: 2, cbw: 2, cbh: 2}},
+		{"RCT, precincts, layers, RPCL", jpxTestParams{
+			w: 45, h: 31, x0: 1, y0: 2, comps: 3, prec: 8, levels: 2, cbw: 2, cbh: 2,
+			precincts: []int{0x22, 0x33, 0x33}, mct: true, layers: 2, order: jpxRPCL, style: jpxStyleTermAll,
+		}},
+		{"bypass, reset, causal, segmentation symbols, PCRL", jpxTestParams{
+			w: 33, h: 35, comps: 2, prec: 8, levels: 3, cbw: 3, cbh: 3, order: jpxPCRL,
+			style: jpxStyleBypass | jpxStyleReset | jpxStyleVCausal | jpxStyleSegSym,
+		}},
+		{"bypass with TERMALL, SOP, EPH, RLCP", jpxTestParams{
+			w: 20, h: 18, comps: 1, prec: 8, levels: 1, cbw: 4, cbh: 4, layers: 3, order: jpxRLCP,
+			style: jpxStyleBypass | jpxStyleTermAll, sop: true, eph: true,
+		}},
+		{"CPRL, 12 bits", jpxTestParams{
+			w: 24, h: 20, x0: 2, y0: 2, comps: 3, prec: 12, levels: 2, cbw: 3, cbh: 2,
+			precincts: []int{0x11, 0x22, 0x22}, layers: 2, order: jpxCPRL, style: jpxStyleTermAll,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.p.reversible = true
+			samples := jpxTestSamples(tt.p.w, tt.p.h, tt.p.comps, tt.p.prec)
+			img, err := DecodeJPX(encodeTestJ2K(t, tt.p, samples))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Width != tt.p.w || img.Height != tt.p.h || img.Components != tt.p.comps || img.Alpha {
+				t.Fatalf("decoded %dx%d with %d components (alpha %v)", img.Width, img.Height, img.Components, img.Alpha)
+			}
+			if want := jpxInterleave8(samples, tt.p.prec); !bytes.Equal(img.Pix, want) {
+				for i := range want {
+					if img.Pix[i] != want[i] {
+						t.Fatalf("sample %d (x %d, y %d) = %d, want %d", i, i/tt.p.comps%tt.p.w, i/tt.p.comps/tt.p.w, img.Pix[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJPXIrreversible(t *testing.T) {
+	p := jpxTestParams{w: 41, h: 27, x0: 5, y0: 3, tw: 32, th: 16, comps: 3, prec: 8, levels: 3, cbw: 3, cbh: 3, mct: true}
+	samples := jpxTestSamples(p.w, p.h, p.comps, p.prec)
+	img, err := DecodeJPX(encodeTestJ2K(t, p, samples))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := jpxInterleave8(samples, p.prec)
+	worst := 0
+	for i := range want {
+		worst = max(worst, abs(int(img.Pix[i])-int(want[i])))
+	}
+	if worst > 2 {
+		t.Errorf("largest error %d, want at most 2", worst)
+	}
+}
+
+// jp2File wraps a codestream and header boxes in a JP2 file.
+func jp2File(code []byte, header ...[]byte) []byte {
+	return bytes.Join([][]byte{
+		jp2Box("jP  ", jp2Signature),
+		jp2Box("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 ")),
+		jp2Box("jp2h", header...),
+		jp2Box("jp2c", code),
+	}, nil)
+}
+
+func TestDecodeJPXBoxes(t *testing.T) {
+	t.Run("palette", func(t *testing.T) {
+		p := jpxTestParams{w: 4, h: 2, comps: 1, prec: 2, levels: 1, cbw: 2, cbh: 2, reversible: true}
+		code := encodeTestJ2K(t, p, [][]int{{0, 1, 2, 3, 3, 2, 1, 0}})
+		pclr := []byte{0, 4, 3, 7, 7, 7, 255, 0, 0, 0, 255, 0, 0, 0, 255, 9, 9, 9}
+		cmap := []byte{0, 0, 1, 0, 0, 0, 1, 1, 0, 0, 1, 2}
+		img, err := DecodeJPX(jp2File(code, colrEnum(1, JPXEnumSRGB), jp2Box("pclr", pclr), jp2Box("cmap", cmap)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []byte{255, 0, 0, 0, 255, 0, 0, 0, 255, 9, 9, 9, 9, 9, 9, 0, 0, 255, 0, 255, 0, 255, 0, 0}
+		if img.Components != 3 || img.EnumCS != JPXEnumSRGB || !bytes.Equal(img.Pix, want) {
+			t.Errorf("decoded %d components, colour space %d, % X", img.Components, img.EnumCS, img.Pix)
+		}
+	})
+
+	t.Run("opacity channel first", func(t *testing.T) {
+		p := jpxTestParams{w: 3, h: 1, comps: 2, prec: 8, levels: 0, cbw: 2, cbh: 2, reversible: true}
+		code := encodeTestJ2K(t, p, [][]int{{10, 20, 30}, {200, 100, 0}})
+		cdef := []byte{0, 2, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1}
+		img, err := DecodeJPX(jp2File(code, colrEnum(1, JPXEnumGreyscale), jp2Box("cdef", cdef)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []byte{200, 10, 100, 20, 0, 30}
+		if img.Components != 2 || !img.Alpha || !bytes.Equal(img.Pix, want) {
+			t.Errorf("decoded %d components, alpha %v, % X", img.Components, img.Alpha, img.Pix)
+		}
+	})
+
+	t.Run("sYCC", func(t *testing.T) {
+		p := jpxTestParams{w: 2, h: 1, comps: 3, prec: 8, levels: 0, cbw: 2, cbh: 2, reversible: true}
+		code := encodeTestJ2K(t, p, [][]int{{128, 76}, {128, 85}, {128, 255}})
+		img, err := DecodeJPX(jp2File(code, colrEnum(1, JPXEnumSYCC)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.EnumCS != JPXEnumSRGB || img.Pix[0] != 128 || img.Pix[3] < 250 || img.Pix[4] > 5 || img.Pix[5] > 5 {
+			t.Errorf("decoded colour space %d, % X", img.EnumCS, img.Pix)
+		}
+	})
+}
+
+func TestDecodeJPXTruncated(t *testing.T) {
+	p := jpxTestParams{w: 30, h: 30, comps: 1, prec: 8, levels: 2, cbw: 3, cbh: 3, reversible: true, layers: 3, style: jpxStyleTermAll}
+	code := encodeTestJ2K(t, p, jpxTestSamples(p.w, p.h, 1, 8))
+	for _, n := range []int{len(code) - 2, len(code) * 3 / 4, len(code) / 2} {
+		img, err := DecodeJPX(code[:n])
+		if err != nil {
+			t.Errorf("%d of %d bytes: %v", n, len(code), err)
+			continue
+		}
+		if len(img.Pix) != 900 {
+			t.Errorf("%d of %d bytes: %d samples", n, len(code), len(img.Pix))
+		}
+	}
+}
+
+func TestDecodeJPXErrors(t *testing.T) {
+	p := jpxTestParams{w: 8, h: 8, comps: 1, prec: 8, levels: 1, cbw: 2, cbh: 2, reversible: true}
+	code := encodeTestJ2K(t, p, jpxTestSamples(8, 8, 1, 8))
+	sot := bytes.Index(code, []byte{0xFF, 0x90})
+	withMarker := func(m []byte) []byte {
+		return append(append(append([]byte(nil), code[:sot]...), m...), code[sot:]...)
+	}
+	noCOD := append([]byte(nil), code...)
+	cod := bytes.Index(noCOD, []byte{0xFF, 0x52})
+	noCOD[cod+1] = 0x64 // COM
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not JPEG 2000", []byte("hello"), "box"},
+		{"truncated SIZ", code[:20], "out of range"},
+		{"no COD", noCOD, "lacks COD"},
+		{"PPM", withMarker([]byte{0xFF, 0x60, 0, 3, 0}), "PPM/PPT"},
+		{"bad precision", func() []byte {
+			b := append([]byte(nil), code...)
+			b[42] = 0x7F
+			return b
+		}(), "precision"},
+		{"JP2 without codestream", jp2Box("jP  ", jp2Signature), "no contiguous codestream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeJPX(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// jpxTestParams describes a codestream for encodeTestJ2K: one tile grid
+// anchored at the origin, no subsampling, one quantization for every
+// component.
+type jpxTestParams struct {
+	w, h, x0, y0 int
+	tw, th       int // tile size; 0 means a single tile
+	comps, prec  int
+	levels       int
+	cbw, cbh     int   // code-block size exponents
+	precincts    []int // PPx | PPy<<4 per resolution level; nil for maximal
+	reversible   bool
+	mct          bool
+	layers       int
+	order        int
+	style        int
+	sop, eph     bool
+}
+
+// jpxTestStep is the quantization exponent the test encoder gives the
+// subband of orientation o of a prec-bit component; lossy coding uses
+// mantissa 0, a step of half a unit.
+func jpxTestStep(prec, o int) int {
+	return prec + [4]int{0, 1, 1, 2}[o] + 1
+}
+
+// encodeTestJ2K codes samples (per component, w*h values of p.prec
+// unsigned bits) as a JPEG 2000 codestream: losslessly with the 5/3
+// wavelet, or with the 9/7 wavelet and a fine quantization step.
+func encodeTestJ2K(t *testing.T, p jpxTestParams, samples [][]int) []byte {
+	t.Helper()
+	x1, y1 := p.x0+p.w, p.y0+p.h
+	if p.tw == 0 {
+		p.tw, p.th = x1, y1
+	}
+	if p.layers == 0 {
+		p.layers = 1
+	}
+	var out []byte
+	be16 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+	be32 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint32(b, uint32(v)) }
+	marker := func(m int, body []byte) {
+		out = be16(out, m)
+		out = be16(out, len(body)+2)
+		out = append(out, body...)
+	}
+	out = be16(out, 0xFF4F)
+
+	siz := be16(nil, 0)
+	for _, v := range []int{x1, y1, p.x0, p.y0, p.tw, p.th, 0, 0} {
+		siz = be32(siz, v)
+	}
+	siz = be16(siz, p.comps)
+	for range p.comps {
+		siz = append(siz, byte(p.prec-1), 1, 1)
+	}
+	marker(0xFF51, siz)
+
+	scod := 0
+	if p.precincts != nil {
+		scod |= 1
+	}
+	if p.sop {
+		scod |= 2
+	}
+	if p.eph {
+		scod |= 4
+	}
+	mct, transform := 0, 0
+	if p.mct {
+		mct = 1
+	}
+	if p.reversible {
+		transform = 1
+	}
+	cod := []byte{byte(scod), byte(p.order)}
+	cod = be16(cod, p.layers)
+	cod = append(cod, byte(mct), byte(p.levels), byte(p.cbw-2), byte(p.cbh-2), byte(p.style), byte(transform))
+	for _, pp := range p.precincts {
+		cod = append(cod, byte(pp))
+	}
+	marker(0xFF52, cod)
+
+	coding := jpxCoding{levels: p.levels, cbw: p.cbw, cbh: p.cbh, style: p.style, reversible: p.reversible}
+	quant := jpxQuant{guard: 2}
+	qcd := []byte{2 << 5}
+	if !p.reversible {
+		quant.style, qcd[0] = 2, 2<<5|2
+	}
+	for r := 0; r <= p.levels; r++ {
+		coding.precincts = append(coding.precincts, 0xFF)
+		if p.precincts != nil {
+			coding.precincts[r] = p.precincts[r]
+		}
+		orients := []int{jpxLL}
+		if r > 0 {
+			orients = []int{jpxHL, jpxLH, jpxHH}
+		}
+		for _, o := range orients {
+			exp := jpxTestStep(p.prec, o)
+			quant.steps = append(quant.steps, [2]int{exp, 0})
+			if p.reversible {
+				qcd = append(qcd, byte(exp<<3))
+			} else {
+				qcd = be16(qcd, exp<<11)
+			}
+		}
+	}
+	marker(0xFF5C, qcd)
+
+	cs := &jpxCodestream{}
+	for range p.comps {
+		cs.comps = append(cs.comps, jpxComponent{prec: p.prec, dx: 1, dy: 1})
+	}
+	ntx, nty := ceilDiv(x1, p.tw), ceilDiv(y1, p.th)
+	for ty := range nty {
+		for tx := range ntx {
+			tx0, ty0 := max(tx*p.tw, p.x0), max(ty*p.th, p.y0)
+			tx1, ty1 := min((tx+1)*p.tw, x1), min((ty+1)*p.th, y1)
+			body := encodeTestTile(t, p, cs, coding, quant, samples, tx0, ty0, tx1, ty1)
+			out = be16(out, 0xFF90)
+			out = be16(out, 10)
+			out = be16(out, ty*ntx+tx)
+			out = be32(out, 14+len(body))
+			out = append(out, 0, 1)
+			out = be16(out, 0xFF93)
+			out = append(out, body...)
+		}
+	}
+	return be16(out, 0xFFD9)
+}
+
+// jpxTestBlock is a code-block's coded passes, grouped into segments,
+// and the layer each segment goes to.
+type jpxTestBlock struct {
+	segs       []jpxSegment
+	layers     []int
+	zeroPlanes int
+	included   bool
+}
+
+// encodeTestTile codes one tile: component transform, forward wavelet,
+// quantization, tier-1 and then packets in the progression order.
+func encodeTestTile(t *testing.T, p jpxTestParams, cs *jpxCodestream, coding jpxCoding, quant jpxQuant, samples [][]int, tx0, ty0, tx1, ty1 int) []byte {
+	t.Helper()
+	w, h := tx1-tx0, ty1-ty0
+	planes := make([][]float64, p.comps)
+	for c := range planes {
+		planes[c] = make([]float64, w*h)
+		for y := range h {
+			for x := range w {
+				planes[c][y*w+x] = float64(samples[c][(ty0+y-p.y0)*p.w+tx0+x-p.x0] - 1<<(p.prec-1))
+			}
+		}
+	}
+	if p.mct {
+		for i := range planes[0] {
+			r, g, b := planes[0][i], planes[1][i], planes[2][i]
+			if p.reversible {
+				planes[0][i], planes[1][i], planes[2][i] = math.Floor((r+2*g+b)/4), b-g, r-g
+			} else {
+				planes[0][i] = 0.299*r + 0.587*g + 0.114*b
+				planes[1][i] = -0.16875*r - 0.33126*g + 0.5*b
+				planes[2][i] = 0.5*r - 0.41869*g - 0.08131*b
+			}
+		}
+	}
+
+	tcs := make([]*jpxTileComp, p.comps)
+	blocks := map[*jpxCodeBlock]*jpxTestBlock{}
+	for c := range tcs {
+		tc, err := newJPXTileComp(cs.comps[c], coding, quant, 0, tx0, ty0, tx1, ty1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tcs[c] = tc
+		bands := analyzeTest(planes[c], tc, p.reversible)
+		for r, res := range tc.res {
+			for i, b := range res.bands {
+				coeffs := bands[r][i]
+				bw := b.x1 - b.x0
+				if len(coeffs) != bw*(b.y1-b.y0) {
+					t.Fatalf("band %d/%d: %d coefficients, layout has %dx%d", r, i, len(coeffs), bw, b.y1-b.y0)
+				}
+				for _, prc := range b.precincts {
+					for j := range prc.blocks {
+						cb := &prc.blocks[j]
+						cw, ch := cb.x1-cb.x0, cb.y1-cb.y0
+						q := make([]int, cw*ch)
+						for y := range ch {
+							for x := range cw {
+								v := coeffs[(cb.y0-b.y0+y)*bw+cb.x0-b.x0+x]
+								if p.reversible {
+									q[y*cw+x] = int(v)
+								} else {
+									q[y*cw+x] = int(math.Copysign(math.Floor(math.Abs(v)/b.step), v))
+								}
+							}
+						}
+						tb := encodeTestCodeBlock(t, q, cw, ch, b.planes, b.orient, p.style)
+						for i := range tb.segs {
+							tb.layers = append(tb.layers, i*p.layers/len(tb.segs))
+						}
+						blocks[cb] = tb
+					}
+				}
+			}
+		}
+	}
+
+	var out []byte
+	packets := 0
+	state := map[*jpxPrecinct]*jpxTestPrecinct{}
+	err := cs.eachPacket(tcs, jpxPOC{layers: p.layers, r1: 33, c1: p.comps, order: p.order}, tx0, ty0, func(l, r, c, k int) error {
+		if p.sop {
+			out = append(out, 0xFF, 0x91, 0, 4, byte(packets>>8), byte(packets))
+		}
+		packets++
+		hdr, body := encodeTestPacket(tcs[c].res[r], k, l, blocks, state)
+		out = append(out, hdr...)
+		if p.eph {
+			out = append(out, 0xFF, 0x92)
+		}
+		out = append(out, body...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// analyzeTest applies the forward wavelet transform to a tile-component's
+// samples, returning each resolution level's subband coefficients in the
+// order of tc's bands.
+func analyzeTest(samples []float64, tc *jpxTileComp, reversible bool) [][][]float64 {
+	bands := make([][][]float64, len(tc.res))
+	cur := samples
+	for r := len(tc.res) - 1; r > 0; r-- {
+		res := tc.res[r]
+		u0, v0 := res.x0, res.y0
+		w, h := res.x1-u0, res.y1-v0
+		line := make([]float64, max(w, h)+2*jpxPad)
+		if w > 0 && h > 0 {
+			for x := range w {
+				for y := range h {
+					line[jpxPad+y] = cur[y*w+x]
+				}
+				analyze1DTest(line[:h+2*jpxPad], v0, reversible)
+				for y := range h {
+					cur[y*w+x] = line[jpxPad+y]
+				}
+			}
+			for y := range h {
+				copy(line[jpxPad:], cur[y*w:(y+1)*w])
+				analyze1DTest(line[:w+2*jpxPad], u0, reversible)
+				copy(cur[y*w:(y+1)*w], line[jpxPad:jpxPad+w])
+			}
+		}
+		split := func(xOdd, yOdd int) []float64 {
+			var b []float64
+			for y := range h {
+				if (v0+y)&1 != yOdd {
+					continue
+				}
+				for x := range w {
+					if (u0+x)&1 == xOdd {
+						b = append(b, cur[y*w+x])
+					}
+				}
+			}
+			return b
+		}
+		bands[r] = [][]float64{split(1, 0), split(0, 1), split(1, 1)}
+		cur = split(0, 0)
+	}
+	bands[0] = [][]float64{cur}
+	return bands
+}
+
+// analyze1DTest is the forward 1D_SD, the inverse of synth1D.
+func analyze1DTest(line []float64, i0 int, reversible bool) {
+	n := len(line) - 2*jpxPad
+	if n == 1 {
+		if i0&1 == 1 {
+			line[jpxPad] *= 2
+		}
+		return
+	}
+	period := 2 * (n - 1)
+	for k := 1; k <= jpxPad; k++ {
+		l, r := (-k%period+period)%period, (n-1+k)%period
+		if l >= n {
+			l = period - l
+		}
+		if r >= n {
+			r = period - r
+		}
+		line[jpxPad-k], line[jpxPad+n-1+k] = line[jpxPad+l], line[jpxPad+r]
+	}
+	even := func(k int) bool { return (i0+k)&1 == 0 }
+	last := len(line) - 1
+	lift := func(onEven bool, f func(a, b float64) float64) {
+		for k := 1; k < last; k++ {
+			if even(k) == onEven {
+				line[k] += f(line[k-1], line[k+1])
+			}
+		}
+	}
+	if reversible {
+		lift(false, func(a, b float64) float64 { return -math.Floor((a + b) / 2) })
+		lift(true, func(a, b float64) float64 { return math.Floor((a + b + 2) / 4) })
+		return
+	}
+	for _, s := range []struct {
+		even bool
+		c    float64
+	}{{false, jpxAlpha}, {true, jpxBeta}, {false, jpxGamma}, {true, jpxDelta}} {
+		lift(s.even, func(a, b float64) float64 { return s.c * (a + b) })
+	}
+	for k := range line {
+		if even(k) {
+			line[k] /= jpxK
+		} else {
+			line[k] *= jpxK
+		}
+	}
+}
+
+// jpxTestCoder emits tier-1 decisions arithmetic-coded or, in bypassed
+// passes, raw.
+type jpxTestCoder struct {
+	mq      *mqEncoder
+	raw     []byte
+	c       byte
+	ct      int
+	isRaw   bool
+	started bool
+}
+
+func (e *jpxTestCoder) bit(cx []byte, i, b int) {
+	if !e.isRaw {
+		e.mq.encode(cx, i, b)
+		return
+	}
+	if e.ct == 0 {
+		e.c, e.ct = 0, 8
+		if len(e.raw) > 0 && e.raw[len(e.raw)-1] == 0xFF {
+			e.ct = 7
+		}
+	}
+	e.ct--
+	e.c |= byte(b) << e.ct
+	if e.ct == 0 {
+		e.raw = append(e.raw, e.c)
+	}
+}
+
+func (e *jpxTestCoder) finish() []byte {
+	if e.isRaw {
+		if e.ct > 0 {
+			e.raw = append(e.raw, e.c)
+		}
+		return e.raw
+	}
+	data := e.mq.flush()
+	return data[:len(data)-2]
+}
+
+// encodeTestCodeBlock is tier-1 encoding mirroring decodeCodeBlock: q
+// holds the quantized coefficients of a w x h code-block with planes
+// magnitude bit-planes.
+func encodeTestCodeBlock(t *testing.T, q []int, w, h, planes, orient, style int) *jpxTestBlock {
+	t.Helper()
+	maxMag := 0
+	for _, v := range q {
+		maxMag = max(maxMag, abs(v))
+	}
+	n := bits.Len(uint(maxMag))
+	if n > planes {
+		t.Fatalf("coefficient %d needs %d bit-planes, band has %d", maxMag, n, planes)
+	}
+	tb := &jpxTestBlock{zeroPlanes: planes - n}
+	if n == 0 {
+		return tb
+	}
+	s := &t1Decoder{w: w, h: h, stride: w + 2, flags: make([]uint8, (w+2)*(h+2)), orient: orient, vcausal: style&jpxStyleVCausal != 0}
+	s.resetContexts()
+	var e *jpxTestCoder
+	at := func(x, y int) *uint8 { return &s.flags[(y+1)*s.stride+x+1] }
+	bitOf := func(x, y, p int) int { return abs(q[y*w+x]) >> p & 1 }
+	sign := func(x, y int) {
+		neg := 0
+		if q[y*w+x] < 0 {
+			neg = 1
+			*at(x, y) |= t1Neg
+		}
+		*at(x, y) |= t1Sig
+		if e.isRaw {
+			e.bit(nil, 0, neg)
+			return
+		}
+		cx, xor := s.signContext(x, y)
+		e.bit(s.cx[:], cx, neg^xor)
+	}
+
+	for pass := 0; pass < 3*n-2; pass++ {
+		kind, p := (pass+2)%3, n-1-(pass+2)/3
+		if len(tb.segs) == 0 || tb.segs[len(tb.segs)-1].passes == tb.segs[len(tb.segs)-1].maxPasses {
+			if e != nil {
+				tb.segs[len(tb.segs)-1].data = e.finish()
+			}
+			tb.segs = append(tb.segs, jpxSegment{maxPasses: nextSegmentPasses(style, tb.segs)})
+			e = &jpxTestCoder{mq: &mqEncoder{}, isRaw: style&jpxStyleBypass != 0 && pass >= 10 && kind != 2}
+		}
+		tb.segs[len(tb.segs)-1].passes++
+		for y0 := 0; y0 < h; y0 += 4 {
+			for x := range w {
+				y := y0
+				if kind == 2 && y0+4 <= h && s.runLengthColumn(x, y0) {
+					first := -1
+					for r := range 4 {
+						if bitOf(x, y0+r, p) == 1 {
+							first = r
+							break
+						}
+					}
+					if first < 0 {
+						e.bit(s.cx[:], t1CtxRL, 0)
+						continue
+					}
+					e.bit(s.cx[:], t1CtxRL, 1)
+					e.bit(s.cx[:], t1CtxUni, first>>1)
+					e.bit(s.cx[:], t1CtxUni, first&1)
+					sign(x, y0+first)
+					y = y0 + first + 1
+				}
+				for ; y < min(y0+4, h); y++ {
+					f := at(x, y)
+					hn, vn, dn := s.neighbours(x, y)
+					switch {
+					case kind == 0 && *f&t1Sig == 0 && hn+vn+dn > 0:
+						*f |= t1Visit
+						fallthrough
+					case kind == 2 && *f&(t1Sig|t1Visit) == 0:
+						b := bitOf(x, y, p)
+						e.bit(s.cx[:], s.zcContext(hn, vn, dn), b)
+						if b == 1 {
+							sign(x, y)
+						}
+					case kind == 1 && *f&(t1Sig|t1Visit) == t1Sig:
+						cx := t1CtxMR + 2
+						if *f&t1Refined == 0 {
+							cx = t1CtxMR
+							if hn+vn+dn > 0 {
+								cx++
+							}
+						}
+						e.bit(s.cx[:], cx, bitOf(x, y, p))
+						*f |= t1Refined
+					}
+				}
+			}
+		}
+		if kind == 2 {
+			for i := range s.flags {
+				s.flags[i] &^= t1Visit
+			}
+			if style&jpxStyleSegSym != 0 {
+				for _, b := range []int{1, 0, 1, 0} {
+					e.bit(s.cx[:], t1CtxUni, b)
+				}
+			}
+		}
+		if style&jpxStyleReset != 0 {
+			s.resetContexts()
+		}
+	}
+	tb.segs[len(tb.segs)-1].data = e.finish()
+	return tb
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// jpxTestBits writes packet header bits with the stuffing jpxBitReader
+// undoes.
+type jpxTestBits struct {
+	out []byte
+	c   byte
+	ct  int
+}
+
+func (w *jpxTestBits) bit(b int) {
+	if w.ct == 0 {
+		w.c, w.ct = 0, 8
+		if len(w.out) > 0 && w.out[len(w.out)-1] == 0xFF {
+			w.ct = 7
+		}
+	}
+	w.ct--
+	w.c |= byte(b) << w.ct
+	if w.ct == 0 {
+		w.out = append(w.out, w.c)
+	}
+}
+
+func (w *jpxTestBits) bits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(v >> i & 1)
+	}
+}
+
+func (w *jpxTestBits) finish() []byte {
+	if w.ct > 0 {
+		w.out = append(w.out, w.c)
+	}
+	if len(w.out) > 0 && w.out[len(w.out)-1] == 0xFF {
+		w.out = append(w.out, 0)
+	}
+	return w.out
+}
+
+// jpxTestTagTree encodes a tag tree the way jpxTagTree decodes it.
+type jpxTestTagTree struct {
+	tree  *jpxTagTree
+	value []int
+	known []bool
+}
+
+func newJPXTestTagTree(w, h int, leaves []int) *jpxTestTagTree {
+	tt := &jpxTestTagTree{tree: newJPXTagTree(w, h)}
+	tt.value = make([]int, len(tt.tree.nodes))
+	tt.known = make([]bool, len(tt.tree.nodes))
+	for i := range tt.value {
+		tt.value[i] = math.MaxInt32
+	}
+	for i, v := range leaves {
+		for n := i; n >= 0; n = tt.tree.nodes[n].parent {
+			tt.value[n] = min(tt.value[n], v)
+		}
+	}
+	return tt
+}
+
+func (tt *jpxTestTagTree) encode(w *jpxTestBits, leaf, threshold int) {
+	var stack []int
+	for n := leaf; n >= 0; n = tt.tree.nodes[n].parent {
+		stack = append(stack, n)
+	}
+	low := 0
+	for i := len(stack) - 1; i >= 0; i-- {
+		n := stack[i]
+		node := &tt.tree.nodes[n]
+		if low > node.low {
+			node.low = low
+		} else {
+			low = node.low
+		}
+		for low < threshold {
+			if low >= tt.value[n] {
+				if !tt.known[n] {
+					w.bit(1)
+					tt.known[n] = true
+				}
+				break
+			}
+			w.bit(0)
+			low++
+		}
+		node.low = low
+	}
+}
+
+// encodeTestPacket codes the packet of layer l for precinct k of res.
+func encodeTestPacket(res *jpxRes, k, l int, blocks map[*jpxCodeBlock]*jpxTestBlock, state map[*jpxPrecinct]*jpxTestPrecinct) (hdr, body []byte) {
+	w := &jpxTestBits{}
+	empty := true
+	for _, b := range res.bands {
+		for j := range b.precincts[k].blocks {
+			tb := blocks[&b.precincts[k].blocks[j]]
+			for _, sl := range tb.layers {
+				empty = empty && sl != l
+			}
+		}
+	}
+	if empty {
+		w.bit(0)
+		return w.finish(), nil
+	}
+	w.bit(1)
+	for _, b := range res.bands {
+		prc := &b.precincts[k]
+		st := state[prc]
+		if st == nil {
+			st = newJPXTestPrecinct(prc, blocks)
+			state[prc] = st
+		}
+		for j := range prc.blocks {
+			tb := blocks[&prc.blocks[j]]
+			var segs []jpxSegment
+			for i, sl := range tb.layers {
+				if sl == l {
+					segs = append(segs, tb.segs[i])
+				}
+			}
+			if !tb.included {
+				st.incl.encode(w, j, l+1)
+			} else {
+				w.bit(min(len(segs), 1))
+			}
+			if len(segs) == 0 {
+				continue
+			}
+			if !tb.included {
+				st.zbp.encode(w, j, 1<<20)
+				tb.included = true
+			}
+			n := 0
+			for _, s := range segs {
+				n += s.passes
+			}
+			switch {
+			case n == 1:
+				w.bit(0)
+			case n == 2:
+				w.bits(2, 2)
+			case n <= 5:
+				w.bits(0xC|(n-3), 4)
+			case n <= 36:
+				w.bits(0x1E0|(n-6), 9)
+			default:
+				w.bits(0xFF80|(n-37), 16)
+			}
+			lblock := st.lblock[j]
+			need := lblock
+			for _, s := range segs {
+				for len(s.data) >= 1<<(need+bits.Len(uint(s.passes))-1) {
+					need++
+				}
+			}
+			for range need - lblock {
+				w.bit(1)
+			}
+			w.bit(0)
+			st.lblock[j] = need
+			for _, s := range segs {
+				w.bits(len(s.data), need+bits.Len(uint(s.passes))-1)
+				body = append(body, s.data...)
+			}
+		}
+	}
+	return w.finish(), body
+}
+
+// jpxTestPrecinct is the encoder's tier-2 state for one precinct.
+type jpxTestPrecinct struct {
+	incl, zbp *jpxTestTagTree
+	lblock    []int
+}
+
+func newJPXTestPrecinct(prc *jpxPrecinct, blocks map[*jpxCodeBlock]*jpxTestBlock) *jpxTestPrecinct {
+	n := len(prc.blocks)
+	st := &jpxTestPrecinct{lblock: make([]int, n)}
+	if n == 0 {
+		return st
+	}
+	first, zbp := make([]int, n), make([]int, n)
+	for j := range prc.blocks {
+		tb := blocks[&prc.blocks[j]]
+		first[j], zbp[j], st.lblock[j] = 1<<20, tb.zeroPlanes, 3
+		if len(tb.layers) > 0 {
+			first[j] = tb.layers[0]
+		}
+	}
+	cw := 0
+	for cw < n && prc.blocks[cw].y0 == prc.blocks[0].y0 {
+		cw++
+	}
+	st.incl = newJPXTestTagTree(cw, n/cw, first)
+	st.zbp = newJPXTestTagTree(cw, n/cw, zbp)
+	return st
+}
@@ -0,0 +1,373 @@
+package pdf
+
+// This file holds tier-1 of the JPEG 2000 decoder (ISO/IEC 15444-1 Annex
+// D): the bit-plane coding passes that turn one code-block's coded data
+// back into quantized coefficients. The arithmetic-coded passes use the
+// MQ decoder shared with JBIG2.
+
+// Tier-1 contexts (Tables D.1-D.4, D.7): zero coding 0-8, sign coding
+// 9-13, magnitude refinement 14-16, run-length and uniform.
+const (
+	t1CtxSC  = 9
+	t1CtxMR  = 14
+	t1CtxRL  = 17
+	t1CtxUni = 18
+	t1NumCtx = 19
+)
+
+// Code-block style flags (Table A.19).
+const (
+	jpxStyleBypass = 1 << iota
+	jpxStyleReset
+	jpxStyleTermAll
+	jpxStyleVCausal
+	jpxStylePredTerm
+	jpxStyleSegSym
+)
+
+// Subband orientations.
+const (
+	jpxLL = iota
+	jpxHL
+	jpxLH
+	jpxHH
+)
+
+// Per-coefficient state flags.
+const (
+	t1Sig = 1 << iota
+	t1Neg
+	t1Visit
+	t1Refined
+)
+
+// jpxSegment is one codeword segment of a code-block: the data of a run
+// of coding passes terminated together.
+type jpxSegment struct {
+	data      []byte
+	passes    int
+	maxPasses int
+}
+
+// t1Decoder decodes one code-block. flags has a one-coefficient border so
+// neighbourhoods need no bounds checks; mag holds each coefficient's
+// magnitude in half units, so the midpoint of the interval the decoded
+// bits leave open is representable.
+type t1Decoder struct {
+	w, h    int
+	stride  int
+	flags   []uint8
+	mag     []int32
+	orient  int
+	vcausal bool
+	cx      [t1NumCtx]byte
+	mq      *mqDecoder
+	raw     *jpxRawReader
+}
+
+func (t *t1Decoder) resetContexts() {
+	t.cx = [t1NumCtx]byte{}
+	t.cx[0] = 4 << 1
+	t.cx[t1CtxRL] = 3 << 1
+	t.cx[t1CtxUni] = 46 << 1
+}
+
+// bit decodes a decision in context cx, or reads a raw bit in a bypassed
+// pass.
+func (t *t1Decoder) bit(cx int) int {
+	if t.raw != nil {
+		return t.raw.bit()
+	}
+	return t.mq.decode(t.cx[:], cx)
+}
+
+// neighbours counts the significant horizontal, vertical and diagonal
+// neighbours of (x, y). In vertically causal mode the stripe below does
+// not count.
+func (t *t1Decoder) neighbours(x, y int) (h, v, d int) {
+	f, s := t.flags, t.stride
+	i := (y+1)*s + x + 1
+	h = int(f[i-1]&t1Sig + f[i+1]&t1Sig)
+	v = int(f[i-s] & t1Sig)
+	d = int(f[i-s-1]&t1Sig + f[i-s+1]&t1Sig)
+	if !t.vcausal || y%4 != 3 {
+		v += int(f[i+s] & t1Sig)
+		d += int(f[i+s-1]&t1Sig + f[i+s+1]&t1Sig)
+	}
+	return h, v, d
+}
+
+// zcContext is the zero coding context (Table D.1).
+func (t *t1Decoder) zcContext(h, v, d int) int {
+	switch t.orient {
+	case jpxHH:
+		hv := h + v
+		switch {
+		case d >= 3:
+			return 8
+		case d == 2:
+			return 6 + min(hv, 1)
+		case d == 1:
+			return 3 + min(hv, 2)
+		default:
+			return min(hv, 2)
+		}
+	case jpxHL:
+		h, v = v, h
+	}
+	switch {
+	case h == 2:
+		return 8
+	case h == 1 && v > 0:
+		return 7
+	case h == 1 && d > 0:
+		return 6
+	case h == 1:
+		return 5
+	case v == 2:
+		return 4
+	case v == 1:
+		return 3
+	default:
+		return min(d, 2)
+	}
+}
+
+// signContribution is +1, -1 or 0 for a significant positive, significant
+// negative or insignificant neighbour.
+func signContribution(f uint8) int {
+	if f&t1Sig == 0 {
+		return 0
+	}
+	if f&t1Neg != 0 {
+		return -1
+	}
+	return 1
+}
+
+// signContext returns the sign coding context of (x, y) and the bit the
+// decoded decision is XORed with (Tables D.2, D.3).
+func (t *t1Decoder) signContext(x, y int) (cx, xor int) {
+	f, s := t.flags, t.stride
+	i := (y+1)*s + x + 1
+	h := max(-1, min(1, signContribution(f[i-1])+signContribution(f[i+1])))
+	below := 0
+	if !t.vcausal || y%4 != 3 {
+		below = signContribution(f[i+s])
+	}
+	v := max(-1, min(1, signContribution(f[i-s])+below))
+	if h < 0 || h == 0 && v < 0 {
+		h, v, xor = -h, -v, 1
+	}
+	switch {
+	case h == 0 && v == 0:
+		return t1CtxSC, xor
+	case h == 0:
+		return t1CtxSC + 1, xor
+	default:
+		return t1CtxSC + 3 + v, xor
+	}
+}
+
+// decodeSign decodes the sign of (x, y), reporting whether it is
+// negative.
+func (t *t1Decoder) decodeSign(x, y int) bool {
+	if t.raw != nil {
+		return t.raw.bit() == 1
+	}
+	cx, xor := t.signContext(x, y)
+	return t.mq.decode(t.cx[:], cx)^xor == 1
+}
+
+// setSignificant makes (x, y) significant at bit-plane p.
+func (t *t1Decoder) setSignificant(x, y, p int) {
+	i := (y+1)*t.stride + x + 1
+	t.flags[i] |= t1Sig
+	if t.decodeSign(x, y) {
+		t.flags[i] |= t1Neg
+	}
+	t.mag[y*t.w+x] = 3 << p
+}
+
+// significancePass is the significance propagation pass (D.3.1).
+func (t *t1Decoder) significancePass(p int) {
+	for y0 := 0; y0 < t.h; y0 += 4 {
+		for x := range t.w {
+			for y := y0; y < min(y0+4, t.h); y++ {
+				i := (y+1)*t.stride + x + 1
+				if t.flags[i]&t1Sig != 0 {
+					continue
+				}
+				h, v, d := t.neighbours(x, y)
+				if h+v+d == 0 {
+					continue
+				}
+				t.flags[i] |= t1Visit
+				if t.bit(t.zcContext(h, v, d)) == 1 {
+					t.setSignificant(x, y, p)
+				}
+			}
+		}
+	}
+}
+
+// refinementPass is the magnitude refinement pass (D.3.3).
+func (t *t1Decoder) refinementPass(p int) {
+	for y0 := 0; y0 < t.h; y0 += 4 {
+		for x := range t.w {
+			for y := y0; y < min(y0+4, t.h); y++ {
+				i := (y+1)*t.stride + x + 1
+				if t.flags[i]&(t1Sig|t1Visit) != t1Sig {
+					continue
+				}
+				cx := t1CtxMR + 2
+				if t.flags[i]&t1Refined == 0 {
+					cx = t1CtxMR
+					if h, v, d := t.neighbours(x, y); h+v+d > 0 {
+						cx++
+					}
+				}
+				if t.bit(cx) == 1 {
+					t.mag[y*t.w+x] += 1 << p
+				} else {
+					t.mag[y*t.w+x] -= 1 << p
+				}
+				t.flags[i] |= t1Refined
+			}
+		}
+	}
+}
+
+// cleanupPass is the cleanup pass (D.3.4), with run-length coding of
+// columns of four insignificant coefficients with insignificant
+// neighbourhoods.
+func (t *t1Decoder) cleanupPass(p int, segSym bool) {
+	for y0 := 0; y0 < t.h; y0 += 4 {
+		for x := range t.w {
+			y := y0
+			if y0+4 <= t.h && t.runLengthColumn(x, y0) {
+				if t.bit(t1CtxRL) == 0 {
+					continue
+				}
+				y += t.bit(t1CtxUni) << 1
+				y += t.bit(t1CtxUni)
+				t.setSignificant(x, y, p)
+				y++
+			}
+			for ; y < min(y0+4, t.h); y++ {
+				i := (y+1)*t.stride + x + 1
+				if t.flags[i]&(t1Sig|t1Visit) != 0 {
+					continue
+				}
+				h, v, d := t.neighbours(x, y)
+				if t.bit(t.zcContext(h, v, d)) == 1 {
+					t.setSignificant(x, y, p)
+				}
+			}
+		}
+	}
+	for i := range t.flags {
+		t.flags[i] &^= t1Visit
+	}
+	if segSym {
+		for range 4 {
+			t.bit(t1CtxUni)
+		}
+	}
+}
+
+// runLengthColumn reports whether the four coefficients of column x from
+// row y0 are coded in run-length mode.
+func (t *t1Decoder) runLengthColumn(x, y0 int) bool {
+	for y := y0; y < y0+4; y++ {
+		if t.flags[(y+1)*t.stride+x+1]&(t1Sig|t1Visit) != 0 {
+			return false
+		}
+		if h, v, d := t.neighbours(x, y); h+v+d != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeCodeBlock runs a code-block's coding passes over its segments,
+// the first pass a cleanup pass in bit-plane planes-1-zeroPlanes (D.2),
+// and returns its coefficients: magnitudes in half units, negated for
+// negative coefficients.
+func decodeCodeBlock(w, h int, segs []jpxSegment, zeroPlanes, planes, orient, style int) []int32 {
+	t := &t1Decoder{
+		w: w, h: h, stride: w + 2,
+		flags:   make([]uint8, (w+2)*(h+2)),
+		mag:     make([]int32, w*h),
+		orient:  orient,
+		vcausal: style&jpxStyleVCausal != 0,
+	}
+	t.resetContexts()
+	start := planes - 1 - zeroPlanes
+	pass := 0
+	for _, seg := range segs {
+		t.mq, t.raw = nil, nil
+		for range seg.passes {
+			kind := (pass + 2) % 3
+			p := start - (pass+2)/3
+			if p < 0 {
+				break
+			}
+			bypass := style&jpxStyleBypass != 0 && pass >= 10 && kind != 2
+			switch {
+			case bypass && t.raw == nil:
+				t.raw = &jpxRawReader{data: seg.data}
+			case !bypass && t.mq == nil:
+				t.mq = newMQDecoder(seg.data)
+			}
+			if !bypass {
+				t.raw = nil
+			}
+			switch kind {
+			case 0:
+				t.significancePass(p)
+			case 1:
+				t.refinementPass(p)
+			default:
+				t.cleanupPass(p, style&jpxStyleSegSym != 0)
+			}
+			if style&jpxStyleReset != 0 {
+				t.resetContexts()
+			}
+			pass++
+		}
+	}
+	for y := range h {
+		for x := range w {
+			if t.flags[(y+1)*t.stride+x+1]&t1Neg != 0 {
+				t.mag[y*w+x] = -t.mag[y*w+x]
+			}
+		}
+	}
+	return t.mag
+}
+
+// jpxRawReader reads the raw bits of a bypassed coding pass (D.6): after
+// a 0xFF byte the next byte's most significant bit is a stuffed 0.
+type jpxRawReader struct {
+	data []byte
+	pos  int
+	c    byte
+	ct   int
+}
+
+func (r *jpxRawReader) bit() int {
+	if r.ct == 0 {
+		prev := r.c
+		r.c, r.ct = 0xFF, 8
+		if r.pos < len(r.data) {
+			r.c = r.data[r.pos]
+			r.pos++
+		}
+		if prev == 0xFF {
+			r.ct = 7
+		}
+	}
+	r.ct--
+	return int(r.c >> r.ct & 1)
+}
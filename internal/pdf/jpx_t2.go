@@ -0,0 +1,341 @@
+package pdf
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// This file holds tier-2 of the JPEG 2000 decoder (ISO/IEC 15444-1 Annex
+// B): the progression orders and the packet headers that hand each
+// code-block its coding passes.
+
+// eachPacket calls fn for each packet of progression volume v in its
+// progression order (B.12). The position-driven orders visit precincts by
+// the reference grid position of their top-left corner.
+func (cs *jpxCodestream) eachPacket(tcs []*jpxTileComp, v jpxPOC, tx0, ty0 int, fn func(l, r, c, k int) error) error {
+	resIn := func(c, r int) *jpxRes {
+		if r < v.r0 || r >= v.r1 || r >= len(tcs[c].res) {
+			return nil
+		}
+		return tcs[c].res[r]
+	}
+	maxRes := 0
+	for _, tc := range tcs {
+		maxRes = max(maxRes, len(tc.res))
+	}
+	layer := func(l, r int) error {
+		for c := v.c0; c < v.c1; c++ {
+			res := resIn(c, r)
+			if res == nil {
+				continue
+			}
+			for k := range res.pw * res.ph {
+				if err := fn(l, r, c, k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	switch v.order {
+	case jpxLRCP:
+		for l := range v.layers {
+			for r := v.r0; r < min(v.r1, maxRes); r++ {
+				if err := layer(l, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case jpxRLCP:
+		for r := v.r0; r < min(v.r1, maxRes); r++ {
+			for l := range v.layers {
+				if err := layer(l, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	type entry struct{ c, r, k, x, y int }
+	var entries []entry
+	for c := v.c0; c < v.c1; c++ {
+		nl := tcs[c].coding.levels
+		for r := v.r0; r < v.r1; r++ {
+			res := resIn(c, r)
+			if res == nil {
+				continue
+			}
+			for k := range res.pw * res.ph {
+				x := (res.x0>>res.ppx + k%res.pw) << (res.ppx + nl - r) * cs.comps[c].dx
+				y := (res.y0>>res.ppy + k/res.pw) << (res.ppy + nl - r) * cs.comps[c].dy
+				entries = append(entries, entry{c, r, k, max(x, tx0), max(y, ty0)})
+			}
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		var ka, kb [4]int
+		switch v.order {
+		case jpxRPCL:
+			ka, kb = [4]int{a.r, a.y, a.x, a.c}, [4]int{b.r, b.y, b.x, b.c}
+		case jpxPCRL:
+			ka, kb = [4]int{a.y, a.x, a.c, a.r}, [4]int{b.y, b.x, b.c, b.r}
+		default:
+			ka, kb = [4]int{a.c, a.y, a.x, a.r}, [4]int{b.c, b.y, b.x, b.r}
+		}
+		return slices.Compare(ka[:], kb[:])
+	})
+	for _, e := range entries {
+		for l := range v.layers {
+			if err := fn(l, e.r, e.c, e.k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jpxPacketReader reads the packets of one tile's data in order.
+type jpxPacketReader struct {
+	data     []byte
+	pos      int
+	sop, eph bool
+}
+
+// jpxContribution is a code-block's share of a packet body.
+type jpxContribution struct {
+	cb     *jpxCodeBlock
+	seg    int
+	length int
+}
+
+// packet reads the packet of layer l for precinct k of res (B.9, B.10):
+// the header naming each code-block's new coding passes and their data
+// lengths, then the body those lengths partition.
+func (d *jpxPacketReader) packet(res *jpxRes, k, l, style int) error {
+	if d.pos >= len(d.data) {
+		return errJPXEndOfData
+	}
+	if d.sop && d.pos+6 <= len(d.data) && d.data[d.pos] == 0xFF && d.data[d.pos+1] == 0x91 {
+		d.pos += 6
+	}
+	br := &jpxBitReader{data: d.data, pos: d.pos}
+	var contribs []jpxContribution
+	if br.bit() == 1 {
+		for _, b := range res.bands {
+			prc := &b.precincts[k]
+			for j := range prc.blocks {
+				cb := &prc.blocks[j]
+				var included bool
+				if cb.included {
+					included = br.bit() == 1
+				} else {
+					included = prc.incl.decode(br, j, l+1)
+				}
+				if !included || br.err {
+					continue
+				}
+				if !cb.included {
+					zb := 0
+					for !prc.zbp.decode(br, j, zb+1) && !br.err {
+						if zb++; zb > 74 {
+							return errors.New("jpx: too many zero bit-planes")
+						}
+					}
+					cb.included, cb.zeroPlanes, cb.lblock = true, zb, 3
+				}
+				n := br.numPasses()
+				for br.bit() == 1 && !br.err {
+					cb.lblock++
+				}
+				if cb.lblock > 32 {
+					return errors.New("jpx: code-block length indicator too long")
+				}
+				for n > 0 && !br.err {
+					if len(cb.segs) == 0 || cb.segs[len(cb.segs)-1].passes == cb.segs[len(cb.segs)-1].maxPasses {
+						cb.segs = append(cb.segs, jpxSegment{maxPasses: nextSegmentPasses(style, cb.segs)})
+					}
+					si := len(cb.segs) - 1
+					take := min(n, cb.segs[si].maxPasses-cb.segs[si].passes)
+					length := br.bits(cb.lblock + bits.Len(uint(take)) - 1)
+					cb.segs[si].passes += take
+					contribs = append(contribs, jpxContribution{cb, si, length})
+					n -= take
+				}
+			}
+		}
+	}
+	if br.err {
+		return errJPXEndOfData
+	}
+	d.pos = br.finish()
+	if d.eph && d.pos+2 <= len(d.data) && d.data[d.pos] == 0xFF && d.data[d.pos+1] == 0x92 {
+		d.pos += 2
+	}
+	for _, c := range contribs {
+		end := d.pos + c.length
+		if end > len(d.data) {
+			seg := &c.cb.segs[c.seg]
+			seg.data = append(seg.data, d.data[d.pos:]...)
+			d.pos = len(d.data)
+			return errJPXEndOfData
+		}
+		c.cb.segs[c.seg].data = append(c.cb.segs[c.seg].data, d.data[d.pos:end]...)
+		d.pos = end
+	}
+	return nil
+}
+
+// nextSegmentPasses is the number of coding passes the next codeword
+// segment of a code-block holds: every pass is terminated with TERMALL,
+// and in bypass mode the first ten passes are one arithmetic-coded
+// segment followed by alternating raw and arithmetic-coded ones.
+func nextSegmentPasses(style int, segs []jpxSegment) int {
+	switch {
+	case style&jpxStyleTermAll != 0:
+		return 1
+	case style&jpxStyleBypass == 0:
+		return 109
+	case len(segs) == 0:
+		return 10
+	}
+	if prev := segs[len(segs)-1].maxPasses; prev == 1 || prev == 10 {
+		return 2
+	}
+	return 1
+}
+
+// jpxBitReader reads packet header bits (B.10.1): after a 0xFF byte the
+// next byte holds only seven bits. Reading past the data sets err.
+type jpxBitReader struct {
+	data []byte
+	pos  int
+	c    byte
+	ct   int
+	err  bool
+}
+
+func (r *jpxBitReader) bit() int {
+	if r.ct == 0 {
+		if r.pos >= len(r.data) {
+			r.err = true
+			return 0
+		}
+		r.ct = 8
+		if r.c == 0xFF {
+			r.ct = 7
+		}
+		r.c = r.data[r.pos]
+		r.pos++
+	}
+	r.ct--
+	return int(r.c >> r.ct & 1)
+}
+
+func (r *jpxBitReader) bits(n int) int {
+	v := 0
+	for range n {
+		v = v<<1 | r.bit()
+	}
+	return v
+}
+
+// numPasses reads a number of coding passes codeword (Table B.4).
+func (r *jpxBitReader) numPasses() int {
+	if r.bit() == 0 {
+		return 1
+	}
+	if r.bit() == 0 {
+		return 2
+	}
+	if n := r.bits(2); n != 3 {
+		return 3 + n
+	}
+	if n := r.bits(5); n != 31 {
+		return 6 + n
+	}
+	return 37 + r.bits(7)
+}
+
+// finish ends a packet header and returns the position after it, which
+// includes the byte holding the stuffed bit after a final 0xFF.
+func (r *jpxBitReader) finish() int {
+	if r.c == 0xFF && r.pos < len(r.data) {
+		r.pos++
+	}
+	return r.pos
+}
+
+// jpxTagTree is a tag tree (B.10.2) over a w x h array of values; nodes
+// holds the leaves, then each coarser level up to the root.
+type jpxTagTree struct {
+	nodes  []jpxTagNode
+	leaves int
+}
+
+type jpxTagNode struct {
+	parent     int
+	value, low int
+}
+
+func newJPXTagTree(w, h int) *jpxTagTree {
+	t := &jpxTagTree{leaves: w * h}
+	if w*h == 0 {
+		return t
+	}
+	type level struct{ start, w, h int }
+	levels := []level{{0, w, h}}
+	n := w * h
+	for w > 1 || h > 1 {
+		w, h = (w+1)/2, (h+1)/2
+		levels = append(levels, level{n, w, h})
+		n += w * h
+	}
+	t.nodes = make([]jpxTagNode, n)
+	for i, lv := range levels {
+		for y := range lv.h {
+			for x := range lv.w {
+				node := &t.nodes[lv.start+y*lv.w+x]
+				node.value, node.parent = math.MaxInt32, -1
+				if i+1 < len(levels) {
+					up := levels[i+1]
+					node.parent = up.start + y/2*up.w + x/2
+				}
+			}
+		}
+	}
+	return t
+}
+
+// decode reads bits until it knows whether leaf's value is below
+// threshold, and reports whether it is.
+func (t *jpxTagTree) decode(r *jpxBitReader, leaf, threshold int) bool {
+	var stack [32]int
+	n := 0
+	for i := leaf; i >= 0; i = t.nodes[i].parent {
+		stack[n] = i
+		n++
+	}
+	low := 0
+	for n > 0 {
+		n--
+		node := &t.nodes[stack[n]]
+		if low > node.low {
+			node.low = low
+		} else {
+			low = node.low
+		}
+		for low < threshold && low < node.value && !r.err {
+			if r.bit() == 1 {
+				node.value = low
+			} else {
+				low++
+			}
+		}
+		node.low = low
+	}
+	return t.nodes[leaf].value < threshold
+}
@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// jp2Box encodes a JP2 box with a 4-byte length.
+func jp2Box(typ string, body ...[]byte) []byte {
+	var b bytes.Buffer
+	n := 8
+	for _, p := range body {
+		n += len(p)
+	}
+	binary.Write(&b, binary.BigEndian, uint32(n))
+	b.WriteString(typ)
+	for _, p := range body {
+		b.Write(p)
+	}
+	return b.Bytes()
+}
+
+// j2kCodestream returns SOC + a SIZ segment for a w x h image whose
+// components have the given bit depths.
+func j2kCodestream(w, h int, depths ...int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0x4F, 0xFF, 0x51})
+	binary.Write(&b, binary.BigEndian, uint16(38+3*len(depths)))
+	binary.Write(&b, binary.BigEndian, uint16(0))
+	for _, v := range []uint32{uint32(w), uint32(h), 0, 0, uint32(w), uint32(h), 0, 0} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	binary.Write(&b, binary.BigEndian, uint16(len(depths)))
+	for _, d := range depths {
+		b.Write([]byte{byte(d - 1), 1, 1})
+	}
+	return b.Bytes()
+}
+
+func ihdr(w, h, nc, bpc int) []byte {
+	b := make([]byte, 14)
+	binary.BigEndian.PutUint32(b, uint32(h))
+	binary.BigEndian.PutUint32(b[4:], uint32(w))
+	binary.BigEndian.PutUint16(b[8:], uint16(nc))
+	b[10] = byte(bpc)
+	b[11] = 7
+	return jp2Box("ihdr", b)
+}
+
+func colrEnum(approx, enumCS int) []byte {
+	b := []byte{1, 0, byte(approx), 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[3:], uint32(enumCS))
+	return jp2Box("colr", b)
+}
+
+func TestParseJPXHeader_JP2(t *testing.T) {
+	data := bytes.Join([][]byte{
+		jp2Box("jP  ", jp2Signature),
+		jp2Box("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 ")),
+		jp2Box("jp2h", ihdr(4, 2, 3, 7), colrEnum(1, JPXEnumSRGB), colrEnum(0, JPXEnumCIEJab)),
+		jp2Box("jp2c", j2kCodestream(4, 2, 8, 8, 8)),
+	}, nil)
+	info, err := ParseJPXHeader(data)
+	if err != nil {
+		t.Fatalf("ParseJPXHeader: %v", err)
+	}
+	if info.Width != 4 || info.Height != 2 || info.Components != 3 {
+		t.Errorf("geometry = %dx%d, %d components", info.Width, info.Height, info.Components)
+	}
+	if len(info.BitDepths) != 3 || info.BitDepths[0] != 8 {
+		t.Errorf("BitDepths = %v, want [8 8 8]", info.BitDepths)
+	}
+	want := []JPXColourSpec{{Method: 1, Approx: 1, EnumCS: JPXEnumSRGB}, {Method: 1, EnumCS: JPXEnumCIEJab}}
+	if len(info.ColourSpecs) != 2 || info.ColourSpecs[0] != want[0] || info.ColourSpecs[1] != want[1] {
+		t.Errorf("ColourSpecs = %+v, want %+v", info.ColourSpecs, want)
+	}
+}
+
+func TestParseJPXHeader_PerComponentDepths(t *testing.T) {
+	data := bytes.Join([][]byte{
+		jp2Box("jP  ", jp2Signature),
+		jp2Box("jp2h", ihdr(1, 1, 2, 0xFF), jp2Box("bpcc", []byte{7, 15})),
+	}, nil)
+	info, err := ParseJPXHeader(data)
+	if err != nil {
+		t.Fatalf("ParseJPXHeader: %v", err)
+	}
+	if len(info.BitDepths) != 2 || info.BitDepths[0] != 8 || info.BitDepths[1] != 16 {
+		t.Errorf("BitDepths = %v, want [8 16]", info.BitDepths)
+	}
+}
+
+func TestParseJPXHeader_Codestream(t *testing.T) {
+	info, err := ParseJPXHeader(j2kCodestream(16, 9, 12))
+	if err != nil {
+		t.Fatalf("ParseJPXHeader: %v", err)
+	}
+	if info.Width != 16 || info.Height != 9 || info.Components != 1 || info.BitDepths[0] != 12 {
+		t.Errorf("info = %+v", info)
+	}
+	if len(info.ColourSpecs) != 0 {
+		t.Errorf("a bare codestream has no colour specs, got %v", info.ColourSpecs)
+	}
+}
+
+func TestParseJPXHeader_Malformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":          nil,
+		"truncated box":  {0, 0, 0, 20, 'j', 'P'},
+		"bad signature":  jp2Box("jP  ", []byte{1, 2, 3, 4}),
+		"no signature":   jp2Box("jp2h", ihdr(1, 1, 1, 7)),
+		"short SIZ":      {0xFF, 0x4F, 0xFF, 0x51, 0, 38},
+		"oversized box":  {0, 0, 1, 0, 'j', 'P', ' ', ' '},
+		"truncated ihdr": bytes.Join([][]byte{jp2Box("jP  ", jp2Signature), jp2Box("jp2h", jp2Box("ihdr", []byte{0}))}, nil),
+	} {
+		if _, err := ParseJPXHeader(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
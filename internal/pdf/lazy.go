@@ -0,0 +1,243 @@
+package pdf
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+)
+
+// This file holds the Reader's bounded mode: an LRU-capped object cache
+// plus detached resolution, which copies part of the object graph out of
+// the cache instead of resolving cached objects in place. A caller walking
+// the document one page at a time then only keeps the current page's
+// objects alive, and the cache can drop the rest.
+
+// objStmCacheLimit caps the decoded object streams kept in bounded mode.
+const objStmCacheLimit = 16
+
+// SetObjectCacheLimit caps the parsed-object cache at n objects, evicting
+// the least recently used one when a new object would exceed it, and
+// returns the previous cap. n <= 0 lifts the cap. While capped, the object
+// stream cache is capped too, and ResolveObject and ResolveGraphByPath
+// resolve detached (see ResolveDetached) without following the page tree
+// out of the object asked for, so looking up a catalog entry never pulls in
+// every page. ResolveGraph still copies the whole graph.
+func (d *Reader) SetObjectCacheLimit(n int) int {
+	prev := d.cacheLimit
+	d.cacheLimit = max(n, 0)
+	d.lru, d.lruElems = nil, nil
+	if d.cacheLimit > 0 {
+		d.lru, d.lruElems = list.New(), map[int]*list.Element{}
+		for num := range d.objCache {
+			d.trackObject(num)
+		}
+	}
+	return prev
+}
+
+// touchObject marks cached object num as most recently used.
+func (d *Reader) touchObject(num int) {
+	if e, ok := d.lruElems[num]; ok {
+		d.lru.MoveToFront(e)
+	}
+}
+
+// trackObject records newly cached object num, evicting the least recently
+// used objects beyond the cap.
+func (d *Reader) trackObject(num int) {
+	if d.lru == nil {
+		return
+	}
+	d.lruElems[num] = d.lru.PushFront(num)
+	for d.lru.Len() > d.cacheLimit {
+		old := d.lru.Remove(d.lru.Back()).(int)
+		delete(d.lruElems, old)
+		delete(d.objCache, old)
+	}
+}
+
+// ReleaseStreamCaches drops the decoded-stream and token caches. A caller
+// working through the document in bounded mode releases them between
+// pages, so they hold one page's streams at a time.
+func (d *Reader) ReleaseStreamCaches() {
+	d.decodedCache, d.scanCache = nil, nil
+}
+
+// ObjectPath locates a dictionary or array of the object graph
+// independently of any in-memory copy of it: the number of the indirect
+// object holding it (0 for the trailer) and the "/"-separated keys and
+// array indices leading to it inside that object ("" for the object
+// itself).
+type ObjectPath struct {
+	ObjNum int
+	Path   string
+}
+
+// Detached is a resolved copy of part of the object graph; see
+// ResolveDetached.
+type Detached struct {
+	Root PDFValue
+	// Origin maps the ValuePointer of every dictionary's Entries and every
+	// array in Root to its ObjectPath, which is the same in every copy, so
+	// state kept across copies can be keyed by it.
+	Origin map[uintptr]ObjectPath
+}
+
+// ResolveDetached resolves v into a fresh copy of the graph below it,
+// pulling each indirect object through the object cache without modifying
+// the cached object, so the cache may evict it while the copy is in use.
+// Within one copy each indirect object is copied once, keeping shared
+// objects (and cycles) shared as in-place resolution does. stop, if
+// non-nil, is asked about every indirect dictionary other than v itself;
+// returning true leaves its references unresolved, bounding the copy.
+func (d *Reader) ResolveDetached(v PDFValue, stop func(PDFRef, PDFDict) bool) (Detached, error) {
+	r := &detacher{
+		d:      d,
+		stop:   stop,
+		memo:   map[int]PDFValue{},
+		origin: map[uintptr]ObjectPath{},
+	}
+	root, err := r.value(v, ObjectPath{}, 0)
+	if err != nil {
+		return Detached{}, err
+	}
+	return Detached{Root: root, Origin: r.origin}, nil
+}
+
+// stopAtPageTree is the bounded-mode stop for ResolveObject and
+// ResolveGraphByPath: page tree nodes stay references.
+func stopAtPageTree(_ PDFRef, dict PDFDict) bool {
+	switch dict.Entries["Type"] {
+	case PDFName{Value: "Page"}, PDFName{Value: "Pages"}:
+		return true
+	}
+	return false
+}
+
+type detacher struct {
+	d      *Reader
+	stop   func(PDFRef, PDFDict) bool
+	memo   map[int]PDFValue
+	origin map[uintptr]ObjectPath
+}
+
+func (r *detacher) value(v PDFValue, at ObjectPath, depth int) (PDFValue, error) {
+	if depth > maxResolveDepth {
+		return nil, fmt.Errorf("resolve depth limit exceeded")
+	}
+	switch v := v.(type) {
+	case PDFRef:
+		if c, ok := r.memo[v.ObjNum]; ok {
+			return c, nil
+		}
+		if err := r.d.Err(); err != nil {
+			return nil, err
+		}
+		target, err := r.d.ResolveReference(v)
+		if err != nil {
+			return nil, err
+		}
+		return r.indirect(v, target, depth)
+
+	case PDFDict:
+		// A dictionary carrying _ref is an indirect object, reached either
+		// through its reference or already resolved in place.
+		if ref, ok := v.Entries["_ref"].(PDFRef); ok {
+			if c, ok := r.memo[ref.ObjNum]; ok {
+				return c, nil
+			}
+			return r.indirect(ref, v, depth)
+		}
+		return r.dict(v, at, depth, -1)
+
+	case PDFArray:
+		return r.array(v, at, depth, -1)
+
+	default:
+		return v, nil
+	}
+}
+
+// indirect copies the target of ref, unless stop leaves it a reference.
+// The root is never stopped.
+func (r *detacher) indirect(ref PDFRef, target PDFValue, depth int) (PDFValue, error) {
+	at := ObjectPath{ObjNum: ref.ObjNum}
+	switch t := target.(type) {
+	case PDFDict:
+		if depth > 0 && r.stop != nil && r.stop(ref, t) {
+			r.memo[ref.ObjNum] = ref
+			return ref, nil
+		}
+		return r.dict(t, at, depth, ref.ObjNum)
+	case PDFArray:
+		return r.array(t, at, depth, ref.ObjNum)
+	default:
+		r.memo[ref.ObjNum] = target
+		return target, nil
+	}
+}
+
+// dict copies v, memoizing the copy as object num (unless num is -1)
+// before copying its entries, so cycles through it terminate.
+func (r *detacher) dict(v PDFDict, at ObjectPath, depth, num int) (PDFValue, error) {
+	out := PDFDict{
+		Entries:   make(map[string]PDFValue, len(v.Entries)),
+		HasStream: v.HasStream,
+		RawStream: v.RawStream,
+	}
+	if num >= 0 {
+		r.memo[num] = out
+	}
+	r.origin[ValuePointer(out.Entries)] = at
+	for k, val := range v.Entries {
+		if k == "_ref" {
+			out.Entries[k] = val
+			continue
+		}
+		c, err := r.value(val, ObjectPath{ObjNum: at.ObjNum, Path: at.Path + "/" + k}, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out.Entries[k] = c
+	}
+	return out, nil
+}
+
+// array is dict's counterpart for arrays.
+func (r *detacher) array(v PDFArray, at ObjectPath, depth, num int) (PDFValue, error) {
+	out := make(PDFArray, len(v))
+	if num >= 0 {
+		r.memo[num] = out
+	}
+	r.origin[ValuePointer(out)] = at
+	for i, val := range v {
+		c, err := r.value(val, ObjectPath{ObjNum: at.ObjNum, Path: at.Path + "/" + strconv.Itoa(i)}, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+// PageRefs walks the page tree without resolving the rest of the graph and
+// returns its indirect pages in document order, with the page index
+// BuildPageIndex builds from the resolved graph.
+func (d *Reader) PageRefs() ([]PDFRef, map[int]int, error) {
+	root, err := d.resolveShallow(d.EffectiveTrailer().Entries["Root"])
+	if err != nil {
+		return nil, nil, err
+	}
+	if root == nil {
+		return nil, nil, fmt.Errorf("dict Root is nil")
+	}
+	rootDict, ok := root.(PDFDict)
+	if !ok {
+		return nil, nil, fmt.Errorf("Root is not a dictionary")
+	}
+	pages := rootDict.Entries["Pages"]
+	if pages == nil {
+		return nil, nil, fmt.Errorf("dict Pages is nil")
+	}
+	return d.walkPageTree(pages)
+}
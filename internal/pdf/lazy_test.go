@@ -0,0 +1,171 @@
+package pdf
+
+import "testing"
+
+// openLazyTestPDF opens a two-page document whose pages share a font and
+// whose second page links to the first.
+func openLazyTestPDF(t *testing.T) *Reader {
+	t.Helper()
+	path := writeMinimalPDF(t, []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Annots [6 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Annot /Subtype /Link /Dest [3 0 R /Fit] >>",
+	}, "<< /Size 7 /Root 1 0 R >>")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// TestSetObjectCacheLimitEvicts confirms a capped cache holds at most the
+// cap, evicting the least recently used object, and that lifting the cap
+// returns the previous one.
+func TestSetObjectCacheLimitEvicts(t *testing.T) {
+	d := openLazyTestPDF(t)
+	if prev := d.SetObjectCacheLimit(2); prev != 0 {
+		t.Errorf("SetObjectCacheLimit returned %d, want 0", prev)
+	}
+	for _, n := range []int{3, 4, 3, 5} {
+		if _, err := d.ResolveReference(PDFRef{ObjNum: n}); err != nil {
+			t.Fatalf("ResolveReference(%d): %v", n, err)
+		}
+		if len(d.objCache) > 2 {
+			t.Fatalf("cache holds %d objects, want at most 2", len(d.objCache))
+		}
+	}
+	// 3 was used after 4, so 4 is the one evicted for 5.
+	if _, ok := d.objCache[4]; ok {
+		t.Error("object 4 still cached; want it evicted as least recently used")
+	}
+	if _, ok := d.objCache[3]; !ok {
+		t.Error("object 3 evicted; want it kept as recently used")
+	}
+	if prev := d.SetObjectCacheLimit(0); prev != 2 {
+		t.Errorf("SetObjectCacheLimit returned %d, want 2", prev)
+	}
+}
+
+// TestResolveDetached confirms a detached copy leaves the cached objects
+// unresolved, copies a shared object once, leaves stopped dictionaries as
+// references, and records the same origins in every copy.
+func TestResolveDetached(t *testing.T) {
+	d := openLazyTestPDF(t)
+	stopPages := func(_ PDFRef, dict PDFDict) bool {
+		return dict.Entries["Type"] == PDFName{Value: "Page"}
+	}
+	res, err := d.ResolveDetached(d.EffectiveTrailer(), stopPages)
+	if err != nil {
+		t.Fatalf("ResolveDetached: %v", err)
+	}
+	pages := res.Root.(PDFDict).Entries["Root"].(PDFDict).Entries["Pages"].(PDFDict)
+	kids := pages.Entries["Kids"].(PDFArray)
+	if _, ok := kids[0].(PDFRef); !ok {
+		t.Errorf("Kids[0] = %T, want the stopped page left a PDFRef", kids[0])
+	}
+	if _, ok := d.objCache[1].(PDFDict).Entries["Pages"].(PDFRef); !ok {
+		t.Error("cached catalog was resolved in place")
+	}
+	if got := res.Origin[ValuePointer(kids)]; got != (ObjectPath{ObjNum: 2, Path: "/Kids"}) {
+		t.Errorf("Kids origin = %+v, want object 2 /Kids", got)
+	}
+
+	page, err := d.ResolveDetached(PDFRef{ObjNum: 4}, stopPages)
+	if err != nil {
+		t.Fatalf("ResolveDetached(page): %v", err)
+	}
+	dest := page.Root.(PDFDict).Entries["Annots"].(PDFArray)[0].(PDFDict).Entries["Dest"].(PDFArray)
+	if _, ok := dest[0].(PDFRef); !ok {
+		t.Errorf("Dest page = %T, want the other page left a PDFRef", dest[0])
+	}
+	font := page.Root.(PDFDict).Entries["Resources"].(PDFDict).Entries["Font"].(PDFDict).Entries["F1"].(PDFDict)
+	if got := page.Origin[ValuePointer(font.Entries)]; got != (ObjectPath{ObjNum: 5}) {
+		t.Errorf("font origin = %+v, want object 5", got)
+	}
+
+	again, err := d.ResolveDetached(PDFRef{ObjNum: 4}, stopPages)
+	if err != nil {
+		t.Fatalf("ResolveDetached(page) again: %v", err)
+	}
+	font2 := again.Root.(PDFDict).Entries["Resources"].(PDFDict).Entries["Font"].(PDFDict).Entries["F1"].(PDFDict)
+	if ValuePointer(font2.Entries) == ValuePointer(font.Entries) {
+		t.Error("second copy shares the first copy's font dict")
+	}
+	if again.Origin[ValuePointer(font2.Entries)] != page.Origin[ValuePointer(font.Entries)] {
+		t.Error("font origin differs between copies")
+	}
+}
+
+// TestResolveDetachedSharesWithinCopy confirms an object reached twice in
+// one copy is copied once, and a cycle terminates.
+func TestResolveDetachedSharesWithinCopy(t *testing.T) {
+	d := openLazyTestPDF(t)
+	res, err := d.ResolveDetached(PDFRef{ObjNum: 2}, nil)
+	if err != nil {
+		t.Fatalf("ResolveDetached: %v", err)
+	}
+	kids := res.Root.(PDFDict).Entries["Kids"].(PDFArray)
+	font := func(i int) uintptr {
+		res := kids[i].(PDFDict).Entries["Resources"].(PDFDict)
+		return ValuePointer(res.Entries["Font"].(PDFDict).Entries["F1"].(PDFDict).Entries)
+	}
+	if font(0) != font(1) {
+		t.Error("font shared by both pages was copied twice")
+	}
+	parent := kids[0].(PDFDict).Entries["Parent"].(PDFDict)
+	if ValuePointer(parent.Entries) != ValuePointer(res.Root.(PDFDict).Entries) {
+		t.Error("page's Parent is not the copied page tree root")
+	}
+}
+
+// TestPageRefs confirms PageRefs matches BuildPageIndex on the resolved
+// graph without resolving it.
+func TestPageRefs(t *testing.T) {
+	d := openLazyTestPDF(t)
+	refs, index, err := d.PageRefs()
+	if err != nil {
+		t.Fatalf("PageRefs: %v", err)
+	}
+	if len(refs) != 2 || refs[0].ObjNum != 3 || refs[1].ObjNum != 4 {
+		t.Errorf("PageRefs = %v, want [3 4]", refs)
+	}
+	if d.graphResolved {
+		t.Error("PageRefs resolved the graph")
+	}
+	graph, err := d.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	want, err := d.BuildPageIndex(graph)
+	if err != nil {
+		t.Fatalf("BuildPageIndex: %v", err)
+	}
+	if len(index) != len(want) || index[3] != want[3] || index[4] != want[4] {
+		t.Errorf("PageRefs index = %v, want %v", index, want)
+	}
+}
+
+// TestResolveGraphByPathBounded confirms a capped Reader resolves a path's
+// value without following the page tree out of it.
+func TestResolveGraphByPathBounded(t *testing.T) {
+	d := openLazyTestPDF(t)
+	d.SetObjectCacheLimit(4)
+	v, err := d.ResolveGraphByPath([]string{"Root", "Pages"})
+	if err != nil {
+		t.Fatalf("ResolveGraphByPath: %v", err)
+	}
+	pages, ok := v.(PDFDict)
+	if !ok || pages.Entries["Count"] != PDFInteger(2) {
+		t.Fatalf("ResolveGraphByPath = %#v, want the Pages dict", v)
+	}
+	if _, ok := pages.Entries["Kids"].(PDFArray)[0].(PDFRef); !ok {
+		t.Error("bounded ResolveGraphByPath resolved a page")
+	}
+	if d.graphResolved || len(d.objCache) > 4 {
+		t.Errorf("bounded lookup resolved the graph or overfilled the cache (%d objects)", len(d.objCache))
+	}
+}
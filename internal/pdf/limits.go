@@ -0,0 +1,222 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits caps the resources reading, verifying and converting one document
+// may use, so untrusted input fails with a *LimitError instead of
+// exhausting the process. A zero field takes its value from DefaultLimits,
+// and a negative one means no limit: the way to lift a default cap, such as
+// StreamBytes or RasterPixels, rather than change it.
+//
+// The nesting guards on object parsing, graph resolution, function
+// evaluation and the verifier's walks are not limits: they bound Go stack
+// depth rather than input size, and stay fixed.
+type Limits struct {
+	// StreamBytes caps the decoded size of a single stream.
+	StreamBytes int64
+	// TotalBytes caps the decoded bytes of all the streams a Reader
+	// decodes while the limits are set.
+	TotalBytes int64
+	// Objects caps the number of indirect objects in the cross-reference
+	// table.
+	Objects int
+	// Pages caps the number of pages in the page tree.
+	Pages int
+	// RasterPixels caps the pixels of a rendered page or form, and of a
+	// decoded image it paints.
+	RasterPixels int64
+	// Duration caps the wall time from setting the limits.
+	Duration time.Duration
+}
+
+// defaultLimits are the limits in force where none are set, and fill the
+// zero fields of those that are. A zero field here means no limit. They are
+// fixed: a program wanting others sets them on its profiles.
+var defaultLimits = Limits{
+	StreamBytes:  256 << 20,
+	RasterPixels: 20000 * 20000,
+}
+
+// DefaultLimits returns the limits in force where none are set.
+func DefaultLimits() Limits { return defaultLimits }
+
+// Effective returns l with its zero fields taken from DefaultLimits. A
+// negative field, meaning no limit, is kept.
+func (l Limits) Effective() Limits {
+	def := defaultLimits
+	if l.StreamBytes == 0 {
+		l.StreamBytes = def.StreamBytes
+	}
+	if l.TotalBytes == 0 {
+		l.TotalBytes = def.TotalBytes
+	}
+	if l.Objects == 0 {
+		l.Objects = def.Objects
+	}
+	if l.Pages == 0 {
+		l.Pages = def.Pages
+	}
+	if l.RasterPixels == 0 {
+		l.RasterPixels = def.RasterPixels
+	}
+	if l.Duration == 0 {
+		l.Duration = def.Duration
+	}
+	return l
+}
+
+// LimitError reports that a document exceeded one of its Limits. Limit
+// names the Limits field, and Max its value.
+type LimitError struct {
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "Duration" {
+		return fmt.Sprintf("limit exceeded: Duration %v", time.Duration(e.Max))
+	}
+	return fmt.Sprintf("limit exceeded: %s %d", e.Limit, e.Max)
+}
+
+// SetLimits makes l govern d's work from now on, starting a fresh budget
+// for TotalBytes and Duration, and returns the previous limits. The verify
+// and convert passes over d stop, as they do once d's context is done (see
+// SetContext), when d exceeds any of them; Err then returns the
+// *LimitError. An object count over l.Objects is recorded at once.
+func (d *Reader) SetLimits(l Limits) Limits {
+	prev := d.limits
+	d.limits, d.limited = l, true
+	d.decodedTotal.Store(0)
+	d.limitErr.Store(nil)
+	d.deadline = time.Time{}
+	eff := l.Effective()
+	if eff.Duration > 0 {
+		d.deadline = time.Now().Add(eff.Duration)
+	}
+	if eff.Objects > 0 && len(d.xrefTable)+len(d.compressedXref) > eff.Objects {
+		d.exceed("Objects", int64(eff.Objects))
+	}
+	return prev
+}
+
+// ApplyLimits puts l in force on d for one pass over it, as SetLimits
+// does, and returns the function restoring d's previous limits and budget.
+// Limits already set and the same are left running, so a pass nested in
+// another under them -- convert verifying the document it is converting --
+// does not restart their budget. Like SetLimits, it changes d for every
+// caller, so it must not be called while another pass runs over d (see
+// Reader).
+func (d *Reader) ApplyLimits(l Limits) (restore func()) {
+	if d.limited && d.Limits() == l.Effective() {
+		return func() {}
+	}
+	limits, limited, deadline := d.limits, d.limited, d.deadline
+	total, err := d.decodedTotal.Load(), d.limitErr.Load()
+	d.SetLimits(l)
+	return func() {
+		d.limits, d.limited, d.deadline = limits, limited, deadline
+		d.decodedTotal.Store(total)
+		d.limitErr.Store(err)
+	}
+}
+
+// Limits returns the limits in force for d: those set by SetLimits, with
+// DefaultLimits filling their zero fields.
+func (d *Reader) Limits() Limits {
+	if d == nil {
+		return defaultLimits
+	}
+	return d.limits.Effective()
+}
+
+// AdoptLimits makes d share src's limits and the budget src has spent so
+// far, so work moved to d -- e.g. verifying convert's output -- counts
+// against the same TotalBytes and Duration.
+func (d *Reader) AdoptLimits(src *Reader) {
+	if src == nil {
+		return
+	}
+	d.limits, d.limited, d.deadline = src.limits, src.limited, src.deadline
+	d.decodedTotal.Store(src.decodedTotal.Load())
+	d.limitErr.Store(src.limitErr.Load())
+}
+
+// exceed records that d exceeded limit, whose value is max, keeping the
+// first breach recorded, and returns it.
+func (d *Reader) exceed(limit string, max int64) error {
+	d.limitErr.CompareAndSwap(nil, &LimitError{Limit: limit, Max: max})
+	return d.limitErr.Load()
+}
+
+// RecordLimit records e as a limit d exceeded, so Err reports it, for work
+// over d done outside the Reader -- rendering a page, say -- that hit one of
+// d's Limits. It returns the first limit d exceeded, which is e unless an
+// earlier breach was recorded. A nil d records nothing and returns e.
+func (d *Reader) RecordLimit(e *LimitError) error {
+	if d == nil {
+		return e
+	}
+	return d.exceed(e.Limit, e.Max)
+}
+
+// countDecoded adds n decoded bytes to d's TotalBytes budget.
+func (d *Reader) countDecoded(n int) error {
+	total := d.decodedTotal.Add(int64(n))
+	if max := d.Limits().TotalBytes; max > 0 && total > max {
+		return d.exceed("TotalBytes", max)
+	}
+	return nil
+}
+
+// DecodeStreamLimited is DecodeStream under d's Limits: a stream decoding
+// to more than StreamBytes, or past what is left of TotalBytes, is a
+// *LimitError, which Err then reports. Unlike DecodeStreamCached it keeps
+// nothing, for a stream decoded to be rewritten. A nil d decodes as
+// DecodeStream does.
+func (d *Reader) DecodeStreamLimited(dict PDFDict) ([]byte, error) {
+	if d == nil {
+		return DecodeStream(dict)
+	}
+	return d.decode(dict)
+}
+
+// DecodeStreamToCodecLimited is DecodeStreamToCodec under d's Limits, as
+// DecodeStreamLimited is DecodeStream.
+func (d *Reader) DecodeStreamToCodecLimited(dict PDFDict) ([]byte, string, error) {
+	if d == nil {
+		return DecodeStreamToCodec(dict)
+	}
+	return d.decodeToCodec(dict)
+}
+
+// decode decodes dict's stream under d's limits.
+func (d *Reader) decode(dict PDFDict) ([]byte, error) {
+	data, codec, err := d.decodeToCodec(dict)
+	if err == nil && codec != "" {
+		err = fmt.Errorf("unsupported filter %q", codec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decodeToCodec decodes dict's stream up to any image codec under d's
+// limits, counting the bytes against TotalBytes.
+func (d *Reader) decodeToCodec(dict PDFDict) ([]byte, string, error) {
+	data, codec, err := decodeStreamToCodec(dict, d.Limits().StreamBytes)
+	if err != nil {
+		if le, ok := err.(*LimitError); ok {
+			return nil, "", d.exceed(le.Limit, le.Max)
+		}
+		return nil, "", err
+	}
+	if err := d.countDecoded(len(data)); err != nil {
+		return nil, "", err
+	}
+	return data, codec, nil
+}
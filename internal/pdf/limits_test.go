@@ -0,0 +1,227 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openLimitsTestPDF opens a two-page document with two ASCIIHex streams,
+// 5 0 R decoding to 40 bytes and 6 0 R to 20.
+func openLimitsTestPDF(t *testing.T) *Reader {
+	t.Helper()
+	hex := func(n int) string {
+		data := strings.Repeat("41", n) + ">"
+		return "<< /Length " + strconv.Itoa(len(data)) + " /Filter /ASCIIHexDecode >>\nstream\n" + data + "\nendstream"
+	}
+	path := writeMinimalPDF(t, []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		hex(40),
+		hex(20),
+	}, "<< /Size 7 /Root 1 0 R >>")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// wantLimit fails t unless err is a *LimitError for limit.
+func wantLimit(t *testing.T, what string, err error, limit string) {
+	t.Helper()
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != limit {
+		t.Errorf("%s = %v, want a %s LimitError", what, err, limit)
+	}
+}
+
+// stream returns the stream object num of d.
+func stream(t *testing.T, d *Reader, num int) PDFDict {
+	t.Helper()
+	v, err := d.ResolveReference(PDFRef{ObjNum: num})
+	if err != nil {
+		t.Fatalf("ResolveReference(%d): %v", num, err)
+	}
+	return v.(PDFDict)
+}
+
+// TestLimitsStreamBytes confirms a stream decoding past StreamBytes is a
+// LimitError that Err then reports, and that restoring the previous limits
+// clears it.
+func TestLimitsStreamBytes(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	prev := d.SetLimits(Limits{StreamBytes: 30})
+	if prev != (Limits{}) {
+		t.Errorf("SetLimits returned %+v, want the zero Limits", prev)
+	}
+	if _, err := d.DecodeStreamCached(stream(t, d, 6)); err != nil {
+		t.Fatalf("DecodeStreamCached(6): %v", err)
+	}
+	_, err := d.DecodeStreamCached(stream(t, d, 5))
+	wantLimit(t, "DecodeStreamCached(5)", err, "StreamBytes")
+	wantLimit(t, "Err", d.Err(), "StreamBytes")
+	if _, err := d.ResolveGraph(); err == nil {
+		t.Error("ResolveGraph after a breach succeeded")
+	}
+
+	d.SetLimits(prev)
+	if err := d.Err(); err != nil {
+		t.Errorf("Err after restoring the limits = %v, want nil", err)
+	}
+	if _, err := d.DecodeStreamCached(stream(t, d, 5)); err != nil {
+		t.Errorf("DecodeStreamCached(5) under the defaults: %v", err)
+	}
+}
+
+// TestLimitsTotalBytes confirms streams each under StreamBytes still stop
+// once their sum passes TotalBytes, and that cache hits do not count again.
+func TestLimitsTotalBytes(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	d.SetLimits(Limits{TotalBytes: 50})
+	for range 2 {
+		if _, err := d.DecodeStreamCached(stream(t, d, 5)); err != nil {
+			t.Fatalf("DecodeStreamCached(5): %v", err)
+		}
+	}
+	_, err := d.DecodeStreamCached(stream(t, d, 6))
+	wantLimit(t, "DecodeStreamCached(6)", err, "TotalBytes")
+}
+
+// TestDecodeStreamLimited confirms the uncached decode counts every call
+// against TotalBytes, caps each stream at StreamBytes, and on a nil Reader
+// decodes under the defaults.
+func TestDecodeStreamLimited(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	d.SetLimits(Limits{TotalBytes: 50})
+	if _, err := d.DecodeStreamLimited(stream(t, d, 5)); err != nil {
+		t.Fatalf("DecodeStreamLimited(5): %v", err)
+	}
+	_, _, err := d.DecodeStreamToCodecLimited(stream(t, d, 5))
+	wantLimit(t, "DecodeStreamToCodecLimited(5) again", err, "TotalBytes")
+	wantLimit(t, "Err", d.Err(), "TotalBytes")
+
+	d = openLimitsTestPDF(t)
+	d.SetLimits(Limits{StreamBytes: 30})
+	_, err = d.DecodeStreamLimited(stream(t, d, 5))
+	wantLimit(t, "DecodeStreamLimited(5)", err, "StreamBytes")
+
+	var none *Reader
+	if data, err := none.DecodeStreamLimited(stream(t, d, 5)); err != nil || len(data) != 40 {
+		t.Errorf("nil Reader DecodeStreamLimited(5) = %d bytes, %v; want 40", len(data), err)
+	}
+}
+
+// TestLimitsNegativeIsUnlimited confirms a negative field survives
+// Effective rather than taking the default, and caps nothing.
+func TestLimitsNegativeIsUnlimited(t *testing.T) {
+	l := Limits{StreamBytes: -1, RasterPixels: -1}.Effective()
+	if l.StreamBytes != -1 || l.RasterPixels != -1 {
+		t.Errorf("Effective = %+v, want the negative fields kept", l)
+	}
+
+	d := openLimitsTestPDF(t)
+	d.SetLimits(Limits{StreamBytes: -1, TotalBytes: -1, Objects: -1, Pages: -1, Duration: -1})
+	for range 2 {
+		if _, err := d.DecodeStreamLimited(stream(t, d, 5)); err != nil {
+			t.Fatalf("DecodeStreamLimited(5): %v", err)
+		}
+	}
+	if _, _, err := d.PageRefs(); err != nil {
+		t.Errorf("PageRefs: %v", err)
+	}
+	if err := d.Err(); err != nil {
+		t.Errorf("Err = %v, want nil", err)
+	}
+}
+
+// TestLimitsObjectsAndPages confirms an object count over Objects is
+// recorded when the limits are set, and a page tree over Pages when it is
+// walked.
+func TestLimitsObjectsAndPages(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	d.SetLimits(Limits{Objects: 3})
+	wantLimit(t, "Err", d.Err(), "Objects")
+
+	d = openLimitsTestPDF(t)
+	d.SetLimits(Limits{Pages: 1})
+	_, _, err := d.PageRefs()
+	wantLimit(t, "PageRefs", err, "Pages")
+	wantLimit(t, "Err", d.Err(), "Pages")
+}
+
+// TestLimitsDuration confirms Err reports a Duration LimitError once the
+// limits have been set for longer than Duration.
+func TestLimitsDuration(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	d.SetLimits(Limits{Duration: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	wantLimit(t, "Err", d.Err(), "Duration")
+}
+
+// TestApplyLimits confirms ApplyLimits leaves limits already in force
+// running, and otherwise restores the previous ones.
+func TestApplyLimits(t *testing.T) {
+	d := openLimitsTestPDF(t)
+	outer := d.ApplyLimits(Limits{TotalBytes: 50})
+	if _, err := d.DecodeStreamCached(stream(t, d, 5)); err != nil {
+		t.Fatalf("DecodeStreamCached(5): %v", err)
+	}
+	d.ApplyLimits(Limits{TotalBytes: 50})()
+	if _, err := d.DecodeStreamCached(stream(t, d, 6)); err == nil {
+		t.Error("nested ApplyLimits restarted the TotalBytes budget")
+	}
+	outer()
+	if got := d.Limits(); got != DefaultLimits() {
+		t.Errorf("Limits after restore = %+v, want DefaultLimits", got)
+	}
+}
+
+// TestDecodeStreamLimitFilters confirms each decoder that can expand its
+// input stops at the cap: Flate, LZW and RunLength.
+func TestDecodeStreamLimitFilters(t *testing.T) {
+	var flate bytes.Buffer
+	zw := zlib.NewWriter(&flate)
+	zw.Write(make([]byte, 4096))
+	zw.Close()
+	rl := bytes.Repeat([]byte{129, 'x'}, 40) // 40 runs of 128 bytes
+
+	cases := map[string]PDFDict{
+		"Flate":     {Entries: map[string]PDFValue{"Filter": PDFName{Value: "FlateDecode"}}, HasStream: true, RawStream: flate.Bytes()},
+		"RunLength": {Entries: map[string]PDFValue{"Filter": PDFName{Value: "RunLengthDecode"}}, HasStream: true, RawStream: rl},
+	}
+	for name, dict := range cases {
+		_, _, err := decodeStreamToCodec(dict, 1024)
+		wantLimit(t, name, err, "StreamBytes")
+		if _, _, err := decodeStreamToCodec(dict, 0); err != nil {
+			t.Errorf("%s with no cap: %v", name, err)
+		}
+	}
+	// 20 rounds of code 65 a hundred times then a table clear, keeping
+	// every code 9 bits wide: 2000 bytes decoded.
+	var lzw []byte
+	var acc, bits uint
+	for i := range 2020 {
+		code := uint(65)
+		if i%101 == 100 {
+			code = lzwClearTable
+		}
+		acc, bits = acc<<9|code, bits+9
+		for bits >= 8 {
+			lzw = append(lzw, byte(acc>>(bits-8)))
+			bits -= 8
+		}
+	}
+	_, err := decodeLZW(lzw, true, 1024)
+	wantLimit(t, "LZW", err, "StreamBytes")
+	if out, err := decodeLZW(lzw, true, 0); err != nil || len(out) < 1024 {
+		t.Errorf("LZW with no cap = %d bytes, %v", len(out), err)
+	}
+}
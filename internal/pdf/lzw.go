@@ -9,8 +9,21 @@ const (
 	lzwMaxCode    = 4096
 )
 
-// decodeLZW decodes a PDF LZWDecode stream into its uncompressed bytes.
+// DecodeLZW decodes a PDF LZWDecode stream into its uncompressed bytes,
+// with the default EarlyChange of 1. Output past DefaultLimits().StreamBytes
+// is a *LimitError.
 func DecodeLZW(data []byte) ([]byte, error) {
+	return decodeLZW(data, true, defaultLimits.StreamBytes)
+}
+
+// decodeLZW decodes an LZWDecode stream. earlyChange is the EarlyChange
+// parameter: whether code widths grow one code early. Output past max bytes
+// is a *LimitError; 0 means no cap.
+func decodeLZW(data []byte, earlyChange bool, max int64) ([]byte, error) {
+	early := 0
+	if earlyChange {
+		early = 1
+	}
 	br := lzwBitReader{data: data}
 	table := make([][]byte, lzwMaxCode)
 	for i := range 256 {
@@ -42,19 +55,22 @@ func DecodeLZW(data []byte) ([]byte, error) {
 			return nil, fmt.Errorf("lzw: invalid code %d", code)
 		}
 		out = append(out, entry...)
+		if max > 0 && int64(len(out)) > max {
+			return nil, &LimitError{Limit: "StreamBytes", Max: max}
+		}
 
 		if prev != nil && nextCode < lzwMaxCode {
 			table[nextCode] = append(append([]byte{}, prev...), entry[0])
 			nextCode++
-			// the code width grows as soon as the just-added entry's
-			// number reaches the boundary, one code sooner than the table
-			// size alone would require.
-			switch nextCode {
-			case 511:
+			// With EarlyChange the code width grows as soon as the
+			// just-added entry's number reaches the boundary, one code
+			// sooner than the table size alone would require.
+			switch nextCode + early {
+			case 512:
 				codeWidth = 10
-			case 1023:
+			case 1024:
 				codeWidth = 11
-			case 2047:
+			case 2048:
 				codeWidth = 12
 			}
 		}
@@ -51,7 +51,7 @@ func (d *Reader) decodeObjStm(streamObjNum int) ([]objStmEntry, error) {
 		return nil, fmt.Errorf("object %d is not an object stream", streamObjNum)
 	}
 
-	data, err := decodeStreamPredicted(dict)
+	data, err := d.decode(dict)
 	if err != nil {
 		return nil, fmt.Errorf("object stream %d: %w", streamObjNum, err)
 	}
@@ -116,6 +116,13 @@ func (d *Reader) decodeObjStm(streamObjNum int) ([]objStmEntry, error) {
 	if d.objStmCache == nil {
 		d.objStmCache = map[int][]objStmEntry{}
 	}
+	if d.cacheLimit > 0 && len(d.objStmCache) >= objStmCacheLimit {
+		// Bounded mode: drop an arbitrary stream to make room.
+		for num := range d.objStmCache {
+			delete(d.objStmCache, num)
+			break
+		}
+	}
 	d.objStmCache[streamObjNum] = entries
 	return entries, nil
 }
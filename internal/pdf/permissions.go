@@ -0,0 +1,77 @@
+package pdf
+
+// Permissions is the set of user access permissions an encrypted document
+// declares in its Encrypt dictionary's P entry (ISO 32000-2 Table 22). A
+// set bit grants the operation; the values are the P bits themselves.
+type Permissions uint32
+
+const (
+	PermPrint              Permissions = 1 << 2  // bit 3: print
+	PermModify             Permissions = 1 << 3  // bit 4: modify contents
+	PermCopy               Permissions = 1 << 4  // bit 5: copy or extract text and graphics
+	PermAnnotate           Permissions = 1 << 5  // bit 6: add or modify annotations, fill forms
+	PermFillForms          Permissions = 1 << 8  // bit 9: fill existing form fields
+	PermExtractAccessible  Permissions = 1 << 9  // bit 10: extract for accessibility
+	PermAssemble           Permissions = 1 << 10 // bit 11: insert, rotate or delete pages
+	PermPrintHighQuality   Permissions = 1 << 11 // bit 12: print at full resolution
+	permAll                            = PermPrint | PermModify | PermCopy | PermAnnotate | PermFillForms | PermExtractAccessible | PermAssemble | PermPrintHighQuality
+	permRevision2Inherited             = PermFillForms | PermExtractAccessible | PermAssemble | PermPrintHighQuality
+)
+
+// permissionNames lists every permission with its description, in bit order.
+var permissionNames = []struct {
+	perm Permissions
+	name string
+}{
+	{PermPrint, "printing"},
+	{PermModify, "modifying contents"},
+	{PermCopy, "copying text and graphics"},
+	{PermAnnotate, "annotating"},
+	{PermFillForms, "filling forms"},
+	{PermExtractAccessible, "extracting for accessibility"},
+	{PermAssemble, "assembling pages"},
+	{PermPrintHighQuality, "high-quality printing"},
+}
+
+// Allows reports whether every permission in q is granted.
+func (p Permissions) Allows(q Permissions) bool { return p&q == q }
+
+// Restricted describes the permissions p withholds, in bit order, e.g.
+// "printing"; it is empty when p grants everything.
+func (p Permissions) Restricted() []string {
+	var out []string
+	for _, n := range permissionNames {
+		if !p.Allows(n.perm) {
+			out = append(out, n.name)
+		}
+	}
+	return out
+}
+
+// Permissions returns the access permissions declared by the document's
+// security handler, and false if the document is not being decrypted.
+// Revision 2 of the Standard handler predates bits 9 to 12; their
+// operations follow the bits that covered them then (annotate, copy,
+// modify and print).
+func (d *Reader) Permissions() (Permissions, bool) {
+	if d.crypt == nil {
+		return 0, false
+	}
+	p := Permissions(uint32(d.crypt.p)) & permAll
+	if d.crypt.r == 2 {
+		p &^= permRevision2Inherited
+		if p.Allows(PermAnnotate) {
+			p |= PermFillForms
+		}
+		if p.Allows(PermCopy) {
+			p |= PermExtractAccessible
+		}
+		if p.Allows(PermModify) {
+			p |= PermAssemble
+		}
+		if p.Allows(PermPrint) {
+			p |= PermPrintHighQuality
+		}
+	}
+	return p, true
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReaderPermissions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		r    int
+		p    int32
+		want Permissions
+	}{
+		{"all granted", 4, -4, permAll},
+		// -3904 sets only reserved bits: everything withheld.
+		{"all restricted", 3, -3904, 0},
+		{"copy and accessibility", 4, -3904 | int32(PermCopy|PermExtractAccessible), PermCopy | PermExtractAccessible},
+		// Bits 9-12 of a revision 2 P are meaningless; they follow bits 3-6.
+		{"revision 2", 2, int32(-64 | int32(PermPrint|PermCopy)), PermPrint | PermCopy | PermExtractAccessible | PermPrintHighQuality},
+		{"public-key handler", 0, -3900, PermPrint},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Reader{crypt: &securityHandler{r: tc.r, p: tc.p}}
+			got, ok := d.Permissions()
+			if !ok || got != tc.want {
+				t.Errorf("Permissions = %012b, %v; want %012b, true", got, ok, tc.want)
+			}
+		})
+	}
+
+	if _, ok := (&Reader{}).Permissions(); ok {
+		t.Error("Permissions of an unencrypted document reported ok")
+	}
+}
+
+func TestPermissionsRestricted(t *testing.T) {
+	if got := permAll.Restricted(); len(got) != 0 {
+		t.Errorf("permAll.Restricted() = %q, want none", got)
+	}
+	p := permAll &^ (PermPrint | PermPrintHighQuality)
+	want := []string{"printing", "high-quality printing"}
+	if got := p.Restricted(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Restricted() = %q, want %q", got, want)
+	}
+	if !p.Allows(PermCopy|PermModify) || p.Allows(PermCopy|PermPrint) {
+		t.Error("Allows disagrees with the permission bits")
+	}
+}
@@ -35,36 +35,6 @@ func DictInt(dict PDFDict, key string, def int) int {
 	return def
 }
 
-// decodeStreamPredicted decodes dict's stream like decodeStream, then undoes
-// any PNG (Predictor >= 10) or TIFF (Predictor == 2) predictor recorded in its
-// DecodeParms. Cross-reference and object streams commonly apply the PNG "Up"
-// predictor to improve compression of their tabular data.
-func decodeStreamPredicted(dict PDFDict) ([]byte, error) {
-	data, err := DecodeStream(dict)
-	if err != nil {
-		return nil, err
-	}
-
-	parms := StreamDecodeParms(dict)
-	predictor := DictInt(parms, "Predictor", 1)
-	if predictor == 1 {
-		return data, nil
-	}
-
-	columns := DictInt(parms, "Columns", 1)
-	colors := DictInt(parms, "Colors", 1)
-	bpc := DictInt(parms, "BitsPerComponent", 8)
-
-	switch {
-	case predictor == 2:
-		return UndoTIFFPredictor(data, columns, colors, bpc)
-	case predictor >= 10:
-		return UndoPNGPredictor(data, columns, colors, bpc)
-	default:
-		return nil, fmt.Errorf("unsupported predictor %d", predictor)
-	}
-}
-
 // undoPNGPredictor reverses the PNG-style per-row predictor (ISO 32000-1
 // 7.4.4.4 / RFC 2083 §6): each output row is prefixed by a one-byte filter
 // type (None/Sub/Up/Average/Paeth) describing how it was encoded relative to
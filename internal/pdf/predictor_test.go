@@ -79,7 +79,7 @@ func TestStreamDecodeParms(t *testing.T) {
 	}
 }
 
-// TestDecodeStreamPredicted covers decodeStreamPredicted across the no-predictor,
+// TestDecodeStreamPredicted covers DecodeStream's predictor undo across the no-predictor,
 // PNG, TIFF, and unsupported-predictor paths.
 func TestDecodeStreamPredicted(t *testing.T) {
 	flate := func(raw []byte) []byte {
@@ -98,14 +98,14 @@ func TestDecodeStreamPredicted(t *testing.T) {
 	}
 
 	// Predictor 1 (none): decodes verbatim.
-	got, err := decodeStreamPredicted(streamDict([]byte("hello"), nil))
+	got, err := DecodeStream(streamDict([]byte("hello"), nil))
 	if err != nil || string(got) != "hello" {
 		t.Fatalf("no-predictor = %q, %v; want \"hello\"", got, err)
 	}
 
 	// PNG Up predictor (12): two rows, filter byte 2 each.
 	pngRaw := []byte{2, 1, 2, 2, 10, 10}
-	got, err = decodeStreamPredicted(streamDict(pngRaw, map[string]PDFValue{
+	got, err = DecodeStream(streamDict(pngRaw, map[string]PDFValue{
 		"Predictor": PDFInteger(12), "Columns": PDFInteger(2),
 	}))
 	if err != nil {
@@ -116,7 +116,7 @@ func TestDecodeStreamPredicted(t *testing.T) {
 	}
 
 	// TIFF predictor (2).
-	got, err = decodeStreamPredicted(streamDict([]byte{1, 2, 3}, map[string]PDFValue{
+	got, err = DecodeStream(streamDict([]byte{1, 2, 3}, map[string]PDFValue{
 		"Predictor": PDFInteger(2), "Columns": PDFInteger(3),
 	}))
 	if err != nil || !bytes.Equal(got, []byte{1, 3, 6}) {
@@ -124,7 +124,7 @@ func TestDecodeStreamPredicted(t *testing.T) {
 	}
 
 	// Unsupported predictor value.
-	if _, err := decodeStreamPredicted(streamDict([]byte("x"), map[string]PDFValue{
+	if _, err := DecodeStream(streamDict([]byte("x"), map[string]PDFValue{
 		"Predictor": PDFInteger(5),
 	})); err == nil {
 		t.Error("expected error for an unsupported predictor")
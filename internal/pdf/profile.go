@@ -9,12 +9,140 @@ type LevelType string
 
 const (
 	Undefined LevelType = "undefined"
+	A_1A      LevelType = "A-1a"
 	A_1B      LevelType = "A-1b"
+	A_2B      LevelType = "A-2b"
+	A_2U      LevelType = "A-2u"
+	A_3B      LevelType = "A-3b"
+	A_3U      LevelType = "A-3u"
+	// A_4 is PDF/A-4, which has no conformance level; A_4E and A_4F are its
+	// engineering (4e) and embedded-file (4f) variants.
+	A_4  LevelType = "A-4"
+	A_4E LevelType = "A-4e"
+	A_4F LevelType = "A-4f"
+	// UA_1 is PDF/UA-1 (ISO 14289-1), the accessibility standard. It is not
+	// a PDF/A level: Part is 0, and its profile checks the ISO 14289-1 rules
+	// and the object model only.
+	UA_1 LevelType = "UA-1"
+	// X_1A and X_4 are PDF/X-1a:2003 (ISO 15930-4) and PDF/X-4 (ISO
+	// 15930-7), the print-production exchange standards. Like UA_1 they are
+	// not PDF/A levels.
+	X_1A LevelType = "X-1a"
+	X_4  LevelType = "X-4"
 	// ObjectModel is a reporting-only level for the generic ISO 32000
 	// object-model checks (see ObjectModelOnly), independent of any PDF/A level.
 	ObjectModel LevelType = "ObjectModel"
 )
 
+// Spec returns the standard whose checks apply at level l: the ISO 19005
+// part for a PDF/A level, SpecPDFUA1 for UA_1, the ISO 15930 part for a
+// PDF/X level, SpecPDF for ObjectModel, ""
+// for Undefined.
+func (l LevelType) Spec() Spec {
+	switch l {
+	case A_1A, A_1B:
+		return SpecPDFA1
+	case A_2B, A_2U:
+		return SpecPDFA2
+	case A_3B, A_3U:
+		return SpecPDFA3
+	case A_4, A_4E, A_4F:
+		return SpecPDFA4
+	case UA_1:
+		return SpecPDFUA1
+	case X_1A:
+		return SpecPDFX1A
+	case X_4:
+		return SpecPDFX4
+	case ObjectModel:
+		return SpecPDF
+	}
+	return ""
+}
+
+// Part returns the ISO 19005 part number of level l (1 for A-1a/b, 2 for
+// A-2b/u, 3 for A-3b/u, 4 for A-4 and its variants), or 0 for a level that
+// is not a PDF/A level.
+func (l LevelType) Part() int {
+	switch l.Spec() {
+	case SpecPDFA1:
+		return 1
+	case SpecPDFA2:
+		return 2
+	case SpecPDFA3:
+		return 3
+	case SpecPDFA4:
+		return 4
+	}
+	return 0
+}
+
+// Conformance returns the pdfaid:conformance value a file conforming to
+// level l declares: "A", "B" or "U" for the lettered levels, "E" or "F" for the
+// PDF/A-4 variants, and "" for plain PDF/A-4 (which declares none) or a
+// level that is not a PDF/A level.
+func (l LevelType) Conformance() string {
+	switch l {
+	case A_1A:
+		return "A"
+	case A_1B, A_2B, A_3B:
+		return "B"
+	case A_2U, A_3U:
+		return "U"
+	case A_4E:
+		return "E"
+	case A_4F:
+		return "F"
+	}
+	return ""
+}
+
+// AllowsAssociatedFiles reports whether level l permits embedded files of
+// any format, provided they are associated files (PDF/A-3 and PDF/A-4f).
+// The other parts admit only embedded PDF/A files, if any.
+func (l LevelType) AllowsAssociatedFiles() bool {
+	return l == A_3B || l == A_3U || l == A_4F
+}
+
+// RequiresLogicalStructure reports whether level l is an accessible level
+// (level A), which requires a tagged PDF: a structure tree whose types map to
+// standard types, valid Lang entries and alternate descriptions.
+func (l LevelType) RequiresLogicalStructure() bool {
+	return l == A_1A
+}
+
+// RequiresUnicode reports whether level l requires every font to map its
+// character codes to Unicode (ISO 19005-1 6.3.8 at level A; ISO 19005-2
+// 6.2.11.7 at level U and its part 3 equivalent; every PDF/A-4 variant).
+func (l LevelType) RequiresUnicode() bool {
+	switch l {
+	case A_1A, A_2U, A_3U, A_4, A_4E, A_4F:
+		return true
+	}
+	return false
+}
+
+// OutputIntentSubtype returns the output intent subtype (S) whose
+// destination profile governs device colour at level l: GTS_PDFX for the
+// PDF/X levels, else GTS_PDFA1.
+func (l LevelType) OutputIntentSubtype() string {
+	if l == X_1A || l == X_4 {
+		return "GTS_PDFX"
+	}
+	return "GTS_PDFA1"
+}
+
+// VerifyOnly reports whether level l can only be verified, not converted
+// to: PDF/UA-1, whose structure tree no fixer can author, and the PDF/X
+// levels, whose output intent and page boxes are print-job decisions.
+func (l LevelType) VerifyOnly() bool {
+	switch l {
+	case UA_1, X_1A, X_4:
+		return true
+	}
+	return false
+}
+
 // Profile is a mutable set of enabled PDF/A checks for a conformance level,
 // used by VerifyProfile. Mutators (Clear, AddCheck, RemoveCheck) return a new
 // *Profile, leaving the receiver unchanged.
@@ -33,6 +161,52 @@ type Profile struct {
 	// are never drawn are silently ignored, matching veraPDF's interpretation.
 	// Legacy_1B keeps this false so every referenced non-embedded font is flagged.
 	SkipUnusedSimpleFonts bool
+
+	// ObjectCacheLimit, when positive, verifies a PDF/A or object-model
+	// profile a page at a time instead of resolving the whole object graph
+	// up front: objects are parsed on demand into a least-recently-used
+	// cache of at most this many objects, so peak memory follows the
+	// largest page rather than the file. Findings are the same, though an
+	// object shared between pages may be reported against another page.
+	// The PDF/UA, PDF/X and Factur-X rules still resolve the whole graph.
+	// Conversion and optimization ignore it: they resolve the whole graph
+	// up front and rewrite it in memory, so their peak memory follows the
+	// file however it is set.
+	ObjectCacheLimit int
+
+	// Limits caps the resources verifying or converting a document under
+	// this profile may use; exceeding one stops the run with a *LimitError.
+	// The zero value applies DefaultLimits.
+	Limits Limits
+
+	// IncrementalSave, when true, has conversion keep the original file's
+	// bytes and append an incremental update holding only the objects it
+	// changed, so signatures over the original stay verifiable. Objects it
+	// leaves alone keep their original bytes, byte-level defects included.
+	// Converting an encrypted document with it fails with an error, since
+	// conversion writes decrypted output that an update cannot carry. A
+	// PDF/A-4 target, whose file header shall declare PDF 2.0, gets a full
+	// rewrite if the original's declares an earlier version, which
+	// ConvertResult.FullRewrite reports.
+	IncrementalSave bool
+
+	// Linearize, when true, has conversion write a linearized file, whose
+	// first page a viewer fetching it by HTTP range requests can display
+	// before the rest arrives. Such a file uses no object streams. It is
+	// ignored with IncrementalSave, whose update cannot reorder the file.
+	Linearize bool
+
+	// ObjectStreams, when true, has conversion to the object model (PDF)
+	// pack every object that is not a stream into object streams behind a
+	// cross-reference stream, declaring PDF 1.5, which introduced them.
+	// PDF/A-2 and later output always does; PDF/A-1, based on PDF 1.4, may
+	// not, so PDF/A levels ignore it, as do Linearize and IncrementalSave.
+	ObjectStreams bool
+
+	// Deduplicate, when true, has conversion merge byte-identical indirect
+	// objects, such as an ICC profile or image embedded once per page, so
+	// the output holds each once. ConvertResult reports what was saved.
+	Deduplicate bool
 }
 
 // PDF is the default profile for generic ISO 32000 object-model checks.
@@ -42,6 +216,40 @@ var PDF *Profile
 // interpretation of the spec. Used by Verify(A_1B).
 var PDFA_1B *Profile
 
+// PDFA_1A is the default PDF/A-1a profile: PDFA_1B plus the level A
+// Unicode mapping and logical structure checks. Used by Verify(A_1A).
+var PDFA_1A *Profile
+
+// PDFA_2B is the default PDF/A-2b profile, tuned like PDFA_1B to veraPDF's
+// interpretation of the spec. Used by Verify(A_2B).
+var PDFA_2B *Profile
+
+// PDFA_3B is the default PDF/A-3b profile, tuned like PDFA_2B. Used by
+// Verify(A_3B).
+var PDFA_3B *Profile
+
+// PDFA_2U and PDFA_3U are the default PDF/A-2u and PDF/A-3u profiles:
+// PDFA_2B and PDFA_3B plus the Unicode mapping checks. Used by Verify(A_2U)
+// and Verify(A_3U).
+var PDFA_2U, PDFA_3U *Profile
+
+// PDFA_4, PDFA_4E and PDFA_4F are the default PDF/A-4 profiles, one per
+// variant, tuned like PDFA_2B. Used by Verify(A_4), Verify(A_4E) and
+// Verify(A_4F).
+var PDFA_4, PDFA_4E, PDFA_4F *Profile
+
+// PDFUA_1 is the default PDF/UA-1 profile. Used by Verify(UA_1).
+var PDFUA_1 *Profile
+
+// PDFX_1A and PDFX_4 are the default PDF/X-1a and PDF/X-4 profiles, tuned
+// like PDFA_2B. Used by Verify(X_1A) and Verify(X_4).
+var PDFX_1A, PDFX_4 *Profile
+
+// FacturX is PDFA_3B plus the Factur-X / ZUGFeRD hybrid invoice checks
+// (Checks.FacturX). Its level is A_3B: a Factur-X invoice is a PDF/A-3
+// file, and converts like one.
+var FacturX *Profile
+
 // Legacy_1B is the strict, fully spec-literal PDF/A-1b profile: every check
 // enabled, every Form XObject checked regardless of reachability. Matches the
 // Isartor suite's interpretation, which is stricter than veraPDF's in places.
@@ -51,26 +259,100 @@ func init() {
 	PDF = ObjectModelOnly()
 	Legacy_1B = NewFullProfile(A_1B)
 
-	// PDFA_1B adjusts the full profile for veraPDF's divergences from the
-	// stricter legacy/Isartor interpretation: unreachable Form XObjects are
+	// PDFA_1A and PDFA_1B adjust the full profile for veraPDF's divergences from
+	// the stricter legacy/Isartor interpretation: unreachable Form XObjects are
 	// out-of-scope (6.2.3.3, 6.2.10); 6.2.7 PostScript XObject checks are
-	// disabled (veraPDF's own corpus intentionally includes one in a pass
-	// file); 6.3.4 simple-font embedding is only required for fonts actually
-	// shown in content (SkipUnusedSimpleFonts), not for fonts in AcroForm /DR.
-	// KeyIntroducedAfterPDF14 is disabled: real-world files carry post-1.4
-	// keys that are purely structural/informational (e.g. FileTrailer's
-	// hybrid-reference XRefStm, Catalog's Extensions) and are ignorable by a
-	// PDF 1.4 reader, but Arlington has no data distinguishing those from
-	// keys that actually change required interpretation -- veraPDF does not
-	// flag them, so this stays Legacy_1B-only (spec-literal) for now.
-	PDFA_1B = NewFullProfile(A_1B)
-	PDFA_1B.SkipUnreachableXObjects = true
-	PDFA_1B.SkipUnusedSimpleFonts = true
-	PDFA_1B = PDFA_1B.RemoveCheck(
-		Checks.Image.FormPostScript,
-		Checks.Image.PostScriptXObject,
+	// disabled (veraPDF's own corpus intentionally includes one in a pass file);
+	// 6.3.4 simple-font embedding is only required for fonts actually shown in
+	// content (SkipUnusedSimpleFonts), not for fonts in AcroForm /DR.
+	// KeyIntroducedAfterPDF14 is disabled: real-world files carry post-1.4 keys
+	// that are purely structural/informational (e.g. FileTrailer's hybrid-
+	// reference XRefStm, Catalog's Extensions) and are ignorable by a PDF 1.4
+	// reader, but Arlington has no data distinguishing those from keys that
+	// actually change required interpretation -- veraPDF does not flag them, so
+	// this stays Legacy_1B-only (spec-literal) for now.
+	newPDFA1 := func(level LevelType) *Profile {
+		p := NewFullProfile(level)
+		p.SkipUnreachableXObjects = true
+		p.SkipUnusedSimpleFonts = true
+		return p.RemoveCheck(
+			Checks.Image.FormPostScript,
+			Checks.Image.PostScriptXObject,
+			Checks.ObjectModel.KeyIntroducedAfterPDF14,
+		)
+	}
+	PDFA_1A = newPDFA1(A_1A)
+
+	// PDFA_1B additionally drops the level A checks (6.3.8, 6.8), which the
+	// verifier only runs at level A.
+	PDFA_1B = newPDFA1(A_1B).RemoveCheck(
+		Checks.Font.ToUnicodeMissing,
+		Checks.LogicalStructure.TaggedMarkInfo,
+		Checks.LogicalStructure.StructTreeRoot,
+		Checks.LogicalStructure.RoleMapStandardType,
+		Checks.LogicalStructure.RoleMapCircular,
+		Checks.LogicalStructure.LangIdentifier,
+		Checks.LogicalStructure.AlternateDescription,
+	)
+
+	// PDFA_2B and PDFA_2U take the same lenient reachability and font-usage
+	// stance as PDFA_1B, and KeyIntroducedAfterPDF17 stays off for the same
+	// reason KeyIntroducedAfterPDF14 does in PDFA_1B. PDFA_3B and PDFA_3U
+	// mirror them; KeyIntroducedAfterPDF17 matters more there, as AF and
+	// AFRelationship, which part 3 requires, are PDF 2.0 keys.
+	newPDFA23 := func(level LevelType) *Profile {
+		p := NewFullProfile(level)
+		p.SkipUnreachableXObjects = true
+		p.SkipUnusedSimpleFonts = true
+		return p.RemoveCheck(
+			Checks.ObjectModel.KeyIntroducedAfterPDF14,
+			Checks.ObjectModel.KeyIntroducedAfterPDF17,
+		)
+	}
+	PDFA_2U, PDFA_3U = newPDFA23(A_2U), newPDFA23(A_3U)
+
+	// Level B does not require Unicode mappings (6.2.11.7 applies to levels
+	// A and U only).
+	PDFA_2B = newPDFA23(A_2B).RemoveCheck(
+		Checks.PDFA2.Font.ToUnicodeMissing,
+		Checks.PDFA2.Font.UnmappableCharCode,
+		Checks.PDFA2.Font.InvalidUnicodeValue,
+	)
+	PDFA_3B = newPDFA23(A_3B).RemoveCheck(
+		Checks.PDFA3.Font.ToUnicodeMissing,
+		Checks.PDFA3.Font.UnmappableCharCode,
+		Checks.PDFA3.Font.InvalidUnicodeValue,
+	)
+
+	// The PDF/A-4 profiles take PDFA_2B's reachability and font-usage
+	// stance. Part 4 has no level B, so its Unicode mapping rule stays on.
+	// It validates against the PDF 2.0 object model, where no key is
+	// introduced too late, so the KeyIntroducedAfter checks are dropped.
+	newPDFA4 := func(level LevelType) *Profile {
+		p := NewFullProfile(level)
+		p.SkipUnreachableXObjects = true
+		p.SkipUnusedSimpleFonts = true
+		return p.RemoveCheck(
+			Checks.ObjectModel.KeyIntroducedAfterPDF14,
+			Checks.ObjectModel.KeyIntroducedAfterPDF17,
+		)
+	}
+	PDFA_4, PDFA_4E, PDFA_4F = newPDFA4(A_4), newPDFA4(A_4E), newPDFA4(A_4F)
+
+	// PDFUA_1 drops the KeyIntroducedAfter checks for the reason PDFA_2B
+	// does.
+	PDFUA_1 = NewFullProfile(UA_1).RemoveCheck(
 		Checks.ObjectModel.KeyIntroducedAfterPDF14,
+		Checks.ObjectModel.KeyIntroducedAfterPDF17,
 	)
+	// PDF/X-4 is based on PDF 1.6, so like PDFA_2B it keeps neither
+	// KeyIntroducedAfter check; PDF/X-1a is based on PDF 1.4, like PDF/A-1.
+	PDFX_1A = NewFullProfile(X_1A)
+	PDFX_1A.SkipUnreachableXObjects = true
+	PDFX_1A.SkipUnusedSimpleFonts = true
+	PDFX_4 = newPDFA23(X_4)
+
+	FacturX = PDFA_3B.AddCheck(ChecksForSpec(SpecFacturX)...)
 }
 
 // NewProfile returns an empty profile for the given conformance level.
@@ -94,14 +376,19 @@ func ObjectModelOnly() *Profile {
 	)
 }
 
+// NewFullProfile returns a profile for level enabling every check that
+// applies at it: the checks numbered by level's spec plus the objmodel checks.
 func NewFullProfile(level LevelType) *Profile {
 	all := AllChecks()
 	p := &Profile{
 		Level:   level,
 		enabled: make(map[int]bool, len(all)),
 	}
+	spec := level.Spec()
 	for _, c := range all {
-		p.enabled[c.ID()] = true
+		if c.Spec() == spec || c.Spec() == SpecPDF {
+			p.enabled[c.ID()] = true
+		}
 	}
 	return p
 }
@@ -112,20 +399,33 @@ func (p *Profile) Clone() *Profile {
 		enabled:                 make(map[int]bool, len(p.enabled)),
 		SkipUnreachableXObjects: p.SkipUnreachableXObjects,
 		SkipUnusedSimpleFonts:   p.SkipUnusedSimpleFonts,
+		ObjectCacheLimit:        p.ObjectCacheLimit,
+		Limits:                  p.Limits,
+		IncrementalSave:         p.IncrementalSave,
+		Linearize:               p.Linearize,
+		ObjectStreams:           p.ObjectStreams,
+		Deduplicate:             p.Deduplicate,
 	}
 	maps.Copy(out.enabled, p.enabled)
 	return out
 }
 
 // Clear returns a new profile with the same conformance level but no checks
-// enabled. Behavioral flags (SkipUnreachableXObjects, SkipUnusedSimpleFonts)
-// are preserved.
+// enabled. Behavioral flags (SkipUnreachableXObjects, SkipUnusedSimpleFonts,
+// ObjectCacheLimit, Limits, IncrementalSave, Linearize, ObjectStreams,
+// Deduplicate) are preserved.
 func (p *Profile) Clear() *Profile {
 	return &Profile{
 		Level:                   p.Level,
 		enabled:                 make(map[int]bool),
 		SkipUnreachableXObjects: p.SkipUnreachableXObjects,
 		SkipUnusedSimpleFonts:   p.SkipUnusedSimpleFonts,
+		ObjectCacheLimit:        p.ObjectCacheLimit,
+		Limits:                  p.Limits,
+		IncrementalSave:         p.IncrementalSave,
+		Linearize:               p.Linearize,
+		ObjectStreams:           p.ObjectStreams,
+		Deduplicate:             p.Deduplicate,
 	}
 }
 
@@ -160,6 +460,17 @@ func (p *Profile) Checks() []Check {
 	return out
 }
 
+// EnablesSpec reports whether any check numbered by spec is enabled, e.g.
+// whether a PDF/A-3 profile asks for the Factur-X invoice checks.
+func (p *Profile) EnablesSpec(spec Spec) bool {
+	for _, c := range ChecksForSpec(spec) {
+		if p.enabled[c.ID()] {
+			return true
+		}
+	}
+	return false
+}
+
 // Has reports whether check c is currently enabled in this profile.
 func (p *Profile) Has(c Check) bool {
 	return p.enabled[c.ID()]
@@ -177,9 +488,24 @@ func (p *Profile) OnlyObjectModelChecks() bool {
 	return true
 }
 
+// Allows reports whether the check registered for (clause, subclause) in the
+// numbering of p's level is enabled. A pair absent from the catalog is always
+// allowed.
 func (p *Profile) Allows(clause string, subclause int) bool {
-	c, inCatalog := CheckByClause(clause, subclause)
+	c, inCatalog := CheckBySpecClause(p.Level.Spec(), clause, subclause)
 	if !inCatalog {
+		c, inCatalog = CheckByClause(clause, subclause)
+	}
+	if !inCatalog {
+		return true
+	}
+	return p.enabled[c.ID()]
+}
+
+// AllowsCheck reports whether c is enabled in p. A check that was never
+// registered (zero ID) is always allowed, mirroring Allows.
+func (p *Profile) AllowsCheck(c Check) bool {
+	if c.ID() == 0 {
 		return true
 	}
 	return p.enabled[c.ID()]
@@ -20,6 +20,7 @@ func TestProfileClearAddCheckHas(t *testing.T) {
 
 	full := NewFullProfile(A_1B)
 	full.SkipUnreachableXObjects = true
+	full.ObjectCacheLimit = 1000
 	if !full.Has(c) {
 		t.Fatal("full profile should have every check enabled")
 	}
@@ -28,7 +29,7 @@ func TestProfileClearAddCheckHas(t *testing.T) {
 	if cleared.Has(c) {
 		t.Error("Clear() should disable all checks")
 	}
-	if !cleared.SkipUnreachableXObjects {
+	if !cleared.SkipUnreachableXObjects || cleared.ObjectCacheLimit != 1000 {
 		t.Error("Clear() should preserve behavioral flags")
 	}
 	if len(cleared.Checks()) != 0 {
@@ -120,3 +121,225 @@ func TestOnlyObjectModelChecks(t *testing.T) {
 		t.Error("adding a PDF/A check must clear OnlyObjectModelChecks")
 	}
 }
+
+func TestLevelSpecAndPart(t *testing.T) {
+	for _, tc := range []struct {
+		level LevelType
+		spec  Spec
+		part  int
+	}{
+		{A_1B, SpecPDFA1, 1},
+		{A_2B, SpecPDFA2, 2},
+		{A_3B, SpecPDFA3, 3},
+		{A_4, SpecPDFA4, 4},
+		{A_4E, SpecPDFA4, 4},
+		{A_4F, SpecPDFA4, 4},
+		{UA_1, SpecPDFUA1, 0},
+		{X_1A, SpecPDFX1A, 0},
+		{X_4, SpecPDFX4, 0},
+		{ObjectModel, SpecPDF, 0},
+		{Undefined, "", 0},
+	} {
+		if got := tc.level.Spec(); got != tc.spec {
+			t.Errorf("%s.Spec() = %q, want %q", tc.level, got, tc.spec)
+		}
+		if got := tc.level.Part(); got != tc.part {
+			t.Errorf("%s.Part() = %d, want %d", tc.level, got, tc.part)
+		}
+	}
+}
+
+// TestFullProfileIsLevelScoped checks NewFullProfile enables only the checks
+// numbered by the level's own spec, plus the objmodel checks.
+func TestFullProfileIsLevelScoped(t *testing.T) {
+	a2 := NewFullProfile(A_2B)
+	if a2.Has(Checks.Structure.ObjectFraming) {
+		t.Error("A-2b full profile enables a PDF/A-1 check")
+	}
+	if !a2.Has(Checks.PDFA2.Structure.ObjectFraming) || !a2.Has(Checks.ObjectModel.MissingRequiredKey) {
+		t.Error("A-2b full profile is missing a PDF/A-2 or objmodel check")
+	}
+	if NewFullProfile(A_1B).Has(Checks.PDFA2.Transparency.BlendMode) {
+		t.Error("A-1b full profile enables a PDF/A-2 check")
+	}
+}
+
+func TestPDFA2BProfile(t *testing.T) {
+	if PDFA_2B.Level != A_2B {
+		t.Errorf("PDFA_2B.Level = %s", PDFA_2B.Level)
+	}
+	if !PDFA_2B.SkipUnreachableXObjects || !PDFA_2B.SkipUnusedSimpleFonts {
+		t.Error("PDFA_2B should share PDFA_1B's reachability and font-usage flags")
+	}
+	if PDFA_2B.Has(Checks.PDFA2.Font.ToUnicodeMissing) {
+		t.Error("PDFA_2B should not require ToUnicode (levels A and U only)")
+	}
+	// 6.1.8/1 means NameNotUTF8 in A-2 numbering, not ObjectFraming.
+	p := PDFA_2B.RemoveCheck(Checks.PDFA2.Structure.NameNotUTF8)
+	if p.Allows("6.1.8", 1) {
+		t.Error("Allows(6.1.8, 1) should resolve against PDF/A-2 numbering")
+	}
+}
+
+func TestPDFA3BProfile(t *testing.T) {
+	if PDFA_3B.Level != A_3B || !PDFA_3B.SkipUnreachableXObjects || !PDFA_3B.SkipUnusedSimpleFonts {
+		t.Errorf("PDFA_3B = %v, want PDFA_2B's flags at level A-3b", PDFA_3B)
+	}
+	if !PDFA_3B.Has(Checks.PDFA3.EmbeddedFile.AFRelationship) || PDFA_3B.Has(Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA) {
+		t.Error("PDFA_3B should enable the part 3 embedded-file rules only")
+	}
+	if PDFA_3B.Has(Checks.ObjectModel.KeyIntroducedAfterPDF17) {
+		t.Error("PDFA_3B should not flag the PDF 2.0 AF keys part 3 requires")
+	}
+}
+
+func TestPDFA4Profiles(t *testing.T) {
+	for _, tc := range []struct {
+		p           *Profile
+		level       LevelType
+		conformance string
+		attachments bool
+	}{
+		{PDFA_4, A_4, "", false},
+		{PDFA_4E, A_4E, "E", false},
+		{PDFA_4F, A_4F, "F", true},
+	} {
+		if tc.p.Level != tc.level || !tc.p.SkipUnreachableXObjects || !tc.p.SkipUnusedSimpleFonts {
+			t.Errorf("%v: want PDFA_2B's flags at level %s", tc.p, tc.level)
+		}
+		if !tc.p.Has(Checks.PDFA4.Metadata.PDFARevision) || !tc.p.Has(Checks.PDFA4.Font.ToUnicodeMissing) {
+			t.Errorf("%s: part 4 rules missing", tc.level)
+		}
+		if tc.p.Has(Checks.ObjectModel.KeyIntroducedAfterPDF17) {
+			t.Errorf("%s: KeyIntroducedAfterPDF17 should be off on a PDF 2.0 base", tc.level)
+		}
+		if got := tc.level.Conformance(); got != tc.conformance {
+			t.Errorf("%s.Conformance() = %q, want %q", tc.level, got, tc.conformance)
+		}
+		if got := tc.level.AllowsAssociatedFiles(); got != tc.attachments {
+			t.Errorf("%s.AllowsAssociatedFiles() = %v, want %v", tc.level, got, tc.attachments)
+		}
+	}
+	if A_2B.AllowsAssociatedFiles() || !A_3B.AllowsAssociatedFiles() || A_1B.Conformance() != "B" {
+		t.Error("A-2b/A-3b/A-1b level properties are wrong")
+	}
+}
+
+func TestAllowsCheck(t *testing.T) {
+	p := NewProfile(A_2B).AddCheck(Checks.PDFA2.Transparency.BlendMode)
+	if !p.AllowsCheck(Checks.PDFA2.Transparency.BlendMode) {
+		t.Error("AllowsCheck should report an enabled check")
+	}
+	if p.AllowsCheck(Checks.Transparency.BlendMode) {
+		t.Error("AllowsCheck should not conflate same-named checks of different specs")
+	}
+	if !p.AllowsCheck(Check{}) {
+		t.Error("AllowsCheck should allow an unregistered check")
+	}
+}
+
+func TestPDFA1AProfile(t *testing.T) {
+	if PDFA_1A.Level != A_1A || !PDFA_1A.SkipUnreachableXObjects || !PDFA_1A.SkipUnusedSimpleFonts {
+		t.Errorf("PDFA_1A = %v, want PDFA_1B's flags at level A-1a", PDFA_1A)
+	}
+	levelA := []Check{Checks.Font.ToUnicodeMissing, Checks.LogicalStructure.TaggedMarkInfo, Checks.LogicalStructure.AlternateDescription}
+	for _, c := range levelA {
+		if !PDFA_1A.Has(c) {
+			t.Errorf("PDFA_1A should enable %s", c.Name())
+		}
+		if PDFA_1B.Has(c) {
+			t.Errorf("PDFA_1B should not enable the level A check %s", c.Name())
+		}
+	}
+	if A_1A.Spec() != SpecPDFA1 || A_1A.Part() != 1 || A_1A.Conformance() != "A" {
+		t.Errorf("A_1A = %s/%d/%q, want ISO 19005-1/1/A", A_1A.Spec(), A_1A.Part(), A_1A.Conformance())
+	}
+	if !A_1A.RequiresLogicalStructure() || A_1B.RequiresLogicalStructure() || A_4.RequiresLogicalStructure() {
+		t.Error("only A-1a requires logical structure")
+	}
+	if !A_1A.RequiresUnicode() || !A_4F.RequiresUnicode() || A_1B.RequiresUnicode() || A_2B.RequiresUnicode() {
+		t.Error("A-1a and PDF/A-4 require Unicode mappings; the level B parts do not")
+	}
+}
+
+func TestPDFAUProfiles(t *testing.T) {
+	for _, tc := range []struct {
+		p, b  *Profile
+		level LevelType
+		spec  Spec
+		part  int
+		font  pdfa2FontChecks
+	}{
+		{PDFA_2U, PDFA_2B, A_2U, SpecPDFA2, 2, Checks.PDFA2.Font},
+		{PDFA_3U, PDFA_3B, A_3U, SpecPDFA3, 3, Checks.PDFA3.Font},
+	} {
+		if tc.p.Level != tc.level || !tc.p.SkipUnreachableXObjects || !tc.p.SkipUnusedSimpleFonts {
+			t.Errorf("%v: want PDFA_2B's flags at level %s", tc.p, tc.level)
+		}
+		if tc.level.Spec() != tc.spec || tc.level.Part() != tc.part || tc.level.Conformance() != "U" || !tc.level.RequiresUnicode() {
+			t.Errorf("%s = %s/%d/%q, want %s/%d/U requiring Unicode", tc.level, tc.level.Spec(), tc.level.Part(), tc.level.Conformance(), tc.spec, tc.part)
+		}
+		for _, c := range []Check{tc.font.ToUnicodeMissing, tc.font.UnmappableCharCode, tc.font.InvalidUnicodeValue} {
+			if !tc.p.Has(c) || tc.b.Has(c) {
+				t.Errorf("%s should be enabled at level U only", c.Name())
+			}
+		}
+		if tc.p.Has(Checks.ObjectModel.KeyIntroducedAfterPDF17) {
+			t.Errorf("%s should not flag keys introduced after PDF 1.7", tc.level)
+		}
+	}
+	if A_2U.AllowsAssociatedFiles() || !A_3U.AllowsAssociatedFiles() {
+		t.Error("A-3u, like A-3b, permits associated files; A-2u does not")
+	}
+}
+
+func TestPDFUA1Profile(t *testing.T) {
+	if PDFUA_1.Level != UA_1 {
+		t.Errorf("PDFUA_1.Level = %s, want %s", PDFUA_1.Level, UA_1)
+	}
+	if !PDFUA_1.Has(Checks.PDFUA1.Tagged.ContentNotTagged) || !PDFUA_1.Has(Checks.ObjectModel.MissingRequiredKey) {
+		t.Error("PDFUA_1 lacks its own or the object-model checks")
+	}
+	if PDFUA_1.Has(Checks.LogicalStructure.TaggedMarkInfo) || PDFUA_1.Has(Checks.PDFA2.Font.ToUnicodeMissing) {
+		t.Error("PDFUA_1 enables PDF/A checks")
+	}
+	if UA_1.RequiresLogicalStructure() || UA_1.Conformance() != "" {
+		t.Error("UA_1 treated as a PDF/A level")
+	}
+}
+
+func TestPDFXProfiles(t *testing.T) {
+	for _, tc := range []struct {
+		p     *Profile
+		level LevelType
+		own   Check
+	}{
+		{PDFX_1A, X_1A, Checks.PDFX1A.Page.TrimOrArtBox},
+		{PDFX_4, X_4, Checks.PDFX4.Page.TrimOrArtBox},
+	} {
+		if tc.p.Level != tc.level {
+			t.Errorf("profile level = %s, want %s", tc.p.Level, tc.level)
+		}
+		if !tc.p.Has(tc.own) || tc.p.Has(Checks.Colour.DeviceColourSpaceUsage) {
+			t.Errorf("%s lacks its own checks or enables PDF/A ones", tc.level)
+		}
+		if !tc.level.VerifyOnly() || tc.level.OutputIntentSubtype() != "GTS_PDFX" {
+			t.Errorf("%s: VerifyOnly = %v, OutputIntentSubtype = %q", tc.level, tc.level.VerifyOnly(), tc.level.OutputIntentSubtype())
+		}
+	}
+	if A_2B.VerifyOnly() || A_2B.OutputIntentSubtype() != "GTS_PDFA1" || !UA_1.VerifyOnly() {
+		t.Error("VerifyOnly or OutputIntentSubtype misclassifies a non-PDF/X level")
+	}
+}
+
+func TestFacturXProfile(t *testing.T) {
+	if FacturX.Level != A_3B {
+		t.Errorf("FacturX level = %s, want %s", FacturX.Level, A_3B)
+	}
+	if !FacturX.Has(Checks.FacturX.Invoice.InvoiceMissing) || !FacturX.Has(Checks.PDFA3.Metadata.MetadataMissing) {
+		t.Error("FacturX lacks the invoice or the PDF/A-3 checks")
+	}
+	if !FacturX.EnablesSpec(SpecFacturX) || PDFA_3B.EnablesSpec(SpecFacturX) {
+		t.Error("EnablesSpec(SpecFacturX) misreports FacturX or PDFA_3B")
+	}
+}
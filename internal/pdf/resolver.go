@@ -22,6 +22,7 @@ func (d *Reader) resolveShallow(obj PDFValue) (PDFValue, error) {
 // from disk at most once per object number.
 func (d *Reader) ResolveReference(ref PDFRef) (PDFValue, error) {
 	if cached, ok := d.objCache[ref.ObjNum]; ok {
+		d.touchObject(ref.ObjNum)
 		return cached, nil
 	}
 
@@ -44,6 +45,7 @@ func (d *Reader) ResolveReference(ref PDFRef) (PDFValue, error) {
 		d.objCache = map[int]PDFValue{}
 	}
 	d.objCache[ref.ObjNum] = v
+	d.trackObject(ref.ObjNum)
 	return v, nil
 }
 
@@ -72,8 +74,18 @@ func (d *Reader) parseReference(ref PDFRef) (PDFValue, error) {
 }
 
 // parseClassicReference performs the actual disk read and parse for an
-// indirect object stored at a classic byte offset.
+// indirect object stored at a classic byte offset, decrypting it if the
+// document is encrypted.
 func (d *Reader) parseClassicReference(ref PDFRef, offset int64) (PDFValue, error) {
+	v, err := d.parseClassicObject(ref, offset)
+	if err != nil || d.crypt == nil {
+		return v, err
+	}
+	return d.crypt.decryptObject(ref, v)
+}
+
+// parseClassicObject parses the indirect object stored at offset.
+func (d *Reader) parseClassicObject(ref PDFRef, offset int64) (PDFValue, error) {
 	var l *Lexer
 	if d.data != nil {
 		l = NewLexerBytes(d.data, offset)
@@ -0,0 +1,181 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Revision is one revision of an incrementally updated document (ISO
+// 32000-1 7.5.6): the original file, or an update appended to it.
+type Revision struct {
+	// Start and End delimit the bytes the revision added to the file. End
+	// lies just past its %%EOF marker and the end-of-line after it; Start is
+	// the previous revision's End, or 0 for the original.
+	Start, End int64
+	// XRefOffset is the absolute offset of the revision's cross-reference
+	// section, and Trailer its trailer dictionary -- a cross-reference
+	// stream's own dictionary when the section is one.
+	XRefOffset int64
+	Trailer    PDFDict
+	// Added lists the object numbers the revision defines that no earlier
+	// revision did, and Changed those it redefines, both ascending.
+	Added, Changed []int
+}
+
+// xrefSection is one cross-reference section of the /Prev chain: its
+// offset, trailer, the object numbers it lists in use, and the end of the
+// revision it closes.
+type xrefSection struct {
+	offset  int64
+	trailer PDFDict
+	objs    map[int]bool
+	end     int64
+}
+
+// Revisions returns d's revisions, the original document first, by walking
+// the cross-reference sections of the /Prev chain from startxref. A
+// linearized file's first-page section, which the chain reaches before the
+// main section written after it, belongs to the same revision as that
+// section. It fails when the chain cannot be followed, as when a damaged
+// table was rebuilt by scanning the file.
+func (d *Reader) Revisions() ([]Revision, error) {
+	sections, err := d.xrefSections()
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(sections)
+
+	var revs []Revision
+	var objs []map[int]bool
+	for _, s := range sections {
+		if n := len(revs); n > 0 && s.end <= revs[n-1].End {
+			// Written ahead of the revision it chains to: a linearized
+			// first-page section, which startxref points at.
+			revs[n-1].XRefOffset, revs[n-1].Trailer = s.offset, s.trailer
+			for num := range s.objs {
+				objs[n-1][num] = true
+			}
+			continue
+		}
+		var start int64
+		if n := len(revs); n > 0 {
+			start = revs[n-1].End
+		}
+		revs = append(revs, Revision{Start: start, End: s.end, XRefOffset: s.offset, Trailer: s.trailer})
+		objs = append(objs, s.objs)
+	}
+
+	seen := map[int]bool{}
+	for i := range revs {
+		for _, num := range slices.Sorted(maps.Keys(objs[i])) {
+			if seen[num] {
+				revs[i].Changed = append(revs[i].Changed, num)
+			} else {
+				revs[i].Added = append(revs[i].Added, num)
+			}
+			seen[num] = true
+		}
+	}
+	return revs, nil
+}
+
+// OpenRevision opens d as it stood at revision n of Revisions, before any
+// later update was appended: a Reader over the first Revisions()[n].End
+// bytes of the file, decrypted with the password or certificate d was
+// opened with. The Reader reads through d, which must stay open while it is
+// in use; closing it leaves d open.
+func (d *Reader) OpenRevision(n int) (*Reader, error) {
+	revs, err := d.Revisions()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= len(revs) {
+		return nil, fmt.Errorf("revision %d out of range: the document has %d", n, len(revs))
+	}
+	end := revs[n].End
+	var data []byte
+	if d.data != nil {
+		data = d.data[:end:end]
+	}
+	return newDocument(readerAtSource{io.NewSectionReader(d.file, 0, end)}, end, data, nil, d.cred)
+}
+
+// xrefSections returns the cross-reference sections of the /Prev chain,
+// newest first, stopping at a /Prev already visited.
+func (d *Reader) xrefSections() ([]xrefSection, error) {
+	var out []xrefSection
+	visited := map[int64]bool{}
+	offset := d.xrefOffset + d.pdfStart
+	for !visited[offset] {
+		visited[offset] = true
+		s, err := d.xrefSectionAt(offset)
+		if err != nil && len(out) == 0 && d.pdfStart != 0 {
+			// As in initializeStructure: startxref relative to true byte 0.
+			s, err = d.xrefSectionAt(d.xrefOffset)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cross-reference section at offset %d: %w", offset, err)
+		}
+		out = append(out, s)
+		prev, ok := s.trailer.Entries["Prev"].(PDFInteger)
+		if !ok {
+			break
+		}
+		offset = int64(prev) + d.pdfStart
+	}
+	return out, nil
+}
+
+// xrefSectionAt parses the classic table or cross-reference stream at
+// offset on its own, into a scratch Reader over d's bytes, so d's merged
+// object table is left alone. A classic section's hybrid /XRefStm entries
+// count as its own.
+func (d *Reader) xrefSectionAt(offset int64) (xrefSection, error) {
+	s := &Reader{file: d.file, size: d.size, data: d.data, pdfStart: d.pdfStart, xrefTable: map[int]int64{}}
+	trailer, err := s.ParseXRefSectionAt(offset, false)
+	if err == nil {
+		s.mergeHybridXRefStream(trailer)
+	} else if trailer, err = s.tryParseXRefStream(offset, false); err != nil {
+		return xrefSection{}, err
+	}
+	objs := make(map[int]bool, len(s.xrefTable)+len(s.compressedXref))
+	for num := range s.xrefTable {
+		objs[num] = true
+	}
+	for num := range s.compressedXref {
+		objs[num] = true
+	}
+	return xrefSection{offset: offset, trailer: trailer, objs: objs, end: d.revisionEnd(offset)}, nil
+}
+
+// revisionEnd returns the offset just past the first %%EOF marker at or
+// after offset and the end-of-line following it, or the file size if there
+// is none.
+func (d *Reader) revisionEnd(offset int64) int64 {
+	const chunk = 4096
+	marker := []byte("%%EOF")
+	// Reading len(marker)-1 bytes past each chunk finds a marker straddling
+	// two chunks.
+	buf := make([]byte, chunk+len(marker)-1)
+	for pos := max(offset, 0); pos < d.size; pos += chunk {
+		n, _ := d.file.ReadAt(buf, pos)
+		i := bytes.Index(buf[:n], marker)
+		if i < 0 {
+			continue
+		}
+		end := pos + int64(i+len(marker))
+		var eol [2]byte
+		m, _ := d.file.ReadAt(eol[:], end)
+		switch {
+		case m == 2 && string(eol[:]) == "\r\n":
+			end += 2
+		case m >= 1 && (eol[0] == '\r' || eol[0] == '\n'):
+			end++
+		}
+		return end
+	}
+	return d.size
+}
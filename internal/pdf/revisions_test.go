@@ -0,0 +1,202 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// appendUpdate appends an incremental update to file: the given objects, a
+// classic xref section listing them, and a trailer chaining to prevXRef.
+func appendUpdate(file string, objs map[int]string, trailer string, prevXRef int) (string, int) {
+	nums := make([]int, 0, len(objs))
+	for num := range objs {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	offsets := map[int]int{}
+	for _, num := range nums {
+		offsets[num] = len(file)
+		file += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", num, objs[num])
+	}
+	xref := len(file)
+	file += "xref\n"
+	for _, num := range nums {
+		file += fmt.Sprintf("%d 1\n%010d 00000 n \n", num, offsets[num])
+	}
+	file += fmt.Sprintf("trailer\n<< %s /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", trailer, prevXRef, xref)
+	return file, xref
+}
+
+// revisionsTestPDF returns a one-page document and an update to it that
+// gives the page contents and a new Info dictionary, with the original's
+// length.
+func revisionsTestPDF() (string, int) {
+	body, xref := buildClassicXRefBody([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	})
+	original := body + fmt.Sprintf("trailer\n<< /Size 4 /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	updated, _ := appendUpdate(original, map[int]string{
+		3: "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		4: "<< /Length 0 >>\nstream\n\nendstream",
+		5: "<< /Title (Signed) >>",
+	}, "/Size 6 /Root 1 0 R /Info 5 0 R", xref)
+	return updated, len(original)
+}
+
+// TestRevisions confirms an incremental update is reported as a second
+// revision with its byte range and its changed and added objects.
+func TestRevisions(t *testing.T) {
+	file, originalLen := revisionsTestPDF()
+	d, err := OpenBytes([]byte(file))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer d.Close()
+
+	revs, err := d.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("Revisions returned %d revisions, want 2", len(revs))
+	}
+	orig, upd := revs[0], revs[1]
+	if orig.Start != 0 || orig.End != int64(originalLen) || upd.Start != orig.End || upd.End != int64(len(file)) {
+		t.Errorf("byte ranges = [%d,%d) [%d,%d), want [0,%d) [%d,%d)",
+			orig.Start, orig.End, upd.Start, upd.End, originalLen, originalLen, len(file))
+	}
+	if !slices.Equal(orig.Added, []int{1, 2, 3}) || orig.Changed != nil {
+		t.Errorf("original: Added %v Changed %v, want [1 2 3] []", orig.Added, orig.Changed)
+	}
+	if !slices.Equal(upd.Added, []int{4, 5}) || !slices.Equal(upd.Changed, []int{3}) {
+		t.Errorf("update: Added %v Changed %v, want [4 5] [3]", upd.Added, upd.Changed)
+	}
+	if upd.XRefOffset != d.XRefOffset() || upd.Trailer.Entries["Prev"] != PDFInteger(orig.XRefOffset) {
+		t.Errorf("update XRefOffset %d Prev %v, want %d and %d",
+			upd.XRefOffset, upd.Trailer.Entries["Prev"], d.XRefOffset(), orig.XRefOffset)
+	}
+}
+
+// TestOpenRevision confirms the original revision opens without the
+// update's objects, from bytes and through an io.ReaderAt, and that a
+// revision past the last is an error.
+func TestOpenRevision(t *testing.T) {
+	file, originalLen := revisionsTestPDF()
+	mem, err := OpenBytes([]byte(file))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer mem.Close()
+	ranged, err := OpenReaderAt(&rangeReader{data: []byte(file)}, int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenReaderAt: %v", err)
+	}
+	defer ranged.Close()
+	for _, d := range []*Reader{mem, ranged} {
+		openOriginalRevision(t, d, originalLen)
+	}
+}
+
+// openOriginalRevision checks d's revision 0 of revisionsTestPDF.
+func openOriginalRevision(t *testing.T, d *Reader, originalLen int) {
+	t.Helper()
+	orig, err := d.OpenRevision(0)
+	if err != nil {
+		t.Fatalf("OpenRevision(0): %v", err)
+	}
+	defer orig.Close()
+	if orig.Size() != int64(originalLen) {
+		t.Errorf("revision 0 Size = %d, want %d", orig.Size(), originalLen)
+	}
+	page, err := orig.ResolveReference(PDFRef{ObjNum: 3})
+	if err != nil {
+		t.Fatalf("ResolveReference(3): %v", err)
+	}
+	if _, ok := page.(PDFDict).Entries["Contents"]; ok {
+		t.Error("revision 0 page has the update's Contents")
+	}
+	if _, ok := orig.XRefTable()[4]; ok {
+		t.Error("revision 0 lists object 4, added by the update")
+	}
+	if n, err := orig.GetPageCount(); err != nil || n != 1 {
+		t.Errorf("revision 0 GetPageCount = %d, %v; want 1", n, err)
+	}
+	if _, err := d.OpenRevision(2); err == nil {
+		t.Error("OpenRevision(2) of a two-revision document succeeded")
+	}
+}
+
+// TestRevisionsXRefStreamUpdate confirms an update written as a
+// cross-reference stream is read as a revision of its own.
+func TestRevisionsXRefStreamUpdate(t *testing.T) {
+	file, originalLen := revisionsTestPDF()
+	file = file[:originalLen]
+	prevXRef := strings.LastIndex(file, "xref\n0 4")
+	obj := len(file)
+	file += "4 0 obj\n<< /Title (Updated) >>\nendobj\n"
+	xref := len(file)
+	entries := string([]byte{1, byte(obj >> 8), byte(obj), 0, 1, byte(xref >> 8), byte(xref), 0})
+	file += fmt.Sprintf("5 0 obj\n<< /Type /XRef /Size 6 /Index [4 2] /W [1 2 1] /Root 1 0 R /Info 4 0 R /Prev %d /Length %d >>\nstream\n%s\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n",
+		prevXRef, len(entries), entries, xref)
+
+	d, err := OpenBytes([]byte(file))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer d.Close()
+	revs, err := d.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	if len(revs) != 2 || !slices.Equal(revs[1].Added, []int{4, 5}) || revs[1].Changed != nil {
+		t.Fatalf("Revisions = %+v, want the update adding objects 4 and 5", revs)
+	}
+	if revs[1].Trailer.Entries["Type"] != (PDFName{Value: "XRef"}) {
+		t.Errorf("update trailer = %v, want the cross-reference stream's dictionary", revs[1].Trailer)
+	}
+}
+
+// TestRevisionsLinearized confirms a linearized file's first-page section,
+// which startxref points at and which chains to the main section written
+// after it, is part of the one original revision.
+func TestRevisionsLinearized(t *testing.T) {
+	const header = "%PDF-1.4\n"
+	const first = "xref\n1 1\n%010d 00000 n \ntrailer\n<< /Size 4 /Root 1 0 R /Prev %010d >>\nstartxref\n0\n%%%%EOF\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+	}
+	body := header + fmt.Sprintf(first, 0, 0)
+	offsets := make([]int, len(objs)+1)
+	for i, o := range objs {
+		offsets[i+1] = len(body)
+		body += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	main := len(body)
+	body += fmt.Sprintf("xref\n0 1\n0000000000 65535 f \n2 2\n%010d 00000 n \n%010d 00000 n \ntrailer\n<< /Size 4 >>\nstartxref\n%d\n%%%%EOF\n",
+		offsets[2], offsets[3], len(header))
+	file := header + fmt.Sprintf(first, offsets[1], main) + body[len(header)+len(fmt.Sprintf(first, 0, 0)):]
+
+	d, err := OpenBytes([]byte(file))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer d.Close()
+	revs, err := d.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	if len(revs) != 1 {
+		t.Fatalf("Revisions returned %d revisions, want 1", len(revs))
+	}
+	r := revs[0]
+	if r.End != int64(len(file)) || r.XRefOffset != int64(len(header)) || !slices.Equal(r.Added, []int{1, 2, 3}) {
+		t.Errorf("revision = End %d XRefOffset %d Added %v, want %d %d [1 2 3]",
+			r.End, r.XRefOffset, r.Added, len(file), len(header))
+	}
+}
@@ -22,7 +22,7 @@ func (d *Reader) validateStream(l *Lexer, dict *PDFDict, objNum int) error {
 
 	lengthObj, err := d.ResolveObject(lengthRef)
 	if err != nil {
-		return fmt.Errorf("could not resolve stream Length: %v", lengthObj)
+		return fmt.Errorf("could not resolve stream Length: %w", err)
 	}
 
 	lengthInt, ok := lengthObj.(PDFInteger)
@@ -55,6 +55,10 @@ func (d *Reader) validateStream(l *Lexer, dict *PDFDict, objNum int) error {
 				"stream Length value includes the EOL marker before endstream")
 		}
 	} else {
+		// Checked before allocating, since a declared Length is untrusted.
+		if end > d.size {
+			return fmt.Errorf("stream body extends past end of file")
+		}
 		data := make([]byte, length)
 		if _, err := d.file.ReadAt(data, streamStart); err != nil {
 			return err
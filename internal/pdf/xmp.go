@@ -10,10 +10,12 @@ import (
 
 // PDFAPartRe and PDFAConfRe extract the PDF/A part and conformance level a
 // document's XMP metadata claims (6.7.11 pdfaid namespace), shared by
-// ClaimedConformance and the 6.7.11 verifier.
+// ClaimedConformance and the 6.7.11 verifier. PDFARevRe extracts the
+// pdfaid:rev year PDF/A-4 declares in place of a conformance level.
 var (
 	PDFAPartRe = regexp.MustCompile(`pdfaid:part\s*=\s*"([^"]*)"|<pdfaid:part>\s*([^<\s]+)\s*</pdfaid:part>`)
 	PDFAConfRe = regexp.MustCompile(`pdfaid:conformance\s*=\s*"([^"]*)"|<pdfaid:conformance>\s*([^<\s]+)\s*</pdfaid:conformance>`)
+	PDFARevRe  = regexp.MustCompile(`pdfaid:rev\s*=\s*"([^"]*)"|<pdfaid:rev>\s*([^<\s]+)\s*</pdfaid:rev>`)
 )
 
 // FirstRegexpGroup returns the first non-empty capture group re matches in s.
@@ -79,6 +79,20 @@ func parseIndirectObjectAt(file fileSource, offset int64) (objNum int, dict PDFD
 	return objNum, dict, nil
 }
 
+// XRefStreamDictAt returns the dictionary of the cross-reference stream at
+// the absolute file offset, without merging its entries into the object
+// table. It fails if the object there is not a /Type /XRef stream.
+func (d *Reader) XRefStreamDictAt(offset int64) (PDFDict, error) {
+	_, dict, err := parseIndirectObjectAt(d.file, offset)
+	if err != nil {
+		return PDFDict{}, err
+	}
+	if !dict.HasStream || !EqualPDFValue(dict.Entries["Type"], PDFName{Value: "XRef"}) {
+		return PDFDict{}, fmt.Errorf("object at offset %d is not a cross-reference stream", offset)
+	}
+	return dict, nil
+}
+
 // tryParseXRefStream attempts to parse the object at offset as a
 // cross-reference stream (ISO 32000-1 7.5.8), the PDF 1.5+ replacement for a
 // classic "xref" table that folds the cross-reference and trailer roles into
@@ -105,7 +119,7 @@ func (d *Reader) tryParseXRefStream(offset int64, fillIn bool) (PDFDict, error)
 		return PDFDict{}, fmt.Errorf("object at offset %d is not a cross-reference stream", offset)
 	}
 
-	data, err := decodeStreamPredicted(dict)
+	data, err := DecodeStream(dict)
 	if err != nil {
 		return PDFDict{}, fmt.Errorf("cross-reference stream: %w", err)
 	}
@@ -220,6 +234,28 @@ func xrefIndexRanges(dict PDFDict) ([]xrefRange, error) {
 	return ranges, nil
 }
 
+// XRefFieldWidth returns the smallest /W field width, in bytes, that holds
+// every value up to max.
+func XRefFieldWidth(max uint64) int {
+	w := 1
+	for max >>= 8; max > 0; max >>= 8 {
+		w++
+	}
+	return w
+}
+
+// AppendXRefEntry appends a cross-reference stream entry to dst: type typ
+// and fields f2 and f3, big-endian in the widths /W declares, as
+// tryParseXRefStream reads them back.
+func AppendXRefEntry(dst []byte, widths [3]int, typ, f2, f3 uint64) []byte {
+	for i, v := range [3]uint64{typ, f2, f3} {
+		for b := widths[i] - 1; b >= 0; b-- {
+			dst = append(dst, byte(v>>(8*b)))
+		}
+	}
+	return dst
+}
+
 // beUint decodes a big-endian unsigned integer from a byte slice of length
 // 0-8, per the variable per-field widths recorded in /W.
 func beUint(b []byte) uint64 {
@@ -333,3 +333,21 @@ func TestBeUint(t *testing.T) {
 		}
 	}
 }
+
+// TestAppendXRefEntry covers XRefFieldWidth at the byte boundaries and
+// checks AppendXRefEntry's fields decode back through beUint.
+func TestAppendXRefEntry(t *testing.T) {
+	for max, want := range map[uint64]int{0: 1, 255: 1, 256: 2, 65535: 2, 65536: 3, 1 << 32: 5} {
+		if got := XRefFieldWidth(max); got != want {
+			t.Errorf("XRefFieldWidth(%d) = %d, want %d", max, got, want)
+		}
+	}
+	widths := [3]int{1, 3, 2}
+	entry := AppendXRefEntry([]byte{0xff}, widths, 2, 70000, 300)
+	if len(entry) != 7 || entry[0] != 0xff {
+		t.Fatalf("AppendXRefEntry = %v, want 6 bytes after the prefix", entry)
+	}
+	if typ, f2, f3 := beUint(entry[1:2]), beUint(entry[2:5]), beUint(entry[5:7]); typ != 2 || f2 != 70000 || f3 != 300 {
+		t.Errorf("entry decodes to %d %d %d, want 2 70000 300", typ, f2, f3)
+	}
+}
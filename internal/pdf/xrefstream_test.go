@@ -456,3 +456,52 @@ func TestOpenObjStmPDF(t *testing.T) {
 		t.Errorf("GetPageCount = %d, %v; want 1 (resolved from an object stream)", n, err)
 	}
 }
+
+// TestXRefStreamOnlyPDFA2 verifies that Verify(A_2B), unlike A_1B, accepts a
+// cross-reference-stream-only file's xref structure: ISO 19005-2 is based on
+// PDF 1.7 and permits cross-reference streams, so no 6.1.4 issue is raised.
+func TestXRefStreamOnlyPDFA2(t *testing.T) {
+	doc, err := pdf.OpenBytes(buildXRefStreamOnlyPDF(t))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+
+	res, err := verify.Verify(doc, pdf.PDFA_2B)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	for _, iss := range res.Issues {
+		if iss.Check().Spec() != pdf.SpecPDFA2 && iss.Check().Spec() != pdf.SpecPDF {
+			t.Errorf("A-2b issue %v reported against %s", iss, iss.Check().Spec())
+		}
+		if clauseMatches(iss.Check().Clause(), "6.1.4") {
+			t.Errorf("unexpected 6.1.4 issue: %v", iss)
+		}
+	}
+}
+
+func TestXRefStreamDictAt(t *testing.T) {
+	data := buildXRefStreamOnlyPDF(t)
+	doc, err := pdf.OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+
+	sx := bytes.LastIndex(data, []byte("startxref"))
+	var off int64
+	if _, err := fmt.Sscan(string(data[sx+len("startxref"):]), &off); err != nil {
+		t.Fatalf("reading startxref: %v", err)
+	}
+	dict, err := doc.XRefStreamDictAt(off)
+	if err != nil {
+		t.Fatalf("XRefStreamDictAt(%d): %v", off, err)
+	}
+	if dict.Entries["Root"] == nil {
+		t.Errorf("xref stream dict has no /Root: %v", dict.Entries)
+	}
+	if _, err := doc.XRefStreamDictAt(int64(bytes.Index(data, []byte("1 0 obj")))); err == nil {
+		t.Error("XRefStreamDictAt on a non-XRef object did not error")
+	}
+}
@@ -7,7 +7,8 @@ import (
 )
 
 // computeColourCoverage inspects the document's OutputIntents and records which
-// device colour models are covered (6.2.2 / 6.2.3.3).
+// device colour models are covered (6.2.2 / 6.2.3.3). Only intents of the
+// verified level's subtype count (see pdf.LevelType.OutputIntentSubtype).
 func computeColourCoverage(d *pdf.Reader, ctx *ValidationContext) {
 	value, err := d.ResolveGraphByPath([]string{"Root", "OutputIntents"})
 	if err != nil || value == nil {
@@ -26,7 +27,7 @@ func computeColourCoverage(d *pdf.Reader, ctx *ValidationContext) {
 		if !ok {
 			continue
 		}
-		if (intent.Entries["S"] != pdf.PDFName{Value: "GTS_PDFA1"}) {
+		if (intent.Entries["S"] != pdf.PDFName{Value: ctx.level.OutputIntentSubtype()}) {
 			continue
 		}
 		ctx.hasOutputIntent = true
@@ -156,9 +157,10 @@ func validateColourSpaceArray(arr pdf.PDFArray, ctx *ValidationContext) {
 	// [/Separation name alternateSpace tintTransform]
 	// [/DeviceN names alternateSpace tintTransform]
 	if head.Value == "DeviceN" {
-		// 6.1.12: DeviceN colour space shall not have more than 8 colorants.
-		if names, ok := arr[1].(pdf.PDFArray); ok && len(names) > 8 {
-			ctx.Report(pdf.Checks.Structure.DeviceNColorants, arr, fmt.Sprintf("DeviceN colour space has %d colorants, maximum is 8", len(names)))
+		// 6.1.12: DeviceN colour space shall not have more than 8 colorants
+		// (32 in PDF/A-2, 6.1.13).
+		if names, ok := arr[1].(pdf.PDFArray); ok && len(names) > ctx.limits().maxDeviceN {
+			ctx.Report(pdf.Checks.Structure.DeviceNColorants, arr, fmt.Sprintf("DeviceN colour space has %d colorants, maximum is %d", len(names), ctx.limits().maxDeviceN))
 		}
 	}
 	alt := arr[2]
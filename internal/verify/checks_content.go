@@ -50,16 +50,27 @@ func scanContentDict(dict pdf.PDFDict, resources pdf.PDFDict, ctx *ValidationCon
 // scanContentValue inspects a /Contents value that may be a single stream or an
 // array of streams.
 func scanContentValue(contents pdf.PDFValue, resources pdf.PDFDict, ctx *ValidationContext) {
+	for _, d := range contentStreams(contents) {
+		scanContentDict(d, resources, ctx)
+	}
+}
+
+// contentStreams returns the streams of a /Contents value that may be a
+// single stream or an array of streams.
+func contentStreams(contents pdf.PDFValue) []pdf.PDFDict {
 	switch v := contents.(type) {
 	case pdf.PDFDict:
-		scanContentDict(v, resources, ctx)
+		return []pdf.PDFDict{v}
 	case pdf.PDFArray:
+		var out []pdf.PDFDict
 		for _, item := range v {
 			if d, ok := item.(pdf.PDFDict); ok {
-				scanContentDict(d, resources, ctx)
+				out = append(out, d)
 			}
 		}
+		return out
 	}
+	return nil
 }
 
 // NamedColourModel resolves a colour-space name to a device model, consulting
@@ -154,12 +165,12 @@ func checkOperandLimits(operand pdf.PDFValue, obj pdf.PDFValue, ctx *ValidationC
 			ctx.Report(pdf.Checks.Structure.IntegerOutOfRange, obj, fmt.Sprintf("integer in content stream exceeds limits: %d", v))
 		}
 	case pdf.PDFReal:
-		if math.Abs(float64(v)) > 32767 {
+		if math.Abs(float64(v)) > ctx.limits().maxReal {
 			ctx.Report(pdf.Checks.Structure.RealOutOfRange, obj, fmt.Sprintf("real number in content stream out of range: %g", float64(v)))
 		}
 	case pdf.PDFString:
-		if len(v.Value) > 65535 {
-			ctx.Report(pdf.Checks.Structure.StringTooLong, obj, "string in content stream exceeds maximum length of 65535 bytes")
+		if maxLen := ctx.limits().maxString; len(v.Value) > maxLen {
+			ctx.Report(pdf.Checks.Structure.StringTooLong, obj, fmt.Sprintf("string in content stream exceeds maximum length of %d bytes", maxLen))
 		}
 	case pdf.PDFHexString:
 		// 6.1.6: hex string operands must be valid hex digits, even count.
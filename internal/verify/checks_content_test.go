@@ -63,6 +63,55 @@ func TestScanAnnotAppearancesRejectsPageDefaultRGB(t *testing.T) {
 	}
 }
 
+// TestScanAnnotAppearancesDecodesEveryFilter confirms content compressed
+// with LZWDecode or RunLengthDecode is scanned like any other: the device
+// colour in it is still reported.
+func TestScanAnnotAppearancesDecodesEveryFilter(t *testing.T) {
+	content := []byte("1 0 0 rg")
+	// LZW: one 9-bit literal code per byte, then EOD (257).
+	var lzw []byte
+	var buf uint32
+	var nbits int
+	for _, code := range append(bytesToCodes(content), 257) {
+		buf = buf<<9 | uint32(code)
+		for nbits += 9; nbits >= 8; nbits -= 8 {
+			lzw = append(lzw, byte(buf>>(nbits-8)))
+		}
+	}
+	lzw = append(lzw, byte(buf<<(8-nbits)))
+	runLength := append(append([]byte{byte(len(content) - 1)}, content...), 128)
+
+	for _, tc := range []struct {
+		filter string
+		raw    []byte
+	}{{"LZWDecode", lzw}, {"RunLengthDecode", runLength}} {
+		t.Run(tc.filter, func(t *testing.T) {
+			page, pageRes := buildAPWithRGB(t)
+			annot := page.Entries["Annots"].(pdf.PDFArray)[0].(pdf.PDFDict)
+			apStream := annot.Entries["AP"].(pdf.PDFDict).Entries["N"].(pdf.PDFDict)
+			apStream.Entries["Filter"] = pdf.PDFName{Value: tc.filter}
+			apStream.RawStream = tc.raw
+			annot.Entries["AP"].(pdf.PDFDict).Entries["N"] = apStream
+
+			ctx := &ValidationContext{hasOutputIntent: true, cmykCovered: true}
+			ctx.pageResources = pageRes
+			scanAnnotAppearances(page, ctx)
+			if !hasCheck(ctx, pdf.Checks.Colour.DeviceColourContentStream) {
+				t.Errorf("%s-encoded rg not reported; got %v", tc.filter, ctx.Issues())
+			}
+		})
+	}
+}
+
+// bytesToCodes returns each byte of b as an LZW literal code.
+func bytesToCodes(b []byte) []int {
+	codes := make([]int, len(b))
+	for i, c := range b {
+		codes[i] = int(c)
+	}
+	return codes
+}
+
 // TestScanAnnotAppearancesHonoursOwnDefaultRGB confirms that a widget
 // appearance stream with /DefaultRGB in its OWN resources is not flagged.
 func TestScanAnnotAppearancesHonoursOwnDefaultRGB(t *testing.T) {
@@ -81,8 +81,16 @@ func validateActions(v pdf.PDFDict, ctx *ValidationContext) {
 	}
 }
 
-// validateAdditionalActions flags presence of an additional-actions dictionary (6.6.2).
+// validateAdditionalActions flags presence of an additional-actions dictionary
+// (6.6.2). PDF/A-2 (6.5.2) only forbids it on the catalog and pages; form
+// fields and widgets are covered by validateFormField.
 func validateAdditionalActions(v pdf.PDFDict, ctx *ValidationContext) {
+	if ctx.part >= 2 {
+		t, _ := v.Entries["Type"].(pdf.PDFName)
+		if t.Value != "Catalog" && t.Value != "Page" {
+			return
+		}
+	}
 	if v.Entries["AA"] != nil {
 		ctx.Report(pdf.Checks.Action.AdditionalActions, v, "additional-actions (AA) dictionary not allowed")
 	}
@@ -94,7 +102,7 @@ var Post14ViewerPrefKeys = []string{"PrintScaling", "PickTrayByPDFSize", "PrintP
 
 // validateViewerPreferences flags ViewerPreferences keys not valid in PDF 1.4.
 func validateViewerPreferences(v pdf.PDFDict, ctx *ValidationContext) {
-	if (v.Entries["Type"] != pdf.PDFName{Value: "Catalog"}) {
+	if ctx.part >= 2 || (v.Entries["Type"] != pdf.PDFName{Value: "Catalog"}) {
 		return
 	}
 	vp, ok := v.Entries["ViewerPreferences"].(pdf.PDFDict)
@@ -119,9 +127,12 @@ func validateExtGState(v pdf.PDFDict, ctx *ValidationContext) {
 	if t, ok := v.Entries["Type"].(pdf.PDFName); ok && t.Value != "ExtGState" {
 		return
 	}
-	if !HasAnyKey(v, "TR", "TR2", "SMask", "BM", "CA", "ca", "RI") {
+	if !HasAnyKey(v, "TR", "TR2", "SMask", "BM", "CA", "ca", "RI", "HTP", "HT") {
 		return
 	}
+	if ctx.part >= 2 {
+		validateExtGStatePdfA2(v, ctx)
+	}
 
 	if v.Entries["TR"] != nil {
 		ctx.Report(pdf.Checks.Transparency.TransferFunction, v, "ExtGState shall not contain a TR key")
@@ -138,6 +149,10 @@ func validateExtGState(v pdf.PDFDict, ctx *ValidationContext) {
 	}
 
 	// 6.4: transparency soft masks, blend modes and non-opaque alpha.
+	// PDF/A-2 permits transparency; validateExtGStatePdfA2 checks BM instead.
+	if ctx.part >= 2 {
+		return
+	}
 	if sm, ok := v.Entries["SMask"]; ok {
 		if name, isName := sm.(pdf.PDFName); !isName || name.Value != "None" {
 			ctx.Report(pdf.Checks.Transparency.SoftMaskExtGState, v, "ExtGState SMask shall be /None")
@@ -179,8 +194,12 @@ func IsAllowedBlendMode(bm pdf.PDFValue) bool {
 
 // --- 6.4 Transparency groups ---
 
-// validateTransparencyGroup flags a transparency group attribute dictionary (6.4).
+// validateTransparencyGroup flags a transparency group attribute dictionary
+// (6.4). PDF/A-2 permits transparency groups (6.2.10).
 func validateTransparencyGroup(v pdf.PDFDict, ctx *ValidationContext) {
+	if ctx.part >= 2 {
+		return
+	}
 	group, ok := v.Entries["Group"].(pdf.PDFDict)
 	if !ok {
 		return
@@ -220,12 +239,13 @@ func validateXObjectDict(v pdf.PDFDict, ctx *ValidationContext) {
 				if bpc != 1 {
 					ctx.Report(pdf.Checks.Image.ImageMaskBitsPerComponent, v, fmt.Sprintf("image mask BitsPerComponent is %d, must be 1", int(bpc)))
 				}
-			} else if bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 {
+			} else if bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 && (ctx.part < 2 || bpc != 16) {
+				// PDF/A-2 (6.2.8) also permits 16.
 				ctx.Report(pdf.Checks.Image.ImageBitsPerComponent, v, fmt.Sprintf("image BitsPerComponent is %d, must be 1, 2, 4, or 8", int(bpc)))
 			}
 		}
 		// 6.4: soft-masked images introduce transparency.
-		if sm, ok := v.Entries["SMask"]; ok {
+		if sm, ok := v.Entries["SMask"]; ok && ctx.part < 2 {
 			if name, isName := sm.(pdf.PDFName); !isName || name.Value != "None" {
 				ctx.Report(pdf.Checks.Transparency.ImageWithSoftMask, v, "image shall not contain a soft mask (SMask)")
 			}
@@ -299,6 +319,10 @@ func validateAnnotation(v pdf.PDFDict, ctx *ValidationContext) {
 
 	subtype, _ := v.Entries["Subtype"].(pdf.PDFName)
 
+	if ctx.part >= 2 {
+		validateAnnotationPdfA2(v, subtype.Value, ctx)
+		return
+	}
 	if !AllowedAnnotationTypes[subtype.Value] {
 		ctx.Report(pdf.Checks.Annotation.DisallowedSubtype, v, fmt.Sprintf("annotation subtype /%s not allowed", subtype.Value))
 		return
@@ -330,13 +354,19 @@ func validateAnnotation(v pdf.PDFDict, ctx *ValidationContext) {
 	checkAnnotColour(v, v.Entries["C"], ctx)
 	checkAnnotColour(v, v.Entries["IC"], ctx)
 
+	checkAnnotAppearance(v, subtype.Value, ctx)
+}
+
+// checkAnnotAppearance checks an annotation's appearance dictionary (6.5.3;
+// 6.3.3 in PDF/A-2).
+func checkAnnotAppearance(v pdf.PDFDict, subtype string, ctx *ValidationContext) {
 	// 6.5.3: appearance dictionary, where present, shall contain only N, an
 	// appearance stream. Non-Popup/Link annotations require an appearance.
 	ap, hasAP := v.Entries["AP"].(pdf.PDFDict)
 	isFormField := resolveInheritedFT(v) != nil
 	switch {
 	case !hasAP:
-		if subtype.Value != "Popup" && subtype.Value != "Link" {
+		if subtype != "Popup" && subtype != "Link" {
 			if isFormField {
 				// Missing AP on a form-field widget is a 6.9 violation, not 6.5.3.
 				ctx.Report(pdf.Checks.Form.WidgetMissingAppearance, v, "form field widget annotation lacks an appearance dictionary (AP)")
@@ -0,0 +1,337 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// Factur-X / ZUGFeRD hybrid invoices
+//
+// A Factur-X invoice is a PDF/A-3 file, so the PDF/A-3 verifier runs as
+// usual; when the profile enables any pdf.Checks.FacturX check,
+// verifyPdfA3bParts adds verifyInvoice's findings: the embedded Cross
+// Industry Invoice XML file and its association (7.2), and the fx: XMP
+// properties describing it with their extension schema (7.3).
+
+// XMP namespaces of the invoice properties. ZUGFeRD 2.0 used its own; from
+// 2.1 on ZUGFeRD shares Factur-X's.
+const (
+	facturXNamespace  = "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"
+	zugferd2Namespace = "urn:zugferd:pdfa:CrossIndustryDocument:invoice:2p0#"
+	ciiNamespace      = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+)
+
+// invoiceFileNamespaces maps each permitted invoice file name to the
+// namespace of the XMP properties describing it.
+var invoiceFileNamespaces = map[string]string{
+	"factur-x.xml":        facturXNamespace,
+	"xrechnung.xml":       facturXNamespace,
+	"zugferd-invoice.xml": zugferd2Namespace,
+}
+
+// InvoiceNamespace returns the XMP namespace of the fx: properties for an
+// embedded invoice called name, and whether name is an invoice file name.
+func InvoiceNamespace(name string) (string, bool) {
+	ns, ok := invoiceFileNamespaces[name]
+	return ns, ok
+}
+
+// invoiceGuidelines maps a CII guideline identifier
+// (GuidelineSpecifiedDocumentContextParameter) to the Factur-X profile
+// name fx:ConformanceLevel declares for it.
+var invoiceGuidelines = map[string]string{
+	"urn:factur-x.eu:1p0:minimum":                                     "MINIMUM",
+	"urn:factur-x.eu:1p0:basicwl":                                     "BASIC WL",
+	"urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic":     "BASIC",
+	"urn:cen.eu:en16931:2017":                                         "EN 16931",
+	"urn:cen.eu:en16931:2017#conformant#urn:factur-x.eu:1p0:extended": "EXTENDED",
+	"urn:zugferd.de:2p0:minimum":                                      "MINIMUM",
+	"urn:zugferd.de:2p0:basicwl":                                      "BASIC WL",
+	"urn:cen.eu:en16931:2017#conformant#urn:zugferd.de:2p0:extended":  "EXTENDED",
+	"urn:cen.eu:en16931:2017#compliant#urn:zugferd.de:2p0:basic":      "BASIC",
+}
+
+// xrechnungGuidelinePrefixes identify the German XRechnung CIUS, whose
+// guideline identifier carries its version.
+var xrechnungGuidelinePrefixes = []string{
+	"urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_",
+	"urn:cen.eu:en16931:2017#compliant#urn:xoev-de:kosit:standard:xrechnung_",
+}
+
+// invoiceLevels are the fx:ConformanceLevel values.
+var invoiceLevels = map[string]bool{
+	"MINIMUM": true, "BASIC WL": true, "BASIC": true,
+	"EN 16931": true, "EXTENDED": true, "XRECHNUNG": true,
+}
+
+// invoiceProperties are the fx: properties every invoice declares.
+var invoiceProperties = []string{"DocumentType", "DocumentFileName", "Version", "ConformanceLevel"}
+
+// InvoiceConformanceLevel parses a Cross Industry Invoice XML document and
+// returns the fx:ConformanceLevel its guideline identifier corresponds to.
+// It returns an error if data is not well-formed XML, its root is not a
+// CrossIndustryInvoice, or its guideline is not a Factur-X profile.
+func InvoiceConformanceLevel(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		path      []string
+		guideline string
+		rootSeen  bool
+	)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invoice XML is not well-formed: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !rootSeen {
+				rootSeen = true
+				if t.Name.Space != ciiNamespace || t.Name.Local != "CrossIndustryInvoice" {
+					return "", fmt.Errorf("invoice XML root is %s, not a Cross Industry Invoice", t.Name.Local)
+				}
+			}
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if n := len(path); n >= 2 && path[n-1] == "ID" && path[n-2] == "GuidelineSpecifiedDocumentContextParameter" && guideline == "" {
+				guideline = strings.TrimSpace(string(t))
+			}
+		}
+	}
+	if !rootSeen {
+		return "", fmt.Errorf("invoice XML has no root element")
+	}
+	if level, ok := invoiceGuidelines[guideline]; ok {
+		return level, nil
+	}
+	for _, prefix := range xrechnungGuidelinePrefixes {
+		if strings.HasPrefix(guideline, prefix) {
+			return "XRECHNUNG", nil
+		}
+	}
+	if guideline == "" {
+		return "", fmt.Errorf("invoice XML has no guideline identifier")
+	}
+	return "", fmt.Errorf("invoice guideline %q is not a Factur-X profile", guideline)
+}
+
+// invoiceAttachment is an embedded invoice file specification and where the
+// document refers to it from.
+type invoiceAttachment struct {
+	spec       pdf.PDFDict
+	name       string
+	inAF       bool
+	inNameTree bool
+}
+
+// findInvoices returns the embedded file specifications the catalog's AF
+// array or EmbeddedFiles name tree lists under an invoice file name, in
+// that order.
+func findInvoices(root pdf.PDFDict) []*invoiceAttachment {
+	var found []*invoiceAttachment
+	byPtr := map[uintptr]*invoiceAttachment{}
+	add := func(v pdf.PDFValue) *invoiceAttachment {
+		spec, ok := v.(pdf.PDFDict)
+		if !ok {
+			return nil
+		}
+		if _, ok := spec.Entries["EF"].(pdf.PDFDict); !ok {
+			return nil
+		}
+		name, ok := textString(spec.Entries["UF"])
+		if !ok {
+			name, _ = textString(spec.Entries["F"])
+		}
+		if _, ok := InvoiceNamespace(name); !ok {
+			return nil
+		}
+		ptr := pdf.ValuePointer(spec.Entries)
+		if inv, ok := byPtr[ptr]; ok {
+			return inv
+		}
+		inv := &invoiceAttachment{spec: spec, name: name}
+		byPtr[ptr] = inv
+		found = append(found, inv)
+		return inv
+	}
+	af, _ := root.Entries["AF"].(pdf.PDFArray)
+	for _, v := range af {
+		if inv := add(v); inv != nil {
+			inv.inAF = true
+		}
+	}
+	names, _ := root.Entries["Names"].(pdf.PDFDict)
+	if tree, ok := names.Entries["EmbeddedFiles"].(pdf.PDFDict); ok {
+		nameTreeEach(tree, 0, func(_ string, v pdf.PDFValue) {
+			if inv := add(v); inv != nil {
+				inv.inNameTree = true
+			}
+		})
+	}
+	return found
+}
+
+// InvoiceAttachment returns the file specification of the invoice embedded
+// in the document with catalog root, and its file name.
+func InvoiceAttachment(root pdf.PDFDict) (pdf.PDFDict, string, bool) {
+	invoices := findInvoices(root)
+	if len(invoices) == 0 {
+		return pdf.PDFDict{}, "", false
+	}
+	return invoices[0].spec, invoices[0].name, true
+}
+
+// InvoiceFile returns the embedded file stream of an invoice file
+// specification: its EF UF entry, else its EF F entry.
+func InvoiceFile(spec pdf.PDFDict) (pdf.PDFDict, bool) {
+	ef, _ := spec.Entries["EF"].(pdf.PDFDict)
+	for _, key := range []string{"UF", "F"} {
+		if stm, ok := ef.Entries[key].(pdf.PDFDict); ok && stm.HasStream {
+			return stm, true
+		}
+	}
+	return pdf.PDFDict{}, false
+}
+
+// nameTreeEach calls fn for every key and value of the name tree rooted at
+// node.
+func nameTreeEach(node pdf.PDFDict, depth int, fn func(key string, v pdf.PDFValue)) {
+	if depth > 32 {
+		return
+	}
+	if names, ok := node.Entries["Names"].(pdf.PDFArray); ok {
+		for i := 0; i+1 < len(names); i += 2 {
+			key, _ := textString(names[i])
+			fn(key, names[i+1])
+		}
+	}
+	kids, _ := node.Entries["Kids"].(pdf.PDFArray)
+	for _, kid := range kids {
+		if kd, ok := kid.(pdf.PDFDict); ok {
+			nameTreeEach(kd, depth+1, fn)
+		}
+	}
+}
+
+// verifyInvoice runs the Factur-X checks against the resolved graph.
+func verifyInvoice(d *pdf.Reader, graph pdf.PDFValue) []pdf.PDFError {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return nil
+	}
+	ctx := &ValidationContext{reader: d}
+	checks := pdf.Checks.FacturX.Invoice
+
+	invoices := findInvoices(root)
+	if len(invoices) == 0 {
+		ctx.Report(checks.InvoiceMissing, root, "document embeds no factur-x.xml, zugferd-invoice.xml or xrechnung.xml file")
+		return ctx.errs
+	}
+	if len(invoices) > 1 {
+		ctx.Report(checks.InvoiceMultiple, root, fmt.Sprintf("document embeds %d invoice files", len(invoices)))
+	}
+
+	inv := invoices[0]
+	if !inv.inAF {
+		ctx.Report(checks.InvoiceNotAssociated, inv.spec, inv.name+" is not listed in the catalog's AF array")
+	}
+	if !inv.inNameTree {
+		ctx.Report(checks.InvoiceNotAssociated, inv.spec, inv.name+" is not listed in the EmbeddedFiles name tree")
+	}
+	if rel, _ := inv.spec.Entries["AFRelationship"].(pdf.PDFName); rel.Value != "Alternative" && rel.Value != "Data" {
+		ctx.Report(checks.InvoiceRelationship, inv.spec, fmt.Sprintf("%s AFRelationship is /%s, not /Alternative or /Data", inv.name, rel.Value))
+	}
+
+	level := ""
+	if stm, ok := InvoiceFile(inv.spec); !ok {
+		ctx.Report(checks.InvoiceXML, inv.spec, inv.name+" has no embedded file stream")
+	} else {
+		if sub, _ := stm.Entries["Subtype"].(pdf.PDFName); string(pdf.DecodePDFName(sub.Value)) != "text/xml" {
+			ctx.Report(checks.InvoiceMIMEType, inv.spec, fmt.Sprintf("%s Subtype is %q, not text/xml", inv.name, pdf.DecodePDFName(sub.Value)))
+		}
+		data, err := ctx.decodeStreamCached(stm)
+		if err == nil {
+			level, err = InvoiceConformanceLevel(data)
+		}
+		if err != nil {
+			ctx.Report(checks.InvoiceXML, inv.spec, fmt.Sprintf("%s: %v", inv.name, err))
+		}
+	}
+
+	ctx.errs = append(ctx.errs, verifyInvoiceXMP(d, inv.name, level)...)
+	return ctx.errs
+}
+
+// verifyInvoiceXMP checks the fx: properties describing the embedded invoice
+// name, whose XML declares the Factur-X profile level ("" if unreadable),
+// and the extension schema declaring them (7.3).
+func verifyInvoiceXMP(d *pdf.Reader, name, level string) []pdf.PDFError {
+	checks := pdf.Checks.FacturX.Metadata
+	data, _, err := d.RawXMP()
+	if err != nil {
+		return []pdf.PDFError{xmpErr(checks.InvoiceProperties, fmt.Sprintf("no XMP metadata to describe %s: %v", name, err))}
+	}
+	xmp := string(data)
+	ns, _ := InvoiceNamespace(name)
+
+	var errs []pdf.PDFError
+	for _, m := range xmpNSBindRe.FindAllStringSubmatch(xmp, -1) {
+		if m[1] == "fx" && m[2] != ns {
+			errs = append(errs, xmpErr(checks.InvoiceProperties, fmt.Sprintf("fx: prefix is bound to %s, not %s", m[2], ns)))
+		}
+	}
+	props := map[string]string{}
+	var missing []string
+	for _, prop := range invoiceProperties {
+		v, _ := xmpScalarValue(xmp, "fx:"+prop)
+		if v == "" {
+			missing = append(missing, "fx:"+prop)
+		}
+		props[prop] = v
+	}
+	if len(missing) > 0 {
+		errs = append(errs, xmpErr(checks.InvoiceProperties, "XMP metadata lacks "+strings.Join(missing, ", ")))
+	}
+	if v := props["DocumentType"]; v != "" && v != "INVOICE" {
+		errs = append(errs, xmpErr(checks.InvoiceProperties, fmt.Sprintf("fx:DocumentType is %q, not INVOICE", v)))
+	}
+	if v := props["DocumentFileName"]; v != "" && v != name {
+		errs = append(errs, xmpErr(checks.InvoiceFileName, fmt.Sprintf("fx:DocumentFileName %q does not name the embedded %s", v, name)))
+	}
+	switch v := props["ConformanceLevel"]; {
+	case v == "":
+	case !invoiceLevels[v]:
+		errs = append(errs, xmpErr(checks.InvoiceConformanceLevel, fmt.Sprintf("fx:ConformanceLevel %q is not a Factur-X profile", v)))
+	case level != "" && v != level:
+		errs = append(errs, xmpErr(checks.InvoiceConformanceLevel, fmt.Sprintf("fx:ConformanceLevel %q does not match the invoice's %s guideline", v, level)))
+	}
+
+	_, schemas, _ := parseExtSchemas(data)
+	i := slices.IndexFunc(schemas, func(s extSchema) bool { return s.namespaceURI == ns })
+	if i < 0 {
+		return append(errs, xmpErr(checks.InvoiceExtensionSchema, "no extension schema declares "+ns))
+	}
+	schema := schemas[i]
+	if schema.prefix != "fx" {
+		errs = append(errs, xmpErr(checks.InvoiceExtensionSchema, fmt.Sprintf("extension schema for %s has prefix %q, not fx", ns, schema.prefix)))
+	}
+	for _, prop := range invoiceProperties {
+		if !slices.ContainsFunc(schema.properties, func(p extProperty) bool { return p.name == prop }) {
+			errs = append(errs, xmpErr(checks.InvoiceExtensionSchema, "extension schema does not declare fx:"+prop))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,197 @@
+package verify
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// ciiInvoice returns a minimal Cross Industry Invoice declaring guideline.
+func ciiInvoice(guideline string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">
+<rsm:ExchangedDocumentContext><ram:GuidelineSpecifiedDocumentContextParameter><ram:ID>` + guideline + `</ram:ID></ram:GuidelineSpecifiedDocumentContextParameter></rsm:ExchangedDocumentContext>
+</rsm:CrossIndustryInvoice>`
+}
+
+// invoiceXMPPacket returns an XMP packet describing an embedded invoice with
+// the given fx: properties and, if schema, the fx: extension schema.
+func invoiceXMPPacket(fileName, level string, schema bool) string {
+	var b strings.Builder
+	b.WriteString(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?><x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">`)
+	b.WriteString(`<rdf:Description rdf:about="" xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#">`)
+	b.WriteString(`<fx:DocumentType>INVOICE</fx:DocumentType><fx:DocumentFileName>` + fileName + `</fx:DocumentFileName><fx:Version>1.0</fx:Version><fx:ConformanceLevel>` + level + `</fx:ConformanceLevel></rdf:Description>`)
+	if schema {
+		b.WriteString(`<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">`)
+		b.WriteString(`<pdfaExtension:schemas><rdf:Bag><rdf:li rdf:parseType="Resource"><pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>`)
+		b.WriteString(`<pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI><pdfaSchema:prefix>fx</pdfaSchema:prefix><pdfaSchema:property><rdf:Seq>`)
+		for _, p := range invoiceProperties {
+			b.WriteString(`<rdf:li rdf:parseType="Resource"><pdfaProperty:name>` + p + `</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>` + p + `</pdfaProperty:description></rdf:li>`)
+		}
+		b.WriteString(`</rdf:Seq></pdfaSchema:property></rdf:li></rdf:Bag></pdfaExtension:schemas></rdf:Description>`)
+	}
+	b.WriteString(`</rdf:RDF></x:xmpmeta><?xpacket end="w"?>`)
+	return b.String()
+}
+
+// invoiceSpec returns an embedded file specification named name holding xml.
+func invoiceSpec(name, rel, xml string) pdf.PDFDict {
+	file := pdf.NewPDFDict()
+	file.Entries["Type"] = pdf.PDFName{Value: "EmbeddedFile"}
+	file.Entries["Subtype"] = pdf.PDFName{Value: "text#2Fxml"}
+	file.HasStream = true
+	file.RawStream = []byte(xml)
+	ef := pdf.NewPDFDict()
+	ef.Entries["F"] = file
+	spec := pdf.NewPDFDict()
+	spec.Entries["Type"] = pdf.PDFName{Value: "Filespec"}
+	spec.Entries["F"] = pdf.PDFString{Value: name}
+	spec.Entries["UF"] = pdf.PDFString{Value: name}
+	spec.Entries["AFRelationship"] = pdf.PDFName{Value: rel}
+	spec.Entries["EF"] = ef
+	return spec
+}
+
+// openInvoiceDoc writes a document embedding specs, listed in the AF array
+// if inAF and in the EmbeddedFiles name tree, with xmp as its metadata, and
+// returns it opened together with its resolved graph.
+func openInvoiceDoc(t *testing.T, specs []pdf.PDFDict, inAF bool, xmp string) (*pdf.Reader, pdf.PDFValue) {
+	t.Helper()
+	trailer := pdf.NewPDFDict()
+	minimalConformantRoot(trailer)
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	var af, names pdf.PDFArray
+	for i, spec := range specs {
+		// Indirect, so AF and the name tree share one object.
+		spec.Entries["_ref"] = pdf.PDFRef{ObjNum: 10 + i}
+		af = append(af, spec)
+		names = append(names, spec.Entries["F"], spec)
+	}
+	if inAF {
+		root.Entries["AF"] = af
+	}
+	tree := pdf.NewPDFDict()
+	tree.Entries["Names"] = names
+	nd := pdf.NewPDFDict()
+	nd.Entries["EmbeddedFiles"] = tree
+	root.Entries["Names"] = nd
+	if xmp != "" {
+		md := pdf.NewPDFDict()
+		md.Entries["Type"] = pdf.PDFName{Value: "Metadata"}
+		md.Entries["Subtype"] = pdf.PDFName{Value: "XML"}
+		md.HasStream = true
+		md.RawStream = []byte(xmp)
+		root.Entries["Metadata"] = md
+	}
+
+	var buf bytes.Buffer
+	if err := writer.WriteDocument(&buf, trailer); err != nil {
+		t.Fatal(err)
+	}
+	d, err := pdf.OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	graph, err := d.ResolveGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, graph
+}
+
+func TestInvoiceConformanceLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		xml     string
+		want    string
+		wantErr bool
+	}{
+		{"EN 16931", ciiInvoice("urn:cen.eu:en16931:2017"), "EN 16931", false},
+		{"MINIMUM", ciiInvoice("urn:factur-x.eu:1p0:minimum"), "MINIMUM", false},
+		{"BASIC WL", ciiInvoice("urn:factur-x.eu:1p0:basicwl"), "BASIC WL", false},
+		{"XRECHNUNG", ciiInvoice("urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0"), "XRECHNUNG", false},
+		{"unknown guideline", ciiInvoice("urn:example:guideline"), "", true},
+		{"no guideline", ciiInvoice(""), "", true},
+		{"wrong root", `<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"/>`, "", true},
+		{"malformed", `<rsm:CrossIndustryInvoice`, "", true},
+		{"empty", ``, "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := InvoiceConformanceLevel([]byte(tc.xml))
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("InvoiceConformanceLevel = %q, %v; want %q, error %v", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyInvoice(t *testing.T) {
+	inv := pdf.Checks.FacturX.Invoice
+	md := pdf.Checks.FacturX.Metadata
+	en := ciiInvoice("urn:cen.eu:en16931:2017")
+	valid := invoiceXMPPacket("factur-x.xml", "EN 16931", true)
+	for _, tc := range []struct {
+		name  string
+		specs []pdf.PDFDict
+		inAF  bool
+		xmp   string
+		want  []pdf.Check
+	}{
+		{"valid", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, valid, nil},
+		{"ZUGFeRD 2.0 name", []pdf.PDFDict{invoiceSpec("zugferd-invoice.xml", "Data", en)}, true,
+			strings.ReplaceAll(invoiceXMPPacket("zugferd-invoice.xml", "EN 16931", true),
+				"urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#", "urn:zugferd:pdfa:CrossIndustryDocument:invoice:2p0#"), nil},
+		{"missing", []pdf.PDFDict{invoiceSpec("invoice.pdf", "Source", en)}, true, valid, []pdf.Check{inv.InvoiceMissing}},
+		{"multiple", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en), invoiceSpec("xrechnung.xml", "Alternative", en)}, true, valid, []pdf.Check{inv.InvoiceMultiple}},
+		{"not in AF", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, false, valid, []pdf.Check{inv.InvoiceNotAssociated}},
+		{"relationship", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Source", en)}, true, valid, []pdf.Check{inv.InvoiceRelationship}},
+		{"not CII", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", "<Invoice/>")}, true, valid, []pdf.Check{inv.InvoiceXML}},
+		{"no XMP", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, "", []pdf.Check{md.InvoiceProperties}},
+		{"wrong file name", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, invoiceXMPPacket("invoice.xml", "EN 16931", true), []pdf.Check{md.InvoiceFileName}},
+		{"level mismatch", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, invoiceXMPPacket("factur-x.xml", "MINIMUM", true), []pdf.Check{md.InvoiceConformanceLevel}},
+		{"unknown level", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, invoiceXMPPacket("factur-x.xml", "GOLD", true), []pdf.Check{md.InvoiceConformanceLevel}},
+		{"no extension schema", []pdf.PDFDict{invoiceSpec("factur-x.xml", "Alternative", en)}, true, invoiceXMPPacket("factur-x.xml", "EN 16931", false), []pdf.Check{md.InvoiceExtensionSchema}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, graph := openInvoiceDoc(t, tc.specs, tc.inAF, tc.xmp)
+			ctx := &ValidationContext{errs: verifyInvoice(d, graph)}
+			if tc.want == nil && len(ctx.errs) != 0 {
+				t.Errorf("unexpected issues: %v", ctx.errs)
+			}
+			for _, c := range tc.want {
+				if !hasCheck(ctx, c) {
+					t.Errorf("%s not reported; got %v", c.Name(), ctx.errs)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyFacturXProfile(t *testing.T) {
+	d, _ := openInvoiceDoc(t, nil, true, "")
+	res, err := Verify(d, pdf.FacturX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[pdf.Check]bool{}
+	for _, iss := range res.Issues {
+		got[iss.Check()] = true
+	}
+	if !got[pdf.Checks.FacturX.Invoice.InvoiceMissing] {
+		t.Error("FacturX profile did not report the missing invoice")
+	}
+
+	res, err = Verify(d, pdf.PDFA_3B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, iss := range res.Issues {
+		if iss.Check().Spec() == pdf.SpecFacturX {
+			t.Errorf("PDFA_3B reported Factur-X issue %s", iss.Check().Name())
+		}
+	}
+}
@@ -61,7 +61,8 @@ func EmbeddedProgramMatchesSubtype(subtype string, desc pdf.PDFDict) bool {
 }
 
 // ValidateFontDict checks font dictionaries: embedding (6.3.4), composite fonts
-// (6.3.3), subsets (6.3.5) and character encodings (6.3.7).
+// (6.3.3), subsets (6.3.5), character encodings (6.3.7) and, where the level
+// requires them, Unicode mappings (6.3.8).
 func ValidateFontDict(v pdf.PDFDict, ctx *ValidationContext) {
 	if (v.Entries["Type"] != pdf.PDFName{Value: "Font"}) {
 		return
@@ -98,6 +99,11 @@ func ValidateFontDict(v pdf.PDFDict, ctx *ValidationContext) {
 		ctx.Report(pdf.Checks.Font.FontBaseFont, v, "font dictionary lacks BaseFont")
 	}
 
+	// 6.3.8
+	if ctx.level.RequiresUnicode() {
+		validateUnicodeMapping(v, subtype.Value, baseFont.Value, ctx)
+	}
+
 	subset := SubsetTagRe.MatchString(baseFont.Value)
 
 	switch subtype.Value {
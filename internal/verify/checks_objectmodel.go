@@ -18,7 +18,7 @@ import (
 // its own check, erring toward false negatives over false positives. Keys absent from both the
 // 1.4 and post-1.4 schema (custom/private keys) are never flagged.
 func validateAgainstSchema(v pdf.PDFDict, typeName string, ctx *ValidationContext) {
-	ot, ok := arlington.Type(typeName)
+	ot, ok := ctx.objectModel().Type(typeName)
 	if !ok {
 		return
 	}
@@ -153,14 +153,19 @@ func validateAgainstSchema(v pdf.PDFDict, typeName string, ctx *ValidationContex
 
 	// A wildcard type allows arbitrary keys, so there is nothing "introduced after PDF 1.4"
 	// to flag there; a custom/private key on a non-wildcard type is likewise never in
-	// Post14Keys, so it is never flagged either.
+	// Post14Keys, so it is never flagged either. PDF/A-2 is based on PDF 1.7, so from part 2
+	// on only the keys PDF 2.0 introduced are flagged.
 	if ot.Wildcard == nil {
+		check, later, version := pdf.Checks.ObjectModel.KeyIntroducedAfterPDF14, ot.Post14Keys, "1.4"
+		if ctx.part >= 2 {
+			check, later, version = pdf.Checks.ObjectModel.KeyIntroducedAfterPDF17, ot.Post17Keys, "1.7"
+		}
 		for k := range v.Entries {
-			if k != "_ref" && stringInList(k, ot.Post14Keys) {
+			if k != "_ref" && stringInList(k, later) {
 				ctx.ReportObjModel(
-					pdf.Checks.ObjectModel.KeyIntroducedAfterPDF14,
+					check,
 					v, typeName, k,
-					fmt.Sprintf("%s key %q was introduced after PDF 1.4", typeName, k),
+					fmt.Sprintf("%s key %q was introduced after PDF %s", typeName, k, version),
 				)
 			}
 		}
@@ -176,7 +181,7 @@ func validateAgainstSchema(v pdf.PDFDict, typeName string, ctx *ValidationContex
 // holding v ("" when v is nested inside another array). A predicated column suppresses
 // only its own check, as in validateAgainstSchema.
 func validateArrayAgainstSchema(v pdf.PDFArray, typeName string, owner pdf.PDFValue, entry string, ctx *ValidationContext) {
-	ot, ok := arlington.Type(typeName)
+	ot, ok := ctx.objectModel().Type(typeName)
 	if !ok {
 		return
 	}
@@ -528,9 +533,9 @@ func comparisonOperands[S condOperands](src S, c *arlington.Cond) (lhs, rhs floa
 	return lhs, rhs, true
 }
 
-// selfIdentifiedType re-anchors an untyped dict by its own /Type (+/Subtype) names via the
-// generated unambiguous-identification table; "" when they do not identify exactly one type.
-func selfIdentifiedType(v pdf.PDFDict) string {
+// selfIdentifiedType re-anchors an untyped dict by its own /Type (+/Subtype) names via model
+// m's generated unambiguous-identification table; "" when they do not identify exactly one type.
+func selfIdentifiedType(m *arlington.Model, v pdf.PDFDict) string {
 	t, ok := v.Entries["Type"].(pdf.PDFName)
 	if !ok {
 		return ""
@@ -539,7 +544,7 @@ func selfIdentifiedType(v pdf.PDFDict) string {
 	if s, ok := v.Entries["Subtype"].(pdf.PDFName); ok {
 		sub = s.Value
 	}
-	return arlington.SelfIdentified(t.Value, sub)
+	return m.SelfIdentified(t.Value, sub)
 }
 
 // numericValue converts a PDF integer or real to float64 for range conditions.
@@ -640,12 +645,12 @@ func valueTypeAllowed(val pdf.PDFValue, vt arlington.ValueType) bool {
 	}
 }
 
-// arlingtonChildType returns the Arlington type name that val (key's value inside a dict of type
+// arlingtonChildType returns the model m type name that val (key's value inside a dict of type
 // parentType) should conform to, or "" if parentType is unknown, key has no schema entry, or
 // val doesn't resolve to a single candidate type. Deliberately conservative: propagating a
 // wrong guessed type would misvalidate an entire subtree.
-func arlingtonChildType(parentType, key string, val pdf.PDFValue) string {
-	ot, ok := arlington.Type(parentType)
+func arlingtonChildType(m *arlington.Model, parentType, key string, val pdf.PDFValue) string {
+	ot, ok := m.Type(parentType)
 	if !ok {
 		return ""
 	}
@@ -656,10 +661,10 @@ func arlingtonChildType(parentType, key string, val pdf.PDFValue) string {
 	return resolveLinkGroups(kd.LinkGroups, kd.Types, val)
 }
 
-// arlingtonElementType returns the Arlington type name that item (an element of an array of
+// arlingtonElementType returns the model m type name that item (an element of an array of
 // type arrayType) should conform to, or "" if unknown or unresolved.
-func arlingtonElementType(arrayType string, item pdf.PDFValue) string {
-	ot, ok := arlington.Type(arrayType)
+func arlingtonElementType(m *arlington.Model, arrayType string, item pdf.PDFValue) string {
+	ot, ok := m.Type(arrayType)
 	if !ok || ot.Wildcard == nil {
 		return ""
 	}
@@ -735,10 +740,14 @@ func linkGroupMatchesKind(val pdf.PDFValue, valueTypes []arlington.ValueType) bo
 			if _, ok := val.(pdf.PDFArray); ok {
 				return true
 			}
-		case arlington.Dictionary, arlington.NameTree, arlington.NumberTree:
+		case arlington.Dictionary:
 			if d, ok := val.(pdf.PDFDict); ok && !d.HasStream {
 				return true
 			}
+		case arlington.NameTree, arlington.NumberTree:
+			// A tree's links type its leaf values, not the tree node val
+			// itself (e.g. EmbeddedFiles links FileSpecification): never a
+			// match, so the tree stays untyped.
 		case arlington.Stream:
 			if d, ok := val.(pdf.PDFDict); ok && d.HasStream {
 				return true
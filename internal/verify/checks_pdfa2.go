@@ -0,0 +1,612 @@
+package verify
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// PDF/A-2b (ISO 19005-2:2011)
+//
+// Part 2 reuses the part 1 pipeline (verifyPdfAParts with part 2): the shared
+// validators consult ctx.part where part 2 relaxes a rule, and report against
+// the part 1 checks, which translateIssues then renumbers into ISO 19005-2
+// clauses. The rules part 2 adds are implemented here and report against
+// pdf.Checks.PDFA2 directly.
+
+func verifyPdfA2bParts(d *pdf.Reader, p *pdf.Profile) Parts {
+	return verifyPdfAParts(d, p, 2).translate(pdf.SpecPDFA2)
+}
+
+// translate applies translateIssues to each part.
+func (pt Parts) translate(spec pdf.Spec) Parts {
+	return Parts{
+		PreStructural:  translateIssues(pt.PreStructural, spec),
+		Graph:          translateIssues(pt.Graph, spec),
+		PostStructural: translateIssues(pt.PostStructural, spec),
+	}
+}
+
+// translateIssues renumbers issues into spec's clauses via pdf.CheckIn,
+// dropping any finding whose rule spec does not carry over (e.g. the part 1
+// transparency prohibitions under part 2).
+func translateIssues(issues []pdf.PDFError, spec pdf.Spec) []pdf.PDFError {
+	out := make([]pdf.PDFError, 0, len(issues))
+	for _, e := range issues {
+		if c, ok := pdf.CheckIn(spec, e.Check()); ok {
+			out = append(out, e.WithCheck(c))
+		}
+	}
+	return out
+}
+
+// --- 6.1 File structure ---
+
+// validateNameEncoding checks that a name, once its #XX escapes are decoded,
+// is valid UTF-8 (6.1.8).
+func validateNameEncoding(name string, owner pdf.PDFValue, ctx *ValidationContext) {
+	if decoded := pdf.DecodePDFName(name); !utf8.Valid(decoded) {
+		ctx.Report(pdf.Checks.PDFA2.Structure.NameNotUTF8, owner, fmt.Sprintf("name /%s is not valid UTF-8", name))
+	}
+}
+
+// validateStreamCryptFilter flags a Crypt filter whose decode parameters do
+// not name the Identity filter (6.1.7.2).
+func validateStreamCryptFilter(v pdf.PDFDict, ctx *ValidationContext) {
+	filters := pdf.FilterNames(v.Entries["Filter"])
+	for i, f := range filters {
+		if f != "Crypt" {
+			continue
+		}
+		var parms pdf.PDFValue = v.Entries["DecodeParms"]
+		if arr, ok := parms.(pdf.PDFArray); ok {
+			parms = nil
+			if i < len(arr) {
+				parms = arr[i]
+			}
+		}
+		name := pdf.PDFName{Value: "Identity"}
+		if pd, ok := parms.(pdf.PDFDict); ok && pd.Entries["Name"] != nil {
+			name, _ = pd.Entries["Name"].(pdf.PDFName)
+		}
+		if name.Value != "Identity" {
+			ctx.Report(pdf.Checks.PDFA2.Structure.StreamCryptFilter, v, "stream uses a Crypt filter other than Identity")
+		}
+	}
+}
+
+// pageBoundaryKeys are the page boundaries whose size 6.1.13 bounds.
+var pageBoundaryKeys = []string{"MediaBox", "CropBox", "BleedBox", "TrimBox", "ArtBox"}
+
+// validatePageBoundaries checks that every page boundary is between 3 and
+// 14400 units in each direction, scaled by UserUnit (6.1.13).
+func validatePageBoundaries(page pdf.PDFDict, ctx *ValidationContext) {
+	unit := 1.0
+	if u, ok := AsFloat(page.Entries["UserUnit"]); ok && u > 0 {
+		unit = u
+	}
+	for _, key := range pageBoundaryKeys {
+		box, ok := page.Entries[key].(pdf.PDFArray)
+		if !ok || len(box) != 4 {
+			continue
+		}
+		var c [4]float64
+		valid := true
+		for i, v := range box {
+			c[i], ok = AsFloat(v)
+			valid = valid && ok
+		}
+		if !valid {
+			continue
+		}
+		w, h := Abs64(c[2]-c[0])*unit, Abs64(c[3]-c[1])*unit
+		if w < 3 || h < 3 || w > 14400 || h > 14400 {
+			ctx.Report(pdf.Checks.PDFA2.Structure.PageBoundaryOutOfRange, page,
+				fmt.Sprintf("page %s is %gx%g units, must be between 3 and 14400 in each direction", key, w, h))
+		}
+	}
+}
+
+// --- 6.2 Graphics ---
+
+// StandardBlendModes are the blend modes defined by ISO 32000-1 11.3.5, the
+// only ones PDF/A-2 permits (6.2.10).
+var StandardBlendModes = map[string]bool{
+	"Normal": true, "Compatible": true, "Multiply": true, "Screen": true,
+	"Overlay": true, "Darken": true, "Lighten": true, "ColorDodge": true,
+	"ColorBurn": true, "HardLight": true, "SoftLight": true, "Difference": true,
+	"Exclusion": true, "Hue": true, "Saturation": true, "Color": true,
+	"Luminosity": true,
+}
+
+// IsStandardBlendMode reports whether a /BM value names only standard blend
+// modes.
+func IsStandardBlendMode(bm pdf.PDFValue) bool {
+	switch v := bm.(type) {
+	case pdf.PDFName:
+		return StandardBlendModes[v.Value]
+	case pdf.PDFArray:
+		for _, item := range v {
+			if name, ok := item.(pdf.PDFName); !ok || !StandardBlendModes[name.Value] {
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+// PrimaryColourants are the colourants for which a type 5 halftone component
+// shall not carry a TransferFunction (6.2.5).
+var PrimaryColourants = map[string]bool{
+	"Cyan": true, "Magenta": true, "Yellow": true, "Black": true,
+	"Red": true, "Green": true, "Blue": true, "Gray": true, "Default": true,
+}
+
+// validateExtGStatePdfA2 checks the ExtGState rules part 2 adds: no HTP
+// (6.2.5), halftone restrictions (6.2.5) and standard blend modes (6.2.10).
+func validateExtGStatePdfA2(v pdf.PDFDict, ctx *ValidationContext) {
+	if v.Entries["HTP"] != nil {
+		ctx.Report(pdf.Checks.PDFA2.Colour.HalftonePhase, v, "ExtGState shall not contain an HTP key")
+	}
+	if ht, ok := v.Entries["HT"].(pdf.PDFDict); ok {
+		validateHalftone(v, ht, ctx)
+	}
+	if bm, ok := v.Entries["BM"]; ok && !IsStandardBlendMode(bm) {
+		ctx.Report(pdf.Checks.PDFA2.Transparency.BlendMode, v, "ExtGState uses a blend mode not defined by ISO 32000-1")
+	}
+}
+
+// validateHalftone checks the halftone ht of ExtGState gs (6.2.5).
+func validateHalftone(gs, ht pdf.PDFDict, ctx *ValidationContext) {
+	typ, _ := ht.Entries["HalftoneType"].(pdf.PDFInteger)
+	if typ != 1 && typ != 5 {
+		ctx.Report(pdf.Checks.PDFA2.Colour.HalftoneType, gs, fmt.Sprintf("halftone type %d not allowed, must be 1 or 5", int(typ)))
+	}
+	if ht.Entries["HalftoneName"] != nil {
+		ctx.Report(pdf.Checks.PDFA2.Colour.HalftoneName, gs, "halftone shall not contain a HalftoneName key")
+	}
+	if typ != 5 {
+		if ht.Entries["TransferFunction"] != nil {
+			ctx.Report(pdf.Checks.PDFA2.Colour.HalftoneTransferFunction, gs, "halftone shall not contain a TransferFunction")
+		}
+		return
+	}
+	keysBase := len(ctx.keyScratch)
+	defer func() { ctx.keyScratch = ctx.keyScratch[:keysBase] }()
+	for _, k := range ctx.sortedKeys(ht.Entries) {
+		comp, ok := ht.Entries[k].(pdf.PDFDict)
+		if !ok || !PrimaryColourants[k] {
+			continue
+		}
+		if comp.Entries["TransferFunction"] != nil {
+			ctx.Report(pdf.Checks.PDFA2.Colour.HalftoneTransferFunction, gs,
+				fmt.Sprintf("halftone component for primary colourant %s shall not contain a TransferFunction", k))
+		}
+		if comp.Entries["HalftoneName"] != nil {
+			ctx.Report(pdf.Checks.PDFA2.Colour.HalftoneName, gs, "halftone shall not contain a HalftoneName key")
+		}
+	}
+}
+
+// validateJPXImage checks the JPEG2000 data of a JPXDecode image (6.2.8.3).
+func validateJPXImage(v pdf.PDFDict, ctx *ValidationContext) {
+	filters := pdf.FilterNames(v.Entries["Filter"])
+	if len(filters) == 0 || filters[len(filters)-1] != "JPXDecode" {
+		return
+	}
+	// Filters ahead of JPXDecode (an ASCII or Flate wrapping) are undone
+	// to reach the JPEG2000 data.
+	data, _, err := pdf.DecodeStreamToCodec(v)
+	if err != nil {
+		return
+	}
+	info, err := pdf.ParseJPXHeader(data)
+	if err != nil {
+		// Undecodable image data is outside what 6.2.8.3 can judge.
+		return
+	}
+	if info.Components != 1 && info.Components != 3 && info.Components != 4 {
+		ctx.Report(pdf.Checks.PDFA2.Image.JPXChannels, v, fmt.Sprintf("JPEG2000 data has %d colour channels, must be 1, 3 or 4", info.Components))
+	}
+	// The colour specification boxes only matter when the image dictionary
+	// does not override them with its own ColorSpace.
+	if v.Entries["ColorSpace"] == nil && len(info.ColourSpecs) > 1 {
+		accurate := 0
+		for _, cs := range info.ColourSpecs {
+			if cs.Approx == 1 {
+				accurate++
+			}
+		}
+		if accurate != 1 {
+			ctx.Report(pdf.Checks.PDFA2.Image.JPXColourSpecBoxes, v,
+				fmt.Sprintf("JPEG2000 data has %d colour specifications, %d with APPROX 1; exactly one is required", len(info.ColourSpecs), accurate))
+		}
+	}
+	for _, cs := range info.ColourSpecs {
+		if cs.Method < 1 || cs.Method > 3 {
+			ctx.Report(pdf.Checks.PDFA2.Image.JPXCIEJabColourSpace, v, fmt.Sprintf("JPEG2000 colour specification METH %d not allowed", cs.Method))
+		} else if cs.Method == 1 && cs.EnumCS == pdf.JPXEnumCIEJab {
+			ctx.Report(pdf.Checks.PDFA2.Image.JPXCIEJabColourSpace, v, "JPEG2000 data uses the CIEJab colour space")
+		}
+	}
+	for i, d := range info.BitDepths {
+		if d < 1 || d > 38 || d != info.BitDepths[0] {
+			ctx.Report(pdf.Checks.PDFA2.Image.JPXBitDepth, v,
+				fmt.Sprintf("JPEG2000 component %d has bit depth %d; depths shall be 1 to 38 and equal for all channels", i, d))
+			break
+		}
+	}
+}
+
+// pageHasTransparency reports whether page uses transparency anywhere in
+// its resources: a transparency group, a soft mask, a non-Normal blend mode
+// or a non-opaque alpha (6.2.10).
+func pageHasTransparency(page pdf.PDFDict) bool {
+	visited := map[uintptr]bool{}
+	var inResources func(res pdf.PDFDict) bool
+	inResources = func(res pdf.PDFDict) bool {
+		ptr := pdf.ValuePointer(res.Entries)
+		if visited[ptr] {
+			return false
+		}
+		visited[ptr] = true
+		if gss, ok := res.Entries["ExtGState"].(pdf.PDFDict); ok {
+			for _, g := range gss.Entries {
+				if gs, ok := g.(pdf.PDFDict); ok && extGStateIsTransparent(gs) {
+					return true
+				}
+			}
+		}
+		if xobjs, ok := res.Entries["XObject"].(pdf.PDFDict); ok {
+			for _, x := range xobjs.Entries {
+				xo, ok := x.(pdf.PDFDict)
+				if !ok {
+					continue
+				}
+				if sm, ok := xo.Entries["SMask"].(pdf.PDFDict); ok && sm.HasStream {
+					return true
+				}
+				if isTransparencyGroup(xo) {
+					return true
+				}
+				if sub, ok := xo.Entries["Resources"].(pdf.PDFDict); ok && inResources(sub) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if isTransparencyGroup(page) {
+		return true
+	}
+	res, ok := page.Entries["Resources"].(pdf.PDFDict)
+	return ok && inResources(res)
+}
+
+// isTransparencyGroup reports whether v carries a /Group of subtype
+// Transparency.
+func isTransparencyGroup(v pdf.PDFDict) bool {
+	group, ok := v.Entries["Group"].(pdf.PDFDict)
+	return ok && (group.Entries["S"] == pdf.PDFName{Value: "Transparency"})
+}
+
+// extGStateIsTransparent reports whether gs introduces transparency.
+func extGStateIsTransparent(gs pdf.PDFDict) bool {
+	if sm, ok := gs.Entries["SMask"]; ok && sm != (pdf.PDFName{Value: "None"}) {
+		return true
+	}
+	if bm, ok := gs.Entries["BM"]; ok && !IsAllowedBlendMode(bm) {
+		return true
+	}
+	for _, k := range []string{"CA", "ca"} {
+		if f, ok := AsFloat(gs.Entries[k]); ok && Abs64(f-1.0) > 1e-5 {
+			return true
+		}
+	}
+	return false
+}
+
+// --- 6.3 Annotations ---
+
+// ForbiddenAnnotationTypesPdfA2 are the annotation subtypes ISO 19005-2
+// forbids (6.3.1); any subtype not defined by ISO 32000-1 is forbidden too.
+var ForbiddenAnnotationTypesPdfA2 = map[string]bool{
+	"3D": true, "Sound": true, "Screen": true, "Movie": true,
+}
+
+// iso32000AnnotationTypes are the annotation subtypes defined by ISO
+// 32000-1 Table 169.
+var iso32000AnnotationTypes = map[string]bool{
+	"Text": true, "Link": true, "FreeText": true, "Line": true, "Square": true,
+	"Circle": true, "Polygon": true, "PolyLine": true, "Highlight": true,
+	"Underline": true, "Squiggly": true, "StrikeOut": true, "Stamp": true,
+	"Caret": true, "Ink": true, "Popup": true, "FileAttachment": true,
+	"Sound": true, "Movie": true, "Widget": true, "Screen": true,
+	"PrinterMark": true, "TrapNet": true, "Watermark": true, "3D": true,
+	"Redact": true,
+}
+
+// AnnotFlagToggleNoView is the ToggleNoView annotation flag (ISO 32000-1
+// 12.5.3), which PDF/A-2 forbids alongside the others (6.3.2).
+const AnnotFlagToggleNoView = 1 << 8
+
+// validateAnnotationPdfA2 checks an annotation against 6.3: permitted
+// subtypes, flags (Popup is exempt from the Print requirement) and
+// appearances (zero-area annotations need none).
+func validateAnnotationPdfA2(v pdf.PDFDict, subtype string, ctx *ValidationContext) {
+	if !annotationSubtypeAllowed(subtype, ctx) {
+		ctx.Report(pdf.Checks.Annotation.DisallowedSubtype, v, fmt.Sprintf("annotation subtype /%s not allowed", subtype))
+		return
+	}
+
+	flags := 0
+	if f, ok := v.Entries["F"].(pdf.PDFInteger); ok {
+		flags = int(f)
+	}
+	if flags&AnnotFlagPrint == 0 && subtype != "Popup" {
+		ctx.Report(pdf.Checks.Annotation.PrintFlagNotSet, v, "annotation Print flag shall be set")
+	}
+	if flags&AnnotFlagHidden != 0 {
+		ctx.Report(pdf.Checks.Annotation.HiddenFlagSet, v, "annotation Hidden flag shall be clear")
+	}
+	if flags&AnnotFlagInvisible != 0 {
+		ctx.Report(pdf.Checks.Annotation.InvisibleFlagSet, v, "annotation Invisible flag shall be clear")
+	}
+	if flags&AnnotFlagNoView != 0 {
+		ctx.Report(pdf.Checks.Annotation.NoViewFlagSet, v, "annotation NoView flag shall be clear")
+	}
+	if flags&AnnotFlagToggleNoView != 0 {
+		ctx.Report(pdf.Checks.PDFA2.Annotation.ToggleNoViewFlagSet, v, "annotation ToggleNoView flag shall be clear")
+	}
+
+	if isZeroAreaRect(v.Entries["Rect"]) {
+		return
+	}
+	checkAnnotAppearance(v, subtype, ctx)
+}
+
+// isZeroAreaRect reports whether rect is a rectangle of zero width and
+// height.
+func isZeroAreaRect(rect pdf.PDFValue) bool {
+	arr, ok := rect.(pdf.PDFArray)
+	if !ok || len(arr) != 4 {
+		return false
+	}
+	var c [4]float64
+	for i, v := range arr {
+		if c[i], ok = AsFloat(v); !ok {
+			return false
+		}
+	}
+	return c[0] == c[2] && c[1] == c[3]
+}
+
+// --- Per-object rules ---
+
+// validatePdfA2Object runs the per-object rules part 2 adds to the walk in
+// verifyDocument. From part 3 on, embedded files follow the associated-file
+// rules of checks_pdfa3.go instead; part 4 adds them to its own PDF/A
+// requirement, which only PDF/A-4f lifts.
+func validatePdfA2Object(v pdf.PDFDict, ctx *ValidationContext) {
+	if v.HasStream {
+		validateStreamCryptFilter(v, ctx)
+	}
+	if (v.Entries["Type"] == pdf.PDFName{Value: "Page"}) {
+		validatePageBoundaries(v, ctx)
+		if v.Entries["PresSteps"] != nil {
+			ctx.Report(pdf.Checks.PDFA2.Document.PresSteps, v, "page shall not contain a PresSteps entry")
+		}
+		// 6.2.10: without a PDF/A output intent, a page using transparency
+		// shall define its blending colour space in a group CS entry.
+		if !ctx.hasOutputIntent && pageHasTransparency(v) {
+			group, _ := v.Entries["Group"].(pdf.PDFDict)
+			if group.Entries["CS"] == nil {
+				ctx.Report(pdf.Checks.PDFA2.Transparency.TransparencyGroupNoColourSpace, v,
+					"page uses transparency but has no Group colour space and the document no output intent")
+			}
+		}
+	}
+	if (v.Entries["Subtype"] == pdf.PDFName{Value: "Image"}) && v.HasStream {
+		validateJPXImage(v, ctx)
+	}
+	if ctx.part >= 3 {
+		collectAssociatedFiles(v, ctx)
+	}
+	if ef, ok := v.Entries["EF"].(pdf.PDFDict); ok {
+		switch {
+		case ctx.part >= 4:
+			validateAssociatedFileSpec(v, ef, ctx)
+			if !ctx.level.AllowsAssociatedFiles() {
+				validateEmbeddedPDFA(v, ef, ctx)
+			}
+		case ctx.part >= 3:
+			validateAssociatedFileSpec(v, ef, ctx)
+		default:
+			validateEmbeddedFileSpec(v, ef, ctx)
+		}
+	}
+}
+
+// --- 6.8 Embedded files ---
+
+// validateEmbeddedFileSpec checks a file specification carrying embedded
+// files: it shall have both F and UF, and every embedded file shall itself
+// be a PDF/A-1 or PDF/A-2 file (6.8).
+func validateEmbeddedFileSpec(spec, ef pdf.PDFDict, ctx *ValidationContext) {
+	if spec.Entries["F"] == nil || spec.Entries["UF"] == nil {
+		ctx.Report(pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileSpecKeys, spec, "embedded file specification shall contain both F and UF")
+	}
+	validateEmbeddedPDFA(spec, ef, ctx)
+}
+
+// validateEmbeddedPDFA checks that every embedded file of spec claims
+// conformance to a PDF/A part the part being verified admits: parts 1 and 2
+// under part 2, any part under part 4.
+func validateEmbeddedPDFA(spec, ef pdf.PDFDict, ctx *ValidationContext) {
+	admitted, want := []string{"1", "2"}, "a PDF/A-1 or PDF/A-2 file"
+	if ctx.part >= 4 {
+		admitted, want = []string{"1", "2", "3", "4"}, "a PDF/A file"
+	}
+	keysBase := len(ctx.keyScratch)
+	defer func() { ctx.keyScratch = ctx.keyScratch[:keysBase] }()
+	for _, k := range ctx.sortedKeys(ef.Entries) {
+		stm, ok := ef.Entries[k].(pdf.PDFDict)
+		if !ok || !stm.HasStream {
+			continue
+		}
+		data, err := ctx.decodeStreamCached(stm)
+		if err != nil {
+			ctx.Report(pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA, spec, fmt.Sprintf("embedded file %s cannot be decoded: %v", k, err))
+			continue
+		}
+		if !slices.Contains(admitted, claimedPDFAPart(data)) {
+			ctx.Report(pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA, spec, fmt.Sprintf("embedded file %s is not %s", k, want))
+		}
+	}
+}
+
+// claimedPDFAPart returns the PDF/A part an embedded file's XMP claims, or
+// "" if data is not a readable PDF or claims none. As with veraPDF, the
+// claim is trusted rather than the embedded file re-verified.
+func claimedPDFAPart(data []byte) string {
+	doc, err := pdf.OpenBytes(data)
+	if err != nil {
+		return ""
+	}
+	defer doc.Close()
+	part, _, err := doc.ClaimedConformance()
+	if err != nil {
+		return ""
+	}
+	return part
+}
+
+// --- Document-level rules ---
+
+// verifyPdfA2Document runs the catalog-level rules part 2 adds: optional
+// content configurations (6.9), NeedsRendering (6.4.2), the permissions
+// dictionary (6.1.12), AlternatePresentations (6.10) and Requirements (6.11).
+func verifyPdfA2Document(d *pdf.Reader, graph pdf.PDFValue) []pdf.PDFError {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return nil
+	}
+	var errs []pdf.PDFError
+	docErr := func(c pdf.Check, format string, args ...any) {
+		errs = append(errs, pdf.NewError(c, []error{fmt.Errorf(format, args...)}, 0, nil))
+	}
+
+	if b, ok := root.Entries["NeedsRendering"].(pdf.PDFBoolean); ok && bool(b) {
+		docErr(pdf.Checks.PDFA2.Form.NeedsRendering, "catalog NeedsRendering shall not be true")
+	}
+	if perms, ok := root.Entries["Perms"].(pdf.PDFDict); ok {
+		for _, k := range slices.Sorted(maps.Keys(perms.Entries)) {
+			if k != "UR3" && k != "DocMDP" && k != "_ref" {
+				docErr(pdf.Checks.PDFA2.Structure.PermissionsKeys, "permissions dictionary shall contain only UR3 and DocMDP, found %s", k)
+			}
+		}
+	}
+	if names, ok := root.Entries["Names"].(pdf.PDFDict); ok && names.Entries["AlternatePresentations"] != nil {
+		docErr(pdf.Checks.PDFA2.Document.AlternatePresentations, "name dictionary shall not contain AlternatePresentations")
+	}
+	if root.Entries["Requirements"] != nil {
+		docErr(pdf.Checks.PDFA2.Document.Requirements, "catalog shall not contain Requirements")
+	}
+	if oc, ok := root.Entries["OCProperties"].(pdf.PDFDict); ok {
+		errs = append(errs, verifyOptionalContentConfigs(oc)...)
+	}
+	return errs
+}
+
+// verifyOptionalContentConfigs checks the optional content configuration
+// dictionaries (D and Configs) of OCProperties oc (6.9): each shall have a
+// unique Name, shall not contain AS, and an Order array shall list every
+// optional content group.
+func verifyOptionalContentConfigs(oc pdf.PDFDict) []pdf.PDFError {
+	var configs []pdf.PDFDict
+	if dflt, ok := oc.Entries["D"].(pdf.PDFDict); ok {
+		configs = append(configs, dflt)
+	}
+	if arr, ok := oc.Entries["Configs"].(pdf.PDFArray); ok {
+		for _, c := range arr {
+			if cd, ok := c.(pdf.PDFDict); ok {
+				configs = append(configs, cd)
+			}
+		}
+	}
+	groups := map[uintptr]bool{}
+	if arr, ok := oc.Entries["OCGs"].(pdf.PDFArray); ok {
+		for _, g := range arr {
+			if gd, ok := g.(pdf.PDFDict); ok {
+				groups[pdf.ValuePointer(gd.Entries)] = true
+			}
+		}
+	}
+
+	var errs []pdf.PDFError
+	ocErr := func(c pdf.Check, cfg pdf.PDFDict, msg string) {
+		var ref *pdf.PDFRef
+		if r, ok := cfg.Entries["_ref"].(pdf.PDFRef); ok {
+			ref = &r
+		}
+		errs = append(errs, pdf.NewError(c, []error{fmt.Errorf("%s", msg)}, 0, ref))
+	}
+	seen := map[string]bool{}
+	for _, cfg := range configs {
+		name, ok := textString(cfg.Entries["Name"])
+		switch {
+		case !ok:
+			ocErr(pdf.Checks.PDFA2.OptionalContent.OCConfigName, cfg, "optional content configuration has no Name")
+		case seen[name]:
+			ocErr(pdf.Checks.PDFA2.OptionalContent.OCConfigNameDuplicate, cfg, fmt.Sprintf("optional content configuration Name %q is not unique", name))
+		}
+		seen[name] = true
+		if cfg.Entries["AS"] != nil {
+			ocErr(pdf.Checks.PDFA2.OptionalContent.OCConfigAS, cfg, "optional content configuration shall not contain AS")
+		}
+		if order, ok := cfg.Entries["Order"].(pdf.PDFArray); ok {
+			listed := map[uintptr]bool{}
+			CollectOrderGroups(order, listed, 0)
+			for g := range groups {
+				if !listed[g] {
+					ocErr(pdf.Checks.PDFA2.OptionalContent.OCOrderIncomplete, cfg, "optional content configuration Order does not list every optional content group")
+					break
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// CollectOrderGroups records the OCGs named anywhere in an Order array,
+// which nests arrays for sub-hierarchies.
+func CollectOrderGroups(order pdf.PDFArray, into map[uintptr]bool, depth int) {
+	if depth > 32 {
+		return
+	}
+	for _, item := range order {
+		switch v := item.(type) {
+		case pdf.PDFDict:
+			into[pdf.ValuePointer(v.Entries)] = true
+		case pdf.PDFArray:
+			CollectOrderGroups(v, into, depth+1)
+		}
+	}
+}
+
+// textString returns the decoded value of a text string, and whether v is a
+// string at all.
+func textString(v pdf.PDFValue) (string, bool) {
+	switch s := v.(type) {
+	case pdf.PDFString:
+		return pdf.DecodePDFTextString([]byte(s.Value)), true
+	case pdf.PDFHexString:
+		return pdf.DecodePDFTextString(pdf.DecodePDFHexStringBytes(s.Value)), true
+	}
+	return "", false
+}
@@ -0,0 +1,304 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+func TestTranslateIssues(t *testing.T) {
+	mk := func(c pdf.Check) pdf.PDFError {
+		return pdf.NewError(c, []error{errors.New("x")}, 0, nil)
+	}
+	in := []pdf.PDFError{
+		mk(pdf.Checks.Structure.TrailerEOF),
+		mk(pdf.Checks.Transparency.SoftMaskExtGState), // no part 2 counterpart
+		mk(pdf.Checks.ObjectModel.MissingRequiredKey),
+	}
+	out := translateIssues(in, pdf.SpecPDFA2)
+	if len(out) != 2 {
+		t.Fatalf("translateIssues kept %d issues, want 2: %v", len(out), out)
+	}
+	if out[0].Check() != pdf.Checks.PDFA2.Structure.TrailerEOF {
+		t.Errorf("TrailerEOF translated to %v, want the PDFA2 check", out[0].Check())
+	}
+	if out[1].Check() != pdf.Checks.ObjectModel.MissingRequiredKey {
+		t.Errorf("objmodel check translated to %v, want it unchanged", out[1].Check())
+	}
+}
+
+func TestIsStandardBlendMode(t *testing.T) {
+	for _, tc := range []struct {
+		bm   pdf.PDFValue
+		want bool
+	}{
+		{pdf.PDFName{Value: "Multiply"}, true},
+		{pdf.PDFName{Value: "Fancy"}, false},
+		{pdf.PDFArray{pdf.PDFName{Value: "Screen"}, pdf.PDFName{Value: "Normal"}}, true},
+		{pdf.PDFArray{pdf.PDFName{Value: "Screen"}, pdf.PDFInteger(1)}, false},
+		{pdf.PDFArray{}, false},
+		{pdf.PDFInteger(0), false},
+	} {
+		if got := IsStandardBlendMode(tc.bm); got != tc.want {
+			t.Errorf("IsStandardBlendMode(%v) = %v, want %v", tc.bm, got, tc.want)
+		}
+	}
+}
+
+func TestValidateExtGStatePdfA2(t *testing.T) {
+	gs := pdf.NewPDFDict()
+	gs.Entries["HTP"] = pdf.PDFArray{}
+	gs.Entries["BM"] = pdf.PDFName{Value: "Fancy"}
+	ht := pdf.NewPDFDict()
+	ht.Entries["HalftoneType"] = pdf.PDFInteger(6)
+	ht.Entries["HalftoneName"] = pdf.PDFString{Value: "x"}
+	gs.Entries["HT"] = ht
+	ctx := &ValidationContext{part: 2}
+	validateExtGStatePdfA2(gs, ctx)
+	for _, c := range []pdf.Check{
+		pdf.Checks.PDFA2.Colour.HalftonePhase,
+		pdf.Checks.PDFA2.Colour.HalftoneType,
+		pdf.Checks.PDFA2.Colour.HalftoneName,
+		pdf.Checks.PDFA2.Transparency.BlendMode,
+	} {
+		if !hasCheck(ctx, c) {
+			t.Errorf("expected %s", c.Name())
+		}
+	}
+}
+
+func TestValidateHalftoneType5(t *testing.T) {
+	comp := func(withTR bool) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.Entries["HalftoneType"] = pdf.PDFInteger(1)
+		if withTR {
+			d.Entries["TransferFunction"] = pdf.PDFName{Value: "Identity"}
+		}
+		return d
+	}
+	ht := pdf.NewPDFDict()
+	ht.Entries["HalftoneType"] = pdf.PDFInteger(5)
+	ht.Entries["Spot1"] = comp(true) // not a primary colourant: allowed
+	ht.Entries["Cyan"] = comp(false)
+
+	ctx := &ValidationContext{part: 2}
+	validateHalftone(pdf.NewPDFDict(), ht, ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("unexpected issues: %v", ctx.errs)
+	}
+
+	ht.Entries["Cyan"] = comp(true)
+	validateHalftone(pdf.NewPDFDict(), ht, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Colour.HalftoneTransferFunction) {
+		t.Error("expected HalftoneTransferFunction for a primary colourant")
+	}
+	if len(ctx.keyScratch) != 0 {
+		t.Errorf("keyScratch not restored: %v", ctx.keyScratch)
+	}
+}
+
+func TestValidatePageBoundaries(t *testing.T) {
+	page := pdf.NewPDFDict()
+	page.Entries["MediaBox"] = pdf.PDFArray{pdf.PDFInteger(0), pdf.PDFInteger(0), pdf.PDFInteger(612), pdf.PDFInteger(792)}
+	ctx := &ValidationContext{}
+	validatePageBoundaries(page, ctx)
+	if len(ctx.errs) != 0 {
+		t.Fatalf("letter page flagged: %v", ctx.errs)
+	}
+
+	// UserUnit scales the boundary past 14400 units.
+	page.Entries["UserUnit"] = pdf.PDFInteger(30)
+	validatePageBoundaries(page, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Structure.PageBoundaryOutOfRange) {
+		t.Error("expected PageBoundaryOutOfRange with UserUnit 30")
+	}
+}
+
+func TestValidateNameEncoding(t *testing.T) {
+	ctx := &ValidationContext{}
+	validateNameEncoding("Caf#C3#A9", nil, ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("UTF-8 name flagged: %v", ctx.errs)
+	}
+	validateNameEncoding("Caf#E9", nil, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Structure.NameNotUTF8) {
+		t.Error("expected NameNotUTF8 for a Latin-1 escape")
+	}
+}
+
+func TestValidateStreamCryptFilter(t *testing.T) {
+	stm := pdf.NewPDFDict()
+	stm.HasStream = true
+	stm.Entries["Filter"] = pdf.PDFName{Value: "Crypt"}
+	ctx := &ValidationContext{}
+	validateStreamCryptFilter(stm, ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("Crypt filter without parameters (Identity) flagged: %v", ctx.errs)
+	}
+
+	parms := pdf.NewPDFDict()
+	parms.Entries["Name"] = pdf.PDFName{Value: "StdCF"}
+	stm.Entries["DecodeParms"] = parms
+	validateStreamCryptFilter(stm, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Structure.StreamCryptFilter) {
+		t.Error("expected StreamCryptFilter for a non-Identity crypt filter")
+	}
+}
+
+func TestValidateAnnotationPdfA2(t *testing.T) {
+	annot := func(subtype string, flags int) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.Entries["Subtype"] = pdf.PDFName{Value: subtype}
+		d.Entries["F"] = pdf.PDFInteger(flags)
+		d.Entries["Rect"] = pdf.PDFArray{pdf.PDFInteger(5), pdf.PDFInteger(5), pdf.PDFInteger(5), pdf.PDFInteger(5)}
+		return d
+	}
+
+	// A zero-area Popup without the Print flag needs neither.
+	ctx := &ValidationContext{part: 2}
+	validateAnnotationPdfA2(annot("Popup", 0), "Popup", ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("zero-area Popup flagged: %v", ctx.errs)
+	}
+
+	ctx = &ValidationContext{part: 2}
+	validateAnnotationPdfA2(annot("Text", AnnotFlagPrint|AnnotFlagToggleNoView), "Text", ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Annotation.ToggleNoViewFlagSet) {
+		t.Error("expected ToggleNoViewFlagSet")
+	}
+
+	ctx = &ValidationContext{part: 2}
+	validateAnnotationPdfA2(annot("3D", AnnotFlagPrint), "3D", ctx)
+	if !hasCheck(ctx, pdf.Checks.Annotation.DisallowedSubtype) {
+		t.Error("expected DisallowedSubtype for a 3D annotation")
+	}
+}
+
+// j2kCodestream returns SOC + a SIZ segment for a 1x1 image whose
+// components have the given bit depths.
+func j2kCodestream(depths ...int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0x4F, 0xFF, 0x51})
+	binary.Write(&b, binary.BigEndian, uint16(38+3*len(depths)))
+	binary.Write(&b, binary.BigEndian, uint16(0))
+	for _, v := range []uint32{1, 1, 0, 0, 1, 1, 0, 0} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	binary.Write(&b, binary.BigEndian, uint16(len(depths)))
+	for _, d := range depths {
+		b.Write([]byte{byte(d - 1), 1, 1})
+	}
+	return b.Bytes()
+}
+
+func TestValidateJPXImage(t *testing.T) {
+	img := func(data []byte) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.HasStream = true
+		d.Entries["Subtype"] = pdf.PDFName{Value: "Image"}
+		d.Entries["Filter"] = pdf.PDFName{Value: "JPXDecode"}
+		d.RawStream = data
+		return d
+	}
+
+	ctx := &ValidationContext{part: 2}
+	validateJPXImage(img(j2kCodestream(8, 8, 8)), ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("3-channel 8-bit codestream flagged: %v", ctx.errs)
+	}
+
+	validateJPXImage(img(j2kCodestream(8, 8)), ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Image.JPXChannels) {
+		t.Error("expected JPXChannels for 2 channels")
+	}
+	validateJPXImage(img(j2kCodestream(8, 16, 8)), ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Image.JPXBitDepth) {
+		t.Error("expected JPXBitDepth for unequal bit depths")
+	}
+
+	// An ASCIIHexDecode wrapping is undone before the JPEG2000 data is
+	// judged.
+	ctx = &ValidationContext{part: 2}
+	wrapped := img([]byte(hex.EncodeToString(j2kCodestream(8, 8)) + ">"))
+	wrapped.Entries["Filter"] = pdf.PDFArray{pdf.PDFName{Value: "ASCIIHexDecode"}, pdf.PDFName{Value: "JPXDecode"}}
+	validateJPXImage(wrapped, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA2.Image.JPXChannels) {
+		t.Error("expected JPXChannels for 2 channels behind ASCIIHexDecode")
+	}
+
+	// Undecodable data is not judged.
+	ctx = &ValidationContext{part: 2}
+	validateJPXImage(img([]byte("garbage")), ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("undecodable JPX data flagged: %v", ctx.errs)
+	}
+}
+
+func TestValidateICCProfilePart2(t *testing.T) {
+	stream := func(major byte, class string) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.HasStream = true
+		d.Entries["N"] = pdf.PDFInteger(3)
+		d.RawStream = buildValidICCProfile()
+		d.RawStream[8] = major
+		copy(d.RawStream[12:16], class)
+		return d
+	}
+	if err := validateICCProfile(stream(4, "prtr"), 2); err != nil {
+		t.Errorf("ICC v4 output profile rejected under part 2: %v", err)
+	}
+	if err := validateICCProfile(stream(4, "prtr"), 1); err == nil {
+		t.Error("ICC v4 accepted under part 1")
+	}
+	if err := validateICCProfile(stream(2, "scnr"), 2); err == nil {
+		t.Error("input device class accepted under part 2")
+	}
+}
+
+func TestVerifyOptionalContentConfigs(t *testing.T) {
+	ocg := func() pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.Entries["Type"] = pdf.PDFName{Value: "OCG"}
+		return d
+	}
+	g1, g2 := ocg(), ocg()
+	cfg := func(name string) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		if name != "" {
+			d.Entries["Name"] = pdf.PDFString{Value: name}
+		}
+		return d
+	}
+
+	dflt := cfg("Default")
+	dflt.Entries["Order"] = pdf.PDFArray{g1, pdf.PDFArray{g2}}
+	oc := pdf.NewPDFDict()
+	oc.Entries["OCGs"] = pdf.PDFArray{g1, g2}
+	oc.Entries["D"] = dflt
+	if errs := verifyOptionalContentConfigs(oc); len(errs) != 0 {
+		t.Fatalf("conforming OCProperties flagged: %v", errs)
+	}
+
+	dup := cfg("Default")
+	dup.Entries["AS"] = pdf.PDFArray{}
+	dup.Entries["Order"] = pdf.PDFArray{g1}
+	oc.Entries["Configs"] = pdf.PDFArray{dup, cfg("")}
+	got := map[pdf.Check]bool{}
+	for _, e := range verifyOptionalContentConfigs(oc) {
+		got[e.Check()] = true
+	}
+	for _, c := range []pdf.Check{
+		pdf.Checks.PDFA2.OptionalContent.OCConfigName,
+		pdf.Checks.PDFA2.OptionalContent.OCConfigNameDuplicate,
+		pdf.Checks.PDFA2.OptionalContent.OCConfigAS,
+		pdf.Checks.PDFA2.OptionalContent.OCOrderIncomplete,
+	} {
+		if !got[c] {
+			t.Errorf("expected %s", c.Name())
+		}
+	}
+}
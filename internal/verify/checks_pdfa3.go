@@ -0,0 +1,122 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// PDF/A-3b (ISO 19005-3:2012)
+//
+// Part 3 is part 2 with clause 6.8 replaced: an embedded file may be of any
+// format, but shall be an associated file. verifyPdfAParts runs with part 3,
+// which takes every part 2 path except the embedded-file rules, and
+// translateIssues renumbers the findings into ISO 19005-3 clauses (the same
+// numbers as part 2). The 6.8 rules are implemented here and report against
+// pdf.Checks.PDFA3 directly. A profile enabling the Factur-X checks adds
+// the hybrid invoice rules (see verifyInvoice).
+
+func verifyPdfA3bParts(d *pdf.Reader, p *pdf.Profile) Parts {
+	pt := verifyPdfAParts(d, p, 3).translate(pdf.SpecPDFA3)
+	if p.EnablesSpec(pdf.SpecFacturX) && !p.OnlyObjectModelChecks() {
+		if graph, err := d.ResolveGraph(); err == nil {
+			pt.Graph = append(pt.Graph, verifyInvoice(d, graph)...)
+		}
+	}
+	return pt
+}
+
+// afRelationships are the AFRelationship values ISO 19005-3 6.8 permits.
+var afRelationships = map[string]bool{
+	"Source":      true,
+	"Data":        true,
+	"Alternative": true,
+	"Supplement":  true,
+	"Unspecified": true,
+}
+
+// IsAFRelationship reports whether name is an AFRelationship value ISO
+// 19005-3 6.8 permits.
+func IsAFRelationship(name string) bool {
+	return afRelationships[name]
+}
+
+// collectAssociatedFiles records the file specifications listed in v's AF
+// array, for reportUnassociatedFiles.
+func collectAssociatedFiles(v pdf.PDFDict, ctx *ValidationContext) {
+	af, ok := v.Entries["AF"].(pdf.PDFArray)
+	if !ok {
+		return
+	}
+	for _, e := range af {
+		if fs, ok := e.(pdf.PDFDict); ok {
+			if ctx.associatedFiles == nil {
+				ctx.associatedFiles = make(map[uintptr]bool)
+			}
+			ctx.associatedFiles[ctx.nodeKey(pdf.ValuePointer(fs.Entries))] = true
+		}
+	}
+}
+
+// validateAssociatedFileSpec checks a file specification carrying embedded
+// files (6.8): it shall have F, UF and a permitted AFRelationship, and every
+// embedded file stream a MIME type Subtype and a Params ModDate. Whether the
+// specification is associated is only known once the walk has seen every AF
+// array, so it is queued for reportUnassociatedFiles.
+func validateAssociatedFileSpec(spec, ef pdf.PDFDict, ctx *ValidationContext) {
+	checks := pdf.Checks.PDFA3.EmbeddedFile
+	if spec.Entries["F"] == nil || spec.Entries["UF"] == nil {
+		ctx.Report(checks.EmbeddedFileSpecKeys, spec, "embedded file specification shall contain both F and UF")
+	}
+	switch rel, ok := spec.Entries["AFRelationship"].(pdf.PDFName); {
+	case !ok:
+		ctx.Report(checks.AFRelationship, spec, "embedded file specification shall contain an AFRelationship name")
+	case !IsAFRelationship(rel.Value):
+		ctx.Report(checks.AFRelationship, spec, fmt.Sprintf("AFRelationship /%s is not a value permitted by ISO 19005-3", rel.Value))
+	}
+
+	keysBase := len(ctx.keyScratch)
+	defer func() { ctx.keyScratch = ctx.keyScratch[:keysBase] }()
+	for _, k := range ctx.sortedKeys(ef.Entries) {
+		stm, ok := ef.Entries[k].(pdf.PDFDict)
+		if !ok || !stm.HasStream {
+			continue
+		}
+		if sub, ok := stm.Entries["Subtype"].(pdf.PDFName); !ok || !IsMIMEType(string(pdf.DecodePDFName(sub.Value))) {
+			ctx.Report(checks.EmbeddedFileMIMEType, spec, fmt.Sprintf("embedded file %s has no MIME type Subtype", k))
+		}
+		params, _ := stm.Entries["Params"].(pdf.PDFDict)
+		if _, ok := params.Entries["ModDate"].(pdf.PDFString); !ok {
+			ctx.Report(checks.EmbeddedFileModDate, spec, fmt.Sprintf("embedded file %s has no Params ModDate", k))
+		}
+	}
+	ctx.embeddedFileSpecs = append(ctx.embeddedFileSpecs, embeddedFileSpec{spec, ctx.nodeKey(pdf.ValuePointer(spec.Entries))})
+}
+
+// embeddedFileSpec is a file specification queued for
+// reportUnassociatedFiles, with its associatedFiles key.
+type embeddedFileSpec struct {
+	dict pdf.PDFDict
+	key  uintptr
+}
+
+// IsMIMEType reports whether s has the type/subtype form of a MIME media
+// type (RFC 2046), e.g. text/xml.
+func IsMIMEType(s string) bool {
+	typ, sub, ok := strings.Cut(s, "/")
+	return ok && typ != "" && sub != "" && !strings.ContainsAny(s, " \t\r\n")
+}
+
+// reportUnassociatedFiles reports every embedded file specification the walk
+// found that no AF array lists (6.8). The finding concerns the document, not
+// the page the walk happened to end on.
+func reportUnassociatedFiles(ctx *ValidationContext) {
+	ctx.CurrentPage = 0
+	for _, spec := range ctx.embeddedFileSpecs {
+		if !ctx.associatedFiles[spec.key] {
+			ctx.Report(pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileNotAssociated, spec.dict,
+				"embedded file specification is not listed in any AF array")
+		}
+	}
+}
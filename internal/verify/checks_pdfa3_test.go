@@ -0,0 +1,84 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// attachment returns a file specification embedding one file, with the
+// part 3 keys present unless dropped.
+func attachment(drop ...string) pdf.PDFDict {
+	params := pdf.NewPDFDict()
+	params.Entries["ModDate"] = pdf.PDFString{Value: "D:20240101000000Z"}
+	stm := pdf.NewPDFDict()
+	stm.HasStream = true
+	stm.RawStream = []byte("<Invoice/>")
+	stm.Entries["Type"] = pdf.PDFName{Value: "EmbeddedFile"}
+	stm.Entries["Subtype"] = pdf.PDFName{Value: "text#2Fxml"}
+	stm.Entries["Params"] = params
+	ef := pdf.NewPDFDict()
+	ef.Entries["F"] = stm
+	spec := pdf.NewPDFDict()
+	spec.Entries["Type"] = pdf.PDFName{Value: "Filespec"}
+	spec.Entries["F"] = pdf.PDFString{Value: "invoice.xml"}
+	spec.Entries["UF"] = pdf.PDFString{Value: "invoice.xml"}
+	spec.Entries["AFRelationship"] = pdf.PDFName{Value: "Source"}
+	spec.Entries["EF"] = ef
+	for _, k := range drop {
+		delete(spec.Entries, k)
+		delete(stm.Entries, k)
+		delete(params.Entries, k)
+	}
+	return spec
+}
+
+func TestValidateAssociatedFileSpec(t *testing.T) {
+	ctx := &ValidationContext{part: 3}
+	spec := attachment()
+	catalog := pdf.NewPDFDict()
+	catalog.Entries["AF"] = pdf.PDFArray{spec}
+	validatePdfA2Object(catalog, ctx)
+	validatePdfA2Object(spec, ctx)
+	reportUnassociatedFiles(ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("conforming associated file flagged: %v", ctx.errs)
+	}
+
+	ctx = &ValidationContext{part: 3}
+	validatePdfA2Object(attachment("UF", "AFRelationship", "Subtype", "ModDate"), ctx)
+	reportUnassociatedFiles(ctx)
+	e := pdf.Checks.PDFA3.EmbeddedFile
+	for _, c := range []pdf.Check{e.EmbeddedFileSpecKeys, e.AFRelationship, e.EmbeddedFileMIMEType, e.EmbeddedFileModDate, e.EmbeddedFileNotAssociated} {
+		if !hasCheck(ctx, c) {
+			t.Errorf("expected %s", c.Name())
+		}
+	}
+	if hasCheck(ctx, pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA) {
+		t.Error("part 3 permits embedded files that are not PDF/A")
+	}
+
+	ctx = &ValidationContext{part: 3}
+	bad := attachment()
+	bad.Entries["AFRelationship"] = pdf.PDFName{Value: "Whatever"}
+	bad.Entries["EF"].(pdf.PDFDict).Entries["F"].(pdf.PDFDict).Entries["Subtype"] = pdf.PDFName{Value: "xml"}
+	validatePdfA2Object(bad, ctx)
+	if !hasCheck(ctx, e.AFRelationship) || !hasCheck(ctx, e.EmbeddedFileMIMEType) {
+		t.Errorf("expected AFRelationship and EmbeddedFileMIMEType, got %v", ctx.errs)
+	}
+}
+
+func TestIsMIMEType(t *testing.T) {
+	for s, want := range map[string]bool{
+		"text/xml":                 true,
+		"application/octet-stream": true,
+		"xml":                      false,
+		"/xml":                     false,
+		"text/":                    false,
+		"text/x ml":                false,
+	} {
+		if got := IsMIMEType(s); got != want {
+			t.Errorf("IsMIMEType(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package verify
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// PDF/A-4 (ISO 19005-4:2020)
+//
+// Part 4 is based on PDF 2.0. verifyPdfAParts runs with part 4, which takes
+// every part 3 path and validates the object graph against the PDF 2.0
+// Arlington model (ValidationContext.objectModel); translateIssues then
+// renumbers the findings into ISO 19005-4 clauses. The rules part 4 changes
+// -- the file header version, the catalog Version, the document information
+// dictionary, annotation types and the embedded files of the 4e and 4f
+// variants -- are implemented here and report against pdf.Checks.PDFA4
+// directly.
+
+func verifyPdfA4Parts(d *pdf.Reader, p *pdf.Profile) Parts {
+	return verifyPdfAParts(d, p, 4).translate(pdf.SpecPDFA4)
+}
+
+// verifyPdfA4FileHeader checks that a well-formed file header names PDF
+// version 2.n (6.1.2). A malformed header is left to verifyFileHeader.
+func verifyPdfA4FileHeader(d *pdf.Reader) []pdf.PDFError {
+	buf := make([]byte, 16)
+	n, _ := d.ReadAt(buf, 0)
+	header, ok := pdf.NewCursor(buf[:n]).ReadLine()
+	if !ok || !pdfVersionRe.MatchString(header) || strings.HasPrefix(header, "%PDF-2.") {
+		return nil
+	}
+	return []pdf.PDFError{pdf.NewError(
+		pdf.Checks.PDFA4.Structure.FileHeaderSignature,
+		[]error{fmt.Errorf("invalid PDF header: %q (must be %%PDF-2.n)", header)},
+		1,
+		nil,
+	)}
+}
+
+// verifyPdfA4Document runs the catalog- and trailer-level rules part 4
+// changes: the catalog Version (6.1.2) and the document information
+// dictionary, which is only permitted alongside PieceInfo and then only
+// with a ModDate (6.1.3).
+func verifyPdfA4Document(graph pdf.PDFValue) []pdf.PDFError {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return nil
+	}
+	checks := pdf.Checks.PDFA4.Structure
+	var errs []pdf.PDFError
+	docErr := func(c pdf.Check, format string, args ...any) {
+		errs = append(errs, pdf.NewError(c, []error{fmt.Errorf(format, args...)}, 0, nil))
+	}
+
+	if v, ok := root.Entries["Version"].(pdf.PDFName); ok && !strings.HasPrefix(v.Value, "2.") {
+		docErr(checks.CatalogVersion, "catalog Version /%s is not a PDF 2.n version", v.Value)
+	}
+	info, ok := trailer.Entries["Info"].(pdf.PDFDict)
+	if !ok {
+		return errs
+	}
+	if root.Entries["PieceInfo"] == nil {
+		docErr(checks.InfoDictWithoutPieceInfo, "trailer contains Info but the catalog has no PieceInfo")
+	}
+	for _, k := range slices.Sorted(maps.Keys(info.Entries)) {
+		if k != "ModDate" && k != "_ref" {
+			docErr(checks.InfoDictKeys, "document information dictionary shall contain only ModDate, found %s", k)
+		}
+	}
+	return errs
+}
+
+// annotationTypesPdfA4 are the annotation subtypes ISO 19005-4 permits
+// (6.3.1): those defined by ISO 32000-2 except Sound, Screen and Movie.
+// engineeringAnnotationTypes among them are permitted in PDF/A-4e only.
+var (
+	annotationTypesPdfA4 = map[string]bool{
+		"Text": true, "Link": true, "FreeText": true, "Line": true, "Square": true,
+		"Circle": true, "Polygon": true, "PolyLine": true, "Highlight": true,
+		"Underline": true, "Squiggly": true, "StrikeOut": true, "Stamp": true,
+		"Caret": true, "Ink": true, "Popup": true, "FileAttachment": true,
+		"Widget": true, "PrinterMark": true, "TrapNet": true, "Watermark": true,
+		"3D": true, "Redact": true, "Projection": true, "RichMedia": true,
+	}
+	engineeringAnnotationTypes = map[string]bool{"3D": true, "RichMedia": true}
+)
+
+// annotationSubtypeAllowed reports whether the part being verified permits
+// annotations of the given subtype (6.3.1 of parts 2 to 4).
+func annotationSubtypeAllowed(subtype string, ctx *ValidationContext) bool {
+	if ctx.part >= 4 {
+		return annotationTypesPdfA4[subtype] && (!engineeringAnnotationTypes[subtype] || ctx.level == pdf.A_4E)
+	}
+	return !ForbiddenAnnotationTypesPdfA2[subtype] && iso32000AnnotationTypes[subtype]
+}
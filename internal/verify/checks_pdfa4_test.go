@@ -0,0 +1,208 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+func TestValidateAgainstSchemaPart4UsesPDF20Model(t *testing.T) {
+	vp := pdf.NewPDFDict()
+	vp.Entries["Duplex"] = pdf.PDFName{Value: "Bogus"}
+
+	// Under the 1.4 model Duplex is merely a later key.
+	ctx := &ValidationContext{part: 1}
+	validateAgainstSchema(vp, "ViewerPreferences", ctx)
+	if !hasCheck(ctx, pdf.Checks.ObjectModel.KeyIntroducedAfterPDF14) || hasCheck(ctx, pdf.Checks.ObjectModel.DisallowedValue) {
+		t.Errorf("part 1: want only KeyIntroducedAfterPDF14, got %v", ctx.errs)
+	}
+
+	// Under the 2.0 model it is a defined key whose value is checked.
+	ctx = &ValidationContext{part: 4}
+	validateAgainstSchema(vp, "ViewerPreferences", ctx)
+	if !hasCheck(ctx, pdf.Checks.ObjectModel.DisallowedValue) {
+		t.Errorf("part 4: expected DisallowedValue for Duplex /Bogus, got %v", ctx.errs)
+	}
+	if hasCheck(ctx, pdf.Checks.ObjectModel.KeyIntroducedAfterPDF14) || hasCheck(ctx, pdf.Checks.ObjectModel.KeyIntroducedAfterPDF17) {
+		t.Errorf("part 4 shall not flag keys as introduced too late, got %v", ctx.errs)
+	}
+}
+
+func TestVerifyPdfA4Document(t *testing.T) {
+	build := func(version string, info pdf.PDFDict, pieceInfo bool) pdf.PDFDict {
+		trailer := pdf.NewPDFDict()
+		minimalConformantRoot(trailer)
+		root := trailer.Entries["Root"].(pdf.PDFDict)
+		if version != "" {
+			root.Entries["Version"] = pdf.PDFName{Value: version}
+		}
+		if pieceInfo {
+			root.Entries["PieceInfo"] = pdf.NewPDFDict()
+		}
+		if info.Entries != nil {
+			trailer.Entries["Info"] = info
+		}
+		return trailer
+	}
+	modDateOnly := pdf.NewPDFDict()
+	modDateOnly.Entries["ModDate"] = pdf.PDFString{Value: "D:20240101000000Z"}
+	withTitle := pdf.NewPDFDict()
+	withTitle.Entries["ModDate"] = pdf.PDFString{Value: "D:20240101000000Z"}
+	withTitle.Entries["Title"] = pdf.PDFString{Value: "Report"}
+
+	s := pdf.Checks.PDFA4.Structure
+	for _, tc := range []struct {
+		name    string
+		trailer pdf.PDFDict
+		want    []pdf.Check
+	}{
+		{"conforming", build("2.0", pdf.PDFDict{}, false), nil},
+		{"ModDate with PieceInfo", build("", modDateOnly, true), nil},
+		{"version 1.7", build("1.7", pdf.PDFDict{}, false), []pdf.Check{s.CatalogVersion}},
+		{"Info without PieceInfo", build("", modDateOnly, false), []pdf.Check{s.InfoDictWithoutPieceInfo}},
+		{"Info with Title", build("", withTitle, true), []pdf.Check{s.InfoDictKeys}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := verifyPdfA4Document(tc.trailer)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("got %v, want %v", errs, tc.want)
+			}
+			for i, c := range tc.want {
+				if errs[i].Check() != c {
+					t.Errorf("issue %d = %s, want %s", i, errs[i].Check().Name(), c.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyPdfA4FileHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "valid.pdf")
+	if err := createValidPDF(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for header, want := range map[string]bool{
+		"%PDF-2.0": false,
+		"%PDF-1.7": true,
+		"%PDF-x.y": false, // malformed: verifyFileHeader's finding
+	} {
+		// Same length as createValidPDF's header, so the xref offsets hold.
+		d, err := pdf.OpenBytes(append([]byte(header), data[len(header):]...))
+		if err != nil {
+			t.Fatalf("%s: OpenBytes: %v", header, err)
+		}
+		got := len(verifyPdfA4FileHeader(d)) > 0
+		d.Close()
+		if got != want {
+			t.Errorf("%s flagged = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestAnnotationSubtypeAllowedPdfA4(t *testing.T) {
+	for _, tc := range []struct {
+		subtype string
+		level   pdf.LevelType
+		want    bool
+	}{
+		{"Text", pdf.A_4, true},
+		{"Projection", pdf.A_4, true},
+		{"Screen", pdf.A_4, false},
+		{"Sound", pdf.A_4E, false},
+		{"3D", pdf.A_4, false},
+		{"3D", pdf.A_4E, true},
+		{"RichMedia", pdf.A_4F, false},
+		{"RichMedia", pdf.A_4E, true},
+	} {
+		ctx := &ValidationContext{part: 4, level: tc.level}
+		if got := annotationSubtypeAllowed(tc.subtype, ctx); got != tc.want {
+			t.Errorf("annotationSubtypeAllowed(%s, %s) = %v, want %v", tc.subtype, tc.level, got, tc.want)
+		}
+	}
+	// Projection is not an ISO 32000-1 type, so part 2 still rejects it.
+	if annotationSubtypeAllowed("Projection", &ValidationContext{part: 2}) {
+		t.Error("part 2 shall not permit Projection annotations")
+	}
+}
+
+func TestEmbeddedFilesPdfA4(t *testing.T) {
+	// attachment embeds XML, which is not a PDF/A file.
+	ctx := &ValidationContext{part: 4, level: pdf.A_4F}
+	validatePdfA2Object(attachment(), ctx)
+	if hasCheck(ctx, pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA) {
+		t.Error("PDF/A-4f permits embedded files that are not PDF/A")
+	}
+	reportUnassociatedFiles(ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFA3.EmbeddedFile.EmbeddedFileNotAssociated) {
+		t.Error("expected EmbeddedFileNotAssociated under PDF/A-4f")
+	}
+
+	for _, level := range []pdf.LevelType{pdf.A_4, pdf.A_4E} {
+		ctx := &ValidationContext{part: 4, level: level}
+		validatePdfA2Object(attachment("AFRelationship"), ctx)
+		if !hasCheck(ctx, pdf.Checks.PDFA2.EmbeddedFile.EmbeddedFileNotPDFA) {
+			t.Errorf("%s: expected EmbeddedFileNotPDFA", level)
+		}
+		if !hasCheck(ctx, pdf.Checks.PDFA3.EmbeddedFile.AFRelationship) {
+			t.Errorf("%s: expected AFRelationship", level)
+		}
+	}
+}
+
+func TestCheckPDFAIdentifierPart4(t *testing.T) {
+	xmp := func(props string) string {
+		return `<rdf:Description xmlns:pdfaid="` + pdfaIDNamespace + `">` + props + `</rdf:Description>`
+	}
+	m := pdf.Checks.PDFA4.Metadata
+	const id = `<pdfaid:part>4</pdfaid:part><pdfaid:rev>2020</pdfaid:rev>`
+	conf := func(c string) string { return id + `<pdfaid:conformance>` + c + `</pdfaid:conformance>` }
+	for _, tc := range []struct {
+		name  string
+		level pdf.LevelType
+		props string
+		want  pdf.Check
+	}{
+		{"plain", pdf.A_4, id, pdf.Check{}},
+		{"plain declaring E", pdf.A_4, conf("E"), m.PDFAConformanceLevel},
+		{"plain declaring F", pdf.A_4, conf("F"), m.PDFAConformanceLevel},
+		{"4e", pdf.A_4E, conf("E"), pdf.Check{}},
+		{"4e declaring F", pdf.A_4E, conf("F"), m.PDFAConformanceLevel},
+		{"4e declaring none", pdf.A_4E, id, m.PDFAIdentifierMissing},
+		{"4f", pdf.A_4F, conf("F"), pdf.Check{}},
+		{"4f declaring E", pdf.A_4F, conf("E"), m.PDFAConformanceLevel},
+		{"4f declaring none", pdf.A_4F, id, m.PDFAIdentifierMissing},
+		{"no rev", pdf.A_4, `<pdfaid:part>4</pdfaid:part>`, m.PDFAIdentifierMissing},
+		{"bad rev", pdf.A_4, `<pdfaid:part>4</pdfaid:part><pdfaid:rev>20</pdfaid:rev>`, m.PDFARevision},
+		{"level B", pdf.A_4F, conf("B"), m.PDFAConformanceLevel},
+		{"part 2", pdf.A_4, `<pdfaid:part>2</pdfaid:part><pdfaid:rev>2020</pdfaid:rev>`, pdf.Checks.Metadata.PDFAPartNumber},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := checkPDFAIdentifier(xmp(tc.props), 4, tc.level)
+			if tc.want == (pdf.Check{}) {
+				if len(errs) != 0 {
+					t.Errorf("unexpected issues %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Check() != tc.want {
+				t.Errorf("got %v, want %s", errs, tc.want.Name())
+			}
+		})
+	}
+
+	// rev is only a defined pdfaid property from part 4 on.
+	rev := []byte(xmp(`<pdfaid:rev>2020</pdfaid:rev>`))
+	if errs := checkXMPPropertySchemas(rev, 4); len(errs) != 0 {
+		t.Errorf("part 4 flagged pdfaid:rev: %v", errs)
+	}
+	if errs := checkXMPPropertySchemas(rev, 2); len(errs) == 0 || !strings.Contains(errs[0].Error(), "rev") {
+		t.Errorf("part 2 shall flag pdfaid:rev, got %v", errs)
+	}
+}
@@ -0,0 +1,597 @@
+package verify
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// PDF/UA-1 (ISO 14289-1:2014)
+//
+// PDF/UA-1 adds no file structure rules of its own to ISO 32000-1, so it has
+// no structural parts: verifyPdfUA1Parts runs the object-model walk, the level
+// A logical structure checks (whose findings translateIssues renumbers into
+// ISO 14289-1 clauses, dropping those with no PDF/UA counterpart) and the rules
+// below, which report against pdf.Checks.PDFUA1 directly. Only the
+// machine-checkable failure conditions are covered, as in the Matterhorn
+// Protocol: whether an Alt text is meaningful, or the tags follow the logical
+// reading order, is left to a human.
+
+func verifyPdfUA1Parts(d *pdf.Reader, p *pdf.Profile) Parts {
+	var pt Parts
+	graph, err := d.ResolveGraph()
+	if d.Err() != nil {
+		return pt
+	}
+	if err != nil {
+		pt.Graph = []pdf.PDFError{pdf.NewError(pdf.Checks.PDFUA1.Document.GraphResolutionFailure, []error{err}, 0, nil)}
+		return pt
+	}
+	pageIndex, err := d.BuildPageIndex(graph)
+	if err != nil {
+		pt.Graph = []pdf.PDFError{pdf.NewError(pdf.Checks.PDFUA1.Document.GraphResolutionFailure, []error{err}, 0, nil)}
+		return pt
+	}
+
+	// The object-model walk runs schema-only: its PDF/A families have no
+	// PDF/UA counterpart.
+	ctx := &ValidationContext{
+		PageIndex:  pageIndex,
+		reader:     d,
+		schemaOnly: true,
+		level:      p.Level,
+	}
+	verifyDocument(graph, ctx)
+	if p.OnlyObjectModelChecks() || ctx.stopped() {
+		pt.Graph = ctx.errs
+		return pt
+	}
+
+	ctx.CurrentPage = 0
+	_, invisibleOnly, usedCodes, usedCIDs := ComputeContentUsage(graph, ctx)
+	ctx.InvisibleOnlyFontPtrs, ctx.UsedCharCodes, ctx.UsedCIDs = invisibleOnly, usedCodes, usedCIDs
+
+	verifyLogicalStructure(graph, ctx)
+	verifyUA1Document(d, graph, ctx)
+	verifyUA1Structure(graph, ctx)
+	verifyUA1Pages(graph, ctx)
+	verifyUA1Objects(graph, ctx)
+	pt.Graph = translateIssues(ctx.errs, pdf.SpecPDFUA1)
+	return pt
+}
+
+// verifyUA1Document checks the catalog- and trailer-level rules: the PDF/UA
+// identifier and title in the XMP metadata (5, 7.1), DisplayDocTitle (7.1),
+// MarkInfo Suspects (7.1), the natural language (7.2), optional content
+// configurations (7.10), dynamic XFA (7.15) and the accessibility permission
+// of an encrypted file (7.16).
+func verifyUA1Document(d *pdf.Reader, graph pdf.PDFValue, ctx *ValidationContext) {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return
+	}
+	checks := pdf.Checks.PDFUA1
+
+	if data, _, err := d.RawXMP(); err != nil {
+		ctx.Report(checks.Document.PDFUAIdentifier, root, fmt.Sprintf("no PDF/UA identification: %v", err))
+		ctx.Report(checks.Document.MetadataTitle, root, "no XMP metadata to carry a dc:title")
+	} else {
+		xmp := string(data)
+		if part, ok := xmpScalarValue(xmp, "pdfuaid:part"); !ok {
+			ctx.Report(checks.Document.PDFUAIdentifier, root, "XMP metadata lacks the pdfuaid:part property")
+		} else if part != "1" {
+			ctx.Report(checks.Document.PDFUAIdentifier, root, fmt.Sprintf("pdfuaid:part is %q, not 1", part))
+		}
+		if title, _ := xmpPropValue(xmp, "dc:title"); title == "" {
+			ctx.Report(checks.Document.MetadataTitle, root, "XMP metadata has no dc:title")
+		}
+	}
+
+	prefs, _ := root.Entries["ViewerPreferences"].(pdf.PDFDict)
+	if show, _ := prefs.Entries["DisplayDocTitle"].(pdf.PDFBoolean); !show {
+		ctx.Report(checks.Document.DisplayDocTitle, root, "ViewerPreferences lacks DisplayDocTitle true")
+	}
+
+	markInfo, _ := root.Entries["MarkInfo"].(pdf.PDFDict)
+	if suspects, _ := markInfo.Entries["Suspects"].(pdf.PDFBoolean); suspects {
+		ctx.Report(checks.Tagged.MarkInfoSuspects, root, "MarkInfo Suspects is true")
+	}
+
+	if lang, _ := textString(root.Entries["Lang"]); lang == "" {
+		ctx.Report(checks.Structure.NaturalLanguage, root, "document catalog has no non-empty Lang entry")
+	}
+
+	if oc, ok := root.Entries["OCProperties"].(pdf.PDFDict); ok {
+		ctx.errs = append(ctx.errs, verifyOptionalContentConfigs(oc)...)
+	}
+
+	if form, ok := root.Entries["AcroForm"].(pdf.PDFDict); ok && form.Entries["XFA"] != nil {
+		if dynamic, _ := root.Entries["NeedsRendering"].(pdf.PDFBoolean); dynamic {
+			ctx.Report(checks.Document.DynamicXFA, root, "document contains a dynamic XFA form (NeedsRendering true)")
+		}
+	}
+
+	if enc, ok := trailer.Entries["Encrypt"].(pdf.PDFDict); ok {
+		if perms, ok := enc.Entries["P"].(pdf.PDFInteger); ok && perms&(1<<9) == 0 {
+			ctx.Report(checks.Document.EncryptAccessibility, enc, "encryption permissions do not allow content extraction for accessibility (P bit 10)")
+		}
+	}
+}
+
+// verifyUA1Structure checks the structure tree rules level A does not: no
+// remapped standard types (7.1), alternate text on Figure and Formula
+// elements (7.3, 7.7), heading levels (7.4), table and list nesting (7.5,
+// 7.6) and Note IDs (7.9).
+func verifyUA1Structure(graph pdf.PDFValue, ctx *ValidationContext) {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	st, ok := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	if !ok {
+		return
+	}
+	checks := pdf.Checks.PDFUA1
+	roleMap, _ := st.Entries["RoleMap"].(pdf.PDFDict)
+	for _, typ := range slices.Sorted(maps.Keys(roleMap.Entries)) {
+		if typ != "_ref" && ctx.isStandardStructType(typ) {
+			ctx.Report(checks.Tagged.StandardTypeRemapped, st, fmt.Sprintf("role map remaps standard structure type /%s", typ))
+		}
+	}
+	resolve := func(typ string) string {
+		resolved, _ := resolveStructType(typ, roleMap, ctx.isStandardStructType)
+		return resolved
+	}
+
+	checkNesting("", structKids(st.Entries["K"]), resolve, ctx)
+	var (
+		sawH, sawHn, mixed bool
+		lastLevel          int
+	)
+	WalkStructElems(st, func(elem pdf.PDFDict, typ string) {
+		resolved := resolve(typ)
+		switch {
+		case resolved == "Figure" || resolved == "Formula":
+			if elem.Entries["Alt"] == nil && elem.Entries["ActualText"] == nil {
+				c := checks.Structure.FigureAlternateText
+				if resolved == "Formula" {
+					c = checks.Structure.FormulaAlternateText
+				}
+				ctx.Report(c, elem, fmt.Sprintf("/%s structure element has neither Alt nor ActualText", typ))
+			}
+		case resolved == "Note":
+			if elem.Entries["ID"] == nil {
+				ctx.Report(checks.Structure.NoteID, elem, fmt.Sprintf("/%s structure element has no ID", typ))
+			}
+		case resolved == "H":
+			sawH = true
+		case len(resolved) == 2 && resolved[0] == 'H' && resolved[1] >= '1' && resolved[1] <= '6':
+			sawHn = true
+			level := int(resolved[1] - '0')
+			if level > lastLevel+1 {
+				if lastLevel == 0 {
+					ctx.Report(checks.Structure.HeadingSequence, elem, fmt.Sprintf("first numbered heading is /%s, not H1", resolved))
+				} else {
+					ctx.Report(checks.Structure.HeadingSequence, elem, fmt.Sprintf("heading /%s follows H%d, skipping a level", resolved, lastLevel))
+				}
+			}
+			lastLevel = level
+		}
+		if sawH && sawHn && !mixed {
+			mixed = true
+			ctx.Report(checks.Structure.HeadingMixed, elem, "document uses both H and numbered H1-H6 headings")
+		}
+
+		kids := structKids(elem.Entries["K"])
+		hs := 0
+		for _, kid := range kids {
+			if s, _ := kid.Entries["S"].(pdf.PDFName); resolve(s.Value) == "H" {
+				hs++
+			}
+		}
+		if hs > 1 {
+			ctx.Report(checks.Structure.HeadingMultipleH, elem, fmt.Sprintf("/%s structure element contains %d H elements", typ, hs))
+		}
+		checkNesting(resolved, kids, resolve, ctx)
+	})
+}
+
+// structKids returns the structure elements among the kids k of a structure
+// element, skipping marked-content and object references.
+func structKids(k pdf.PDFValue) []pdf.PDFDict {
+	var out []pdf.PDFDict
+	add := func(v pdf.PDFValue) {
+		if d, ok := v.(pdf.PDFDict); ok {
+			if _, ok := d.Entries["S"].(pdf.PDFName); ok {
+				out = append(out, d)
+			}
+		}
+	}
+	if arr, ok := k.(pdf.PDFArray); ok {
+		for _, kid := range arr {
+			add(kid)
+		}
+	} else {
+		add(k)
+	}
+	return out
+}
+
+// tableListParents maps each table and list structure type to the types it
+// may be a child of (ISO 32000-1 14.8.4.3.3 and 14.8.4.3.4).
+var tableListParents = map[string][]string{
+	"THead": {"Table"}, "TBody": {"Table"}, "TFoot": {"Table"},
+	"TR": {"Table", "THead", "TBody", "TFoot"},
+	"TH": {"TR"}, "TD": {"TR"},
+	"LI": {"L"}, "Lbl": {"LI"}, "LBody": {"LI"},
+}
+
+// tableListKids maps each table and list structure type to the types its
+// children may have.
+var tableListKids = map[string][]string{
+	"Table": {"TR", "THead", "TBody", "TFoot", "Caption"},
+	"THead": {"TR"}, "TBody": {"TR"}, "TFoot": {"TR"},
+	"TR": {"TH", "TD"},
+	"L":  {"LI", "Caption"},
+	"LI": {"Lbl", "LBody"},
+}
+
+// checkNesting reports the kids of an element of resolved type parent that
+// break the table (7.5) or list (7.6) nesting. parent is "" for the kids of
+// the structure tree root.
+func checkNesting(parent string, kids []pdf.PDFDict, resolve func(string) string, ctx *ValidationContext) {
+	checkFor := func(typ string) pdf.Check {
+		switch typ {
+		case "L", "LI", "Lbl", "LBody":
+			return pdf.Checks.PDFUA1.Structure.ListStructure
+		}
+		return pdf.Checks.PDFUA1.Structure.TableStructure
+	}
+	allowed, constrained := tableListKids[parent]
+	for _, kid := range kids {
+		s, _ := kid.Entries["S"].(pdf.PDFName)
+		typ := resolve(s.Value)
+		if constrained && !slices.Contains(allowed, typ) {
+			ctx.Report(checkFor(parent), kid, fmt.Sprintf("/%s is not a permitted child of /%s", typ, parent))
+			continue
+		}
+		if parents, ok := tableListParents[typ]; ok && !slices.Contains(parents, parent) {
+			if parent == "" {
+				ctx.Report(checkFor(typ), kid, fmt.Sprintf("/%s is a child of the structure tree root", typ))
+			} else {
+				ctx.Report(checkFor(typ), kid, fmt.Sprintf("/%s is not a permitted child of /%s", typ, parent))
+			}
+		}
+	}
+}
+
+// uaPaintingOps are the operators whose output content must be tagged or
+// marked as an artifact (7.1): paintingOps plus XObjects and inline images.
+var uaPaintingOps = map[string]bool{
+	"Do": true, "INLINEIMAGE": true,
+}
+
+// verifyUA1Pages checks each page in page-tree order: its content is tagged
+// or marked as an artifact (7.1), its annotations are tagged and described
+// (7.18.1, 7.18.4) and, if it has any, it declares the structure tab order
+// (7.18.3).
+func verifyUA1Pages(graph pdf.PDFValue, ctx *ValidationContext) {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	st, _ := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	parentTree, _ := st.Entries["ParentTree"].(pdf.PDFDict)
+	roleMap, _ := st.Entries["RoleMap"].(pdf.PDFDict)
+
+	visited := map[uintptr]bool{}
+	var walk func(node pdf.PDFDict, resources pdf.PDFDict, depth int)
+	walk = func(node pdf.PDFDict, resources pdf.PDFDict, depth int) {
+		ptr := pdf.ValuePointer(node.Entries)
+		if depth > 64 || visited[ptr] {
+			return
+		}
+		visited[ptr] = true
+		if res, ok := node.Entries["Resources"].(pdf.PDFDict); ok {
+			resources = res
+		}
+		if kids, ok := node.Entries["Kids"].(pdf.PDFArray); ok {
+			for _, kid := range kids {
+				if kd, ok := kid.(pdf.PDFDict); ok {
+					walk(kd, resources, depth+1)
+				}
+			}
+			return
+		}
+		if ref, ok := node.Entries["_ref"].(pdf.PDFRef); ok {
+			ctx.CurrentPage = ctx.PageIndex[ref.ObjNum]
+		}
+		m := &markedContentScan{ctx: ctx, forms: map[uintptr]bool{}}
+		for _, stm := range contentStreams(node.Entries["Contents"]) {
+			m.scan(stm, resources, false, false)
+		}
+		verifyUA1Annotations(node, parentTree, roleMap, ctx)
+	}
+	if pages, ok := root.Entries["Pages"].(pdf.PDFDict); ok {
+		walk(pages, pdf.PDFDict{}, 0)
+	}
+	ctx.CurrentPage = 0
+}
+
+// markedContentScan walks a page's content streams, and the Form XObjects
+// they paint, tracking the marked-content sequences each operator is in.
+type markedContentScan struct {
+	ctx   *ValidationContext
+	forms map[uintptr]bool
+}
+
+// scan checks one content stream. tagged and artifact say whether the
+// stream is painted from within tagged content or an Artifact, as a Form
+// XObject can be.
+func (m *markedContentScan) scan(stm, resources pdf.PDFDict, tagged, artifact bool) {
+	ops, err := m.ctx.scanStreamCached(stm)
+	if err != nil {
+		return
+	}
+	checks := pdf.Checks.PDFUA1.Tagged
+	properties, _ := resources.Entries["Properties"].(pdf.PDFDict)
+	xobjects, _ := resources.Entries["XObject"].(pdf.PDFDict)
+
+	type sequence struct{ tagged, artifact bool }
+	var stack []sequence
+	inTagged := func() bool { return tagged || slices.ContainsFunc(stack, func(s sequence) bool { return s.tagged }) }
+	inArtifact := func() bool {
+		return artifact || slices.ContainsFunc(stack, func(s sequence) bool { return s.artifact })
+	}
+
+	untagged, artifactInTagged, taggedInArtifact := 0, false, false
+	pdf.ReplayOps(ops, func(op string, operands []pdf.PDFValue) {
+		switch op {
+		case "BMC", "BDC":
+			var seq sequence
+			if len(operands) > 0 {
+				tag, _ := operands[0].(pdf.PDFName)
+				seq.artifact = tag.Value == "Artifact"
+			}
+			if op == "BDC" && len(operands) > 1 {
+				props, ok := operands[1].(pdf.PDFDict)
+				if name, isName := operands[1].(pdf.PDFName); isName {
+					props, ok = properties.Entries[name.Value].(pdf.PDFDict)
+				}
+				if ok {
+					_, seq.tagged = props.Entries["MCID"].(pdf.PDFInteger)
+				}
+			}
+			if seq.artifact && inTagged() {
+				artifactInTagged = true
+			}
+			if seq.tagged && inArtifact() {
+				taggedInArtifact = true
+			}
+			stack = append(stack, seq)
+			return
+		case "EMC":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			return
+		}
+		if !paintingOps[op] && !uaPaintingOps[op] {
+			return
+		}
+		isTagged, isArtifact := inTagged(), inArtifact()
+		if op == "Do" && len(operands) > 0 {
+			name, _ := operands[len(operands)-1].(pdf.PDFName)
+			if form, ok := xobjects.Entries[name.Value].(pdf.PDFDict); ok {
+				if sub, _ := form.Entries["Subtype"].(pdf.PDFName); sub.Value == "Form" {
+					if _, ok := form.Entries["StructParent"].(pdf.PDFInteger); ok {
+						return // the whole form is a content item of the structure tree
+					}
+					ptr := pdf.ValuePointer(form.Entries)
+					if !m.forms[ptr] {
+						m.forms[ptr] = true
+						formRes, ok := form.Entries["Resources"].(pdf.PDFDict)
+						if !ok {
+							formRes = resources
+						}
+						m.scan(form, formRes, isTagged, isArtifact)
+					}
+					return
+				}
+			}
+		}
+		if !isTagged && !isArtifact {
+			untagged++
+		}
+	})
+
+	if untagged > 0 {
+		m.ctx.Report(checks.ContentNotTagged, stm, fmt.Sprintf("content stream paints %d operator(s) neither tagged nor marked as an Artifact", untagged))
+	}
+	if artifactInTagged {
+		m.ctx.Report(checks.ArtifactInTaggedContent, stm, "content stream opens an Artifact inside tagged content")
+	}
+	if taggedInArtifact {
+		m.ctx.Report(checks.TaggedContentInArtifact, stm, "content stream opens tagged content inside an Artifact")
+	}
+}
+
+// verifyUA1Annotations checks the annotations of page against the 7.18
+// rules, finding each annotation's structure element through the structure
+// tree's ParentTree.
+func verifyUA1Annotations(page, parentTree, roleMap pdf.PDFDict, ctx *ValidationContext) {
+	annots, _ := page.Entries["Annots"].(pdf.PDFArray)
+	checks := pdf.Checks.PDFUA1.Annotation
+	counted := 0
+	for _, item := range annots {
+		annot, ok := item.(pdf.PDFDict)
+		if !ok {
+			continue
+		}
+		counted++
+		subtype, _ := annot.Entries["Subtype"].(pdf.PDFName)
+		flags, _ := annot.Entries["F"].(pdf.PDFInteger)
+		if subtype.Value == "Popup" || subtype.Value == "PrinterMark" || flags&2 != 0 {
+			continue
+		}
+
+		var elem pdf.PDFDict
+		key, tagged := annot.Entries["StructParent"].(pdf.PDFInteger)
+		if !tagged {
+			ctx.Report(checks.AnnotationNotTagged, annot, fmt.Sprintf("/%s annotation has no StructParent", subtype.Value))
+		} else {
+			elem, _ = numberTreeLookup(parentTree, int(key), 0).(pdf.PDFDict)
+		}
+
+		if subtype.Value == "Widget" {
+			if s, ok := elem.Entries["S"].(pdf.PDFName); ok {
+				if resolved, _ := resolveStructType(s.Value, roleMap, ctx.isStandardStructType); resolved != "Form" {
+					ctx.Report(checks.WidgetNotInForm, annot, fmt.Sprintf("widget annotation is tagged as /%s, not Form", s.Value))
+				}
+			}
+			continue
+		}
+		if contents, _ := textString(annot.Entries["Contents"]); contents == "" && elem.Entries["Alt"] == nil {
+			ctx.Report(checks.AnnotationContents, annot, fmt.Sprintf("/%s annotation has neither Contents nor an Alt on its structure element", subtype.Value))
+		}
+	}
+	if tabs, _ := page.Entries["Tabs"].(pdf.PDFName); counted > 0 && tabs.Value != "S" {
+		ctx.Report(checks.PageTabOrder, page, "page with annotations lacks Tabs /S")
+	}
+}
+
+// numberTreeLookup returns the value number tree tree maps key to, or nil.
+func numberTreeLookup(tree pdf.PDFDict, key, depth int) pdf.PDFValue {
+	if depth > 32 {
+		return nil
+	}
+	if limits, ok := tree.Entries["Limits"].(pdf.PDFArray); ok && len(limits) == 2 {
+		lo, _ := limits[0].(pdf.PDFInteger)
+		hi, _ := limits[1].(pdf.PDFInteger)
+		if key < int(lo) || key > int(hi) {
+			return nil
+		}
+	}
+	if nums, ok := tree.Entries["Nums"].(pdf.PDFArray); ok {
+		for i := 0; i+1 < len(nums); i += 2 {
+			if k, ok := nums[i].(pdf.PDFInteger); ok && int(k) == key {
+				return nums[i+1]
+			}
+		}
+	}
+	kids, _ := tree.Entries["Kids"].(pdf.PDFArray)
+	for _, kid := range kids {
+		if kd, ok := kid.(pdf.PDFDict); ok {
+			if v := numberTreeLookup(kd, key, depth+1); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// verifyUA1Objects walks the graph once for the object-level rules: embedded
+// file specifications (7.11), reference XObjects (7.20) and fonts (7.21).
+func verifyUA1Objects(graph pdf.PDFValue, ctx *ValidationContext) {
+	checks := pdf.Checks.PDFUA1
+	visited := map[uintptr]bool{}
+	var walk func(v pdf.PDFValue, depth int)
+	walk = func(v pdf.PDFValue, depth int) {
+		if depth > maxWalkDepth {
+			return
+		}
+		switch val := v.(type) {
+		case pdf.PDFArray:
+			ptr := pdf.ValuePointer(val)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			for _, item := range val {
+				walk(item, depth+1)
+			}
+		case pdf.PDFDict:
+			ptr := pdf.ValuePointer(val.Entries)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			if (val.Entries["Type"] == pdf.PDFName{Value: "Page"}) {
+				if ref, ok := val.Entries["_ref"].(pdf.PDFRef); ok {
+					saved := ctx.CurrentPage
+					ctx.CurrentPage = ctx.PageIndex[ref.ObjNum]
+					defer func() { ctx.CurrentPage = saved }()
+				}
+			}
+
+			subtype, _ := val.Entries["Subtype"].(pdf.PDFName)
+			switch {
+			case (val.Entries["Type"] == pdf.PDFName{Value: "Font"}):
+				verifyUA1Font(val, ctx)
+			case subtype.Value == "Form" && val.Entries["Ref"] != nil:
+				ctx.Report(checks.Document.ReferenceXObject, val, "Form XObject is a reference XObject")
+			case val.Entries["EF"] != nil:
+				if val.Entries["F"] == nil || val.Entries["UF"] == nil {
+					ctx.Report(checks.Document.EmbeddedFileSpecKeys, val, "embedded file specification lacks F or UF")
+				}
+			}
+			for _, k := range slices.Sorted(maps.Keys(val.Entries)) {
+				if k != "_ref" {
+					walk(val.Entries[k], depth+1)
+				}
+			}
+		}
+	}
+	walk(graph, 0)
+}
+
+// verifyUA1Font checks that a font shown visibly embeds its program
+// (7.21.4.1) and that every code it shows maps to Unicode (7.21.7).
+// Descendant CIDFonts are covered by their Type 0 parent.
+func verifyUA1Font(v pdf.PDFDict, ctx *ValidationContext) {
+	subtype, _ := v.Entries["Subtype"].(pdf.PDFName)
+	baseFont, _ := v.Entries["BaseFont"].(pdf.PDFName)
+	checks := pdf.Checks.PDFUA1.Font
+	if subtype.Value == "CIDFontType0" || subtype.Value == "CIDFontType2" {
+		return
+	}
+
+	var shown bool
+	switch subtype.Value {
+	case "Type0":
+		desc := DescendantCIDFont(v)
+		_, known := ctx.usedCIDsFor(desc)
+		enc, _ := v.Entries["Encoding"].(pdf.PDFName)
+		shown = known || !strings.HasPrefix(enc.Value, "Identity-")
+	default:
+		shown = ctx.simpleFontShown(v)
+	}
+	if !shown {
+		return
+	}
+
+	if subtype.Value != "Type3" && !ctx.isInvisibleOnlyFont(v) {
+		program, programSubtype := v, subtype.Value
+		if subtype.Value == "Type0" {
+			program = DescendantCIDFont(v)
+			st, _ := program.Entries["Subtype"].(pdf.PDFName)
+			programSubtype = st.Value
+		}
+		desc, _ := program.Entries["FontDescriptor"].(pdf.PDFDict)
+		if !EmbeddedProgramMatchesSubtype(programSubtype, desc) {
+			ctx.Report(checks.FontNotEmbedded, v, fmt.Sprintf("font %s is not embedded", baseFont.Value))
+		}
+	}
+
+	if HasUnicodeMapping(v) {
+		return
+	}
+	if subtype.Value != "Type0" {
+		table := SimpleFontUnicode(v)
+		codes, _ := ctx.usedCodesFor(v)
+		if !slices.ContainsFunc(slices.Collect(maps.Keys(codes)), func(code int) bool { return code >= 256 || table[code] == 0 }) {
+			return
+		}
+	}
+	ctx.Report(checks.UnicodeMapping, v, fmt.Sprintf("font %s does not map the codes it shows to Unicode", baseFont.Value))
+}
@@ -0,0 +1,255 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+func TestVerifyUA1Structure(t *testing.T) {
+	s := pdf.Checks.PDFUA1.Structure
+	alt := pdf.PDFString{Value: "A bar chart"}
+	row := func(cells ...string) pdf.PDFDict {
+		kids := pdf.PDFArray{}
+		for _, c := range cells {
+			kids = append(kids, structElem(c))
+		}
+		return structElem("TR", "K", kids)
+	}
+	for _, tc := range []struct {
+		name  string
+		elems []pdf.PDFDict
+		want  []pdf.Check
+	}{
+		{"conforming", []pdf.PDFDict{
+			structElem("H1"), structElem("H2"), structElem("H2"), structElem("Figure", "Alt", alt),
+			structElem("Table", "K", pdf.PDFArray{structElem("THead", "K", row("TH", "TH")), row("TD", "TD")}),
+			structElem("L", "K", structElem("LI", "K", pdf.PDFArray{structElem("Lbl"), structElem("LBody")})),
+			structElem("Note", "ID", pdf.PDFString{Value: "n1"}),
+		}, nil},
+		{"Figure and Formula without Alt", []pdf.PDFDict{structElem("Figure"), structElem("Formula")},
+			[]pdf.Check{s.FigureAlternateText, s.FormulaAlternateText}},
+		{"first heading not H1", []pdf.PDFDict{structElem("H2")}, []pdf.Check{s.HeadingSequence}},
+		{"skipped heading level", []pdf.PDFDict{structElem("H1"), structElem("H3")}, []pdf.Check{s.HeadingSequence}},
+		{"H and Hn mixed", []pdf.PDFDict{structElem("H1"), structElem("H")}, []pdf.Check{s.HeadingMixed}},
+		{"two H in one element", []pdf.PDFDict{structElem("Sect", "K", pdf.PDFArray{structElem("H"), structElem("H")})},
+			[]pdf.Check{s.HeadingMultipleH}},
+		{"TD outside TR", []pdf.PDFDict{structElem("Table", "K", structElem("TD"))}, []pdf.Check{s.TableStructure}},
+		{"TR outside Table", []pdf.PDFDict{row("TD")}, []pdf.Check{s.TableStructure}},
+		{"P in a list", []pdf.PDFDict{structElem("L", "K", structElem("P"))}, []pdf.Check{s.ListStructure}},
+		{"Note without ID", []pdf.PDFDict{structElem("Note")}, []pdf.Check{s.NoteID}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &ValidationContext{level: pdf.UA_1}
+			verifyUA1Structure(taggedTrailer(nil, tc.elems...), ctx)
+			if len(ctx.errs) != len(tc.want) {
+				t.Fatalf("got %v, want %d issue(s)", ctx.errs, len(tc.want))
+			}
+			for i, c := range tc.want {
+				if ctx.errs[i].Check() != c {
+					t.Errorf("issue %d = %s, want %s", i, ctx.errs[i].Check().Name(), c.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyUA1StructureRoleMap(t *testing.T) {
+	ctx := &ValidationContext{level: pdf.UA_1}
+	verifyUA1Structure(taggedTrailer(map[string]string{"P": "Span", "Chart": "Figure"}, structElem("Chart")), ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFUA1.Tagged.StandardTypeRemapped) {
+		t.Error("remapped standard type /P not reported")
+	}
+	if !hasCheck(ctx, pdf.Checks.PDFUA1.Structure.FigureAlternateText) {
+		t.Error("role-mapped Figure without Alt not reported")
+	}
+}
+
+func TestVerifyLogicalStructurePDF17Types(t *testing.T) {
+	tr := func() pdf.PDFDict { return taggedTrailer(nil, structElem("Table", "K", structElem("TBody"))) }
+	ctx := &ValidationContext{level: pdf.UA_1}
+	verifyLogicalStructure(tr(), ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("PDF/UA-1: TBody reported as non-standard: %v", ctx.errs)
+	}
+	ctx = &ValidationContext{part: 1, level: pdf.A_1A}
+	verifyLogicalStructure(tr(), ctx)
+	if !hasCheck(ctx, pdf.Checks.LogicalStructure.RoleMapStandardType) {
+		t.Error("PDF/A-1a: TBody, a PDF 1.5 type, accepted as standard")
+	}
+}
+
+func TestMarkedContentScan(t *testing.T) {
+	c := pdf.Checks.PDFUA1.Tagged
+	form := pdf.NewPDFDict()
+	form.Entries["Subtype"] = pdf.PDFName{Value: "Form"}
+	form.HasStream = true
+	form.RawStream = []byte("0 0 1 1 re f")
+	props := pdf.NewPDFDict()
+	props.Entries["P1"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{"MCID": pdf.PDFInteger(1)}}
+	resources := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"XObject":    pdf.PDFDict{Entries: map[string]pdf.PDFValue{"X1": form}},
+		"Properties": props,
+	}}
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    []pdf.Check
+	}{
+		{"tagged and artifact", "/P <</MCID 0>> BDC BT (a) Tj ET EMC /Artifact BMC 0 0 1 1 re f EMC", nil},
+		{"named properties", "/Span /P1 BDC BT (a) Tj ET EMC", nil},
+		{"form painted inside tagged content", "/Figure <</MCID 0>> BDC /X1 Do EMC", nil},
+		{"untagged text", "BT (a) Tj ET", []pdf.Check{c.ContentNotTagged}},
+		{"untagged form content", "/X1 Do", []pdf.Check{c.ContentNotTagged}},
+		{"marked but not tagged", "/Span BMC BT (a) Tj ET EMC", []pdf.Check{c.ContentNotTagged}},
+		{"artifact in tagged content", "/P <</MCID 0>> BDC /Artifact BMC 0 0 1 1 re f EMC EMC", []pdf.Check{c.ArtifactInTaggedContent}},
+		{"tagged content in artifact", "/Artifact BMC /P <</MCID 0>> BDC 0 0 1 1 re f EMC EMC", []pdf.Check{c.TaggedContentInArtifact}},
+		{"path construction only", "0 0 m 1 1 l n", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stm := pdf.NewPDFDict()
+			stm.HasStream = true
+			stm.RawStream = []byte(tc.content)
+			ctx := &ValidationContext{level: pdf.UA_1}
+			m := &markedContentScan{ctx: ctx, forms: map[uintptr]bool{}}
+			m.scan(stm, resources, false, false)
+			if len(ctx.errs) != len(tc.want) {
+				t.Fatalf("got %v, want %d issue(s)", ctx.errs, len(tc.want))
+			}
+			for i, want := range tc.want {
+				if ctx.errs[i].Check() != want {
+					t.Errorf("issue %d = %s, want %s", i, ctx.errs[i].Check().Name(), want.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyUA1Annotations(t *testing.T) {
+	a := pdf.Checks.PDFUA1.Annotation
+	formElem := structElem("Form")
+	linkElem := structElem("Link", "Alt", pdf.PDFString{Value: "Home page"})
+	parentTree := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Kids": pdf.PDFArray{
+			pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"Limits": pdf.PDFArray{pdf.PDFInteger(0), pdf.PDFInteger(1)},
+				"Nums":   pdf.PDFArray{pdf.PDFInteger(0), formElem, pdf.PDFInteger(1), linkElem},
+			}},
+			pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"Limits": pdf.PDFArray{pdf.PDFInteger(2), pdf.PDFInteger(2)},
+				"Nums":   pdf.PDFArray{pdf.PDFInteger(2), structElem("P")},
+			}},
+		},
+	}}
+	annot := func(subtype string, kv ...any) pdf.PDFDict {
+		d := pdf.NewPDFDict()
+		d.Entries["Subtype"] = pdf.PDFName{Value: subtype}
+		for i := 0; i+1 < len(kv); i += 2 {
+			d.Entries[kv[i].(string)] = kv[i+1].(pdf.PDFValue)
+		}
+		return d
+	}
+	for _, tc := range []struct {
+		name   string
+		annots pdf.PDFArray
+		tabs   bool
+		want   []pdf.Check
+	}{
+		{"conforming", pdf.PDFArray{
+			annot("Widget", "StructParent", pdf.PDFInteger(0)),
+			annot("Link", "StructParent", pdf.PDFInteger(1)),
+			annot("Text", "StructParent", pdf.PDFInteger(2), "Contents", pdf.PDFString{Value: "Note"}),
+			annot("Popup"),
+			annot("Square", "F", pdf.PDFInteger(2)),
+		}, true, nil},
+		{"untagged", pdf.PDFArray{annot("Text", "Contents", pdf.PDFString{Value: "Note"})}, true, []pdf.Check{a.AnnotationNotTagged}},
+		{"no description", pdf.PDFArray{annot("Text", "StructParent", pdf.PDFInteger(2))}, true, []pdf.Check{a.AnnotationContents}},
+		{"widget outside Form", pdf.PDFArray{annot("Widget", "StructParent", pdf.PDFInteger(2))}, true, []pdf.Check{a.WidgetNotInForm}},
+		{"no tab order", pdf.PDFArray{annot("Widget", "StructParent", pdf.PDFInteger(0))}, false, []pdf.Check{a.PageTabOrder}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			page := pdf.NewPDFDict()
+			page.Entries["Annots"] = tc.annots
+			if tc.tabs {
+				page.Entries["Tabs"] = pdf.PDFName{Value: "S"}
+			}
+			ctx := &ValidationContext{level: pdf.UA_1}
+			verifyUA1Annotations(page, parentTree, pdf.PDFDict{}, ctx)
+			if len(ctx.errs) != len(tc.want) {
+				t.Fatalf("got %v, want %d issue(s)", ctx.errs, len(tc.want))
+			}
+			for i, want := range tc.want {
+				if ctx.errs[i].Check() != want {
+					t.Errorf("issue %d = %s, want %s", i, ctx.errs[i].Check().Name(), want.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyUA1Font(t *testing.T) {
+	f := pdf.NewPDFDict()
+	f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+	f.Entries["Subtype"] = pdf.PDFName{Value: "Type1"}
+	f.Entries["BaseFont"] = pdf.PDFName{Value: "Helvetica"}
+	ptr := pdf.ValuePointer(f.Entries)
+
+	ctx := &ValidationContext{level: pdf.UA_1, UsedCharCodes: map[uintptr]map[int]bool{}}
+	verifyUA1Font(f, ctx)
+	if len(ctx.errs) != 0 {
+		t.Errorf("unused font reported: %v", ctx.errs)
+	}
+
+	// StandardEncoding maps 'A'; code 0x80 has no value.
+	ctx.UsedCharCodes[ptr] = map[int]bool{'A': true}
+	verifyUA1Font(f, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFUA1.Font.FontNotEmbedded) || hasCheck(ctx, pdf.Checks.PDFUA1.Font.UnicodeMapping) {
+		t.Errorf("shown unembedded font: got %v, want FontNotEmbedded only", ctx.errs)
+	}
+	ctx.errs = nil
+	ctx.UsedCharCodes[ptr][0x80] = true
+	verifyUA1Font(f, ctx)
+	if !hasCheck(ctx, pdf.Checks.PDFUA1.Font.UnicodeMapping) {
+		t.Error("unmappable code 0x80 not reported")
+	}
+}
+
+func TestVerifyPdfUA1(t *testing.T) {
+	trailer := pdf.NewPDFDict()
+	minimalConformantRoot(trailer)
+	var buf bytes.Buffer
+	if err := writer.WriteDocument(&buf, trailer); err != nil {
+		t.Fatal(err)
+	}
+	d, err := pdf.OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	res, err := Verify(d, pdf.PDFUA_1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid {
+		t.Fatal("untagged document verified as PDF/UA-1")
+	}
+	got := map[pdf.Check]bool{}
+	for _, iss := range res.Issues {
+		if spec := iss.Check().Spec(); spec != pdf.SpecPDFUA1 && spec != pdf.SpecPDF {
+			t.Errorf("issue %s numbered by %s", iss.Check().Name(), spec)
+		}
+		got[iss.Check()] = true
+	}
+	ua := pdf.Checks.PDFUA1
+	for _, c := range []pdf.Check{
+		ua.Document.PDFUAIdentifier, ua.Document.DisplayDocTitle, ua.Tagged.TaggedMarkInfo,
+		ua.Tagged.StructTreeRoot, ua.Structure.NaturalLanguage,
+	} {
+		if !got[c] {
+			t.Errorf("%s not reported", c.Name())
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// PDF/X-1a:2003 (ISO 15930-4) and PDF/X-4 (ISO 15930-7)
+//
+// A PDF/X file's colour, font and transparency rules are the PDF/A ones
+// with a GTS_PDFX output intent in place of GTS_PDFA1, so verifyPdfXParts
+// runs verifyPdfAParts (as part 1 for X-1a, based on PDF 1.4, and part 2 for
+// X-4, based on PDF 1.6) and translateIssues keeps the findings PDF/X
+// shares, dropping the archival rules. The output intent, identification,
+// trapping and page box rules PDF/X adds are implemented here and report
+// against pdf.Checks.PDFX1A; X-4 findings are renumbered the same way.
+
+func verifyPdfXParts(d *pdf.Reader, p *pdf.Profile) Parts {
+	part, spec := 1, pdf.SpecPDFX1A
+	if p.Level == pdf.X_4 {
+		part, spec = 2, pdf.SpecPDFX4
+	}
+	pt := verifyPdfAParts(d, p, part)
+	if !p.OnlyObjectModelChecks() {
+		if graph, err := d.ResolveGraph(); err == nil {
+			pt.Graph = append(pt.Graph, verifyPdfXDocument(d, graph, p.Level)...)
+		}
+	}
+	return pt.translate(spec)
+}
+
+// pdfxOutputColourSpaces are the ICC colour spaces a GTS_PDFX destination
+// profile may have: X-1a is CMYK (or grey) print, X-4 admits RGB output too.
+var pdfxOutputColourSpaces = map[pdf.LevelType]map[string]bool{
+	pdf.X_1A: {"CMYK": true, "GRAY": true},
+	pdf.X_4:  {"CMYK": true, "GRAY": true, "RGB ": true},
+}
+
+// verifyPdfXDocument runs the rules PDF/X adds to PDF/A's: encryption,
+// version identification and trapping (6.1), the GTS_PDFX output intent
+// (6.2.2), the colour spaces X-1a admits (6.2.3) and page boxes (6.4).
+func verifyPdfXDocument(d *pdf.Reader, graph pdf.PDFValue, level pdf.LevelType) []pdf.PDFError {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return nil
+	}
+	pageIndex, _ := d.BuildPageIndex(graph)
+	ctx := &ValidationContext{PageIndex: pageIndex, reader: d, level: level}
+
+	verifyPdfXIdentification(d, trailer, ctx)
+	verifyPdfXOutputIntent(root, ctx)
+	if level == pdf.X_1A {
+		verifyPdfX1aColourSpaces(graph, ctx)
+	}
+	verifyPdfXPageBoxes(root, ctx)
+	return ctx.errs
+}
+
+// verifyPdfXIdentification checks that the file is not encrypted and
+// declares its PDF/X version and trapping state: in the document
+// information dictionary for X-1a, in the XMP metadata for X-4.
+func verifyPdfXIdentification(d *pdf.Reader, trailer pdf.PDFDict, ctx *ValidationContext) {
+	checks := pdf.Checks.PDFX1A.Document
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	if enc, ok := trailer.Entries["Encrypt"]; ok && enc != nil {
+		ctx.Report(checks.Encrypted, trailer, "trailer contains an Encrypt entry")
+	}
+
+	if ctx.level == pdf.X_1A {
+		info, _ := trailer.Entries["Info"].(pdf.PDFDict)
+		if version, _ := textString(info.Entries["GTS_PDFXVersion"]); !strings.HasPrefix(version, "PDF/X-1") {
+			ctx.Report(checks.VersionIdentifier, root, fmt.Sprintf("document information GTS_PDFXVersion %q does not name PDF/X-1", version))
+		}
+		if trapped, _ := info.Entries["Trapped"].(pdf.PDFName); trapped.Value != "True" && trapped.Value != "False" {
+			ctx.Report(checks.TrappedKey, root, "document information Trapped is not /True or /False")
+		}
+		return
+	}
+
+	data, _, err := d.RawXMP()
+	if err != nil {
+		ctx.Report(checks.VersionIdentifier, root, fmt.Sprintf("no PDF/X identification: %v", err))
+		ctx.Report(checks.TrappedKey, root, "no XMP metadata to carry pdf:Trapped")
+		return
+	}
+	xmp := string(data)
+	if version, _ := xmpScalarValue(xmp, "pdfxid:GTS_PDFXVersion"); !strings.HasPrefix(version, "PDF/X-4") {
+		ctx.Report(checks.VersionIdentifier, root, fmt.Sprintf("pdfxid:GTS_PDFXVersion %q does not name PDF/X-4", version))
+	}
+	if trapped, _ := xmpScalarValue(xmp, "pdf:Trapped"); trapped != "True" && trapped != "False" {
+		ctx.Report(checks.TrappedKey, root, "XMP pdf:Trapped is not True or False")
+	}
+}
+
+// verifyPdfXOutputIntent checks the catalog's single GTS_PDFX output intent:
+// its OutputConditionIdentifier and its destination profile, which X-1a may
+// leave out for a registered characterization (one with a RegistryName).
+func verifyPdfXOutputIntent(root pdf.PDFDict, ctx *ValidationContext) {
+	checks := pdf.Checks.PDFX1A.OutputIntent
+	var pdfx []pdf.PDFDict
+	intents, _ := root.Entries["OutputIntents"].(pdf.PDFArray)
+	for _, item := range intents {
+		if intent, ok := item.(pdf.PDFDict); ok && (intent.Entries["S"] == pdf.PDFName{Value: "GTS_PDFX"}) {
+			pdfx = append(pdfx, intent)
+		}
+	}
+	switch {
+	case len(pdfx) == 0:
+		ctx.Report(checks.PDFXOutputIntent, root, "catalog has no GTS_PDFX output intent")
+		return
+	case len(pdfx) > 1:
+		ctx.Report(checks.PDFXOutputIntentMultiple, root, fmt.Sprintf("catalog has %d GTS_PDFX output intents", len(pdfx)))
+	}
+
+	intent := pdfx[0]
+	if id, _ := textString(intent.Entries["OutputConditionIdentifier"]); id == "" {
+		ctx.Report(checks.OutputConditionIdentifier, intent, "GTS_PDFX output intent has no OutputConditionIdentifier")
+	}
+	profile, ok := intent.Entries["DestOutputProfile"].(pdf.PDFDict)
+	if !ok {
+		if ctx.level == pdf.X_4 || intent.Entries["RegistryName"] == nil {
+			ctx.Report(checks.DestOutputProfile, intent, "GTS_PDFX output intent embeds no DestOutputProfile")
+		}
+		return
+	}
+	data, err := ctx.decodeStreamCached(profile)
+	if err != nil || len(data) < 20 {
+		ctx.Report(checks.OutputProfileClass, profile, "destination output profile is not a readable ICC profile")
+		return
+	}
+	class, space := string(data[12:16]), string(data[16:20])
+	if class != "prtr" {
+		ctx.Report(checks.OutputProfileClass, profile, fmt.Sprintf("destination output profile has device class %q, not prtr", class))
+	}
+	if !pdfxOutputColourSpaces[ctx.level][space] {
+		ctx.Report(checks.OutputProfileClass, profile, fmt.Sprintf("destination output profile colour space %q is not permitted in PDF/%s", strings.TrimSpace(space), ctx.level))
+	}
+}
+
+// pdfx1aForbiddenFamilies are the colour space families PDF/X-1a excludes
+// beyond DeviceRGB, which the shared device colour checks already report.
+var pdfx1aForbiddenFamilies = map[string]bool{
+	"CalGray": true, "CalRGB": true, "Lab": true, "ICCBased": true,
+}
+
+// verifyPdfX1aColourSpaces reports the CIE-based colour spaces anywhere in
+// the graph, directly or as the base or alternate of another space (6.2.3).
+func verifyPdfX1aColourSpaces(graph pdf.PDFValue, ctx *ValidationContext) {
+	visited := map[uintptr]bool{}
+	var walk func(v pdf.PDFValue, owner pdf.PDFDict, depth int)
+	walk = func(v pdf.PDFValue, owner pdf.PDFDict, depth int) {
+		if depth > maxWalkDepth {
+			return
+		}
+		switch val := v.(type) {
+		case pdf.PDFArray:
+			ptr := pdf.ValuePointer(val)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			if len(val) > 0 {
+				if family, ok := val[0].(pdf.PDFName); ok && pdfx1aForbiddenFamilies[family.Value] {
+					ctx.Report(pdf.Checks.PDFX1A.Colour.ColourSpaceNotAllowed, owner, fmt.Sprintf("%s colour space is not permitted in PDF/X-1a", family.Value))
+					return
+				}
+			}
+			for _, item := range val {
+				walk(item, owner, depth+1)
+			}
+		case pdf.PDFDict:
+			ptr := pdf.ValuePointer(val.Entries)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			if (val.Entries["Type"] == pdf.PDFName{Value: "Page"}) {
+				if ref, ok := val.Entries["_ref"].(pdf.PDFRef); ok {
+					saved := ctx.CurrentPage
+					ctx.CurrentPage = ctx.PageIndex[ref.ObjNum]
+					defer func() { ctx.CurrentPage = saved }()
+				}
+			}
+			keysBase := len(ctx.keyScratch)
+			defer func() { ctx.keyScratch = ctx.keyScratch[:keysBase] }()
+			for _, k := range ctx.sortedKeys(val.Entries) {
+				if k != "OutputIntents" {
+					walk(val.Entries[k], val, depth+1)
+				}
+			}
+		}
+	}
+	walk(graph, pdf.PDFDict{}, 0)
+}
+
+// verifyPdfXPageBoxes checks every page's TrimBox or ArtBox and the nesting
+// of its boxes (6.4): the BleedBox within the MediaBox, and the TrimBox or
+// ArtBox within the BleedBox, or the MediaBox if there is none.
+func verifyPdfXPageBoxes(root pdf.PDFDict, ctx *ValidationContext) {
+	checks := pdf.Checks.PDFX1A.Page
+	visited := map[uintptr]bool{}
+	var walk func(node pdf.PDFDict, mediaBox pdf.PDFValue, depth int)
+	walk = func(node pdf.PDFDict, mediaBox pdf.PDFValue, depth int) {
+		ptr := pdf.ValuePointer(node.Entries)
+		if depth > 64 || visited[ptr] {
+			return
+		}
+		visited[ptr] = true
+		if mb := node.Entries["MediaBox"]; mb != nil {
+			mediaBox = mb
+		}
+		if kids, ok := node.Entries["Kids"].(pdf.PDFArray); ok {
+			for _, kid := range kids {
+				if kd, ok := kid.(pdf.PDFDict); ok {
+					walk(kd, mediaBox, depth+1)
+				}
+			}
+			return
+		}
+		if ref, ok := node.Entries["_ref"].(pdf.PDFRef); ok {
+			ctx.CurrentPage = ctx.PageIndex[ref.ObjNum]
+		}
+
+		trim, hasTrim := pageBox(node.Entries["TrimBox"])
+		art, hasArt := pageBox(node.Entries["ArtBox"])
+		switch {
+		case !hasTrim && !hasArt:
+			ctx.Report(checks.TrimOrArtBox, node, "page has neither a TrimBox nor an ArtBox")
+		case hasTrim && hasArt:
+			ctx.Report(checks.TrimAndArtBox, node, "page has both a TrimBox and an ArtBox")
+		}
+
+		media, hasMedia := pageBox(mediaBox)
+		outer, outerName := media, "MediaBox"
+		if bleed, ok := pageBox(node.Entries["BleedBox"]); ok {
+			if hasMedia && !boxWithin(bleed, media) {
+				ctx.Report(checks.BoxNesting, node, "BleedBox extends beyond the MediaBox")
+			}
+			outer, outerName, hasMedia = bleed, "BleedBox", true
+		}
+		for name, inner := range map[string]struct {
+			box [4]float64
+			ok  bool
+		}{"TrimBox": {trim, hasTrim}, "ArtBox": {art, hasArt}} {
+			if inner.ok && hasMedia && !boxWithin(inner.box, outer) {
+				ctx.Report(checks.BoxNesting, node, fmt.Sprintf("%s extends beyond the %s", name, outerName))
+			}
+		}
+	}
+	if pages, ok := root.Entries["Pages"].(pdf.PDFDict); ok {
+		walk(pages, nil, 0)
+	}
+	ctx.CurrentPage = 0
+}
+
+// pageBox returns v as a normalized rectangle (llx, lly, urx, ury), and
+// whether it is one.
+func pageBox(v pdf.PDFValue) ([4]float64, bool) {
+	var r [4]float64
+	arr, ok := v.(pdf.PDFArray)
+	if !ok || len(arr) != 4 {
+		return r, false
+	}
+	for i, item := range arr {
+		if r[i], ok = AsFloat(item); !ok {
+			return r, false
+		}
+	}
+	return [4]float64{min(r[0], r[2]), min(r[1], r[3]), max(r[0], r[2]), max(r[1], r[3])}, true
+}
+
+// boxWithin reports whether rectangle inner lies within outer, allowing for
+// rounding in the last decimal place writers commonly emit.
+func boxWithin(inner, outer [4]float64) bool {
+	const eps = 0.01
+	return inner[0] >= outer[0]-eps && inner[1] >= outer[1]-eps &&
+		inner[2] <= outer[2]+eps && inner[3] <= outer[3]+eps
+}
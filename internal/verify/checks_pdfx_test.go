@@ -0,0 +1,257 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+	"github.com/voidrab/gopdfrab/internal/writer"
+)
+
+// iccHeader returns a 128-byte ICC profile header of the given device class
+// and colour space, enough for the destination profile checks.
+func iccHeader(class, space string) pdf.PDFDict {
+	data := make([]byte, 128)
+	copy(data[12:16], class)
+	copy(data[16:20], space)
+	profile := pdf.NewPDFDict()
+	profile.Entries["N"] = pdf.PDFInteger(4)
+	profile.HasStream = true
+	profile.RawStream = data
+	return profile
+}
+
+func pdfxIntent(kv map[string]pdf.PDFValue) pdf.PDFDict {
+	intent := pdf.NewPDFDict()
+	intent.Entries["Type"] = pdf.PDFName{Value: "OutputIntent"}
+	intent.Entries["S"] = pdf.PDFName{Value: "GTS_PDFX"}
+	for k, v := range kv {
+		intent.Entries[k] = v
+	}
+	return intent
+}
+
+func TestVerifyPdfXOutputIntent(t *testing.T) {
+	oi := pdf.Checks.PDFX1A.OutputIntent
+	cmyk := iccHeader("prtr", "CMYK")
+	id := pdf.PDFString{Value: "FOGRA39"}
+	for _, tc := range []struct {
+		name    string
+		level   pdf.LevelType
+		intents pdf.PDFArray
+		want    pdf.Check
+	}{
+		{"valid", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": cmyk})}, pdf.Check{}},
+		{"none", pdf.X_1A, nil, oi.PDFXOutputIntent},
+		{"PDF/A intent only", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"S": pdf.PDFName{Value: "GTS_PDFA1"}})}, oi.PDFXOutputIntent},
+		{"multiple", pdf.X_1A, pdf.PDFArray{
+			pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": cmyk}),
+			pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": cmyk}),
+		}, oi.PDFXOutputIntentMultiple},
+		{"no identifier", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"DestOutputProfile": cmyk})}, oi.OutputConditionIdentifier},
+		{"registered, no profile", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "RegistryName": pdf.PDFString{Value: "http://www.color.org"}})}, pdf.Check{}},
+		{"unregistered, no profile", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id})}, oi.DestOutputProfile},
+		{"X-4 registered, no profile", pdf.X_4, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "RegistryName": pdf.PDFString{Value: "http://www.color.org"}})}, oi.DestOutputProfile},
+		{"display profile", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": iccHeader("mntr", "CMYK")})}, oi.OutputProfileClass},
+		{"X-1a RGB profile", pdf.X_1A, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": iccHeader("prtr", "RGB ")})}, oi.OutputProfileClass},
+		{"X-4 RGB profile", pdf.X_4, pdf.PDFArray{pdfxIntent(map[string]pdf.PDFValue{"OutputConditionIdentifier": id, "DestOutputProfile": iccHeader("prtr", "RGB ")})}, pdf.Check{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := pdf.NewPDFDict()
+			if tc.intents != nil {
+				root.Entries["OutputIntents"] = tc.intents
+			}
+			ctx := &ValidationContext{level: tc.level}
+			verifyPdfXOutputIntent(root, ctx)
+			if tc.want == (pdf.Check{}) {
+				if len(ctx.errs) != 0 {
+					t.Errorf("unexpected issues: %v", ctx.errs)
+				}
+				return
+			}
+			if !hasCheck(ctx, tc.want) {
+				t.Errorf("%s not reported; got %v", tc.want.Name(), ctx.errs)
+			}
+		})
+	}
+}
+
+func TestVerifyPdfXIdentificationX1a(t *testing.T) {
+	doc := pdf.Checks.PDFX1A.Document
+	for _, tc := range []struct {
+		name     string
+		info     map[string]pdf.PDFValue
+		encrypt  bool
+		want     []pdf.Check
+		wantNone bool
+	}{
+		{"valid", map[string]pdf.PDFValue{"GTS_PDFXVersion": pdf.PDFString{Value: "PDF/X-1:2001"}, "Trapped": pdf.PDFName{Value: "False"}}, false, nil, true},
+		{"missing", nil, false, []pdf.Check{doc.VersionIdentifier, doc.TrappedKey}, false},
+		{"wrong version", map[string]pdf.PDFValue{"GTS_PDFXVersion": pdf.PDFString{Value: "PDF/X-3:2002"}, "Trapped": pdf.PDFName{Value: "True"}}, false, []pdf.Check{doc.VersionIdentifier}, false},
+		{"unknown trapping", map[string]pdf.PDFValue{"GTS_PDFXVersion": pdf.PDFString{Value: "PDF/X-1:2001"}, "Trapped": pdf.PDFName{Value: "Unknown"}}, false, []pdf.Check{doc.TrappedKey}, false},
+		{"encrypted", map[string]pdf.PDFValue{"GTS_PDFXVersion": pdf.PDFString{Value: "PDF/X-1:2001"}, "Trapped": pdf.PDFName{Value: "True"}}, true, []pdf.Check{doc.Encrypted}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			trailer := pdf.NewPDFDict()
+			minimalConformantRoot(trailer)
+			info := pdf.NewPDFDict()
+			for k, v := range tc.info {
+				info.Entries[k] = v
+			}
+			trailer.Entries["Info"] = info
+			if tc.encrypt {
+				trailer.Entries["Encrypt"] = pdf.NewPDFDict()
+			}
+			ctx := &ValidationContext{level: pdf.X_1A}
+			verifyPdfXIdentification(nil, trailer, ctx)
+			if tc.wantNone && len(ctx.errs) != 0 {
+				t.Errorf("unexpected issues: %v", ctx.errs)
+			}
+			for _, c := range tc.want {
+				if !hasCheck(ctx, c) {
+					t.Errorf("%s not reported; got %v", c.Name(), ctx.errs)
+				}
+			}
+		})
+	}
+}
+
+func pdfxPage(kv map[string]pdf.PDFValue) pdf.PDFDict {
+	page := pdf.NewPDFDict()
+	page.Entries["Type"] = pdf.PDFName{Value: "Page"}
+	for k, v := range kv {
+		page.Entries[k] = v
+	}
+	return page
+}
+
+func rect(llx, lly, urx, ury int) pdf.PDFArray {
+	return pdf.PDFArray{pdf.PDFInteger(llx), pdf.PDFInteger(lly), pdf.PDFInteger(urx), pdf.PDFInteger(ury)}
+}
+
+func TestVerifyPdfXPageBoxes(t *testing.T) {
+	page := pdf.Checks.PDFX1A.Page
+	for _, tc := range []struct {
+		name string
+		page pdf.PDFDict
+		want pdf.Check
+	}{
+		{"trim within bleed", pdfxPage(map[string]pdf.PDFValue{"BleedBox": rect(0, 0, 600, 800), "TrimBox": rect(9, 9, 591, 791)}), pdf.Check{}},
+		{"art within media", pdfxPage(map[string]pdf.PDFValue{"ArtBox": rect(600, 800, 10, 10)}), pdf.Check{}},
+		{"no trim or art", pdfxPage(nil), page.TrimOrArtBox},
+		{"trim and art", pdfxPage(map[string]pdf.PDFValue{"TrimBox": rect(10, 10, 100, 100), "ArtBox": rect(10, 10, 100, 100)}), page.TrimAndArtBox},
+		{"bleed beyond media", pdfxPage(map[string]pdf.PDFValue{"BleedBox": rect(-9, 0, 600, 800), "TrimBox": rect(10, 10, 500, 500)}), page.BoxNesting},
+		{"trim beyond bleed", pdfxPage(map[string]pdf.PDFValue{"BleedBox": rect(10, 10, 500, 500), "TrimBox": rect(0, 0, 600, 800)}), page.BoxNesting},
+		{"own media box", pdfxPage(map[string]pdf.PDFValue{"MediaBox": rect(0, 0, 300, 300), "TrimBox": rect(0, 0, 600, 800)}), page.BoxNesting},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pages := pdf.NewPDFDict()
+			pages.Entries["Type"] = pdf.PDFName{Value: "Pages"}
+			pages.Entries["MediaBox"] = rect(0, 0, 612, 842)
+			pages.Entries["Kids"] = pdf.PDFArray{tc.page}
+			root := pdf.NewPDFDict()
+			root.Entries["Pages"] = pages
+			ctx := &ValidationContext{level: pdf.X_1A}
+			verifyPdfXPageBoxes(root, ctx)
+			if tc.want == (pdf.Check{}) {
+				if len(ctx.errs) != 0 {
+					t.Errorf("unexpected issues: %v", ctx.errs)
+				}
+				return
+			}
+			if !hasCheck(ctx, tc.want) {
+				t.Errorf("%s not reported; got %v", tc.want.Name(), ctx.errs)
+			}
+		})
+	}
+}
+
+func TestVerifyPdfX1aColourSpaces(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cs   pdf.PDFValue
+		want bool
+	}{
+		{"DeviceCMYK", pdf.PDFName{Value: "DeviceCMYK"}, false},
+		{"Separation over CMYK", pdf.PDFArray{pdf.PDFName{Value: "Separation"}, pdf.PDFName{Value: "Spot"}, pdf.PDFName{Value: "DeviceCMYK"}, pdf.NewPDFDict()}, false},
+		{"ICCBased", pdf.PDFArray{pdf.PDFName{Value: "ICCBased"}, iccHeader("prtr", "CMYK")}, true},
+		{"Lab", pdf.PDFArray{pdf.PDFName{Value: "Lab"}, pdf.NewPDFDict()}, true},
+		{"Indexed over CalRGB", pdf.PDFArray{pdf.PDFName{Value: "Indexed"}, pdf.PDFArray{pdf.PDFName{Value: "CalRGB"}, pdf.NewPDFDict()}, pdf.PDFInteger(1), pdf.PDFString{Value: "abcdef"}}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			csDict := pdf.NewPDFDict()
+			csDict.Entries["CS0"] = tc.cs
+			res := pdf.NewPDFDict()
+			res.Entries["ColorSpace"] = csDict
+			graph := pdfxPage(map[string]pdf.PDFValue{"Resources": res})
+			ctx := &ValidationContext{level: pdf.X_1A}
+			verifyPdfX1aColourSpaces(graph, ctx)
+			if got := hasCheck(ctx, pdf.Checks.PDFX1A.Colour.ColourSpaceNotAllowed); got != tc.want {
+				t.Errorf("ColourSpaceNotAllowed reported = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeColourCoverageGTSPDFX(t *testing.T) {
+	f := t.TempDir() + "/oi-pdfx.pdf"
+	writeMinimalPDFWithOutputIntent(t, f, "<< /S /GTS_PDFX /DestOutputProfile 5 0 R >>")
+	doc, err := pdf.Open(f)
+	if err != nil {
+		t.Fatalf("pdf.Open: %v", err)
+	}
+	defer doc.Close()
+	for _, tc := range []struct {
+		level pdf.LevelType
+		want  bool
+	}{{pdf.A_1B, false}, {pdf.X_1A, true}, {pdf.X_4, true}} {
+		ctx := &ValidationContext{level: tc.level}
+		computeColourCoverage(doc, ctx)
+		if ctx.hasOutputIntent != tc.want {
+			t.Errorf("%s: hasOutputIntent = %v, want %v", tc.level, ctx.hasOutputIntent, tc.want)
+		}
+	}
+}
+
+func TestVerifyPdfX(t *testing.T) {
+	for _, tc := range []struct {
+		profile *pdf.Profile
+		spec    pdf.Spec
+		want    pdf.Check
+	}{
+		{pdf.PDFX_1A, pdf.SpecPDFX1A, pdf.Checks.PDFX1A.OutputIntent.PDFXOutputIntent},
+		{pdf.PDFX_4, pdf.SpecPDFX4, pdf.Checks.PDFX4.OutputIntent.PDFXOutputIntent},
+	} {
+		t.Run(string(tc.profile.Level), func(t *testing.T) {
+			trailer := pdf.NewPDFDict()
+			minimalConformantRoot(trailer)
+			var buf bytes.Buffer
+			if err := writer.WriteDocument(&buf, trailer); err != nil {
+				t.Fatal(err)
+			}
+			d, err := pdf.OpenBytes(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			res, err := Verify(d, tc.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Valid {
+				t.Fatalf("document without a GTS_PDFX output intent verified as %s", tc.profile.Level)
+			}
+			got := map[pdf.Check]bool{}
+			for _, iss := range res.Issues {
+				if spec := iss.Check().Spec(); spec != tc.spec && spec != pdf.SpecPDF {
+					t.Errorf("issue %s numbered by %s", iss.Check().Name(), spec)
+				}
+				got[iss.Check()] = true
+			}
+			if !got[tc.want] {
+				t.Errorf("%s not reported", tc.want.Name())
+			}
+		})
+	}
+}
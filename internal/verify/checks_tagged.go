@@ -0,0 +1,172 @@
+package verify
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// Level A: logical structure (ISO 19005-1:2005 6.8). These rules only apply
+// to levels whose LevelType.RequiresLogicalStructure holds; a level B profile
+// never runs them, whatever checks it enables. The Unicode character map
+// rules level A shares with level U are in checks_unicode.go.
+
+// StandardStructureTypes are the standard structure types of PDF Reference
+// 1.4, 9.7.4, which a role map must ultimately resolve every other type to.
+var StandardStructureTypes = map[string]bool{
+	"Document": true, "Part": true, "Art": true, "Sect": true, "Div": true,
+	"BlockQuote": true, "Caption": true, "TOC": true, "TOCI": true,
+	"Index": true, "NonStruct": true, "Private": true,
+	"P": true, "H": true, "H1": true, "H2": true, "H3": true, "H4": true,
+	"H5": true, "H6": true, "L": true, "LI": true, "Lbl": true, "LBody": true,
+	"Table": true, "TR": true, "TH": true, "TD": true,
+	"Span": true, "Quote": true, "Note": true, "Reference": true,
+	"BibEntry": true, "Code": true, "Link": true,
+	"Figure": true, "Formula": true, "Form": true,
+}
+
+// pdf17StructureTypes are the standard structure types PDF 1.5 to 1.7 added
+// to StandardStructureTypes, which PDF/UA-1, being based on PDF 1.7, accepts
+// as well.
+var pdf17StructureTypes = map[string]bool{
+	"THead": true, "TBody": true, "TFoot": true, "Annot": true,
+	"Ruby": true, "RB": true, "RT": true, "RP": true,
+	"Warichu": true, "WT": true, "WP": true,
+}
+
+// isStandardStructType reports whether typ is a standard structure type of
+// the PDF version the level being verified is based on.
+func (ctx *ValidationContext) isStandardStructType(typ string) bool {
+	return StandardStructureTypes[typ] || (ctx.level == pdf.UA_1 && pdf17StructureTypes[typ])
+}
+
+// langRe matches an RFC 1766 language identifier: a primary tag and any
+// number of subtags of one to eight letters or digits.
+var langRe = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// ValidLang reports whether v, a Lang entry's value, is empty (language
+// unknown) or an RFC 1766 language identifier. A value that is not a string
+// is left to the object-model checks.
+func ValidLang(v pdf.PDFValue) bool {
+	switch v.(type) {
+	case pdf.PDFString, pdf.PDFHexString:
+		lang := pdf.DecodeInfoTextString(v)
+		return lang == "" || langRe.MatchString(lang)
+	}
+	return true
+}
+
+// ResolveStructType follows roleMap from typ until it reaches a standard
+// structure type, returning the type it stopped at and whether the mapping
+// revisited a type (a circular role map).
+func ResolveStructType(typ string, roleMap pdf.PDFDict) (resolved string, circular bool) {
+	return resolveStructType(typ, roleMap, func(t string) bool { return StandardStructureTypes[t] })
+}
+
+// resolveStructType is ResolveStructType with the set of standard types
+// given by standard.
+func resolveStructType(typ string, roleMap pdf.PDFDict, standard func(string) bool) (resolved string, circular bool) {
+	seen := map[string]bool{}
+	for !standard(typ) {
+		if seen[typ] {
+			return typ, true
+		}
+		seen[typ] = true
+		next, ok := roleMap.Entries[typ].(pdf.PDFName)
+		if !ok {
+			return typ, false
+		}
+		typ = next.Value
+	}
+	return typ, false
+}
+
+// verifyLogicalStructure checks the tagged PDF requirements of level A
+// (6.8): the catalog's MarkInfo, the structure tree and its role map, Lang
+// entries, and alternate descriptions of Figure and Formula elements. The
+// PDF/UA-1 verifier runs it too, for the rules ISO 14289-1 7.1 and 7.2 share.
+func verifyLogicalStructure(graph pdf.PDFValue, ctx *ValidationContext) {
+	trailer, _ := graph.(pdf.PDFDict)
+	root, ok := trailer.Entries["Root"].(pdf.PDFDict)
+	if !ok {
+		return
+	}
+	checks := pdf.Checks.LogicalStructure
+
+	markInfo, _ := root.Entries["MarkInfo"].(pdf.PDFDict)
+	if marked, _ := markInfo.Entries["Marked"].(pdf.PDFBoolean); !marked {
+		ctx.Report(checks.TaggedMarkInfo, root, "document catalog lacks a MarkInfo dictionary with Marked true")
+	}
+	if lang, ok := root.Entries["Lang"]; ok && !ValidLang(lang) {
+		ctx.Report(checks.LangIdentifier, root, fmt.Sprintf("catalog Lang %q is not a language identifier", pdf.DecodeInfoTextString(lang)))
+	}
+
+	st, ok := root.Entries["StructTreeRoot"].(pdf.PDFDict)
+	if !ok {
+		ctx.Report(checks.StructTreeRoot, root, "document catalog lacks a StructTreeRoot")
+		return
+	}
+	roleMap, _ := st.Entries["RoleMap"].(pdf.PDFDict)
+	circular := map[string]bool{}
+	for _, typ := range slices.Sorted(maps.Keys(roleMap.Entries)) {
+		if typ == "_ref" {
+			continue
+		}
+		if _, cyc := resolveStructType(typ, roleMap, ctx.isStandardStructType); cyc {
+			circular[typ] = true
+			ctx.Report(checks.RoleMapCircular, st, fmt.Sprintf("role map entry /%s maps back onto itself", typ))
+		}
+	}
+
+	WalkStructElems(st, func(elem pdf.PDFDict, typ string) {
+		verifyStructElem(elem, typ, roleMap, circular, ctx)
+	})
+}
+
+// WalkStructElems calls fn for every structure element below the structure
+// tree root st, in document order, with its structure type. It follows only
+// K entries, so marked-content and object references are skipped and the P
+// back-pointers are never walked.
+func WalkStructElems(st pdf.PDFDict, fn func(elem pdf.PDFDict, typ string)) {
+	visited := map[uintptr]bool{}
+	var walk func(k pdf.PDFValue)
+	walk = func(k pdf.PDFValue) {
+		switch v := k.(type) {
+		case pdf.PDFArray:
+			for _, kid := range v {
+				walk(kid)
+			}
+		case pdf.PDFDict:
+			s, ok := v.Entries["S"].(pdf.PDFName)
+			if !ok {
+				return // marked-content or object reference
+			}
+			ptr := pdf.ValuePointer(v.Entries)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			fn(v, s.Value)
+			walk(v.Entries["K"])
+		}
+	}
+	walk(st.Entries["K"])
+}
+
+// verifyStructElem checks one structure element of type typ.
+func verifyStructElem(elem pdf.PDFDict, typ string, roleMap pdf.PDFDict, circular map[string]bool, ctx *ValidationContext) {
+	checks := pdf.Checks.LogicalStructure
+	resolved, _ := resolveStructType(typ, roleMap, ctx.isStandardStructType)
+	if !ctx.isStandardStructType(resolved) && !circular[typ] {
+		ctx.Report(checks.RoleMapStandardType, elem, fmt.Sprintf("structure type /%s is not mapped to a standard structure type", typ))
+	}
+	if lang, ok := elem.Entries["Lang"]; ok && !ValidLang(lang) {
+		ctx.Report(checks.LangIdentifier, elem, fmt.Sprintf("structure element Lang %q is not a language identifier", pdf.DecodeInfoTextString(lang)))
+	}
+	if (resolved == "Figure" || resolved == "Formula") && elem.Entries["Alt"] == nil && elem.Entries["ActualText"] == nil {
+		ctx.Report(checks.AlternateDescription, elem, fmt.Sprintf("/%s structure element has neither Alt nor ActualText", typ))
+	}
+}
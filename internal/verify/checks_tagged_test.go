@@ -0,0 +1,114 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// taggedTrailer returns a trailer whose catalog is marked and carries a
+// structure tree of the given elements under a Document element.
+func taggedTrailer(roleMap map[string]string, elems ...pdf.PDFDict) pdf.PDFDict {
+	trailer := pdf.NewPDFDict()
+	minimalConformantRoot(trailer)
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	markInfo := pdf.NewPDFDict()
+	markInfo.Entries["Marked"] = pdf.PDFBoolean(true)
+	root.Entries["MarkInfo"] = markInfo
+	root.Entries["Lang"] = pdf.PDFString{Value: "en-US"}
+
+	doc := structElem("Document")
+	kids := pdf.PDFArray{}
+	for _, e := range elems {
+		e.Entries["P"] = doc
+		kids = append(kids, e)
+	}
+	doc.Entries["K"] = kids
+	st := pdf.NewPDFDict()
+	st.Entries["Type"] = pdf.PDFName{Value: "StructTreeRoot"}
+	st.Entries["K"] = doc
+	if roleMap != nil {
+		rm := pdf.NewPDFDict()
+		for k, v := range roleMap {
+			rm.Entries[k] = pdf.PDFName{Value: v}
+		}
+		st.Entries["RoleMap"] = rm
+	}
+	root.Entries["StructTreeRoot"] = st
+	return trailer
+}
+
+func structElem(typ string, kv ...any) pdf.PDFDict {
+	e := pdf.NewPDFDict()
+	e.Entries["Type"] = pdf.PDFName{Value: "StructElem"}
+	e.Entries["S"] = pdf.PDFName{Value: typ}
+	for i := 0; i+1 < len(kv); i += 2 {
+		e.Entries[kv[i].(string)] = kv[i+1].(pdf.PDFValue)
+	}
+	return e
+}
+
+func TestVerifyLogicalStructure(t *testing.T) {
+	ls := pdf.Checks.LogicalStructure
+	alt := pdf.PDFString{Value: "A bar chart"}
+	for _, tc := range []struct {
+		name    string
+		trailer func() pdf.PDFDict
+		want    []pdf.Check
+	}{
+		{"conforming", func() pdf.PDFDict {
+			return taggedTrailer(map[string]string{"Para": "P", "Chart": "Figure"},
+				structElem("Para", "Lang", pdf.PDFString{Value: "de"}), structElem("Chart", "Alt", alt), structElem("Formula", "ActualText", alt))
+		}, nil},
+		{"not marked", func() pdf.PDFDict {
+			tr := taggedTrailer(nil)
+			delete(tr.Entries["Root"].(pdf.PDFDict).Entries, "MarkInfo")
+			return tr
+		}, []pdf.Check{ls.TaggedMarkInfo}},
+		{"no structure tree", func() pdf.PDFDict {
+			tr := taggedTrailer(nil)
+			delete(tr.Entries["Root"].(pdf.PDFDict).Entries, "StructTreeRoot")
+			return tr
+		}, []pdf.Check{ls.StructTreeRoot}},
+		{"unmapped type", func() pdf.PDFDict {
+			return taggedTrailer(map[string]string{"Para": "Paragraph"}, structElem("Para"))
+		}, []pdf.Check{ls.RoleMapStandardType}},
+		{"circular role map", func() pdf.PDFDict {
+			return taggedTrailer(map[string]string{"A": "B", "B": "A"}, structElem("A"))
+		}, []pdf.Check{ls.RoleMapCircular, ls.RoleMapCircular}},
+		{"bad Lang", func() pdf.PDFDict {
+			return taggedTrailer(nil, structElem("P", "Lang", pdf.PDFString{Value: "en_US"}))
+		}, []pdf.Check{ls.LangIdentifier}},
+		{"Figure without Alt", func() pdf.PDFDict {
+			return taggedTrailer(map[string]string{"Chart": "Figure"}, structElem("Chart"))
+		}, []pdf.Check{ls.AlternateDescription}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &ValidationContext{part: 1, level: pdf.A_1A}
+			verifyLogicalStructure(tc.trailer(), ctx)
+			if len(ctx.errs) != len(tc.want) {
+				t.Fatalf("got %v, want %d issue(s)", ctx.errs, len(tc.want))
+			}
+			for i, c := range tc.want {
+				if ctx.errs[i].Check() != c {
+					t.Errorf("issue %d = %s, want %s", i, ctx.errs[i].Check().Name(), c.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestValidLang(t *testing.T) {
+	for v, want := range map[pdf.PDFValue]bool{
+		pdf.PDFString{Value: ""}:             true,
+		pdf.PDFString{Value: "en"}:           true,
+		pdf.PDFString{Value: "zh-Hant-TW"}:   true,
+		pdf.PDFString{Value: "en_US"}:        false,
+		pdf.PDFString{Value: "english (UK)"}: false,
+		pdf.PDFName{Value: "en"}:             true, // type is the object model's concern
+	} {
+		if got := ValidLang(v); got != want {
+			t.Errorf("ValidLang(%v) = %v, want %v", v, got, want)
+		}
+	}
+}
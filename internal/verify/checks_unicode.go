@@ -0,0 +1,206 @@
+package verify
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// Unicode character maps: ISO 19005-1 6.3.8 (level A), ISO 19005-2 6.2.11.7
+// (levels A and U, likewise part 3) and every PDF/A-4 variant. Every font
+// shall map its character codes to Unicode; from part 2 on, each code shown
+// shall actually have a mapping, to a value that is not U+0000, U+FEFF or
+// U+FFFE. Only levels whose LevelType.RequiresUnicode holds run these rules.
+
+// unicodeCollections are the CIDFont character collections whose CIDs have
+// a predefined Unicode mapping, exempting a Type 0 font from ToUnicode.
+var unicodeCollections = map[string]bool{
+	"Adobe-GB1": true, "Adobe-CNS1": true, "Adobe-Japan1": true, "Adobe-Korea1": true,
+}
+
+// unicodeEncodings are the predefined simple font encodings whose codes map
+// to Unicode without a ToUnicode CMap.
+var unicodeEncodings = map[string]bool{
+	"MacRomanEncoding": true, "MacExpertEncoding": true, "WinAnsiEncoding": true,
+}
+
+// HasUnicodeMapping reports whether font dictionary v maps its character
+// codes to Unicode (6.3.8): through a ToUnicode CMap, a predefined encoding
+// whose Differences name only glyphs with standard Unicode values, or, for a
+// Type 0 font, a descendant using a predefined Adobe character collection.
+func HasUnicodeMapping(v pdf.PDFDict) bool {
+	if _, ok := v.Entries["ToUnicode"].(pdf.PDFDict); ok {
+		return true
+	}
+	subtype, _ := v.Entries["Subtype"].(pdf.PDFName)
+	if subtype.Value == "Type0" {
+		info, _ := DescendantCIDFont(v).Entries["CIDSystemInfo"].(pdf.PDFDict)
+		return unicodeCollections[cidInfoField(info, "Registry")+"-"+cidInfoField(info, "Ordering")]
+	}
+	switch enc := v.Entries["Encoding"].(type) {
+	case pdf.PDFName:
+		return unicodeEncodings[enc.Value]
+	case pdf.PDFDict:
+		if base, ok := enc.Entries["BaseEncoding"].(pdf.PDFName); ok && !unicodeEncodings[base.Value] {
+			return false
+		}
+		diffs, _ := enc.Entries["Differences"].(pdf.PDFArray)
+		for _, item := range diffs {
+			if name, ok := item.(pdf.PDFName); ok {
+				if _, known := GlyphNameToUnicode(name.Value); !known {
+					if _, known := SymbolGlyphNameUnicode[name.Value]; !known {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// SimpleFontUnicode returns the code -> Unicode table simple font d's
+// encoding implies, 0 marking a code without a known value: the standard
+// Symbol or ZapfDingbats encoding for those fonts when they declare no
+// Encoding, StandardEncoding for any other non-symbolic font without one,
+// else the table its Encoding resolves to, with Differences names from the
+// Symbol and ZapfDingbats glyph sets filled in as well. A symbolic font
+// without an Encoding uses its program's built-in encoding, which maps to
+// nothing here.
+func SimpleFontUnicode(d pdf.PDFDict) [256]uint16 {
+	if d.Entries["Encoding"] == nil {
+		baseFont, _ := d.Entries["BaseFont"].(pdf.PDFName)
+		name := baseFont.Value
+		if SubsetTagRe.MatchString(name) {
+			name = name[7:]
+		}
+		switch {
+		case strings.HasPrefix(name, "Symbol"):
+			return SymbolToUnicode
+		case strings.HasPrefix(name, "ZapfDingbats"):
+			return ZapfDingbatsToUnicode
+		}
+		desc, _ := d.Entries["FontDescriptor"].(pdf.PDFDict)
+		if flags, _ := desc.Entries["Flags"].(pdf.PDFInteger); flags&4 != 0 {
+			return [256]uint16{}
+		}
+		return StandardToUnicode
+	}
+	table := SimpleFontCodeToUnicode(d.Entries["Encoding"])
+	enc, _ := d.Entries["Encoding"].(pdf.PDFDict)
+	diffs, _ := enc.Entries["Differences"].(pdf.PDFArray)
+	code := 0
+	for _, item := range diffs {
+		switch v := item.(type) {
+		case pdf.PDFInteger:
+			code = int(v)
+		case pdf.PDFName:
+			if code >= 0 && code < 256 && table[code] == 0 {
+				if u, ok := SymbolGlyphNameUnicode[v.Value]; ok {
+					table[code] = u
+				} else {
+					table[code] = ZapfDingbatsGlyphNameUnicode[v.Value]
+				}
+			}
+			code++
+		}
+	}
+	return table
+}
+
+// validateUnicodeMapping reports a font that does not map its codes to
+// Unicode (6.3.8) and, from part 2 on, each shown code of a font that does
+// but leaves the code unmapped or maps it to a forbidden value (6.2.11.7).
+// Descendant CIDFonts are covered by their Type 0 parent.
+func validateUnicodeMapping(v pdf.PDFDict, subtype, baseFont string, ctx *ValidationContext) {
+	if subtype == "CIDFontType0" || subtype == "CIDFontType2" {
+		return
+	}
+	if !HasUnicodeMapping(v) {
+		ctx.Report(pdf.Checks.Font.ToUnicodeMissing, v, fmt.Sprintf("font %s has no ToUnicode CMap or predefined Unicode encoding", baseFont))
+		return
+	}
+	if ctx.part >= 2 {
+		validateShownCodesUnicode(v, subtype, baseFont, ctx)
+	}
+}
+
+// InvalidUnicodeValue reports whether text holds a value 6.2.11.7 forbids a
+// ToUnicode CMap to map to: U+0000, U+FEFF or U+FFFE.
+func InvalidUnicodeValue(text string) bool {
+	return strings.ContainsAny(text, "\x00\uFEFF\uFFFE")
+}
+
+// validateShownCodesUnicode resolves every code font v shows to Unicode and
+// reports, per page, the codes without a mapping (UnmappableCharCode) and
+// those whose ToUnicode value is forbidden (InvalidUnicodeValue). Codes
+// resolve through the ToUnicode CMap first and a simple font's encoding
+// second. A Type 0 font is checked only for the Identity-H/V encodings
+// whose shown CIDs are tracked, and only through its ToUnicode CMap: a
+// predefined character collection maps every CID it defines.
+func validateShownCodesUnicode(v pdf.PDFDict, subtype, baseFont string, ctx *ValidationContext) {
+	var toUnicode *pdf.ToUnicodeCMap
+	if stm, ok := v.Entries["ToUnicode"].(pdf.PDFDict); ok && stm.HasStream {
+		if data, err := ctx.decodeStreamCached(stm); err == nil {
+			toUnicode = pdf.ParseToUnicodeCMap(data)
+		}
+	}
+
+	var (
+		key      uintptr
+		used     map[int]bool
+		known    bool
+		n        = 1
+		encTable *[256]uint16
+	)
+	if subtype == "Type0" {
+		if toUnicode == nil {
+			return
+		}
+		desc := DescendantCIDFont(v)
+		key, n = ctx.nodeKey(pdf.ValuePointer(desc.Entries)), 2
+		used, known = ctx.usedCIDsFor(desc)
+	} else {
+		key = ctx.nodeKey(pdf.ValuePointer(v.Entries))
+		used, known = ctx.usedCodesFor(v)
+		table := SimpleFontUnicode(v)
+		encTable = &table
+	}
+	if !known || len(used) == 0 {
+		return
+	}
+
+	pages := ctx.usedCodePages[key]
+	if pages == nil {
+		pages = map[int]map[int]bool{ctx.CurrentPage: used}
+	}
+	savedPage := ctx.CurrentPage
+	defer func() { ctx.CurrentPage = savedPage }()
+	for _, page := range slices.Sorted(maps.Keys(pages)) {
+		var unmapped, invalid []string
+		for _, code := range slices.Sorted(maps.Keys(pages[page])) {
+			if toUnicode != nil {
+				if text, ok := toUnicode.Lookup(uint32(code), n); ok && text != "" {
+					if InvalidUnicodeValue(text) {
+						invalid = append(invalid, fmt.Sprintf("<%0*X>", 2*n, code))
+					}
+					continue
+				}
+			}
+			if encTable != nil && code < 256 && encTable[code] != 0 {
+				continue
+			}
+			unmapped = append(unmapped, fmt.Sprintf("<%0*X>", 2*n, code))
+		}
+		ctx.CurrentPage = page
+		if len(unmapped) > 0 {
+			ctx.Report(pdf.Checks.PDFA2.Font.UnmappableCharCode, v, fmt.Sprintf("font %s shows character code(s) %s with no Unicode mapping", baseFont, strings.Join(unmapped, " ")))
+		}
+		if len(invalid) > 0 {
+			ctx.Report(pdf.Checks.PDFA2.Font.InvalidUnicodeValue, v, fmt.Sprintf("font %s maps character code(s) %s to U+0000, U+FEFF or U+FFFE", baseFont, strings.Join(invalid, " ")))
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// TestHasUnicodeMapping, the PDF/A-1a case moved here with the check,
+// covers each way a font can map to Unicode and which levels report one
+// that cannot.
+func TestHasUnicodeMapping(t *testing.T) {
+	font := func(kv ...any) pdf.PDFDict {
+		f := pdf.NewPDFDict()
+		f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+		for i := 0; i+1 < len(kv); i += 2 {
+			f.Entries[kv[i].(string)] = kv[i+1].(pdf.PDFValue)
+		}
+		return f
+	}
+	diffs := func(base string, names ...string) pdf.PDFDict {
+		enc := pdf.NewPDFDict()
+		if base != "" {
+			enc.Entries["BaseEncoding"] = pdf.PDFName{Value: base}
+		}
+		arr := pdf.PDFArray{pdf.PDFInteger(65)}
+		for _, n := range names {
+			arr = append(arr, pdf.PDFName{Value: n})
+		}
+		enc.Entries["Differences"] = arr
+		return enc
+	}
+	cid := func(ordering string) pdf.PDFDict {
+		info := pdf.NewPDFDict()
+		info.Entries["Registry"] = pdf.PDFString{Value: "Adobe"}
+		info.Entries["Ordering"] = pdf.PDFString{Value: ordering}
+		desc := font("Subtype", pdf.PDFName{Value: "CIDFontType0"}, "CIDSystemInfo", info)
+		return font("Subtype", pdf.PDFName{Value: "Type0"}, "DescendantFonts", pdf.PDFArray{desc})
+	}
+	for _, tc := range []struct {
+		name string
+		font pdf.PDFDict
+		want bool
+	}{
+		{"ToUnicode", font("Subtype", pdf.PDFName{Value: "Type1"}, "ToUnicode", pdf.NewPDFDict()), true},
+		{"WinAnsi", font("Subtype", pdf.PDFName{Value: "Type1"}, "Encoding", pdf.PDFName{Value: "WinAnsiEncoding"}), true},
+		{"StandardEncoding", font("Subtype", pdf.PDFName{Value: "Type1"}, "Encoding", pdf.PDFName{Value: "StandardEncoding"}), false},
+		{"no Encoding", font("Subtype", pdf.PDFName{Value: "TrueType"}), false},
+		{"standard Differences", font("Subtype", pdf.PDFName{Value: "Type1"}, "Encoding", diffs("WinAnsiEncoding", "Euro", "alpha")), true},
+		{"custom Differences", font("Subtype", pdf.PDFName{Value: "Type1"}, "Encoding", diffs("", "g123")), false},
+		{"Adobe-Japan1", cid("Japan1"), true},
+		{"Identity", cid("Identity"), false},
+	} {
+		if got := HasUnicodeMapping(tc.font); got != tc.want {
+			t.Errorf("%s: HasUnicodeMapping = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Only a level requiring Unicode runs the check.
+	noMap := font("Subtype", pdf.PDFName{Value: "Type1"}, "BaseFont", pdf.PDFName{Value: "Custom"})
+	for level, want := range map[pdf.LevelType]bool{pdf.A_1A: true, pdf.A_1B: false, pdf.A_4: true} {
+		ctx := &ValidationContext{part: level.Part(), level: level}
+		ValidateFontDict(noMap, ctx)
+		if got := hasCheck(ctx, pdf.Checks.Font.ToUnicodeMissing); got != want {
+			t.Errorf("%s: ToUnicodeMissing reported = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestSimpleFontUnicode(t *testing.T) {
+	font := func(kv ...any) pdf.PDFDict {
+		f := pdf.NewPDFDict()
+		for i := 0; i+1 < len(kv); i += 2 {
+			f.Entries[kv[i].(string)] = kv[i+1].(pdf.PDFValue)
+		}
+		return f
+	}
+	symbolic := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Flags": pdf.PDFInteger(4)}}
+	diffs := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Differences": pdf.PDFArray{pdf.PDFInteger(65), pdf.PDFName{Value: "alpha"}, pdf.PDFName{Value: "a20"}, pdf.PDFName{Value: "g7"}},
+	}}
+	for _, tc := range []struct {
+		name string
+		font pdf.PDFDict
+		code int
+		want uint16
+	}{
+		{"Symbol built-in", font("BaseFont", pdf.PDFName{Value: "ABCDEF+Symbol"}), 0x61, 0x03B1},
+		{"non-symbolic default", font("BaseFont", pdf.PDFName{Value: "Custom"}), 0x41, 'A'},
+		{"symbolic built-in", font("BaseFont", pdf.PDFName{Value: "Custom"}, "FontDescriptor", symbolic), 0x41, 0},
+		{"WinAnsi", font("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"}), 0x80, 0x20AC},
+		{"Symbol glyph name", font("Encoding", diffs), 65, 0x03B1},
+		{"ZapfDingbats glyph name", font("Encoding", diffs), 66, 0x2714},
+		{"unknown glyph name", font("Encoding", diffs), 67, 0},
+	} {
+		if got := SimpleFontUnicode(tc.font)[tc.code]; got != tc.want {
+			t.Errorf("%s: SimpleFontUnicode[%02X] = %04X, want %04X", tc.name, tc.code, got, tc.want)
+		}
+	}
+}
+
+// unicodeFontCtx returns a part 2 level U context in which font f showed
+// codes on the given pages.
+func unicodeFontCtx(f pdf.PDFDict, pages map[int][]int) *ValidationContext {
+	ctx := &ValidationContext{part: 2, level: pdf.A_2U, UsedCharCodes: map[uintptr]map[int]bool{}, UsedCIDs: map[uintptr]map[int]bool{}}
+	key, used := pdf.ValuePointer(f.Entries), ctx.UsedCharCodes
+	if desc := DescendantCIDFont(f); desc.Entries != nil {
+		key, used = pdf.ValuePointer(desc.Entries), ctx.UsedCIDs
+	}
+	used[key] = map[int]bool{}
+	ctx.usedCodePages = map[uintptr]map[int]map[int]bool{key: {}}
+	for page, codes := range pages {
+		ctx.usedCodePages[key][page] = map[int]bool{}
+		for _, c := range codes {
+			used[key][c] = true
+			ctx.usedCodePages[key][page][c] = true
+		}
+	}
+	return ctx
+}
+
+func TestValidateShownCodesUnicode(t *testing.T) {
+	toUnicode := func(cmap string) pdf.PDFDict {
+		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: []byte(cmap)}
+	}
+	simple := func(kv ...any) pdf.PDFDict {
+		f := pdf.NewPDFDict()
+		f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+		f.Entries["Subtype"] = pdf.PDFName{Value: "Type1"}
+		f.Entries["BaseFont"] = pdf.PDFName{Value: "Test"}
+		for i := 0; i+1 < len(kv); i += 2 {
+			f.Entries[kv[i].(string)] = kv[i+1].(pdf.PDFValue)
+		}
+		return f
+	}
+	type0 := func(cmap string) pdf.PDFDict {
+		desc := pdf.NewPDFDict()
+		desc.Entries["Subtype"] = pdf.PDFName{Value: "CIDFontType2"}
+		f := simple("Subtype", pdf.PDFName{Value: "Type0"}, "Encoding", pdf.PDFName{Value: "Identity-H"},
+			"DescendantFonts", pdf.PDFArray{desc}, "ToUnicode", toUnicode(cmap))
+		return f
+	}
+	font := pdf.Checks.PDFA2.Font
+	for _, tc := range []struct {
+		name  string
+		font  pdf.PDFDict
+		pages map[int][]int
+		want  []pdf.Check
+		page  []int
+	}{
+		{"WinAnsi", simple("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"}), map[int][]int{1: {0x41, 0x80}}, nil, nil},
+		{"WinAnsi undefined code", simple("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"}), map[int][]int{1: {0x41}, 3: {0x81}},
+			[]pdf.Check{font.UnmappableCharCode}, []int{3}},
+		{"ToUnicode fills the encoding's gap", simple("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"},
+			"ToUnicode", toUnicode("1 beginbfchar <81> <2022> endbfchar")), map[int][]int{1: {0x41, 0x81}}, nil, nil},
+		{"ToUnicode to U+FFFE", simple("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"},
+			"ToUnicode", toUnicode("1 beginbfchar <41> <FFFE> endbfchar")), map[int][]int{2: {0x41}},
+			[]pdf.Check{font.InvalidUnicodeValue}, []int{2}},
+		{"Identity-H", type0("1 beginbfrange <0001> <0003> <0061> endbfrange"), map[int][]int{1: {1, 3}}, nil, nil},
+		{"Identity-H unmapped CID", type0("1 beginbfrange <0001> <0003> <0061> endbfrange"), map[int][]int{1: {1, 7}, 2: {8}},
+			[]pdf.Check{font.UnmappableCharCode, font.UnmappableCharCode}, []int{1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := unicodeFontCtx(tc.font, tc.pages)
+			ValidateFontDict(tc.font, ctx)
+			var got []pdf.PDFError
+			for _, e := range ctx.errs {
+				if e.Check() == font.UnmappableCharCode || e.Check() == font.InvalidUnicodeValue {
+					got = append(got, e)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %d issue(s)", got, len(tc.want))
+			}
+			for i, c := range tc.want {
+				if got[i].Check() != c || got[i].Page() != tc.page[i] {
+					t.Errorf("issue %d = %s on page %d, want %s on page %d", i, got[i].Check().Name(), got[i].Page(), c.Name(), tc.page[i])
+				}
+			}
+		})
+	}
+
+	// Level B and part 1 level A do not check individual codes.
+	f := simple("Encoding", pdf.PDFName{Value: "WinAnsiEncoding"})
+	for _, level := range []pdf.LevelType{pdf.A_2B, pdf.A_1A} {
+		ctx := unicodeFontCtx(f, map[int][]int{1: {0x81}})
+		ctx.part, ctx.level = level.Part(), level
+		ValidateFontDict(f, ctx)
+		if hasCheck(ctx, font.UnmappableCharCode) {
+			t.Errorf("%s: UnmappableCharCode reported", level)
+		}
+	}
+}
+
+// TestComputeContentUsagePages confirms codes are recorded against the page
+// showing them, and only for a level requiring Unicode.
+func TestComputeContentUsagePages(t *testing.T) {
+	f := pdf.NewPDFDict()
+	f.Entries["Type"] = pdf.PDFName{Value: "Font"}
+	f.Entries["Subtype"] = pdf.PDFName{Value: "Type1"}
+	resources := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Font": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F1": f}}}}
+	page := func(objNum int, text string) pdf.PDFDict {
+		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"Type":      pdf.PDFName{Value: "Page"},
+			"_ref":      pdf.PDFRef{ObjNum: objNum},
+			"Resources": resources,
+			"Contents":  pdf.PDFDict{Entries: map[string]pdf.PDFValue{}, HasStream: true, RawStream: []byte("BT /F1 12 Tf (" + text + ") Tj ET")},
+		}}
+	}
+	graph := pdf.PDFArray{page(10, "AB"), page(11, "BC")}
+
+	ctx := &ValidationContext{PageIndex: map[int]int{10: 1, 11: 2}, part: 2, level: pdf.A_2U}
+	ComputeContentUsage(graph, ctx)
+	pages := ctx.usedCodePages[pdf.ValuePointer(f.Entries)]
+	if !pages[1]['A'] || !pages[1]['B'] || pages[1]['C'] || !pages[2]['C'] || pages[2]['A'] {
+		t.Errorf("usedCodePages = %v, want A,B on page 1 and B,C on page 2", pages)
+	}
+
+	ctx = &ValidationContext{PageIndex: map[int]int{10: 1, 11: 2}, part: 2, level: pdf.A_2B}
+	ComputeContentUsage(graph, ctx)
+	if ctx.usedCodePages != nil {
+		t.Error("usedCodePages collected for a level B target")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -218,33 +219,7 @@ func checkExtensionSchemas(xmp string) []pdf.PDFError {
 
 // validateExtSchemas parses and validates the pdfaExtension:schemas structure.
 func validateExtSchemas(data []byte) []pdf.PDFError {
-	// Strip leading BOM / whitespace to make a valid XML fragment.
-	if i := bytes.IndexByte(data, '<'); i > 0 {
-		data = data[i:]
-	}
-	dec := xml.NewDecoder(bytes.NewReader(data))
-	dec.Strict = false
-
-	// Locate the pdfaExtension:schemas element.
-	var schemas []extSchema
-	schemasContainerType := ""
-	found := false
-
-	for !found {
-		tok, err := dec.Token()
-		if err != nil {
-			break
-		}
-		se, ok := tok.(xml.StartElement)
-		if !ok {
-			continue
-		}
-		if se.Name.Space == nsExt && se.Name.Local == "schemas" {
-			found = true
-			schemasContainerType, schemas = parseSchemasBag(dec)
-		}
-	}
-
+	schemasContainerType, schemas, found := parseExtSchemas(data)
 	if !found {
 		return nil
 	}
@@ -256,15 +231,36 @@ func validateExtSchemas(data []byte) []pdf.PDFError {
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.ExtSchemasNotBag, "pdfaExtension:schemas must use rdf:Bag, not rdf:Seq"))
 	}
 
-	docNS := map[string]bool{}
 	for _, s := range schemas {
-		docNS[s.namespaceURI] = true
 		errs = append(errs, validateExtSchema(s, string(data))...)
 	}
 
 	return errs
 }
 
+// parseExtSchemas locates the pdfaExtension:schemas element in an XMP packet
+// and parses its entries, returning the container type ("Bag" or "Seq"),
+// the schemas, and whether the element was found at all.
+func parseExtSchemas(data []byte) (string, []extSchema, bool) {
+	// Strip leading BOM / whitespace to make a valid XML fragment.
+	if i := bytes.IndexByte(data, '<'); i > 0 {
+		data = data[i:]
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", nil, false
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Space == nsExt && se.Name.Local == "schemas" {
+			containerType, schemas := parseSchemasBag(dec)
+			return containerType, schemas, true
+		}
+	}
+}
+
 // parseSchemasBag parses the content of pdfaExtension:schemas, returning the
 // container type ("Bag" or "Seq") and all schema entries found.
 func parseSchemasBag(dec *xml.Decoder) (string, []extSchema) {
@@ -702,8 +698,9 @@ func xmpErr(c pdf.Check, msg string) pdf.PDFError {
 	return pdf.NewError(c, []error{fmt.Errorf("%s", msg)}, 0, nil)
 }
 
-// verifyXMPMetadata validates the document's XMP metadata (6.7).
-func verifyXMPMetadata(d *pdf.Reader) []pdf.PDFError {
+// verifyXMPMetadata validates the document's XMP metadata (6.7) for the
+// given part and, where the part's identifier depends on it, level.
+func verifyXMPMetadata(d *pdf.Reader, part int, level pdf.LevelType) []pdf.PDFError {
 	data, meta, err := d.RawXMP()
 	if errors.Is(err, pdf.ErrNoXMPMetadata) || errors.Is(err, pdf.ErrXMPMetadataNotStream) {
 		return []pdf.PDFError{xmpErr(pdf.Checks.Metadata.MetadataMissing, err.Error())}
@@ -722,12 +719,12 @@ func verifyXMPMetadata(d *pdf.Reader) []pdf.PDFError {
 	xmp := string(data)
 
 	errs = append(errs, checkXMPHeader(xmp)...)
-	errs = append(errs, checkPDFAIdentifier(xmp)...)
+	errs = append(errs, checkPDFAIdentifier(xmp, part, level)...)
 	if !xmpWellFormed(data) {
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.XMPNotWellFormed, "XMP metadata is not well-formed XML"))
 	}
 	errs = append(errs, checkXMPPropertyTypes(xmp)...)
-	errs = append(errs, checkXMPPropertySchemas(data)...)
+	errs = append(errs, checkXMPPropertySchemas(data, part)...)
 	errs = append(errs, checkInfoXMPSync(d, xmp)...)
 	errs = append(errs, checkExtensionSchemas(xmp)...)
 
@@ -750,20 +747,25 @@ func checkNonCatalogXMPStreams(graph pdf.PDFValue) []pdf.PDFError {
 	if meta, ok := root.Entries["Metadata"].(pdf.PDFDict); ok && meta.HasStream {
 		catalogMetaPtr = pdf.ValuePointer(meta.Entries)
 	}
+	return scanNonCatalogXMPStreams(graph, catalogMetaPtr, newVisitSet(nil, nil, nil), nil)
+}
 
+// scanNonCatalogXMPStreams is checkNonCatalogXMPStreams for a graph whose
+// catalog metadata stream is catalogMeta, by ctx.nodeKey, skipping nodes
+// already in visited, so a page-at-a-time verification can scan each pass's
+// graph in turn.
+func scanNonCatalogXMPStreams(graph pdf.PDFValue, catalogMeta uintptr, visited *visitSet, ctx *ValidationContext) []pdf.PDFError {
 	var errs []pdf.PDFError
-	visited := make(map[uintptr]bool)
 	var walk func(v pdf.PDFValue)
 	walk = func(v pdf.PDFValue) {
 		switch val := v.(type) {
 		case pdf.PDFDict:
 			ptr := pdf.ValuePointer(val.Entries)
-			if visited[ptr] {
+			if first, _ := visited.visit(ptr, ""); !first {
 				return
 			}
-			visited[ptr] = true
 			if val.HasStream && val.Entries["Type"] == (pdf.PDFName{Value: "Metadata"}) &&
-				ptr != catalogMetaPtr {
+				ctx.nodeKey(ptr) != catalogMeta {
 				data, err := pdf.DecodeStream(val)
 				if err != nil || !xpacketRe.Match(data) {
 					errs = append(errs, xmpErr(pdf.Checks.Metadata.ObjectXMPNoXPacket,
@@ -774,11 +776,9 @@ func checkNonCatalogXMPStreams(graph pdf.PDFValue) []pdf.PDFError {
 				walk(child)
 			}
 		case pdf.PDFArray:
-			ptr := pdf.ValuePointer(val)
-			if visited[ptr] {
+			if first, _ := visited.visit(pdf.ValuePointer(val), ""); !first {
 				return
 			}
-			visited[ptr] = true
 			for _, item := range val {
 				walk(item)
 			}
@@ -804,8 +804,9 @@ func checkXMPHeader(xmp string) []pdf.PDFError {
 	return errs
 }
 
-// checkPDFAIdentifier validates the PDF/A version identifier (6.7.11).
-func checkPDFAIdentifier(xmp string) []pdf.PDFError {
+// checkPDFAIdentifier validates the PDF/A version identifier (6.7.11). A part
+// 4 identifier is checked against level, whose variant it names.
+func checkPDFAIdentifier(xmp string, part int, level pdf.LevelType) []pdf.PDFError {
 	var errs []pdf.PDFError
 
 	ns, hasNS := pdf.FirstRegexpGroup(pdfaNSRe, xmp)
@@ -816,23 +817,57 @@ func checkPDFAIdentifier(xmp string) []pdf.PDFError {
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAIdentifierNamespace, "invalid PDF/A identifier namespace"))
 	}
 
-	part, hasPart := pdf.FirstRegexpGroup(pdf.PDFAPartRe, xmp)
+	gotPart, hasPart := pdf.FirstRegexpGroup(pdf.PDFAPartRe, xmp)
 	if !hasPart {
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAIdentifierMissing, "missing PDF/A part identifier"))
-	} else if part != "1" {
-		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAPartNumber, fmt.Sprintf("invalid PDF/A part number %q", part)))
+	} else if gotPart != strconv.Itoa(part) {
+		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAPartNumber, fmt.Sprintf("invalid PDF/A part number %q", gotPart)))
+	}
+
+	if part >= 4 {
+		return append(errs, checkPDFA4Identifier(xmp, level)...)
 	}
 
 	conf, hasConf := pdf.FirstRegexpGroup(pdf.PDFAConfRe, xmp)
 	if !hasConf {
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAIdentifierMissing, "missing PDF/A conformance level"))
-	} else if conf != "A" && conf != "B" {
+	} else if conf != "A" && conf != "B" && (part < 2 || conf != "U") {
+		// Level U was introduced by ISO 19005-2.
 		errs = append(errs, xmpErr(pdf.Checks.Metadata.PDFAConformanceLevel, fmt.Sprintf("invalid PDF/A conformance level %q", conf)))
 	}
 
 	return errs
 }
 
+// pdfaRevRe matches a pdfaid:rev value: the four digit year of the ISO
+// 19005-4 revision.
+var pdfaRevRe = regexp.MustCompile(`^[0-9]{4}$`)
+
+// checkPDFA4Identifier validates the part 4 identification properties (ISO
+// 19005-4 6.7.4): a pdfaid:rev year is required, and the conformance level
+// names level's variant -- E for A_4E, F for A_4F, and none for plain A_4.
+// Its findings report against the part 4 checks directly.
+func checkPDFA4Identifier(xmp string, level pdf.LevelType) []pdf.PDFError {
+	checks := pdf.Checks.PDFA4.Metadata
+	var errs []pdf.PDFError
+	if rev, ok := pdf.FirstRegexpGroup(pdf.PDFARevRe, xmp); !ok {
+		errs = append(errs, xmpErr(checks.PDFAIdentifierMissing, "missing PDF/A revision identifier (pdfaid:rev)"))
+	} else if !pdfaRevRe.MatchString(rev) {
+		errs = append(errs, xmpErr(checks.PDFARevision, fmt.Sprintf("invalid PDF/A revision %q", rev)))
+	}
+	want := level.Conformance()
+	conf, ok := pdf.FirstRegexpGroup(pdf.PDFAConfRe, xmp)
+	switch {
+	case !ok && want != "":
+		errs = append(errs, xmpErr(checks.PDFAIdentifierMissing, fmt.Sprintf("missing PDF/A conformance level, want %q for %s", want, level)))
+	case ok && want == "":
+		errs = append(errs, xmpErr(checks.PDFAConformanceLevel, fmt.Sprintf("PDF/A conformance level %q declared for %s, which has none", conf, level)))
+	case ok && conf != want:
+		errs = append(errs, xmpErr(checks.PDFAConformanceLevel, fmt.Sprintf("invalid PDF/A conformance level %q, want %q for %s", conf, want, level)))
+	}
+	return errs
+}
+
 // xmpWellFormed reports whether the XMP packet is well-formed XML (6.7.9).
 func xmpWellFormed(data []byte) bool {
 	if i := bytes.IndexByte(data, '<'); i > 0 {
@@ -1292,9 +1327,14 @@ func xmpCheckValueRule(rule xmpValueRule, value string) string {
 	return ""
 }
 
+// pdfa4IDProperties are the pdfaid properties ISO 19005-4 6.7.4 adds to the
+// identification schema; checkPDFAIdentifier validates their values.
+var pdfa4IDProperties = map[string]bool{"rev": true, "corr": true}
+
 // checkXMPPropertySchemas validates that XMP properties are used in accordance
-// with their definitions in XMP 2004 (PDF/A-1b clause 6.7.2).
-func checkXMPPropertySchemas(data []byte) []pdf.PDFError {
+// with their definitions in XMP 2004 (PDF/A-1b clause 6.7.2). part is the ISO
+// 19005 part, which decides the pdfaid properties defined.
+func checkXMPPropertySchemas(data []byte, part int) []pdf.PDFError {
 	if i := bytes.IndexByte(data, '<'); i > 0 {
 		data = data[i:]
 	}
@@ -1318,8 +1358,13 @@ func checkXMPPropertySchemas(data []byte) []pdf.PDFError {
 					a.Name.Space == "http://www.w3.org/XML/1998/namespace" {
 					continue
 				}
+				if part >= 4 && a.Name.Space == pdfaIDNamespace && pdfa4IDProperties[a.Name.Local] {
+					continue
+				}
 				errs = append(errs, xmpValidateProp(a.Name.Space, a.Name.Local, xmpKindScalar, a.Value, nil)...)
 			}
+		} else if part >= 4 && se.Name.Space == pdfaIDNamespace && pdfa4IDProperties[se.Name.Local] {
+			continue
 		} else if _, inSchema := xmpNSSchemas[se.Name.Space]; inSchema {
 			// Element-style property from a known schema.
 			container, textVal, items := xmpConsumeProperty(dec, se)
@@ -267,7 +267,7 @@ func TestCheckXMPPropertySchemasStructProperty(t *testing.T) {
 	  </xmpMM:DerivedFrom>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(xmp)); len(errs) != 0 {
+	if errs := checkXMPPropertySchemas([]byte(xmp), 1); len(errs) != 0 {
 		t.Errorf("unexpected violation for a valid struct property: %v", errs)
 	}
 }
@@ -282,7 +282,7 @@ func TestCheckXMPPropertySchemasContainerForms(t *testing.T) {
 	  <xmpMM:DerivedFrom rdf:resource="urn:uuid:abc"/>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(resourceForm)); len(errs) == 0 {
+	if errs := checkXMPPropertySchemas([]byte(resourceForm), 1); len(errs) == 0 {
 		t.Error("expected a container-type-mismatch violation for the rdf:resource form")
 	}
 
@@ -293,7 +293,7 @@ func TestCheckXMPPropertySchemasContainerForms(t *testing.T) {
 	  <xmpMM:DerivedFrom><stRef:instanceID>uuid:xyz</stRef:instanceID></xmpMM:DerivedFrom>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(implicitStruct)); len(errs) != 0 {
+	if errs := checkXMPPropertySchemas([]byte(implicitStruct), 1); len(errs) != 0 {
 		t.Errorf("unexpected violation for an implicit (non-rdf-child) struct: %v", errs)
 	}
 
@@ -304,7 +304,7 @@ func TestCheckXMPPropertySchemasContainerForms(t *testing.T) {
 	  <xmpMM:DerivedFrom><rdf:Description><xmpMM:InstanceID>x</xmpMM:InstanceID></rdf:Description></xmpMM:DerivedFrom>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(explicitDesc)); len(errs) != 0 {
+	if errs := checkXMPPropertySchemas([]byte(explicitDesc), 1); len(errs) != 0 {
 		t.Errorf("unexpected violation for an explicit rdf:Description struct: %v", errs)
 	}
 
@@ -315,7 +315,7 @@ func TestCheckXMPPropertySchemasContainerForms(t *testing.T) {
 	  <dc:subject><rdf:Bag><rdf:li>keyword1</rdf:li><rdf:li>keyword2</rdf:li></rdf:Bag></dc:subject>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(bagForm)); len(errs) != 0 {
+	if errs := checkXMPPropertySchemas([]byte(bagForm), 1); len(errs) != 0 {
 		t.Errorf("unexpected violation for a valid Bag container: %v", errs)
 	}
 
@@ -326,7 +326,7 @@ func TestCheckXMPPropertySchemasContainerForms(t *testing.T) {
 	  <exif:ISOSpeedRatings><rdf:Seq><rdf:li>100</rdf:li><rdf:li>not-a-number</rdf:li></rdf:Seq></exif:ISOSpeedRatings>
 	</rdf:Description>
 	</rdf:RDF>`
-	if errs := checkXMPPropertySchemas([]byte(badSeqItem)); len(errs) == 0 {
+	if errs := checkXMPPropertySchemas([]byte(badSeqItem), 1); len(errs) == 0 {
 		t.Error("expected a violation for a non-integer item in an integer Seq")
 	}
 }
@@ -335,7 +335,7 @@ func TestCheckXMPPropertySchemasCorpus(t *testing.T) {
 	path := firstFileMatching(t, propSchemaDir, "t03-fail")
 	doc, xmp := rawXMPOf(t, path)
 	data, _, _ := doc.RawXMP()
-	errs := checkXMPPropertySchemas(data)
+	errs := checkXMPPropertySchemas(data, 1)
 	if len(errs) == 0 {
 		t.Errorf("checkXMPPropertySchemas found no violation in %s (xmp len %d)", path, len(xmp))
 	}
@@ -350,7 +350,7 @@ func TestVerifyXMPMetadataPass(t *testing.T) {
 		t.Fatalf("pdf.Open: %v", err)
 	}
 	defer doc.Close()
-	errs := verifyXMPMetadata(doc)
+	errs := verifyXMPMetadata(doc, 1, pdf.A_1B)
 	for _, e := range errs {
 		t.Logf("unexpected(?) XMP violation on a pass file: %v", e)
 	}
@@ -366,7 +366,7 @@ func TestVerifyXMPMetadataMissing(t *testing.T) {
 		t.Fatalf("pdf.Open: %v", err)
 	}
 	defer doc.Close()
-	errs := verifyXMPMetadata(doc)
+	errs := verifyXMPMetadata(doc, 1, pdf.A_1B)
 	if len(errs) != 1 || errs[0].Check() != pdf.Checks.Metadata.MetadataMissing {
 		t.Errorf("verifyXMPMetadata(no XMP) = %v, want a single MetadataMissing", errs)
 	}
@@ -539,22 +539,38 @@ func TestCheckXMPHeader(t *testing.T) {
 
 func TestCheckPDFAIdentifier(t *testing.T) {
 	good := `<x xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="1" pdfaid:conformance="B"/>`
-	if errs := checkPDFAIdentifier(good); len(errs) != 0 {
+	if errs := checkPDFAIdentifier(good, 1, pdf.A_1B); len(errs) != 0 {
 		t.Errorf("unexpected violation for a valid PDF/A identifier: %v", errs)
 	}
 
-	if errs := checkPDFAIdentifier("no identifier here"); len(errs) != 1 ||
+	if errs := checkPDFAIdentifier("no identifier here", 1, pdf.A_1B); len(errs) != 1 ||
 		errs[0].Check() != pdf.Checks.Metadata.PDFAIdentifierMissing {
 		t.Errorf("checkPDFAIdentifier(missing) = %v", errs)
 	}
 
 	wrongNS := `<x xmlns:pdfaid="http://example.com/wrong" pdfaid:part="2" pdfaid:conformance="X"/>`
-	errs := checkPDFAIdentifier(wrongNS)
+	errs := checkPDFAIdentifier(wrongNS, 1, pdf.A_1B)
 	if len(errs) != 3 {
 		t.Fatalf("checkPDFAIdentifier(wrong ns/part/conformance) = %d errs, want 3: %v", len(errs), errs)
 	}
 }
 
+func TestCheckPDFAIdentifier_Part2(t *testing.T) {
+	level := func(part, conf string) string {
+		return `<x xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="` + part + `" pdfaid:conformance="` + conf + `"/>`
+	}
+	if errs := checkPDFAIdentifier(level("2", "U"), 2, pdf.A_2U); len(errs) != 0 {
+		t.Errorf("PDF/A-2u identifier rejected: %v", errs)
+	}
+	if errs := checkPDFAIdentifier(level("1", "B"), 2, pdf.A_2B); len(errs) != 1 || errs[0].Check() != pdf.Checks.Metadata.PDFAPartNumber {
+		t.Errorf("part 1 identifier under part 2 = %v, want a single PDFAPartNumber", errs)
+	}
+	// Level U does not exist in ISO 19005-1.
+	if errs := checkPDFAIdentifier(level("1", "U"), 1, pdf.A_1B); len(errs) != 1 || errs[0].Check() != pdf.Checks.Metadata.PDFAConformanceLevel {
+		t.Errorf("level U under part 1 = %v, want a single PDFAConformanceLevel", errs)
+	}
+}
+
 func TestXmpWellFormed(t *testing.T) {
 	if !xmpWellFormed([]byte(`<a><b>text</b></a>`)) {
 		t.Error("well-formed XML reported as malformed")
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 
+	"github.com/voidrab/gopdfrab/internal/arlington"
 	"github.com/voidrab/gopdfrab/internal/pdf"
 )
 
@@ -65,11 +66,36 @@ type ValidationContext struct {
 	// fall back to checking every W entry.
 	UsedCIDs map[uintptr]map[int]bool
 
+	// usedCodePages maps a UsedCharCodes or UsedCIDs key to the pages the
+	// font shows codes on, each with the codes shown there, so the Unicode
+	// mapping check (6.2.11.7) can attribute an unmappable code to its page.
+	// A Form XObject is scanned once, so codes it shows on several pages are
+	// attributed to the first. ComputeContentUsage collects it only for
+	// levels requiring Unicode.
+	usedCodePages map[uintptr]map[int]map[int]bool
+
 	// schemaOnly restricts verifyDocument's walk to the generic object-model
 	// schema checks, skipping every PDF/A-specific family whose findings the
 	// profile would filter out anyway (see Profile.OnlyObjectModelChecks).
 	schemaOnly bool
 
+	// part is the ISO 19005 part being verified (1 to 4). Shared validators
+	// consult it where part 2 relaxes or tightens a part 1 rule; their
+	// findings are always reported against the part 1 check and renumbered
+	// afterwards (see translateIssues).
+	part int
+
+	// level is the conformance level being verified, for the few rules that
+	// differ between the variants of one part (PDF/A-4e and 4f).
+	level pdf.LevelType
+
+	// associatedFiles and embeddedFileSpecs collect, during a part 3 walk,
+	// the file specifications listed in any AF array (by Entries-map
+	// pointer) and those carrying embedded files, so the 6.8 association
+	// rule can be checked once the whole graph has been seen.
+	associatedFiles   map[uintptr]bool
+	embeddedFileSpecs []embeddedFileSpec
+
 	// pageResources is the Resources dict of the current page. Default* colour
 	// spaces defined at page level are inherited by patterns and Form XObjects
 	// that do not define their own Default*.
@@ -81,6 +107,44 @@ type ValidationContext struct {
 	// throwaway contexts built outside a real verify pass, which then decode
 	// uncached.
 	reader *pdf.Reader
+
+	// lazy is set while verifying a page at a time (see lazyState).
+	// The pointer-keyed state above then holds nodeKey keys instead.
+	lazy *lazyState
+}
+
+// archLimits are the ISO 19005 implementation limits that differ between
+// parts (6.1.12 in part 1, 6.1.13 in part 2). A zero bound means unlimited.
+type archLimits struct {
+	maxReal    float64
+	maxString  int
+	maxArray   int
+	maxDict    int
+	maxDeviceN int
+}
+
+var (
+	part1Limits = archLimits{maxReal: 32767, maxString: 65535, maxArray: 8191, maxDict: 4095, maxDeviceN: 8}
+	part2Limits = archLimits{maxReal: 3.403e38, maxString: 32767, maxDeviceN: 32}
+)
+
+// limits returns the implementation limits of the part being verified.
+// Contexts built outside a verify pass (part 0) get the part 1 limits.
+func (ctx *ValidationContext) limits() archLimits {
+	if ctx != nil && ctx.part >= 2 {
+		return part2Limits
+	}
+	return part1Limits
+}
+
+// objectModel returns the Arlington object model of the part being verified:
+// PDF 2.0 for part 4, else PDF 1.4, whose later-key lists cover parts 2 and
+// 3. Contexts built outside a verify pass get the PDF 1.4 model.
+func (ctx *ValidationContext) objectModel() *arlington.Model {
+	if ctx != nil && ctx.part >= 4 {
+		return arlington.PDF20
+	}
+	return arlington.PDF14
 }
 
 // NewContext returns a ValidationContext whose stream decodes and scans go
@@ -89,6 +153,12 @@ func NewContext(d *pdf.Reader) *ValidationContext {
 	return &ValidationContext{reader: d}
 }
 
+// stopped reports whether the reader's context is done or it exceeded one
+// of its limits (see pdf.Reader.Err), for the long walks to stop early.
+func (ctx *ValidationContext) stopped() bool {
+	return ctx != nil && ctx.reader != nil && ctx.reader.Err() != nil
+}
+
 // decodeStreamCached decodes dict's stream, caching the result via ctx.reader
 // (see pdf.Reader.DecodeStreamCached) when available.
 func (ctx *ValidationContext) decodeStreamCached(dict pdf.PDFDict) ([]byte, error) {
@@ -119,7 +189,7 @@ func (ctx *ValidationContext) isReachableXObject(v pdf.PDFDict) bool {
 	if ctx.ReachableXObjectPtrs == nil {
 		return true
 	}
-	return ctx.ReachableXObjectPtrs[pdf.ValuePointer(v.Entries)]
+	return ctx.ReachableXObjectPtrs[ctx.nodeKey(pdf.ValuePointer(v.Entries))]
 }
 
 // isInvisibleOnlyFont reports whether font v is shown only under invisible
@@ -128,13 +198,13 @@ func (ctx *ValidationContext) isInvisibleOnlyFont(v pdf.PDFDict) bool {
 	if ctx.InvisibleOnlyFontPtrs == nil {
 		return false
 	}
-	return ctx.InvisibleOnlyFontPtrs[pdf.ValuePointer(v.Entries)]
+	return ctx.InvisibleOnlyFontPtrs[ctx.nodeKey(pdf.ValuePointer(v.Entries))]
 }
 
 // simpleFontShown reports whether v was used to show text (its pointer appears
 // in UsedCharCodes). Callers should check UsedCharCodes != nil first.
 func (ctx *ValidationContext) simpleFontShown(v pdf.PDFDict) bool {
-	_, known := ctx.UsedCharCodes[pdf.ValuePointer(v.Entries)]
+	_, known := ctx.UsedCharCodes[ctx.nodeKey(pdf.ValuePointer(v.Entries))]
 	return known
 }
 
@@ -144,7 +214,7 @@ func (ctx *ValidationContext) usedCodesFor(v pdf.PDFDict) (codes map[int]bool, k
 	if ctx.UsedCharCodes == nil {
 		return nil, false
 	}
-	codes, known = ctx.UsedCharCodes[pdf.ValuePointer(v.Entries)]
+	codes, known = ctx.UsedCharCodes[ctx.nodeKey(pdf.ValuePointer(v.Entries))]
 	return codes, known
 }
 
@@ -154,7 +224,7 @@ func (ctx *ValidationContext) usedCIDsFor(v pdf.PDFDict) (cids map[int]bool, kno
 	if ctx.UsedCIDs == nil {
 		return nil, false
 	}
-	cids, known = ctx.UsedCIDs[pdf.ValuePointer(v.Entries)]
+	cids, known = ctx.UsedCIDs[ctx.nodeKey(pdf.ValuePointer(v.Entries))]
 	return cids, known
 }
 
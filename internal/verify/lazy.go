@@ -0,0 +1,257 @@
+package verify
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// lazyState is what a page-at-a-time verification (see
+// pdf.Profile.ObjectCacheLimit) carries across its passes: one over the
+// skeleton, the graph minus its pages, then one per page. Each pass works on
+// a fresh detached copy of its part of the graph, so pointer identity does
+// not carry over between passes; state that must is keyed by nodeKey.
+type lazyState struct {
+	d *pdf.Reader
+
+	// origin is the current pass's pdf.Detached.Origin. ids numbers the
+	// nodes inside an object (see nodeKey) that the checks have recorded
+	// state against, such as a font dictionary written inline in a page's
+	// resources.
+	origin map[uintptr]pdf.ObjectPath
+	ids    map[pdf.ObjectPath]uintptr
+
+	// walked and walkedTyped are the part of verifyGraph's visited sets
+	// shared by all passes, and xmpWalked that of
+	// scanNonCatalogXMPStreams's: the indirect objects walked so far, by
+	// nodeKey. Nodes inside an object are only tracked for the current
+	// pass; see visitSet.
+	walked      map[uintptr]bool
+	walkedTyped map[typedVisit]bool
+	xmpWalked   map[uintptr]bool
+	catalogMeta uintptr
+
+	// pages lists the pages to verify, in page tree order followed by any
+	// page objects found outside the page tree; queued dedupes it.
+	pages  []pdf.PDFRef
+	queued map[int]bool
+
+	skeleton pdf.Detached
+}
+
+// nodeKey returns the key the pointer-keyed context state uses for the node
+// whose ValuePointer is ptr: ptr itself, or when verifying a page at a time,
+// a number identifying the node's ObjectPath, which is the same in every
+// pass: odd, from its number, for an indirect object, and even, from ids,
+// for a node inside one. Such numbers are small, so they cannot collide
+// with a pointer.
+func (ctx *ValidationContext) nodeKey(ptr uintptr) uintptr {
+	if ctx == nil || ctx.lazy == nil {
+		return ptr
+	}
+	at, ok := ctx.lazy.origin[ptr]
+	if !ok {
+		return ptr
+	}
+	if at.Path == "" {
+		return objectKey(at.ObjNum)
+	}
+	id, ok := ctx.lazy.ids[at]
+	if !ok {
+		id = uintptr(len(ctx.lazy.ids)+1) << 1
+		ctx.lazy.ids[at] = id
+	}
+	return id
+}
+
+// objectKey is nodeKey's key for indirect object num.
+func objectKey(num int) uintptr {
+	return uintptr(num)<<1 | 1
+}
+
+// newLazyState resolves d's skeleton: the graph from the trailer, with page
+// objects left as references and queued after the page tree's own pages.
+func newLazyState(d *pdf.Reader) (*lazyState, error) {
+	lz := &lazyState{
+		d:           d,
+		ids:         map[pdf.ObjectPath]uintptr{},
+		walked:      map[uintptr]bool{},
+		walkedTyped: map[typedVisit]bool{},
+		xmpWalked:   map[uintptr]bool{},
+		queued:      map[int]bool{},
+	}
+	skeleton, err := d.ResolveDetached(d.EffectiveTrailer(), lz.stop(false))
+	if err != nil {
+		return nil, err
+	}
+	lz.skeleton = skeleton
+	return lz, nil
+}
+
+// queuePageTree puts the page tree's pages ahead of those found so far
+// outside it.
+func (lz *lazyState) queuePageTree(pages []pdf.PDFRef) {
+	outside := lz.pages
+	sortRefs(outside)
+	lz.pages, lz.queued = nil, map[int]bool{}
+	for _, ref := range append(pages, outside...) {
+		lz.queue(ref)
+	}
+}
+
+// sortRefs orders refs by object number, making the order of pages queued
+// during a copy, which follows map iteration, deterministic.
+func sortRefs(refs []pdf.PDFRef) {
+	slices.SortFunc(refs, func(a, b pdf.PDFRef) int { return cmp.Compare(a.ObjNum, b.ObjNum) })
+}
+
+func (lz *lazyState) queue(ref pdf.PDFRef) {
+	if !lz.queued[ref.ObjNum] {
+		lz.queued[ref.ObjNum] = true
+		lz.pages = append(lz.pages, ref)
+	}
+}
+
+// stop is the detached-resolution stop for a pass: page objects stay
+// references (and are queued), and within a page pass so do the page tree
+// nodes and catalog reachable from the page, which the skeleton covers.
+func (lz *lazyState) stop(page bool) func(pdf.PDFRef, pdf.PDFDict) bool {
+	return func(ref pdf.PDFRef, dict pdf.PDFDict) bool {
+		switch dict.Entries["Type"] {
+		case pdf.PDFName{Value: "Page"}:
+			lz.queue(ref)
+			return true
+		case pdf.PDFName{Value: "Pages"}, pdf.PDFName{Value: "Catalog"}:
+			return page
+		}
+		return false
+	}
+}
+
+// enter starts a pass over res: the usage maps keep nodeKey keys, but the
+// stream and font program caches are dropped, so they only ever hold one
+// pass's streams.
+func (lz *lazyState) enter(ctx *ValidationContext, res pdf.Detached) {
+	lz.origin = res.Origin
+	ctx.type1Cache = nil
+	lz.d.ReleaseStreamCaches()
+}
+
+// eachPage resolves each queued page in turn, including pages queued while
+// resolving earlier ones, and calls fn on it.
+func (lz *lazyState) eachPage(ctx *ValidationContext, fn func(pdf.Detached)) error {
+	for i := 0; i < len(lz.pages); i++ {
+		if err := lz.d.Err(); err != nil {
+			return err
+		}
+		n := len(lz.pages)
+		res, err := lz.d.ResolveDetached(lz.pages[i], lz.stop(true))
+		if err != nil {
+			return err
+		}
+		sortRefs(lz.pages[n:])
+		lz.enter(ctx, res)
+		fn(res)
+	}
+	return nil
+}
+
+// computeContentUsage is ComputeContentUsage a page at a time: each page's
+// usage is merged into maps keyed by nodeKey, which it sets on ctx.
+func (lz *lazyState) computeContentUsage(ctx *ValidationContext, skipUnreachable bool) error {
+	reachable := map[uintptr]bool{}
+	total := &fontUsage{
+		visible:   map[uintptr]bool{},
+		invisible: map[uintptr]bool{},
+		usedCodes: map[uintptr]map[int]bool{},
+		usedCIDs:  map[uintptr]map[int]bool{},
+	}
+	if ctx.level.RequiresUnicode() {
+		total.pages = map[uintptr]map[int]map[int]bool{}
+	}
+	merge := func(res pdf.Detached) {
+		r, fu := contentUsage(res.Root, ctx)
+		for ptr := range r {
+			reachable[ctx.nodeKey(ptr)] = true
+		}
+		for ptr := range fu.visible {
+			total.visible[ctx.nodeKey(ptr)] = true
+		}
+		for ptr := range fu.invisible {
+			total.invisible[ctx.nodeKey(ptr)] = true
+		}
+		mergeCodes(total.usedCodes, fu.usedCodes, ctx)
+		mergeCodes(total.usedCIDs, fu.usedCIDs, ctx)
+		for ptr, byPage := range fu.pages {
+			key := ctx.nodeKey(ptr)
+			if total.pages[key] == nil {
+				total.pages[key] = map[int]map[int]bool{}
+			}
+			for page, codes := range byPage {
+				if total.pages[key][page] == nil {
+					total.pages[key][page] = map[int]bool{}
+				}
+				maps.Copy(total.pages[key][page], codes)
+			}
+		}
+	}
+
+	lz.enter(ctx, lz.skeleton)
+	merge(lz.skeleton)
+	if err := lz.eachPage(ctx, merge); err != nil {
+		return err
+	}
+
+	if skipUnreachable {
+		ctx.ReachableXObjectPtrs = reachable
+	}
+	ctx.InvisibleOnlyFontPtrs = map[uintptr]bool{}
+	for key := range total.invisible {
+		if !total.visible[key] {
+			ctx.InvisibleOnlyFontPtrs[key] = true
+		}
+	}
+	ctx.UsedCharCodes, ctx.UsedCIDs = total.usedCodes, total.usedCIDs
+	ctx.usedCodePages = total.pages
+	return nil
+}
+
+// mergeCodes adds the codes in from, keyed by pointer, to into, keyed by
+// nodeKey.
+func mergeCodes(into, from map[uintptr]map[int]bool, ctx *ValidationContext) {
+	for ptr, codes := range from {
+		key := ctx.nodeKey(ptr)
+		if into[key] == nil {
+			into[key] = map[int]bool{}
+		}
+		maps.Copy(into[key], codes)
+	}
+}
+
+// verify runs verifyGraph and the non-catalog XMP scan over the skeleton,
+// then over each page, returning the XMP scan's findings.
+func (lz *lazyState) verify(ctx *ValidationContext) ([]pdf.PDFError, error) {
+	var xmpErrs []pdf.PDFError
+	scanXMP := func(res pdf.Detached) {
+		if !ctx.schemaOnly {
+			xmpErrs = append(xmpErrs, scanNonCatalogXMPStreams(res.Root, lz.catalogMeta, newVisitSet(lz, lz.xmpWalked, nil), ctx)...)
+		}
+	}
+
+	lz.enter(ctx, lz.skeleton)
+	trailer, _ := lz.skeleton.Root.(pdf.PDFDict)
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	if meta, ok := root.Entries["Metadata"].(pdf.PDFDict); ok && meta.HasStream {
+		lz.catalogMeta = ctx.nodeKey(pdf.ValuePointer(meta.Entries))
+	}
+	verifyGraph(lz.skeleton.Root, "FileTrailer", ctx)
+	scanXMP(lz.skeleton)
+
+	err := lz.eachPage(ctx, func(res pdf.Detached) {
+		verifyGraph(res.Root, "", ctx)
+		scanXMP(res)
+	})
+	return xmpErrs, err
+}
@@ -0,0 +1,177 @@
+package verify
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// findingSet reduces a result to its sorted, deduplicated (check, object)
+// pairs. Messages and pages are left out: a few messages name one of
+// several offending glyphs by map order, and an object shared between pages
+// may be attributed to another page when verifying a page at a time.
+func findingSet(r pdf.Result) []string {
+	var out []string
+	for _, e := range r.Issues {
+		ref, _ := e.ObjectRef()
+		out = append(out, fmt.Sprintf("%s %d", e.Check().Name(), ref.ObjNum))
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// TestVerifyPagewiseMatchesFull confirms verifying a page at a time through
+// a small object cache finds what verifying the fully resolved graph does,
+// across both reference corpora.
+func TestVerifyPagewiseMatchesFull(t *testing.T) {
+	var files []string
+	for _, dir := range []string{isartorDir, veraPDFDir} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			t.Skip("reference test suites not present")
+		}
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && filepath.Ext(path) == ".pdf" {
+				files = append(files, path)
+			}
+			return err
+		})
+	}
+	if testing.Short() {
+		files = files[:min(len(files), 40)]
+	}
+
+	verifyFile := func(path string, p *pdf.Profile) []string {
+		doc, err := pdf.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer doc.Close()
+		res, err := Verify(doc, p)
+		if err != nil {
+			t.Fatalf("Verify(%s): %v", path, err)
+		}
+		return findingSet(res)
+	}
+	for _, p := range []*pdf.Profile{pdf.PDFA_1A, pdf.PDFA_2U, pdf.PDFA_3B, pdf.PDFA_4F, pdf.PDF} {
+		lazy := p.Clone()
+		lazy.ObjectCacheLimit = 16
+		for _, path := range files {
+			full, paged := verifyFile(path, p), verifyFile(path, lazy)
+			if !slices.Equal(full, paged) {
+				t.Errorf("%s %s:\nfull  %v\npaged %v", p.Level, path, full, paged)
+			}
+		}
+	}
+}
+
+// TestVerifyPagewiseSharedFont confirms a font shown on the second page
+// only is still known as used when it was first reached from the first
+// page, so page-at-a-time font usage is document-wide.
+func TestVerifyPagewiseSharedFont(t *testing.T) {
+	data := buildTestPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> /Contents 6 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Length 24 >>\nstream\nBT /F1 12 Tf (Hi) Tj ET\nendstream",
+	}, "<< /Size 7 /Root 1 0 R >>")
+	p := pdf.NewProfile(pdf.A_1B).AddCheck(pdf.Checks.Font.SimpleNotEmbedded)
+	p.SkipUnusedSimpleFonts = true
+	lazy := p.Clone()
+	lazy.ObjectCacheLimit = 2
+	for _, prof := range []*pdf.Profile{p, lazy} {
+		doc, err := pdf.OpenBytes(data)
+		if err != nil {
+			t.Fatalf("OpenBytes: %v", err)
+		}
+		res, err := Verify(doc, prof)
+		doc.Close()
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if got := findingSet(res); !slices.Equal(got, []string{"SimpleNotEmbedded 5"}) {
+			t.Errorf("ObjectCacheLimit %d: findings %v, want the shown font flagged once", prof.ObjectCacheLimit, got)
+		}
+	}
+}
+
+// TestVerifyPagewiseStateBounded confirms that what verifying a page at a
+// time carries between passes is one entry per indirect object, not one per
+// dictionary and array: here the many direct nodes of each page's
+// resources, media box and annotation are dropped with the page.
+func TestVerifyPagewiseStateBounded(t *testing.T) {
+	const pages = 300
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Length 24 >>\nstream\nBT /F1 12 Tf (Hi) Tj ET\nendstream",
+	}
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objs)+1))
+		objs = append(objs, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R"+
+			" /Resources << /Font << /F1 3 0 R >> /ProcSet [/PDF /Text] >>"+
+			fmt.Sprintf(" /Annots [<< /Type /Annot /Subtype /Square /Rect [0 0 10 %d] /Border [0 0 1] /F 4 >>] >>", i+10))
+	}
+	objs[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages)
+	doc, err := pdf.OpenBytes(buildTestPDF(objs, fmt.Sprintf("<< /Size %d /Root 1 0 R >>", len(objs)+1)))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	defer doc.SetObjectCacheLimit(doc.SetObjectCacheLimit(4))
+
+	lz, err := newLazyState(doc)
+	if err != nil {
+		t.Fatalf("newLazyState: %v", err)
+	}
+	refs, pageIndex, err := doc.PageRefs()
+	if err != nil {
+		t.Fatalf("PageRefs: %v", err)
+	}
+	lz.queuePageTree(refs)
+	ctx := &ValidationContext{PageIndex: pageIndex, reader: doc, part: 2, level: pdf.A_2U, lazy: lz}
+	if err := lz.computeContentUsage(ctx, true); err != nil {
+		t.Fatalf("computeContentUsage: %v", err)
+	}
+	if _, err := lz.verify(ctx); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// The trailer counts as object 0.
+	limit := len(objs) + 1
+	if len(lz.ids) != 0 {
+		t.Errorf("ids holds %d direct nodes, want none: no check records state against one", len(lz.ids))
+	}
+	for name, n := range map[string]int{"walked": len(lz.walked), "walkedTyped": len(lz.walkedTyped), "xmpWalked": len(lz.xmpWalked)} {
+		if n > limit {
+			t.Errorf("%s holds %d entries, want at most one per object (%d)", name, n, limit)
+		}
+	}
+}
+
+// buildTestPDF returns a classic-xref PDF with the given object bodies
+// (numbered from 1) and trailer dictionary.
+func buildTestPDF(objs []string, trailer string) []byte {
+	body := "%PDF-1.4\n"
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = len(body)
+		body += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := len(body)
+	body += fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		body += fmt.Sprintf("%010d 00000 n \n", off)
+	}
+	body += fmt.Sprintf("trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer, xref)
+	return []byte(body)
+}
@@ -9,8 +9,20 @@ import (
 	"github.com/voidrab/gopdfrab/internal/pdf"
 )
 
+// a1Checks returns the catalog checks that apply at A-1b: the ISO 19005-1
+// checks plus the objmodel checks, in catalog order.
+func a1Checks() []pdf.Check {
+	var out []pdf.Check
+	for _, c := range pdf.AllChecks() {
+		if c.Spec() == pdf.SpecPDFA1 || c.Spec() == pdf.SpecPDF {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 func TestProfile_Legacy1BIsFullProfile(t *testing.T) {
-	all := pdf.AllChecks()
+	all := a1Checks()
 	if len(pdf.Legacy_1B.Checks()) != len(all) {
 		t.Errorf("Legacy_1B has %d checks, catalog has %d", len(pdf.Legacy_1B.Checks()), len(all))
 	}
@@ -91,7 +103,7 @@ func TestProfile_RemoveCheck(t *testing.T) {
 }
 
 func TestProfile_ChecksOrder(t *testing.T) {
-	all := pdf.AllChecks()
+	all := a1Checks()
 	got := pdf.Legacy_1B.Checks()
 	if len(got) != len(all) {
 		t.Fatalf("Checks() length mismatch: %d vs %d", len(got), len(all))
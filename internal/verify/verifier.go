@@ -2,6 +2,7 @@ package verify
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"regexp"
 	"runtime"
@@ -21,7 +22,10 @@ var xrefHeaderRe = regexp.MustCompile(`^[0-9]+ [0-9]+$`)
 // can lower it.
 var maxWalkDepth = 1 << 17
 
-// Verify verifies d against the checks enabled in profile p.
+// Verify verifies d against the checks enabled in profile p, under p's
+// Limits. If d's context (see pdf.Reader.SetContext) is done or d exceeds
+// one of the limits before verification finishes, it returns the issues
+// found so far, with the context's error or the *pdf.LimitError.
 func Verify(d *pdf.Reader, p *pdf.Profile) (pdf.Result, error) {
 	if p == nil {
 		return pdf.Result{}, fmt.Errorf("nil profile")
@@ -29,19 +33,29 @@ func Verify(d *pdf.Reader, p *pdf.Profile) (pdf.Result, error) {
 	if p.Level == pdf.Undefined {
 		return pdf.Result{Type: p.Level, Valid: false}, fmt.Errorf("cannot verify PDF to undefined conformance level")
 	}
-
-	var issues []pdf.PDFError
-	if p.Level == pdf.A_1B || p.Level == pdf.ObjectModel {
-		issues = verifyPdfA1b(d, p)
+	defer d.ApplyLimits(p.Limits)()
+	if err := d.Err(); err != nil {
+		return pdf.Result{Type: p.Level, Valid: false}, fmt.Errorf("verify: %w", err)
 	}
-	issues = filterByProfile(issues, p)
 
+	issues := filterByProfile(verifyLevelParts(d, p).Issues(), p)
+
+	if err := d.Err(); err != nil {
+		return pdf.Result{Type: p.Level, Valid: false, Issues: issues}, fmt.Errorf("verify: %w", err)
+	}
 	if len(issues) > 0 {
 		return pdf.Result{Type: p.Level, Valid: false, Issues: issues}, nil
 	}
 	return pdf.Result{Type: p.Level, Valid: true}, nil
 }
 
+// VerifyContext is Verify governed by ctx: once ctx is done, verification
+// stops and the issues found so far are returned with ctx's error.
+func VerifyContext(ctx context.Context, d *pdf.Reader, p *pdf.Profile) (pdf.Result, error) {
+	defer d.SetContext(d.SetContext(ctx))
+	return Verify(d, p)
+}
+
 // Parts splits the A-1b issue list by what the checks read. PreStructural
 // and PostStructural are byte-level checks against the reader's file and
 // xref bytes, in exactly the positions verifyPdfA1b emits them (the header/
@@ -84,11 +98,15 @@ func VerifyParts(d *pdf.Reader, p *pdf.Profile) (Parts, error) {
 	if p.Level == pdf.Undefined {
 		return Parts{}, fmt.Errorf("cannot verify PDF to undefined conformance level")
 	}
-	var pt Parts
-	if p.Level == pdf.A_1B || p.Level == pdf.ObjectModel {
-		pt = verifyPdfA1bParts(d, p)
+	defer d.ApplyLimits(p.Limits)()
+	if err := d.Err(); err != nil {
+		return Parts{}, fmt.Errorf("verify: %w", err)
 	}
-	return pt.filter(p), nil
+	pt := verifyLevelParts(d, p).filter(p)
+	if err := d.Err(); err != nil {
+		return pt, fmt.Errorf("verify: %w", err)
+	}
+	return pt, nil
 }
 
 // VerifyStructural runs only the byte-level structural checks against d --
@@ -104,9 +122,30 @@ func VerifyStructural(d *pdf.Reader, p *pdf.Profile) (Parts, error) {
 		return Parts{}, fmt.Errorf("cannot verify PDF to undefined conformance level")
 	}
 	var pt Parts
-	if (p.Level == pdf.A_1B || p.Level == pdf.ObjectModel) && !p.OnlyObjectModelChecks() {
-		pt.PreStructural = structuralPreIssues(d)
-		pt.PostStructural = structuralPostIssues(d)
+	switch p.Level {
+	case pdf.A_1A, pdf.A_1B, pdf.ObjectModel:
+		if !p.OnlyObjectModelChecks() {
+			pt.PreStructural = structuralPreIssues(d, 1)
+			pt.PostStructural = structuralPostIssues(d)
+		}
+	case pdf.A_2B, pdf.A_2U:
+		if !p.OnlyObjectModelChecks() {
+			pt.PreStructural = structuralPreIssues(d, 2)
+			pt.PostStructural = structuralPostIssues(d)
+		}
+		pt = pt.translate(pdf.SpecPDFA2)
+	case pdf.A_3B, pdf.A_3U:
+		if !p.OnlyObjectModelChecks() {
+			pt.PreStructural = structuralPreIssues(d, 3)
+			pt.PostStructural = structuralPostIssues(d)
+		}
+		pt = pt.translate(pdf.SpecPDFA3)
+	case pdf.A_4, pdf.A_4E, pdf.A_4F:
+		if !p.OnlyObjectModelChecks() {
+			pt.PreStructural = structuralPreIssues(d, 4)
+			pt.PostStructural = structuralPostIssues(d)
+		}
+		pt = pt.translate(pdf.SpecPDFA4)
 	}
 	return pt.filter(p), nil
 }
@@ -122,6 +161,13 @@ func ResultFromIssues(p *pdf.Profile, issues []pdf.PDFError) pdf.Result {
 
 // VerifyAll opens and verifies multiple PDF files concurrently.
 func VerifyAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[pdf.Result], error) {
+	return VerifyAllContext(context.Background(), paths, p)
+}
+
+// VerifyAllContext is VerifyAll governed by ctx: once ctx is done, the
+// files being verified return partial results and the rest are not opened,
+// each with ctx's error.
+func VerifyAllContext(ctx context.Context, paths []string, p *pdf.Profile) ([]pdf.FileResult[pdf.Result], error) {
 	results := make([]pdf.FileResult[pdf.Result], len(paths))
 
 	workers := min(runtime.NumCPU(), len(paths))
@@ -136,7 +182,7 @@ func VerifyAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[pdf.Result], er
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
-				results[i] = verifyFile(paths[i], p)
+				results[i] = verifyFile(ctx, paths[i], p)
 			}
 		}()
 	}
@@ -151,22 +197,32 @@ func VerifyAll(paths []string, p *pdf.Profile) ([]pdf.FileResult[pdf.Result], er
 
 // VerifyFile opens, verifies, and closes a single file.
 func VerifyFile(path string, p *pdf.Profile) (pdf.Result, error) {
+	return VerifyFileContext(context.Background(), path, p)
+}
+
+// VerifyFileContext is VerifyFile governed by ctx; see VerifyContext.
+func VerifyFileContext(ctx context.Context, path string, p *pdf.Profile) (pdf.Result, error) {
 	doc, err := pdf.Open(path)
 	if err != nil {
 		return pdf.Result{}, err
 	}
 	defer doc.Close()
-	return Verify(doc, p)
+	return VerifyContext(ctx, doc, p)
 }
 
 // VerifyBytes verifies an in-memory PDF.
 func VerifyBytes(data []byte, p *pdf.Profile) (pdf.Result, error) {
+	return VerifyBytesContext(context.Background(), data, p)
+}
+
+// VerifyBytesContext is VerifyBytes governed by ctx; see VerifyContext.
+func VerifyBytesContext(ctx context.Context, data []byte, p *pdf.Profile) (pdf.Result, error) {
 	doc, err := pdf.OpenBytes(data)
 	if err != nil {
 		return pdf.Result{}, fmt.Errorf("verify: %w", err)
 	}
 	defer doc.Close()
-	return Verify(doc, p)
+	return VerifyContext(ctx, doc, p)
 }
 
 // VerifyObjectModel checks d against the generic ISO 32000 object-model
@@ -196,37 +252,65 @@ func VerifyObjectModelBytes(data []byte) (pdf.Result, error) {
 	return VerifyObjectModel(doc)
 }
 
-func verifyFile(path string, p *pdf.Profile) pdf.FileResult[pdf.Result] {
-	res, err := VerifyFile(path, p)
+func verifyFile(ctx context.Context, path string, p *pdf.Profile) pdf.FileResult[pdf.Result] {
+	if err := ctx.Err(); err != nil {
+		return pdf.FileResult[pdf.Result]{Path: path, Err: fmt.Errorf("verify: %w", err)}
+	}
+	res, err := VerifyFileContext(ctx, path, p)
 	return pdf.FileResult[pdf.Result]{Path: path, Result: res, Err: err}
 }
 
-// filterByProfile removes from issues any PDFError whose (clause, subclause)
-// pair is registered in the catalog but disabled in p.
+// filterByProfile removes from issues any PDFError whose check is registered
+// in the catalog but disabled in p.
 func filterByProfile(issues []pdf.PDFError, p *pdf.Profile) []pdf.PDFError {
 	out := make([]pdf.PDFError, 0, len(issues))
 	for _, e := range issues {
-		if p.Allows(e.Check().Clause(), e.Check().Subclause()) {
+		if p.AllowsCheck(e.Check()) {
 			out = append(out, e)
 		}
 	}
 	return out
 }
 
+// verifyLevelParts runs the unfiltered verification pipeline for p's level.
+// A level with no pipeline yields no issues.
+func verifyLevelParts(d *pdf.Reader, p *pdf.Profile) Parts {
+	switch p.Level {
+	case pdf.A_1A, pdf.A_1B, pdf.ObjectModel:
+		return verifyPdfA1bParts(d, p)
+	case pdf.A_2B, pdf.A_2U:
+		return verifyPdfA2bParts(d, p)
+	case pdf.A_3B, pdf.A_3U:
+		return verifyPdfA3bParts(d, p)
+	case pdf.A_4, pdf.A_4E, pdf.A_4F:
+		return verifyPdfA4Parts(d, p)
+	case pdf.UA_1:
+		return verifyPdfUA1Parts(d, p)
+	case pdf.X_1A, pdf.X_4:
+		return verifyPdfXParts(d, p)
+	}
+	return Parts{}
+}
+
 // PDF/A-1b (ISO 19005-1:2005)
 
 func verifyPdfA1b(d *pdf.Reader, p *pdf.Profile) []pdf.PDFError {
 	return verifyPdfA1bParts(d, p).Issues()
 }
 
-// structuralPreIssues runs the byte-level checks that lead the A-1b issue
-// list: file header, linearized ID consistency, trailer, and xref format.
-func structuralPreIssues(d *pdf.Reader) []pdf.PDFError {
+// structuralPreIssues runs the byte-level checks that lead the issue list:
+// file header, linearized ID consistency, trailer, and xref format. part is
+// the ISO 19005 part; from part 2 on, xref streams are permitted, and part 4
+// requires a PDF 2.n header.
+func structuralPreIssues(d *pdf.Reader, part int) []pdf.PDFError {
 	issues := []pdf.PDFError{}
 	issues = append(issues, verifyFileHeader(d)...)
+	if part >= 4 {
+		issues = append(issues, verifyPdfA4FileHeader(d)...)
+	}
 	issues = append(issues, checkLinearizedFileID(d)...)
 	issues = append(issues, verifyFileTrailer(d)...)
-	issues = append(issues, verifyCrossReferenceTable(d)...)
+	issues = append(issues, verifyCrossReferenceTable(d, part)...)
 	return issues
 }
 
@@ -238,6 +322,14 @@ func structuralPostIssues(d *pdf.Reader) []pdf.PDFError {
 }
 
 func verifyPdfA1bParts(d *pdf.Reader, p *pdf.Profile) Parts {
+	return verifyPdfAParts(d, p, 1)
+}
+
+// verifyPdfAParts runs the PDF/A pipeline shared by every part, with part
+// selecting the ISO 19005 part's deviations. Findings are reported against
+// the part 1 checks (plus the part-specific checks it alone defines); the
+// part's own entry point renumbers them.
+func verifyPdfAParts(d *pdf.Reader, p *pdf.Profile, part int) Parts {
 	// A profile enabling nothing but the object-model checks skips every
 	// PDF/A-specific family up front instead of filtering its findings away
 	// at the end, so VerifyObjectModel never decodes content streams, parses
@@ -246,19 +338,38 @@ func verifyPdfA1bParts(d *pdf.Reader, p *pdf.Profile) Parts {
 	var pt Parts
 
 	if !schemaOnly {
-		pt.PreStructural = structuralPreIssues(d)
+		pt.PreStructural = structuralPreIssues(d, part)
 	}
 
 	issues := []pdf.PDFError{}
-
-	// Resolve the graph once up front; all subsequent checks work on the
-	// resolved graph so no per-check lazy resolve occurs.
-	graph, err := d.ResolveGraph()
-	if err != nil {
+	graphFailure := func(err error) Parts {
+		if d.Err() != nil {
+			// Stopped (cancelled or over a limit), not unresolvable:
+			// nothing to report.
+			pt.Graph = issues
+			return pt
+		}
 		pt.Graph = append(issues, pdf.NewError(pdf.Checks.Structure.GraphResolutionFailure, []error{err}, 0, nil))
 		return pt
 	}
 
+	// Resolve the graph once up front; all subsequent checks work on the
+	// resolved graph so no per-check lazy resolve occurs. With an object
+	// cache limit, only the skeleton (the graph minus its pages) is resolved
+	// here, and the pages one at a time as they are verified.
+	var graph pdf.PDFValue
+	var lz *lazyState
+	var err error
+	if p.ObjectCacheLimit > 0 {
+		defer d.SetObjectCacheLimit(d.SetObjectCacheLimit(p.ObjectCacheLimit))
+		if lz, err = newLazyState(d); err != nil {
+			return graphFailure(err)
+		}
+		graph = lz.skeleton.Root
+	} else if graph, err = d.ResolveGraph(); err != nil {
+		return graphFailure(err)
+	}
+
 	if !schemaOnly {
 		errs := verifyDocumentInformationDictionary(graph)
 		if errs != nil {
@@ -266,38 +377,82 @@ func verifyPdfA1bParts(d *pdf.Reader, p *pdf.Profile) Parts {
 		}
 	}
 
-	pageIndex, err := d.BuildPageIndex(graph)
+	var pageIndex map[int]int
+	if lz != nil {
+		var pages []pdf.PDFRef
+		pages, pageIndex, err = d.PageRefs()
+		lz.queuePageTree(pages)
+	} else {
+		pageIndex, err = d.BuildPageIndex(graph)
+	}
 	if err != nil {
-		pt.Graph = append(issues, pdf.NewError(pdf.Checks.Structure.GraphResolutionFailure, []error{err}, 0, nil))
-		return pt
+		return graphFailure(err)
 	}
 
 	ctx := &ValidationContext{
 		PageIndex:  pageIndex,
 		reader:     d,
 		schemaOnly: schemaOnly,
+		part:       part,
+		level:      p.Level,
+		lazy:       lz,
 	}
 	if !schemaOnly {
-		reachable, invisibleOnly, usedCodes, usedCIDs := ComputeContentUsage(graph, ctx)
-		if p.SkipUnreachableXObjects {
-			ctx.ReachableXObjectPtrs = reachable
-		}
 		ctx.SkipUnusedSimpleFonts = p.SkipUnusedSimpleFonts
-		ctx.InvisibleOnlyFontPtrs, ctx.UsedCharCodes, ctx.UsedCIDs = invisibleOnly, usedCodes, usedCIDs
+		if lz != nil {
+			if err := lz.computeContentUsage(ctx, p.SkipUnreachableXObjects); err != nil {
+				return graphFailure(err)
+			}
+		} else {
+			reachable, invisibleOnly, usedCodes, usedCIDs := ComputeContentUsage(graph, ctx)
+			if p.SkipUnreachableXObjects {
+				ctx.ReachableXObjectPtrs = reachable
+			}
+			ctx.InvisibleOnlyFontPtrs, ctx.UsedCharCodes, ctx.UsedCIDs = invisibleOnly, usedCodes, usedCIDs
+		}
 		computeColourCoverage(d, ctx)
 	}
 
-	verifyDocument(graph, ctx)
+	var xmpErrs []pdf.PDFError
+	if lz != nil {
+		if xmpErrs, err = lz.verify(ctx); err != nil {
+			return graphFailure(err)
+		}
+		lz.enter(ctx, lz.skeleton)
+	} else {
+		verifyDocument(graph, ctx)
+		if !schemaOnly {
+			xmpErrs = checkNonCatalogXMPStreams(graph)
+		}
+	}
+	if d.Err() != nil {
+		pt.Graph = append(issues, ctx.errs...)
+		return pt
+	}
+	if p.Level.RequiresLogicalStructure() {
+		verifyLogicalStructure(graph, ctx)
+	}
+	if part >= 3 {
+		reportUnassociatedFiles(ctx)
+	}
 	issues = append(issues, ctx.errs...)
 	if schemaOnly {
 		pt.Graph = issues
 		return pt
 	}
-	errs := verifyOptionalContent(d)
+	var errs []pdf.PDFError
+	if part >= 2 {
+		errs = verifyPdfA2Document(d, graph)
+		if part >= 4 {
+			errs = append(errs, verifyPdfA4Document(graph)...)
+		}
+	} else {
+		errs = verifyOptionalContent(d)
+	}
 	if errs != nil {
 		issues = append(issues, errs...)
 	}
-	errs = verifyOutputIntent(d)
+	errs = verifyOutputIntent(d, part)
 	if errs != nil {
 		issues = append(issues, errs...)
 	}
@@ -305,13 +460,12 @@ func verifyPdfA1bParts(d *pdf.Reader, p *pdf.Profile) Parts {
 	if errs != nil {
 		issues = append(issues, errs...)
 	}
-	errs = verifyXMPMetadata(d)
+	errs = verifyXMPMetadata(d, part, p.Level)
 	if errs != nil {
 		issues = append(issues, errs...)
 	}
-	errs = checkNonCatalogXMPStreams(graph)
-	if errs != nil {
-		issues = append(issues, errs...)
+	if xmpErrs != nil {
+		issues = append(issues, xmpErrs...)
 	}
 	pt.Graph = issues
 
@@ -460,14 +614,27 @@ func verifyFileTrailer(d *pdf.Reader) []pdf.PDFError {
 }
 
 // verifyCrossReferenceTable verifies requirements outlined in 6.1.4 for the
-// current xref section and all prior sections linked via Prev.
-func verifyCrossReferenceTable(d *pdf.Reader) []pdf.PDFError {
-	if errs := checkXRefSectionFormat(d, d.XRefOffset()); len(errs) > 0 {
-		return errs
+// current xref section and all prior sections linked via Prev. From part 2
+// on, a section may instead be a cross-reference stream (ISO 19005-2, 6.1.4),
+// which has no keyword or subsection syntax to check.
+func verifyCrossReferenceTable(d *pdf.Reader, part int) []pdf.PDFError {
+	allowStreams := part >= 2
+	visited := map[int64]bool{}
+	offset := d.XRefOffset()
+	var prev pdf.PDFValue
+	if allowStreams {
+		if stm, err := d.XRefStreamDictAt(offset + d.PDFStart()); err == nil {
+			visited[offset] = true
+			prev = stm.Entries["Prev"]
+		}
+	}
+	if !visited[offset] {
+		if errs := checkXRefSectionFormat(d, offset); len(errs) > 0 {
+			return errs
+		}
+		visited[offset] = true
+		prev = d.Trailer().Entries["Prev"]
 	}
-
-	visited := map[int64]bool{d.XRefOffset(): true}
-	prev := d.Trailer().Entries["Prev"]
 	for {
 		prevInt, ok := prev.(pdf.PDFInteger)
 		if !ok {
@@ -479,11 +646,19 @@ func verifyCrossReferenceTable(d *pdf.Reader) []pdf.PDFError {
 		}
 		visited[prevOffset] = true
 
+		if allowStreams {
+			if stm, err := d.XRefStreamDictAt(prevOffset + d.PDFStart()); err == nil {
+				prev = stm.Entries["Prev"]
+				continue
+			}
+		}
 		if errs := checkXRefSectionFormat(d, prevOffset); len(errs) > 0 {
 			return errs
 		}
 
-		prevTrailer, err := d.ParseXRefSectionAt(prevOffset+d.PDFStart(), false)
+		// fillIn: the reader already holds the newer revisions' entries,
+		// which an older section must not overwrite.
+		prevTrailer, err := d.ParseXRefSectionAt(prevOffset+d.PDFStart(), true)
 		if err != nil {
 			break
 		}
@@ -655,17 +830,89 @@ func verifyDocumentInformationDictionary(graph pdf.PDFValue) []pdf.PDFError {
 
 // verifyDocument verifies the entire document graph, including all pages, resources, and content streams.
 func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
-	visited := make(map[uintptr]bool)
+	verifyGraph(graph, "FileTrailer", ctx)
+}
+
+// typedVisit dedupes schema validation per (node, Arlington type): a node shared
+// between differently-typed paths is re-descended once per new type, so schema
+// coverage does not depend on map iteration order, while every per-node PDF/A
+// check still runs exactly once (on the first visit).
+type typedVisit struct {
+	ptr uintptr
+	typ string
+}
+
+// visitSet is a walk's record of the nodes it has visited and the
+// Arlington types it visited each as. When verifying a page at a time the
+// indirect objects go in sets shared by all passes, by nodeKey, so an
+// object shared between passes is walked once, but the nodes inside them
+// are only tracked for the current walk's pass: such a node is reachable
+// only through its object, so one inside an object an earlier pass walked
+// was walked then too. The state carried between passes so follows the
+// number of objects rather than of every dictionary and array.
+type visitSet struct {
+	lz    *lazyState
+	nodes map[uintptr]bool
+	typed map[typedVisit]bool
+
+	// walked and walkedTyped are the shared sets, and fresh holds the
+	// objects this walk added to walked.
+	walked      map[uintptr]bool
+	walkedTyped map[typedVisit]bool
+	fresh       map[uintptr]bool
+}
+
+// newVisitSet returns an empty visitSet, whose objects go in walked and
+// walkedTyped when lz is set.
+func newVisitSet(lz *lazyState, walked map[uintptr]bool, walkedTyped map[typedVisit]bool) *visitSet {
+	return &visitSet{
+		lz:          lz,
+		nodes:       map[uintptr]bool{},
+		typed:       map[typedVisit]bool{},
+		walked:      walked,
+		walkedTyped: walkedTyped,
+		fresh:       map[uintptr]bool{},
+	}
+}
 
-	// typedVisit dedupes schema validation per (node, Arlington type): a node shared
-	// between differently-typed paths is re-descended once per new type, so schema
-	// coverage does not depend on map iteration order, while every per-node PDF/A
-	// check still runs exactly once (on the first visit).
-	type typedVisit struct {
-		ptr uintptr
-		typ string
+// visit records a visit as typ ("" for none) to the node whose ValuePointer
+// is ptr. It reports whether this is the node's first visit, which runs its
+// checks, and whether to walk it: on its first visit, or its first as typ.
+func (s *visitSet) visit(ptr uintptr, typ string) (first, walk bool) {
+	key, nodes, typed := ptr, s.nodes, s.typed
+	if s.lz != nil {
+		if at, ok := s.lz.origin[ptr]; ok {
+			obj := objectKey(at.ObjNum)
+			if at.Path == "" {
+				key, nodes, typed = obj, s.walked, s.walkedTyped
+				if !nodes[key] {
+					s.fresh[key] = true
+				}
+			} else if s.walked[obj] && !s.fresh[obj] {
+				nodes[key] = true
+			}
+		}
+	}
+	first = !nodes[key]
+	if !first && (typ == "" || typed[typedVisit{key, typ}]) {
+		return false, false
+	}
+	nodes[key] = true
+	if typ != "" {
+		typed[typedVisit{key, typ}] = true
+	}
+	return first, true
+}
+
+// verifyGraph walks graph, whose root should conform to the Arlington type
+// rootType ("" to identify it by its own Type), running every per-object
+// check. When verifying a page at a time an object shared between passes is
+// checked once; see visitSet.
+func verifyGraph(graph pdf.PDFValue, rootType string, ctx *ValidationContext) {
+	visited := newVisitSet(nil, nil, nil)
+	if lz := ctx.lazy; lz != nil {
+		visited = newVisitSet(lz, lz.walked, lz.walkedTyped)
 	}
-	visitedTyped := make(map[typedVisit]bool)
 
 	// owner is the nearest enclosing dict, threaded through arrays, so
 	// scalar-limit violations are reported against an object fixers can
@@ -680,7 +927,7 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 		if node == nil {
 			return
 		}
-		if depth > maxWalkDepth {
+		if depth > maxWalkDepth || ctx.stopped() {
 			return
 		}
 
@@ -689,17 +936,12 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 			// A subtree that lost its schema type can re-anchor when the dict's own
 			// /Type (+/Subtype) names identify exactly one Arlington type.
 			if expectedType == "" {
-				expectedType = selfIdentifiedType(v)
+				expectedType = selfIdentifiedType(ctx.objectModel(), v)
 			}
-			ptr := pdf.ValuePointer(v.Entries)
-			first := !visited[ptr]
-			if !first && (expectedType == "" || visitedTyped[typedVisit{ptr, expectedType}]) {
+			first, ok := visited.visit(pdf.ValuePointer(v.Entries), expectedType)
+			if !ok {
 				return
 			}
-			visited[ptr] = true
-			if expectedType != "" {
-				visitedTyped[typedVisit{ptr, expectedType}] = true
-			}
 
 			if (v.Entries["Type"] == pdf.PDFName{Value: "Page"}) {
 				if ref, ok := v.Entries["_ref"].(pdf.PDFRef); ok {
@@ -718,6 +960,9 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 				validateExtGState(v, ctx)
 				validateTransparencyGroup(v, ctx)
 				validateXObjectDict(v, ctx)
+				if ctx.part >= 2 {
+					validatePdfA2Object(v, ctx)
+				}
 				validateAnnotation(v, ctx)
 				validateFormField(v, ctx)
 				validateColourSpaceUsage(v, ctx)
@@ -746,6 +991,9 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 							)
 						}
 					}
+					if k != "_ref" && ctx.part >= 2 {
+						validateNameEncoding(k, v, ctx)
+					}
 				}
 				if (!first || ctx.schemaOnly) && !isContainer(val) {
 					// Scalars carry no schema, and on a re-descent they were
@@ -754,7 +1002,7 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 				}
 				var childType string
 				if expectedType != "" && k != "_ref" {
-					childType = arlingtonChildType(expectedType, k, val)
+					childType = arlingtonChildType(ctx.objectModel(), expectedType, k, val)
 				}
 				// Pass node (v already boxed) to avoid re-boxing v per call.
 				walk(val, node, k, childType, depth+1)
@@ -765,25 +1013,20 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 			}
 
 		case pdf.PDFArray:
-			ptr := pdf.ValuePointer(v)
-			first := !visited[ptr]
-			if !first && (expectedType == "" || visitedTyped[typedVisit{ptr, expectedType}]) {
+			first, ok := visited.visit(pdf.ValuePointer(v), expectedType)
+			if !ok {
 				return
 			}
-			visited[ptr] = true
-			if expectedType != "" {
-				visitedTyped[typedVisit{ptr, expectedType}] = true
-			}
 
 			if first && !ctx.schemaOnly {
 				validateColourSpaceArray(v, ctx)
 
 				// 6.1.12: maximum number of elements in an array is 8191.
-				if len(v) > 8191 {
+				if maxLen := ctx.limits().maxArray; maxLen > 0 && len(v) > maxLen {
 					ctx.Report(
 						pdf.Checks.Structure.ArrayTooLarge,
 						v,
-						fmt.Sprintf("array exceeds 8191 elements: %d", len(v)),
+						fmt.Sprintf("array exceeds %d elements: %d", maxLen, len(v)),
 					)
 				}
 			}
@@ -797,7 +1040,7 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 				}
 				var elemType string
 				if expectedType != "" {
-					elemType = arlingtonElementType(expectedType, item)
+					elemType = arlingtonElementType(ctx.objectModel(), expectedType, item)
 				}
 				walk(item, owner, "", elemType, depth+1)
 			}
@@ -818,7 +1061,7 @@ func verifyDocument(graph pdf.PDFValue, ctx *ValidationContext) {
 		}
 	}
 
-	walk(graph, nil, "", "FileTrailer", 0)
+	walk(graph, nil, "", rootType, 0)
 }
 
 // sortedKeys appends m's keys in sorted order to ctx.keyScratch and returns
@@ -855,18 +1098,40 @@ func isContainer(val pdf.PDFValue) bool {
 //     page content or other reachable Form XObjects.
 //   - invisibleOnly, usedCodes, usedCIDs: font usage, as computed by
 //     collectFontUsageFromBytes.
+//
+// For a level requiring Unicode mappings it also records on ctx the pages
+// each code is shown on (see ValidationContext.usedCodePages).
 func ComputeContentUsage(graph pdf.PDFValue, ctx *ValidationContext) (
 	reachable map[uintptr]bool,
 	invisibleOnly map[uintptr]bool,
 	usedCodes, usedCIDs map[uintptr]map[int]bool,
 ) {
-	reachable = map[uintptr]bool{}
+	reachable, fu := contentUsage(graph, ctx)
+	invisibleOnly = map[uintptr]bool{}
+	for ptr := range fu.invisible {
+		if !fu.visible[ptr] {
+			invisibleOnly[ptr] = true
+		}
+	}
+	if fu.pages != nil {
+		ctx.usedCodePages = fu.pages
+	}
+	return reachable, invisibleOnly, fu.usedCodes, fu.usedCIDs
+}
+
+// contentUsage is ComputeContentUsage before the font usage is reduced to
+// the invisible-only set.
+func contentUsage(graph pdf.PDFValue, ctx *ValidationContext) (map[uintptr]bool, *fontUsage) {
+	reachable := map[uintptr]bool{}
 	fu := &fontUsage{
 		visible:   map[uintptr]bool{},
 		invisible: map[uintptr]bool{},
 		usedCodes: map[uintptr]map[int]bool{},
 		usedCIDs:  map[uintptr]map[int]bool{},
 	}
+	if ctx != nil && ctx.level.RequiresUnicode() {
+		fu.pages = map[uintptr]map[int]map[int]bool{}
+	}
 	visitedPtrs := map[uintptr]bool{}
 
 	var walkGraph func(v pdf.PDFValue)
@@ -880,6 +1145,12 @@ func ComputeContentUsage(graph pdf.PDFValue, ctx *ValidationContext) (
 			visitedPtrs[ptr] = true
 
 			if val.Entries["Type"] == (pdf.PDFName{Value: "Page"}) {
+				if ctx.stopped() {
+					return
+				}
+				if ref, ok := val.Entries["_ref"].(pdf.PDFRef); ok && ctx != nil {
+					fu.page = ctx.PageIndex[ref.ObjNum]
+				}
 				resources, _ := val.Entries["Resources"].(pdf.PDFDict)
 				collectContentUsage(ctx, val.Entries["Contents"], resources, reachable, fu)
 				collectAnnotAppearanceUsage(ctx, val, reachable, fu)
@@ -900,14 +1171,7 @@ func ComputeContentUsage(graph pdf.PDFValue, ctx *ValidationContext) (
 		}
 	}
 	walkGraph(graph)
-
-	invisibleOnly = map[uintptr]bool{}
-	for ptr := range fu.invisible {
-		if !fu.visible[ptr] {
-			invisibleOnly[ptr] = true
-		}
-	}
-	return reachable, invisibleOnly, fu.usedCodes, fu.usedCIDs
+	return reachable, fu
 }
 
 // collectAnnotAppearanceUsage marks XObjects reachable via annotation
@@ -973,11 +1237,33 @@ func collectContentUsage(
 
 // fontUsage tracks visible vs. invisible-only rendering per font, plus the
 // character codes (simple fonts) and CIDs (Identity-H/V fonts) actually shown.
+// When pages is non-nil, the codes are also recorded per page, page being
+// the number of the page whose content is being scanned.
 type fontUsage struct {
 	visible   map[uintptr]bool
 	invisible map[uintptr]bool
 	usedCodes map[uintptr]map[int]bool
 	usedCIDs  map[uintptr]map[int]bool
+	pages     map[uintptr]map[int]map[int]bool
+	page      int
+}
+
+// recordPage adds code to the codes font ptr shows on the current page.
+func (fu *fontUsage) recordPage(ptr uintptr, code int) {
+	if fu.pages == nil {
+		return
+	}
+	byPage := fu.pages[ptr]
+	if byPage == nil {
+		byPage = map[int]map[int]bool{}
+		fu.pages[ptr] = byPage
+	}
+	codes := byPage[fu.page]
+	if codes == nil {
+		codes = map[int]bool{}
+		byPage[fu.page] = codes
+	}
+	codes[code] = true
 }
 
 // collectUsageFromBytes scans dict's content stream exactly once, tracking
@@ -1064,6 +1350,7 @@ func collectUsageFromBytes(ctx *ValidationContext, dict pdf.PDFDict, resources p
 				}
 				for _, b := range ShownStringBytes(op, operands) {
 					set[int(b)] = true
+					fu.recordPage(simpleFontPtr, int(b))
 				}
 			}
 			if haveCompositeFont {
@@ -1074,7 +1361,9 @@ func collectUsageFromBytes(ctx *ValidationContext, dict pdf.PDFDict, resources p
 				}
 				shown := ShownStringBytes(op, operands)
 				for i := 0; i+1 < len(shown); i += 2 {
-					set[int(shown[i])<<8|int(shown[i+1])] = true
+					cid := int(shown[i])<<8 | int(shown[i+1])
+					set[cid] = true
+					fu.recordPage(compositeFontPtr, cid)
 				}
 			}
 		case "Do":
@@ -1185,11 +1474,19 @@ func validateStreamObject(v pdf.PDFDict, ctx *ValidationContext) {
 		if f == "LZWDecode" || f == "LZW" {
 			ctx.Report(pdf.Checks.Structure.StreamLZWFilter, v, "stream object uses forbidden LZWDecode filter")
 		}
+		// PDF/A-2 admits JPEG 2000, under the rules of validateJPXImage.
+		if f == "JPXDecode" && ctx.part < 2 {
+			ctx.Report(pdf.Checks.Structure.StreamJPXFilter, v, "stream object uses the JPXDecode filter, not available in PDF 1.4")
+		}
 	}
 }
 
-// validateObject validates requirements outlined in 6.1.11
+// validateObject validates requirements outlined in 6.1.11. PDF/A-2 permits
+// embedded files (6.8, see validateEmbeddedFiles).
 func validateObject(v pdf.PDFDict, ctx *ValidationContext) {
+	if ctx.part >= 2 {
+		return
+	}
 	if v.Entries["EF"] != nil {
 		ctx.Report(pdf.Checks.Structure.EmbeddedFileSpec, v, "dictionary shall not contain EF key")
 	}
@@ -1213,6 +1510,9 @@ func validateArchitecturalLimits(node pdf.PDFValue, owner pdf.PDFValue, ctx *Val
 				nameLen,
 			))
 		}
+		if ctx.part >= 2 {
+			validateNameEncoding(v.Value, owner, ctx)
+		}
 	case pdf.PDFInteger:
 		// 6.1.12: integer values are limited to the 32-bit signed range.
 		if v < -2_147_483_648 || v > 2_147_483_647 {
@@ -1220,24 +1520,28 @@ func validateArchitecturalLimits(node pdf.PDFValue, owner pdf.PDFValue, ctx *Val
 		}
 	case pdf.PDFReal:
 		// 6.1.12: magnitude of real numbers shall not exceed 32767.
-		if v < -32767 || v > 32767 {
+		if maxReal := ctx.limits().maxReal; float64(v) < -maxReal || float64(v) > maxReal {
 			ctx.Report(pdf.Checks.Structure.RealOutOfRange, owner, fmt.Sprintf("real number out of range: %g", float64(v)))
 		}
 	case pdf.PDFString:
 		// 6.1.12: maximum length of a string object is 65535 bytes.
-		if len(v.Value) > 65535 {
-			ctx.Report(pdf.Checks.Structure.StringTooLong, owner, "string exceeds maximum length of 65535 bytes")
+		if maxLen := ctx.limits().maxString; len(v.Value) > maxLen {
+			ctx.Report(pdf.Checks.Structure.StringTooLong, owner, fmt.Sprintf("string exceeds maximum length of %d bytes", maxLen))
 		}
 	case pdf.PDFDict:
 		// 6.1.12: maximum number of entries in a dictionary is 4095.
+		maxLen := ctx.limits().maxDict
+		if maxLen == 0 {
+			break
+		}
 		realCount := len(v.Entries)
 		if _, has := v.Entries["_ref"]; has {
 			realCount--
 		}
-		if realCount > 4095 {
+		if realCount > maxLen {
 			ctx.Report(pdf.Checks.Structure.DictTooLarge, v, fmt.Sprintf(
-				"dictionary exceeds 4095 entries: %d",
-				realCount,
+				"dictionary exceeds %d entries: %d",
+				maxLen, realCount,
 			))
 		}
 	}
@@ -1259,8 +1563,9 @@ func verifyOptionalContent(d *pdf.Reader) []pdf.PDFError {
 
 // 6.2 Graphics
 
-// verifyOutputIntent verifies requirements outlined in 6.2.2
-func verifyOutputIntent(d *pdf.Reader) []pdf.PDFError {
+// verifyOutputIntent verifies requirements outlined in 6.2.2 (6.2.3 in
+// ISO 19005-2, which keeps the GTS_PDFA1 subtype but widens the ICC rules).
+func verifyOutputIntent(d *pdf.Reader, part int) []pdf.PDFError {
 	values, err := d.ResolveGraphByPath([]string{"Root", "OutputIntents"})
 	if err != nil || values == nil {
 		// OutputIntents are optional.
@@ -1405,7 +1710,7 @@ func verifyOutputIntent(d *pdf.Reader) []pdf.PDFError {
 
 		// 6.2.2: the ICC profile stream shall be a valid ICC.1:2003-09 profile (version ≤ 2.x).
 		if profileMap.HasStream {
-			if iccErr := ValidateICCProfileStream(profileMap); iccErr != nil {
+			if iccErr := validateICCProfile(profileMap, part); iccErr != nil {
 				errs = append(errs, *iccErr)
 			}
 		}
@@ -1436,6 +1741,13 @@ var iccValidColorSpaces = map[string]bool{
 // ValidateICCProfileStream checks that a DestOutputProfile stream is a valid
 // ICC profile version 2.x as required by PDF/A-1 (6.2.2, 6.2.3 / ICC.1:2003-09).
 func ValidateICCProfileStream(dict pdf.PDFDict) *pdf.PDFError {
+	return validateICCProfile(dict, 1)
+}
+
+// validateICCProfile is ValidateICCProfileStream for ISO 19005 part. Part 2
+// (6.2.3) also permits ICC.1:2004-10 (version 4.x) profiles, but restricts an
+// output intent's profile to the output (prtr) or display (mntr) classes.
+func validateICCProfile(dict pdf.PDFDict, part int) *pdf.PDFError {
 	data, err := pdf.DecodeStream(dict)
 	if err != nil {
 		newErr := pdf.NewError(pdf.Checks.Colour.OutputIntentICCVersion, []error{fmt.Errorf("cannot decode ICC profile stream: %v", err)}, 0, nil)
@@ -1453,17 +1765,21 @@ func ValidateICCProfileStream(dict pdf.PDFDict) *pdf.PDFError {
 		return &newErr
 	}
 
-	// Version must be < 3.0 (PDF/A-1 permits ICC v2.x only).
+	// Version must be < 3.0 (PDF/A-1 permits ICC v2.x only; PDF/A-2 adds v4.x).
 	major := data[8]
-	if major >= 3 {
-		newErr := pdf.NewError(pdf.Checks.Colour.OutputIntentICCVersion, []error{fmt.Errorf("ICC profile version %d.x not allowed in PDF/A-1 (must be < 3.0)", major)}, 0, nil)
+	maxMajor := byte(2)
+	if part >= 2 {
+		maxMajor = 4
+	}
+	if major > maxMajor {
+		newErr := pdf.NewError(pdf.Checks.Colour.OutputIntentICCVersion, []error{fmt.Errorf("ICC profile version %d.x not allowed in PDF/A-%d (must be < %d.0)", major, part, maxMajor+1)}, 0, nil)
 		return &newErr
 	}
 
 	// Device class must be one of:
 	// prtr (output), mntr (display), scnr (input), spac (colorspace conversion).
 	deviceClass := string(data[12:16])
-	if !iccValidDeviceClasses[deviceClass] {
+	if !iccValidDeviceClasses[deviceClass] || (part >= 2 && deviceClass != "prtr" && deviceClass != "mntr") {
 		newErr := pdf.NewError(pdf.Checks.Colour.OutputIntentICCVersion, []error{fmt.Errorf("ICC profile has invalid deviceClass %q", deviceClass)}, 0, nil)
 		return &newErr
 	}
@@ -1,6 +1,8 @@
 package verify
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -68,6 +70,135 @@ func TestVerifyAll(t *testing.T) {
 	}
 }
 
+// countdownContext is done once its Done method has been called n times,
+// to cancel a verification part way through.
+type countdownContext struct {
+	context.Context
+	n    int
+	done chan struct{}
+}
+
+func newCountdownContext(n int) *countdownContext {
+	return &countdownContext{Context: context.Background(), n: n, done: make(chan struct{})}
+}
+
+func (c *countdownContext) Done() <-chan struct{} {
+	if c.n--; c.n == 0 {
+		close(c.done)
+	}
+	return c.done
+}
+
+func (c *countdownContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// TestVerifyContextCancelled confirms a verification cancelled part way
+// through returns the context's error with an invalid Result whose findings
+// are a subset of the full verification's, and leaves the Reader usable.
+func TestVerifyContextCancelled(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(isartorDir, "*", "*.pdf"))
+	if len(files) == 0 {
+		t.Skip("reference test suites not present")
+	}
+	for _, path := range files[:min(len(files), 10)] {
+		doc, err := pdf.Open(path)
+		if err != nil {
+			continue
+		}
+		full, err := Verify(doc, pdf.PDFA_2B)
+		if err != nil {
+			t.Fatalf("Verify(%s): %v", path, err)
+		}
+		doc.Close()
+		want := findingSet(full)
+		// Cancel ever later, until the countdown outlasts the verification.
+		for n := 1; ; n *= 2 {
+			doc, _ := pdf.Open(path)
+			res, err := VerifyContext(newCountdownContext(n), doc, pdf.PDFA_2B)
+			if !errors.Is(err, context.Canceled) {
+				if err != nil || !slices.Equal(findingSet(res), want) {
+					t.Errorf("%s, n=%d: err %v, findings %v, want %v", path, n, err, findingSet(res), want)
+				}
+				doc.Close()
+				break
+			}
+			if res.Valid {
+				t.Errorf("%s, n=%d: cancelled Result is Valid", path, n)
+			}
+			for _, f := range findingSet(res) {
+				if _, ok := slices.BinarySearch(want, f); !ok {
+					t.Errorf("%s, n=%d: cancelled verification found %q, which the full one does not", path, n, f)
+				}
+			}
+			if again, err := Verify(doc, pdf.PDFA_2B); err != nil || !slices.Equal(findingSet(again), want) {
+				t.Errorf("%s, n=%d: Verify after a cancelled VerifyContext = %v, %v, want the full findings", path, n, findingSet(again), err)
+			}
+			doc.Close()
+		}
+	}
+}
+
+// TestVerifyAllContextCancelled confirms a batch whose context is already
+// done opens no files and returns the context's error for each.
+func TestVerifyAllContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := VerifyAllContext(ctx, []string{sampleVeraPassFile, "/nonexistent/path.pdf"}, pdf.PDFA_1B)
+	if err != nil {
+		t.Fatalf("VerifyAllContext: %v", err)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: Err = %v, want context.Canceled", r.Path, r.Err)
+		}
+	}
+}
+
+// TestVerifyLimits confirms a document over one of the profile's Limits
+// stops verification with a LimitError, for the whole-graph and page-at-a-
+// time paths alike, and that the Reader's own limits are restored after.
+func TestVerifyLimits(t *testing.T) {
+	data := buildTestPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	}, "<< /Size 5 /Root 1 0 R >>")
+	p := pdf.PDFA_1B.Clone()
+	p.Limits = pdf.Limits{Pages: 1}
+	lazy := p.Clone()
+	lazy.ObjectCacheLimit = 2
+	for _, prof := range []*pdf.Profile{p, lazy} {
+		doc, err := pdf.OpenBytes(data)
+		if err != nil {
+			t.Fatalf("OpenBytes: %v", err)
+		}
+		res, err := Verify(doc, prof)
+		var le *pdf.LimitError
+		if !errors.As(err, &le) || le.Limit != "Pages" || le.Max != 1 {
+			t.Errorf("ObjectCacheLimit %d: Verify error = %v, want a Pages LimitError", prof.ObjectCacheLimit, err)
+		}
+		if res.Valid {
+			t.Errorf("ObjectCacheLimit %d: stopped verification reported Valid", prof.ObjectCacheLimit)
+		}
+		if doc.Err() != nil || doc.Limits() != pdf.DefaultLimits() {
+			t.Errorf("ObjectCacheLimit %d: Reader limits not restored: %v, %+v", prof.ObjectCacheLimit, doc.Err(), doc.Limits())
+		}
+		doc.Close()
+	}
+
+	p.Limits = pdf.Limits{Pages: 2}
+	if _, err := VerifyBytes(data, p); err != nil {
+		t.Errorf("Verify within the limits: %v", err)
+	}
+}
+
 // plainPDF is a minimal one-page PDF with no PDF/A structure but a
 // well-formed base object model, so object-model-only checks pass on it.
 const plainPDF = "%PDF-1.4\n" +
@@ -314,7 +445,7 @@ func TestDocument_VerifyPDFACrossReferenceTable_MissingXref(t *testing.T) {
 	doc := pdf.NewRawReader(f, pdf.PDFDict{}, 0, 0)
 	defer doc.Close()
 
-	errs := verifyCrossReferenceTable(doc)
+	errs := verifyCrossReferenceTable(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for missing xref keyword, got %v", errs)
 	}
@@ -334,7 +465,7 @@ func TestDocument_VerifyPDFACrossReferenceTable_MissingXrefHeader(t *testing.T)
 	doc := pdf.NewRawReader(f, pdf.PDFDict{}, 0, 0)
 	defer doc.Close()
 
-	errs := verifyCrossReferenceTable(doc)
+	errs := verifyCrossReferenceTable(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for missing xref header, got %v", errs)
 	}
@@ -354,7 +485,7 @@ func TestDocument_VerifyPDFACrossReferenceTable_MultipleEOLSeperators(t *testing
 	doc := pdf.NewRawReader(f, pdf.PDFDict{}, 0, 0)
 	defer doc.Close()
 
-	errs := verifyCrossReferenceTable(doc)
+	errs := verifyCrossReferenceTable(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for invalid EOL, got %v", errs)
 	}
@@ -690,6 +821,47 @@ func TestDocument_VerifyPDFAFilter_LZWDecode(t *testing.T) {
 	}
 }
 
+func TestDocument_VerifyPDFAFilter_JPXDecode(t *testing.T) {
+	filename := "test.pdf"
+	content := []byte("")
+	os.WriteFile(filename, content, 0644)
+	defer os.Remove(filename)
+
+	trailer := pdf.NewPDFDict()
+	minimalConformantRoot(trailer)
+	stream := pdf.NewPDFDict()
+	stream.HasStream = true
+	stream.Entries["Filter"] = pdf.PDFName{Value: "JPXDecode"}
+	stream.Entries["_ref"] = pdf.PDFRef{ObjNum: 90}
+
+	trailer.Entries["XStream"] = stream
+
+	f, _ := os.Open(filename)
+	doc := pdf.NewRawReader(f, trailer, 0, 0)
+	defer doc.Close()
+
+	graph, _ := doc.ResolveGraph()
+	pageIndex, _ := doc.BuildPageIndex(graph)
+	ctx := &ValidationContext{
+		PageIndex: pageIndex,
+	}
+	verifyDocument(graph, ctx)
+	errs := ctx.errs
+	if len(errs) != 1 {
+		t.Fatalf("Expected one error for Filter JPXDecode, got %v", errs)
+	}
+	if errs[0].Check() != pdf.Checks.Structure.StreamJPXFilter {
+		t.Errorf("Got unexpected error %v", errs[0])
+	}
+
+	// PDF/A-2 permits JPEG 2000.
+	ctx = &ValidationContext{PageIndex: pageIndex, part: 2}
+	verifyDocument(graph, ctx)
+	if hasCheck(ctx, pdf.Checks.Structure.StreamJPXFilter) {
+		t.Error("JPXDecode flagged for PDF/A-2")
+	}
+}
+
 // 6.1.11
 
 func TestDocument_VerifyPDFAEmbeddedFiles_EF(t *testing.T) {
@@ -888,7 +1060,7 @@ func TestDocument_VerifyPDFAOutputIntent(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 0 {
 		t.Errorf("Unexpected error: %v", errs)
 	}
@@ -912,7 +1084,7 @@ func TestDocument_VerifyPDFAOutputIntent_InvalidOutputIntents(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for invalid OutputIntents type, got %v", errs)
 	}
@@ -940,7 +1112,7 @@ func TestDocument_VerifyPDFAOutputIntent_InvalidOutputIntent(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for invalid OutputIntent type, got %v", errs)
 	}
@@ -969,7 +1141,7 @@ func TestDocument_VerifyPDFAOutputIntent_InvalidSType(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for invalid S type, got %v", errs)
 	}
@@ -1003,7 +1175,7 @@ func TestDocument_VerifyPDFAOutputIntent_WrongS(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for wrong S, got %v", errs)
 	}
@@ -1034,7 +1206,7 @@ func TestDocument_VerifyPDFAOutputIntent_WrongOutputConditionIdentifier(t *testi
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for nil OutputConditionIentifier, got %v", errs)
 	}
@@ -1080,7 +1252,7 @@ func TestDocument_VerifyPDFAOutputIntent_DifferingDestOutputProfiles(t *testing.
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for differing DestOutputProfiles, got %v", errs)
 	}
@@ -1113,7 +1285,7 @@ func TestDocument_VerifyPDFAOutputIntent_DestOutputProfileWrongFormat(t *testing
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for wrong DestOutputProfile format, got %v", errs)
 	}
@@ -1148,7 +1320,7 @@ func TestDocument_VerifyPDFAOutputIntent_WrongNType(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for wrong N, got %v", errs)
 	}
@@ -1183,7 +1355,7 @@ func TestDocument_VerifyPDFAOutputIntent_WrongN(t *testing.T) {
 	doc := pdf.NewRawReader(f, trailer, 0, 0)
 	defer doc.Close()
 
-	errs := verifyOutputIntent(doc)
+	errs := verifyOutputIntent(doc, 1)
 	if len(errs) != 1 {
 		t.Errorf("Expected one error for wrong N, got %v", errs)
 	}
@@ -1403,7 +1575,44 @@ func TestVerifyCrossReferenceTablePrevChain(t *testing.T) {
 	// Not asserting a specific outcome: this file's xref sections may or may
 	// not themselves be spec-conformant. The point is exercising the Prev-walk
 	// loop (and its visited-offset cycle guard) without panicking.
-	_ = verifyCrossReferenceTable(doc)
+	_ = verifyCrossReferenceTable(doc, 1)
+}
+
+// TestVerifyCrossReferenceTableKeepsNewerEntries checks that walking /Prev
+// to an older section leaves the reader resolving an object its update
+// replaced to the replacement, not the superseded original.
+func TestVerifyCrossReferenceTableKeepsNewerEntries(t *testing.T) {
+	var b strings.Builder
+	entry := func(off int) string { return fmt.Sprintf("%010d 00000 n \n", off) }
+	b.WriteString("%PDF-1.4\n")
+	old := b.Len()
+	b.WriteString("1 0 obj\n(old)\nendobj\n")
+	catalog := b.Len()
+	b.WriteString("2 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	xref1 := b.Len()
+	fmt.Fprintf(&b, "xref\n0 3\n0000000000 65535 f \n%s%strailer\n<< /Size 3 /Root 2 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		entry(old), entry(catalog), xref1)
+	updated := b.Len()
+	b.WriteString("1 0 obj\n(new)\nendobj\n")
+	xref2 := b.Len()
+	fmt.Fprintf(&b, "xref\n1 1\n%strailer\n<< /Size 3 /Root 2 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n",
+		entry(updated), xref1, xref2)
+
+	doc, err := pdf.OpenBytes([]byte(b.String()))
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	if errs := verifyCrossReferenceTable(doc, 1); len(errs) > 0 {
+		t.Fatalf("verifyCrossReferenceTable: %v", errs)
+	}
+	v, err := doc.ResolveReference(pdf.PDFRef{ObjNum: 1})
+	if err != nil {
+		t.Fatalf("ResolveReference(1): %v", err)
+	}
+	if s, _ := v.(pdf.PDFString); s.Value != "new" {
+		t.Errorf("object 1 = %v, want the updated (new)", v)
+	}
 }
 
 func TestComputeContentUsageFullFlow(t *testing.T) {
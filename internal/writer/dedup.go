@@ -0,0 +1,161 @@
+package writer
+
+import (
+	"crypto/sha256"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// DedupReport summarizes a Dedup pass: how many indirect objects it merged
+// into an identical one and the serialized bytes they would have taken.
+type DedupReport struct {
+	Objects int
+	Bytes   int64
+}
+
+// identityTypes are the /Type values of objects whose identity, not just
+// their content, carries meaning: the page tree, annotations, the structure
+// tree and outline, signatures, optional content groups and membership
+// dictionaries (which /OCProperties and /OC entries tell apart by
+// reference), and article threads and beads. Dedup never merges them.
+var identityTypes = map[string]bool{
+	"Catalog": true, "Pages": true, "Page": true, "Annot": true,
+	"StructTreeRoot": true, "StructElem": true, "OBJR": true, "MCR": true,
+	"Outlines": true, "Sig": true, "OCG": true, "OCMD": true,
+	"Thread": true, "Bead": true,
+}
+
+// mergeable reports whether obj may be replaced by an identical object:
+// not one of identityTypes, nor a dict linked into a tree by /Parent or to
+// its page by /P, nor an annotation (/Rect) or form field (/FT), for which
+// /Type is optional.
+func mergeable(obj pdf.PDFDict) bool {
+	for _, k := range []string{"Parent", "P", "Rect", "FT"} {
+		if _, ok := obj.Entries[k]; ok {
+			return false
+		}
+	}
+	t, _ := obj.Entries["Type"].(pdf.PDFName)
+	return !identityTypes[t.Value]
+}
+
+// Dedup merges byte-identical indirect objects reachable from trailer --
+// the same ICC profile, image or font subset embedded once per page --
+// rewriting every reference to a duplicate to point at the first such
+// object instead, so the writer, which only writes what is reachable, emits
+// it once. Objects are compared by a SHA-256 hash of their serialization
+// with references written as the number of the object they resolve to after
+// merging, repeated until no more merge, so two fonts whose only difference
+// was pointing at separate copies of one font file merge too. Objects whose
+// identity matters (see mergeable) are left alone. Run it before numbering:
+// _ref entries are not updated.
+func Dedup(trailer pdf.PDFDict) (DedupReport, error) {
+	wr := &pdfWriter{
+		numbers: map[objectIdentity]int{},
+		visited: map[uintptr]bool{},
+	}
+	wr.discover(trailer.Entries["Root"])
+	wr.discover(trailer.Entries["Info"])
+
+	// class[i] is the position in wr.order of the object order[i] merges
+	// into, i itself while it has no earlier twin.
+	class := make([]int, len(wr.order))
+	for i := range class {
+		class[i] = i
+	}
+	var report DedupReport
+	for {
+		seen := map[[sha256.Size]byte]int{}
+		merged := false
+		for i, obj := range wr.order {
+			if class[i] != i || !mergeable(obj) {
+				continue
+			}
+			h := sha256.New()
+			cw := &countingWriter{w: h}
+			if err := wr.writeIndirectObject(cw, 0, obj); err != nil {
+				return DedupReport{}, err
+			}
+			var sum [sha256.Size]byte
+			h.Sum(sum[:0])
+			if rep, ok := seen[sum]; ok {
+				class[i] = rep
+				report.Objects++
+				report.Bytes += cw.n
+				merged = true
+				continue
+			}
+			seen[sum] = i
+		}
+		if !merged {
+			break
+		}
+		// A representative may itself have merged this round; each class
+		// points earlier in order, so one forward pass settles every chain.
+		for i := range class {
+			class[i] = class[class[i]]
+			wr.numbers[wr.identityOf(wr.order[i])] = class[i] + 1
+		}
+	}
+	if report.Objects == 0 {
+		return report, nil
+	}
+
+	wr.visited = map[uintptr]bool{}
+	wr.redirect(trailer)
+	return report, nil
+}
+
+// redirect replaces, throughout v, every indirect dict Dedup merged with the
+// object it merged into, which wr.numbers holds the position of.
+func (wr *pdfWriter) redirect(v pdf.PDFValue) {
+	if wr.depth > maxWriteDepth {
+		return
+	}
+	wr.depth++
+	defer func() { wr.depth-- }()
+
+	target := func(child pdf.PDFValue) (pdf.PDFDict, bool) {
+		d, ok := child.(pdf.PDFDict)
+		if !ok || !isIndirectDict(d) {
+			return pdf.PDFDict{}, false
+		}
+		id := wr.identityOf(d)
+		n, ok := wr.numbers[id]
+		if !ok { // outside Root and Info, e.g. the trailer's /Encrypt
+			return pdf.PDFDict{}, false
+		}
+		rep := wr.order[n-1]
+		return rep, wr.identityOf(rep) != id
+	}
+	switch val := v.(type) {
+	case pdf.PDFDict:
+		ptr := pdf.ValuePointer(val.Entries)
+		if wr.visited[ptr] {
+			return
+		}
+		wr.visited[ptr] = true
+		for k, child := range val.Entries {
+			if k == "_ref" || k == "_dirty" {
+				continue
+			}
+			if rep, ok := target(child); ok {
+				val.Entries[k] = rep
+				child = rep
+			}
+			wr.redirect(child)
+		}
+	case pdf.PDFArray:
+		ptr := pdf.ValuePointer(val)
+		if wr.visited[ptr] {
+			return
+		}
+		wr.visited[ptr] = true
+		for i, child := range val {
+			if rep, ok := target(child); ok {
+				val[i] = rep
+			}
+			wr.redirect(val[i])
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// dedupTestGraph returns a three-page document in which every page carries
+// its own copy of an ICC profile, an image drawn in it, and an annotation,
+// all byte-identical across pages once the images' profiles merge.
+func dedupTestGraph() (pdf.PDFDict, []pdf.PDFDict) {
+	profile := bytes.Repeat([]byte("icc"), 1000)
+	pages := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 2}, "Type": pdf.PDFName{Value: "Pages"}, "Count": pdf.PDFInteger(3),
+	}}
+	var kids pdf.PDFArray
+	var pageDicts []pdf.PDFDict
+	for i := range 3 {
+		icc := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 10 + i}, "N": pdf.PDFInteger(3),
+		}, HasStream: true, RawStream: profile}
+		image := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 20 + i}, "Type": pdf.PDFName{Value: "XObject"}, "Subtype": pdf.PDFName{Value: "Image"},
+			"Width": pdf.PDFInteger(1), "Height": pdf.PDFInteger(1), "BitsPerComponent": pdf.PDFInteger(8),
+			"ColorSpace": pdf.PDFArray{pdf.PDFName{Value: "ICCBased"}, icc},
+		}, HasStream: true, RawStream: []byte{0, 0, 0}}
+		annot := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 30 + i}, "Subtype": pdf.PDFName{Value: "Square"},
+			"Rect": pdf.PDFArray{pdf.PDFInteger(0), pdf.PDFInteger(0), pdf.PDFInteger(10), pdf.PDFInteger(10)},
+		}}
+		page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 3 + i}, "Type": pdf.PDFName{Value: "Page"}, "Parent": pages,
+			"Resources": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Im0": image}},
+			}},
+			"Annots": pdf.PDFArray{annot},
+		}}
+		kids = append(kids, page)
+		pageDicts = append(pageDicts, page)
+	}
+	pages.Entries["Kids"] = kids
+	return pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "Pages": pages}},
+	}}, pageDicts
+}
+
+// TestDedup checks that the per-page profiles merge, that the images, which
+// differed only in which profile they pointed at, then merge too, that the
+// identical annotations stay distinct, and that the report counts the
+// merged objects' bytes.
+func TestDedup(t *testing.T) {
+	trailer, pages := dedupTestGraph()
+	var before bytes.Buffer
+	if err := WriteDocument(&before, trailer); err != nil {
+		t.Fatalf("WriteDocument: %v", err)
+	}
+
+	report, err := Dedup(trailer)
+	if err != nil {
+		t.Fatalf("Dedup: %v", err)
+	}
+	if report.Objects != 4 {
+		t.Errorf("merged %d objects, want 4 (two profiles, two images)", report.Objects)
+	}
+	if report.Bytes < 2*3000 {
+		t.Errorf("report.Bytes = %d, want at least the two duplicate profiles' 6000", report.Bytes)
+	}
+
+	image := func(page pdf.PDFDict) pdf.PDFDict {
+		return page.Entries["Resources"].(pdf.PDFDict).Entries["XObject"].(pdf.PDFDict).Entries["Im0"].(pdf.PDFDict)
+	}
+	annots := map[uintptr]bool{}
+	for i, page := range pages {
+		if pdf.ValuePointer(image(page).Entries) != pdf.ValuePointer(image(pages[0]).Entries) {
+			t.Errorf("page %d draws its own image, want the first page's", i+1)
+		}
+		annots[pdf.ValuePointer(page.Entries["Annots"].(pdf.PDFArray)[0].(pdf.PDFDict).Entries)] = true
+	}
+	if len(annots) != 3 {
+		t.Errorf("pages share annotations: %d distinct, want 3", len(annots))
+	}
+
+	var after bytes.Buffer
+	objs, err := WriteDocumentIndexed(&after, trailer)
+	if err != nil {
+		t.Fatalf("WriteDocumentIndexed: %v", err)
+	}
+	if len(objs) != 2+3+2+3 {
+		t.Errorf("wrote %d objects, want 10", len(objs))
+	}
+	if saved := int64(before.Len() - after.Len()); saved < report.Bytes {
+		t.Errorf("output shrank by %d bytes, want at least the reported %d", saved, report.Bytes)
+	}
+	if n := bytes.Count(after.Bytes(), bytes.Repeat([]byte("icc"), 1000)); n != 1 {
+		t.Errorf("output holds %d copies of the profile, want 1", n)
+	}
+}
+
+// TestDedupKeepsOptionalContentGroups checks that two identical optional
+// content groups, separate layers in /Order, are not merged.
+func TestDedupKeepsOptionalContentGroups(t *testing.T) {
+	ocg := func(ref int) pdf.PDFDict {
+		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: ref}, "Type": pdf.PDFName{Value: "OCG"}, "Name": pdf.PDFString{Value: "Layer"},
+		}}
+	}
+	a, b := ocg(10), ocg(11)
+	props := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"OCGs": pdf.PDFArray{a, b},
+		"D":    pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Order": pdf.PDFArray{a, b}, "OFF": pdf.PDFArray{b}}},
+	}}
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "OCProperties": props,
+	}}}}
+
+	report, err := Dedup(trailer)
+	if err != nil || report.Objects != 0 {
+		t.Fatalf("Dedup = %+v, %v; want nothing merged", report, err)
+	}
+	order := props.Entries["D"].(pdf.PDFDict).Entries["Order"].(pdf.PDFArray)
+	if pdf.ValuePointer(order[0].(pdf.PDFDict).Entries) == pdf.ValuePointer(order[1].(pdf.PDFDict).Entries) {
+		t.Error("the two layers in /Order were merged into one")
+	}
+	var buf bytes.Buffer
+	objs, err := WriteDocumentIndexed(&buf, trailer)
+	if err != nil || len(objs) != 3 {
+		t.Errorf("wrote %d objects, %v; want the catalog and both groups", len(objs), err)
+	}
+}
+
+// TestDedupNothingToMerge checks that a graph without duplicates is left
+// as it was.
+func TestDedupNothingToMerge(t *testing.T) {
+	trailer := linearizeTestGraph()
+	var before, after bytes.Buffer
+	if err := WriteDocument(&before, trailer); err != nil {
+		t.Fatalf("WriteDocument: %v", err)
+	}
+	report, err := Dedup(trailer)
+	if err != nil || report != (DedupReport{}) {
+		t.Fatalf("Dedup = %+v, %v; want an empty report", report, err)
+	}
+	if err := WriteDocument(&after, trailer); err != nil {
+		t.Fatalf("WriteDocument: %v", err)
+	}
+	if !bytes.Equal(before.Bytes(), after.Bytes()) {
+		t.Errorf("output changed:\n%s\nwant\n%s", after.Bytes(), before.Bytes())
+	}
+}
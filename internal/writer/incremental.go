@@ -0,0 +1,325 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// Origins records the object each indirect dict reachable from trailer was
+// read as, keyed by the pdf.ValuePointer of its Entries, for
+// WriteIncremental. Call it on a freshly resolved graph (see
+// pdf.Reader.ResolveGraph), before NumberObjects or a write renumbers the
+// graph's _ref entries. A dict a fixup later edits in place keeps its
+// origin; one built afresh has none.
+func Origins(trailer pdf.PDFDict) map[uintptr]pdf.PDFRef {
+	wr := &pdfWriter{
+		numbers: map[objectIdentity]int{},
+		visited: map[uintptr]bool{},
+	}
+	wr.discover(trailer.Entries["Root"])
+	wr.discover(trailer.Entries["Info"])
+
+	origins := make(map[uintptr]pdf.PDFRef, len(wr.order))
+	for _, obj := range wr.order {
+		if ref, ok := obj.Entries["_ref"].(pdf.PDFRef); ok {
+			origins[pdf.ValuePointer(obj.Entries)] = ref
+		}
+	}
+	return origins
+}
+
+// WriteIncremental writes base's bytes to w unchanged and appends an
+// incremental update (ISO 32000-1 7.5.6) bringing them in line with
+// trailer's graph, which was resolved from base and whose indirect dicts
+// came from the base objects origins (see Origins) records. The update
+// holds only the objects that differ from base -- those a fixup edited or
+// marked with a true "_dirty" entry, under their base numbers, and those it
+// built, under numbers past base's -- and a cross-reference section, a
+// stream if base's newest is one, chaining to base's with /Prev. Earlier
+// revisions stay byte-for-byte intact, so signatures over them still
+// verify; unchanged objects keep whatever byte-level defects they had.
+//
+// It returns the reachable indirect objects, each dict's _ref rewritten to
+// its number in the output. An encrypted base, or one whose /Prev chain
+// cannot be followed, is refused.
+func WriteIncremental(w io.Writer, base *pdf.Reader, trailer pdf.PDFDict, origins map[uintptr]pdf.PDFRef) (objs []pdf.PDFDict, err error) {
+	if base.Encrypted() {
+		return nil, fmt.Errorf("writer: incremental update of an encrypted document is not supported")
+	}
+	revs, err := base.Revisions()
+	if err != nil {
+		return nil, fmt.Errorf("writer: incremental update: %w", err)
+	}
+	// A Reader of its own parses the base objects afresh, untouched by the
+	// conversion that edited base's resolved graph.
+	orig, err := pdf.OpenReaderAt(base, base.Size())
+	if err != nil {
+		return nil, fmt.Errorf("writer: incremental update: %w", err)
+	}
+	defer orig.Close()
+
+	if origins == nil {
+		origins = map[uintptr]pdf.PDFRef{}
+	}
+	wr := &pdfWriter{
+		numbers: map[objectIdentity]int{},
+		visited: map[uintptr]bool{},
+		origins: origins,
+		gens:    map[int]int{},
+		next:    nextObjectNumber(base, origins),
+	}
+	for _, ref := range origins {
+		wr.gens[ref.ObjNum] = ref.GenNum
+	}
+	wr.discover(trailer.Entries["Root"])
+	wr.discover(trailer.Entries["Info"])
+
+	var nums []int
+	for _, obj := range wr.order {
+		ref, ok := origins[pdf.ValuePointer(obj.Entries)]
+		if ok && wr.unchanged(orig, ref, obj) {
+			continue
+		}
+		nums = append(nums, wr.numbers[wr.identityOf(obj)])
+	}
+	slices.Sort(nums)
+	byNum := make(map[int]pdf.PDFDict, len(wr.order))
+	for _, obj := range wr.order {
+		byNum[wr.numbers[wr.identityOf(obj)]] = obj
+	}
+
+	bw := bufio.NewWriterSize(w, 64<<10)
+	defer func() {
+		if ferr := bw.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}()
+	cw := &countingWriter{w: bw}
+	if _, err := io.Copy(cw, io.NewSectionReader(base, 0, base.Size())); err != nil {
+		return nil, err
+	}
+	var last [1]byte
+	if n, _ := base.ReadAt(last[:], base.Size()-1); n == 0 || (last[0] != '\n' && last[0] != '\r') {
+		if _, err := io.WriteString(cw, "\n"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Offsets count from the %PDF header, as base's own do.
+	offsets := make(map[int]int64, len(nums))
+	for _, num := range nums {
+		offsets[num] = cw.n - base.PDFStart()
+		if err := wr.writeIndirectObject(cw, num, byNum[num]); err != nil {
+			return nil, fmt.Errorf("writer: object %d: %w", num, err)
+		}
+	}
+
+	xrefOffset := cw.n - base.PDFStart()
+	newest := revs[len(revs)-1]
+	// /Prev is the section base was read from, header-relative like every
+	// other offset in the update, even where base's startxref counted from
+	// byte 0 and only the reader's fallback found it.
+	prev := newest.XRefOffset - base.PDFStart()
+	if newest.Trailer.Entries["Type"] == (pdf.PDFName{Value: "XRef"}) {
+		err = wr.writeIncrementalXRefStream(cw, trailer, prev, nums, offsets, xrefOffset)
+	} else {
+		err = wr.writeIncrementalXRefTable(cw, trailer, prev, nums, offsets, xrefOffset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, obj := range wr.order {
+		num := wr.numbers[wr.identityOf(obj)]
+		obj.Entries["_ref"] = pdf.PDFRef{ObjNum: num, GenNum: wr.gens[num]}
+		wr.order[i] = obj
+	}
+	return wr.order, nil
+}
+
+// nextObjectNumber returns the first object number base leaves free: its
+// trailer's /Size, or past the highest number it uses, if that is larger.
+func nextObjectNumber(base *pdf.Reader, origins map[uintptr]pdf.PDFRef) int {
+	next := 1
+	if size, ok := base.EffectiveTrailer().Entries["Size"].(pdf.PDFInteger); ok {
+		next = max(next, int(size))
+	}
+	for num := range base.XRefTable() {
+		next = max(next, num+1)
+	}
+	for _, ref := range origins {
+		next = max(next, ref.ObjNum+1)
+	}
+	return next
+}
+
+// writeIncrementalXRefTable writes the update's classic cross-reference
+// section, one subsection per run of consecutive object numbers, and its
+// trailer.
+func (wr *pdfWriter) writeIncrementalXRefTable(cw *countingWriter, trailer pdf.PDFDict, prev int64, nums []int, offsets map[int]int64, xrefOffset int64) error {
+	if _, err := io.WriteString(cw, "xref\n"); err != nil {
+		return err
+	}
+	for _, run := range xrefRuns(nums) {
+		var b [48]byte
+		h := strconv.AppendInt(b[:0], int64(run[0]), 10)
+		h = append(h, ' ')
+		h = strconv.AppendInt(h, int64(run[1]), 10)
+		h = append(h, '\n')
+		if _, err := cw.Write(h); err != nil {
+			return err
+		}
+		for num := run[0]; num < run[0]+run[1]; num++ {
+			if err := writeXRefEntry(cw, offsets[num], wr.gens[num]); err != nil {
+				return err
+			}
+		}
+	}
+
+	newTrailer := wr.trailerEntries(trailer, xrefOffset)
+	newTrailer["Size"] = pdf.PDFInteger(wr.next)
+	newTrailer["Prev"] = pdf.PDFInteger(prev)
+	if _, err := io.WriteString(cw, "trailer\n"); err != nil {
+		return err
+	}
+	if err := wr.writeDictEntries(cw, newTrailer); err != nil {
+		return fmt.Errorf("writer: trailer: %w", err)
+	}
+	if _, err := io.WriteString(cw, "\n"); err != nil {
+		return err
+	}
+	return writeStartXRef(cw, xrefOffset)
+}
+
+// writeIncrementalXRefStream writes the update's cross-reference stream,
+// numbered after every other object, listing the written objects and
+// itself in /Index runs.
+func (wr *pdfWriter) writeIncrementalXRefStream(cw *countingWriter, trailer pdf.PDFDict, prev int64, nums []int, offsets map[int]int64, xrefOffset int64) error {
+	num := wr.next
+	wr.next++
+	nums = append(nums, num)
+	offsets[num] = xrefOffset
+
+	var maxF2, maxF3 uint64
+	for _, n := range nums {
+		maxF2, maxF3 = max(maxF2, uint64(offsets[n])), max(maxF3, uint64(wr.gens[n]))
+	}
+	widths := [3]int{1, pdf.XRefFieldWidth(maxF2), pdf.XRefFieldWidth(maxF3)}
+	data := make([]byte, 0, len(nums)*(widths[0]+widths[1]+widths[2]))
+	for _, n := range nums {
+		data = pdf.AppendXRefEntry(data, widths, 1, uint64(offsets[n]), uint64(wr.gens[n]))
+	}
+	var index pdf.PDFArray
+	for _, run := range xrefRuns(nums) {
+		index = append(index, pdf.PDFInteger(run[0]), pdf.PDFInteger(run[1]))
+	}
+
+	xref := pdf.PDFDict{Entries: wr.trailerEntries(trailer, xrefOffset)}
+	xref.Entries["Type"] = pdf.PDFName{Value: "XRef"}
+	xref.Entries["Size"] = pdf.PDFInteger(wr.next)
+	xref.Entries["Prev"] = pdf.PDFInteger(prev)
+	xref.Entries["Index"] = index
+	xref.Entries["W"] = pdf.PDFArray{pdf.PDFInteger(widths[0]), pdf.PDFInteger(widths[1]), pdf.PDFInteger(widths[2])}
+	if err := SetStreamFlate(&xref, data); err != nil {
+		return err
+	}
+	if err := wr.writeIndirectObject(cw, num, xref); err != nil {
+		return fmt.Errorf("writer: cross-reference stream: %w", err)
+	}
+	return writeStartXRef(cw, xrefOffset)
+}
+
+// xrefRuns splits the ascending object numbers nums into runs of
+// consecutive numbers, each given as its first number and its length.
+func xrefRuns(nums []int) [][2]int {
+	var runs [][2]int
+	for _, num := range nums {
+		if n := len(runs); n > 0 && runs[n-1][0]+runs[n-1][1] == num {
+			runs[n-1][1]++
+			continue
+		}
+		runs = append(runs, [2]int{num, 1})
+	}
+	return runs
+}
+
+// unchanged reports whether v, read from base as the object ref, still
+// holds what base does: the same stream bytes and entries, its indirect
+// dicts the same base objects. A true "_dirty" entry marks it changed
+// regardless.
+func (wr *pdfWriter) unchanged(base *pdf.Reader, ref pdf.PDFRef, v pdf.PDFDict) bool {
+	if dirty, _ := v.Entries["_dirty"].(pdf.PDFBoolean); dirty {
+		return false
+	}
+	old, err := base.ResolveReference(ref)
+	od, ok := old.(pdf.PDFDict)
+	if err != nil || !ok || od.HasStream != v.HasStream || !bytes.Equal(od.RawStream, v.RawStream) {
+		return false
+	}
+	return wr.sameEntries(base, v.Entries, od.Entries, 0)
+}
+
+// sameEntries reports whether the entries of a graph dict match those of
+// the base dict old, bookkeeping keys aside.
+func (wr *pdfWriter) sameEntries(base *pdf.Reader, cur, old map[string]pdf.PDFValue, depth int) bool {
+	n := 0
+	for k, cv := range cur {
+		if k == "_ref" || k == "_dirty" {
+			continue
+		}
+		n++
+		ov, ok := old[k]
+		if !ok || !wr.sameValue(base, cv, ov, depth+1) {
+			return false
+		}
+	}
+	if _, ok := old["_ref"]; ok {
+		n++
+	}
+	return n == len(old)
+}
+
+// sameValue reports whether the graph value cur matches the base value
+// old. An indirect dict matches a reference to the base object it is; any
+// other reference in old is resolved first, as the graph resolved it in
+// place.
+func (wr *pdfWriter) sameValue(base *pdf.Reader, cur, old pdf.PDFValue, depth int) bool {
+	if depth > maxWriteDepth {
+		return false
+	}
+	if d, ok := cur.(pdf.PDFDict); ok && isIndirectDict(d) {
+		num := wr.numbers[wr.identityOf(d)]
+		ref, ok := old.(pdf.PDFRef)
+		return ok && ref.ObjNum == num && ref.GenNum == wr.gens[num]
+	}
+	if ref, ok := old.(pdf.PDFRef); ok {
+		resolved, err := base.ResolveReference(ref)
+		if _, isDict := resolved.(pdf.PDFDict); err != nil || isDict {
+			return false
+		}
+		old = resolved
+	}
+	switch c := cur.(type) {
+	case pdf.PDFDict:
+		o, ok := old.(pdf.PDFDict)
+		return ok && !o.HasStream && !c.HasStream && wr.sameEntries(base, c.Entries, o.Entries, depth)
+	case pdf.PDFArray:
+		o, ok := old.(pdf.PDFArray)
+		if !ok || len(o) != len(c) {
+			return false
+		}
+		for i := range c {
+			if !wr.sameValue(base, c[i], o[i], depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	return pdf.EqualPDFValue(cur, old)
+}
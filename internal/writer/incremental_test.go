@@ -0,0 +1,211 @@
+package writer
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// incrementalBase writes a one-page document with opts and opens it, its
+// graph resolved: the trailer and its page.
+func incrementalBase(t *testing.T, opts Options) ([]byte, *pdf.Reader, pdf.PDFDict, pdf.PDFDict) {
+	t.Helper()
+	page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 3},
+		"Type": pdf.PDFName{Value: "Page"},
+		"Contents": pdf.PDFDict{
+			Entries:   map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 4}},
+			HasStream: true,
+			RawStream: []byte("q\nQ\n"),
+		},
+	}}
+	pages := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 2}, "Type": pdf.PDFName{Value: "Pages"},
+		"Kids": pdf.PDFArray{page}, "Count": pdf.PDFInteger(1),
+	}}
+	page.Entries["Parent"] = pages
+	trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "Pages": pages,
+	}}}}
+	var buf bytes.Buffer
+	if _, err := WriteDocumentOptions(&buf, trailer, opts); err != nil {
+		t.Fatalf("WriteDocumentOptions: %v", err)
+	}
+
+	doc, err := pdf.OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	t.Cleanup(func() { doc.Close() })
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	return buf.Bytes(), doc, graph.(pdf.PDFDict), assertOnePageGraph(t, graph)
+}
+
+// readRevisions opens out and returns its revisions.
+func readRevisions(t *testing.T, out []byte) (*pdf.Reader, []pdf.Revision) {
+	t.Helper()
+	doc, err := pdf.OpenBytes(out)
+	if err != nil {
+		t.Fatalf("OpenBytes(output): %v", err)
+	}
+	t.Cleanup(func() { doc.Close() })
+	revs, err := doc.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	return doc, revs
+}
+
+// TestWriteIncremental checks that an incremental update keeps the base
+// bytes as a prefix and holds only the edited page and a new Info dict --
+// numbered past the base's even though conversion-style renumbering gave
+// it a base object's _ref -- chained to the base in the base's own
+// cross-reference format.
+func TestWriteIncremental(t *testing.T) {
+	for name, opts := range map[string]Options{"classic": {}, "xref stream": {ObjectStreams: true}} {
+		t.Run(name, func(t *testing.T) {
+			base, doc, trailer, page := incrementalBase(t, opts)
+			origins := Origins(trailer)
+			pageRef := origins[pdf.ValuePointer(page.Entries)]
+			NumberObjects(trailer)
+
+			page.Entries["Rotate"] = pdf.PDFInteger(90)
+			trailer.Entries["Info"] = pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"_ref": pdf.PDFRef{ObjNum: 1}, "Title": pdf.PDFString{Value: "Repaired"},
+			}}
+			var buf bytes.Buffer
+			objs, err := WriteIncremental(&buf, doc, trailer, origins)
+			if err != nil {
+				t.Fatalf("WriteIncremental: %v", err)
+			}
+			out := buf.Bytes()
+			if !bytes.HasPrefix(out, base) {
+				t.Fatal("output does not begin with the base bytes")
+			}
+
+			got, revs := readRevisions(t, out)
+			if len(revs) != 2 {
+				t.Fatalf("output has %d revisions, want 2", len(revs))
+			}
+			upd := revs[1]
+			if !slices.Equal(upd.Changed, []int{pageRef.ObjNum}) {
+				t.Errorf("update changed %v, want only the page, %d", upd.Changed, pageRef.ObjNum)
+			}
+			size := int(doc.EffectiveTrailer().Entries["Size"].(pdf.PDFInteger))
+			wantAdded := []int{size}
+			if opts.ObjectStreams {
+				wantAdded = append(wantAdded, size+1) // the cross-reference stream
+				if upd.Trailer.Entries["Type"] != (pdf.PDFName{Value: "XRef"}) {
+					t.Errorf("update trailer = %v, want a cross-reference stream", upd.Trailer)
+				}
+			}
+			if !slices.Equal(upd.Added, wantAdded) {
+				t.Errorf("update added %v, want %v", upd.Added, wantAdded)
+			}
+			if upd.Trailer.Entries["Prev"] != pdf.PDFInteger(doc.XRefOffset()) {
+				t.Errorf("update /Prev = %v, want %d", upd.Trailer.Entries["Prev"], doc.XRefOffset())
+			}
+
+			graph, err := got.ResolveGraph()
+			if err != nil {
+				t.Fatalf("ResolveGraph(output): %v", err)
+			}
+			readPage := assertOnePageGraph(t, graph)
+			assertContentStream(t, got, readPage, "q\nQ\n")
+			if readPage.Entries["Rotate"] != pdf.PDFInteger(90) {
+				t.Errorf("page /Rotate = %v, want 90", readPage.Entries["Rotate"])
+			}
+			info, _ := graph.(pdf.PDFDict).Entries["Info"].(pdf.PDFDict)
+			if info.Entries["Title"] != (pdf.PDFString{Value: "Repaired"}) {
+				t.Errorf("Info = %v, want the new Title", info)
+			}
+			for _, obj := range objs {
+				if ref := obj.Entries["_ref"].(pdf.PDFRef); ref.ObjNum == pageRef.ObjNum && pdf.ValuePointer(obj.Entries) != pdf.ValuePointer(page.Entries) {
+					t.Errorf("returned object %d is not the page", ref.ObjNum)
+				}
+			}
+		})
+	}
+}
+
+// TestWriteIncrementalUnchanged checks that an unedited graph appends an
+// update with no objects, and that a true _dirty entry forces its object
+// into the update.
+func TestWriteIncrementalUnchanged(t *testing.T) {
+	_, doc, trailer, _ := incrementalBase(t, Options{})
+	origins := Origins(trailer)
+	var buf bytes.Buffer
+	if _, err := WriteIncremental(&buf, doc, trailer, origins); err != nil {
+		t.Fatalf("WriteIncremental: %v", err)
+	}
+	if _, revs := readRevisions(t, buf.Bytes()); len(revs) != 2 || revs[1].Added != nil || revs[1].Changed != nil {
+		t.Errorf("unedited update = %+v, want one listing no objects", revs[len(revs)-1])
+	}
+
+	root := trailer.Entries["Root"].(pdf.PDFDict)
+	root.Entries["_dirty"] = pdf.PDFBoolean(true)
+	buf.Reset()
+	if _, err := WriteIncremental(&buf, doc, trailer, origins); err != nil {
+		t.Fatalf("WriteIncremental(_dirty): %v", err)
+	}
+	rootNum := origins[pdf.ValuePointer(root.Entries)].ObjNum
+	if _, revs := readRevisions(t, buf.Bytes()); !slices.Equal(revs[len(revs)-1].Changed, []int{rootNum}) {
+		t.Errorf("_dirty update changed %v, want [%d]", revs[len(revs)-1].Changed, rootNum)
+	}
+}
+
+// TestWriteIncrementalLeadingBytes checks an update to a base with bytes
+// before its header and a startxref counting from byte 0 rather than from
+// the header, which the reader only follows by falling back: the update's
+// /Prev must still reach that section, so the output's revisions can be
+// read and updated again.
+func TestWriteIncrementalLeadingBytes(t *testing.T) {
+	clean, _, _, _ := incrementalBase(t, Options{})
+	junk := "junk before the header\n"
+	base := []byte(junk)
+	base = append(base, regexp.MustCompile(`startxref\n\d+`).ReplaceAllFunc(clean, func(m []byte) []byte {
+		n, _ := strconv.Atoi(string(m[len("startxref\n"):]))
+		return []byte("startxref\n" + strconv.Itoa(n+len(junk)))
+	})...)
+
+	out := base
+	for rev := 2; rev <= 3; rev++ {
+		doc, err := pdf.OpenBytes(out)
+		if err != nil {
+			t.Fatalf("OpenBytes(revision %d): %v", rev-1, err)
+		}
+		defer doc.Close()
+		graph, err := doc.ResolveGraph()
+		if err != nil {
+			t.Fatalf("ResolveGraph(revision %d): %v", rev-1, err)
+		}
+		trailer := graph.(pdf.PDFDict)
+		page := assertOnePageGraph(t, graph)
+		origins := Origins(trailer)
+		page.Entries["Rotate"] = pdf.PDFInteger(90 * (rev - 1))
+
+		var buf bytes.Buffer
+		if _, err := WriteIncremental(&buf, doc, trailer, origins); err != nil {
+			t.Fatalf("WriteIncremental(revision %d): %v", rev, err)
+		}
+		out = buf.Bytes()
+		got, revs := readRevisions(t, out)
+		if len(revs) != rev {
+			t.Fatalf("output has %d revisions, want %d", len(revs), rev)
+		}
+		graph, err = got.ResolveGraph()
+		if err != nil {
+			t.Fatalf("ResolveGraph(output): %v", err)
+		}
+		if r := assertOnePageGraph(t, graph).Entries["Rotate"]; r != pdf.PDFInteger(90*(rev-1)) {
+			t.Errorf("revision %d page /Rotate = %v, want %d", rev, r, 90*(rev-1))
+		}
+	}
+}
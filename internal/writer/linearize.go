@@ -0,0 +1,590 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"strconv"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// linearLayout places the discovered objects in the parts of a linearized
+// file (ISO 32000-1 Annex F.3), each part in file order: the document-level
+// objects (part 4), the first page's (part 6), each further page's own
+// (part 7), those shared by several further pages (part 8) and the rest
+// (part 9). sharedRefs lists, for each further page, the entries of the
+// shared object hint table it uses.
+type linearLayout struct {
+	numPages   int
+	docLevel   []pdf.PDFDict
+	firstPage  []pdf.PDFDict
+	pages      [][]pdf.PDFDict
+	shared     []pdf.PDFDict
+	other      []pdf.PDFDict
+	sharedRefs [][]int
+}
+
+// documentLevelKeys are the catalog entries whose objects a viewer needs
+// before the first page, placed with the catalog (ISO 32000-1 F.3.4).
+var documentLevelKeys = []string{"AcroForm", "OpenAction", "Threads", "ViewerPreferences"}
+
+// linearize sorts wr.order into the parts of a linearized file.
+func (wr *pdfWriter) linearize(trailer pdf.PDFDict) linearLayout {
+	var lay linearLayout
+	placed := map[objectIdentity]bool{}
+	place := func(part *[]pdf.PDFDict, obj pdf.PDFDict) {
+		id := wr.identityOf(obj)
+		if !placed[id] {
+			placed[id] = true
+			*part = append(*part, obj)
+		}
+	}
+
+	if root, ok := trailer.Entries["Root"].(pdf.PDFDict); ok && isIndirectDict(root) {
+		place(&lay.docLevel, root)
+		for _, k := range documentLevelKeys {
+			if d, ok := root.Entries[k].(pdf.PDFDict); ok && isIndirectDict(d) {
+				place(&lay.docLevel, d)
+			}
+		}
+	}
+
+	pages := linearPages(trailer)
+	lay.numPages = len(pages)
+	if len(pages) > 0 {
+		for _, obj := range wr.pageObjects(pages[0]) {
+			place(&lay.firstPage, obj)
+		}
+		// The first page's ancestors carry the attributes it inherits.
+		seen := map[uintptr]bool{}
+		for node, ok := pages[0].Entries["Parent"].(pdf.PDFDict); ok && !seen[pdf.ValuePointer(node.Entries)]; node, ok = node.Entries["Parent"].(pdf.PDFDict) {
+			seen[pdf.ValuePointer(node.Entries)] = true
+			if isIndirectDict(node) {
+				place(&lay.firstPage, node)
+			}
+		}
+	}
+
+	// Further pages: an object only one of them uses is its own, one
+	// several use is shared.
+	reach := make([][]pdf.PDFDict, len(pages))
+	users := map[objectIdentity]int{}
+	for i := 1; i < len(pages); i++ {
+		reach[i] = wr.pageObjects(pages[i])
+		for _, obj := range reach[i] {
+			users[wr.identityOf(obj)]++
+		}
+	}
+	for i := 1; i < len(pages); i++ {
+		var own []pdf.PDFDict
+		for _, obj := range reach[i] {
+			if users[wr.identityOf(obj)] == 1 {
+				place(&own, obj)
+			}
+		}
+		lay.pages = append(lay.pages, own)
+	}
+	for i := 1; i < len(pages); i++ {
+		for _, obj := range reach[i] {
+			place(&lay.shared, obj)
+		}
+	}
+	for _, obj := range wr.order {
+		place(&lay.other, obj)
+	}
+
+	// The shared object hint table lists the first page's objects, then
+	// the shared ones; a further page refers to those it uses by index.
+	entry := map[objectIdentity]int{}
+	for i, obj := range slices.Concat(lay.firstPage, lay.shared) {
+		entry[wr.identityOf(obj)] = i
+	}
+	for i := 1; i < len(pages); i++ {
+		var refs []int
+		for _, obj := range reach[i] {
+			if e, ok := entry[wr.identityOf(obj)]; ok {
+				refs = append(refs, e)
+			}
+		}
+		slices.Sort(refs)
+		lay.sharedRefs = append(lay.sharedRefs, slices.Compact(refs))
+	}
+	return lay
+}
+
+// linearPages returns the page objects of trailer's page tree in order.
+func linearPages(trailer pdf.PDFDict) []pdf.PDFDict {
+	root, _ := trailer.Entries["Root"].(pdf.PDFDict)
+	var out []pdf.PDFDict
+	seen := map[uintptr]bool{}
+	var walk func(node pdf.PDFDict)
+	walk = func(node pdf.PDFDict) {
+		if node.Entries == nil || seen[pdf.ValuePointer(node.Entries)] {
+			return
+		}
+		seen[pdf.ValuePointer(node.Entries)] = true
+		kids, ok := node.Entries["Kids"].(pdf.PDFArray)
+		if !ok {
+			if isIndirectDict(node) {
+				out = append(out, node)
+			}
+			return
+		}
+		for _, kid := range kids {
+			if kd, ok := kid.(pdf.PDFDict); ok {
+				walk(kd)
+			}
+		}
+	}
+	pages, _ := root.Entries["Pages"].(pdf.PDFDict)
+	walk(pages)
+	return out
+}
+
+// pageObjects returns the indirect objects reachable from page, page
+// first, without passing into another page, a page tree node or the
+// catalog.
+func (wr *pdfWriter) pageObjects(page pdf.PDFDict) []pdf.PDFDict {
+	out := []pdf.PDFDict{page}
+	visited := map[uintptr]bool{pdf.ValuePointer(page.Entries): true}
+	var walk func(v pdf.PDFValue, depth int)
+	walk = func(v pdf.PDFValue, depth int) {
+		if depth > maxWriteDepth {
+			return
+		}
+		switch val := v.(type) {
+		case pdf.PDFDict:
+			ptr := pdf.ValuePointer(val.Entries)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			switch val.Entries["Type"] {
+			case pdf.PDFName{Value: "Page"}, pdf.PDFName{Value: "Pages"}, pdf.PDFName{Value: "Catalog"}:
+				return
+			}
+			if isIndirectDict(val) {
+				out = append(out, val)
+			}
+			wr.walkEntries(val.Entries, func(child pdf.PDFValue) { walk(child, depth+1) })
+		case pdf.PDFArray:
+			ptr := pdf.ValuePointer(val)
+			if visited[ptr] {
+				return
+			}
+			visited[ptr] = true
+			for _, child := range val {
+				walk(child, depth+1)
+			}
+		}
+	}
+	wr.walkEntries(page.Entries, func(child pdf.PDFValue) {
+		walk(child, 1)
+	})
+	return out
+}
+
+// walkEntries calls fn on each of entries' values in sorted key order,
+// skipping /Parent, which leads up the page tree or annotation hierarchy
+// rather than to what the dictionary uses.
+func (wr *pdfWriter) walkEntries(entries map[string]pdf.PDFValue, fn func(pdf.PDFValue)) {
+	base := len(wr.keyScratch)
+	defer func() { wr.keyScratch = wr.keyScratch[:base] }()
+	for _, k := range wr.sortedEntryKeys(entries) {
+		if k != "Parent" {
+			fn(entries[k])
+		}
+	}
+}
+
+// offsetDigits is the width reserved for each offset written before the
+// objects it locates, padded with spaces once the value is known.
+const offsetDigits = 10
+
+// writeLinearized writes the discovered objects as a linearized file
+// (ISO 32000-1 Annex F) after the header cw has already received: the
+// linearization parameter dictionary, the first-page cross-reference
+// section, the document-level objects, the primary hint stream and the
+// first page, then the further pages, the shared objects, the rest and the
+// main cross-reference section. The main section numbers the objects after
+// the first page from 1; the first-page section follows on from it.
+func (wr *pdfWriter) writeLinearized(cw *countingWriter, trailer pdf.PDFDict) error {
+	lay := wr.linearize(trailer)
+	mainObjs := slices.Concat(slices.Concat(lay.pages...), lay.shared, lay.other)
+	mainSize := len(mainObjs) + 1
+	for i, obj := range mainObjs {
+		wr.numbers[wr.identityOf(obj)] = i + 1
+	}
+	linNum := mainSize
+	num := linNum + 1
+	for _, obj := range lay.docLevel {
+		wr.numbers[wr.identityOf(obj)] = num
+		num++
+	}
+	hintNum := num
+	num++
+	for _, obj := range lay.firstPage {
+		wr.numbers[wr.identityOf(obj)] = num
+		num++
+	}
+	size := num
+
+	lengths := map[int]int64{}
+	measure := func(objs []pdf.PDFDict) error {
+		for _, obj := range objs {
+			n := wr.numbers[wr.identityOf(obj)]
+			mc := &countingWriter{w: io.Discard}
+			if err := wr.writeIndirectObject(mc, n, obj); err != nil {
+				return fmt.Errorf("writer: object %d: %w", n, err)
+			}
+			lengths[n] = mc.n
+		}
+		return nil
+	}
+	if err := measure(lay.docLevel); err != nil {
+		return err
+	}
+	if err := measure(lay.firstPage); err != nil {
+		return err
+	}
+	if err := measure(mainObjs); err != nil {
+		return err
+	}
+
+	entries := wr.trailerEntries(trailer, 0)
+	placeholder := pdf.PDFInteger(1)
+	for range offsetDigits - 1 {
+		placeholder = placeholder*10 + 9
+	}
+	linEntries := func(l, hOff, hLen, o, e, n, t pdf.PDFInteger) map[string]pdf.PDFValue {
+		return map[string]pdf.PDFValue{
+			"Linearized": pdf.PDFInteger(1), "L": l, "H": pdf.PDFArray{hOff, hLen},
+			"O": o, "E": e, "N": n, "T": t,
+		}
+	}
+	fpTrailer := func(prev pdf.PDFInteger) map[string]pdf.PDFValue {
+		out := map[string]pdf.PDFValue{"Size": pdf.PDFInteger(size), "Prev": prev}
+		for k, v := range entries {
+			out[k] = v
+		}
+		return out
+	}
+	linObj, err := wr.paddedObject(linNum, linEntries(placeholder, placeholder, placeholder, placeholder, placeholder, placeholder, placeholder), 0)
+	if err != nil {
+		return err
+	}
+	fpTrailerBytes, err := wr.paddedDict(fpTrailer(placeholder), 0)
+	if err != nil {
+		return err
+	}
+	fpCount := size - linNum
+	fpXRefLen := int64(len("xref\n"+strconv.Itoa(linNum)+" "+strconv.Itoa(fpCount)+"\n")) + 20*int64(fpCount) +
+		int64(len("trailer\n")+len(fpTrailerBytes)+len("\nstartxref\n0\n%%EOF\n"))
+
+	// Lay the file out without the hint stream, whose tables give offsets
+	// as though it were absent (ISO 32000-1 F.4).
+	offsets := map[int]int64{}
+	pos := cw.n
+	offsets[linNum] = pos
+	pos += int64(len(linObj))
+	fpXRef := pos
+	pos += fpXRefLen
+	place := func(objs []pdf.PDFDict) {
+		for _, obj := range objs {
+			n := wr.numbers[wr.identityOf(obj)]
+			offsets[n] = pos
+			pos += lengths[n]
+		}
+	}
+	place(lay.docLevel)
+	hintAt := pos
+	place(lay.firstPage)
+	endFirstPage := pos
+	place(mainObjs)
+	hint, err := wr.hintStream(lay, offsets, lengths)
+	if err != nil {
+		return err
+	}
+	hintObj := &bytes.Buffer{}
+	if err := wr.writeIndirectObject(&countingWriter{w: hintObj}, hintNum, hint); err != nil {
+		return fmt.Errorf("writer: hint stream: %w", err)
+	}
+	hintLen := int64(hintObj.Len())
+	for n, off := range offsets {
+		if off >= hintAt {
+			offsets[n] = off + hintLen
+		}
+	}
+	offsets[hintNum] = hintAt
+	endFirstPage += hintLen
+	mainXRef := pos + hintLen
+	mainHeader := "xref\n0 " + strconv.Itoa(mainSize) + "\n"
+	mainTrailer := map[string]pdf.PDFValue{"Size": pdf.PDFInteger(mainSize)}
+	if id, ok := entries["ID"]; ok {
+		mainTrailer["ID"] = id
+	}
+	mainTrailerBytes, err := wr.paddedDict(mainTrailer, 0)
+	if err != nil {
+		return err
+	}
+	fileLen := mainXRef + int64(len(mainHeader)) + 20*int64(mainSize) +
+		int64(len("trailer\n")+len(mainTrailerBytes)+len("\nstartxref\n")+len(strconv.FormatInt(fpXRef, 10))+len("\n%%EOF"))
+
+	firstPageNum := pdf.PDFInteger(0)
+	if len(lay.firstPage) > 0 {
+		firstPageNum = pdf.PDFInteger(wr.numbers[wr.identityOf(lay.firstPage[0])])
+	}
+	linObj, err = wr.paddedObject(linNum, linEntries(
+		pdf.PDFInteger(fileLen), pdf.PDFInteger(hintAt), pdf.PDFInteger(hintLen), firstPageNum,
+		pdf.PDFInteger(endFirstPage), pdf.PDFInteger(lay.numPages),
+		pdf.PDFInteger(mainXRef+int64(len(mainHeader))-1)), len(linObj))
+	if err != nil {
+		return err
+	}
+	if fpTrailerBytes, err = wr.paddedDict(fpTrailer(pdf.PDFInteger(mainXRef)), len(fpTrailerBytes)); err != nil {
+		return err
+	}
+
+	// Write it all out as laid.
+	if _, err := cw.Write(linObj); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(cw, "xref\n"+strconv.Itoa(linNum)+" "+strconv.Itoa(fpCount)+"\n"); err != nil {
+		return err
+	}
+	for n := linNum; n < size; n++ {
+		if err := writeXRefEntry(cw, offsets[n], 0); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(cw, "trailer\n"); err != nil {
+		return err
+	}
+	if _, err := cw.Write(fpTrailerBytes); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(cw, "\nstartxref\n0\n%%EOF\n"); err != nil {
+		return err
+	}
+	write := func(objs []pdf.PDFDict) error {
+		for _, obj := range objs {
+			n := wr.numbers[wr.identityOf(obj)]
+			if cw.n != offsets[n] {
+				return fmt.Errorf("writer: object %d at offset %d, laid out at %d", n, cw.n, offsets[n])
+			}
+			if err := wr.writeIndirectObject(cw, n, obj); err != nil {
+				return fmt.Errorf("writer: object %d: %w", n, err)
+			}
+		}
+		return nil
+	}
+	if err := write(lay.docLevel); err != nil {
+		return err
+	}
+	if _, err := cw.Write(hintObj.Bytes()); err != nil {
+		return err
+	}
+	if err := write(lay.firstPage); err != nil {
+		return err
+	}
+	if err := write(mainObjs); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(cw, mainHeader+"0000000000 65535 f \n"); err != nil {
+		return err
+	}
+	for n := 1; n < mainSize; n++ {
+		if err := writeXRefEntry(cw, offsets[n], 0); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(cw, "trailer\n"); err != nil {
+		return err
+	}
+	if _, err := cw.Write(mainTrailerBytes); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(cw, "\n"); err != nil {
+		return err
+	}
+	return writeStartXRef(cw, fpXRef)
+}
+
+// paddedDict renders entries as a dictionary, padded with spaces to width
+// bytes when that is longer.
+func (wr *pdfWriter) paddedDict(entries map[string]pdf.PDFValue, width int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wr.writeDictEntries(&countingWriter{w: &buf}, entries); err != nil {
+		return nil, err
+	}
+	if pad := width - buf.Len(); pad > 0 {
+		buf.Write(bytes.Repeat([]byte{' '}, pad))
+	}
+	return buf.Bytes(), nil
+}
+
+// paddedObject renders the indirect object num holding entries, padded
+// with spaces before endobj to width bytes when that is longer.
+func (wr *pdfWriter) paddedObject(num int, entries map[string]pdf.PDFValue, width int) ([]byte, error) {
+	head := strconv.Itoa(num) + " 0 obj\n"
+	const tail = "\nendobj\n"
+	dict, err := wr.paddedDict(entries, width-len(head)-len(tail))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(head + string(dict) + tail), nil
+}
+
+// hintStream builds the primary hint stream: the page offset hint table,
+// then the shared object hint table at /S (ISO 32000-1 F.4.1, F.4.2).
+// offsets locate every object as though the stream were absent, as its
+// tables do, and lengths give each object's size in bytes.
+func (wr *pdfWriter) hintStream(lay linearLayout, offsets, lengths map[int]int64) (pdf.PDFDict, error) {
+	numOf := func(obj pdf.PDFDict) int { return wr.numbers[wr.identityOf(obj)] }
+	size := func(objs []pdf.PDFDict) int64 {
+		var n int64
+		for _, obj := range objs {
+			n += lengths[numOf(obj)]
+		}
+		return n
+	}
+
+	// Each page's object count, length and shared object references; the
+	// first page's objects are its own.
+	var counts, lens []int64
+	var refs [][]int
+	if lay.numPages > 0 {
+		counts, lens, refs = []int64{int64(len(lay.firstPage))}, []int64{size(lay.firstPage)}, [][]int{nil}
+	}
+	for i, objs := range lay.pages {
+		counts = append(counts, int64(len(objs)))
+		lens = append(lens, size(objs))
+		refs = append(refs, lay.sharedRefs[i])
+	}
+	var leastCount, leastLen int64
+	if len(counts) > 0 {
+		leastCount, leastLen = slices.Min(counts), slices.Min(lens)
+	}
+	var maxCount, maxLen, maxRefs, maxID uint64
+	for i := range counts {
+		maxCount = max(maxCount, uint64(counts[i]-leastCount))
+		maxLen = max(maxLen, uint64(lens[i]-leastLen))
+		maxRefs = max(maxRefs, uint64(len(refs[i])))
+		for _, id := range refs[i] {
+			maxID = max(maxID, uint64(id))
+		}
+	}
+	countBits, lenBits := bits.Len64(maxCount), bits.Len64(maxLen)
+	refBits, idBits := bits.Len64(maxRefs), bits.Len64(maxID)
+	var firstPageAt int64
+	if len(lay.firstPage) > 0 {
+		firstPageAt = offsets[numOf(lay.firstPage[0])]
+	}
+
+	var b bitWriter
+	b.write(uint64(leastCount), 32)
+	b.write(uint64(firstPageAt), 32)
+	b.write(uint64(countBits), 16)
+	b.write(uint64(leastLen), 32)
+	b.write(uint64(lenBits), 16)
+	// Content streams are given as spanning the whole page, as qpdf writes
+	// them: viewers find them through the page object, not these fields.
+	b.write(0, 32)
+	b.write(0, 16)
+	b.write(uint64(leastLen), 32)
+	b.write(uint64(lenBits), 16)
+	b.write(uint64(refBits), 16)
+	b.write(uint64(idBits), 16)
+	b.write(0, 16) // no fractional positions
+	b.write(1, 16)
+	// Each item is written for every page in turn, starting on a byte
+	// boundary.
+	for i := range counts {
+		b.write(uint64(counts[i]-leastCount), countBits)
+	}
+	b.flush()
+	for i := range lens {
+		b.write(uint64(lens[i]-leastLen), lenBits)
+	}
+	b.flush()
+	for i := range refs {
+		b.write(uint64(len(refs[i])), refBits)
+	}
+	b.flush()
+	for i := range refs {
+		for _, id := range refs[i] {
+			b.write(uint64(id), idBits)
+		}
+	}
+	b.flush()
+	for i := range lens {
+		b.write(uint64(lens[i]-leastLen), lenBits)
+	}
+	b.flush()
+	sharedAt := len(b.buf)
+
+	// One group per object: the first page's, then the shared objects
+	// section's.
+	var groups []int64
+	for _, obj := range slices.Concat(lay.firstPage, lay.shared) {
+		groups = append(groups, lengths[numOf(obj)])
+	}
+	var sharedNum int
+	var sharedAtOffset, leastGroup int64
+	if len(lay.shared) > 0 {
+		sharedNum = numOf(lay.shared[0])
+		sharedAtOffset = offsets[sharedNum]
+	}
+	if len(groups) > 0 {
+		leastGroup = slices.Min(groups)
+	}
+	groupBits := bits.Len64(uint64(slices.Max(append(groups, leastGroup)) - leastGroup))
+	b.write(uint64(sharedNum), 32)
+	b.write(uint64(sharedAtOffset), 32)
+	b.write(uint64(len(lay.firstPage)), 32)
+	b.write(uint64(len(groups)), 32)
+	b.write(0, 16) // every group is one object
+	b.write(uint64(leastGroup), 32)
+	b.write(uint64(groupBits), 16)
+	for _, g := range groups {
+		b.write(uint64(g-leastGroup), groupBits)
+	}
+	b.flush()
+	for range groups {
+		b.write(0, 1) // no MD5 signatures
+	}
+	b.flush()
+
+	hint := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"S": pdf.PDFInteger(sharedAt)}}
+	return hint, SetStreamFlate(&hint, b.buf)
+}
+
+// bitWriter packs values most significant bit first, as hint tables are.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits int
+}
+
+// write appends the low n bits of v.
+func (b *bitWriter) write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.acc = b.acc<<1 | (v>>i)&1
+		if b.nbits++; b.nbits == 8 {
+			b.buf = append(b.buf, byte(b.acc))
+			b.acc, b.nbits = 0, 0
+		}
+	}
+}
+
+// flush pads the last partial byte with zero bits, so the next table item
+// starts on a byte boundary.
+func (b *bitWriter) flush() {
+	if b.nbits > 0 {
+		b.write(0, 8-b.nbits)
+	}
+}
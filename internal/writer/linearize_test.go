@@ -0,0 +1,183 @@
+package writer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/voidrab/gopdfrab/internal/pdf"
+)
+
+// linearizeTestGraph returns a three-page document: an image the first two
+// pages draw, a font the last two share, and a content stream per page.
+func linearizeTestGraph() pdf.PDFDict {
+	stream := func(ref int, data string) pdf.PDFDict {
+		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: ref}}, HasStream: true, RawStream: []byte(data)}
+	}
+	image := stream(20, "image")
+	image.Entries["Type"] = pdf.PDFName{Value: "XObject"}
+	font := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 21}, "Type": pdf.PDFName{Value: "Font"}, "Subtype": pdf.PDFName{Value: "Type1"},
+		"BaseFont": pdf.PDFName{Value: "Helvetica"},
+	}}
+	pages := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"_ref": pdf.PDFRef{ObjNum: 2}, "Type": pdf.PDFName{Value: "Pages"}, "Count": pdf.PDFInteger(3),
+		"MediaBox": pdf.PDFArray{pdf.PDFInteger(0), pdf.PDFInteger(0), pdf.PDFInteger(612), pdf.PDFInteger(792)},
+	}}
+	var kids pdf.PDFArray
+	for i, res := range []map[string]pdf.PDFValue{
+		{"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Im0": image}}},
+		{"XObject": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Im0": image}}, "Font": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F0": font}}},
+		{"Font": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"F0": font}}},
+	} {
+		kids = append(kids, pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 10 + i}, "Type": pdf.PDFName{Value: "Page"}, "Parent": pages,
+			"Resources": pdf.PDFDict{Entries: res},
+			"Contents":  stream(30+i, fmt.Sprintf("page %d", i+1)),
+		}})
+	}
+	pages.Entries["Kids"] = kids
+	return pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "Pages": pages}},
+		"Info": pdf.PDFDict{Entries: map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 40}, "Title": pdf.PDFString{Value: "Linearized"}}},
+	}}
+}
+
+// TestWriteDocumentLinearized checks a linearized file's layout against
+// its parameter dictionary: /L the file length, /O the first page, /E past
+// the first page's objects and before every other page's, /T at the main
+// table's first entry, /H the hint stream, whose page offset table places
+// the first page as though the stream were absent; and that the file reads
+// back as one revision with its pages intact.
+func TestWriteDocumentLinearized(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteDocumentOptions(&buf, linearizeTestGraph(), Options{Linearize: true, ObjectStreams: true}); err != nil {
+		t.Fatalf("WriteDocumentOptions: %v", err)
+	}
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Errorf("header = %q, want %%PDF-1.4", out[:9])
+	}
+	m := regexp.MustCompile(`^%PDF-1\.4\n[^\n]*\n(\d+) 0 obj\n<<[^>]*/Linearized 1`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("first object is not the linearization dictionary: %q", out[:min(len(out), 200)])
+	}
+
+	doc, err := pdf.OpenBytes(out)
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	var linNum int
+	fmt.Sscan(string(m[1]), &linNum)
+	v, err := doc.ResolveReference(pdf.PDFRef{ObjNum: linNum})
+	if err != nil {
+		t.Fatalf("ResolveReference(%d): %v", linNum, err)
+	}
+	lin := v.(pdf.PDFDict).Entries
+	param := func(k string) int64 { n, _ := lin[k].(pdf.PDFInteger); return int64(n) }
+
+	if param("L") != int64(len(out)) {
+		t.Errorf("/L = %d, want the file length %d", param("L"), len(out))
+	}
+	if param("N") != 3 {
+		t.Errorf("/N = %d, want 3", param("N"))
+	}
+	refs, _, err := doc.PageRefs()
+	if err != nil || len(refs) != 3 {
+		t.Fatalf("PageRefs = %v, %v; want 3 pages", refs, err)
+	}
+	if param("O") != int64(refs[0].ObjNum) {
+		t.Errorf("/O = %d, want the first page, %d", param("O"), refs[0].ObjNum)
+	}
+	table := doc.XRefTable()
+	end := param("E")
+	if table[refs[0].ObjNum] >= end || table[refs[1].ObjNum] < end || table[refs[2].ObjNum] < end {
+		t.Errorf("/E = %d does not split page 1 (at %d) from pages 2 and 3 (at %d, %d)",
+			end, table[refs[0].ObjNum], table[refs[1].ObjNum], table[refs[2].ObjNum])
+	}
+	if tpos := param("T"); !bytes.HasPrefix(out[tpos:], []byte("\n0000000000 65535 f \n")) {
+		t.Errorf("/T = %d points at %q, want the end of the main table's header", tpos, out[tpos:tpos+21])
+	}
+
+	h, _ := lin["H"].(pdf.PDFArray)
+	if len(h) != 2 {
+		t.Fatalf("/H = %v, want [offset length]", lin["H"])
+	}
+	hintAt, hintLen := int64(h[0].(pdf.PDFInteger)), int64(h[1].(pdf.PDFInteger))
+	hm := regexp.MustCompile(`^(\d+) 0 obj\n`).FindSubmatch(out[hintAt:])
+	if hm == nil || !bytes.HasSuffix(out[:hintAt+hintLen], []byte("endobj\n")) {
+		t.Fatalf("/H [%d %d] does not span an object", hintAt, hintLen)
+	}
+	var hintNum int
+	fmt.Sscan(string(hm[1]), &hintNum)
+	hv, err := doc.ResolveReference(pdf.PDFRef{ObjNum: hintNum})
+	if err != nil {
+		t.Fatalf("ResolveReference(hint %d): %v", hintNum, err)
+	}
+	hint := hv.(pdf.PDFDict)
+	zr, err := zlib.NewReader(bytes.NewReader(hint.RawStream))
+	if err != nil {
+		t.Fatalf("hint stream: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if s, _ := hint.Entries["S"].(pdf.PDFInteger); s <= 0 || int(s) >= len(data) {
+		t.Errorf("hint /S = %v outside the %d-byte stream", hint.Entries["S"], len(data))
+	}
+	if got := int64(binary.BigEndian.Uint32(data[4:])); got != table[refs[0].ObjNum]-hintLen {
+		t.Errorf("hint table locates page 1 at %d, want %d less the hint stream", got, table[refs[0].ObjNum])
+	}
+
+	revs, err := doc.Revisions()
+	if err != nil || len(revs) != 1 {
+		t.Errorf("Revisions = %d, %v; want one revision", len(revs), err)
+	}
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	kids := graph.(pdf.PDFDict).Entries["Root"].(pdf.PDFDict).Entries["Pages"].(pdf.PDFDict).Entries["Kids"].(pdf.PDFArray)
+	for i, kid := range kids {
+		assertContentStream(t, doc, kid.(pdf.PDFDict), fmt.Sprintf("page %d", i+1))
+	}
+}
+
+// TestLinearizeLayout checks which part each object of the test document
+// lands in: the image drawn by page 1 with page 1, the font pages 2 and 3
+// share in the shared objects section, and the Info dict with the rest.
+func TestLinearizeLayout(t *testing.T) {
+	trailer := linearizeTestGraph()
+	wr := &pdfWriter{numbers: map[objectIdentity]int{}, visited: map[uintptr]bool{}}
+	wr.discover(trailer.Entries["Root"])
+	wr.discover(trailer.Entries["Info"])
+	lay := wr.linearize(trailer)
+
+	refs := func(objs []pdf.PDFDict) []int {
+		var out []int
+		for _, obj := range objs {
+			out = append(out, obj.Entries["_ref"].(pdf.PDFRef).ObjNum)
+		}
+		return out
+	}
+	want := map[string][2][]int{
+		"document-level": {refs(lay.docLevel), {1}},
+		"first page":     {refs(lay.firstPage), {10, 30, 20, 2}},
+		"page 2":         {refs(lay.pages[0]), {11, 31}},
+		"page 3":         {refs(lay.pages[1]), {12, 32}},
+		"shared":         {refs(lay.shared), {21}},
+		"other":          {refs(lay.other), {40}},
+	}
+	for part, got := range want {
+		if fmt.Sprint(got[0]) != fmt.Sprint(got[1]) {
+			t.Errorf("%s = %v, want %v", part, got[0], got[1])
+		}
+	}
+	// Page 2 uses the image (first-page entry 2) and the font (entry 4).
+	if fmt.Sprint(lay.sharedRefs) != "[[2 4] [4]]" {
+		t.Errorf("sharedRefs = %v, want [[2 4] [4]]", lay.sharedRefs)
+	}
+}
@@ -32,9 +32,45 @@ func WritePDF(r *pdf.Reader, w io.Writer) error {
 	return WriteDocument(w, trailer)
 }
 
+// Options controls serialization details of WriteDocumentOptions. The zero
+// value writes exactly what WriteDocument does.
+type Options struct {
+	// Version is the PDF version the file header declares, e.g. "2.0" for
+	// PDF/A-4 output. Empty means "1.4", or "1.5" with ObjectStreams
+	// unless Linearize.
+	Version string
+	// ObjectStreams packs every indirect object that is not a stream into
+	// compressed object streams and writes a cross-reference stream in
+	// place of the classic table and trailer (ISO 32000-1 7.5.7, 7.5.8),
+	// which PDF 1.5 introduced.
+	ObjectStreams bool
+	// Linearize writes a linearized file (ISO 32000-1 Annex F), which a
+	// viewer fetching it by HTTP range requests can display the first page
+	// of before the rest arrives: the linearization parameter dictionary,
+	// a first-page cross-reference section, the catalog, a hint stream
+	// locating every page's objects and the first page's objects lead the
+	// file. It is written with classic cross-reference tables and no
+	// object streams, so ObjectStreams is then ignored.
+	Linearize bool
+}
+
 // WriteDocumentIndexed serializes a fully-resolved PDF object graph to w and
 // returns the ordered slice of indirect objects as written.
 func WriteDocumentIndexed(w io.Writer, trailer pdf.PDFDict) (objs []pdf.PDFDict, err error) {
+	return WriteDocumentOptions(w, trailer, Options{})
+}
+
+// WriteDocumentOptions is WriteDocumentIndexed with the serialization
+// controlled by opts. Each returned object's _ref holds its output number,
+// which is its position in the slice plus one unless opts.Linearize.
+func WriteDocumentOptions(w io.Writer, trailer pdf.PDFDict, opts Options) (objs []pdf.PDFDict, err error) {
+	version := opts.Version
+	if version == "" {
+		version = "1.4"
+		if opts.ObjectStreams && !opts.Linearize {
+			version = "1.5"
+		}
+	}
 	wr := &pdfWriter{
 		numbers: map[objectIdentity]int{},
 		visited: map[uintptr]bool{},
@@ -53,40 +89,195 @@ func WriteDocumentIndexed(w io.Writer, trailer pdf.PDFDict) (objs []pdf.PDFDict,
 
 	// 6.1.2: version line followed by a binary-marker comment line (at least
 	// four bytes, each > 127) on its own line.
-	if _, err = io.WriteString(cw, "%PDF-1.4\n%\xc2\xb5\xc2\xb6\xc2\xb7\xc2\xb8\n"); err != nil {
+	if _, err = io.WriteString(cw, "%PDF-"+version+"\n%\xc2\xb5\xc2\xb6\xc2\xb7\xc2\xb8\n"); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.Linearize:
+		err = wr.writeLinearized(cw, trailer)
+	case opts.ObjectStreams:
+		err = wr.writeObjectStreams(cw, trailer)
+	default:
+		err = wr.writeXRefTable(cw, trailer)
+	}
+	if err != nil {
 		return nil, err
 	}
 
+	// Rewrite each dict's _ref to its assigned output object number so the
+	// in-memory graph's numbering matches the serialized output.
+	nums := make([]int, len(wr.order))
+	for i, obj := range wr.order {
+		nums[i] = wr.numbers[wr.identityOf(obj)]
+	}
+	for i, obj := range wr.order {
+		obj.Entries["_ref"] = pdf.PDFRef{ObjNum: nums[i]}
+		wr.order[i] = obj
+	}
+
+	return wr.order, nil
+}
+
+// writeXRefTable writes every discovered object uncompressed, then a
+// classic cross-reference table and trailer.
+func (wr *pdfWriter) writeXRefTable(cw *countingWriter, trailer pdf.PDFDict) error {
 	offsets := make([]int64, len(wr.order)+1) // index 0 (the free-list head) is unused
 	for i, obj := range wr.order {
 		num := i + 1
 		offsets[num] = cw.n
-		if err = wr.writeIndirectObject(cw, num, obj); err != nil {
-			return nil, fmt.Errorf("writer: object %d: %w", num, err)
+		if err := wr.writeIndirectObject(cw, num, obj); err != nil {
+			return fmt.Errorf("writer: object %d: %w", num, err)
 		}
 	}
 
 	xrefOffset := cw.n
-	if err = writeXRefHeader(cw, len(wr.order)+1); err != nil {
-		return nil, err
+	if err := writeXRefHeader(cw, len(wr.order)+1); err != nil {
+		return err
 	}
 	for i := 1; i <= len(wr.order); i++ {
-		if err = writeXRefEntry(cw, offsets[i]); err != nil {
-			return nil, err
+		if err := writeXRefEntry(cw, offsets[i], 0); err != nil {
+			return err
 		}
 	}
 
-	newTrailer := map[string]pdf.PDFValue{
-		"Size": pdf.PDFInteger(len(wr.order) + 1),
+	newTrailer := wr.trailerEntries(trailer, xrefOffset)
+	newTrailer["Size"] = pdf.PDFInteger(len(wr.order) + 1)
+	if _, err := io.WriteString(cw, "trailer\n"); err != nil {
+		return err
+	}
+	if err := wr.writeDictEntries(cw, newTrailer); err != nil {
+		return fmt.Errorf("writer: trailer: %w", err)
+	}
+	if _, err := io.WriteString(cw, "\n"); err != nil {
+		return err
+	}
+	return writeStartXRef(cw, xrefOffset)
+}
+
+// objStmCapacity caps the objects packed into one object stream, bounding
+// what a reader decodes to reach any one of them.
+const objStmCapacity = 100
+
+// xrefStreamEntry is one cross-reference stream entry: its type and its two
+// fields (ISO 32000-1 Table 18).
+type xrefStreamEntry struct {
+	typ, f2, f3 uint64
+}
+
+// writeObjectStreams writes the discovered streams uncompressed and packs
+// every other object into object streams numbered after them, then writes
+// a cross-reference stream, numbered last, which doubles as the trailer.
+func (wr *pdfWriter) writeObjectStreams(cw *countingWriter, trailer pdf.PDFDict) error {
+	entries := make([]xrefStreamEntry, len(wr.order)+1, len(wr.order)+2)
+	entries[0] = xrefStreamEntry{typ: 0, f3: 65535}
+	var pending []int
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		stm, err := wr.objectStream(pending)
+		if err != nil {
+			return err
+		}
+		num := len(entries)
+		for i, packed := range pending {
+			entries[packed] = xrefStreamEntry{typ: 2, f2: uint64(num), f3: uint64(i)}
+		}
+		entries = append(entries, xrefStreamEntry{typ: 1, f2: uint64(cw.n)})
+		pending = pending[:0]
+		if err := wr.writeIndirectObject(cw, num, stm); err != nil {
+			return fmt.Errorf("writer: object stream %d: %w", num, err)
+		}
+		return nil
 	}
+	for i, obj := range wr.order {
+		num := i + 1
+		if !obj.HasStream {
+			if pending = append(pending, num); len(pending) == objStmCapacity {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		entries[num] = xrefStreamEntry{typ: 1, f2: uint64(cw.n)}
+		if err := wr.writeIndirectObject(cw, num, obj); err != nil {
+			return fmt.Errorf("writer: object %d: %w", num, err)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	xrefOffset := cw.n
+	num := len(entries)
+	entries = append(entries, xrefStreamEntry{typ: 1, f2: uint64(xrefOffset)})
+	var maxF2, maxF3 uint64
+	for _, e := range entries {
+		maxF2, maxF3 = max(maxF2, e.f2), max(maxF3, e.f3)
+	}
+	widths := [3]int{1, pdf.XRefFieldWidth(maxF2), pdf.XRefFieldWidth(maxF3)}
+	data := make([]byte, 0, len(entries)*(widths[0]+widths[1]+widths[2]))
+	for _, e := range entries {
+		data = pdf.AppendXRefEntry(data, widths, e.typ, e.f2, e.f3)
+	}
+
+	xref := pdf.PDFDict{Entries: wr.trailerEntries(trailer, xrefOffset)}
+	xref.Entries["Type"] = pdf.PDFName{Value: "XRef"}
+	xref.Entries["Size"] = pdf.PDFInteger(len(entries))
+	xref.Entries["W"] = pdf.PDFArray{pdf.PDFInteger(widths[0]), pdf.PDFInteger(widths[1]), pdf.PDFInteger(widths[2])}
+	if err := SetStreamFlate(&xref, data); err != nil {
+		return err
+	}
+	if err := wr.writeIndirectObject(cw, num, xref); err != nil {
+		return fmt.Errorf("writer: cross-reference stream: %w", err)
+	}
+	return writeStartXRef(cw, xrefOffset)
+}
+
+// objectStream packs the non-stream objects nums into an object stream:
+// the "num offset" pairs, then each object's body, offsets counted from
+// /First.
+func (wr *pdfWriter) objectStream(nums []int) (pdf.PDFDict, error) {
+	var pairs []byte
+	var body bytes.Buffer
+	bc := &countingWriter{w: &body}
+	for i, num := range nums {
+		if i > 0 {
+			if _, err := io.WriteString(bc, "\n"); err != nil {
+				return pdf.PDFDict{}, err
+			}
+		}
+		pairs = strconv.AppendInt(pairs, int64(num), 10)
+		pairs = append(pairs, ' ')
+		pairs = strconv.AppendInt(pairs, bc.n, 10)
+		pairs = append(pairs, '\n')
+		if err := wr.writeDictEntries(bc, wr.order[num-1].Entries); err != nil {
+			return pdf.PDFDict{}, fmt.Errorf("object %d: %w", num, err)
+		}
+	}
+	stm := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+		"Type":  pdf.PDFName{Value: "ObjStm"},
+		"N":     pdf.PDFInteger(len(nums)),
+		"First": pdf.PDFInteger(len(pairs)),
+	}}
+	return stm, SetStreamFlate(&stm, append(pairs, body.Bytes()...))
+}
+
+// trailerEntries returns the trailer entries carried over from trailer --
+// Root, Info and ID -- synthesizing an ID when trailer has none. The caller
+// adds Size.
+func (wr *pdfWriter) trailerEntries(trailer pdf.PDFDict, xrefOffset int64) map[string]pdf.PDFValue {
+	out := map[string]pdf.PDFValue{}
 	if root, ok := trailer.Entries["Root"]; ok {
-		newTrailer["Root"] = root
+		out["Root"] = root
 	}
 	if info, ok := trailer.Entries["Info"]; ok {
-		newTrailer["Info"] = info
+		out["Info"] = info
 	}
 	if id, ok := trailer.Entries["ID"]; ok {
-		newTrailer["ID"] = id
+		out["ID"] = id
 	} else {
 		// 6.1.3: the trailer shall contain an ID. Synthesize one deterministically
 		// from content already fixed at this point (object count and xref offset)
@@ -94,33 +285,19 @@ func WriteDocumentIndexed(w io.Writer, trailer pdf.PDFDict) (objs []pdf.PDFDict,
 		// input is reproducible; PDF/A permits ID[0] == ID[1].
 		sum := md5.Sum(fmt.Appendf(nil, "gopdfrab:%d:%d", len(wr.order), xrefOffset))
 		id := pdf.PDFHexString{Value: hex.EncodeToString(sum[:])}
-		newTrailer["ID"] = pdf.PDFArray{id, id}
-	}
-
-	if _, err = io.WriteString(cw, "trailer\n"); err != nil {
-		return nil, err
-	}
-	if err = wr.writeDictEntries(cw, newTrailer); err != nil {
-		return nil, fmt.Errorf("writer: trailer: %w", err)
+		out["ID"] = pdf.PDFArray{id, id}
 	}
+	return out
+}
 
-	// "\nstartxref\nOFFSET\n%%EOF"
+// writeStartXRef writes "startxref\nOFFSET\n%%EOF".
+func writeStartXRef(cw *countingWriter, xrefOffset int64) error {
 	var tail [64]byte
-	t := append(tail[:0], "\nstartxref\n"...)
+	t := append(tail[:0], "startxref\n"...)
 	t = strconv.AppendInt(t, xrefOffset, 10)
 	t = append(t, "\n%%EOF"...)
-	if _, err = cw.Write(t); err != nil {
-		return nil, err
-	}
-
-	// Rewrite each dict's _ref to its assigned output object number so the
-	// in-memory graph's numbering matches the serialized output.
-	for i, obj := range wr.order {
-		obj.Entries["_ref"] = pdf.PDFRef{ObjNum: i + 1}
-		wr.order[i] = obj
-	}
-
-	return wr.order, nil
+	_, err := cw.Write(t)
+	return err
 }
 
 // writeXRefHeader writes "xref\n0 N\n0000000000 65535 f \n".
@@ -133,19 +310,18 @@ func writeXRefHeader(cw *countingWriter, count int) error {
 	return err
 }
 
-// writeXRefEntry writes a 20-byte xref entry "OOOOOOOOOO 00000 n \n".
-func writeXRefEntry(cw *countingWriter, offset int64) error {
+// writeXRefEntry writes a 20-byte xref entry "OOOOOOOOOO GGGGG n \n".
+func writeXRefEntry(cw *countingWriter, offset int64, gen int) error {
 	var b [20]byte
 	for i := 9; i >= 0; i-- {
 		b[i] = byte('0' + offset%10)
 		offset /= 10
 	}
 	b[10] = ' '
-	b[11] = '0'
-	b[12] = '0'
-	b[13] = '0'
-	b[14] = '0'
-	b[15] = '0'
+	for i := 15; i >= 11; i-- {
+		b[i] = byte('0' + gen%10)
+		gen /= 10
+	}
 	b[16] = ' '
 	b[17] = 'n'
 	b[18] = ' '
@@ -192,6 +368,12 @@ func SetStreamFlateFast(d *pdf.PDFDict, decoded []byte) error {
 	return setStreamFlateLevel(d, decoded, zlib.BestSpeed)
 }
 
+// SetStreamFlateBest is SetStreamFlate at the best compression level, for
+// output kept long enough that its size matters more than the time taken.
+func SetStreamFlateBest(d *pdf.PDFDict, decoded []byte) error {
+	return setStreamFlateLevel(d, decoded, zlib.BestCompression)
+}
+
 func setStreamFlateLevel(d *pdf.PDFDict, decoded []byte, level int) error {
 	compressed, err := deflateZlibLevel(decoded, level)
 	if err != nil {
@@ -258,13 +440,50 @@ func identityOf(v pdf.PDFDict) objectIdentity {
 	return objectIdentity{ptr: pdf.ValuePointer(v.Entries)}
 }
 
+// identityOf is the package identityOf, except in an incremental update,
+// where _ref no longer names the base file's objects (conversion renumbers
+// it) and an indirect dict is the base object it came from, if any.
+func (wr *pdfWriter) identityOf(v pdf.PDFDict) objectIdentity {
+	if wr.origins == nil {
+		return identityOf(v)
+	}
+	ptr := pdf.ValuePointer(v.Entries)
+	if ref, ok := wr.origins[ptr]; ok {
+		return objectIdentity{hasRef: true, objNum: ref.ObjNum}
+	}
+	return objectIdentity{ptr: ptr}
+}
+
+// assign returns the object number of the newly discovered object id: its
+// position in order, or in an incremental update its number in the base
+// file, if it has one, and otherwise the next number past the base file's.
+func (wr *pdfWriter) assign(id objectIdentity) int {
+	if wr.origins == nil {
+		return len(wr.order) + 1
+	}
+	if id.hasRef {
+		return id.objNum
+	}
+	wr.next++
+	return wr.next - 1
+}
+
 // pdfWriter accumulates the set of indirect objects reachable from the
 // graph being serialized, in first-encounter order, and their assigned
-// output object numbers (1-based, matching position in order).
+// output object numbers (1-based, matching position in order, unless the
+// writer appends an incremental update; see WriteIncremental).
 type pdfWriter struct {
 	numbers map[objectIdentity]int
 	order   []pdf.PDFDict
 
+	// origins, set only for an incremental update, maps an indirect dict
+	// read from the base file to the object it keeps there, and gens holds
+	// those objects' generation numbers; other indirect dicts are numbered
+	// from next.
+	origins map[uintptr]pdf.PDFRef
+	gens    map[int]int
+	next    int
+
 	// visited guards against infinite recursion on any composite value
 	// (dict or array) that participates in a cycle, indirect or not.
 	visited map[uintptr]bool
@@ -322,9 +541,9 @@ func (wr *pdfWriter) discover(v pdf.PDFValue) {
 		wr.visited[ptr] = true
 
 		if isIndirectDict(val) {
-			id := identityOf(val)
+			id := wr.identityOf(val)
 			if _, ok := wr.numbers[id]; !ok {
-				wr.numbers[id] = len(wr.order) + 1
+				wr.numbers[id] = wr.assign(id)
 				wr.order = append(wr.order, val)
 			}
 		}
@@ -350,12 +569,14 @@ func (wr *pdfWriter) discover(v pdf.PDFValue) {
 	}
 }
 
-// writeIndirectObject writes "N 0 obj\n<body>\nendobj\n" for a previously
+// writeIndirectObject writes "N G obj\n<body>\nendobj\n" for a previously
 // discovered indirect object.
 func (wr *pdfWriter) writeIndirectObject(cw *countingWriter, num int, val pdf.PDFDict) error {
-	var hdr [32]byte
+	var hdr [48]byte
 	h := strconv.AppendInt(hdr[:0], int64(num), 10)
-	h = append(h, " 0 obj\n"...)
+	h = append(h, ' ')
+	h = strconv.AppendInt(h, int64(wr.gens[num]), 10)
+	h = append(h, " obj\n"...)
 	if _, err := cw.Write(h); err != nil {
 		return err
 	}
@@ -421,7 +642,7 @@ func (wr *pdfWriter) writeDictEntries(cw *countingWriter, entries map[string]pdf
 }
 
 // writeValue serializes a single PDF value. An indirect dict (see
-// isIndirectDict) is written as an "N 0 R" reference to its own
+// isIndirectDict) is written as an "N G R" reference to its own
 // already-discovered object instead of being inlined.
 //
 // pdf.PDFString holds decoded bytes (the lexer resolves backslash escapes),
@@ -443,13 +664,15 @@ func (wr *pdfWriter) writeValue(cw *countingWriter, v pdf.PDFValue) error {
 
 	case pdf.PDFDict:
 		if isIndirectDict(val) {
-			num, ok := wr.numbers[identityOf(val)]
+			num, ok := wr.numbers[wr.identityOf(val)]
 			if !ok {
 				return fmt.Errorf("internal error: indirect dict was not discovered before writing")
 			}
-			var b [24]byte
+			var b [48]byte
 			ref := strconv.AppendInt(b[:0], int64(num), 10)
-			ref = append(ref, " 0 R"...)
+			ref = append(ref, ' ')
+			ref = strconv.AppendInt(ref, int64(wr.gens[num]), 10)
+			ref = append(ref, " R"...)
 			_, err := cw.Write(ref)
 			return err
 		}
@@ -501,6 +501,123 @@ func TestNumberObjects(t *testing.T) {
 	}
 }
 
+// TestWriteDocumentOptionsVersion checks that Options.Version sets the header
+// version, that the zero value keeps WriteDocument's 1.4 header, and that the
+// output still parses.
+func TestWriteDocumentOptionsVersion(t *testing.T) {
+	for _, tc := range []struct{ version, header string }{
+		{"", "%PDF-1.4\n"},
+		{"2.0", "%PDF-2.0\n"},
+	} {
+		catalog := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"Type": pdf.PDFName{Value: "Catalog"},
+			"Pages": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"Type": pdf.PDFName{Value: "Pages"}, "Kids": pdf.PDFArray{}, "Count": pdf.PDFInteger(0),
+			}},
+		}}
+		trailer := pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": catalog}}
+		var buf bytes.Buffer
+		if _, err := WriteDocumentOptions(&buf, trailer, Options{Version: tc.version}); err != nil {
+			t.Fatalf("WriteDocumentOptions(%q): %v", tc.version, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte(tc.header)) {
+			t.Errorf("Version %q: header = %q, want prefix %q", tc.version, buf.Bytes()[:10], tc.header)
+		}
+		doc, err := pdf.OpenBytes(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Version %q: OpenBytes: %v", tc.version, err)
+		}
+		doc.Close()
+	}
+}
+
+// TestWriteDocumentObjectStreams checks that Options.ObjectStreams packs
+// every non-stream object into object streams of at most objStmCapacity
+// objects behind a cross-reference stream, and that the output reads back
+// to the same graph in less space than the classic layout.
+func TestWriteDocumentObjectStreams(t *testing.T) {
+	build := func() pdf.PDFDict {
+		var annots pdf.PDFArray
+		for i := range 250 {
+			annots = append(annots, pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+				"_ref":    pdf.PDFRef{ObjNum: 100 + i},
+				"Type":    pdf.PDFName{Value: "Annot"},
+				"Subtype": pdf.PDFName{Value: "Text"},
+				"Rect":    pdf.PDFArray{pdf.PDFInteger(i), pdf.PDFInteger(0), pdf.PDFInteger(i + 10), pdf.PDFInteger(10)},
+			}})
+		}
+		page := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref":   pdf.PDFRef{ObjNum: 3},
+			"Type":   pdf.PDFName{Value: "Page"},
+			"Annots": annots,
+			"Contents": pdf.PDFDict{
+				Entries:   map[string]pdf.PDFValue{"_ref": pdf.PDFRef{ObjNum: 4}},
+				HasStream: true,
+				RawStream: []byte("q\nQ\n"),
+			},
+		}}
+		pages := pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 2}, "Type": pdf.PDFName{Value: "Pages"},
+			"Kids": pdf.PDFArray{page}, "Count": pdf.PDFInteger(1),
+		}}
+		page.Entries["Parent"] = pages
+		return pdf.PDFDict{Entries: map[string]pdf.PDFValue{"Root": pdf.PDFDict{Entries: map[string]pdf.PDFValue{
+			"_ref": pdf.PDFRef{ObjNum: 1}, "Type": pdf.PDFName{Value: "Catalog"}, "Pages": pages,
+		}}}}
+	}
+
+	var classic, packed bytes.Buffer
+	if _, err := WriteDocumentOptions(&classic, build(), Options{}); err != nil {
+		t.Fatalf("WriteDocumentOptions(classic): %v", err)
+	}
+	objs, err := WriteDocumentOptions(&packed, build(), Options{ObjectStreams: true})
+	if err != nil {
+		t.Fatalf("WriteDocumentOptions(ObjectStreams): %v", err)
+	}
+	out := packed.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.5\n")) {
+		t.Errorf("header = %q, want %%PDF-1.5", out[:9])
+	}
+	if bytes.Contains(out, []byte("\nxref\n")) || bytes.Contains(out, []byte("trailer")) {
+		t.Error("output has a classic xref table or trailer")
+	}
+	if got := bytes.Count(out, []byte("/Type /ObjStm")); got != 3 {
+		t.Errorf("%d object streams, want 3 for 253 objects", got)
+	}
+	if len(out) >= classic.Len() {
+		t.Errorf("packed output is %d bytes, classic %d", len(out), classic.Len())
+	}
+
+	doc, err := pdf.OpenBytes(out)
+	if err != nil {
+		t.Fatalf("OpenBytes: %v", err)
+	}
+	defer doc.Close()
+	if n, err := doc.GetPageCount(); err != nil || n != 1 {
+		t.Fatalf("GetPageCount() = %d, %v; want 1, nil", n, err)
+	}
+	graph, err := doc.ResolveGraph()
+	if err != nil {
+		t.Fatalf("ResolveGraph: %v", err)
+	}
+	page := assertOnePageGraph(t, graph)
+	assertContentStream(t, doc, page, "q\nQ\n")
+	annots, _ := page.Entries["Annots"].(pdf.PDFArray)
+	if len(annots) != 250 {
+		t.Fatalf("Annots has %d entries, want 250", len(annots))
+	}
+	last, _ := annots[249].(pdf.PDFDict)
+	if !pdf.EqualPDFValue(last.Entries["Rect"], pdf.PDFArray{pdf.PDFInteger(249), pdf.PDFInteger(0), pdf.PDFInteger(259), pdf.PDFInteger(10)}) {
+		t.Errorf("Annots[249]/Rect = %v", last.Entries["Rect"])
+	}
+	if ref, _ := last.Entries["_ref"].(pdf.PDFRef); objs[ref.ObjNum-1].Entries["Subtype"] != (pdf.PDFName{Value: "Text"}) {
+		t.Errorf("Annots[249] read back as object %d, which was not written as it", ref.ObjNum)
+	}
+	if _, err := doc.XRefStreamDictAt(doc.XRefOffset()); err != nil {
+		t.Errorf("startxref does not point at a cross-reference stream: %v", err)
+	}
+}
+
 // TestSetStreamFlateVariants covers SetStreamFlateFast and SetStreamFlateRows,
 // asserting each stores a FlateDecode stream that decodes back to its input.
 func TestSetStreamFlateVariants(t *testing.T) {
@@ -31,19 +31,70 @@ func main() {
 
 func usage() {
 	fmt.Fprintln(os.Stderr, `usage:
-  go run main.go convert [-pdf] <input.pdf> [output.pdf]   convert towards PDF/A-1b conformance
+  go run main.go convert [-pdf|-1a|-2b|-2u|-3b|-3u|-4|-4e|-4f|-facturx <invoice.xml>] <input.pdf> [output.pdf]
+                                                           convert towards PDF/A-1b conformance
                                                            (-pdf: repair generic ISO 32000
-                                                            object-model conformance instead)
-  go run main.go verify <path-or-dir>...                   verify PDF/A-1b conformance`)
+                                                            object-model conformance instead;
+                                                            -1a/-2b/-2u/-3b/-3u/-4/-4e/-4f: that
+                                                            PDF/A level instead; -facturx: embed
+                                                            invoice.xml and produce a Factur-X /
+                                                            ZUGFeRD invoice)
+  go run main.go verify [-1a|-2b|-2u|-3b|-3u|-4|-4e|-4f|-ua1|-x1a|-x4|-facturx] <path-or-dir>...
+                                                           verify PDF/A-1b conformance
+                                                           (-1a/-2b/-2u/-3b/-3u/-4/-4e/-4f: that
+                                                            PDF/A level instead; -ua1: PDF/UA-1
+                                                            accessibility; -x1a/-x4: PDF/X-1a or
+                                                            PDF/X-4 preflight; -facturx: Factur-X
+                                                            / ZUGFeRD invoice)`)
 }
 
 // runConvert converts a single PDF and reports the outcome: how many
 // verify/fixup passes it took and whether the result is fully conformant.
 func runConvert(args []string) {
 	profile, label, suffix := pdf.PDFA_1B, "PDF/A-1b", ".pdfa.pdf"
-	if len(args) > 0 && args[0] == "-pdf" {
-		profile, label, suffix = gopdfrab.PDF, "PDF (object model)", ".fixed.pdf"
-		args = args[1:]
+	var invoice []byte
+	if len(args) > 0 {
+		switch args[0] {
+		case "-pdf":
+			profile, label, suffix = gopdfrab.PDF, "PDF (object model)", ".fixed.pdf"
+			args = args[1:]
+		case "-1a":
+			profile, label = gopdfrab.PDFA_1A, "PDF/A-1a"
+			args = args[1:]
+		case "-2b":
+			profile, label = gopdfrab.PDFA_2B, "PDF/A-2b"
+			args = args[1:]
+		case "-2u":
+			profile, label = gopdfrab.PDFA_2U, "PDF/A-2u"
+			args = args[1:]
+		case "-3b":
+			profile, label = gopdfrab.PDFA_3B, "PDF/A-3b"
+			args = args[1:]
+		case "-3u":
+			profile, label = gopdfrab.PDFA_3U, "PDF/A-3u"
+			args = args[1:]
+		case "-4":
+			profile, label = gopdfrab.PDFA_4, "PDF/A-4"
+			args = args[1:]
+		case "-4e":
+			profile, label = gopdfrab.PDFA_4E, "PDF/A-4e"
+			args = args[1:]
+		case "-4f":
+			profile, label = gopdfrab.PDFA_4F, "PDF/A-4f"
+			args = args[1:]
+		case "-facturx":
+			if len(args) < 2 {
+				usage()
+				os.Exit(1)
+			}
+			data, err := os.ReadFile(args[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "read %s: %v\n", args[1], err)
+				os.Exit(1)
+			}
+			profile, label, invoice = gopdfrab.FacturX, "Factur-X", data
+			args = args[2:]
+		}
 	}
 	if len(args) < 1 {
 		usage()
@@ -56,7 +107,15 @@ func runConvert(args []string) {
 	}
 
 	start := time.Now()
-	cr, err := gopdfrab.Convert(input, profile)
+	var (
+		cr  gopdfrab.ConvertResult
+		err error
+	)
+	if invoice != nil {
+		cr, err = gopdfrab.ConvertInvoice(input, invoice, profile)
+	} else {
+		cr, err = gopdfrab.Convert(input, profile)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "convert %s: %v\n", input, err)
 		os.Exit(1)
@@ -71,6 +130,13 @@ func runConvert(args []string) {
 
 	fmt.Printf("%s -> %s\n", input, output)
 	fmt.Printf("iterations: %d\n", cr.Iterations)
+	if cr.Decrypted {
+		if restricted := cr.Permissions.Restricted(); len(restricted) > 0 {
+			fmt.Printf("decrypted: source restricted %s\n", strings.Join(restricted, ", "))
+		} else {
+			fmt.Println("decrypted: source granted all permissions")
+		}
+	}
 
 	if cr.Result.Valid {
 		fmt.Printf("result: fully %s conformant\n", label)
@@ -93,6 +159,47 @@ func runConvert(args []string) {
 // runVerify verifies every PDF found at or under each given path (a single
 // file or a directory walked recursively) and prints a pass/fail summary.
 func runVerify(args []string) {
+	profile := gopdfrab.PDFA_1B
+	if len(args) > 0 {
+		switch args[0] {
+		case "-1a":
+			profile = gopdfrab.PDFA_1A
+			args = args[1:]
+		case "-2b":
+			profile = gopdfrab.PDFA_2B
+			args = args[1:]
+		case "-2u":
+			profile = gopdfrab.PDFA_2U
+			args = args[1:]
+		case "-3b":
+			profile = gopdfrab.PDFA_3B
+			args = args[1:]
+		case "-3u":
+			profile = gopdfrab.PDFA_3U
+			args = args[1:]
+		case "-4":
+			profile = gopdfrab.PDFA_4
+			args = args[1:]
+		case "-4e":
+			profile = gopdfrab.PDFA_4E
+			args = args[1:]
+		case "-4f":
+			profile = gopdfrab.PDFA_4F
+			args = args[1:]
+		case "-ua1":
+			profile = gopdfrab.PDFUA_1
+			args = args[1:]
+		case "-x1a":
+			profile = gopdfrab.PDFX_1A
+			args = args[1:]
+		case "-x4":
+			profile = gopdfrab.PDFX_4
+			args = args[1:]
+		case "-facturx":
+			profile = gopdfrab.FacturX
+			args = args[1:]
+		}
+	}
 	if len(args) < 1 {
 		usage()
 		os.Exit(1)
@@ -124,7 +231,7 @@ func runVerify(args []string) {
 		}
 	}
 
-	results, err := gopdfrab.VerifyAll(paths, gopdfrab.PDFA_1B)
+	results, err := gopdfrab.VerifyAll(paths, profile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
 		os.Exit(1)